@@ -0,0 +1,125 @@
+// Package audit records the operations arbor performs on a project, such as
+// creating and removing worktrees, in a log kept in the bare repository.
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"time"
+)
+
+// Operations recorded in the audit log
+const (
+	OperationInit   = "init"
+	OperationWork   = "work"
+	OperationRemove = "remove"
+	OperationPrune  = "prune"
+	OperationDbDrop = "db.drop"
+)
+
+// Outcomes of a recorded operation
+const (
+	OutcomeSucceeded = "succeeded"
+	OutcomeFailed    = "failed"
+)
+
+// Entry is one operation in the audit log
+type Entry struct {
+	Time      time.Time `json:"time"`
+	User      string    `json:"user"`
+	Operation string    `json:"operation"`
+	Branch    string    `json:"branch,omitempty"`
+	Path      string    `json:"path,omitempty"`
+	// Target names what was acted on when it is not a worktree, such as a
+	// dropped database
+	Target string `json:"target,omitempty"`
+	// DbSuffix is the database suffix of a removed worktree, which arbor db
+	// gc uses to find the databases it left behind
+	DbSuffix string `json:"db_suffix,omitempty"`
+	Outcome  string `json:"outcome"`
+	Error    string `json:"error,omitempty"`
+}
+
+// LogPath returns where the audit log for the project at barePath is kept
+func LogPath(barePath string) string {
+	return filepath.Join(barePath, "arbor", "audit.log")
+}
+
+// Record appends an entry to the audit log, filling in the time and user.
+// The outcome is taken from err.
+func Record(barePath string, entry Entry, err error) error {
+	if entry.Time.IsZero() {
+		entry.Time = time.Now().UTC()
+	}
+	if entry.User == "" {
+		entry.User = currentUser()
+	}
+	entry.Outcome = OutcomeSucceeded
+	if err != nil {
+		entry.Outcome = OutcomeFailed
+		entry.Error = err.Error()
+	}
+
+	data, marshalErr := json.Marshal(entry)
+	if marshalErr != nil {
+		return marshalErr
+	}
+
+	path := LogPath(barePath)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("creating %s: %w", filepath.Dir(path), err)
+	}
+	f, openErr := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if openErr != nil {
+		return fmt.Errorf("opening audit log: %w", openErr)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("writing audit log: %w", err)
+	}
+	return nil
+}
+
+// Read returns the entries in the audit log, oldest first. A project
+// without a log has no entries; lines that cannot be parsed are skipped.
+func Read(barePath string) ([]Entry, error) {
+	f, err := os.Open(LogPath(barePath))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("opening audit log: %w", err)
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry Entry
+		if json.Unmarshal(scanner.Bytes(), &entry) == nil {
+			entries = append(entries, entry)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading audit log: %w", err)
+	}
+	return entries, nil
+}
+
+func currentUser() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	for _, key := range []string{"USER", "USERNAME"} {
+		if name := os.Getenv(key); name != "" {
+			return name
+		}
+	}
+	return "unknown"
+}
@@ -0,0 +1,39 @@
+package audit
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordAndRead(t *testing.T) {
+	barePath := t.TempDir()
+
+	entries, err := Read(barePath)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	require.NoError(t, Record(barePath, Entry{Operation: OperationWork, Branch: "feature/login", Path: "/p/feature-login"}, nil))
+	require.NoError(t, Record(barePath, Entry{Operation: OperationRemove, Branch: "feature/login"}, errors.New("git worktree remove failed")))
+
+	f, err := os.OpenFile(LogPath(barePath), os.O_APPEND|os.O_WRONLY, 0644)
+	require.NoError(t, err)
+	_, err = f.WriteString("not json\n")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	entries, err = Read(barePath)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	assert.Equal(t, OperationWork, entries[0].Operation)
+	assert.Equal(t, OutcomeSucceeded, entries[0].Outcome)
+	assert.NotEmpty(t, entries[0].User)
+	assert.False(t, entries[0].Time.IsZero())
+
+	assert.Equal(t, OutcomeFailed, entries[1].Outcome)
+	assert.Equal(t, "git worktree remove failed", entries[1].Error)
+}
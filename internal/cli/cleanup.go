@@ -0,0 +1,238 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/michaeldyrynda/arbor/internal/config"
+	arborerrors "github.com/michaeldyrynda/arbor/internal/errors"
+	"github.com/michaeldyrynda/arbor/internal/git"
+	"github.com/michaeldyrynda/arbor/internal/ui"
+)
+
+var cleanupCmd = &cobra.Command{
+	Use:   "cleanup --orphans",
+	Short: "Run cleanup steps for worktrees removed outside arbor",
+	Long: `Runs the cleanup steps, such as dropping databases and unlinking Herd
+sites, for worktrees whose directory was removed without arbor, e.g. with
+git worktree remove.
+
+arbor records each worktree it creates in the bare repository. --orphans
+finds the recorded worktrees whose directory is gone, and whose branch git
+does not have checked out in another worktree, and runs their cleanup
+from a temporary stand-in directory at the same path, holding the recorded
+database suffix and the default branch worktree's .env. Git's records of
+the missing worktrees are pruned afterwards.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !mustGetBool(cmd, "orphans") {
+			return arborerrors.Wrap(arborerrors.ErrInvalidArguments, fmt.Errorf("nothing to clean up, use --orphans"))
+		}
+
+		pc, err := OpenProjectFromCWD(cmd)
+		if err != nil {
+			return err
+		}
+
+		force := mustGetBool(cmd, "force")
+		dryRun := mustGetBool(cmd, "dry-run")
+		verbose := mustGetBool(cmd, "verbose")
+
+		records, err := config.ReadWorktreeRecords(pc.BarePath)
+		if err != nil {
+			return err
+		}
+
+		worktrees, err := git.ListWorktrees(pc.BarePath)
+		if err != nil {
+			return fmt.Errorf("listing worktrees: %w", err)
+		}
+
+		orphans, moved := orphanedWorktrees(records, worktrees)
+		for _, record := range moved {
+			ui.PrintWarning(fmt.Sprintf("Skipping %s: %s is gone, but the branch is still checked out in another worktree", record.Branch, record.Path))
+		}
+		if len(orphans) == 0 {
+			ui.PrintDone("No orphaned worktrees found.")
+			return nil
+		}
+
+		ui.PrintInfo(fmt.Sprintf("%d orphaned worktree(s) found:", len(orphans)))
+		for _, record := range orphans {
+			ui.PrintStep(fmt.Sprintf("%s at %s", record.Branch, record.Path))
+		}
+
+		if !force && !dryRun {
+			if !ui.ShouldPrompt(cmd, false) {
+				ui.PrintInfo("Run with --force to clean them up.")
+				return nil
+			}
+
+			confirmed, err := ui.Confirm(fmt.Sprintf("Run cleanup for %d worktree(s)?", len(orphans)))
+			if err != nil {
+				return fmt.Errorf("confirmation: %w", err)
+			}
+			if !confirmed {
+				ui.PrintInfo("No worktrees cleaned up.")
+				return nil
+			}
+		}
+
+		envSource := defaultWorktreePath(pc)
+		for _, record := range orphans {
+			ui.PrintStep(fmt.Sprintf("Cleaning up %s...", record.Branch))
+			if err := cleanupOrphan(pc, record, envSource, dryRun, verbose); err != nil {
+				ui.PrintErrorWithHint(fmt.Sprintf("Cleanup failed for %s", record.Branch), err.Error())
+				continue
+			}
+			if !dryRun {
+				forgetWorktree(pc, record.Path)
+			}
+		}
+
+		if dryRun {
+			ui.PrintDone(fmt.Sprintf("Would clean up %d worktree(s).", len(orphans)))
+			return nil
+		}
+
+		if err := git.PruneWorktrees(pc.BarePath); err != nil {
+			ui.PrintWarning(fmt.Sprintf("Could not prune git worktree records: %v", err))
+		}
+		ui.PrintDone(fmt.Sprintf("Cleaned up %d worktree(s).", len(orphans)))
+		return nil
+	},
+}
+
+// orphanedWorktrees returns the records whose worktree directory is gone,
+// cross-checked against the worktrees git lists. A record whose branch git
+// has checked out in a worktree that still exists, e.g. one moved with git
+// worktree move, is returned in moved instead, as its databases are in use.
+func orphanedWorktrees(records []config.WorktreeRecord, worktrees []git.Worktree) (orphans, moved []config.WorktreeRecord) {
+	checkedOut := make(map[string]bool)
+	for _, wt := range worktrees {
+		if _, err := os.Stat(wt.Path); err == nil {
+			checkedOut[wt.Branch] = true
+		}
+	}
+
+	for _, record := range records {
+		if _, err := os.Stat(record.Path); !os.IsNotExist(err) {
+			continue
+		}
+		if checkedOut[record.Branch] {
+			moved = append(moved, record)
+			continue
+		}
+		orphans = append(orphans, record)
+	}
+	return orphans, moved
+}
+
+// cleanupOrphan runs the cleanup steps for a removed worktree from a stand-in
+// directory at its old path, so steps that run in the worktree or read its
+// config and .env find what they need. The stand-in is removed afterwards.
+func cleanupOrphan(pc *ProjectContext, record config.WorktreeRecord, envSource string, dryRun, verbose bool) (err error) {
+	created, err := mkdirAllCreated(record.Path)
+	if err != nil {
+		return fmt.Errorf("creating stand-in directory: %w", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(created); removeErr != nil && err == nil {
+			err = fmt.Errorf("removing stand-in directory: %w", removeErr)
+		}
+	}()
+
+	if err := config.WriteWorktreeConfig(record.Path, map[string]string{
+		"db_suffix":   record.DbSuffix,
+		"base_branch": record.BaseBranch,
+	}); err != nil {
+		return err
+	}
+	if envSource != "" {
+		if data, err := os.ReadFile(filepath.Join(envSource, ".env")); err == nil {
+			if err := os.WriteFile(filepath.Join(record.Path, ".env"), data, 0600); err != nil {
+				return fmt.Errorf("writing stand-in .env: %w", err)
+			}
+		}
+	}
+
+	siteName := record.SiteName
+	if siteName == "" {
+		siteName = filepath.Base(record.Path)
+	}
+	preset := record.Preset
+	if preset == "" {
+		preset = pc.Config.Preset
+	}
+	return pc.ScaffoldManager().RunCleanup(record.Path, record.Branch, "", siteName, preset, pc.Config, dryRun, verbose)
+}
+
+// mkdirAllCreated creates path and any missing parents, returning the
+// outermost directory it created
+func mkdirAllCreated(path string) (string, error) {
+	created := path
+	for dir := filepath.Dir(path); dir != created; dir = filepath.Dir(dir) {
+		if _, err := os.Stat(dir); err == nil {
+			break
+		}
+		created = dir
+	}
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return "", err
+	}
+	return created, nil
+}
+
+// defaultWorktreePath returns the path of the default branch worktree, or ""
+// when it has none
+func defaultWorktreePath(pc *ProjectContext) string {
+	worktrees, err := git.ListWorktrees(pc.BarePath)
+	if err != nil {
+		return ""
+	}
+	for _, wt := range worktrees {
+		if wt.Branch == pc.DefaultBranch {
+			return wt.Path
+		}
+	}
+	return ""
+}
+
+// recordWorktree remembers a worktree arbor created or scaffolded, so its
+// cleanup can run with arbor cleanup --orphans if it is removed without arbor
+func recordWorktree(pc *ProjectContext, branch, path, preset string) {
+	record := config.WorktreeRecord{
+		Path:     path,
+		Branch:   branch,
+		SiteName: filepath.Base(path),
+		Preset:   preset,
+	}
+	if wtConfig, err := config.ReadWorktreeConfig(path); err == nil {
+		record.DbSuffix = wtConfig.DbSuffix
+		record.BaseBranch = wtConfig.BaseBranch
+	}
+	if err := config.RecordWorktree(pc.BarePath, record); err != nil {
+		ui.PrintWarning(fmt.Sprintf("Could not record worktree: %v", err))
+	}
+}
+
+// forgetWorktree drops the record of a worktree arbor removed and releases
+// the ports reserved for it
+func forgetWorktree(pc *ProjectContext, path string) {
+	if err := config.ForgetWorktree(pc.BarePath, path); err != nil {
+		ui.PrintWarning(fmt.Sprintf("Could not forget worktree record: %v", err))
+	}
+	if err := config.ReleasePorts(pc.BarePath, path); err != nil {
+		ui.PrintWarning(fmt.Sprintf("Could not release worktree ports: %v", err))
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(cleanupCmd)
+
+	cleanupCmd.Flags().Bool("orphans", false, "Clean up recorded worktrees whose directory is gone")
+	cleanupCmd.Flags().BoolP("force", "f", false, "Skip confirmation")
+}
@@ -0,0 +1,80 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/michaeldyrynda/arbor/internal/config"
+	"github.com/michaeldyrynda/arbor/internal/git"
+)
+
+func TestOrphanedWorktrees(t *testing.T) {
+	tmpDir := t.TempDir()
+	present := filepath.Join(tmpDir, "present")
+	require.NoError(t, os.Mkdir(present, 0755))
+
+	movedTo := filepath.Join(tmpDir, "moved-to")
+	require.NoError(t, os.Mkdir(movedTo, 0755))
+
+	records := []config.WorktreeRecord{
+		{Path: filepath.Join(tmpDir, "gone"), Branch: "feature/gone"},
+		{Path: present, Branch: "feature/present"},
+		{Path: filepath.Join(tmpDir, "moved"), Branch: "feature/moved"},
+	}
+	worktrees := []git.Worktree{
+		{Path: filepath.Join(tmpDir, "gone"), Branch: "feature/gone"},
+		{Path: present, Branch: "feature/present"},
+		{Path: movedTo, Branch: "feature/moved"},
+	}
+
+	orphans, moved := orphanedWorktrees(records, worktrees)
+	assert.Equal(t, records[:1], orphans)
+	assert.Equal(t, records[2:], moved)
+}
+
+func TestMkdirAllCreated(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	created, err := mkdirAllCreated(filepath.Join(tmpDir, "a", "b", "c"))
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(tmpDir, "a"), created)
+	assert.DirExists(t, filepath.Join(tmpDir, "a", "b", "c"))
+
+	created, err = mkdirAllCreated(filepath.Join(tmpDir, "a", "d"))
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(tmpDir, "a", "d"), created)
+}
+
+func TestCleanupOrphan(t *testing.T) {
+	projectDir := t.TempDir()
+	mainPath := filepath.Join(projectDir, "main")
+	require.NoError(t, os.Mkdir(mainPath, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(mainPath, ".env"), []byte("DB_CONNECTION=mysql\n"), 0644))
+
+	output := filepath.Join(projectDir, "cleanup.out")
+	pc := &ProjectContext{
+		BarePath:    filepath.Join(projectDir, ".bare"),
+		ProjectPath: projectDir,
+		Config: &config.Config{
+			Cleanup: []config.StepConfig{{Name: "command.run", Command: "echo \"{{ .SiteName }} {{ .DbSuffix }} $(cat .env)\" > " + output}},
+		},
+		DefaultBranch: "main",
+	}
+	record := config.WorktreeRecord{
+		Path:     filepath.Join(projectDir, "feature-login"),
+		Branch:   "feature/login",
+		SiteName: "feature-login",
+		DbSuffix: "swift_runner",
+	}
+
+	require.NoError(t, cleanupOrphan(pc, record, mainPath, false, false))
+
+	data, err := os.ReadFile(output)
+	require.NoError(t, err)
+	assert.Equal(t, "feature-login swift_runner DB_CONNECTION=mysql\n", string(data))
+	assert.NoDirExists(t, record.Path, "the stand-in directory should be removed")
+}
@@ -0,0 +1,180 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/michaeldyrynda/arbor/internal/config"
+	"github.com/michaeldyrynda/arbor/internal/git"
+	"github.com/michaeldyrynda/arbor/internal/ui"
+)
+
+var codeWorkspaceCmd = &cobra.Command{
+	Use:   "code-workspace",
+	Short: "Write a VS Code workspace listing every worktree",
+	Long: `Writes a .code-workspace file in the project directory with a folder for
+each worktree, so they can be opened together in VS Code.
+
+The file defaults to <site_name>.code-workspace and can be set with
+code_workspace.file in arbor.yaml. Settings under code_workspace.settings,
+such as php.validate.executablePath, are written to the workspace.
+
+Running it again updates the folders and settings while keeping any other
+folders, settings and extensions in the file. With code_workspace.auto_update,
+arbor work, remove and prune keep the folders up to date.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pc, err := OpenProjectFromCWD(cmd)
+		if err != nil {
+			return err
+		}
+
+		path, err := writeCodeWorkspace(pc)
+		if err != nil {
+			return err
+		}
+
+		ui.PrintSuccessPath("Wrote", path)
+		return nil
+	},
+}
+
+// codeWorkspacePath returns where the project's workspace file lives
+func codeWorkspacePath(pc *ProjectContext) string {
+	file := pc.Config.CodeWorkspace.File
+	if file == "" {
+		name := pc.Config.SiteName
+		if name == "" {
+			name = filepath.Base(pc.ProjectPath)
+		}
+		file = name + ".code-workspace"
+	}
+	if filepath.IsAbs(file) {
+		return file
+	}
+	return filepath.Join(pc.ProjectPath, file)
+}
+
+// writeCodeWorkspace creates or updates the project's workspace file
+func writeCodeWorkspace(pc *ProjectContext) (string, error) {
+	path := codeWorkspacePath(pc)
+
+	worktrees, err := git.ListWorktreesDetailed(pc.BarePath, pc.CWD, pc.DefaultBranch)
+	if err != nil {
+		return "", fmt.Errorf("listing worktrees: %w", err)
+	}
+
+	settings, err := config.CodeWorkspaceSettings(pc.ProjectPath)
+	if err != nil {
+		return "", fmt.Errorf("reading code_workspace settings: %w", err)
+	}
+
+	existing, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	data, err := buildCodeWorkspace(existing, filepath.Dir(path), pc.ProjectPath, worktrees, settings)
+	if err != nil {
+		return "", fmt.Errorf("updating %s: %w", path, err)
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", fmt.Errorf("writing %s: %w", path, err)
+	}
+	return path, nil
+}
+
+// syncCodeWorkspace updates the workspace file after worktrees are created
+// or removed, when code_workspace.auto_update is set
+func syncCodeWorkspace(pc *ProjectContext) {
+	if !pc.Config.CodeWorkspace.AutoUpdate {
+		return
+	}
+	if _, err := writeCodeWorkspace(pc); err != nil {
+		ui.PrintWarning(fmt.Sprintf("Could not update VS Code workspace: %v", err))
+	}
+}
+
+// buildCodeWorkspace returns the workspace JSON with a folder per worktree,
+// relative to dir. Folders inside the project that are not worktrees are
+// dropped, since they belong to removed worktrees; folders elsewhere and
+// every other key in the existing file are kept.
+func buildCodeWorkspace(existing []byte, dir, projectPath string, worktrees []git.Worktree, settings map[string]interface{}) ([]byte, error) {
+	doc := map[string]interface{}{}
+	if len(strings.TrimSpace(string(existing))) > 0 {
+		if err := json.Unmarshal(existing, &doc); err != nil {
+			return nil, fmt.Errorf("parsing workspace (comments and trailing commas are not supported): %w", err)
+		}
+	}
+
+	sorted := make([]git.Worktree, len(worktrees))
+	copy(sorted, worktrees)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].IsMain != sorted[j].IsMain {
+			return sorted[i].IsMain
+		}
+		return sorted[i].Path < sorted[j].Path
+	})
+
+	var folders []interface{}
+	for _, wt := range sorted {
+		folders = append(folders, map[string]interface{}{
+			"name": wt.Branch,
+			"path": workspaceRelPath(dir, wt.Path),
+		})
+	}
+
+	if existingFolders, ok := doc["folders"].([]interface{}); ok {
+		for _, entry := range existingFolders {
+			folder, ok := entry.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			folderPath, _ := folder["path"].(string)
+			if !filepath.IsAbs(folderPath) {
+				folderPath = filepath.Join(dir, folderPath)
+			}
+			if rel, err := filepath.Rel(projectPath, folderPath); err == nil && !strings.HasPrefix(rel, "..") {
+				continue
+			}
+			folders = append(folders, folder)
+		}
+	}
+	doc["folders"] = folders
+
+	if len(settings) > 0 {
+		merged, _ := doc["settings"].(map[string]interface{})
+		if merged == nil {
+			merged = map[string]interface{}{}
+		}
+		for key, value := range settings {
+			merged[key] = value
+		}
+		doc["settings"] = merged
+	}
+
+	data, err := json.MarshalIndent(doc, "", "\t")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+func workspaceRelPath(dir, path string) string {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return path
+	}
+	return filepath.ToSlash(rel)
+}
+
+func init() {
+	rootCmd.AddCommand(codeWorkspaceCmd)
+}
@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/michaeldyrynda/arbor/internal/git"
+)
+
+func TestBuildCodeWorkspace(t *testing.T) {
+	worktrees := []git.Worktree{
+		{Path: "/code/app/feature-login", Branch: "feature/login"},
+		{Path: "/code/app/main", Branch: "main", IsMain: true},
+	}
+
+	t.Run("new workspace lists main first", func(t *testing.T) {
+		data, err := buildCodeWorkspace(nil, "/code/app", "/code/app", worktrees, map[string]interface{}{
+			"php.validate.executablePath": "/opt/php/8.3/bin/php",
+		})
+		require.NoError(t, err)
+
+		var doc map[string]interface{}
+		require.NoError(t, json.Unmarshal(data, &doc))
+		assert.Equal(t, []interface{}{
+			map[string]interface{}{"name": "main", "path": "main"},
+			map[string]interface{}{"name": "feature/login", "path": "feature-login"},
+		}, doc["folders"])
+		assert.Equal(t, map[string]interface{}{"php.validate.executablePath": "/opt/php/8.3/bin/php"}, doc["settings"])
+	})
+
+	t.Run("updates an existing workspace", func(t *testing.T) {
+		existing := []byte(`{
+			"folders": [
+				{"path": "main"},
+				{"path": "removed-branch"},
+				{"name": "docs", "path": "../docs"}
+			],
+			"settings": {"editor.tabSize": 4},
+			"extensions": {"recommendations": ["bmewburn.vscode-intelephense-client"]}
+		}`)
+
+		data, err := buildCodeWorkspace(existing, "/code/app", "/code/app", worktrees[1:], nil)
+		require.NoError(t, err)
+
+		var doc map[string]interface{}
+		require.NoError(t, json.Unmarshal(data, &doc))
+		assert.Equal(t, []interface{}{
+			map[string]interface{}{"name": "main", "path": "main"},
+			map[string]interface{}{"name": "docs", "path": "../docs"},
+		}, doc["folders"])
+		assert.Equal(t, map[string]interface{}{"editor.tabSize": float64(4)}, doc["settings"])
+		assert.Contains(t, doc, "extensions")
+	})
+
+	t.Run("rejects files it cannot parse", func(t *testing.T) {
+		_, err := buildCodeWorkspace([]byte("{\n  // comment\n}"), "/code/app", "/code/app", worktrees, nil)
+		assert.ErrorContains(t, err, "comments and trailing commas are not supported")
+	})
+}
@@ -0,0 +1,172 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/michaeldyrynda/arbor/internal/config"
+	"github.com/michaeldyrynda/arbor/internal/git"
+	"github.com/michaeldyrynda/arbor/internal/scaffold/steps"
+	"github.com/michaeldyrynda/arbor/internal/scaffold/types"
+	"github.com/michaeldyrynda/arbor/internal/ui"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect and validate project configuration",
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate [FILE]",
+	Short: "Validate arbor.yaml against the schema",
+	Long: `Validates a project arbor.yaml, reporting unknown keys, unknown step
+names, malformed conditions and values of the wrong type with their line numbers.
+
+Arguments:
+  FILE  Optional path to the config file (defaults to the project arbor.yaml)`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		configPath, err := resolveConfigPath(args)
+		if err != nil {
+			return err
+		}
+
+		err = config.ValidateProjectFile(configPath, config.ValidateOptions{
+			StepNames:      steps.Names(),
+			ConditionNames: types.ConditionNames(),
+		})
+
+		var validationErr *config.ValidationError
+		if errors.As(err, &validationErr) {
+			for _, issue := range validationErr.Issues {
+				ui.PrintError(issue.String())
+			}
+			ui.PrintInfo(fmt.Sprintf("See %s for the configuration reference", config.SchemaDocURL))
+			return fmt.Errorf("%s has %d problem(s)", configPath, len(validationErr.Issues))
+		}
+		if err != nil {
+			return err
+		}
+
+		ui.PrintDone(fmt.Sprintf("%s is valid", configPath))
+		return nil
+	},
+}
+
+var configSchemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Print the JSON Schema for arbor.yaml",
+	Long: `Prints a JSON Schema describing arbor.yaml so editors can provide
+completion and validation.
+
+For the VS Code YAML extension, save the output and reference it from
+the top of arbor.yaml:
+
+  arbor config schema > .arbor.schema.json
+  # yaml-language-server: $schema=.arbor.schema.json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		schema, err := config.JSONSchema(config.ValidateOptions{
+			StepNames:      steps.Names(),
+			ConditionNames: types.ConditionNames(),
+		})
+		if err != nil {
+			return fmt.Errorf("generating schema: %w", err)
+		}
+
+		_, err = fmt.Fprintln(cmd.OutOrStdout(), string(schema))
+		return err
+	},
+}
+
+var configMigrateCmd = &cobra.Command{
+	Use:   "migrate [FILE]",
+	Short: "Upgrade arbor.yaml to the current format",
+	Long: `Rewrites an arbor.yaml written for an older release in the current
+format, e.g. renaming legacy step names and converting the cleanup format.
+
+Arguments:
+  FILE  Optional path to the config file (defaults to the project arbor.yaml)`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		configPath, err := resolveConfigPath(args)
+		if err != nil {
+			return err
+		}
+
+		changes, err := config.MigrateProjectFile(configPath)
+		if err != nil {
+			return err
+		}
+
+		if len(changes) == 0 {
+			ui.PrintDone(fmt.Sprintf("%s is up to date", configPath))
+			return nil
+		}
+
+		for _, change := range changes {
+			ui.PrintStep(change)
+		}
+		ui.PrintDone(fmt.Sprintf("Migrated %s to version %d", configPath, config.CurrentConfigVersion))
+		return nil
+	},
+}
+
+// handleConfigMigrations reports upgrades applied in memory to an outdated
+// arbor.yaml and offers to write them back
+func handleConfigMigrations(projectPath string, cfg *config.Config) {
+	if len(cfg.Migrations) == 0 {
+		return
+	}
+
+	ui.PrintWarning("arbor.yaml uses an older format and was upgraded in memory:")
+	for _, change := range cfg.Migrations {
+		ui.PrintStep(change)
+	}
+
+	if !ui.CanPrompt() {
+		ui.PrintInfo("Run 'arbor config migrate' to update arbor.yaml")
+		return
+	}
+
+	write, err := ui.Confirm("Write the migrated arbor.yaml?")
+	if err != nil || !write {
+		ui.PrintInfo("Run 'arbor config migrate' to update arbor.yaml")
+		return
+	}
+
+	if _, err := config.MigrateProjectFile(filepath.Join(projectPath, "arbor.yaml")); err != nil {
+		ui.PrintWarning(fmt.Sprintf("Failed to write migrated config: %v", err))
+		return
+	}
+	ui.PrintSuccess("Updated arbor.yaml")
+}
+
+func resolveConfigPath(args []string) (string, error) {
+	if len(args) > 0 {
+		return filepath.Abs(args[0])
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("getting current directory: %w", err)
+	}
+
+	barePath, err := git.FindBarePath(cwd)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(filepath.Dir(barePath), "arbor.yaml"), nil
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configValidateCmd)
+	configCmd.AddCommand(configSchemaCmd)
+	configCmd.AddCommand(configMigrateCmd)
+}
@@ -1,16 +1,21 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"sync"
 
+	"github.com/spf13/cobra"
+
 	"github.com/michaeldyrynda/arbor/internal/config"
 	arborerrors "github.com/michaeldyrynda/arbor/internal/errors"
 	"github.com/michaeldyrynda/arbor/internal/git"
 	"github.com/michaeldyrynda/arbor/internal/presets"
 	"github.com/michaeldyrynda/arbor/internal/scaffold"
+	"github.com/michaeldyrynda/arbor/internal/scaffold/types"
+	"github.com/michaeldyrynda/arbor/internal/ui"
 )
 
 type ProjectContext struct {
@@ -19,21 +24,28 @@ type ProjectContext struct {
 	ProjectPath   string
 	Config        *config.Config
 	DefaultBranch string
+	// Interaction is how scaffold steps prompt and notify the user
+	Interaction types.Interaction
 
 	presetManager   *presets.Manager
 	scaffoldManager *scaffold.ScaffoldManager
 	managersInit    sync.Once
 }
 
-func OpenProjectFromCWD() (*ProjectContext, error) {
+func OpenProjectFromCWD(cmd *cobra.Command) (*ProjectContext, error) {
 	cwd, err := os.Getwd()
 	if err != nil {
 		return nil, fmt.Errorf("getting current directory: %w", err)
 	}
+	return OpenProject(cmd, cwd)
+}
 
+// OpenProject opens the project containing dir, which may be the project
+// directory or any of its worktrees, for cmd to act on
+func OpenProject(cmd *cobra.Command, cwd string) (*ProjectContext, error) {
 	barePath, err := git.FindBarePath(cwd)
 	if err != nil {
-		return nil, fmt.Errorf("finding bare repository: %w", err)
+		return nil, err
 	}
 
 	projectPath := filepath.Dir(barePath)
@@ -41,6 +53,11 @@ func OpenProjectFromCWD() (*ProjectContext, error) {
 	if err != nil {
 		return nil, fmt.Errorf("loading project config: %w", err)
 	}
+	handleConfigMigrations(projectPath, cfg)
+
+	if err := applyGlobalDefaults(cfg); err != nil {
+		return nil, err
+	}
 
 	defaultBranch := cfg.DefaultBranch
 	if defaultBranch == "" {
@@ -56,9 +73,25 @@ func OpenProjectFromCWD() (*ProjectContext, error) {
 		ProjectPath:   projectPath,
 		Config:        cfg,
 		DefaultBranch: defaultBranch,
+		Interaction:   newInteraction(cmd),
 	}, nil
 }
 
+// applyGlobalDefaults merges default steps from the global config into cfg.
+// A missing global config is not an error.
+func applyGlobalDefaults(cfg *config.Config) error {
+	global, err := config.LoadGlobal()
+	if errors.Is(err, arborerrors.ErrConfigNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("loading global config: %w", err)
+	}
+
+	cfg.ApplyGlobalDefaults(global)
+	return nil
+}
+
 func (pc *ProjectContext) IsInWorktree() bool {
 	_, err := git.FindBarePath(pc.CWD)
 	return err == nil
@@ -71,11 +104,42 @@ func (pc *ProjectContext) MustBeInWorktree() error {
 	return nil
 }
 
+// SelectWorktree returns the worktree whose folder is named in args, the
+// current worktree when no folder is given, or asks which one to use
+func (pc *ProjectContext) SelectWorktree(args []string, title string) (*git.Worktree, error) {
+	worktrees, err := git.ListWorktreesDetailed(pc.BarePath, pc.CWD, pc.DefaultBranch)
+	if err != nil {
+		return nil, fmt.Errorf("listing worktrees: %w", err)
+	}
+
+	for _, wt := range worktrees {
+		if len(args) > 0 && filepath.Base(wt.Path) == args[0] {
+			return &wt, nil
+		}
+		if len(args) == 0 && wt.IsCurrent {
+			return &wt, nil
+		}
+	}
+
+	if len(args) > 0 {
+		return nil, fmt.Errorf("worktree '%s' not found: %w", args[0], arborerrors.ErrWorktreeNotFound)
+	}
+
+	if !ui.CanPrompt() {
+		return nil, arborerrors.Wrap(arborerrors.ErrInvalidArguments, fmt.Errorf("worktree folder name required (run interactively or provide folder as argument)"))
+	}
+
+	selected, err := ui.SelectWorktree(title, worktrees)
+	if err != nil {
+		return nil, fmt.Errorf("selecting worktree: %w", err)
+	}
+	return selected, nil
+}
+
 func (pc *ProjectContext) PresetManager() *presets.Manager {
 	pc.managersInit.Do(func() {
 		pc.presetManager = presets.NewManager()
-		pc.scaffoldManager = scaffold.NewScaffoldManager()
-		presets.RegisterAllWithScaffold(pc.scaffoldManager)
+		pc.scaffoldManager = newScaffoldManager(pc.Interaction)
 	})
 	return pc.presetManager
 }
@@ -83,8 +147,17 @@ func (pc *ProjectContext) PresetManager() *presets.Manager {
 func (pc *ProjectContext) ScaffoldManager() *scaffold.ScaffoldManager {
 	pc.managersInit.Do(func() {
 		pc.presetManager = presets.NewManager()
-		pc.scaffoldManager = scaffold.NewScaffoldManager()
-		presets.RegisterAllWithScaffold(pc.scaffoldManager)
+		pc.scaffoldManager = newScaffoldManager(pc.Interaction)
 	})
 	return pc.scaffoldManager
 }
+
+// newScaffoldManager returns a scaffold manager with every preset registered,
+// which records step timings when stats are turned on
+func newScaffoldManager(interaction types.Interaction) *scaffold.ScaffoldManager {
+	manager := scaffold.NewScaffoldManager()
+	manager.Interaction = interaction
+	manager.StepTimings = stepTimings()
+	presets.RegisterAllWithScaffold(manager)
+	return manager
+}
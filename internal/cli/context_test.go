@@ -93,7 +93,7 @@ func TestOpenProjectFromCWD_NotInWorktree(t *testing.T) {
 		t.Fatalf("failed to change directory: %v", err)
 	}
 
-	_, err = OpenProjectFromCWD()
+	_, err = OpenProjectFromCWD(nil)
 	if err == nil {
 		t.Error("expected error when not in worktree, got nil")
 	}
@@ -114,9 +114,9 @@ func TestOpenProjectFromCWD_Success(t *testing.T) {
 		t.Fatalf("failed to change directory: %v", err)
 	}
 
-	pc, err := OpenProjectFromCWD()
+	pc, err := OpenProjectFromCWD(nil)
 	if err != nil {
-		t.Fatalf("OpenProjectFromCWD() error = %v", err)
+		t.Fatalf("OpenProjectFromCWD(nil) error = %v", err)
 	}
 
 	expectedCWD := evalSymlinks(worktreePath)
@@ -193,9 +193,9 @@ func TestProjectContext_Managers(t *testing.T) {
 		t.Fatalf("failed to change directory: %v", err)
 	}
 
-	pc, err := OpenProjectFromCWD()
+	pc, err := OpenProjectFromCWD(nil)
 	if err != nil {
-		t.Fatalf("OpenProjectFromCWD() error = %v", err)
+		t.Fatalf("OpenProjectFromCWD(nil) error = %v", err)
 	}
 
 	pm := pc.PresetManager()
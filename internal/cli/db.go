@@ -0,0 +1,251 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/michaeldyrynda/arbor/internal/audit"
+	"github.com/michaeldyrynda/arbor/internal/config"
+	arborerrors "github.com/michaeldyrynda/arbor/internal/errors"
+	"github.com/michaeldyrynda/arbor/internal/git"
+	"github.com/michaeldyrynda/arbor/internal/scaffold/steps"
+	"github.com/michaeldyrynda/arbor/internal/scaffold/types"
+	"github.com/michaeldyrynda/arbor/internal/secrets"
+	"github.com/michaeldyrynda/arbor/internal/ui"
+)
+
+var dbCmd = &cobra.Command{
+	Use:   "db",
+	Short: "Manage worktree databases",
+}
+
+var dbGcCmd = &cobra.Command{
+	Use:   "gc",
+	Short: "Drop databases left behind by removed worktrees",
+	Long: `Finds databases named {prefix}_{suffix} or {prefix}_test_{suffix} whose
+suffix arbor recorded for a worktree that has since been removed, and offers
+to drop them. Suffixes are read from the worktree records and the audit log,
+never guessed from a database's name, so databases such as {prefix}_staging
+are left alone. With db.mode set to schema, orphaned schemas are found
+instead.
+
+Only databases using one of this project's prefixes are considered: the
+site_name and any --prefix set on its db.create steps, or the prefixes given
+with --prefix. Databases from other projects sharing the same server are left
+alone.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pc, err := OpenProjectFromCWD(cmd)
+		if err != nil {
+			return err
+		}
+
+		force := mustGetBool(cmd, "force")
+		dryRun := mustGetBool(cmd, "dry-run")
+		prefixes, err := cmd.Flags().GetStringSlice("prefix")
+		if err != nil {
+			return fmt.Errorf("reading --prefix: %w", err)
+		}
+		if len(prefixes) == 0 {
+			prefixes = pc.Config.DatabasePrefixes()
+		}
+		if len(prefixes) == 0 {
+			return arborerrors.Wrap(arborerrors.ErrInvalidArguments, fmt.Errorf("no database prefix to search for; set site_name in arbor.yaml or pass --prefix"))
+		}
+
+		worktrees, err := git.ListWorktrees(pc.BarePath)
+		if err != nil {
+			return fmt.Errorf("listing worktrees: %w", err)
+		}
+
+		active, err := activeDbSuffixes(worktrees)
+		if err != nil {
+			return err
+		}
+
+		recorded, err := recordedDbSuffixes(pc.BarePath)
+		if err != nil {
+			return err
+		}
+
+		ctx, err := dbContext(pc, worktrees)
+		if err != nil {
+			return err
+		}
+
+		client, engine, err := steps.OpenDatabaseClient(ctx, projectDbType(pc.Config), steps.DefaultDatabaseClientFactory)
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		target, err := steps.NewDatabaseTarget(ctx, client)
+		if err != nil {
+			return err
+		}
+
+		databases, err := target.List("%")
+		if err != nil {
+			return err
+		}
+
+		orphaned := steps.OrphanedDatabases(databases, recorded, active, prefixes)
+		if len(orphaned) == 0 {
+			ui.PrintDone(fmt.Sprintf("No orphaned %ss found.", target.Kind))
+			return nil
+		}
+
+		ui.PrintInfo(fmt.Sprintf("%d orphaned %s %s(s) found:", len(orphaned), engine, target.Kind))
+		for _, name := range orphaned {
+			ui.PrintStep(name)
+		}
+
+		toDrop := orphaned
+		if !force {
+			if !ui.ShouldPrompt(cmd, false) {
+				ui.PrintInfo("Run with --force to drop them.")
+				return nil
+			}
+
+			toDrop, err = ui.SelectDatabasesToDrop(orphaned)
+			if err != nil {
+				return fmt.Errorf("selecting databases: %w", err)
+			}
+			if len(toDrop) == 0 {
+				ui.PrintInfo(fmt.Sprintf("No %ss selected.", target.Kind))
+				return nil
+			}
+
+			confirmed, err := ui.Confirm(fmt.Sprintf("Drop %d %s(s)? This cannot be undone.", len(toDrop), target.Kind))
+			if err != nil {
+				return fmt.Errorf("confirmation: %w", err)
+			}
+			if !confirmed {
+				ui.PrintInfo(fmt.Sprintf("No %ss dropped.", target.Kind))
+				return nil
+			}
+		}
+
+		for _, name := range toDrop {
+			if dryRun {
+				ui.PrintInfo(fmt.Sprintf("[DRY RUN] Would drop %s", name))
+				continue
+			}
+
+			err := target.Drop(name)
+			recordAudit(pc.BarePath, audit.Entry{Operation: audit.OperationDbDrop, Target: name}, err)
+			if err != nil {
+				ui.PrintErrorWithHint(fmt.Sprintf("Error dropping %s", name), err.Error())
+				continue
+			}
+			ui.PrintSuccess(fmt.Sprintf("Dropped %s", name))
+		}
+
+		ui.PrintDone("Done.")
+		return nil
+	},
+}
+
+// activeDbSuffixes returns the database suffixes recorded by each worktree
+func activeDbSuffixes(worktrees []git.Worktree) (map[string]bool, error) {
+	active := make(map[string]bool)
+	for _, wt := range worktrees {
+		if wt.Branch == "(bare)" {
+			continue
+		}
+
+		wtConfig, err := config.ReadWorktreeConfig(wt.Path)
+		if err != nil {
+			return nil, fmt.Errorf("reading worktree config for %s: %w", wt.Branch, err)
+		}
+		if wtConfig.DbSuffix != "" {
+			active[wtConfig.DbSuffix] = true
+		}
+	}
+	return active, nil
+}
+
+// recordedDbSuffixes returns the database suffixes arbor recorded for the
+// worktrees it created, including those since removed
+func recordedDbSuffixes(barePath string) (map[string]bool, error) {
+	recorded := make(map[string]bool)
+
+	records, err := config.ReadWorktreeRecords(barePath)
+	if err != nil {
+		return nil, err
+	}
+	for _, record := range records {
+		if record.DbSuffix != "" {
+			recorded[record.DbSuffix] = true
+		}
+	}
+
+	entries, err := audit.Read(barePath)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if entry.DbSuffix != "" {
+			recorded[entry.DbSuffix] = true
+		}
+	}
+	return recorded, nil
+}
+
+// dbContext builds a scaffold context for the worktree whose .env describes the
+// database server: the current worktree, or the default branch worktree
+func dbContext(pc *ProjectContext, worktrees []git.Worktree) (*types.ScaffoldContext, error) {
+	var selected *git.Worktree
+	for i, wt := range worktrees {
+		if wt.Branch == "(bare)" {
+			continue
+		}
+		if pc.CWD == wt.Path || strings.HasPrefix(pc.CWD, wt.Path+string(filepath.Separator)) {
+			selected = &worktrees[i]
+			break
+		}
+		if selected == nil || wt.Branch == pc.DefaultBranch {
+			selected = &worktrees[i]
+		}
+	}
+
+	if selected == nil {
+		return nil, fmt.Errorf("no worktrees found")
+	}
+
+	database, err := secrets.NewInterpolator(selected.Path).InterpolateDatabase(pc.Config.DB)
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.ScaffoldContext{
+		WorktreePath: selected.Path,
+		Branch:       selected.Branch,
+		SiteName:     pc.Config.SiteName,
+		RepoPath:     filepath.Base(pc.ProjectPath),
+		ProjectPath:  pc.ProjectPath,
+		BarePath:     pc.BarePath,
+		Interaction:  pc.Interaction,
+		Database:     database,
+	}, nil
+}
+
+// projectDbType returns the engine set on the project's db.create steps, if any
+func projectDbType(cfg *config.Config) string {
+	for _, step := range append(cfg.Scaffold.Steps, cfg.GlobalSteps...) {
+		if step.Name == "db.create" && step.Type != "" {
+			return step.Type
+		}
+	}
+	return ""
+}
+
+func init() {
+	rootCmd.AddCommand(dbCmd)
+	dbCmd.AddCommand(dbGcCmd)
+
+	dbGcCmd.Flags().BoolP("force", "f", false, "Drop all orphaned databases without prompting")
+	dbGcCmd.Flags().StringSlice("prefix", nil, "Only consider databases with this prefix, instead of the project's (repeatable)")
+}
@@ -0,0 +1,51 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/michaeldyrynda/arbor/internal/audit"
+	"github.com/michaeldyrynda/arbor/internal/config"
+	"github.com/michaeldyrynda/arbor/internal/git"
+)
+
+func TestActiveDbSuffixes(t *testing.T) {
+	mainPath := t.TempDir()
+	featurePath := t.TempDir()
+	require.NoError(t, config.WriteWorktreeConfig(mainPath, map[string]string{"db_suffix": "cool_engine"}))
+
+	active, err := activeDbSuffixes([]git.Worktree{
+		{Branch: "(bare)", Path: t.TempDir()},
+		{Branch: "main", Path: mainPath},
+		{Branch: "feature", Path: featurePath},
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{"cool_engine": true}, active)
+}
+
+func TestRecordedDbSuffixes(t *testing.T) {
+	barePath := t.TempDir()
+	require.NoError(t, config.RecordWorktree(barePath, config.WorktreeRecord{Path: "/project/feature", Branch: "feature", DbSuffix: "cool_engine"}))
+	require.NoError(t, audit.Record(barePath, audit.Entry{Operation: audit.OperationRemove, Branch: "old", DbSuffix: "swift_runner"}, nil))
+	require.NoError(t, audit.Record(barePath, audit.Entry{Operation: audit.OperationDbDrop, Target: "app_staging"}, nil))
+
+	recorded, err := recordedDbSuffixes(barePath)
+
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{"cool_engine": true, "swift_runner": true}, recorded)
+}
+
+func TestProjectDbType(t *testing.T) {
+	cfg := &config.Config{
+		Scaffold: config.ScaffoldConfig{Steps: []config.StepConfig{
+			{Name: "php.composer"},
+			{Name: "db.create", Type: "pgsql"},
+		}},
+	}
+
+	assert.Equal(t, "pgsql", projectDbType(cfg))
+	assert.Empty(t, projectDbType(&config.Config{}))
+}
@@ -12,7 +12,6 @@ import (
 	"github.com/michaeldyrynda/arbor/internal/config"
 	"github.com/michaeldyrynda/arbor/internal/git"
 	"github.com/michaeldyrynda/arbor/internal/presets"
-	"github.com/michaeldyrynda/arbor/internal/scaffold"
 	"github.com/michaeldyrynda/arbor/internal/ui"
 )
 
@@ -35,6 +34,8 @@ This operation cannot be undone.`,
 		var projectPath string
 		if len(args) > 0 {
 			projectPath = args[0]
+		} else if !ui.CanPrompt() {
+			return fmt.Errorf("project path required (run interactively or provide path as argument)")
 		} else {
 			cwd, err := os.Getwd()
 			if err != nil {
@@ -64,6 +65,11 @@ This operation cannot be undone.`,
 		if err != nil {
 			return fmt.Errorf("not an arbor project: %w", err)
 		}
+		handleConfigMigrations(absProjectPath, cfg)
+
+		if err := applyGlobalDefaults(cfg); err != nil {
+			return err
+		}
 
 		barePath := filepath.Join(absProjectPath, ".bare")
 		if _, err := os.Stat(barePath); err != nil {
@@ -82,6 +88,10 @@ This operation cannot be undone.`,
 		}
 
 		if !force && !dryRun {
+			if !ui.CanPrompt() {
+				return fmt.Errorf("destroying a project requires confirmation (use --force to skip)")
+			}
+
 			confirmed, err := ui.ConfirmDestroy(projectName, worktrees)
 			if err != nil {
 				return err
@@ -102,8 +112,7 @@ This operation cannot be undone.`,
 
 		preset := cfg.Preset
 		presetManager := presets.NewManager()
-		scaffoldManager := scaffold.NewScaffoldManager()
-		presets.RegisterAllWithScaffold(scaffoldManager)
+		scaffoldManager := newScaffoldManager(newInteraction(cmd))
 
 		allCleanupFailed := true
 		repoName := filepath.Base(absProjectPath)
@@ -129,6 +138,7 @@ This operation cannot be undone.`,
 				allCleanupFailed = false
 			}
 
+			stopWorktreeTunnel(barePath, wt.Path)
 			if err := git.RemoveWorktree(wt.Path, true); err != nil {
 				ui.PrintWarning(fmt.Sprintf("Failed to remove worktree %s: %v", wt.Branch, err))
 			}
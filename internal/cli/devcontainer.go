@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/michaeldyrynda/arbor/internal/config"
+	"github.com/michaeldyrynda/arbor/internal/scaffold"
+	"github.com/michaeldyrynda/arbor/internal/scaffold/steps"
+	"github.com/michaeldyrynda/arbor/internal/ui"
+)
+
+var devcontainerCmd = &cobra.Command{
+	Use:   "devcontainer [FOLDER]",
+	Short: "Write a dev container configuration for a worktree",
+	Long: `Writes .devcontainer/devcontainer.json for a worktree, so each branch can
+run in its own container.
+
+Arguments:
+  FOLDER  Name of the worktree folder (defaults to the current worktree)
+
+The container is named after the project and worktree folders. Each port listed
+by a devcontainer step in arbor.yaml is published on the host at that port
+plus an offset from 1 to 999 derived from the worktree's database suffix, so
+worktrees don't fight over ports:
+
+  scaffold:
+    steps:
+      - name: devcontainer
+        image: mcr.microsoft.com/devcontainers/php:8.3
+        ports: [80, 5173]
+        mounts:
+          - source={{ .RepoName }}-{{ .Path }}-vendor,target=/workspaces/{{ .Path }}/vendor,type=volume
+
+A devcontainer.json that arbor did not write is never replaced.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pc, err := OpenProjectFromCWD(cmd)
+		if err != nil {
+			return err
+		}
+
+		target, err := pc.SelectWorktree(args, "Select a worktree")
+		if err != nil {
+			return err
+		}
+
+		ctx, err := scaffold.TemplateContext(target.Path, target.Branch, filepath.Base(target.Path), pc.Config)
+		if err != nil {
+			return err
+		}
+
+		settings := devcontainerSettings(pc.Config)
+		path, err := steps.WriteDevcontainer(ctx, settings.Image, settings.Ports, settings.Mounts)
+		if err != nil {
+			return err
+		}
+		ui.PrintSuccessPath("Wrote", path)
+		return nil
+	},
+}
+
+// devcontainerSettings returns the project's devcontainer step, or an empty
+// one when there is none
+func devcontainerSettings(cfg *config.Config) config.StepConfig {
+	for _, list := range [][]config.StepConfig{cfg.Scaffold.Steps, cfg.GlobalSteps} {
+		for _, step := range list {
+			if step.Name == "devcontainer" {
+				return step
+			}
+		}
+	}
+	return config.StepConfig{Name: "devcontainer"}
+}
+
+func init() {
+	rootCmd.AddCommand(devcontainerCmd)
+}
@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/michaeldyrynda/arbor/internal/config"
+	"github.com/michaeldyrynda/arbor/internal/scaffold/steps"
+	"github.com/michaeldyrynda/arbor/internal/ui"
+)
+
+var direnvCmd = &cobra.Command{
+	Use:   "direnv [FOLDER]",
+	Short: "Write a direnv .envrc for a worktree",
+	Long: `Writes an .envrc for a worktree and runs direnv allow, so shells in the
+worktree pick up its settings.
+
+Arguments:
+  FOLDER  Name of the worktree folder (defaults to the current worktree)
+
+The .envrc exports APP_URL and DB_DATABASE from the worktree's .env and adds
+vendor/bin, node_modules/.bin and bin to PATH when they exist. A direnv step
+in arbor.yaml changes these with keys and paths. An .envrc that arbor did not
+write is never replaced.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pc, err := OpenProjectFromCWD(cmd)
+		if err != nil {
+			return err
+		}
+
+		target, err := pc.SelectWorktree(args, "Select a worktree")
+		if err != nil {
+			return err
+		}
+
+		keys, paths := direnvSettings(pc.Config)
+		if err := steps.WriteEnvrc(target.Path, keys, paths); err != nil {
+			return err
+		}
+		ui.PrintSuccessPath("Wrote", filepath.Join(target.Path, ".envrc"))
+
+		if err := steps.AllowEnvrc(target.Path); err != nil {
+			ui.PrintWarning(err.Error())
+			return nil
+		}
+		ui.PrintSuccess("Allowed .envrc with direnv")
+		return nil
+	},
+}
+
+// direnvSettings returns the keys and paths of the project's direnv step,
+// falling back to the defaults
+func direnvSettings(cfg *config.Config) ([]string, []string) {
+	keys, paths := steps.DefaultEnvrcKeys, steps.DefaultEnvrcPaths
+	for _, list := range [][]config.StepConfig{cfg.Scaffold.Steps, cfg.GlobalSteps} {
+		for _, step := range list {
+			if step.Name != "direnv" {
+				continue
+			}
+			if len(step.Keys) > 0 {
+				keys = step.Keys
+			}
+			if len(step.Paths) > 0 {
+				paths = step.Paths
+			}
+			return keys, paths
+		}
+	}
+	return keys, paths
+}
+
+func init() {
+	rootCmd.AddCommand(direnvCmd)
+}
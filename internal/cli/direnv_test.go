@@ -0,0 +1,24 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/michaeldyrynda/arbor/internal/config"
+	"github.com/michaeldyrynda/arbor/internal/scaffold/steps"
+)
+
+func TestDirenvSettings(t *testing.T) {
+	keys, paths := direnvSettings(&config.Config{})
+	assert.Equal(t, steps.DefaultEnvrcKeys, keys)
+	assert.Equal(t, steps.DefaultEnvrcPaths, paths)
+
+	cfg := &config.Config{
+		Scaffold:    config.ScaffoldConfig{Steps: []config.StepConfig{{Name: "direnv", Keys: []string{"APP_URL"}}}},
+		GlobalSteps: []config.StepConfig{{Name: "direnv", Paths: []string{"bin"}}},
+	}
+	keys, paths = direnvSettings(cfg)
+	assert.Equal(t, []string{"APP_URL"}, keys)
+	assert.Equal(t, steps.DefaultEnvrcPaths, paths)
+}
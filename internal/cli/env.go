@@ -0,0 +1,232 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+
+	"github.com/michaeldyrynda/arbor/internal/config"
+	arborerrors "github.com/michaeldyrynda/arbor/internal/errors"
+	"github.com/michaeldyrynda/arbor/internal/git"
+	"github.com/michaeldyrynda/arbor/internal/scaffold/steps"
+	"github.com/michaeldyrynda/arbor/internal/ui"
+	"github.com/michaeldyrynda/arbor/internal/utils"
+)
+
+var envCmd = &cobra.Command{
+	Use:   "env",
+	Short: "Inspect and edit worktree .env files",
+}
+
+var envGetCmd = &cobra.Command{
+	Use:   "get KEY",
+	Short: "Print a value from the current worktree's .env",
+	Long: `Prints the value of KEY from the current worktree's .env, without
+surrounding quotes. Exits non-zero when the key is not set.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pc, err := OpenProjectFromCWD(cmd)
+		if err != nil {
+			return err
+		}
+
+		target, err := pc.SelectWorktree(nil, "Select a worktree")
+		if err != nil {
+			return err
+		}
+
+		file := mustGetString(cmd, "file")
+		value, ok := utils.ReadEnvFile(target.Path, file)[args[0]]
+		if !ok {
+			return fmt.Errorf("%s not found in %s", args[0], filepath.Join(target.Path, file))
+		}
+		_, err = fmt.Fprintln(cmd.OutOrStdout(), utils.UnquoteEnvValue(value))
+		return err
+	},
+}
+
+var envSetCmd = &cobra.Command{
+	Use:   "set KEY VALUE",
+	Short: "Set a value in the current worktree's .env",
+	Long: `Sets KEY to VALUE in the current worktree's .env, replacing an existing
+entry or appending a new one. A value with spaces, a # or a newline is written
+in double quotes. The file is written the same way as the env.write
+step: through a temporary file that replaces it, keeping its permissions.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pc, err := OpenProjectFromCWD(cmd)
+		if err != nil {
+			return err
+		}
+
+		target, err := pc.SelectWorktree(nil, "Select a worktree")
+		if err != nil {
+			return err
+		}
+
+		file := mustGetString(cmd, "file")
+		if err := utils.WriteEnvValue(target.Path, file, args[0], utils.QuoteEnvValue(args[1])); err != nil {
+			return err
+		}
+		ui.PrintSuccessPath(fmt.Sprintf("Set %s in", args[0]), filepath.Join(target.Path, file))
+		return nil
+	},
+}
+
+var envDiffCmd = &cobra.Command{
+	Use:   "diff [OTHER_WORKTREE]",
+	Short: "Compare the .env keys of two worktrees",
+	Long: `Compares the current worktree's .env with another worktree's, key by key.
+Keys set in the other worktree but missing here, such as those added on its
+branch, are listed first; then keys only set here, and keys whose values
+differ. Values are not printed.
+
+Arguments:
+  OTHER_WORKTREE  Name of the worktree folder to compare with (defaults to
+                  the main worktree)`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pc, err := OpenProjectFromCWD(cmd)
+		if err != nil {
+			return err
+		}
+
+		current, err := pc.SelectWorktree(nil, "Select a worktree")
+		if err != nil {
+			return err
+		}
+
+		other, err := envDiffTarget(pc, args, current)
+		if err != nil {
+			return err
+		}
+
+		file := mustGetString(cmd, "file")
+		diff := utils.DiffEnv(utils.ReadEnvFile(current.Path, file), utils.ReadEnvFile(other.Path, file))
+		return printEnvDiff(cmd.OutOrStdout(), diff, filepath.Base(other.Path))
+	},
+}
+
+var envSyncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Add keys from .env.example that the current worktree's .env lacks",
+	Long: `Compares the current worktree's .env with .env.example, appending the keys
+the example has that .env lacks. Run interactively, it asks for each value,
+starting from the example's; otherwise, or when .env is missing or empty, the
+example's value is used.
+
+Keys in .env that the example no longer has are listed but kept, since
+another branch may still need them. The env.sync step does the same during
+scaffolding.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pc, err := OpenProjectFromCWD(cmd)
+		if err != nil {
+			return err
+		}
+
+		target, err := pc.SelectWorktree(nil, "Select a worktree")
+		if err != nil {
+			return err
+		}
+
+		file := mustGetString(cmd, "file")
+		example := mustGetString(cmd, "from")
+		dryRun := mustGetBool(cmd, "dry-run")
+		result, err := steps.SyncEnv(target.Path, file, example, pc.Interaction.EnvValue, dryRun)
+		if err != nil {
+			return err
+		}
+
+		for _, key := range result.Removed {
+			ui.PrintWarning(fmt.Sprintf("%s is no longer in %s", key, example))
+		}
+		if len(result.Added) == 0 {
+			ui.PrintDone(fmt.Sprintf("%s has every key in %s.", file, example))
+			return nil
+		}
+
+		verb := "Added"
+		if dryRun {
+			verb = "[DRY RUN] Would add"
+		}
+		ui.PrintDone(fmt.Sprintf("%s %s to %s.", verb, strings.Join(result.Added, ", "), file))
+		return nil
+	},
+}
+
+// promptEnvValue asks for the value of a key env.sync adds
+func promptEnvValue(key, example string) (string, error) {
+	return ui.PromptInput(config.InputConfig{Name: key, Prompt: fmt.Sprintf("Value for %s", key), Default: example})
+}
+
+// envDiffTarget returns the worktree named in args, or the main worktree when
+// none is given and the current worktree is not the main one
+func envDiffTarget(pc *ProjectContext, args []string, current *git.Worktree) (*git.Worktree, error) {
+	if len(args) > 0 {
+		return pc.SelectWorktree(args, "Select a worktree to compare with")
+	}
+
+	if !current.IsMain {
+		worktrees, err := git.ListWorktreesDetailed(pc.BarePath, pc.CWD, pc.DefaultBranch)
+		if err != nil {
+			return nil, fmt.Errorf("listing worktrees: %w", err)
+		}
+		for _, wt := range worktrees {
+			if wt.IsMain {
+				return &wt, nil
+			}
+		}
+	}
+
+	return nil, arborerrors.Wrap(arborerrors.ErrInvalidArguments, fmt.Errorf("worktree to compare with required (provide its folder as argument)"))
+}
+
+func printEnvDiff(w io.Writer, diff utils.EnvDiff, otherName string) error {
+	if diff.Empty() {
+		_, err := fmt.Fprintf(w, "No differences with %s.\n", otherName)
+		return err
+	}
+
+	missing := lipgloss.NewStyle().Foreground(ui.ColorWarning).Bold(true)
+	sections := []struct {
+		title  string
+		marker string
+		keys   []string
+		style  lipgloss.Style
+	}{
+		{fmt.Sprintf("Missing here (set in %s):", otherName), "+", diff.Missing, missing},
+		{fmt.Sprintf("Only here (not in %s):", otherName), "-", diff.Extra, lipgloss.NewStyle()},
+		{"Different values:", "~", diff.Changed, lipgloss.NewStyle()},
+	}
+
+	for _, section := range sections {
+		if len(section.keys) == 0 {
+			continue
+		}
+		if _, err := fmt.Fprintln(w, section.style.Render(section.title)); err != nil {
+			return err
+		}
+		for _, key := range section.keys {
+			if _, err := fmt.Fprintln(w, section.style.Render(fmt.Sprintf("  %s %s", section.marker, key))); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(envCmd)
+	envCmd.AddCommand(envGetCmd)
+	envCmd.AddCommand(envSetCmd)
+	envCmd.AddCommand(envDiffCmd)
+	envCmd.AddCommand(envSyncCmd)
+
+	envCmd.PersistentFlags().String("file", ".env", "Env file, relative to the worktree")
+	envSyncCmd.Flags().String("from", steps.DefaultEnvExample, "Example file to take new keys from")
+}
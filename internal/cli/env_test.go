@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/michaeldyrynda/arbor/internal/utils"
+)
+
+func TestPrintEnvDiff(t *testing.T) {
+	var buf bytes.Buffer
+	diff := utils.EnvDiff{Missing: []string{"STRIPE_KEY"}, Changed: []string{"DB_DATABASE"}}
+	require.NoError(t, printEnvDiff(&buf, diff, "main"))
+
+	assert.Equal(t, "Missing here (set in main):\n  + STRIPE_KEY\nDifferent values:\n  ~ DB_DATABASE\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, printEnvDiff(&buf, utils.EnvDiff{}, "main"))
+	assert.Equal(t, "No differences with main.\n", buf.String())
+}
+
+func TestEnvGetAndSetCmds(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	project := createWorkspaceProject(t, t.TempDir(), "app")
+	mainPath := filepath.Join(project, "main")
+	require.NoError(t, os.WriteFile(filepath.Join(mainPath, ".env"), []byte("APP_NAME=\"My App\"\nDB_DATABASE=app\n"), 0644))
+
+	originalDir, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalDir)
+	require.NoError(t, os.Chdir(mainPath))
+
+	newCmd := func() (*cobra.Command, *bytes.Buffer) {
+		var out bytes.Buffer
+		cmd := &cobra.Command{}
+		cmd.Flags().String("file", ".env", "")
+		cmd.SetOut(&out)
+		return cmd, &out
+	}
+	get := func(key string) (string, error) {
+		cmd, out := newCmd()
+		err := envGetCmd.RunE(cmd, []string{key})
+		return out.String(), err
+	}
+
+	t.Run("get prints the value without quotes", func(t *testing.T) {
+		value, err := get("APP_NAME")
+		require.NoError(t, err)
+		assert.Equal(t, "My App\n", value)
+	})
+
+	t.Run("get fails for a missing key", func(t *testing.T) {
+		_, err := get("MISSING")
+		assert.ErrorContains(t, err, "MISSING not found")
+	})
+
+	t.Run("set replaces and appends values", func(t *testing.T) {
+		cmd, _ := newCmd()
+		require.NoError(t, envSetCmd.RunE(cmd, []string{"DB_DATABASE", "app_feature"}))
+		cmd, _ = newCmd()
+		require.NoError(t, envSetCmd.RunE(cmd, []string{"MAIL_FROM_NAME", "Arbor Dev"}))
+
+		content, err := os.ReadFile(filepath.Join(mainPath, ".env"))
+		require.NoError(t, err)
+		assert.Equal(t, "APP_NAME=\"My App\"\nDB_DATABASE=app_feature\nMAIL_FROM_NAME=\"Arbor Dev\"\n", string(content))
+
+		value, err := get("MAIL_FROM_NAME")
+		require.NoError(t, err)
+		assert.Equal(t, "Arbor Dev\n", value)
+	})
+
+	t.Run("set escapes newlines", func(t *testing.T) {
+		cmd, _ := newCmd()
+		require.NoError(t, envSetCmd.RunE(cmd, []string{"MOTD", "line one\nline two"}))
+
+		env := utils.ReadEnvFile(mainPath, ".env")
+		assert.Equal(t, `"line one\nline two"`, env["MOTD"])
+		assert.Equal(t, "app_feature", env["DB_DATABASE"], "other keys are kept")
+	})
+}
@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/michaeldyrynda/arbor/internal/config"
+	"github.com/michaeldyrynda/arbor/internal/events"
+	"github.com/michaeldyrynda/arbor/internal/ui"
+)
+
+// worktreeDbSuffix reads a worktree's database suffix, which is gone once
+// the worktree is removed
+func worktreeDbSuffix(worktreePath string) string {
+	wtConfig, err := config.ReadWorktreeConfig(worktreePath)
+	if err != nil {
+		return ""
+	}
+	return wtConfig.DbSuffix
+}
+
+// sendEvent posts a lifecycle event to the project's webhook for it, if one
+// is configured. The worktree change has already happened, so failures are
+// reported as warnings.
+func sendEvent(pc *ProjectContext, event, branch, path, dbSuffix string, start time.Time) {
+	var url string
+	switch event {
+	case events.WorktreeCreated:
+		url = pc.Config.Events.WorktreeCreated
+	case events.WorktreeRemoved:
+		url = pc.Config.Events.WorktreeRemoved
+	}
+	if url == "" {
+		return
+	}
+
+	project := pc.Config.SiteName
+	if project == "" {
+		project = filepath.Base(pc.ProjectPath)
+	}
+
+	err := events.Send(url, events.Payload{
+		Event:      event,
+		Project:    project,
+		Branch:     branch,
+		Path:       path,
+		DbSuffix:   dbSuffix,
+		DurationMs: time.Since(start).Milliseconds(),
+		Timestamp:  time.Now().UTC(),
+	})
+	if err != nil {
+		ui.PrintWarning(fmt.Sprintf("Could not send %s event: %v", event, err))
+	}
+}
@@ -0,0 +1,51 @@
+package cli
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/michaeldyrynda/arbor/internal/config"
+	arborerrors "github.com/michaeldyrynda/arbor/internal/errors"
+)
+
+// ExitCode maps an error returned by Execute to the exit code for its class
+// of failure, so scripts can tell a missing worktree from a failed step
+func ExitCode(err error) int {
+	switch {
+	case err == nil:
+		return config.ExitSuccess
+	case errors.Is(err, arborerrors.ErrInvalidArguments):
+		return config.ExitInvalidArguments
+	case errors.Is(err, arborerrors.ErrWorktreeNotFound):
+		return config.ExitWorktreeNotFound
+	case errors.Is(err, arborerrors.ErrConfigNotFound), errors.Is(err, arborerrors.ErrInvalidConfig):
+		return config.ExitConfigurationError
+	case errors.Is(err, arborerrors.ErrScaffoldStepFailed):
+		return config.ExitScaffoldStepFailed
+	case errors.Is(err, arborerrors.ErrGitOperationFailed):
+		return config.ExitGitOperationFailed
+	case strings.HasPrefix(err.Error(), "unknown command"), strings.HasPrefix(err.Error(), "if any flags in the group"):
+		return config.ExitInvalidArguments
+	}
+	return config.ExitGeneralError
+}
+
+// markArgumentErrors wraps the flag parsing and argument validation of cmd
+// and its subcommands so their errors are reported as invalid arguments
+func markArgumentErrors(cmd *cobra.Command) {
+	cmd.SetFlagErrorFunc(func(_ *cobra.Command, err error) error {
+		return arborerrors.Wrap(arborerrors.ErrInvalidArguments, err)
+	})
+
+	if validate := cmd.Args; validate != nil {
+		cmd.Args = func(c *cobra.Command, args []string) error {
+			return arborerrors.Wrap(arborerrors.ErrInvalidArguments, validate(c, args))
+		}
+	}
+
+	for _, sub := range cmd.Commands() {
+		markArgumentErrors(sub)
+	}
+}
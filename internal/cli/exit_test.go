@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/michaeldyrynda/arbor/internal/config"
+	arborerrors "github.com/michaeldyrynda/arbor/internal/errors"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		code int
+	}{
+		{"success", nil, config.ExitSuccess},
+		{"general", errors.New("boom"), config.ExitGeneralError},
+		{"worktree not found", fmt.Errorf("worktree 'x' not found: %w", arborerrors.ErrWorktreeNotFound), config.ExitWorktreeNotFound},
+		{"git", arborerrors.Wrap(arborerrors.ErrGitOperationFailed, errors.New("git clone failed")), config.ExitGitOperationFailed},
+		{"config missing", arborerrors.Wrap(arborerrors.ErrConfigNotFound, errors.New("arbor.yaml not found")), config.ExitConfigurationError},
+		{"config invalid", fmt.Errorf("loading project config: %w", &config.ValidationError{File: "arbor.yaml"}), config.ExitConfigurationError},
+		{"scaffold", fmt.Errorf("scaffold: %w", arborerrors.Wrap(arborerrors.ErrScaffoldStepFailed, errors.New("step php.composer failed"))), config.ExitScaffoldStepFailed},
+		{"invalid arguments", arborerrors.Wrap(arborerrors.ErrInvalidArguments, errors.New("accepts at most 1 arg(s)")), config.ExitInvalidArguments},
+		{"unknown command", errors.New(`unknown command "wrok" for "arbor"`), config.ExitInvalidArguments},
+		{"conflicting flags", errors.New("if any flags in the group [quiet verbose] are set none of the others can be; [quiet verbose] were all set"), config.ExitInvalidArguments},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.code, ExitCode(tt.err))
+		})
+	}
+}
+
+func TestMarkArgumentErrors(t *testing.T) {
+	root := &cobra.Command{Use: "arbor", SilenceErrors: true, SilenceUsage: true}
+	child := &cobra.Command{Use: "remove", Args: cobra.MaximumNArgs(1), RunE: func(*cobra.Command, []string) error { return nil }}
+	child.Flags().Bool("force", false, "")
+	root.AddCommand(child)
+	markArgumentErrors(root)
+
+	root.SetArgs([]string{"remove", "a", "b"})
+	err := root.Execute()
+	assert.ErrorContains(t, err, "accepts at most 1 arg(s)")
+	assert.Equal(t, config.ExitInvalidArguments, ExitCode(err))
+
+	root.SetArgs([]string{"remove", "--nope"})
+	assert.Equal(t, config.ExitInvalidArguments, ExitCode(root.Execute()))
+
+	root.SetArgs([]string{"remove", "a"})
+	assert.NoError(t, root.Execute())
+}
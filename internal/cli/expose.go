@@ -0,0 +1,164 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/michaeldyrynda/arbor/internal/logging"
+	"github.com/michaeldyrynda/arbor/internal/scaffold/steps"
+	"github.com/michaeldyrynda/arbor/internal/ui"
+	"github.com/michaeldyrynda/arbor/internal/utils"
+)
+
+var exposeCmd = &cobra.Command{
+	Use:   "expose [FOLDER]",
+	Short: "Share a worktree's site through a public tunnel",
+	Long: `Starts an expose or ngrok tunnel to the worktree's APP_URL, so a feature
+branch can be previewed by others.
+
+Arguments:
+  FOLDER  Name of the worktree folder (defaults to the current worktree)
+
+The public URL is printed, copied to the clipboard and recorded as public_url
+in the worktree's arbor.yaml. The tunnel runs until Ctrl-C; with --detach it
+keeps running in the background until arbor expose --stop.
+
+expose is used when installed, then ngrok; --via picks one. An expose step
+in arbor.yaml starts a background tunnel when a worktree is scaffolded.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pc, err := OpenProjectFromCWD(cmd)
+		if err != nil {
+			return err
+		}
+
+		target, err := pc.SelectWorktree(args, "Select a worktree")
+		if err != nil {
+			return err
+		}
+
+		if mustGetBool(cmd, "stop") {
+			stopped, err := steps.StopTunnel(pc.BarePath, target.Path)
+			if err != nil {
+				return err
+			}
+			if stopped {
+				ui.PrintSuccess("Stopped tunnel")
+			} else {
+				ui.PrintInfo("No tunnel running")
+			}
+			return nil
+		}
+
+		appURL := utils.ReadEnvFile(target.Path, ".env")["APP_URL"]
+		if appURL == "" {
+			return fmt.Errorf("APP_URL is not set in %s/.env", target.Path)
+		}
+		via := mustGetString(cmd, "via")
+
+		if mustGetBool(cmd, "detach") {
+			var publicURL string
+			err := ui.RunWithSpinner(fmt.Sprintf("Sharing %s...", appURL), func() error {
+				var err error
+				publicURL, err = steps.StartTunnel(pc.BarePath, target.Path, via, appURL)
+				return err
+			})
+			if err != nil {
+				return err
+			}
+			announcePublicURL(publicURL)
+			ui.PrintInfo("Run 'arbor expose --stop' to stop it")
+			return nil
+		}
+
+		return runTunnel(pc.BarePath, target.Path, via, appURL)
+	},
+}
+
+// runTunnel shares appURL until the tunnel exits or is interrupted, then
+// clears the recorded public URL
+func runTunnel(barePath, worktreePath, via, appURL string) error {
+	tool, err := steps.TunnelTool(via)
+	if err != nil {
+		return err
+	}
+	c, err := steps.TunnelCommand(tool, appURL)
+	if err != nil {
+		return err
+	}
+
+	c.Dir = worktreePath
+	c.Stderr = os.Stderr
+	stdout, err := c.StdoutPipe()
+	if err != nil {
+		return err
+	}
+
+	// Ctrl-C reaches the tunnel too; arbor waits for it to exit and tidies up
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	defer signal.Stop(interrupts)
+
+	if err := c.Start(); err != nil {
+		return fmt.Errorf("starting %s: %w", tool, err)
+	}
+
+	local, err := url.Parse(appURL)
+	if err != nil {
+		return fmt.Errorf("APP_URL %q is not a URL: %w", appURL, err)
+	}
+	publicURL, err := steps.WaitForTunnelURL(stdout, local.Hostname())
+	if err == nil {
+		if err := steps.RecordTunnel(barePath, worktreePath, tool, publicURL, c.Process.Pid); err != nil {
+			ui.PrintWarning(err.Error())
+		}
+		announcePublicURL(publicURL)
+		ui.PrintInfo("Press Ctrl-C to stop sharing")
+		// Drain the tunnel's output until it exits, so it never blocks
+		// writing to a full pipe
+		if _, copyErr := io.Copy(io.Discard, stdout); copyErr != nil {
+			logging.Verbosef("Reading %s output: %v", tool, copyErr)
+		}
+	}
+
+	waitErr := c.Wait()
+	if _, stopErr := steps.StopTunnel(barePath, worktreePath); stopErr != nil {
+		ui.PrintWarning(stopErr.Error())
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", tool, err)
+	}
+	if waitErr != nil && c.ProcessState.ExitCode() > 0 {
+		return fmt.Errorf("%s exited: %w", tool, waitErr)
+	}
+	return nil
+}
+
+// stopWorktreeTunnel stops the tunnel sharing a worktree that is being
+// removed, warning when it cannot
+func stopWorktreeTunnel(barePath, worktreePath string) {
+	if _, err := steps.StopTunnel(barePath, worktreePath); err != nil {
+		ui.PrintWarning(fmt.Sprintf("Could not stop tunnel for %s: %v", filepath.Base(worktreePath), err))
+	}
+}
+
+func announcePublicURL(publicURL string) {
+	ui.PrintSuccess(fmt.Sprintf("Sharing at %s", publicURL))
+	if err := ui.CopyToClipboard(publicURL); err == nil {
+		ui.PrintInfo("Copied to clipboard")
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(exposeCmd)
+
+	exposeCmd.Flags().String("via", "", "Tunnel to use: expose or ngrok")
+	exposeCmd.Flags().BoolP("detach", "d", false, "Keep the tunnel running in the background")
+	exposeCmd.Flags().Bool("stop", false, "Stop the worktree's background tunnel")
+}
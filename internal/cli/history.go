@@ -0,0 +1,111 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/michaeldyrynda/arbor/internal/audit"
+	"github.com/michaeldyrynda/arbor/internal/ui"
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Show what arbor has done to the project",
+	Long: `Shows the operations recorded in the project's audit log, newest first:
+worktrees created, removed and pruned, databases dropped by arbor db gc, and
+the initial clone. Each entry has the time, the user who ran it, the branch
+or database it acted on, and whether it succeeded.
+
+The log is kept in .bare/arbor/audit.log, one JSON object per line.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pc, err := OpenProjectFromCWD(cmd)
+		if err != nil {
+			return err
+		}
+
+		entries, err := audit.Read(pc.BarePath)
+		if err != nil {
+			return err
+		}
+		entries = filterHistory(entries, mustGetString(cmd, "branch"), mustGetInt(cmd, "limit"))
+
+		if mustGetBool(cmd, "json") {
+			return printHistoryJSON(os.Stdout, entries)
+		}
+		return printHistory(os.Stdout, entries)
+	},
+}
+
+// recordAudit adds an operation to the project's audit log. The operation
+// has already happened, so failing to record it is only a warning.
+func recordAudit(barePath string, entry audit.Entry, err error) {
+	if recordErr := audit.Record(barePath, entry, err); recordErr != nil {
+		ui.PrintWarning(fmt.Sprintf("Could not update the audit log: %v", recordErr))
+	}
+}
+
+// filterHistory returns the entries for branch, or every entry when branch
+// is empty, newest first and at most limit of them when limit is positive
+func filterHistory(entries []audit.Entry, branch string, limit int) []audit.Entry {
+	var filtered []audit.Entry
+	for i := len(entries) - 1; i >= 0; i-- {
+		if branch != "" && entries[i].Branch != branch {
+			continue
+		}
+		filtered = append(filtered, entries[i])
+		if limit > 0 && len(filtered) == limit {
+			break
+		}
+	}
+	return filtered
+}
+
+func printHistory(w io.Writer, entries []audit.Entry) error {
+	if len(entries) == 0 {
+		_, err := fmt.Fprintln(w, "No history recorded.")
+		return err
+	}
+
+	rows := make([][]string, len(entries))
+	for i, entry := range entries {
+		subject := entry.Branch
+		if entry.Target != "" {
+			subject = entry.Target
+		}
+		outcome := entry.Outcome
+		if entry.Error != "" {
+			outcome += ": " + strings.SplitN(entry.Error, "\n", 2)[0]
+		}
+		rows[i] = []string{entry.Time.Local().Format(time.DateTime), entry.User, entry.Operation, subject, outcome}
+	}
+
+	_, err := fmt.Fprintln(w, ui.RenderTable([]string{"TIME", "USER", "OPERATION", "SUBJECT", "OUTCOME"}, rows))
+	return err
+}
+
+func printHistoryJSON(w io.Writer, entries []audit.Entry) error {
+	if entries == nil {
+		entries = []audit.Entry{}
+	}
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+
+	historyCmd.Flags().IntP("limit", "n", 20, "Number of entries to show (0 for all)")
+	historyCmd.Flags().String("branch", "", "Only show entries for this branch")
+	historyCmd.Flags().Bool("json", false, "Output as JSON")
+}
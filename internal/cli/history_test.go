@@ -0,0 +1,54 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/michaeldyrynda/arbor/internal/audit"
+)
+
+func TestFilterHistory(t *testing.T) {
+	entries := []audit.Entry{
+		{Operation: audit.OperationWork, Branch: "feature/a"},
+		{Operation: audit.OperationWork, Branch: "feature/b"},
+		{Operation: audit.OperationRemove, Branch: "feature/a"},
+	}
+
+	filtered := filterHistory(entries, "", 0)
+	require.Len(t, filtered, 3)
+	assert.Equal(t, audit.OperationRemove, filtered[0].Operation)
+
+	filtered = filterHistory(entries, "feature/a", 0)
+	require.Len(t, filtered, 2)
+	assert.Equal(t, audit.OperationRemove, filtered[0].Operation)
+	assert.Equal(t, audit.OperationWork, filtered[1].Operation)
+
+	assert.Len(t, filterHistory(entries, "", 2), 2)
+}
+
+func TestPrintHistory(t *testing.T) {
+	barePath := t.TempDir()
+	recordAudit(barePath, audit.Entry{Operation: audit.OperationWork, Branch: "feature/login", User: "taylor"}, nil)
+	recordAudit(barePath, audit.Entry{Operation: audit.OperationDbDrop, Target: "app_swift_runner", User: "taylor"}, errors.New("access denied\nfull output"))
+
+	entries, err := audit.Read(barePath)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, printHistory(&buf, filterHistory(entries, "", 0)))
+	out := buf.String()
+	assert.Contains(t, out, "feature/login")
+	assert.Contains(t, out, "app_swift_runner")
+	assert.Contains(t, out, "failed: access denied")
+	assert.NotContains(t, out, "full output")
+	assert.Contains(t, out, entries[0].Time.Local().Format(time.DateTime)[:10])
+
+	buf.Reset()
+	require.NoError(t, printHistory(&buf, nil))
+	assert.Equal(t, "No history recorded.\n", buf.String())
+}
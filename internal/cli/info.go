@@ -0,0 +1,119 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/michaeldyrynda/arbor/internal/diskusage"
+	"github.com/michaeldyrynda/arbor/internal/git"
+	"github.com/michaeldyrynda/arbor/internal/ui"
+)
+
+var infoCmd = &cobra.Command{
+	Use:   "info [FOLDER]",
+	Short: "Show details of a worktree",
+	Long: `Shows a worktree's branch, status, last commit, how far it is ahead of and
+behind the default branch, whether it has uncommitted changes, its database
+suffix and the disk space it uses.
+
+Arguments:
+  FOLDER  Name of the worktree folder (defaults to the current worktree)
+
+The disk usage lists the build artifact directories, such as node_modules
+and vendor, biggest first. Sizes are cached for ten minutes; --refresh
+measures the worktree again.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pc, err := OpenProjectFromCWD(cmd)
+		if err != nil {
+			return err
+		}
+
+		target, err := pc.SelectWorktree(args, "Select a worktree")
+		if err != nil {
+			return err
+		}
+
+		columns := mustSelectListColumns("commit,age,active,ahead,behind,dirty,db,size")
+		row := loadListRows([]git.Worktree{*target}, listDetails(columns, nil), pc.DefaultBranch)[0]
+		if row.Err != nil {
+			return row.Err
+		}
+
+		cache := diskusage.OpenCache(pc.BarePath)
+		usage, err := cache.Usage(target.Path, mustGetBool(cmd, "refresh"))
+		if err != nil {
+			ui.PrintWarning(fmt.Sprintf("Could not measure disk usage: %v", err))
+		} else {
+			row.DiskUsage = &usage
+			if err := cache.Save(); err != nil {
+				ui.PrintWarning(fmt.Sprintf("Could not cache disk usage: %v", err))
+			}
+		}
+
+		if mustGetBool(cmd, "json") {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(listRowJSON(row, columns))
+		}
+		return printInfo(os.Stdout, row, pc.DefaultBranch)
+	},
+}
+
+func printInfo(w io.Writer, row listRow, defaultBranch string) error {
+	fields := [][2]string{
+		{"Worktree", filepath.Base(row.Path)},
+		{"Branch", row.Branch},
+		{"Path", row.Path},
+		{"Status", ui.WorktreeStatus(row.Worktree)},
+	}
+	if row.LastCommit != "" {
+		fields = append(fields, [2]string{"Commit", fmt.Sprintf("%s (%s)", row.LastCommit, ui.RelativeTime(row.LastCommitAt))})
+	}
+	if !row.LastActiveAt.IsZero() {
+		fields = append(fields, [2]string{"Active", ui.RelativeTime(row.LastActiveAt)})
+	}
+	if !row.IsMain {
+		fields = append(fields, [2]string{"Sync", fmt.Sprintf("%d ahead, %d behind %s", row.Ahead, row.Behind, defaultBranch)})
+	}
+	fields = append(fields, [2]string{"Dirty", yesNo(row.Dirty)})
+	if row.DBSuffix != "" {
+		fields = append(fields, [2]string{"Database", row.DBSuffix})
+	}
+	if row.DiskUsage != nil {
+		fields = append(fields, [2]string{"Size", diskusage.FormatBytes(row.DiskUsage.Bytes)})
+	}
+
+	for _, field := range fields {
+		if _, err := fmt.Fprintf(w, "%-9s %s\n", field[0], field[1]); err != nil {
+			return err
+		}
+	}
+
+	if row.DiskUsage == nil || len(row.DiskUsage.Artifacts) == 0 {
+		return nil
+	}
+
+	fmt.Fprintf(w, "\nBuild artifacts (%s):\n", diskusage.FormatBytes(row.DiskUsage.ArtifactBytes()))
+	for _, artifact := range row.DiskUsage.LargestArtifacts() {
+		if _, err := fmt.Fprintf(w, "  %-14s %s\n", artifact.Path, diskusage.FormatBytes(artifact.Bytes)); err != nil {
+			return err
+		}
+	}
+	if row.IsMerged && !row.IsMain {
+		fmt.Fprintln(w, "\nThe branch is merged; arbor prune --clean-artifacts removes them.")
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(infoCmd)
+
+	infoCmd.Flags().Bool("json", false, "Output as JSON")
+	infoCmd.Flags().Bool("refresh", false, "Measure disk usage again instead of using the cached size")
+}
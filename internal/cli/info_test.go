@@ -0,0 +1,54 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/michaeldyrynda/arbor/internal/diskusage"
+	"github.com/michaeldyrynda/arbor/internal/git"
+)
+
+func TestPrintInfo(t *testing.T) {
+	row := listRow{
+		Worktree:     git.Worktree{Path: "/repo/feature-login", Branch: "feature/login", IsMerged: true},
+		LastCommit:   "Add login form",
+		LastCommitAt: time.Now().Add(-2 * time.Hour),
+		LastActiveAt: time.Now().Add(-2 * time.Hour),
+		Ahead:        3,
+		DBSuffix:     "swift_fox",
+		DiskUsage: &diskusage.Usage{
+			Bytes:     5 * 1024 * 1024,
+			Artifacts: map[string]int64{"vendor": 1024 * 1024, "node_modules": 3 * 1024 * 1024},
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, printInfo(&buf, row, "main"))
+	out := buf.String()
+
+	assert.Contains(t, out, "Worktree  feature-login\n")
+	assert.Contains(t, out, "Commit    Add login form (2 hours ago)\n")
+	assert.Contains(t, out, "Sync      3 ahead, 0 behind main\n")
+	assert.Contains(t, out, "Database  swift_fox\n")
+	assert.Contains(t, out, "Size      5.0 MB\n")
+	assert.Contains(t, out, "Build artifacts (4.0 MB):\n  node_modules   3.0 MB\n  vendor         1.0 MB\n")
+	assert.Contains(t, out, "arbor prune --clean-artifacts")
+}
+
+func TestPrintInfo_MainWithoutArtifacts(t *testing.T) {
+	row := listRow{
+		Worktree:  git.Worktree{Path: "/repo/main", Branch: "main", IsMain: true},
+		DiskUsage: &diskusage.Usage{Bytes: 2048},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, printInfo(&buf, row, "main"))
+
+	assert.NotContains(t, buf.String(), "Sync")
+	assert.NotContains(t, buf.String(), "Build artifacts")
+	assert.Contains(t, buf.String(), "Size      2.0 KB\n")
+}
@@ -3,13 +3,16 @@ package cli
 import (
 	"fmt"
 	"path/filepath"
+	"time"
 
 	"github.com/spf13/cobra"
 
+	"github.com/michaeldyrynda/arbor/internal/audit"
 	"github.com/michaeldyrynda/arbor/internal/config"
+	arborerrors "github.com/michaeldyrynda/arbor/internal/errors"
+	"github.com/michaeldyrynda/arbor/internal/forge"
 	"github.com/michaeldyrynda/arbor/internal/git"
 	"github.com/michaeldyrynda/arbor/internal/presets"
-	"github.com/michaeldyrynda/arbor/internal/scaffold"
 	"github.com/michaeldyrynda/arbor/internal/ui"
 	"github.com/michaeldyrynda/arbor/internal/utils"
 )
@@ -20,15 +23,32 @@ var initCmd = &cobra.Command{
 	Long: `Initialises a new repository as a bare git repository with an initial worktree.
 
 Arguments:
-  REPO  Repository URL (supports both full URLs and short GH format)
-  PATH  Optional target directory (defaults to repository basename)`,
+  REPO  Repository URL, an owner/name shortname on the --provider host, or
+        the path of an existing local clone
+  PATH  Optional target directory (defaults to repository basename)
+
+GitHub, GitLab and Bitbucket are supported. URLs are matched to a provider by
+their host; shortnames use --provider, which defaults to github. The gh and
+glab CLIs are used to clone when installed, otherwise plain git is used.
+
+Given a local clone, such as "arbor init .", init converts it in place
+instead of cloning: .git becomes .bare and the clone's files, including
+ignored ones like .env, move into a worktree for the checked out branch,
+which is left as it was rather than scaffolded. The clone must have no
+uncommitted or untracked changes, operation in progress, submodules or
+linked worktrees. If another branch is checked out, a worktree for the
+default branch is created and scaffolded as usual.
+
+A failed scaffold is reported but leaves the repository ready. With --strict,
+or scaffold.strict in the global config, init then exits non-zero as arbor
+work does.`,
 	Args: cobra.MaximumNArgs(2),
-	RunE: func(cmd *cobra.Command, args []string) error {
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
 		var repo string
 
 		if len(args) > 0 {
 			repo = args[0]
-		} else if ui.IsInteractive() {
+		} else if ui.CanPrompt() {
 			input, err := ui.PromptRepoURL()
 			if err != nil {
 				return fmt.Errorf("prompting for repository: %w", err)
@@ -38,8 +58,15 @@ Arguments:
 			return fmt.Errorf("repository URL required (run interactively or provide repo as argument)")
 		}
 
+		localClone := git.IsWorkingClone(repo)
+		if localClone && len(args) > 1 {
+			return arborerrors.Wrap(arborerrors.ErrInvalidArguments, fmt.Errorf("%s is a local clone, which is converted in place; PATH cannot be given", repo))
+		}
+
 		path := ""
-		if len(args) > 1 {
+		if localClone {
+			path = repo
+		} else if len(args) > 1 {
 			path = args[1]
 		} else {
 			path = utils.SanitisePath(utils.ExtractRepoName(repo))
@@ -50,25 +77,37 @@ Arguments:
 			return fmt.Errorf("getting absolute path: %w", err)
 		}
 
-		ghAvailable := isCommandAvailable("gh")
-
+		start := time.Now()
 		barePath := filepath.Join(absPath, ".bare")
 
-		var cloneErr error
-		if ghAvailable {
-			ui.PrintInfo("Using gh CLI for repository clone")
-			cloneErr = ui.RunWithSpinner(fmt.Sprintf("Cloning %s...", repo), func() error {
-				return git.CloneRepoWithGH(repo, barePath)
-			})
+		// convertedBranch is the branch of the worktree a local clone's
+		// files were moved into
+		var convertedBranch string
+		if localClone {
+			convertedBranch, err = convertLocalClone(cmd, absPath)
+			if err != nil || convertedBranch == "" {
+				return err
+			}
 		} else {
-			cloneErr = ui.RunWithSpinner(fmt.Sprintf("Cloning %s...", repo), func() error {
-				return git.CloneRepo(repo, barePath)
+			provider, err := forge.ForRepo(repo, mustGetString(cmd, "provider"))
+			if err != nil {
+				return err
+			}
+
+			if cli := provider.CLI(); cli != "" && isCommandAvailable(cli) {
+				ui.PrintInfo(fmt.Sprintf("Using %s CLI for repository clone", cli))
+			}
+			cloneErr := ui.RunWithProgress(fmt.Sprintf("Cloning %s...", repo), func() error {
+				return provider.Clone(repo, barePath)
 			})
+			if cloneErr != nil {
+				return fmt.Errorf("cloning repository: %w", cloneErr)
+			}
+			ui.PrintSuccess(fmt.Sprintf("Cloned %s", repo))
 		}
-		if cloneErr != nil {
-			return fmt.Errorf("cloning repository: %w", cloneErr)
-		}
-		ui.PrintSuccess(fmt.Sprintf("Cloned %s", repo))
+		defer func() {
+			recordAudit(barePath, audit.Entry{Operation: audit.OperationInit, Path: absPath, Target: repo}, err)
+		}()
 
 		defaultBranch, err := git.GetDefaultBranch(barePath)
 		if err != nil {
@@ -77,15 +116,22 @@ Arguments:
 		ui.PrintSuccess(fmt.Sprintf("Default branch: %s", defaultBranch))
 
 		mainPath := filepath.Join(absPath, defaultBranch)
-		ui.PrintStep(fmt.Sprintf("Creating main worktree at %s", mainPath))
+		if convertedBranch == defaultBranch {
+			mainPath = filepath.Join(absPath, utils.SanitisePath(convertedBranch))
+		} else {
+			ui.PrintStep(fmt.Sprintf("Creating main worktree at %s", mainPath))
 
-		if err := git.CreateWorktree(barePath, mainPath, defaultBranch, ""); err != nil {
-			return fmt.Errorf("creating main worktree: %w", err)
+			if err := git.CreateWorktree(barePath, mainPath, defaultBranch, ""); err != nil {
+				return fmt.Errorf("creating main worktree: %w", err)
+			}
+			ui.PrintSuccess(fmt.Sprintf("Created main worktree at %s", mainPath))
 		}
-		ui.PrintSuccess(fmt.Sprintf("Created main worktree at %s", mainPath))
 
 		repoName := utils.SanitisePath(utils.ExtractRepoName(repo))
-		siteName := utils.SanitisePath(filepath.Base(path))
+		if localClone {
+			repoName = utils.SanitisePath(filepath.Base(absPath))
+		}
+		siteName := utils.SanitisePath(filepath.Base(absPath))
 
 		cfg := &config.Config{
 			DefaultBranch: defaultBranch,
@@ -95,8 +141,7 @@ Arguments:
 		preset := mustGetString(cmd, "preset")
 
 		presetManager := presets.NewManager()
-		scaffoldManager := scaffold.NewScaffoldManager()
-		presets.RegisterAllWithScaffold(scaffoldManager)
+		scaffoldManager := newScaffoldManager(newInteraction(cmd))
 
 		if preset != "" {
 			cfg.Preset = preset
@@ -118,23 +163,47 @@ Arguments:
 		if err := config.SaveProject(absPath, cfg); err != nil {
 			return fmt.Errorf("saving config: %w", err)
 		}
+		// Pick up the scaffold, cleanup and tools of an arbor.yaml that was
+		// already there
+		saved, err := config.LoadProject(absPath)
+		if err != nil {
+			return err
+		}
+		cfg = saved
+
+		if err := applyGlobalDefaults(cfg); err != nil {
+			return err
+		}
 
 		verbose := mustGetBool(cmd, "verbose")
 		skipScaffold := mustGetBool(cmd, "skip-scaffold")
+		if convertedBranch == defaultBranch && !skipScaffold {
+			// The clone's worktree is already set up; scaffolding it would
+			// give it new databases and rewrite its .env
+			ui.PrintInfo(fmt.Sprintf("Kept %s as it was (use 'arbor scaffold %s' to scaffold it)", mainPath, filepath.Base(mainPath)))
+			skipScaffold = true
+		}
 
 		if !skipScaffold && cfg.Preset != "" && verbose {
 			ui.PrintInfo(fmt.Sprintf("Running scaffold for preset: %s", cfg.Preset))
 		}
 
+		scaffolded := true
 		if !skipScaffold {
 			if err := scaffoldManager.RunScaffold(mainPath, defaultBranch, repoName, cfg.SiteName, cfg.Preset, cfg, false, verbose); err != nil {
 				ui.PrintErrorWithHint("Scaffold steps failed", err.Error())
+				scaffolded = false
 			}
-		} else {
+		} else if convertedBranch != defaultBranch {
 			ui.PrintInfo("Skipped scaffold (use 'arbor scaffold main' to scaffold manually)")
 		}
 
+		if !scaffolded && strictScaffold(cmd) {
+			return fmt.Errorf("scaffolding %s: %w", defaultBranch, arborerrors.ErrScaffoldStepFailed)
+		}
+
 		ui.PrintDone("Repository ready!")
+		ui.NotifyIfSlow(start, "arbor init", fmt.Sprintf("%s is ready", repoName))
 		ui.PrintInfo(fmt.Sprintf("cd %s", absPath))
 		ui.PrintInfo("arbor work feature/my-feature")
 
@@ -142,9 +211,50 @@ Arguments:
 	},
 }
 
+// convertLocalClone converts the regular clone at clonePath into an arbor
+// project in place, after checking nothing would be lost and, when it can
+// prompt, asking first. It returns the branch of the worktree the clone's
+// files moved into, or "" when the user cancelled.
+func convertLocalClone(cmd *cobra.Command, clonePath string) (string, error) {
+	branch, err := git.CheckConvertible(clonePath)
+	if err != nil {
+		return "", arborerrors.Wrap(arborerrors.ErrInvalidArguments, err)
+	}
+
+	folder := utils.SanitisePath(branch)
+	if ui.ShouldPrompt(cmd, false) {
+		confirmed, err := ui.Confirm(fmt.Sprintf("Convert %s to an arbor project, moving its files into %s?", clonePath, filepath.Join(clonePath, folder)))
+		if err != nil {
+			return "", fmt.Errorf("confirmation: %w", err)
+		}
+		if !confirmed {
+			ui.PrintInfo("Cancelled.")
+			return "", nil
+		}
+	}
+
+	if _, err := git.ConvertClone(clonePath, branch, folder); err != nil {
+		return "", fmt.Errorf("converting %s: %w", clonePath, err)
+	}
+	ui.PrintSuccess(fmt.Sprintf("Converted %s, with %s checked out at %s", clonePath, branch, filepath.Join(clonePath, folder)))
+	return branch, nil
+}
+
+// strictScaffold reports whether a failed scaffold fails the command: as
+// --strict says, or as the global scaffold.strict does when it is not given
+func strictScaffold(cmd *cobra.Command) bool {
+	if cmd.Flags().Changed("strict") {
+		return mustGetBool(cmd, "strict")
+	}
+	global, err := config.LoadGlobal()
+	return err == nil && global.Scaffold.Strict
+}
+
 func init() {
 	rootCmd.AddCommand(initCmd)
 
 	initCmd.Flags().String("preset", "", "Project preset (laravel, php)")
 	initCmd.Flags().Bool("skip-scaffold", false, "Skip scaffold steps during init")
+	initCmd.Flags().Bool("strict", false, "Exit non-zero when scaffold steps fail (default from scaffold.strict)")
+	initCmd.Flags().String("provider", forge.GitHubProvider, "Host for owner/name shortnames (github, gitlab, bitbucket)")
 }
@@ -1,12 +1,17 @@
 package cli
 
 import (
+	"os"
 	"os/exec"
 	"path/filepath"
 	"testing"
 
+	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
+	"github.com/michaeldyrynda/arbor/internal/config"
+	arborerrors "github.com/michaeldyrynda/arbor/internal/errors"
 	"github.com/michaeldyrynda/arbor/internal/utils"
 )
 
@@ -145,3 +150,82 @@ func TestInitShortFormatShouldUseGhClone(t *testing.T) {
 	assert.DirExists(t, barePath, "gh repo clone --bare should succeed")
 	assert.DirExists(t, filepath.Join(barePath, "refs"), "bare repo should have refs directory")
 }
+
+func TestStrictScaffold(t *testing.T) {
+	newCmd := func() *cobra.Command {
+		cmd := &cobra.Command{}
+		cmd.Flags().Bool("strict", false, "")
+		return cmd
+	}
+
+	t.Run("defaults to lenient", func(t *testing.T) {
+		t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+		assert.False(t, strictScaffold(newCmd()))
+	})
+
+	t.Run("follows the global config", func(t *testing.T) {
+		t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+		require.NoError(t, config.CreateGlobalConfig(&config.GlobalConfig{Scaffold: config.GlobalScaffoldConfig{Strict: true}}))
+		assert.True(t, strictScaffold(newCmd()))
+
+		cmd := newCmd()
+		require.NoError(t, cmd.Flags().Set("strict", "false"))
+		assert.False(t, strictScaffold(cmd), "the flag overrides the global config")
+	})
+
+	t.Run("flag turns it on", func(t *testing.T) {
+		t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+		cmd := newCmd()
+		require.NoError(t, cmd.Flags().Set("strict", "true"))
+		assert.True(t, strictScaffold(cmd))
+	})
+}
+
+func TestInitConvertsLocalClone(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	clonePath := filepath.Join(t.TempDir(), "myapp")
+	runGitCmd(t, filepath.Dir(clonePath), "init", "-b", "main", clonePath)
+	require.NoError(t, os.WriteFile(filepath.Join(clonePath, ".gitignore"), []byte(".env\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(clonePath, "README.md"), []byte("test"), 0644))
+	runGitCmd(t, clonePath, "add", ".")
+	runGitCmd(t, clonePath, "-c", "user.email=test@example.com", "-c", "user.name=Test User", "commit", "-m", "Initial commit")
+	require.NoError(t, os.WriteFile(filepath.Join(clonePath, ".env"), []byte("DB_DATABASE=myapp\n"), 0644))
+
+	newInitCmd := func() *cobra.Command {
+		cmd := &cobra.Command{}
+		cmd.Flags().String("provider", "github", "")
+		cmd.Flags().String("preset", "", "")
+		cmd.Flags().Bool("verbose", false, "")
+		cmd.Flags().Bool("skip-scaffold", false, "")
+		cmd.Flags().Bool("strict", false, "")
+		return cmd
+	}
+
+	t.Run("refuses a path", func(t *testing.T) {
+		err := initCmd.RunE(newInitCmd(), []string{clonePath, filepath.Join(t.TempDir(), "elsewhere")})
+		assert.ErrorIs(t, err, arborerrors.ErrInvalidArguments)
+	})
+
+	t.Run("refuses uncommitted changes", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(filepath.Join(clonePath, "README.md"), []byte("changed"), 0644))
+		defer runGitCmd(t, clonePath, "checkout", "README.md")
+
+		err := initCmd.RunE(newInitCmd(), []string{clonePath})
+		assert.ErrorIs(t, err, arborerrors.ErrInvalidArguments)
+		assert.DirExists(t, filepath.Join(clonePath, ".git"))
+	})
+
+	t.Run("converts in place", func(t *testing.T) {
+		require.NoError(t, initCmd.RunE(newInitCmd(), []string{clonePath}))
+
+		mainPath := filepath.Join(clonePath, "main")
+		assert.DirExists(t, filepath.Join(clonePath, ".bare"))
+		assert.FileExists(t, filepath.Join(mainPath, "README.md"))
+		assert.FileExists(t, filepath.Join(clonePath, "arbor.yaml"))
+
+		env, err := os.ReadFile(filepath.Join(mainPath, ".env"))
+		require.NoError(t, err)
+		assert.Equal(t, "DB_DATABASE=myapp\n", string(env), "the converted worktree is not scaffolded")
+	})
+}
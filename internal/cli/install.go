@@ -5,12 +5,12 @@ import (
 	"os"
 	"os/exec"
 	"runtime"
-	"strings"
 
 	"github.com/spf13/cobra"
 
 	"github.com/michaeldyrynda/arbor/internal/config"
 	"github.com/michaeldyrynda/arbor/internal/ui"
+	"github.com/michaeldyrynda/arbor/internal/utils"
 )
 
 var installCmd = &cobra.Command{
@@ -86,7 +86,7 @@ func detectTool(name string) (string, string, error) {
 		return "", "", fmt.Errorf("not found")
 	}
 
-	version, err := getToolVersion(name, path)
+	version, err := utils.ToolVersion(name, path)
 	if err != nil {
 		version = "unknown"
 	}
@@ -94,84 +94,6 @@ func detectTool(name string) (string, string, error) {
 	return path, version, nil
 }
 
-func getToolVersion(name, path string) (string, error) {
-	var cmd *exec.Cmd
-
-	switch name {
-	case "gh":
-		cmd = exec.Command(path, "version")
-	case "php":
-		cmd = exec.Command(path, "-v")
-	case "composer":
-		cmd = exec.Command(path, "--version")
-	case "npm":
-		cmd = exec.Command(path, "--version")
-	case "herd":
-		cmd = exec.Command(path, "version")
-	default:
-		return "", fmt.Errorf("unknown tool")
-	}
-
-	output, err := cmd.Output()
-	if err != nil {
-		return "", err
-	}
-
-	return extractVersion(string(output), name), nil
-}
-
-func extractVersion(output, tool string) string {
-	lines := strings.Split(strings.TrimRight(output, "\n"), "\n")
-	switch tool {
-	case "gh":
-		for _, line := range lines {
-			if strings.Contains(line, "gh version") {
-				parts := strings.Split(line, " ")
-				if len(parts) >= 3 {
-					return strings.TrimPrefix(parts[2], "v")
-				}
-			}
-		}
-	case "php":
-		for _, line := range lines {
-			if strings.Contains(line, "PHP") {
-				parts := strings.Split(line, " ")
-				if len(parts) >= 2 {
-					return strings.TrimPrefix(parts[1], "v")
-				}
-			}
-		}
-	case "composer":
-		for _, line := range lines {
-			if strings.Contains(line, "Composer version") {
-				parts := strings.Split(line, " ")
-				if len(parts) >= 3 {
-					return strings.TrimPrefix(parts[2], "v")
-				}
-			}
-		}
-	case "npm":
-		for _, line := range lines {
-			if strings.Contains(line, ".") {
-				return strings.TrimSpace(line)
-			}
-		}
-	case "herd":
-		for _, line := range lines {
-			if strings.Contains(line, "version") || strings.Contains(line, "Herd") {
-				parts := strings.Fields(line)
-				for _, part := range parts {
-					if strings.HasPrefix(part, "v") && len(part) > 1 {
-						return strings.TrimPrefix(part, "v")
-					}
-				}
-			}
-		}
-	}
-
-	return ""
-}
-
 func init() {
 	rootCmd.AddCommand(installCmd)
 }
@@ -5,9 +5,17 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/spf13/cobra"
 
+	"github.com/michaeldyrynda/arbor/internal/config"
+	"github.com/michaeldyrynda/arbor/internal/diskusage"
+	"github.com/michaeldyrynda/arbor/internal/forge"
 	"github.com/michaeldyrynda/arbor/internal/git"
 	"github.com/michaeldyrynda/arbor/internal/ui"
 )
@@ -18,18 +26,89 @@ var listCmd = &cobra.Command{
 	Long: `List all worktrees in the repository with their status.
 
 Shows worktrees with merge status, current worktree indicator,
-and main branch highlighting.`,
+and main branch highlighting.
+
+Columns:
+  worktree  Worktree folder name
+  branch    Checked out branch
+  status    Current, main, merged or active
+  path      Absolute worktree path
+  commit    Subject of the last commit
+  age       Time since the last commit
+  active    Time since the last commit or change to an uncommitted file
+  ahead     Commits not yet on the default branch
+  behind    Commits on the default branch not yet in the worktree
+  dirty     Whether there are uncommitted changes
+  db        Database suffix recorded for the worktree
+  pr        Open pull request number, from gh
+  review    Pull request review state
+  checks    Pull request checks: passing, failing or pending
+  size      Disk space used, with the largest build artifact directory
+
+--all shows every column except the pull request ones, which --prs adds,
+and size, which --size adds.
+--columns picks them, e.g. --columns worktree,branch,age.
+
+Filters:
+  --filter merged        Branches merged into the default branch
+  --filter unmerged      Branches not yet merged
+  --filter dirty         Worktrees with uncommitted changes
+  --filter stale:<age>   No commits or file changes for at least this long
+  --stale <age>          Same as --filter stale:<age>, adding the active column
+  --match <glob>         Branch or folder name matches, e.g. 'feature/*'
+
+Ages are days, weeks or hours, such as 30d, 2w or 12h; a bare number is
+days. The main worktree is never stale. Repeated filters must all match.
+--group splits the table into main, active and merged sections.
+
+Sizes add up every file in the worktree, like du. node_modules, vendor,
+public/build, .next, .nuxt and dist are counted as build artifacts when git
+ignores them. Sizes are cached in the bare repository for ten minutes.
+
+--porcelain=v2 prints NUL-terminated key=value fields, with an empty field
+ending each record. The first record is arbor-porcelain=v2; each worktree
+record has path, folder, branch, main, current and merged, plus commit, age,
+active, ahead, behind, dirty, db, pr, review, checks and size for the
+requested columns.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		pc, err := OpenProjectFromCWD()
+		pc, err := OpenProjectFromCWD(cmd)
 		if err != nil {
 			return err
 		}
 
 		jsonOutput := mustGetBool(cmd, "json")
-		porcelain := mustGetBool(cmd, "porcelain")
+		porcelain := mustGetString(cmd, "porcelain")
+		if porcelain != "" && porcelain != porcelainV1 && porcelain != porcelainV2 {
+			return fmt.Errorf("unknown porcelain format %q (use %s or %s)", porcelain, porcelainV1, porcelainV2)
+		}
 		sortBy := mustGetString(cmd, "sort-by")
 		reverse := mustGetBool(cmd, "reverse")
 
+		columns, err := selectListColumns(mustGetString(cmd, "columns"), mustGetBool(cmd, "all"))
+		if err != nil {
+			return err
+		}
+
+		if mustGetBool(cmd, "prs") {
+			columns = withColumns(columns, prColumns...)
+		}
+		if mustGetBool(cmd, "size") {
+			columns = withColumns(columns, "size")
+		}
+
+		filterSpecs := mustGetStringSlice(cmd, "filter")
+		if stale := mustGetString(cmd, "stale"); stale != "" {
+			filterSpecs = append(filterSpecs, "stale:"+stale)
+			columns = withColumns(columns, "active")
+		}
+		filters, err := parseListFilters(filterSpecs)
+		if err != nil {
+			return err
+		}
+
+		match := mustGetString(cmd, "match")
+		group := mustGetBool(cmd, "group")
+
 		worktrees, err := git.ListWorktreesDetailed(pc.BarePath, pc.CWD, pc.DefaultBranch)
 		if err != nil {
 			return fmt.Errorf("listing worktrees: %w", err)
@@ -37,18 +116,479 @@ and main branch highlighting.`,
 
 		worktrees = git.SortWorktrees(worktrees, sortBy, reverse)
 
+		worktrees, err = matchWorktrees(worktrees, match)
+		if err != nil {
+			return err
+		}
+
+		needs := listDetails(columns, filters)
+		rows := loadListRows(worktrees, needs, pc.DefaultBranch)
+		warnUnreadableRows(rows)
+		if needs[detailPR] {
+			if err := attachPullRequests(rows, pc.BarePath); err != nil {
+				ui.PrintWarning(fmt.Sprintf("Could not load pull requests: %v", err))
+			}
+		}
+		if needs[detailSize] {
+			attachDiskUsage(rows, pc.BarePath)
+		}
+		rows = filterListRows(rows, filters)
+
+		if porcelain == porcelainV2 {
+			return printPorcelainV2(os.Stdout, rows, columns)
+		}
+
+		if columns == nil {
+			worktrees = rowWorktrees(rows)
+			switch {
+			case jsonOutput:
+				return printJSON(os.Stdout, worktrees)
+			case porcelain != "":
+				return printPorcelain(os.Stdout, worktrees)
+			case group:
+				return printListGroups(os.Stdout, rows, defaultListColumns())
+			default:
+				return printTable(os.Stdout, worktrees)
+			}
+		}
+
 		if jsonOutput {
-			return printJSON(os.Stdout, worktrees)
+			return printListJSON(os.Stdout, rows, columns)
 		}
 
-		if porcelain {
-			return printPorcelain(os.Stdout, worktrees)
+		if porcelain != "" {
+			return printListPorcelain(os.Stdout, rows, columns)
 		}
 
-		return printTable(os.Stdout, worktrees)
+		if group {
+			return printListGroups(os.Stdout, rows, columns)
+		}
+
+		return printListTable(os.Stdout, rows, columns)
 	},
 }
 
+// listRow is a worktree along with the details shown by the extra columns
+type listRow struct {
+	git.Worktree
+	LastCommit   string
+	LastCommitAt time.Time
+	// LastActiveAt is the later of LastCommitAt and the last change to an
+	// uncommitted file
+	LastActiveAt time.Time
+	Ahead        int
+	Behind       int
+	Dirty        bool
+	DBSuffix     string
+	PullRequest  *forge.PullRequest
+	DiskUsage    *diskusage.Usage
+	// Err is why the worktree's details could not be read, such as its
+	// directory having been deleted
+	Err error
+}
+
+// unreadable reports whether column shows a detail that could not be read
+// for the row
+func (r listRow) unreadable(column listColumn) bool {
+	return r.Err != nil && column.Detail != detailNone && column.Detail != detailPR && column.Detail != detailSize
+}
+
+// listValue returns the column's value for row, or ? when it could not be
+// read
+func listValue(column listColumn, row listRow) string {
+	if row.unreadable(column) {
+		return "?"
+	}
+	return column.Value(row)
+}
+
+// listDetail names the work needed to fill a column
+type listDetail int
+
+const (
+	detailNone listDetail = iota
+	detailCommit
+	detailActivity
+	detailSync
+	detailDirty
+	detailDB
+	detailPR
+	detailSize
+)
+
+// listColumn is a column arbor list can show
+type listColumn struct {
+	Name     string
+	Header   string
+	Truncate bool
+	Detail   listDetail
+	Value    func(row listRow) string
+}
+
+var listColumns = []listColumn{
+	{Name: "worktree", Header: "WORKTREE", Truncate: true, Value: func(r listRow) string { return filepath.Base(r.Path) }},
+	{Name: "branch", Header: "BRANCH", Truncate: true, Value: func(r listRow) string { return r.Branch }},
+	{Name: "status", Header: "STATUS", Value: func(r listRow) string { return ui.WorktreeStatus(r.Worktree) }},
+	{Name: "path", Header: "PATH", Truncate: true, Value: func(r listRow) string { return r.Path }},
+	{Name: "commit", Header: "COMMIT", Truncate: true, Detail: detailCommit, Value: func(r listRow) string { return r.LastCommit }},
+	{Name: "age", Header: "AGE", Detail: detailCommit, Value: func(r listRow) string { return ui.RelativeTime(r.LastCommitAt) }},
+	{Name: "active", Header: "ACTIVE", Detail: detailActivity, Value: func(r listRow) string { return ui.RelativeTime(r.LastActiveAt) }},
+	{Name: "ahead", Header: "AHEAD", Detail: detailSync, Value: func(r listRow) string { return strconv.Itoa(r.Ahead) }},
+	{Name: "behind", Header: "BEHIND", Detail: detailSync, Value: func(r listRow) string { return strconv.Itoa(r.Behind) }},
+	{Name: "dirty", Header: "DIRTY", Detail: detailDirty, Value: func(r listRow) string { return yesNo(r.Dirty) }},
+	{Name: "db", Header: "DB", Detail: detailDB, Value: func(r listRow) string { return r.DBSuffix }},
+	{Name: "pr", Header: "PR", Detail: detailPR, Value: func(r listRow) string { return pullRequestNumber(r.PullRequest) }},
+	{Name: "review", Header: "REVIEW", Detail: detailPR, Value: func(r listRow) string { return pullRequestField(r.PullRequest, "review") }},
+	{Name: "checks", Header: "CHECKS", Detail: detailPR, Value: func(r listRow) string { return pullRequestField(r.PullRequest, "checks") }},
+	{Name: "size", Header: "SIZE", Detail: detailSize, Value: func(r listRow) string { return formatDiskUsage(r.DiskUsage) }},
+}
+
+// prColumns are the columns added by --prs
+var prColumns = []string{"pr", "review", "checks"}
+
+func pullRequestNumber(pr *forge.PullRequest) string {
+	if pr == nil {
+		return ""
+	}
+	if pr.Draft {
+		return fmt.Sprintf("#%d (draft)", pr.Number)
+	}
+	return fmt.Sprintf("#%d", pr.Number)
+}
+
+func pullRequestField(pr *forge.PullRequest, field string) string {
+	if pr == nil {
+		return ""
+	}
+	if field == "review" {
+		return strings.ReplaceAll(pr.Review, "_", " ")
+	}
+	return pr.Checks
+}
+
+// selectListColumns returns the columns named in spec, every local column
+// for --all, or nil for the default output. Pull request columns ask GitHub
+// and size walks every worktree, so --all leaves them to --prs and --size.
+func selectListColumns(spec string, all bool) ([]listColumn, error) {
+	if spec == "" {
+		if all {
+			var columns []listColumn
+			for _, column := range listColumns {
+				if column.Detail != detailPR && column.Detail != detailSize {
+					columns = append(columns, column)
+				}
+			}
+			return columns, nil
+		}
+		return nil, nil
+	}
+
+	var selected []listColumn
+	for _, name := range strings.Split(spec, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		found := false
+		for _, column := range listColumns {
+			if column.Name == name {
+				selected = append(selected, column)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown column %q (available: %s)", name, listColumnNames())
+		}
+	}
+
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("--columns needs at least one column (available: %s)", listColumnNames())
+	}
+	return selected, nil
+}
+
+func listColumnNames() string {
+	names := make([]string, len(listColumns))
+	for i, column := range listColumns {
+		names[i] = column.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+// defaultListColumns are the columns arbor list shows without --columns
+func defaultListColumns() []listColumn {
+	return mustSelectListColumns("worktree,branch,status")
+}
+
+// mustSelectListColumns returns the columns arbor itself asks for by name,
+// which are always known
+func mustSelectListColumns(spec string) []listColumn {
+	columns, err := selectListColumns(spec, false)
+	if err != nil {
+		panic(fmt.Sprintf("programming error: %v", err))
+	}
+	return columns
+}
+
+// listFilter keeps the rows matching one --filter value
+type listFilter struct {
+	Detail listDetail
+	Keep   func(row listRow) bool
+}
+
+// parseListFilters parses --filter values: merged, unmerged, dirty and
+// stale:<age>. A row must match every filter to be listed.
+func parseListFilters(specs []string) ([]listFilter, error) {
+	var filters []listFilter
+	for _, spec := range specs {
+		spec = strings.ToLower(strings.TrimSpace(spec))
+		name, arg, _ := strings.Cut(spec, ":")
+
+		switch name {
+		case "merged":
+			filters = append(filters, listFilter{Keep: func(r listRow) bool { return r.IsMerged && !r.IsMain }})
+		case "unmerged":
+			filters = append(filters, listFilter{Keep: func(r listRow) bool { return !r.IsMerged && !r.IsMain }})
+		case "dirty":
+			filters = append(filters, listFilter{Detail: detailDirty, Keep: func(r listRow) bool { return r.Dirty }})
+		case "stale":
+			age, err := parseStaleAge(arg)
+			if err != nil {
+				return nil, fmt.Errorf("invalid filter %q: %w", spec, err)
+			}
+			filters = append(filters, listFilter{Detail: detailActivity, Keep: func(r listRow) bool {
+				return !r.IsMain && isStale(r.LastActiveAt, age)
+			}})
+		default:
+			return nil, fmt.Errorf("unknown filter %q (use merged, unmerged, dirty or stale:<age>)", spec)
+		}
+	}
+	return filters, nil
+}
+
+func filterListRows(rows []listRow, filters []listFilter) []listRow {
+	if len(filters) == 0 {
+		return rows
+	}
+
+	var kept []listRow
+	for _, row := range rows {
+		keep := true
+		for _, filter := range filters {
+			if !filter.Keep(row) {
+				keep = false
+				break
+			}
+		}
+		if keep {
+			kept = append(kept, row)
+		}
+	}
+	return kept
+}
+
+// matchWorktrees keeps the worktrees whose branch or folder name matches
+// the glob pattern, such as "feature/*"
+func matchWorktrees(worktrees []git.Worktree, pattern string) ([]git.Worktree, error) {
+	if pattern == "" {
+		return worktrees, nil
+	}
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid --match pattern %q: %w", pattern, err)
+	}
+
+	var matched []git.Worktree
+	for _, wt := range worktrees {
+		branchMatch, err := filepath.Match(pattern, wt.Branch)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --match pattern %q: %w", pattern, err)
+		}
+		folderMatch, err := filepath.Match(pattern, filepath.Base(wt.Path))
+		if err != nil {
+			return nil, fmt.Errorf("invalid --match pattern %q: %w", pattern, err)
+		}
+		if branchMatch || folderMatch {
+			matched = append(matched, wt)
+		}
+	}
+	return matched, nil
+}
+
+// listDetails returns the details the columns and filters need gathered
+func listDetails(columns []listColumn, filters []listFilter) map[listDetail]bool {
+	needs := make(map[listDetail]bool)
+	for _, column := range columns {
+		needs[column.Detail] = true
+	}
+	for _, filter := range filters {
+		needs[filter.Detail] = true
+	}
+	return needs
+}
+
+// loadListRows gathers the requested details for each worktree concurrently.
+// A worktree whose details cannot be read keeps its row, with the error in
+// Err.
+func loadListRows(worktrees []git.Worktree, needs map[listDetail]bool, defaultBranch string) []listRow {
+	rows := make([]listRow, len(worktrees))
+	var wg sync.WaitGroup
+	for i, wt := range worktrees {
+		rows[i] = listRow{Worktree: wt}
+
+		wg.Add(1)
+		go func(row *listRow) {
+			defer wg.Done()
+			row.Err = loadListRow(row, needs, defaultBranch)
+		}(&rows[i])
+	}
+	wg.Wait()
+
+	return rows
+}
+
+// warnUnreadableRows prints a warning for each row whose details could not
+// be read
+func warnUnreadableRows(rows []listRow) {
+	for _, row := range rows {
+		if row.Err != nil {
+			ui.PrintWarning(fmt.Sprintf("Could not read %s: %v", filepath.Base(row.Path), row.Err))
+		}
+	}
+}
+
+// loadListRow fills in the requested details of one row
+func loadListRow(row *listRow, needs map[listDetail]bool, defaultBranch string) error {
+	var err error
+	if needs[detailCommit] || needs[detailActivity] {
+		if row.LastCommit, row.LastCommitAt, err = git.LastCommit(row.Path); err != nil {
+			return fmt.Errorf("reading last commit of %s: %w", row.Path, err)
+		}
+	}
+	if needs[detailActivity] {
+		row.LastActiveAt = lastActive(row.LastCommitAt, row.Path)
+	}
+	if needs[detailSync] && !row.IsMain {
+		if row.Ahead, row.Behind, err = git.AheadBehind(row.Path, defaultBranch); err != nil {
+			return fmt.Errorf("comparing %s with %s: %w", row.Path, defaultBranch, err)
+		}
+	}
+	if needs[detailDirty] {
+		if row.Dirty, err = git.IsDirty(row.Path); err != nil {
+			return fmt.Errorf("checking %s for changes: %w", row.Path, err)
+		}
+	}
+	if needs[detailDB] {
+		if wtConfig, err := config.ReadWorktreeConfig(row.Path); err == nil {
+			row.DBSuffix = wtConfig.DbSuffix
+		}
+	}
+	return nil
+}
+
+// withColumns adds the named columns to the selected columns, or to the
+// default ones, unless they are already shown
+func withColumns(columns []listColumn, names ...string) []listColumn {
+	if columns == nil {
+		columns = defaultListColumns()
+	}
+
+	result := append([]listColumn(nil), columns...)
+	for _, name := range names {
+		found := false
+		for _, column := range result {
+			if column.Name == name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			result = append(result, mustSelectListColumns(name)...)
+		}
+	}
+	return result
+}
+
+// attachPullRequests fills in the open pull request for each row's branch
+func attachPullRequests(rows []listRow, barePath string) error {
+	provider, err := forge.Detect(barePath)
+	if err != nil {
+		return err
+	}
+
+	prs, err := forge.OpenPullRequests(barePath, provider)
+	if err != nil {
+		return err
+	}
+
+	for i := range rows {
+		if pr, ok := prs[rows[i].Branch]; ok {
+			rows[i].PullRequest = &pr
+		}
+	}
+	return nil
+}
+
+// attachDiskUsage fills in each row's disk usage, measuring the worktrees
+// without a recent cached measurement concurrently
+func attachDiskUsage(rows []listRow, barePath string) {
+	cache := diskusage.OpenCache(barePath)
+
+	var wg sync.WaitGroup
+	for i := range rows {
+		wg.Add(1)
+		go func(row *listRow) {
+			defer wg.Done()
+			if usage, err := cache.Usage(row.Path, false); err == nil {
+				row.DiskUsage = &usage
+			}
+		}(&rows[i])
+	}
+	wg.Wait()
+
+	if err := cache.Save(); err != nil {
+		ui.PrintWarning(fmt.Sprintf("Could not cache disk usage: %v", err))
+	}
+}
+
+// formatDiskUsage shows a worktree's size and its largest artifact
+// directory, e.g. "1.2 GB (node_modules 900 MB)"
+func formatDiskUsage(usage *diskusage.Usage) string {
+	if usage == nil {
+		return ""
+	}
+	size := diskusage.FormatBytes(usage.Bytes)
+	if largest := usage.LargestArtifacts(); len(largest) > 0 {
+		size += fmt.Sprintf(" (%s %s)", largest[0].Path, diskusage.FormatBytes(largest[0].Bytes))
+	}
+	return size
+}
+
+// diskUsageBytes formats the size for porcelain output, empty when it is
+// unknown
+func diskUsageBytes(usage *diskusage.Usage) string {
+	if usage == nil {
+		return ""
+	}
+	return strconv.FormatInt(usage.Bytes, 10)
+}
+
+func rowWorktrees(rows []listRow) []git.Worktree {
+	worktrees := make([]git.Worktree, len(rows))
+	for i, row := range rows {
+		worktrees[i] = row.Worktree
+	}
+	return worktrees
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
 func printTable(w io.Writer, worktrees []git.Worktree) error {
 	if len(worktrees) == 0 {
 		fmt.Fprintln(w, "No worktrees found.")
@@ -59,31 +599,158 @@ func printTable(w io.Writer, worktrees []git.Worktree) error {
 	return err
 }
 
-func printJSON(w io.Writer, worktrees []git.Worktree) error {
-	type worktreeJSON struct {
-		Path      string `json:"path"`
-		Branch    string `json:"branch"`
-		IsMain    bool   `json:"isMain"`
-		IsCurrent bool   `json:"isCurrent"`
-		IsMerged  bool   `json:"isMerged"`
+func printListTable(w io.Writer, rows []listRow, columns []listColumn) error {
+	if len(rows) == 0 {
+		fmt.Fprintln(w, "No worktrees found.")
+		return nil
 	}
 
-	jsonWorktrees := make([]worktreeJSON, len(worktrees))
-	for i, wt := range worktrees {
-		jsonWorktrees[i] = worktreeJSON{
-			Path:      wt.Path,
-			Branch:    wt.Branch,
-			IsMain:    wt.IsMain,
-			IsCurrent: wt.IsCurrent,
-			IsMerged:  wt.IsMerged,
+	_, err := fmt.Fprintln(w, ui.RenderWorktreeColumns(rowWorktrees(rows), tableColumns(columns), columnValues(rows, columns)))
+	return err
+}
+
+// printListGroups renders the rows in sections for the main worktree,
+// active branches and merged branches
+func printListGroups(w io.Writer, rows []listRow, columns []listColumn) error {
+	if len(rows) == 0 {
+		fmt.Fprintln(w, "No worktrees found.")
+		return nil
+	}
+
+	sections := []struct {
+		title string
+		keep  func(git.Worktree) bool
+	}{
+		{"Main", func(wt git.Worktree) bool { return wt.IsMain }},
+		{"Active", func(wt git.Worktree) bool { return !wt.IsMain && !wt.IsMerged }},
+		{"Merged", func(wt git.Worktree) bool { return !wt.IsMain && wt.IsMerged }},
+	}
+
+	var groups []ui.WorktreeGroup
+	for _, section := range sections {
+		var sectionRows []listRow
+		for _, row := range rows {
+			if section.keep(row.Worktree) {
+				sectionRows = append(sectionRows, row)
+			}
+		}
+		groups = append(groups, ui.WorktreeGroup{
+			Title:     section.title,
+			Worktrees: rowWorktrees(sectionRows),
+			Rows:      columnValues(sectionRows, columns),
+		})
+	}
+
+	_, err := fmt.Fprintln(w, ui.RenderWorktreeGroups(groups, tableColumns(columns)))
+	return err
+}
+
+func tableColumns(columns []listColumn) []ui.TableColumn {
+	result := make([]ui.TableColumn, len(columns))
+	for i, column := range columns {
+		result[i] = ui.TableColumn{Header: column.Header, Truncate: column.Truncate}
+	}
+	return result
+}
+
+func columnValues(rows []listRow, columns []listColumn) [][]string {
+	values := make([][]string, len(rows))
+	for i, row := range rows {
+		values[i] = make([]string, len(columns))
+		for j, column := range columns {
+			values[i][j] = listValue(column, row)
 		}
 	}
+	return values
+}
+
+type worktreeJSON struct {
+	Path         string             `json:"path"`
+	Branch       string             `json:"branch"`
+	IsMain       bool               `json:"isMain"`
+	IsCurrent    bool               `json:"isCurrent"`
+	IsMerged     bool               `json:"isMerged"`
+	LastCommit   *string            `json:"lastCommit,omitempty"`
+	LastCommitAt *time.Time         `json:"lastCommitAt,omitempty"`
+	LastActiveAt *time.Time         `json:"lastActiveAt,omitempty"`
+	Ahead        *int               `json:"ahead,omitempty"`
+	Behind       *int               `json:"behind,omitempty"`
+	Dirty        *bool              `json:"dirty,omitempty"`
+	DBSuffix     *string            `json:"dbSuffix,omitempty"`
+	PullRequest  *forge.PullRequest `json:"pullRequest,omitempty"`
+	Size         *int64             `json:"size,omitempty"`
+	Artifacts    map[string]int64   `json:"artifacts,omitempty"`
+	Error        string             `json:"error,omitempty"`
+}
+
+func printJSON(w io.Writer, worktrees []git.Worktree) error {
+	rows := make([]listRow, len(worktrees))
+	for i, wt := range worktrees {
+		rows[i] = listRow{Worktree: wt}
+	}
+	return printListJSON(w, rows, nil)
+}
+
+// printListJSON always includes the worktree's path, branch and status, and
+// adds the fields for any extra columns requested
+func printListJSON(w io.Writer, rows []listRow, columns []listColumn) error {
+	jsonWorktrees := make([]worktreeJSON, len(rows))
+	for i, row := range rows {
+		jsonWorktrees[i] = listRowJSON(row, columns)
+	}
 
 	encoder := json.NewEncoder(w)
 	encoder.SetIndent("", "  ")
 	return encoder.Encode(jsonWorktrees)
 }
 
+func listRowJSON(row listRow, columns []listColumn) worktreeJSON {
+	entry := worktreeJSON{
+		Path:      row.Path,
+		Branch:    row.Branch,
+		IsMain:    row.IsMain,
+		IsCurrent: row.IsCurrent,
+		IsMerged:  row.IsMerged,
+	}
+	if row.Err != nil {
+		entry.Error = row.Err.Error()
+	}
+
+	for _, column := range columns {
+		if row.unreadable(column) {
+			continue
+		}
+		switch column.Name {
+		case "commit":
+			entry.LastCommit = &row.LastCommit
+		case "age":
+			if !row.LastCommitAt.IsZero() {
+				entry.LastCommitAt = &row.LastCommitAt
+			}
+		case "active":
+			if !row.LastActiveAt.IsZero() {
+				entry.LastActiveAt = &row.LastActiveAt
+			}
+		case "ahead":
+			entry.Ahead = &row.Ahead
+		case "behind":
+			entry.Behind = &row.Behind
+		case "dirty":
+			entry.Dirty = &row.Dirty
+		case "db":
+			entry.DBSuffix = &row.DBSuffix
+		case "pr", "review", "checks":
+			entry.PullRequest = row.PullRequest
+		case "size":
+			if row.DiskUsage != nil {
+				entry.Size = &row.DiskUsage.Bytes
+				entry.Artifacts = row.DiskUsage.Artifacts
+			}
+		}
+	}
+	return entry
+}
+
 func printPorcelain(w io.Writer, worktrees []git.Worktree) error {
 	for _, wt := range worktrees {
 		current := ""
@@ -109,11 +776,165 @@ func printPorcelain(w io.Writer, worktrees []git.Worktree) error {
 	return nil
 }
 
+// Porcelain formats accepted by --porcelain
+const (
+	porcelainV1 = "v1"
+	porcelainV2 = "v2"
+)
+
+// printPorcelainV2 prints a header record followed by one record per
+// worktree. Each field is a NUL-terminated key=value pair and each record
+// ends with an extra NUL, so paths and branches may contain spaces.
+//
+// Every record has path, folder, branch, main, current and merged. Extra
+// columns add commit, age and active (Unix seconds), ahead, behind, dirty,
+// db, pr, review, checks and size (bytes).
+// New keys may be added, but existing keys will not change meaning.
+func printPorcelainV2(w io.Writer, rows []listRow, columns []listColumn) error {
+	writeRecord := func(fields [][2]string) error {
+		var b strings.Builder
+		for _, field := range fields {
+			b.WriteString(field[0])
+			b.WriteByte('=')
+			b.WriteString(field[1])
+			b.WriteByte(0)
+		}
+		b.WriteByte(0)
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+
+	if err := writeRecord([][2]string{{"arbor-porcelain", porcelainV2}}); err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		fields := [][2]string{
+			{"path", row.Path},
+			{"folder", filepath.Base(row.Path)},
+			{"branch", row.Branch},
+			{"main", strconv.FormatBool(row.IsMain)},
+			{"current", strconv.FormatBool(row.IsCurrent)},
+			{"merged", strconv.FormatBool(row.IsMerged)},
+		}
+		if row.Err != nil {
+			fields = append(fields, [2]string{"error", row.Err.Error()})
+		}
+
+		for _, column := range columns {
+			if row.unreadable(column) {
+				continue
+			}
+			switch column.Name {
+			case "commit":
+				fields = append(fields, [2]string{"commit", row.LastCommit})
+			case "age", "active":
+				fields = append(fields, [2]string{column.Name, unixSeconds(listRowTime(row, column.Name))})
+			case "ahead":
+				fields = append(fields, [2]string{"ahead", strconv.Itoa(row.Ahead)})
+			case "behind":
+				fields = append(fields, [2]string{"behind", strconv.Itoa(row.Behind)})
+			case "dirty":
+				fields = append(fields, [2]string{"dirty", strconv.FormatBool(row.Dirty)})
+			case "db":
+				fields = append(fields, [2]string{"db", row.DBSuffix})
+			case "pr":
+				number := ""
+				if row.PullRequest != nil {
+					number = strconv.Itoa(row.PullRequest.Number)
+				}
+				fields = append(fields, [2]string{"pr", number})
+			case "review", "checks":
+				fields = append(fields, [2]string{column.Name, pullRequestField(row.PullRequest, column.Name)})
+			case "size":
+				fields = append(fields, [2]string{"size", diskUsageBytes(row.DiskUsage)})
+			}
+		}
+
+		if err := writeRecord(fields); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// printListPorcelain prints the selected columns tab-separated, one worktree
+// per line, with "-" for empty values. The status column is plain words
+// joined by commas, such as "current,main".
+func printListPorcelain(w io.Writer, rows []listRow, columns []listColumn) error {
+	for _, row := range rows {
+		values := make([]string, len(columns))
+		for i, column := range columns {
+			value := listValue(column, row)
+			switch column.Name {
+			case "status":
+				value = porcelainStatus(row.Worktree)
+			case "age", "active":
+				value = unixSeconds(listRowTime(row, column.Name))
+			case "size":
+				value = diskUsageBytes(row.DiskUsage)
+			}
+			if value == "" {
+				value = "-"
+			}
+			values[i] = value
+		}
+
+		if _, err := fmt.Fprintln(w, strings.Join(values, "\t")); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// listRowTime returns the time shown by the age or active column
+func listRowTime(row listRow, column string) time.Time {
+	if column == "active" {
+		return row.LastActiveAt
+	}
+	return row.LastCommitAt
+}
+
+// unixSeconds formats t for porcelain output, empty when it is unknown
+func unixSeconds(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return strconv.FormatInt(t.Unix(), 10)
+}
+
+func porcelainStatus(wt git.Worktree) string {
+	var parts []string
+	if wt.IsCurrent {
+		parts = append(parts, "current")
+	}
+	switch {
+	case wt.IsMain:
+		parts = append(parts, "main")
+	case wt.IsMerged:
+		parts = append(parts, "merged")
+	default:
+		parts = append(parts, "active")
+	}
+	return strings.Join(parts, ",")
+}
+
 func init() {
 	rootCmd.AddCommand(listCmd)
 
 	listCmd.Flags().Bool("json", false, "Output as JSON array")
-	listCmd.Flags().Bool("porcelain", false, "Machine-parseable output")
+	listCmd.Flags().String("porcelain", "", "Machine-parseable output; --porcelain=v2 for NUL-separated key=value records")
+	listCmd.Flags().Lookup("porcelain").NoOptDefVal = porcelainV1
 	listCmd.Flags().String("sort-by", "name", "Sort by: name, branch, created")
 	listCmd.Flags().Bool("reverse", false, "Reverse sort order")
+	listCmd.Flags().Bool("all", false, "Show every column")
+	listCmd.Flags().String("columns", "", "Comma-separated columns to show (see --help)")
+	listCmd.Flags().StringSlice("filter", nil, "Only list worktrees that are merged, unmerged, dirty or stale:<age>")
+	listCmd.Flags().String("stale", "", "Only list worktrees with no commits or file changes for this long, e.g. 30d")
+	listCmd.Flags().String("match", "", "Only list worktrees whose branch or folder matches a glob, e.g. 'feature/*'")
+	listCmd.Flags().Bool("prs", false, "Show each branch's open pull request, review state and checks (needs gh)")
+	listCmd.Flags().Bool("size", false, "Show each worktree's disk usage and largest build artifact directory")
+	listCmd.Flags().Bool("group", false, "Group the table into main, active and merged sections")
 }
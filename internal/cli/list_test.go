@@ -8,9 +8,14 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
+	"github.com/michaeldyrynda/arbor/internal/diskusage"
+	"github.com/michaeldyrynda/arbor/internal/forge"
 	"github.com/michaeldyrynda/arbor/internal/git"
 )
 
@@ -382,3 +387,354 @@ func TestListCommand_FolderNameMatchesArborRemove(t *testing.T) {
 		t.Errorf("expected path %s (resolved: %s), got %s (resolved: %s)", featurePath, featurePathEval, myFeatureWorktree.Path, wtPathEval)
 	}
 }
+
+func TestSelectListColumns(t *testing.T) {
+	columns, err := selectListColumns("", false)
+	assert.NoError(t, err)
+	assert.Nil(t, columns)
+
+	columns, err = selectListColumns("", true)
+	assert.NoError(t, err)
+	assert.Len(t, columns, len(listColumns)-len(prColumns)-1, "--all leaves out the pull request and size columns")
+
+	columns, err = selectListColumns("Branch, age", false)
+	assert.NoError(t, err)
+	if assert.Len(t, columns, 2) {
+		assert.Equal(t, "branch", columns[0].Name)
+		assert.Equal(t, "age", columns[1].Name)
+	}
+
+	_, err = selectListColumns("branch,bogus", false)
+	assert.ErrorContains(t, err, `unknown column "bogus"`)
+}
+
+func TestPrintListPorcelain(t *testing.T) {
+	columns, err := selectListColumns("path,status,ahead,dirty,db", false)
+	assert.NoError(t, err)
+
+	rows := []listRow{
+		{Worktree: git.Worktree{Path: "/test/main", Branch: "main", IsMain: true, IsCurrent: true}},
+		{Worktree: git.Worktree{Path: "/test/feature", Branch: "feature"}, Ahead: 2, Dirty: true, DBSuffix: "swift_fox"},
+	}
+
+	var buf bytes.Buffer
+	assert.NoError(t, printListPorcelain(&buf, rows, columns))
+	assert.Equal(t, "/test/main\tcurrent,main\t0\tno\t-\n/test/feature\tactive\t2\tyes\tswift_fox\n", buf.String())
+}
+
+func TestPrintListJSON_ExtraColumns(t *testing.T) {
+	columns, err := selectListColumns("branch,ahead,dirty", false)
+	assert.NoError(t, err)
+
+	rows := []listRow{{Worktree: git.Worktree{Path: "/test/feature", Branch: "feature"}, Ahead: 3}}
+
+	var buf bytes.Buffer
+	assert.NoError(t, printListJSON(&buf, rows, columns))
+
+	var result []map[string]interface{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &result))
+	assert.Equal(t, float64(3), result[0]["ahead"])
+	assert.Equal(t, false, result[0]["dirty"])
+	assert.NotContains(t, result[0], "behind")
+	assert.NotContains(t, result[0], "dbSuffix")
+}
+
+func TestLoadListRows(t *testing.T) {
+	barePath, _ := createTestRepo(t)
+	projectDir := filepath.Dir(barePath)
+
+	mainPath := filepath.Join(projectDir, "main")
+	if err := git.CreateWorktree(barePath, mainPath, "main", ""); err != nil {
+		t.Fatalf("creating main worktree: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(mainPath, "arbor.yaml"), []byte("db_suffix: swift_fox\n"), 0644); err != nil {
+		t.Fatalf("writing worktree config: %v", err)
+	}
+
+	columns, err := selectListColumns("commit,age,dirty,db", false)
+	assert.NoError(t, err)
+
+	rows := loadListRows([]git.Worktree{{Path: mainPath, Branch: "main", IsMain: true}}, listDetails(columns, nil), "main")
+	require.NoError(t, rows[0].Err)
+
+	assert.Equal(t, "Initial commit", rows[0].LastCommit)
+	assert.False(t, rows[0].LastCommitAt.IsZero())
+	assert.True(t, rows[0].Dirty)
+	assert.Equal(t, "swift_fox", rows[0].DBSuffix)
+}
+
+func TestLoadListRows_MissingWorktree(t *testing.T) {
+	barePath, _ := createTestRepo(t)
+	projectDir := filepath.Dir(barePath)
+
+	mainPath := filepath.Join(projectDir, "main")
+	require.NoError(t, git.CreateWorktree(barePath, mainPath, "main", ""))
+	featurePath := filepath.Join(projectDir, "feature")
+	require.NoError(t, git.CreateWorktree(barePath, featurePath, "feature", "main"))
+	require.NoError(t, os.RemoveAll(featurePath))
+
+	worktrees, err := git.ListWorktreesDetailed(barePath, mainPath, "main")
+	require.NoError(t, err)
+
+	columns, err := selectListColumns("", true)
+	require.NoError(t, err)
+
+	rows := loadListRows(worktrees, listDetails(columns, nil), "main")
+	require.Len(t, rows, 2)
+
+	byBranch := map[string]listRow{}
+	for _, row := range rows {
+		byBranch[row.Branch] = row
+	}
+	assert.NoError(t, byBranch["main"].Err)
+	assert.Error(t, byBranch["feature"].Err)
+
+	var table bytes.Buffer
+	require.NoError(t, printListTable(&table, rows, columns))
+	assert.Contains(t, table.String(), "feature")
+	assert.Contains(t, table.String(), "?")
+
+	var out bytes.Buffer
+	require.NoError(t, printListJSON(&out, rows, columns))
+	var entries []map[string]interface{}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &entries))
+	for _, entry := range entries {
+		if entry["branch"] == "feature" {
+			assert.NotEmpty(t, entry["error"])
+			assert.NotContains(t, entry, "dirty")
+		} else {
+			assert.NotContains(t, entry, "error")
+			assert.Contains(t, entry, "dirty")
+		}
+	}
+}
+
+func TestListCmd_MissingWorktree(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	project := createWorkspaceProject(t, t.TempDir(), "app")
+	featurePath := filepath.Join(project, "feature")
+	require.NoError(t, git.CreateWorktree(filepath.Join(project, ".bare"), featurePath, "feature", "main"))
+	require.NoError(t, os.RemoveAll(featurePath))
+
+	originalDir, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalDir)
+	require.NoError(t, os.Chdir(filepath.Join(project, "main")))
+
+	for _, args := range [][]string{{"--all"}, {"--filter", "dirty"}} {
+		cmd := &cobra.Command{}
+		cmd.Flags().Bool("json", false, "")
+		cmd.Flags().String("porcelain", "", "")
+		cmd.Flags().String("sort-by", "name", "")
+		cmd.Flags().Bool("reverse", false, "")
+		cmd.Flags().Bool("all", false, "")
+		cmd.Flags().String("columns", "", "")
+		cmd.Flags().StringSlice("filter", nil, "")
+		cmd.Flags().String("stale", "", "")
+		cmd.Flags().String("match", "", "")
+		cmd.Flags().Bool("prs", false, "")
+		cmd.Flags().Bool("size", false, "")
+		cmd.Flags().Bool("group", false, "")
+		require.NoError(t, cmd.ParseFlags(args))
+
+		assert.NoError(t, listCmd.RunE(cmd, nil), "arbor list %v", args)
+	}
+}
+
+func TestParseListFilters(t *testing.T) {
+	now := time.Now()
+	rows := []listRow{
+		{Worktree: git.Worktree{Branch: "main", IsMain: true}, LastCommitAt: now.AddDate(0, 0, -60), LastActiveAt: now.AddDate(0, 0, -60)},
+		{Worktree: git.Worktree{Branch: "feature/done", IsMerged: true}, LastCommitAt: now.AddDate(0, 0, -40), LastActiveAt: now.AddDate(0, 0, -40)},
+		{Worktree: git.Worktree{Branch: "feature/wip"}, LastCommitAt: now.AddDate(0, 0, -90), LastActiveAt: now.AddDate(0, 0, -2), Dirty: true},
+		{Worktree: git.Worktree{Branch: "feature/old"}, LastCommitAt: now.AddDate(0, 0, -90), LastActiveAt: now.AddDate(0, 0, -90)},
+	}
+
+	branches := func(rows []listRow) []string {
+		var names []string
+		for _, row := range rows {
+			names = append(names, row.Branch)
+		}
+		return names
+	}
+
+	tests := []struct {
+		specs []string
+		want  []string
+	}{
+		{[]string{"merged"}, []string{"feature/done"}},
+		{[]string{"unmerged"}, []string{"feature/wip", "feature/old"}},
+		{[]string{"dirty"}, []string{"feature/wip"}},
+		{[]string{"stale:30"}, []string{"feature/done", "feature/old"}},
+		{[]string{"stale:6w"}, []string{"feature/old"}},
+		{[]string{"unmerged", "stale:30d"}, []string{"feature/old"}},
+		{nil, []string{"main", "feature/done", "feature/wip", "feature/old"}},
+	}
+
+	for _, tt := range tests {
+		filters, err := parseListFilters(tt.specs)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.want, branches(filterListRows(rows, filters)), "filters %v", tt.specs)
+	}
+
+	_, err := parseListFilters([]string{"stale:soon"})
+	assert.ErrorContains(t, err, "invalid age")
+
+	_, err = parseListFilters([]string{"shiny"})
+	assert.ErrorContains(t, err, `unknown filter "shiny"`)
+}
+
+func TestListDetails_IncludesFilters(t *testing.T) {
+	filters, err := parseListFilters([]string{"dirty"})
+	assert.NoError(t, err)
+
+	needs := listDetails(nil, filters)
+	assert.True(t, needs[detailDirty])
+	assert.False(t, needs[detailCommit])
+}
+
+func TestMatchWorktrees(t *testing.T) {
+	worktrees := []git.Worktree{
+		{Path: "/repo/main", Branch: "main"},
+		{Path: "/repo/feature-login", Branch: "feature/login"},
+		{Path: "/repo/hotfix", Branch: "hotfix/crash"},
+	}
+
+	matched, err := matchWorktrees(worktrees, "feature/*")
+	assert.NoError(t, err)
+	assert.Len(t, matched, 1)
+	assert.Equal(t, "feature/login", matched[0].Branch)
+
+	matched, err = matchWorktrees(worktrees, "hot*")
+	assert.NoError(t, err)
+	assert.Len(t, matched, 1)
+	assert.Equal(t, "hotfix/crash", matched[0].Branch)
+
+	matched, err = matchWorktrees(worktrees, "")
+	assert.NoError(t, err)
+	assert.Len(t, matched, 3)
+
+	_, err = matchWorktrees(worktrees, "[")
+	assert.ErrorContains(t, err, "invalid --match pattern")
+}
+
+func TestPrintListGroups(t *testing.T) {
+	rows := []listRow{
+		{Worktree: git.Worktree{Path: "/repo/main", Branch: "main", IsMain: true}},
+		{Worktree: git.Worktree{Path: "/repo/feature-a", Branch: "feature/a"}},
+		{Worktree: git.Worktree{Path: "/repo/feature-b", Branch: "feature/b", IsMerged: true}},
+	}
+
+	var buf bytes.Buffer
+	assert.NoError(t, printListGroups(&buf, rows, defaultListColumns()))
+
+	output := buf.String()
+	assert.Contains(t, output, "Main (1)")
+	assert.Contains(t, output, "Active (1)")
+	assert.Contains(t, output, "Merged (1)")
+	assert.Less(t, strings.Index(output, "feature/a"), strings.Index(output, "Merged (1)"))
+	assert.Greater(t, strings.Index(output, "feature/b"), strings.Index(output, "Merged (1)"))
+}
+
+func TestPrintPorcelainV2(t *testing.T) {
+	rows := []listRow{
+		{Worktree: git.Worktree{Path: "/repo/my feature", Branch: "feature/with space", IsCurrent: true}, Dirty: true},
+	}
+
+	columns, err := selectListColumns("branch,dirty", false)
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	assert.NoError(t, printPorcelainV2(&buf, rows, columns))
+
+	records := strings.Split(strings.TrimSuffix(buf.String(), "\x00\x00"), "\x00\x00")
+	assert.Len(t, records, 2)
+	assert.Equal(t, "arbor-porcelain=v2", records[0])
+	assert.Equal(t, []string{
+		"path=/repo/my feature",
+		"folder=my feature",
+		"branch=feature/with space",
+		"main=false",
+		"current=true",
+		"merged=false",
+		"dirty=true",
+	}, strings.Split(records[1], "\x00"))
+}
+
+func TestWithPullRequestColumns(t *testing.T) {
+	columns := withColumns(nil, prColumns...)
+	var names []string
+	for _, column := range columns {
+		names = append(names, column.Name)
+	}
+	assert.Equal(t, []string{"worktree", "branch", "status", "pr", "review", "checks"}, names)
+
+	selected, err := selectListColumns("branch,pr", false)
+	assert.NoError(t, err)
+	assert.Len(t, withColumns(selected, prColumns...), 4)
+}
+
+func TestPrintListPullRequests(t *testing.T) {
+	rows := []listRow{
+		{
+			Worktree:    git.Worktree{Path: "/repo/feature-login", Branch: "feature/login"},
+			PullRequest: &forge.PullRequest{Number: 12, Branch: "feature/login", Review: "changes_requested", Checks: "failing"},
+		},
+		{Worktree: git.Worktree{Path: "/repo/feature-wip", Branch: "feature/wip"}},
+	}
+	columns := withColumns(nil, prColumns...)
+
+	var porcelain bytes.Buffer
+	assert.NoError(t, printListPorcelain(&porcelain, rows, columns))
+	lines := strings.Split(strings.TrimSpace(porcelain.String()), "\n")
+	assert.Equal(t, "feature-login\tfeature/login\tactive\t#12\tchanges requested\tfailing", lines[0])
+	assert.Equal(t, "feature-wip\tfeature/wip\tactive\t-\t-\t-", lines[1])
+
+	var out bytes.Buffer
+	assert.NoError(t, printListJSON(&out, rows, columns))
+
+	var decoded []map[string]interface{}
+	assert.NoError(t, json.Unmarshal(out.Bytes(), &decoded))
+	assert.Equal(t, float64(12), decoded[0]["pullRequest"].(map[string]interface{})["number"])
+	assert.NotContains(t, decoded[1], "pullRequest")
+}
+
+func TestPrintListSize(t *testing.T) {
+	rows := []listRow{
+		{
+			Worktree:  git.Worktree{Path: "/repo/feature-login", Branch: "feature/login"},
+			DiskUsage: &diskusage.Usage{Bytes: 3 * 1024 * 1024, Artifacts: map[string]int64{"node_modules": 2 * 1024 * 1024, "vendor": 1024}},
+		},
+		{Worktree: git.Worktree{Path: "/repo/feature-docs", Branch: "feature/docs"}, DiskUsage: &diskusage.Usage{Bytes: 2048}},
+	}
+	columns, err := selectListColumns("branch,size", false)
+	require.NoError(t, err)
+
+	assert.Equal(t, "3.0 MB (node_modules 2.0 MB)", columns[1].Value(rows[0]))
+	assert.Equal(t, "2.0 KB", columns[1].Value(rows[1]))
+
+	var porcelain bytes.Buffer
+	require.NoError(t, printListPorcelain(&porcelain, rows, columns))
+	assert.Equal(t, "feature/login\t3145728\nfeature/docs\t2048\n", porcelain.String())
+
+	var out bytes.Buffer
+	require.NoError(t, printListJSON(&out, rows, columns))
+	var decoded []map[string]interface{}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &decoded))
+	assert.Equal(t, float64(3145728), decoded[0]["size"])
+	assert.Equal(t, float64(2097152), decoded[0]["artifacts"].(map[string]interface{})["node_modules"])
+	assert.NotContains(t, decoded[1], "artifacts")
+}
+
+func TestAttachDiskUsage(t *testing.T) {
+	barePath, _ := createTestRepo(t)
+	path := filepath.Join(filepath.Dir(barePath), "feature")
+	require.NoError(t, git.CreateWorktree(barePath, path, "feature", "main"))
+
+	rows := []listRow{{Worktree: git.Worktree{Path: path, Branch: "feature"}}}
+	attachDiskUsage(rows, barePath)
+
+	require.NotNil(t, rows[0].DiskUsage)
+	assert.Positive(t, rows[0].DiskUsage.Bytes)
+	assert.FileExists(t, filepath.Join(barePath, "arbor", "disk-usage.json"))
+}
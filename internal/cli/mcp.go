@@ -0,0 +1,270 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/michaeldyrynda/arbor/internal/git"
+	"github.com/michaeldyrynda/arbor/internal/mcp"
+)
+
+var mcpCmd = &cobra.Command{
+	Use:   "mcp",
+	Short: "Serve arbor to AI agents over the Model Context Protocol",
+	Long: `Runs a Model Context Protocol server on stdin and stdout, so coding agents
+can create a worktree per task, scaffold it and clean it up afterwards.
+
+Run it from inside a project. The tools are:
+
+  list_worktrees   Worktrees with their status, last commit and database suffix
+  create_worktree  Create and scaffold a worktree for a branch
+  run_scaffold     Re-run the scaffold steps for a worktree
+  remove_worktree  Run cleanup steps and remove a worktree
+
+Tools never prompt. Progress output goes to stderr, and each result includes
+the worktree as JSON.
+
+To use it with an agent, register the command, e.g. for Claude Code:
+
+  claude mcp add arbor -- arbor mcp`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pc, err := OpenProjectFromCWD(cmd)
+		if err != nil {
+			return err
+		}
+
+		// stdout carries the protocol, so everything else printed, including
+		// step output, goes to stderr
+		protocolOut := os.Stdout
+		os.Stdout = os.Stderr
+		defer func() { os.Stdout = protocolOut }()
+
+		return mcp.NewServer("arbor", arborVersion(), mcpTools(pc)).Serve(os.Stdin, protocolOut)
+	},
+}
+
+// arborExecutable is the binary the MCP tools run commands with
+var arborExecutable = os.Executable
+
+func arborVersion() string {
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" {
+		return info.Main.Version
+	}
+	return "dev"
+}
+
+type mcpWorktreeArgs struct {
+	Worktree     string `json:"worktree"`
+	Branch       string `json:"branch"`
+	Base         string `json:"base"`
+	Path         string `json:"path"`
+	DeleteBranch bool   `json:"delete_branch"`
+	Force        bool   `json:"force"`
+}
+
+func mcpSchema(properties map[string]interface{}, required ...string) map[string]interface{} {
+	schema := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+var mcpWorktreeProperty = map[string]interface{}{
+	"type":        "string",
+	"description": "Worktree folder name, as shown in list_worktrees, e.g. feature-login",
+}
+
+func mcpTools(pc *ProjectContext) []mcp.Tool {
+	return []mcp.Tool{
+		{
+			Name:        "list_worktrees",
+			Description: "List the project's worktrees with their branch, path, status, last commit, ahead/behind counts, uncommitted changes and database suffix.",
+			InputSchema: mcpSchema(map[string]interface{}{}),
+			Handler: func(json.RawMessage) (*mcp.Result, error) {
+				worktrees, err := mcpListWorktrees(pc)
+				if err != nil {
+					return nil, err
+				}
+				data, err := json.MarshalIndent(worktrees, "", "  ")
+				if err != nil {
+					return nil, fmt.Errorf("encoding worktrees: %w", err)
+				}
+				return &mcp.Result{Text: string(data), Structured: map[string]interface{}{"worktrees": worktrees}}, nil
+			},
+		},
+		{
+			Name:        "create_worktree",
+			Description: "Create a worktree for a branch, creating the branch from base if it does not exist, and run its scaffold steps.",
+			InputSchema: mcpSchema(map[string]interface{}{
+				"branch": map[string]interface{}{"type": "string", "description": "Branch name, e.g. feature/login"},
+				"base":   map[string]interface{}{"type": "string", "description": "Branch to create it from, defaults to the default branch"},
+				"path":   map[string]interface{}{"type": "string", "description": "Worktree path, defaults to the branch name with / replaced by -"},
+			}, "branch"),
+			Handler: mcpHandler(pc, "work"),
+		},
+		{
+			Name:        "run_scaffold",
+			Description: "Run the scaffold steps for an existing worktree again.",
+			InputSchema: mcpSchema(map[string]interface{}{"worktree": mcpWorktreeProperty}, "worktree"),
+			Handler:     mcpHandler(pc, "scaffold"),
+		},
+		{
+			Name:        "remove_worktree",
+			Description: "Run the cleanup steps for a worktree, such as dropping its databases, and remove it.",
+			InputSchema: mcpSchema(map[string]interface{}{
+				"worktree":      mcpWorktreeProperty,
+				"delete_branch": map[string]interface{}{"type": "boolean", "description": "Also delete the worktree's branch"},
+				"force": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Confirm the removal. Destructive: uncommitted changes in the worktree and any databases its cleanup drops are lost. Without it nothing is removed.",
+				},
+			}, "worktree"),
+			Handler: mcpHandler(pc, "remove"),
+		},
+	}
+}
+
+// mcpHandler runs an arbor command for a tool call and returns its output,
+// along with the worktree it acted on
+func mcpHandler(pc *ProjectContext, command string) func(json.RawMessage) (*mcp.Result, error) {
+	return func(raw json.RawMessage) (*mcp.Result, error) {
+		var args mcpWorktreeArgs
+		if err := json.Unmarshal(raw, &args); err != nil {
+			return nil, fmt.Errorf("invalid arguments: %w", err)
+		}
+
+		cmdArgs, err := mcpCommandArgs(command, args)
+		if err != nil {
+			return nil, err
+		}
+
+		exe, err := arborExecutable()
+		if err != nil {
+			return nil, fmt.Errorf("finding arbor executable: %w", err)
+		}
+
+		c := exec.Command(exe, cmdArgs...)
+		c.Dir = pc.ProjectPath
+		c.Env = append(os.Environ(), "NO_COLOR=1")
+		output, runErr := c.CombinedOutput()
+
+		result := &mcp.Result{Text: strings.TrimSpace(string(output)), IsError: runErr != nil}
+		if runErr != nil {
+			if result.Text == "" {
+				result.Text = runErr.Error()
+			}
+			return result, nil
+		}
+
+		if command == "remove" {
+			result.Structured = map[string]interface{}{"removed": args.Worktree}
+		} else if worktree := mcpFindWorktree(pc, args); worktree != nil {
+			result.Structured = map[string]interface{}{"worktree": worktree}
+		}
+		return result, nil
+	}
+}
+
+// mcpCommandArgs builds the arbor command line for a tool call. Commands
+// run without prompts, and positional arguments follow -- so a branch such
+// as --force is not read as a flag.
+func mcpCommandArgs(command string, args mcpWorktreeArgs) ([]string, error) {
+	var flags, positional []string
+	switch command {
+	case "work":
+		if args.Branch == "" {
+			return nil, fmt.Errorf("branch is required")
+		}
+		positional = append(positional, args.Branch)
+		if args.Path != "" {
+			positional = append(positional, args.Path)
+		}
+		if args.Base != "" {
+			flags = append(flags, "--base", args.Base)
+		}
+	case "scaffold", "remove":
+		if args.Worktree == "" {
+			return nil, fmt.Errorf("worktree is required")
+		}
+		if strings.ContainsAny(args.Worktree, `/\`) || args.Worktree == ".." {
+			return nil, fmt.Errorf("worktree must be a folder name, e.g. feature-login")
+		}
+		positional = append(positional, args.Worktree)
+		if command == "remove" {
+			if args.Force {
+				flags = append(flags, "--force")
+			}
+			if args.DeleteBranch {
+				flags = append(flags, "--delete-branch")
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unsupported command %q", command)
+	}
+
+	cmdArgs := append([]string{command}, flags...)
+	cmdArgs = append(cmdArgs, "--no-input", "--plain", "--")
+	return append(cmdArgs, positional...), nil
+}
+
+// mcpListWorktrees returns every worktree with the details of arbor list
+// --all --json
+func mcpListWorktrees(pc *ProjectContext) ([]map[string]interface{}, error) {
+	worktrees, err := git.ListWorktreesDetailed(pc.BarePath, pc.ProjectPath, pc.DefaultBranch)
+	if err != nil {
+		return nil, fmt.Errorf("listing worktrees: %w", err)
+	}
+
+	columns, err := selectListColumns("", true)
+	if err != nil {
+		return nil, err
+	}
+	rows := loadListRows(worktrees, listDetails(columns, nil), pc.DefaultBranch)
+
+	var buf bytes.Buffer
+	if err := printListJSON(&buf, rows, columns); err != nil {
+		return nil, err
+	}
+
+	var entries []map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entries); err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if path, ok := entry["path"].(string); ok {
+			entry["folder"] = filepath.Base(path)
+		}
+	}
+	return entries, nil
+}
+
+// mcpFindWorktree returns the listed worktree a tool call acted on
+func mcpFindWorktree(pc *ProjectContext, args mcpWorktreeArgs) map[string]interface{} {
+	entries, err := mcpListWorktrees(pc)
+	if err != nil {
+		return nil
+	}
+	for _, entry := range entries {
+		if args.Worktree != "" && entry["folder"] == args.Worktree {
+			return entry
+		}
+		if args.Worktree == "" && entry["branch"] == args.Branch {
+			return entry
+		}
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(mcpCmd)
+}
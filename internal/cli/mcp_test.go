@@ -0,0 +1,115 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/michaeldyrynda/arbor/internal/config"
+	"github.com/michaeldyrynda/arbor/internal/git"
+	"github.com/michaeldyrynda/arbor/internal/mcp"
+)
+
+func TestMcpCommandArgs(t *testing.T) {
+	args, err := mcpCommandArgs("work", mcpWorktreeArgs{Branch: "feature/login", Base: "develop"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"work", "--base", "develop", "--no-input", "--plain", "--", "feature/login"}, args)
+
+	args, err = mcpCommandArgs("work", mcpWorktreeArgs{Branch: "--force"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"work", "--no-input", "--plain", "--", "--force"}, args)
+
+	args, err = mcpCommandArgs("remove", mcpWorktreeArgs{Worktree: "feature-login", DeleteBranch: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"remove", "--delete-branch", "--no-input", "--plain", "--", "feature-login"}, args)
+
+	args, err = mcpCommandArgs("remove", mcpWorktreeArgs{Worktree: "feature-login", Force: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"remove", "--force", "--no-input", "--plain", "--", "feature-login"}, args)
+
+	args, err = mcpCommandArgs("scaffold", mcpWorktreeArgs{Worktree: "main"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"scaffold", "--no-input", "--plain", "--", "main"}, args)
+
+	_, err = mcpCommandArgs("work", mcpWorktreeArgs{})
+	assert.ErrorContains(t, err, "branch is required")
+
+	_, err = mcpCommandArgs("remove", mcpWorktreeArgs{Worktree: "../other"})
+	assert.ErrorContains(t, err, "folder name")
+}
+
+func TestMcpRemoveWorktreeForce(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("stand-in executable is a POSIX shell script")
+	}
+	exe := filepath.Join(t.TempDir(), "arbor")
+	require.NoError(t, os.WriteFile(exe, []byte("#!/bin/sh\necho \"$@\"\n"), 0755))
+	original := arborExecutable
+	arborExecutable = func() (string, error) { return exe, nil }
+	defer func() { arborExecutable = original }()
+
+	pc := &ProjectContext{ProjectPath: t.TempDir(), Config: &config.Config{}}
+
+	var tool mcp.Tool
+	for _, candidate := range mcpTools(pc) {
+		if candidate.Name == "remove_worktree" {
+			tool = candidate
+		}
+	}
+	require.NotNil(t, tool.Handler)
+
+	properties := tool.InputSchema["properties"].(map[string]interface{})
+	require.Contains(t, properties, "force")
+	assert.Equal(t, "boolean", properties["force"].(map[string]interface{})["type"])
+
+	args := map[string]interface{}{"worktree": "feature-login"}
+	for name, property := range properties {
+		if property.(map[string]interface{})["type"] == "boolean" {
+			args[name] = true
+		}
+	}
+	raw, err := json.Marshal(args)
+	require.NoError(t, err)
+
+	result, err := tool.Handler(raw)
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	assert.Equal(t, "remove --force --delete-branch --no-input --plain -- feature-login", result.Text)
+}
+
+func TestMcpListWorktrees(t *testing.T) {
+	barePath, _ := createTestRepo(t)
+	projectDir := filepath.Dir(barePath)
+	require.NoError(t, git.CreateWorktree(barePath, filepath.Join(projectDir, "main"), "main", ""))
+	require.NoError(t, git.CreateWorktree(barePath, filepath.Join(projectDir, "feature-login"), "feature/login", "main"))
+
+	pc := &ProjectContext{BarePath: barePath, ProjectPath: projectDir, Config: &config.Config{}, DefaultBranch: "main"}
+
+	var listTool func(json.RawMessage) (*mcp.Result, error)
+	for _, tool := range mcpTools(pc) {
+		if tool.Name == "list_worktrees" {
+			listTool = tool.Handler
+		}
+	}
+	require.NotNil(t, listTool)
+
+	result, err := listTool(json.RawMessage("{}"))
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+
+	worktrees := result.Structured.(map[string]interface{})["worktrees"].([]map[string]interface{})
+	require.Len(t, worktrees, 2)
+
+	byFolder := map[string]map[string]interface{}{}
+	for _, wt := range worktrees {
+		byFolder[wt["folder"].(string)] = wt
+	}
+	assert.Equal(t, "feature/login", byFolder["feature-login"]["branch"])
+	assert.Equal(t, true, byFolder["main"]["isMain"])
+	assert.Contains(t, byFolder["main"], "lastCommit")
+}
@@ -0,0 +1,96 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+
+	"github.com/spf13/cobra"
+
+	"github.com/michaeldyrynda/arbor/internal/config"
+	"github.com/michaeldyrynda/arbor/internal/git"
+	"github.com/michaeldyrynda/arbor/internal/ui"
+	"github.com/michaeldyrynda/arbor/internal/utils"
+)
+
+// onCreateActions combines the on_create config with the --editor, --browser
+// and --tmux flags of arbor work, which turn actions on for a single run
+func onCreateActions(cmd *cobra.Command, cfg config.OnCreateConfig) config.OnCreateConfig {
+	actions := cfg
+	actions.Editor = actions.Editor || mustGetBool(cmd, "editor")
+	actions.Browser = actions.Browser || mustGetBool(cmd, "browser")
+	actions.Tmux = actions.Tmux || mustGetBool(cmd, "tmux")
+	return actions
+}
+
+// runOnCreate runs the post-create actions for a new worktree, starting the
+// tmux session with the project's windows. The worktree is already usable,
+// so failures are reported as warnings.
+func runOnCreate(actions config.OnCreateConfig, tmux config.TmuxConfig, windowsShell, worktreePath, branch string) {
+	appURL := utils.ReadEnvFile(worktreePath, ".env")["APP_URL"]
+
+	if actions.Command != "" {
+		c := utils.ShellCommand(windowsShell, actions.Command)
+		c.Dir = worktreePath
+		c.Env = append(os.Environ(),
+			"ARBOR_WORKTREE_PATH="+worktreePath,
+			"ARBOR_BRANCH="+branch,
+			"APP_URL="+appURL,
+		)
+		c.Stdout = os.Stdout
+		c.Stderr = os.Stderr
+		if err := c.Run(); err != nil {
+			ui.PrintWarning(fmt.Sprintf("on_create command failed: %v", err))
+		}
+	}
+
+	if actions.Browser {
+		if appURL == "" {
+			ui.PrintWarning("Not opening the browser: APP_URL is not set in .env")
+		} else if err := openURL(appURL); err != nil {
+			ui.PrintWarning(fmt.Sprintf("Could not open %s: %v", appURL, err))
+		}
+	}
+
+	if actions.Tmux {
+		if err := startTmuxSession(worktreePath, tmux.Windows); err != nil {
+			ui.PrintWarning(fmt.Sprintf("Could not start tmux session: %v", err))
+		}
+	}
+
+	if actions.Editor {
+		if !ui.CanPrompt() {
+			ui.PrintInfo("Not opening the editor without a terminal")
+			return
+		}
+		c := editorCommand(git.Worktree{Path: worktreePath})
+		c.Stdin = os.Stdin
+		c.Stdout = os.Stdout
+		c.Stderr = os.Stderr
+		if err := c.Run(); err != nil {
+			ui.PrintWarning(fmt.Sprintf("Could not open editor: %v", err))
+		}
+	}
+}
+
+// openURL opens url in the default browser
+func openURL(url string) error {
+	c := openURLCommand(runtime.GOOS, url)
+	if c == nil {
+		return fmt.Errorf("opening URLs is not supported on %s", runtime.GOOS)
+	}
+	return c.Start()
+}
+
+func openURLCommand(goos, url string) *exec.Cmd {
+	switch goos {
+	case "darwin":
+		return exec.Command("open", url)
+	case "windows":
+		return exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	case "linux", "freebsd", "openbsd", "netbsd":
+		return exec.Command("xdg-open", url)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/michaeldyrynda/arbor/internal/config"
+)
+
+func TestRunOnCreate_Command(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("command uses POSIX shell syntax")
+	}
+	worktreePath := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(worktreePath, ".env"), []byte("APP_URL=https://feature.test\n"), 0644))
+
+	runOnCreate(config.OnCreateConfig{
+		Command: `echo "$ARBOR_BRANCH $APP_URL $ARBOR_WORKTREE_PATH" > created.txt`,
+	}, config.TmuxConfig{}, "", worktreePath, "feature/login")
+
+	data, err := os.ReadFile(filepath.Join(worktreePath, "created.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "feature/login https://feature.test "+worktreePath+"\n", string(data))
+}
+
+func TestOnCreateActions_FlagsAddToConfig(t *testing.T) {
+	t.Cleanup(func() { _ = workCmd.Flags().Set("tmux", "false") })
+	require.NoError(t, workCmd.Flags().Set("tmux", "true"))
+
+	actions := onCreateActions(workCmd, config.OnCreateConfig{Editor: true, Command: "make dev"})
+
+	assert.True(t, actions.Editor)
+	assert.True(t, actions.Tmux)
+	assert.False(t, actions.Browser)
+	assert.Equal(t, "make dev", actions.Command)
+}
+
+func TestOpenURLCommand(t *testing.T) {
+	assert.Equal(t, []string{"open", "https://app.test"}, openURLCommand("darwin", "https://app.test").Args)
+	assert.Equal(t, []string{"xdg-open", "https://app.test"}, openURLCommand("linux", "https://app.test").Args)
+	assert.Nil(t, openURLCommand("plan9", "https://app.test"))
+}
+
+func TestTmuxSessionName(t *testing.T) {
+	assert.Equal(t, "feature-login", tmuxSessionName("/code/app/feature-login"))
+	assert.Equal(t, "release-1_2", tmuxSessionName("/code/app/release-1.2"))
+}
+
+func TestTmuxSessionCommands(t *testing.T) {
+	t.Run("plain session without windows", func(t *testing.T) {
+		assert.Equal(t, [][]string{
+			{"new-session", "-d", "-s", "feature", "-c", "/code/app/feature"},
+		}, tmuxSessionCommands("feature", "/code/app/feature", nil))
+	})
+
+	t.Run("configured windows", func(t *testing.T) {
+		commands := tmuxSessionCommands("feature", "/code/app/feature", []config.TmuxWindow{
+			{Name: "editor", Command: "nvim ."},
+			{Name: "server", Command: "php artisan serve"},
+			{},
+		})
+
+		assert.Equal(t, [][]string{
+			{"new-session", "-d", "-s", "feature", "-n", "editor", "-c", "/code/app/feature"},
+			{"send-keys", "-t", "feature:editor", "nvim .", "Enter"},
+			{"new-window", "-d", "-t", "feature:", "-n", "server", "-c", "/code/app/feature"},
+			{"send-keys", "-t", "feature:server", "php artisan serve", "Enter"},
+			{"new-window", "-d", "-t", "feature:", "-n", "window3", "-c", "/code/app/feature"},
+		}, commands)
+	})
+}
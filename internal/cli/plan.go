@@ -0,0 +1,125 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/michaeldyrynda/arbor/internal/git"
+	"github.com/michaeldyrynda/arbor/internal/scaffold"
+	"github.com/michaeldyrynda/arbor/internal/ui"
+)
+
+// addPlanFlags adds --plan and --json to a command that scaffolds
+func addPlanFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("plan", false, "Print the resolved scaffold plan instead of running it (implies --dry-run)")
+	cmd.Flags().Bool("json", false, "Print the --plan as JSON")
+}
+
+// wantsPlan reports whether --plan or --json was passed
+func wantsPlan(cmd *cobra.Command) bool {
+	return mustGetBool(cmd, "plan") || mustGetBool(cmd, "json")
+}
+
+// planSource returns the worktree a new worktree's plan reads files from:
+// the worktree of its base branch, or the main worktree
+func planSource(pc *ProjectContext, baseBranch string) string {
+	worktrees, err := git.ListWorktrees(pc.BarePath)
+	if err != nil {
+		return ""
+	}
+
+	source := ""
+	for _, wt := range worktrees {
+		if wt.Branch == baseBranch {
+			return wt.Path
+		}
+		if wt.IsMain {
+			source = wt.Path
+		}
+	}
+	return source
+}
+
+// printScaffoldPlan writes the plan as a table, or as JSON with --json
+func printScaffoldPlan(cmd *cobra.Command, w io.Writer, plan *scaffold.Plan) error {
+	if mustGetBool(cmd, "json") {
+		return printPlanJSON(w, plan)
+	}
+	return printPlan(w, plan)
+}
+
+func printPlan(w io.Writer, plan *scaffold.Plan) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Scaffold plan for %s at %s\n", plan.Branch, plan.Worktree)
+	if plan.Source != "" {
+		fmt.Fprintf(&b, "Files read from: %s\n", plan.Source)
+	}
+	preset := plan.Preset
+	if preset == "" {
+		preset = "none"
+	}
+	fmt.Fprintf(&b, "Preset: %s\n", preset)
+	fmt.Fprintf(&b, "Database suffix: %s\n", plan.DbSuffix)
+
+	if len(plan.Steps) == 0 {
+		b.WriteString("\nNo steps to run.\n")
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+
+	rows := make([][]string, len(plan.Steps))
+	for i, step := range plan.Steps {
+		action := "run"
+		if !step.Run {
+			action = "skip"
+		}
+		rows[i] = []string{strconv.Itoa(step.Group), step.Name, action, planDetails(step)}
+	}
+	b.WriteString("\n")
+	b.WriteString(ui.RenderTable([]string{"GROUP", "STEP", "ACTION", "DETAILS"}, rows))
+	b.WriteString("\n\nSteps in the same group run in parallel.\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// planDetails summarises what a planned step would do in one line
+func planDetails(step scaffold.PlannedStep) string {
+	if !step.Run {
+		return step.Reason
+	}
+
+	var details []string
+	if step.Command != "" {
+		details = append(details, step.Command)
+	}
+	if len(step.Files) > 0 {
+		details = append(details, "writes "+strings.Join(step.Files, ", "))
+	}
+	if len(step.Databases) > 0 {
+		details = append(details, "creates "+strings.Join(step.Databases, ", "))
+	}
+	if step.Detail != "" {
+		details = append(details, step.Detail)
+	}
+	if step.Error != "" {
+		details = append(details, "error: "+step.Error)
+	}
+	return strings.Join(details, "; ")
+}
+
+func printPlanJSON(w io.Writer, plan *scaffold.Plan) error {
+	if plan.Steps == nil {
+		plan.Steps = []scaffold.PlannedStep{}
+	}
+	// Placeholders such as <suffix> are written as they are, not escaped
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(plan)
+}
@@ -0,0 +1,47 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/michaeldyrynda/arbor/internal/scaffold"
+	"github.com/michaeldyrynda/arbor/internal/scaffold/types"
+)
+
+func TestPrintPlan(t *testing.T) {
+	plan := &scaffold.Plan{
+		Worktree: "/code/myapp/feature-login",
+		Source:   "/code/myapp/main",
+		Branch:   "feature/login",
+		Preset:   "laravel",
+		DbSuffix: scaffold.PlanDbSuffix,
+		Steps: []scaffold.PlannedStep{
+			{Group: 1, Name: "file.copy", Run: true, StepPlan: types.StepPlan{Files: []string{".env"}, Detail: "copied from .env.example"}},
+			{Group: 2, Name: "db.create", Run: true, StepPlan: types.StepPlan{Databases: []string{"myapp_<suffix>"}, Detail: "mysql"}},
+			{Group: 3, Name: "node.npm", Reason: "condition not met"},
+			{Group: 4, Name: "bash.run", Run: true, StepPlan: types.StepPlan{Command: "echo"}, Error: "unknown field"},
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, printPlan(&buf, plan))
+	out := buf.String()
+	assert.Contains(t, out, "Scaffold plan for feature/login at /code/myapp/feature-login")
+	assert.Contains(t, out, "Files read from: /code/myapp/main")
+	assert.Contains(t, out, "writes .env; copied from .env.example")
+	assert.Contains(t, out, "creates myapp_<suffix>; mysql")
+	assert.Contains(t, out, "condition not met")
+	assert.Contains(t, out, "echo; error: unknown field")
+
+	buf.Reset()
+	require.NoError(t, printPlanJSON(&buf, plan))
+	assert.Contains(t, buf.String(), `"dbSuffix": "<suffix>"`)
+
+	var decoded scaffold.Plan
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, *plan, decoded)
+}
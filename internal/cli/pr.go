@@ -0,0 +1,148 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/michaeldyrynda/arbor/internal/forge"
+	"github.com/michaeldyrynda/arbor/internal/git"
+	"github.com/michaeldyrynda/arbor/internal/scaffold"
+	"github.com/michaeldyrynda/arbor/internal/scaffold/template"
+	"github.com/michaeldyrynda/arbor/internal/ui"
+)
+
+var prCmd = &cobra.Command{
+	Use:   "pr",
+	Short: "Work with pull requests",
+}
+
+var prCreateCmd = &cobra.Command{
+	Use:   "create [FOLDER]",
+	Short: "Push a worktree's branch and open a pull request",
+	Long: `Pushes a worktree's branch, setting its upstream if needed, and opens a
+pull request against the branch it was created from. The provider is detected
+from the origin remote: GitHub uses gh, GitLab uses glab to open a merge
+request, and Bitbucket opens the new pull request page in the browser.
+
+Arguments:
+  FOLDER  Name of the worktree folder (defaults to the current worktree)
+
+The title and body default to the pr settings in arbor.yaml, which are
+templates like step values:
+
+  pr:
+    title: '{{ .Branch | trimPrefix "feature/" | title }}'
+    body: "Preview: https://{{ .Path }}.test"
+    draft: true
+
+Without a title, gh and glab fill the title and body from the branch's
+commits.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pc, err := OpenProjectFromCWD(cmd)
+		if err != nil {
+			return err
+		}
+
+		target, err := pc.SelectWorktree(args, "Select a worktree")
+		if err != nil {
+			return err
+		}
+		if target.IsMain {
+			return fmt.Errorf("cannot open a pull request from the main worktree")
+		}
+
+		ctx, err := scaffold.TemplateContext(target.Path, target.Branch, filepath.Base(target.Path), pc.Config)
+		if err != nil {
+			return err
+		}
+
+		title := mustGetString(cmd, "title")
+		if title == "" {
+			title = pc.Config.PR.Title
+		}
+		body := mustGetString(cmd, "body")
+		if body == "" {
+			body = pc.Config.PR.Body
+		}
+		if title, err = template.ReplaceTemplateVars(title, ctx); err != nil {
+			return fmt.Errorf("rendering pr title: %w", err)
+		}
+		if body, err = template.ReplaceTemplateVars(body, ctx); err != nil {
+			return fmt.Errorf("rendering pr body: %w", err)
+		}
+
+		base := mustGetString(cmd, "base")
+		if base == "" {
+			base = ctx.BaseBranch
+		}
+		draft := mustGetBool(cmd, "draft") || pc.Config.PR.Draft
+
+		provider, err := forge.Detect(pc.BarePath)
+		if err != nil {
+			return err
+		}
+
+		opts := forge.PullRequestOptions{Branch: target.Branch, Base: base, Title: title, Body: body, Draft: draft}
+		var webURL string
+		c, err := provider.CreatePullRequest(target.Path, opts)
+		if err != nil {
+			browser, ok := provider.(forge.BrowserProvider)
+			if !errors.Is(err, forge.ErrUnsupported) || !ok {
+				return err
+			}
+			webURL = browser.NewPullRequestURL(target.Branch, base)
+		}
+
+		if mustGetBool(cmd, "dry-run") {
+			ui.PrintInfo(fmt.Sprintf("[DRY RUN] Would push %s", target.Branch))
+			if webURL != "" {
+				ui.PrintInfo(fmt.Sprintf("[DRY RUN] Would open %s", webURL))
+			} else {
+				ui.PrintInfo(fmt.Sprintf("[DRY RUN] Would run %q", c.Args))
+			}
+			return nil
+		}
+
+		err = ui.RunWithSpinner(fmt.Sprintf("Pushing %s...", target.Branch), func() error {
+			return git.Push(target.Path, target.Branch)
+		})
+		if err != nil {
+			return err
+		}
+		ui.PrintSuccess(fmt.Sprintf("Pushed %s", target.Branch))
+
+		if webURL != "" {
+			ui.PrintInfo(fmt.Sprintf("Open a pull request at %s", webURL))
+			if ui.IsInteractive() {
+				if err := openURL(webURL); err != nil {
+					ui.PrintWarning(fmt.Sprintf("Could not open %s: %v", webURL, err))
+				}
+			}
+			return nil
+		}
+
+		c.Stdin = os.Stdin
+		c.Stdout = os.Stdout
+		c.Stderr = os.Stderr
+		if err := c.Run(); err != nil {
+			return fmt.Errorf("%s failed: %w", strings.Join(c.Args[:3], " "), err)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(prCmd)
+	prCmd.AddCommand(prCreateCmd)
+
+	prCreateCmd.Flags().String("title", "", "Pull request title, overriding pr.title")
+	prCreateCmd.Flags().String("body", "", "Pull request body, overriding pr.body")
+	prCreateCmd.Flags().String("base", "", "Branch to merge into (defaults to the worktree's base branch)")
+	prCreateCmd.Flags().Bool("draft", false, "Open the pull request as a draft")
+}
@@ -2,10 +2,16 @@ package cli
 
 import (
 	"fmt"
+	"io"
 	"path/filepath"
+	"time"
 
 	"github.com/spf13/cobra"
 
+	"github.com/michaeldyrynda/arbor/internal/audit"
+	"github.com/michaeldyrynda/arbor/internal/diskusage"
+	arborerrors "github.com/michaeldyrynda/arbor/internal/errors"
+	"github.com/michaeldyrynda/arbor/internal/events"
 	"github.com/michaeldyrynda/arbor/internal/git"
 	"github.com/michaeldyrynda/arbor/internal/ui"
 )
@@ -16,9 +22,25 @@ var pruneCmd = &cobra.Command{
 	Long: `Removes merged worktrees automatically.
 
 Lists all worktrees, identifies merged ones, and provides an
-interactive review before removal.`,
+interactive review before removal. Each merged worktree is shown with
+when its branch was last committed to and whether it has uncommitted
+changes; dirty worktrees start unselected.
+
+--stale 60d also reports worktrees with no commits or file changes for that
+long whose branch is not merged. Prune never removes them; use arbor remove
+once you are sure they are abandoned.
+
+Worktrees for branches listed in protected_branches are never removed.
+
+--clean-artifacts keeps the merged worktrees and removes their build
+artifacts instead: node_modules, vendor, public/build, .next, .nuxt and dist,
+when git ignores them. Run arbor info to see what a worktree holds.
+
+With --porcelain, progress output is replaced by one line per worktree,
+"<path> <branch>", listing the worktrees removed (with --force), those
+that would be removed (with --dry-run) or the merged candidates.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		pc, err := OpenProjectFromCWD()
+		pc, err := OpenProjectFromCWD(cmd)
 		if err != nil {
 			return err
 		}
@@ -26,17 +48,43 @@ interactive review before removal.`,
 		force := mustGetBool(cmd, "force")
 		dryRun := mustGetBool(cmd, "dry-run")
 		verbose := mustGetBool(cmd, "verbose")
+		porcelain := mustGetBool(cmd, "porcelain")
+
+		var staleAge time.Duration
+		if spec := mustGetString(cmd, "stale"); spec != "" {
+			if staleAge, err = parseStaleAge(spec); err != nil {
+				return arborerrors.Wrap(arborerrors.ErrInvalidArguments, fmt.Errorf("--stale: %w", err))
+			}
+		}
+
+		info := ui.PrintInfo
+		if porcelain {
+			info = func(string) {}
+		}
 
 		worktrees, err := git.ListWorktrees(pc.BarePath)
 		if err != nil {
 			return fmt.Errorf("listing worktrees: %w", err)
 		}
 
+		if mustGetBool(cmd, "clean-artifacts") {
+			return cleanMergedArtifacts(cmd, pc, worktrees, force, dryRun)
+		}
+
 		var removable []git.Worktree
+		stale := 0
 
 		for _, wt := range worktrees {
 			if wt.Branch == pc.DefaultBranch || wt.Branch == "(bare)" {
-				ui.PrintInfo(fmt.Sprintf("%s at %s", wt.Branch, wt.Path))
+				info(fmt.Sprintf("%s at %s", wt.Branch, wt.Path))
+				continue
+			}
+			if pc.Config.IsProtectedBranch(wt.Branch) {
+				info(fmt.Sprintf("%s is protected", wt.Branch))
+				continue
+			}
+			if wt.IsCheckout {
+				info(fmt.Sprintf("%s is the repository's own checkout", wt.Branch))
 				continue
 			}
 
@@ -48,24 +96,51 @@ interactive review before removal.`,
 
 			if merged {
 				removable = append(removable, wt)
-				ui.PrintSuccess(fmt.Sprintf("%s is merged", wt.Branch))
+				if !porcelain {
+					ui.PrintSuccess(fmt.Sprintf("%s is merged", wt.Branch))
+				}
+			} else if active := staleSince(wt, staleAge); !active.IsZero() {
+				stale++
+				if !porcelain {
+					ui.PrintWarning(fmt.Sprintf("%s is not merged, last active %s; remove it with arbor remove %s", wt.Branch, ui.RelativeTime(active), filepath.Base(wt.Path)))
+				}
 			} else {
-				ui.PrintInfo(fmt.Sprintf("%s is not merged", wt.Branch))
+				info(fmt.Sprintf("%s is not merged", wt.Branch))
 			}
 		}
 
+		if stale > 0 {
+			info(fmt.Sprintf("%d stale unmerged worktree(s) found, which prune does not remove.", stale))
+		}
+
 		if len(removable) == 0 {
-			ui.PrintDone("No merged worktrees to remove.")
+			if !porcelain {
+				ui.PrintDone("No merged worktrees to remove.")
+			}
 			return nil
 		}
 
-		ui.PrintInfo(fmt.Sprintf("%d merged worktree(s) found.", len(removable)))
+		info(fmt.Sprintf("%d merged worktree(s) found.", len(removable)))
 
 		var toRemove []git.Worktree
 		if force {
+			toRemove = skipUnreadable(removable, ui.PrintWarning)
+		} else if porcelain || !ui.ShouldPrompt(cmd, false) {
+			if porcelain && !dryRun {
+				return printPruneLines(cmd.OutOrStdout(), removable)
+			}
+			if !dryRun {
+				ui.PrintInfo("Run with --force to remove them.")
+				return nil
+			}
 			toRemove = removable
 		} else {
-			selected, err := ui.SelectWorktreesToPrune(removable)
+			candidates := pruneCandidates(pc.BarePath, removable, ui.PrintWarning)
+			if len(candidates) == 0 {
+				ui.PrintInfo("No worktrees left to choose from.")
+				return nil
+			}
+			selected, err := ui.SelectWorktreesToPrune(candidates)
 			if err != nil {
 				return fmt.Errorf("selecting worktrees: %w", err)
 			}
@@ -86,40 +161,218 @@ interactive review before removal.`,
 			}
 		}
 
-		ui.PrintInfo(fmt.Sprintf("Removing %d worktree(s):", len(toRemove)))
+		info(fmt.Sprintf("Removing %d worktree(s):", len(toRemove)))
 		for _, wt := range toRemove {
-			ui.PrintSuccessPath("Removed", wt.Path)
+			info("  " + wt.Path)
 		}
 
+		start := time.Now()
+		var removed []git.Worktree
 		for _, wt := range toRemove {
-			ui.PrintStep(fmt.Sprintf("Removing %s...", wt.Branch))
+			if !porcelain {
+				ui.PrintStep(fmt.Sprintf("Removing %s...", wt.Branch))
+			}
 
-			if !dryRun {
-				preset := pc.Config.Preset
-				if preset == "" {
-					preset = pc.PresetManager().Detect(wt.Path)
-				}
+			preset := pc.Config.Preset
+			if preset == "" {
+				preset = pc.PresetManager().Detect(wt.Path)
+			}
+			siteName := filepath.Base(wt.Path)
 
-				siteName := filepath.Base(wt.Path)
+			if !dryRun {
+				removeStart := time.Now()
+				dbSuffix := worktreeDbSuffix(wt.Path)
 				if err := pc.ScaffoldManager().RunCleanup(wt.Path, wt.Branch, "", siteName, preset, pc.Config, false, verbose); err != nil {
 					ui.PrintErrorWithHint("Cleanup failed", err.Error())
 				}
 
+				stopWorktreeTunnel(pc.BarePath, wt.Path)
+				entry := audit.Entry{Operation: audit.OperationPrune, Branch: wt.Branch, Path: wt.Path, DbSuffix: dbSuffix}
 				if err := git.RemoveWorktree(wt.Path, true); err != nil {
+					recordAudit(pc.BarePath, entry, err)
 					ui.PrintErrorWithHint(fmt.Sprintf("Error removing %s", wt.Branch), err.Error())
+					continue
 				}
-			} else {
+				recordAudit(pc.BarePath, entry, nil)
+				forgetWorktree(pc, wt.Path)
+				sendEvent(pc, events.WorktreeRemoved, wt.Branch, wt.Path, dbSuffix, removeStart)
+			} else if !porcelain {
 				ui.PrintInfo(fmt.Sprintf("[DRY RUN] Would remove %s and run cleanup", wt.Branch))
+				if err := pc.ScaffoldManager().RunCleanup(wt.Path, wt.Branch, "", siteName, preset, pc.Config, true, verbose); err != nil {
+					ui.PrintErrorWithHint("Cleanup preview failed", err.Error())
+				}
 			}
+			removed = append(removed, wt)
+		}
+
+		if !dryRun && len(removed) > 0 {
+			syncCodeWorkspace(pc)
+		}
+
+		if porcelain {
+			return printPruneLines(cmd.OutOrStdout(), removed)
 		}
 
-		ui.PrintDone("Done.")
+		if dryRun {
+			ui.PrintDone(fmt.Sprintf("Would remove %d worktree(s).", len(removed)))
+		} else {
+			ui.PrintDone(fmt.Sprintf("Removed %d worktree(s).", len(removed)))
+		}
+		if !dryRun {
+			ui.NotifyIfSlow(start, "arbor prune", fmt.Sprintf("Removed %d worktree(s)", len(removed)))
+		}
 		return nil
 	},
 }
 
+// cleanMergedArtifacts removes the build artifacts of merged worktrees,
+// leaving the worktrees in place
+func cleanMergedArtifacts(cmd *cobra.Command, pc *ProjectContext, worktrees []git.Worktree, force, dryRun bool) error {
+	type candidate struct {
+		worktree  git.Worktree
+		artifacts []diskusage.Artifact
+	}
+
+	var candidates []candidate
+	var total int64
+	for _, wt := range worktrees {
+		if wt.Branch == pc.DefaultBranch || wt.Branch == "(bare)" {
+			continue
+		}
+		merged, err := git.IsMerged(pc.BarePath, wt.Branch, pc.DefaultBranch)
+		if err != nil || !merged {
+			continue
+		}
+
+		artifacts, err := diskusage.RemoveArtifacts(wt.Path, true)
+		if err != nil {
+			ui.PrintErrorWithHint(fmt.Sprintf("Error measuring %s", wt.Branch), err.Error())
+			continue
+		}
+		if len(artifacts) == 0 {
+			continue
+		}
+
+		candidates = append(candidates, candidate{worktree: wt, artifacts: artifacts})
+		for _, artifact := range artifacts {
+			ui.PrintStep(fmt.Sprintf("%s: %s (%s)", wt.Branch, artifact.Path, diskusage.FormatBytes(artifact.Bytes)))
+			total += artifact.Bytes
+		}
+	}
+
+	if len(candidates) == 0 {
+		ui.PrintDone("No build artifacts in merged worktrees.")
+		return nil
+	}
+
+	if dryRun {
+		ui.PrintDone(fmt.Sprintf("Would free %s from %d worktree(s).", diskusage.FormatBytes(total), len(candidates)))
+		return nil
+	}
+
+	if !force {
+		if !ui.ShouldPrompt(cmd, false) {
+			ui.PrintInfo("Run with --force to remove them.")
+			return nil
+		}
+
+		confirmed, err := ui.Confirm(fmt.Sprintf("Remove %s of build artifacts from %d worktree(s)?", diskusage.FormatBytes(total), len(candidates)))
+		if err != nil {
+			return fmt.Errorf("confirmation: %w", err)
+		}
+		if !confirmed {
+			ui.PrintInfo("No build artifacts removed.")
+			return nil
+		}
+	}
+
+	cache := diskusage.OpenCache(pc.BarePath)
+	var freed int64
+	for _, c := range candidates {
+		removed, err := diskusage.RemoveArtifacts(c.worktree.Path, false)
+		for _, artifact := range removed {
+			freed += artifact.Bytes
+		}
+		cache.Forget(c.worktree.Path)
+		if err != nil {
+			ui.PrintErrorWithHint(fmt.Sprintf("Error cleaning %s", c.worktree.Branch), err.Error())
+		}
+	}
+	if err := cache.Save(); err != nil {
+		ui.PrintWarning(fmt.Sprintf("Could not cache disk usage: %v", err))
+	}
+
+	ui.PrintDone(fmt.Sprintf("Freed %s from %d worktree(s).", diskusage.FormatBytes(freed), len(candidates)))
+	return nil
+}
+
+// pruneCandidates adds the last commit date and dirty state shown when
+// choosing worktrees to prune. A worktree whose state cannot be read, such as
+// one whose directory was deleted, is skipped with a warning through warn.
+func pruneCandidates(barePath string, worktrees []git.Worktree, warn func(string)) []ui.PruneCandidate {
+	dates, err := git.BranchCommitDates(barePath)
+	if err != nil {
+		warn(fmt.Sprintf("Could not read branch commit dates: %v", err))
+	}
+
+	var candidates []ui.PruneCandidate
+	for _, wt := range worktrees {
+		dirty, err := git.IsDirty(wt.Path)
+		if err != nil {
+			warn(fmt.Sprintf("Skipping %s: %v", wt.Branch, err))
+			continue
+		}
+		candidates = append(candidates, ui.PruneCandidate{Worktree: wt, LastCommit: dates[wt.Branch], Dirty: dirty})
+	}
+	return candidates
+}
+
+// skipUnreadable drops the worktrees whose state cannot be read, as
+// pruneCandidates does when choosing, warning about each through warn
+func skipUnreadable(worktrees []git.Worktree, warn func(string)) []git.Worktree {
+	var readable []git.Worktree
+	for _, wt := range worktrees {
+		if _, err := git.IsDirty(wt.Path); err != nil {
+			warn(fmt.Sprintf("Skipping %s: %v", wt.Branch, err))
+			continue
+		}
+		readable = append(readable, wt)
+	}
+	return readable
+}
+
+// staleSince returns when the worktree was last active if it has been idle
+// for at least age, or zero when it has not, its last commit cannot be read
+// or age is zero
+func staleSince(wt git.Worktree, age time.Duration) time.Time {
+	if age <= 0 {
+		return time.Time{}
+	}
+	_, lastCommit, err := git.LastCommit(wt.Path)
+	if err != nil {
+		return time.Time{}
+	}
+	active := lastActive(lastCommit, wt.Path)
+	if !isStale(active, age) {
+		return time.Time{}
+	}
+	return active
+}
+
+func printPruneLines(w io.Writer, worktrees []git.Worktree) error {
+	for _, wt := range worktrees {
+		if _, err := fmt.Fprintf(w, "%s %s\n", wt.Path, wt.Branch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func init() {
 	rootCmd.AddCommand(pruneCmd)
 
 	pruneCmd.Flags().BoolP("force", "f", false, "Skip interactive confirmation")
+	pruneCmd.Flags().String("stale", "", "Also report unmerged worktrees with no commits or file changes for this long, e.g. 60d")
+	pruneCmd.Flags().Bool("clean-artifacts", false, "Keep merged worktrees but remove their build artifacts, such as node_modules and vendor")
+	pruneCmd.Flags().Bool("porcelain", false, "Print one \"<path> <branch>\" line per worktree instead of progress output")
 }
@@ -0,0 +1,110 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/michaeldyrynda/arbor/internal/config"
+	"github.com/michaeldyrynda/arbor/internal/git"
+)
+
+func TestPrintPruneLines(t *testing.T) {
+	worktrees := []git.Worktree{
+		{Path: "/test/feature-a", Branch: "feature/a"},
+		{Path: "/test/feature-b", Branch: "feature/b"},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, printPruneLines(&buf, worktrees))
+
+	assert.Equal(t, "/test/feature-a feature/a\n/test/feature-b feature/b\n", buf.String())
+}
+
+func TestPruneCandidates(t *testing.T) {
+	barePath, _ := createTestRepo(t)
+	projectDir := filepath.Dir(barePath)
+
+	cleanPath := filepath.Join(projectDir, "clean")
+	require.NoError(t, git.CreateWorktree(barePath, cleanPath, "clean", "main"))
+	dirtyPath := filepath.Join(projectDir, "dirty")
+	require.NoError(t, git.CreateWorktree(barePath, dirtyPath, "dirty", "main"))
+	require.NoError(t, os.WriteFile(filepath.Join(dirtyPath, "notes.txt"), []byte("wip"), 0644))
+
+	missingPath := filepath.Join(projectDir, "missing")
+	require.NoError(t, git.CreateWorktree(barePath, missingPath, "missing", "main"))
+	require.NoError(t, os.RemoveAll(missingPath))
+
+	var warnings []string
+	candidates := pruneCandidates(barePath, []git.Worktree{
+		{Path: cleanPath, Branch: "clean"},
+		{Path: missingPath, Branch: "missing"},
+		{Path: dirtyPath, Branch: "dirty"},
+	}, func(msg string) { warnings = append(warnings, msg) })
+
+	require.Len(t, candidates, 2)
+	assert.False(t, candidates[0].Dirty)
+	assert.True(t, candidates[1].Dirty)
+	assert.False(t, candidates[0].LastCommit.IsZero())
+	require.Len(t, warnings, 1)
+	assert.Contains(t, warnings[0], "Skipping missing")
+}
+
+func TestSkipUnreadable(t *testing.T) {
+	barePath, _ := createTestRepo(t)
+	projectDir := filepath.Dir(barePath)
+
+	dirtyPath := filepath.Join(projectDir, "dirty")
+	require.NoError(t, git.CreateWorktree(barePath, dirtyPath, "dirty", "main"))
+	require.NoError(t, os.WriteFile(filepath.Join(dirtyPath, "notes.txt"), []byte("wip"), 0644))
+	missingPath := filepath.Join(projectDir, "missing")
+	require.NoError(t, git.CreateWorktree(barePath, missingPath, "missing", "main"))
+	require.NoError(t, os.RemoveAll(missingPath))
+
+	var warnings []string
+	readable := skipUnreadable([]git.Worktree{
+		{Path: dirtyPath, Branch: "dirty"},
+		{Path: missingPath, Branch: "missing"},
+	}, func(msg string) { warnings = append(warnings, msg) })
+
+	assert.Equal(t, []git.Worktree{{Path: dirtyPath, Branch: "dirty"}}, readable, "dirty worktrees are still removed")
+	require.Len(t, warnings, 1)
+	assert.Contains(t, warnings[0], "Skipping missing")
+}
+
+func TestStaleSince(t *testing.T) {
+	barePath, _ := createTestRepo(t)
+	path := filepath.Join(filepath.Dir(barePath), "feature")
+	require.NoError(t, git.CreateWorktree(barePath, path, "feature", "main"))
+	wt := git.Worktree{Path: path, Branch: "feature"}
+
+	assert.True(t, staleSince(wt, 0).IsZero(), "no age means nothing is stale")
+	assert.True(t, staleSince(wt, 24*time.Hour).IsZero(), "a fresh commit is activity")
+	assert.False(t, staleSince(wt, time.Nanosecond).IsZero())
+}
+
+func TestCleanMergedArtifacts(t *testing.T) {
+	barePath, _ := createTestRepo(t)
+	projectDir := filepath.Dir(barePath)
+	pc := &ProjectContext{BarePath: barePath, ProjectPath: projectDir, Config: &config.Config{}, DefaultBranch: "main"}
+
+	mergedPath := filepath.Join(projectDir, "merged")
+	require.NoError(t, git.CreateWorktree(barePath, mergedPath, "merged", "main"))
+	require.NoError(t, os.WriteFile(filepath.Join(mergedPath, ".gitignore"), []byte("node_modules\n"), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(mergedPath, "node_modules", "pkg"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(mergedPath, "node_modules", "pkg", "index.js"), []byte("module.exports = 1"), 0644))
+
+	worktrees := []git.Worktree{{Path: mergedPath, Branch: "merged"}}
+
+	require.NoError(t, cleanMergedArtifacts(pruneCmd, pc, worktrees, false, true))
+	assert.DirExists(t, filepath.Join(mergedPath, "node_modules"), "a dry run removes nothing")
+
+	require.NoError(t, cleanMergedArtifacts(pruneCmd, pc, worktrees, true, false))
+	assert.NoDirExists(t, filepath.Join(mergedPath, "node_modules"))
+	assert.FileExists(t, filepath.Join(mergedPath, ".gitignore"), "the worktree is kept")
+}
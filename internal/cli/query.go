@@ -0,0 +1,272 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/michaeldyrynda/arbor/internal/config"
+	"github.com/michaeldyrynda/arbor/internal/git"
+)
+
+var queryCmd = &cobra.Command{
+	Use:   "query",
+	Short: "Print project data for scripts and editor plugins",
+	Long: `Prints branches, worktrees and databases in a form meant for other tools,
+such as fzf scripts, editor plugins and shell completion.
+
+Text output has one entry per line with tab-separated fields, and never
+includes colours or headers. With --format json, output is an array whose
+objects always have the same fields in the same order.
+
+  arbor query worktrees | fzf | cut -f1`,
+}
+
+var queryBranchesCmd = &cobra.Command{
+	Use:   "branches",
+	Short: "Print local branch names",
+	Long: `Prints local branch names, one per line, sorted by name.
+
+With --remote, branches on origin that have no local branch are included
+without their origin/ prefix.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pc, err := OpenProjectFromCWD(cmd)
+		if err != nil {
+			return err
+		}
+
+		branches, err := queryBranches(pc.BarePath, mustGetBool(cmd, "remote"))
+		if err != nil {
+			return err
+		}
+		return printQuery(os.Stdout, mustGetString(cmd, "format"), branches, func(branch string) []string {
+			return []string{branch}
+		})
+	},
+}
+
+var queryWorktreesCmd = &cobra.Command{
+	Use:   "worktrees",
+	Short: "Print worktree folders, branches and paths",
+	Long: `Prints each worktree as its folder, branch and path, separated by tabs.
+The main worktree comes first, then the rest sorted by path.
+
+JSON objects have folder, branch, path, isMain, isCurrent and dbSuffix, in
+that order.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pc, err := OpenProjectFromCWD(cmd)
+		if err != nil {
+			return err
+		}
+
+		worktrees, err := queryWorktrees(pc)
+		if err != nil {
+			return err
+		}
+		return printQuery(os.Stdout, mustGetString(cmd, "format"), worktrees, func(wt queryWorktree) []string {
+			return []string{wt.Folder, wt.Branch, wt.Path}
+		})
+	},
+}
+
+var queryDatabasesCmd = &cobra.Command{
+	Use:   "databases",
+	Short: "Print the database suffix of each worktree",
+	Long: `Prints the database suffix recorded by each worktree and the worktree's
+folder, separated by tabs. Worktrees without a database are left out.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pc, err := OpenProjectFromCWD(cmd)
+		if err != nil {
+			return err
+		}
+
+		worktrees, err := queryWorktrees(pc)
+		if err != nil {
+			return err
+		}
+
+		var databases []queryDatabase
+		for _, wt := range worktrees {
+			if wt.DbSuffix != "" {
+				databases = append(databases, queryDatabase{DbSuffix: wt.DbSuffix, Folder: wt.Folder, Branch: wt.Branch})
+			}
+		}
+		return printQuery(os.Stdout, mustGetString(cmd, "format"), databases, func(db queryDatabase) []string {
+			return []string{db.DbSuffix, db.Folder}
+		})
+	},
+}
+
+// queryWorktree is a worktree as printed by arbor query. Field order is part
+// of the output format, so new fields go at the end.
+type queryWorktree struct {
+	Folder    string `json:"folder"`
+	Branch    string `json:"branch"`
+	Path      string `json:"path"`
+	IsMain    bool   `json:"isMain"`
+	IsCurrent bool   `json:"isCurrent"`
+	DbSuffix  string `json:"dbSuffix"`
+}
+
+// queryDatabase is a worktree database as printed by arbor query
+type queryDatabase struct {
+	DbSuffix string `json:"dbSuffix"`
+	Folder   string `json:"folder"`
+	Branch   string `json:"branch"`
+}
+
+// queryBranches returns the sorted local branches, and with remote, the
+// origin branches that have no local branch
+func queryBranches(barePath string, remote bool) ([]string, error) {
+	local, err := git.ListAllBranches(barePath)
+	if err != nil {
+		return nil, fmt.Errorf("listing branches: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	branches := []string{}
+	for _, branch := range local {
+		seen[branch] = true
+		branches = append(branches, branch)
+	}
+
+	if remote {
+		remotes, err := git.ListRemoteBranches(barePath)
+		if err != nil {
+			return nil, fmt.Errorf("listing remote branches: %w", err)
+		}
+		for _, branch := range remotes {
+			if strings.Contains(branch, " -> ") {
+				continue
+			}
+			branch = strings.TrimPrefix(branch, "origin/")
+			if !seen[branch] {
+				seen[branch] = true
+				branches = append(branches, branch)
+			}
+		}
+	}
+
+	sort.Strings(branches)
+	return branches, nil
+}
+
+// queryWorktrees returns the project's worktrees, main first and the rest
+// sorted by path
+func queryWorktrees(pc *ProjectContext) ([]queryWorktree, error) {
+	worktrees, err := git.ListWorktreesDetailed(pc.BarePath, pc.CWD, pc.DefaultBranch)
+	if err != nil {
+		return nil, fmt.Errorf("listing worktrees: %w", err)
+	}
+
+	sort.SliceStable(worktrees, func(i, j int) bool {
+		if worktrees[i].IsMain != worktrees[j].IsMain {
+			return worktrees[i].IsMain
+		}
+		return worktrees[i].Path < worktrees[j].Path
+	})
+
+	rows := []queryWorktree{}
+	for _, wt := range worktrees {
+		row := queryWorktree{
+			Folder:    filepath.Base(wt.Path),
+			Branch:    wt.Branch,
+			Path:      wt.Path,
+			IsMain:    wt.IsMain,
+			IsCurrent: wt.IsCurrent,
+		}
+		if wtConfig, err := config.ReadWorktreeConfig(wt.Path); err == nil {
+			row.DbSuffix = wtConfig.DbSuffix
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// printQuery writes items as JSON or as tab-separated lines of fields
+func printQuery[T any](w io.Writer, format string, items []T, fields func(T) []string) error {
+	switch format {
+	case "json":
+		if items == nil {
+			items = []T{}
+		}
+		data, err := json.MarshalIndent(items, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	case "", "text":
+		for _, item := range items {
+			if _, err := fmt.Fprintln(w, strings.Join(fields(item), "\t")); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown format %q (use text or json)", format)
+}
+
+// completeWorktreeFolders completes a FOLDER argument with worktree folders
+func completeWorktreeFolders(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	pc, err := OpenProjectFromCWD(cmd)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	worktrees, err := queryWorktrees(pc)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var folders []string
+	for _, wt := range worktrees {
+		folders = append(folders, wt.Folder+"\t"+wt.Branch)
+	}
+	return folders, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeBranches completes the BRANCH argument of arbor work with local
+// and remote branches
+func completeBranches(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveDefault
+	}
+
+	pc, err := OpenProjectFromCWD(cmd)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	branches, err := queryBranches(pc.BarePath, true)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return branches, cobra.ShellCompDirectiveNoFileComp
+}
+
+func init() {
+	rootCmd.AddCommand(queryCmd)
+	queryCmd.AddCommand(queryBranchesCmd)
+	queryCmd.AddCommand(queryWorktreesCmd)
+	queryCmd.AddCommand(queryDatabasesCmd)
+
+	queryCmd.PersistentFlags().String("format", "text", "Output format: text or json")
+	queryBranchesCmd.Flags().Bool("remote", false, "Include branches that only exist on origin")
+
+	for _, c := range []*cobra.Command{removeCmd, tmuxCmd, direnvCmd, exposeCmd, devcontainerCmd, prCreateCmd, envDiffCmd} {
+		c.ValidArgsFunction = completeWorktreeFolders
+	}
+	workCmd.ValidArgsFunction = completeBranches
+}
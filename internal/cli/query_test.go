@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/michaeldyrynda/arbor/internal/config"
+	"github.com/michaeldyrynda/arbor/internal/git"
+)
+
+func TestQueryWorktrees(t *testing.T) {
+	barePath, _ := createTestRepo(t)
+	projectDir := filepath.Dir(barePath)
+	require.NoError(t, git.CreateWorktree(barePath, filepath.Join(projectDir, "zeta"), "zeta", "main"))
+	require.NoError(t, git.CreateWorktree(barePath, filepath.Join(projectDir, "main"), "main", ""))
+	require.NoError(t, git.CreateWorktree(barePath, filepath.Join(projectDir, "alpha"), "alpha", "main"))
+	require.NoError(t, config.WriteWorktreeConfig(filepath.Join(projectDir, "alpha"), map[string]string{"db_suffix": "swift_runner"}))
+
+	pc := &ProjectContext{BarePath: barePath, ProjectPath: projectDir, Config: &config.Config{}, DefaultBranch: "main"}
+	worktrees, err := queryWorktrees(pc)
+	require.NoError(t, err)
+
+	var folders []string
+	for _, wt := range worktrees {
+		folders = append(folders, wt.Folder)
+	}
+	assert.Equal(t, []string{"main", "alpha", "zeta"}, folders)
+	assert.True(t, worktrees[0].IsMain)
+	assert.Equal(t, "swift_runner", worktrees[1].DbSuffix)
+
+	branches, err := queryBranches(barePath, false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alpha", "main", "zeta"}, branches)
+}
+
+func TestPrintQuery(t *testing.T) {
+	worktrees := []queryWorktree{{Folder: "feature-login", Branch: "feature/login", Path: "/p/feature-login", DbSuffix: "swift_runner"}}
+	fields := func(wt queryWorktree) []string { return []string{wt.Folder, wt.Branch, wt.Path} }
+
+	var buf bytes.Buffer
+	require.NoError(t, printQuery(&buf, "text", worktrees, fields))
+	assert.Equal(t, "feature-login\tfeature/login\t/p/feature-login\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, printQuery(&buf, "json", worktrees, fields))
+	assert.Equal(t, `[
+  {
+    "folder": "feature-login",
+    "branch": "feature/login",
+    "path": "/p/feature-login",
+    "isMain": false,
+    "isCurrent": false,
+    "dbSuffix": "swift_runner"
+  }
+]
+`, buf.String())
+
+	buf.Reset()
+	require.NoError(t, printQuery(&buf, "json", []queryWorktree(nil), fields))
+	assert.Equal(t, "[]\n", buf.String())
+
+	assert.ErrorContains(t, printQuery(&buf, "yaml", worktrees, fields), "unknown format")
+}
@@ -4,10 +4,14 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/spf13/cobra"
 
+	"github.com/michaeldyrynda/arbor/internal/audit"
 	arborerrors "github.com/michaeldyrynda/arbor/internal/errors"
+	"github.com/michaeldyrynda/arbor/internal/events"
+	"github.com/michaeldyrynda/arbor/internal/forge"
 	"github.com/michaeldyrynda/arbor/internal/git"
 	"github.com/michaeldyrynda/arbor/internal/ui"
 )
@@ -20,12 +24,20 @@ var removeCmd = &cobra.Command{
 Arguments:
   FOLDER  Name of the worktree folder to remove (e.g., feature-test-change)
 
+Worktrees for branches listed in protected_branches are never removed.
+
+--merge-check checks the branch is merged into the default branch, or has an
+open pull request, before removing it. Otherwise it warns with the commits
+and changes that would be lost, and with --force refuses to remove it.
+--if-merged removes the worktree only when its branch is merged, and does
+nothing otherwise, for scripts.
+
 Cleanup steps may include:
   - Removing Herd site links
   - Database cleanup prompts`,
 	Args: cobra.MaximumNArgs(1),
-	RunE: func(cmd *cobra.Command, args []string) error {
-		pc, err := OpenProjectFromCWD()
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		pc, err := OpenProjectFromCWD(cmd)
 		if err != nil {
 			return err
 		}
@@ -62,7 +74,7 @@ Cleanup steps may include:
 			if targetWorktree == nil {
 				return fmt.Errorf("worktree '%s' not found: %w", folderName, arborerrors.ErrWorktreeNotFound)
 			}
-		} else if ui.IsInteractive() {
+		} else if ui.CanPrompt() {
 			selected, err := ui.SelectWorktreeToRemove(worktrees)
 			if err != nil {
 				return fmt.Errorf("selecting worktree: %w", err)
@@ -75,12 +87,43 @@ Cleanup steps may include:
 		if targetWorktree.IsMain {
 			return fmt.Errorf("cannot remove main worktree")
 		}
+		if targetWorktree.IsCheckout {
+			return arborerrors.Wrap(arborerrors.ErrInvalidArguments, fmt.Errorf("cannot remove %s, the repository's own checkout", targetWorktree.Path))
+		}
+		if pc.Config.IsProtectedBranch(targetWorktree.Branch) {
+			return arborerrors.Wrap(arborerrors.ErrInvalidArguments, fmt.Errorf("cannot remove worktree for protected branch '%s' (see protected_branches in arbor.yaml)", targetWorktree.Branch))
+		}
+
+		mergeCheck := mustGetBool(cmd, "merge-check")
+		ifMerged := mustGetBool(cmd, "if-merged")
+		if mergeCheck || ifMerged {
+			check, err := checkRemoval(pc.BarePath, targetWorktree, defaultBranch)
+			if err != nil {
+				return err
+			}
+			if ifMerged && (!check.Merged || check.Dirty) {
+				ui.PrintInfo(fmt.Sprintf("%s, leaving %s in place", check.Risk(targetWorktree.Branch, defaultBranch), targetWorktree.Path))
+				return nil
+			}
+			if check.PullRequest != nil && !check.Merged {
+				ui.PrintInfo(fmt.Sprintf("%s has open pull request #%d: %s", targetWorktree.Branch, check.PullRequest.Number, check.PullRequest.URL))
+			}
+			if !check.Safe() {
+				ui.PrintWarning(check.Risk(targetWorktree.Branch, defaultBranch))
+				for _, detail := range check.Details(defaultBranch) {
+					ui.PrintInfo("  " + detail)
+				}
+				if force {
+					return arborerrors.Wrap(arborerrors.ErrInvalidArguments, fmt.Errorf("refusing to remove '%s' with unmerged work (remove it without --merge-check to discard the work)", targetWorktree.Branch))
+				}
+			}
+		}
 
 		ui.PrintInfo(fmt.Sprintf("Removing %s at %s", targetWorktree.Branch, targetWorktree.Path))
 
 		deleteBranch := false
 		if !force {
-			if !ui.IsInteractive() {
+			if !ui.CanPrompt() {
 				return fmt.Errorf("worktree removal requires confirmation (use --force to skip)")
 			}
 
@@ -105,12 +148,18 @@ Cleanup steps may include:
 		}
 
 		ui.PrintStep("Removing worktree")
+		start := time.Now()
+		dbSuffix := worktreeDbSuffix(targetWorktree.Path)
+
+		preset := pc.Config.Preset
+		if preset == "" {
+			preset = pc.PresetManager().Detect(targetWorktree.Path)
+		}
 
 		if !dryRun {
-			preset := pc.Config.Preset
-			if preset == "" {
-				preset = pc.PresetManager().Detect(targetWorktree.Path)
-			}
+			defer func() {
+				recordAudit(pc.BarePath, audit.Entry{Operation: audit.OperationRemove, Branch: targetWorktree.Branch, Path: targetWorktree.Path, DbSuffix: dbSuffix}, err)
+			}()
 
 			if verbose && preset != "" {
 				ui.PrintInfo(fmt.Sprintf("Running cleanup for preset: %s", preset))
@@ -123,10 +172,12 @@ Cleanup steps may include:
 				}
 			}
 
+			stopWorktreeTunnel(pc.BarePath, targetWorktree.Path)
 			if err := git.RemoveWorktree(targetWorktree.Path, true); err != nil {
 				return fmt.Errorf("removing worktree: %w", err)
 			}
 			ui.PrintSuccessPath("Removed", targetWorktree.Path)
+			forgetWorktree(pc, targetWorktree.Path)
 
 			if deleteBranch && git.BranchExists(pc.BarePath, targetWorktree.Branch) {
 				if err := git.DeleteBranch(pc.BarePath, targetWorktree.Branch, true); err != nil {
@@ -143,8 +194,17 @@ Cleanup steps may include:
 					ui.PrintErrorWithHint(fmt.Sprintf("Could not remove empty directory %s", parentDir), err.Error())
 				}
 			}
+
+			syncCodeWorkspace(pc)
+			sendEvent(pc, events.WorktreeRemoved, targetWorktree.Branch, targetWorktree.Path, dbSuffix, start)
 		} else {
 			ui.PrintInfo("[DRY RUN] Would run cleanup and remove worktree")
+			if preset != "" {
+				siteName := filepath.Base(targetWorktree.Path)
+				if err := pc.ScaffoldManager().RunCleanup(targetWorktree.Path, targetWorktree.Branch, "", siteName, preset, pc.Config, true, verbose); err != nil {
+					ui.PrintErrorWithHint("Cleanup preview failed", err.Error())
+				}
+			}
 			if deleteBranch {
 				ui.PrintInfo("[DRY RUN] Would delete branch")
 			}
@@ -155,9 +215,85 @@ Cleanup steps may include:
 	},
 }
 
+// removalCheck is what --merge-check found out about a worktree's branch
+type removalCheck struct {
+	Merged      bool
+	PullRequest *forge.PullRequest
+	// PullRequestErr is why open pull requests could not be checked
+	PullRequestErr error
+	Ahead          int
+	Dirty          bool
+}
+
+// Safe reports whether the branch's work is merged or up for review, with
+// nothing uncommitted, so removing the worktree loses nothing
+func (c removalCheck) Safe() bool {
+	return (c.Merged || c.PullRequest != nil) && !c.Dirty
+}
+
+// Risk says why removing the worktree would lose work
+func (c removalCheck) Risk(branch, defaultBranch string) string {
+	if !c.Merged && c.PullRequest == nil {
+		return fmt.Sprintf("%s is not merged into %s and has no open pull request", branch, defaultBranch)
+	}
+	if c.Dirty {
+		return fmt.Sprintf("%s has uncommitted changes", branch)
+	}
+	return fmt.Sprintf("%s is not merged into %s", branch, defaultBranch)
+}
+
+// Details describes the work that removing the worktree would put at risk
+func (c removalCheck) Details(defaultBranch string) []string {
+	var details []string
+	if c.Ahead > 0 {
+		details = append(details, fmt.Sprintf("%d commit(s) not on %s", c.Ahead, defaultBranch))
+	}
+	if c.Dirty && !c.Merged && c.PullRequest == nil {
+		details = append(details, "uncommitted changes")
+	}
+	if c.PullRequestErr != nil {
+		details = append(details, fmt.Sprintf("open pull requests could not be checked: %v", c.PullRequestErr))
+	}
+	return details
+}
+
+// checkRemoval checks whether wt's branch is merged into defaultBranch, and
+// when it is not, whether it has an open pull request
+func checkRemoval(barePath string, wt *git.Worktree, defaultBranch string) (removalCheck, error) {
+	var check removalCheck
+	var err error
+	if check.Merged, err = git.IsMerged(barePath, wt.Branch, defaultBranch); err != nil {
+		return check, fmt.Errorf("checking whether %s is merged: %w", wt.Branch, err)
+	}
+	if check.Dirty, err = git.IsDirty(wt.Path); err != nil {
+		return check, fmt.Errorf("checking %s for uncommitted changes: %w", wt.Path, err)
+	}
+	if check.Merged {
+		return check, nil
+	}
+
+	if check.Ahead, _, err = git.AheadBehind(wt.Path, defaultBranch); err != nil {
+		return check, fmt.Errorf("counting commits not on %s: %w", defaultBranch, err)
+	}
+
+	provider, err := forge.Detect(barePath)
+	if err == nil {
+		var prs map[string]forge.PullRequest
+		if prs, err = forge.OpenPullRequests(barePath, provider); err == nil {
+			if pr, ok := prs[wt.Branch]; ok {
+				check.PullRequest = &pr
+			}
+		}
+	}
+	check.PullRequestErr = err
+	return check, nil
+}
+
 func init() {
 	rootCmd.AddCommand(removeCmd)
 
 	removeCmd.Flags().BoolP("force", "f", false, "Skip confirmation and cleanup prompts")
 	removeCmd.Flags().Bool("delete-branch", false, "Also delete the branch after removing worktree")
+	removeCmd.Flags().Bool("merge-check", false, "Warn before removing a branch that is not merged and has no open pull request, and refuse with --force")
+	removeCmd.Flags().Bool("if-merged", false, "Only remove the worktree when its branch is merged, doing nothing otherwise")
 }
@@ -13,6 +13,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	arborerrors "github.com/michaeldyrynda/arbor/internal/errors"
 	"github.com/michaeldyrynda/arbor/internal/git"
 )
 
@@ -89,6 +90,8 @@ preset: ""
 		cmd.Flags().Bool("dry-run", false, "")
 		cmd.Flags().Bool("verbose", false, "")
 		cmd.Flags().Bool("delete-branch", false, "")
+		cmd.Flags().Bool("merge-check", false, "")
+		cmd.Flags().Bool("if-merged", false, "")
 
 		originalDir, err := os.Getwd()
 		require.NoError(t, err)
@@ -108,6 +111,146 @@ preset: ""
 	})
 }
 
+func TestRemoveCmd_RefusesProtectedBranch(t *testing.T) {
+	tmpDir := t.TempDir()
+	repoDir := filepath.Join(tmpDir, "repo")
+	barePath := filepath.Join(tmpDir, ".bare")
+
+	require.NoError(t, os.MkdirAll(repoDir, 0755))
+
+	runGitCmd(t, repoDir, "init", "-b", "main")
+	runGitCmd(t, repoDir, "config", "user.email", "test@example.com")
+	runGitCmd(t, repoDir, "config", "user.name", "Test User")
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, "README.md"), []byte("test"), 0644))
+	runGitCmd(t, repoDir, "add", ".")
+	runGitCmd(t, repoDir, "commit", "-m", "Initial commit")
+	runGitCmd(t, repoDir, "clone", "--bare", repoDir, barePath)
+
+	mainPath := filepath.Join(tmpDir, "main")
+	require.NoError(t, git.CreateWorktree(barePath, mainPath, "main", ""))
+
+	releasePath := filepath.Join(tmpDir, "release-1.0")
+	require.NoError(t, git.CreateWorktree(barePath, releasePath, "release/1.0", "main"))
+
+	configContent := `default_branch: main
+preset: ""
+protected_branches: [release/*]
+`
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "arbor.yaml"), []byte(configContent), 0644))
+
+	cmd := &cobra.Command{}
+	cmd.Flags().Bool("force", true, "")
+	cmd.Flags().Bool("dry-run", false, "")
+	cmd.Flags().Bool("verbose", false, "")
+	cmd.Flags().Bool("delete-branch", true, "")
+
+	originalDir, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalDir)
+	require.NoError(t, os.Chdir(mainPath))
+
+	err = removeCmd.RunE(cmd, []string{filepath.Base(releasePath)})
+	require.Error(t, err)
+	assert.ErrorIs(t, err, arborerrors.ErrInvalidArguments)
+	assert.Contains(t, err.Error(), "protected branch 'release/1.0'")
+
+	_, err = os.Stat(releasePath)
+	assert.NoError(t, err, "protected worktree should not be removed")
+	assert.True(t, git.BranchExists(barePath, "release/1.0"), "protected branch should not be deleted")
+}
+
+func TestRemoveCmd_MergeCheck(t *testing.T) {
+	tmpDir := t.TempDir()
+	repoDir := filepath.Join(tmpDir, "repo")
+	barePath := filepath.Join(tmpDir, ".bare")
+
+	require.NoError(t, os.MkdirAll(repoDir, 0755))
+
+	runGitCmd(t, repoDir, "init", "-b", "main")
+	runGitCmd(t, repoDir, "config", "user.email", "test@example.com")
+	runGitCmd(t, repoDir, "config", "user.name", "Test User")
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, "README.md"), []byte("test"), 0644))
+	runGitCmd(t, repoDir, "add", ".")
+	runGitCmd(t, repoDir, "commit", "-m", "Initial commit")
+	runGitCmd(t, repoDir, "clone", "--bare", repoDir, barePath)
+
+	mainPath := filepath.Join(tmpDir, "main")
+	require.NoError(t, git.CreateWorktree(barePath, mainPath, "main", ""))
+
+	featurePath := filepath.Join(tmpDir, "feature")
+	require.NoError(t, git.CreateWorktree(barePath, featurePath, "feature", "main"))
+	runGitCmd(t, featurePath, "config", "user.email", "test@example.com")
+	runGitCmd(t, featurePath, "config", "user.name", "Test User")
+	require.NoError(t, os.WriteFile(filepath.Join(featurePath, "feature.txt"), []byte("work"), 0644))
+	runGitCmd(t, featurePath, "add", ".")
+	runGitCmd(t, featurePath, "commit", "-m", "Feature work")
+
+	mergedPath := filepath.Join(tmpDir, "merged")
+	require.NoError(t, git.CreateWorktree(barePath, mergedPath, "merged", "main"))
+
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "arbor.yaml"), []byte("default_branch: main\npreset: \"\"\n"), 0644))
+
+	originalDir, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalDir)
+	require.NoError(t, os.Chdir(mainPath))
+
+	newCmd := func(mergeCheck, ifMerged bool) *cobra.Command {
+		cmd := &cobra.Command{}
+		cmd.Flags().Bool("force", true, "")
+		cmd.Flags().Bool("dry-run", false, "")
+		cmd.Flags().Bool("verbose", false, "")
+		cmd.Flags().Bool("delete-branch", false, "")
+		cmd.Flags().Bool("merge-check", mergeCheck, "")
+		cmd.Flags().Bool("if-merged", ifMerged, "")
+		return cmd
+	}
+
+	t.Run("details the work on an unmerged branch", func(t *testing.T) {
+		featureWorktree := git.Worktree{Path: featurePath, Branch: "feature"}
+		check, err := checkRemoval(barePath, &featureWorktree, "main")
+		require.NoError(t, err)
+
+		assert.False(t, check.Safe())
+		assert.Equal(t, "feature is not merged into main and has no open pull request", check.Risk("feature", "main"))
+		details := check.Details("main")
+		require.Len(t, details, 2)
+		assert.Equal(t, "1 commit(s) not on main", details[0])
+		assert.Contains(t, details[1], "open pull requests could not be checked")
+	})
+
+	t.Run("if-merged leaves an unmerged branch in place", func(t *testing.T) {
+		require.NoError(t, removeCmd.RunE(newCmd(false, true), []string{"feature"}))
+
+		assert.DirExists(t, featurePath)
+	})
+
+	t.Run("merge-check refuses to force remove an unmerged branch", func(t *testing.T) {
+		err := removeCmd.RunE(newCmd(true, false), []string{"feature"})
+
+		require.Error(t, err)
+		assert.ErrorIs(t, err, arborerrors.ErrInvalidArguments)
+		assert.Contains(t, err.Error(), "refusing to remove 'feature'")
+		assert.DirExists(t, featurePath)
+	})
+
+	t.Run("if-merged leaves a merged branch with uncommitted changes in place", func(t *testing.T) {
+		scratch := filepath.Join(mergedPath, "scratch.txt")
+		require.NoError(t, os.WriteFile(scratch, []byte("notes"), 0644))
+		defer os.Remove(scratch)
+
+		require.NoError(t, removeCmd.RunE(newCmd(false, true), []string{"merged"}))
+
+		assert.DirExists(t, mergedPath)
+	})
+
+	t.Run("if-merged removes a merged branch", func(t *testing.T) {
+		require.NoError(t, removeCmd.RunE(newCmd(false, true), []string{"merged"}))
+
+		assert.NoDirExists(t, mergedPath)
+	})
+}
+
 func TestRemoveCmd_EmptyInputBehavior(t *testing.T) {
 	tmpDir := t.TempDir()
 	repoDir := filepath.Join(tmpDir, "repo")
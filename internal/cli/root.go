@@ -1,11 +1,17 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/spf13/cobra"
 
+	"github.com/michaeldyrynda/arbor/internal/config"
+	arborerrors "github.com/michaeldyrynda/arbor/internal/errors"
+	"github.com/michaeldyrynda/arbor/internal/git"
+	"github.com/michaeldyrynda/arbor/internal/logging"
+	"github.com/michaeldyrynda/arbor/internal/scaffold/types"
 	"github.com/michaeldyrynda/arbor/internal/ui"
 )
 
@@ -15,8 +21,23 @@ var rootCmd = &cobra.Command{
 	Long: `Arbor is a self-contained binary for managing git worktrees
 to assist with agentic development of applications.
 It is cross-project, cross-language, and cross-environment compatible.`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		global, err := config.LoadGlobal()
+		configureOutput(cmd, global)
+		if err := configureLogging(cmd); err != nil {
+			return err
+		}
+		// Commands run without the global config, so arbor install can still
+		// write a new one
+		if err != nil && !errors.Is(err, arborerrors.ErrConfigNotFound) {
+			ui.PrintWarning(fmt.Sprintf("Ignoring global config: %v", err))
+		}
+		configurePrompts(cmd, global)
+		configureNetwork(global)
+		return nil
+	},
 	RunE: func(cmd *cobra.Command, args []string) error {
-		if noColor || !ui.IsInteractive() {
+		if noColor || ui.Plain || !ui.IsInteractive() {
 			return cmd.Help()
 		}
 		printBanner()
@@ -55,8 +76,11 @@ func printBanner() {
 	fmt.Println(style.Render(banner))
 }
 
+// Execute runs the command line. Pass the error to ExitCode for the
+// process exit code.
 func Execute() error {
 	rootCmd.SilenceUsage = true
+	markArgumentErrors(rootCmd)
 	if err := rootCmd.Execute(); err != nil {
 		if ui.IsAbort(err) {
 			return nil
@@ -69,8 +93,101 @@ func Execute() error {
 func init() {
 	rootCmd.PersistentFlags().Bool("dry-run", false, "Preview operations without executing")
 	rootCmd.PersistentFlags().Bool("verbose", false, "Enable verbose output")
+	rootCmd.PersistentFlags().BoolP("quiet", "q", false, "Only print the result, warnings and errors")
+	rootCmd.PersistentFlags().Bool("debug", false, "Log every external command with its arguments and duration")
+	rootCmd.PersistentFlags().Bool("log-timestamps", false, "Prefix step output with the time")
 	rootCmd.PersistentFlags().BoolVar(&noColor, "no-color", false, "Disable colored output")
-	rootCmd.PersistentFlags().Bool("no-interactive", false, "Disable interactive prompts")
+	rootCmd.PersistentFlags().Bool("plain", false, "Disable styling, emoji and symbols")
+	rootCmd.PersistentFlags().Bool("no-input", false, "Never prompt; fail or use defaults instead")
+	rootCmd.PersistentFlags().Bool("no-interactive", false, "Alias for --no-input")
+	if err := rootCmd.PersistentFlags().MarkHidden("no-interactive"); err != nil {
+		panic(fmt.Sprintf("programming error: hiding --no-interactive: %v", err))
+	}
+	rootCmd.MarkFlagsMutuallyExclusive("quiet", "verbose")
+	rootCmd.MarkFlagsMutuallyExclusive("quiet", "debug")
+}
+
+// configureOutput applies the ui.theme from the global config and --plain,
+// and turns styling off for --no-color or when NO_COLOR is set
+func configureOutput(cmd *cobra.Command, global *config.GlobalConfig) {
+	if global != nil {
+		if err := ui.ApplyTheme(global.UI); err != nil {
+			ui.PrintWarning(err.Error())
+		}
+	}
+
+	if mustGetBool(cmd, "plain") {
+		ui.SetPlain()
+		return
+	}
+	if noColor || ui.NoColorRequested() {
+		ui.DisableColor()
+	}
+}
+
+// configureLogging sets the step output level from --verbose and --debug.
+// --debug also logs every external command, and implies --verbose.
+func configureLogging(cmd *cobra.Command) error {
+	level := logging.LevelInfo
+	if mustGetBool(cmd, "quiet") {
+		level = logging.LevelQuiet
+		ui.SetQuiet()
+	}
+	if mustGetBool(cmd, "verbose") {
+		level = logging.LevelVerbose
+	}
+	if mustGetBool(cmd, "debug") {
+		level = logging.LevelDebug
+		if err := cmd.Flags().Set("verbose", "true"); err != nil {
+			return fmt.Errorf("setting --verbose for --debug: %w", err)
+		}
+	}
+	logging.SetLevel(level)
+	logging.SetTimestamps(mustGetBool(cmd, "log-timestamps"))
+	return nil
+}
+
+// configurePrompts turns every prompt off for --no-input, or no_input in the
+// global config
+func configurePrompts(cmd *cobra.Command, global *config.GlobalConfig) {
+	noInput := mustGetBool(cmd, "no-input") || mustGetBool(cmd, "no-interactive")
+	ui.SetNoInput(noInput || (global != nil && global.NoInput))
+}
+
+// newInteraction returns how scaffold steps talk to the user while cmd runs.
+// Database passwords, env.sync values and inputs are only prompted for when
+// arbor is running interactively, which is also what is_interactive
+// conditions see.
+func newInteraction(cmd *cobra.Command) types.Interaction {
+	interaction := types.Interaction{
+		Notify:    ui.Notify,
+		Clipboard: ui.CopyToClipboard,
+	}
+	if !ui.ShouldPrompt(cmd, false) {
+		return interaction
+	}
+
+	interaction.Interactive = true
+	interaction.Password = ui.PromptPassword
+	interaction.Confirm = ui.Confirm
+	interaction.EnvValue = promptEnvValue
+	interaction.Input = ui.PromptInput
+	return interaction
+}
+
+// configureNetwork sets how git clone, fetch and push are retried from the
+// network section of the global config
+func configureNetwork(global *config.GlobalConfig) {
+	git.NetworkRetry = git.DefaultRetryPolicy
+	if global == nil {
+		return
+	}
+	if retries := global.Network.Retries; retries != nil && *retries >= 0 {
+		git.NetworkRetry.Retries = *retries
+	}
+	if delay := global.Network.RetryDelay; delay > 0 {
+		git.NetworkRetry.Delay = delay
+	}
 }
 
 func mustGetString(cmd *cobra.Command, name string) string {
@@ -88,3 +205,19 @@ func mustGetBool(cmd *cobra.Command, name string) bool {
 	}
 	return value
 }
+
+func mustGetInt(cmd *cobra.Command, name string) int {
+	value, err := cmd.Flags().GetInt(name)
+	if err != nil {
+		panic(fmt.Sprintf("programming error: flag %q not defined: %v", name, err))
+	}
+	return value
+}
+
+func mustGetStringSlice(cmd *cobra.Command, name string) []string {
+	value, err := cmd.Flags().GetStringSlice(name)
+	if err != nil {
+		panic(fmt.Sprintf("programming error: flag %q not defined: %v", name, err))
+	}
+	return value
+}
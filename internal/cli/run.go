@@ -0,0 +1,125 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/michaeldyrynda/arbor/internal/config"
+	arborerrors "github.com/michaeldyrynda/arbor/internal/errors"
+	"github.com/michaeldyrynda/arbor/internal/scaffold"
+	"github.com/michaeldyrynda/arbor/internal/ui"
+)
+
+var runCmd = &cobra.Command{
+	Use:   "run [TASK] [FOLDER]",
+	Short: "Run a task defined in arbor.yaml",
+	Long: `Runs a task, a named list of steps defined under tasks in arbor.yaml, in a
+worktree. Steps take the same options, templates and conditions as scaffold
+steps, and run one at a time in the order they are listed.
+
+Arguments:
+  TASK    Name of the task to run (lists the tasks when omitted)
+  FOLDER  Name of the worktree folder (defaults to the current worktree)
+
+With --dry-run, the steps that would run are logged without running them.`,
+	Args:              cobra.MaximumNArgs(2),
+	ValidArgsFunction: completeTaskNames,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pc, err := OpenProjectFromCWD(cmd)
+		if err != nil {
+			return err
+		}
+
+		if len(args) == 0 {
+			return printTasks(cmd.OutOrStdout(), pc.Config)
+		}
+
+		name := strings.ToLower(args[0])
+		if _, ok := pc.Config.Tasks[name]; !ok {
+			return arborerrors.Wrap(arborerrors.ErrInvalidArguments, unknownTaskError(pc.Config, args[0]))
+		}
+
+		target, err := pc.SelectWorktree(args[1:], "Select a worktree to run the task in")
+		if err != nil {
+			return err
+		}
+
+		dryRun := mustGetBool(cmd, "dry-run")
+		verbose := mustGetBool(cmd, "verbose")
+
+		ui.PrintStep(fmt.Sprintf("Running task %s in %s", name, target.Branch))
+		if err := pc.ScaffoldManager().RunTask(name, target.Path, target.Branch, filepath.Base(target.Path), pc.Config, dryRun, verbose); err != nil {
+			ui.PrintErrorWithHint(fmt.Sprintf("Task %s failed", name), err.Error())
+			return err
+		}
+
+		if dryRun {
+			ui.PrintDone(fmt.Sprintf("Would run task %s.", name))
+			return nil
+		}
+		ui.PrintDone(fmt.Sprintf("Task %s complete.", name))
+		return nil
+	},
+}
+
+// unknownTaskError names the tasks the project does define
+func unknownTaskError(cfg *config.Config, name string) error {
+	names := scaffold.TaskNames(cfg)
+	if len(names) == 0 {
+		return fmt.Errorf("unknown task %q, arbor.yaml defines no tasks", name)
+	}
+	return fmt.Errorf("unknown task %q, available tasks: %s", name, strings.Join(names, ", "))
+}
+
+// printTasks lists the project's tasks with their steps
+func printTasks(w io.Writer, cfg *config.Config) error {
+	names := scaffold.TaskNames(cfg)
+	if len(names) == 0 {
+		_, err := fmt.Fprintln(w, "No tasks defined. Add them under tasks in arbor.yaml.")
+		return err
+	}
+
+	for _, name := range names {
+		if _, err := fmt.Fprintln(w, name); err != nil {
+			return err
+		}
+		for _, step := range cfg.Tasks[name] {
+			if _, err := fmt.Fprintf(w, "  %s\n", describeTaskStep(step)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// describeTaskStep renders a step as its name followed by its command or args
+func describeTaskStep(step config.StepConfig) string {
+	parts := []string{step.Name}
+	if step.Command != "" {
+		parts = append(parts, step.Command)
+	}
+	parts = append(parts, step.Args...)
+	return strings.Join(parts, " ")
+}
+
+// completeTaskNames completes the TASK argument of arbor run, then the
+// worktree folder
+func completeTaskNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return completeWorktreeFolders(cmd, args[1:], toComplete)
+	}
+
+	pc, err := OpenProjectFromCWD(cmd)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return scaffold.TaskNames(pc.Config), cobra.ShellCompDirectiveNoFileComp
+}
+
+func init() {
+	rootCmd.AddCommand(runCmd)
+}
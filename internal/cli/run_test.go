@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/michaeldyrynda/arbor/internal/config"
+)
+
+func TestPrintTasks(t *testing.T) {
+	cfg := &config.Config{Tasks: map[string][]config.StepConfig{
+		"fresh": {
+			{Name: "db.create"},
+			{Name: "php.laravel.artisan", Args: []string{"migrate:fresh", "--seed"}},
+		},
+		"build": {
+			{Name: "bash.run", Command: "npm run build"},
+		},
+	}}
+
+	var buf bytes.Buffer
+	require.NoError(t, printTasks(&buf, cfg))
+
+	assert.Equal(t, "build\n  bash.run npm run build\nfresh\n  db.create\n  php.laravel.artisan migrate:fresh --seed\n", buf.String())
+}
+
+func TestPrintTasks_None(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, printTasks(&buf, &config.Config{}))
+
+	assert.Contains(t, buf.String(), "No tasks defined")
+}
+
+func TestRunCmd_ListsTasks(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	project := createWorkspaceProject(t, t.TempDir(), "app")
+	require.NoError(t, os.WriteFile(filepath.Join(project, "arbor.yaml"), []byte("default_branch: main\npreset: \"\"\ntasks:\n  build:\n    - name: bash.run\n      command: npm run build\n"), 0644))
+
+	originalDir, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalDir)
+	require.NoError(t, os.Chdir(filepath.Join(project, "main")))
+
+	var out bytes.Buffer
+	cmd := &cobra.Command{}
+	cmd.SetOut(&out)
+	require.NoError(t, runCmd.RunE(cmd, nil))
+
+	assert.Equal(t, "build\n  bash.run npm run build\n", out.String())
+}
+
+func TestUnknownTaskError(t *testing.T) {
+	cfg := &config.Config{Tasks: map[string][]config.StepConfig{"fresh": nil, "build": nil}}
+
+	assert.EqualError(t, unknownTaskError(cfg, "deploy"), `unknown task "deploy", available tasks: build, fresh`)
+	assert.EqualError(t, unknownTaskError(&config.Config{}, "deploy"), `unknown task "deploy", arbor.yaml defines no tasks`)
+}
@@ -2,11 +2,13 @@ package cli
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
 
 	"github.com/spf13/cobra"
 
 	"github.com/michaeldyrynda/arbor/internal/git"
+	"github.com/michaeldyrynda/arbor/internal/scaffold"
 	"github.com/michaeldyrynda/arbor/internal/ui"
 )
 
@@ -22,10 +24,15 @@ When run from inside a worktree without arguments, you'll be prompted to confirm
 scaffolding the current worktree.
 
 If no path is provided and not inside a worktree, you can interactively select
-a worktree to scaffold.`,
+a worktree to scaffold.
+
+--dry-run --plan prints the steps that would run, in order, with their
+templates resolved, whether their conditions hold and the files and
+databases they would touch, without running anything. Secret references
+are shown as written. Add --json for JSON.`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		pc, err := OpenProjectFromCWD()
+		pc, err := OpenProjectFromCWD(cmd)
 		if err != nil {
 			return fmt.Errorf("opening project: %w", err)
 		}
@@ -48,7 +55,7 @@ a worktree to scaffold.`,
 			worktreePath := args[0]
 
 			if !filepath.IsAbs(worktreePath) {
-				worktreePath = filepath.Join(pc.ProjectPath, worktreePath)
+				worktreePath = filepath.Join(git.WorktreeRoot(pc.BarePath), worktreePath)
 			}
 
 			absWorktreePath, err := filepath.Abs(worktreePath)
@@ -72,14 +79,9 @@ a worktree to scaffold.`,
 			}
 		} else if pc.IsInWorktree() {
 			for _, wt := range worktrees {
-				wtAbsPath, _ := filepath.Abs(wt.Path)
-				projectRootAbsPath, _ := filepath.Abs(pc.ProjectPath)
-
-				if filepath.Dir(wtAbsPath) == projectRootAbsPath {
-					if wt.IsCurrent {
-						selectedWorktree = &wt
-						break
-					}
+				if wt.IsCurrent {
+					selectedWorktree = &wt
+					break
 				}
 			}
 
@@ -87,7 +89,7 @@ a worktree to scaffold.`,
 				return fmt.Errorf("current worktree not found")
 			}
 
-			if ui.IsInteractive() {
+			if ui.CanPrompt() && !wantsPlan(cmd) {
 				confirmed, err := ui.ConfirmScaffold(selectedWorktree.Branch)
 				if err != nil {
 					return err
@@ -98,7 +100,7 @@ a worktree to scaffold.`,
 				}
 			}
 		} else {
-			if !ui.IsInteractive() {
+			if !ui.CanPrompt() {
 				return fmt.Errorf("worktree path required (run from project root with path, or use interactive mode)")
 			}
 
@@ -113,22 +115,40 @@ a worktree to scaffold.`,
 			return fmt.Errorf("no worktree selected")
 		}
 
-		ui.PrintStep(fmt.Sprintf("Scaffolding worktree: %s", selectedWorktree.Branch))
-		ui.PrintInfo(fmt.Sprintf("Path: %s", selectedWorktree.Path))
-
 		preset := pc.Config.Preset
 		if preset == "" {
 			preset = pc.PresetManager().Detect(selectedWorktree.Path)
 		}
 
+		repoName := filepath.Base(pc.ProjectPath)
+		worktreeName := filepath.Base(selectedWorktree.Path)
+
+		if wantsPlan(cmd) {
+			plan, err := pc.ScaffoldManager().PlanScaffold(scaffold.PlanOptions{
+				WorktreePath: selectedWorktree.Path,
+				Branch:       selectedWorktree.Branch,
+				RepoName:     repoName,
+				SiteName:     worktreeName,
+				Preset:       preset,
+			}, pc.Config)
+			if err != nil {
+				return err
+			}
+			return printScaffoldPlan(cmd, os.Stdout, plan)
+		}
+
+		ui.PrintStep(fmt.Sprintf("Scaffolding worktree: %s", selectedWorktree.Branch))
+		ui.PrintInfo(fmt.Sprintf("Path: %s", selectedWorktree.Path))
+
 		if verbose && preset != "" {
 			ui.PrintInfo(fmt.Sprintf("Running scaffold for preset: %s", preset))
 		}
 
-		repoName := filepath.Base(pc.ProjectPath)
-		worktreeName := filepath.Base(selectedWorktree.Path)
-
-		if err := pc.ScaffoldManager().RunScaffold(selectedWorktree.Path, selectedWorktree.Branch, repoName, worktreeName, preset, pc.Config, dryRun, verbose); err != nil {
+		err = pc.ScaffoldManager().RunScaffold(selectedWorktree.Path, selectedWorktree.Branch, repoName, worktreeName, preset, pc.Config, dryRun, verbose)
+		if !dryRun && selectedWorktree.Branch != pc.DefaultBranch {
+			recordWorktree(pc, selectedWorktree.Branch, selectedWorktree.Path, preset)
+		}
+		if err != nil {
 			ui.PrintErrorWithHint("Scaffold steps failed", err.Error())
 			return err
 		}
@@ -140,4 +160,5 @@ a worktree to scaffold.`,
 
 func init() {
 	rootCmd.AddCommand(scaffoldCmd)
+	addPlanFlags(scaffoldCmd)
 }
@@ -0,0 +1,46 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/michaeldyrynda/arbor/internal/git"
+)
+
+// parseStaleAge parses how long a worktree must be idle to count as stale:
+// a number of days such as 30 or 30d, weeks such as 2w, or hours such as 12h
+func parseStaleAge(spec string) (time.Duration, error) {
+	spec = strings.ToLower(strings.TrimSpace(spec))
+	units := map[string]time.Duration{"h": time.Hour, "d": 24 * time.Hour, "w": 7 * 24 * time.Hour}
+
+	unit := units["d"]
+	number := spec
+	if n := len(spec); n > 0 {
+		if u, ok := units[spec[n-1:]]; ok {
+			unit, number = u, spec[:n-1]
+		}
+	}
+
+	count, err := strconv.Atoi(number)
+	if err != nil || count <= 0 {
+		return 0, fmt.Errorf("invalid age %q: use a positive number of days, weeks or hours, e.g. 30d, 2w or 12h", spec)
+	}
+	return time.Duration(count) * unit, nil
+}
+
+// lastActive returns when the worktree was last worked on: its last commit
+// or the last change to a file it has not committed, whichever is later
+func lastActive(lastCommit time.Time, worktreePath string) time.Time {
+	if changed, err := git.LastActivity(worktreePath); err == nil && changed.After(lastCommit) {
+		return changed
+	}
+	return lastCommit
+}
+
+// isStale reports whether a worktree last active at lastActive has been idle
+// for at least age. Worktrees with no known activity are not stale.
+func isStale(lastActive time.Time, age time.Duration) bool {
+	return !lastActive.IsZero() && time.Since(lastActive) >= age
+}
@@ -0,0 +1,36 @@
+package cli
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseStaleAge(t *testing.T) {
+	tests := []struct {
+		spec string
+		want time.Duration
+	}{
+		{"30", 30 * 24 * time.Hour},
+		{"30d", 30 * 24 * time.Hour},
+		{"2W", 14 * 24 * time.Hour},
+		{"12h", 12 * time.Hour},
+	}
+	for _, tt := range tests {
+		got, err := parseStaleAge(tt.spec)
+		assert.NoError(t, err, tt.spec)
+		assert.Equal(t, tt.want, got, tt.spec)
+	}
+
+	for _, spec := range []string{"", "d", "soon", "-3d", "3m", "0", "0d", "0h"} {
+		_, err := parseStaleAge(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestIsStale(t *testing.T) {
+	assert.True(t, isStale(time.Now().Add(-48*time.Hour), 24*time.Hour))
+	assert.False(t, isStale(time.Now(), 24*time.Hour))
+	assert.False(t, isStale(time.Time{}, 24*time.Hour), "unknown activity is not stale")
+}
@@ -0,0 +1,195 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/michaeldyrynda/arbor/internal/config"
+	arborerrors "github.com/michaeldyrynda/arbor/internal/errors"
+	"github.com/michaeldyrynda/arbor/internal/logging"
+	"github.com/michaeldyrynda/arbor/internal/scaffold"
+	"github.com/michaeldyrynda/arbor/internal/scaffold/types"
+	"github.com/michaeldyrynda/arbor/internal/stats"
+	"github.com/michaeldyrynda/arbor/internal/ui"
+)
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show how long scaffold steps take",
+	Long: `Shows the run count and the mean, median (p50), p90 and longest time of each
+scaffold step across recorded runs, slowest first, to help decide what to cache
+or run in parallel. Failed runs are counted but not timed.
+
+Timings are only recorded once stats are turned on in the global config:
+
+  # ~/.config/arbor/arbor.yaml
+  stats: true
+
+They are kept in stats.jsonl next to that file and never leave the machine.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := statsPath()
+		if err != nil {
+			return err
+		}
+
+		records, err := stats.Read(path)
+		if err != nil {
+			return err
+		}
+
+		if mustGetBool(cmd, "project") {
+			pc, err := OpenProjectFromCWD(cmd)
+			if err != nil {
+				return err
+			}
+			records = projectStats(records, pc.ProjectPath)
+		}
+
+		if mustGetBool(cmd, "json") {
+			return printStatsJSON(os.Stdout, stats.Summarise(records))
+		}
+
+		if len(records) == 0 {
+			global, err := config.LoadGlobal()
+			if err != nil && !errors.Is(err, arborerrors.ErrConfigNotFound) {
+				return fmt.Errorf("loading global config: %w", err)
+			}
+			if global == nil || !global.Stats {
+				ui.PrintInfo("No timings recorded. Set stats: true in the global arbor.yaml to record them.")
+			} else {
+				ui.PrintInfo("No timings recorded yet.")
+			}
+			return nil
+		}
+		return printStats(os.Stdout, stats.Summarise(records))
+	},
+}
+
+func statsPath() (string, error) {
+	dir, err := config.GetGlobalConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, stats.FileName), nil
+}
+
+// stepTimings returns recordStepTimings when stats are turned on in the
+// global config, and nil otherwise
+func stepTimings() func(ctx *types.ScaffoldContext, results []scaffold.ExecutionResult) {
+	global, err := config.LoadGlobal()
+	if err != nil {
+		if !errors.Is(err, arborerrors.ErrConfigNotFound) {
+			logging.Verbosef("Not recording step timings: %v", err)
+		}
+		return nil
+	}
+	if !global.Stats {
+		return nil
+	}
+	return recordStepTimings
+}
+
+// recordStepTimings appends the steps that ran to the stats file. Stats are
+// a convenience, so failing to write them is only logged.
+func recordStepTimings(ctx *types.ScaffoldContext, results []scaffold.ExecutionResult) {
+	path, err := statsPath()
+	if err != nil {
+		return
+	}
+
+	now := time.Now().UTC()
+	var records []stats.Record
+	for _, result := range results {
+		if result.Skipped || result.Step == nil || result.Duration == 0 {
+			continue
+		}
+		records = append(records, stats.Record{
+			Time:       now,
+			Project:    ctx.ProjectPath,
+			Step:       result.Step.Name(),
+			DurationMs: result.Duration.Milliseconds(),
+			Failed:     result.Error != nil,
+		})
+	}
+
+	if err := stats.Append(path, records); err != nil {
+		ui.PrintWarning(fmt.Sprintf("Could not record step timings: %v", err))
+	}
+}
+
+// projectStats returns the records for the project at projectPath
+func projectStats(records []stats.Record, projectPath string) []stats.Record {
+	var filtered []stats.Record
+	for _, record := range records {
+		if record.Project == projectPath {
+			filtered = append(filtered, record)
+		}
+	}
+	return filtered
+}
+
+func printStats(w io.Writer, summaries []stats.Summary) error {
+	rows := make([][]string, len(summaries))
+	for i, s := range summaries {
+		rows[i] = []string{
+			s.Step,
+			strconv.Itoa(s.Runs),
+			formatStatDuration(s.Mean),
+			formatStatDuration(s.P50),
+			formatStatDuration(s.P90),
+			formatStatDuration(s.Max),
+			strconv.Itoa(s.Failures),
+		}
+	}
+
+	_, err := fmt.Fprintln(w, ui.RenderTable([]string{"STEP", "RUNS", "MEAN", "P50", "P90", "MAX", "FAILED"}, rows))
+	return err
+}
+
+func printStatsJSON(w io.Writer, summaries []stats.Summary) error {
+	type summaryJSON struct {
+		Step     string `json:"step"`
+		Runs     int    `json:"runs"`
+		Failures int    `json:"failures"`
+		MeanMs   int64  `json:"meanMs"`
+		P50Ms    int64  `json:"p50Ms"`
+		P90Ms    int64  `json:"p90Ms"`
+		MaxMs    int64  `json:"maxMs"`
+	}
+
+	out := make([]summaryJSON, len(summaries))
+	for i, s := range summaries {
+		out[i] = summaryJSON{s.Step, s.Runs, s.Failures, s.Mean.Milliseconds(), s.P50.Milliseconds(), s.P90.Milliseconds(), s.Max.Milliseconds()}
+	}
+	data, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
+
+// formatStatDuration shows sub-second steps in milliseconds and longer ones
+// to a tenth of a second, e.g. 350ms or 1m35.2s
+func formatStatDuration(d time.Duration) string {
+	if d < time.Second {
+		return d.Round(time.Millisecond).String()
+	}
+	return d.Round(100 * time.Millisecond).String()
+}
+
+func init() {
+	rootCmd.AddCommand(statsCmd)
+
+	statsCmd.Flags().Bool("project", false, "Only include runs for the current project")
+	statsCmd.Flags().Bool("json", false, "Output as JSON")
+}
@@ -0,0 +1,43 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/michaeldyrynda/arbor/internal/stats"
+)
+
+func TestProjectStats(t *testing.T) {
+	records := []stats.Record{
+		{Project: "/code/app", Step: "php.composer"},
+		{Project: "/code/other", Step: "node.npm"},
+		{Project: "/code/app", Step: "db.create"},
+	}
+
+	filtered := projectStats(records, "/code/app")
+	require.Len(t, filtered, 2)
+	assert.Equal(t, "php.composer", filtered[0].Step)
+	assert.Equal(t, "db.create", filtered[1].Step)
+}
+
+func TestPrintStats(t *testing.T) {
+	summaries := []stats.Summary{
+		{Step: "php.composer", Runs: 3, Failures: 1, Mean: 95234 * time.Millisecond, P50: 90 * time.Second, P90: 101 * time.Second, Max: 101 * time.Second},
+		{Step: "db.create", Runs: 2, Mean: 350 * time.Millisecond, P50: 300 * time.Millisecond, P90: 400 * time.Millisecond, Max: 400 * time.Millisecond},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, printStats(&buf, summaries))
+	out := buf.String()
+	assert.Contains(t, out, "php.composer")
+	assert.Contains(t, out, "1m35.2s")
+	assert.Contains(t, out, "350ms")
+
+	buf.Reset()
+	require.NoError(t, printStatsJSON(&buf, summaries))
+	assert.Contains(t, buf.String(), `"meanMs": 95234`)
+}
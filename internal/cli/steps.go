@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/michaeldyrynda/arbor/internal/scaffold/steps"
+	"github.com/michaeldyrynda/arbor/internal/ui"
+)
+
+var stepsCmd = &cobra.Command{
+	Use:   "steps",
+	Short: "Describe the built-in scaffold steps",
+}
+
+var stepsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List every scaffold step with its default priority and config fields",
+	Long: `Lists every registered scaffold step in the order steps run by default,
+with its default priority and the config keys it reads besides name, enabled,
+condition and inputs.
+
+Steps run in ascending priority, and steps sharing a priority run in
+parallel. Steps that list priority among their fields can be moved with the
+priority key.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if mustGetBool(cmd, "json") {
+			return printStepsJSON(os.Stdout, steps.Steps())
+		}
+		return printSteps(os.Stdout, steps.Steps())
+	},
+}
+
+func printSteps(w io.Writer, infos []steps.StepInfo) error {
+	rows := make([][]string, len(infos))
+	for i, info := range infos {
+		rows[i] = []string{info.Name, strconv.Itoa(info.Priority), strings.Join(info.Fields, ", ")}
+	}
+
+	_, err := fmt.Fprintln(w, ui.RenderTable([]string{"STEP", "PRIORITY", "FIELDS"}, rows))
+	return err
+}
+
+func printStepsJSON(w io.Writer, infos []steps.StepInfo) error {
+	data, err := json.MarshalIndent(infos, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
+
+func init() {
+	rootCmd.AddCommand(stepsCmd)
+	stepsCmd.AddCommand(stepsListCmd)
+
+	stepsListCmd.Flags().Bool("json", false, "Output as JSON")
+}
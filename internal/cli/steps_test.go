@@ -0,0 +1,30 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/michaeldyrynda/arbor/internal/scaffold/steps"
+)
+
+func TestPrintSteps(t *testing.T) {
+	infos := []steps.StepInfo{
+		{Name: "env.write", Priority: 0, Fields: []string{"key", "value"}},
+		{Name: "notify", Priority: 110, Fields: []string{"title", "priority"}},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, printSteps(&buf, infos))
+	assert.Contains(t, buf.String(), "env.write")
+	assert.Contains(t, buf.String(), "title, priority")
+
+	buf.Reset()
+	require.NoError(t, printStepsJSON(&buf, infos))
+	var decoded []steps.StepInfo
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, infos, decoded)
+}
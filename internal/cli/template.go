@@ -0,0 +1,51 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/michaeldyrynda/arbor/internal/scaffold/template"
+	"github.com/michaeldyrynda/arbor/internal/ui"
+)
+
+var templateCmd = &cobra.Command{
+	Use:   "template",
+	Short: "Reference for step templates",
+}
+
+var templateVarsCmd = &cobra.Command{
+	Use:   "vars",
+	Short: "List the fields and functions available to step templates",
+	Long: `Lists the context fields and functions available in templated step
+values such as env.write values, command.run commands and binary args.
+
+Example:
+  - name: env.write
+    key: APP_URL
+    value: "https://{{ .Branch | slug }}.test"`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var fields [][]string
+		for _, field := range template.Fields {
+			fields = append(fields, []string{"." + field.Name, field.Description})
+		}
+
+		var functions [][]string
+		for _, fn := range template.Functions {
+			functions = append(functions, []string{fn.Name, fn.Usage, fn.Description})
+		}
+
+		out := cmd.OutOrStdout()
+		fmt.Fprintln(out, ui.RenderTable([]string{"FIELD", "DESCRIPTION"}, fields))
+		fmt.Fprintln(out, "Project vars and values captured by earlier steps are also available by name, e.g. {{ .api_url }}.")
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, ui.RenderTable([]string{"FUNCTION", "USAGE", "DESCRIPTION"}, functions))
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(templateCmd)
+	templateCmd.AddCommand(templateVarsCmd)
+}
@@ -0,0 +1,133 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/michaeldyrynda/arbor/internal/config"
+	"github.com/michaeldyrynda/arbor/internal/ui"
+)
+
+var tmuxCmd = &cobra.Command{
+	Use:   "tmux [FOLDER]",
+	Short: "Open a tmux session for a worktree",
+	Long: `Creates or attaches to a tmux session for a worktree, named after its folder.
+
+Arguments:
+  FOLDER  Name of the worktree folder (defaults to the current worktree)
+
+New sessions open the windows listed under tmux.windows in arbor.yaml, each
+running its command in the worktree:
+
+  tmux:
+    windows:
+      - name: editor
+        command: nvim .
+      - name: server
+        command: php artisan serve
+      - name: logs
+        command: tail -f storage/logs/laravel.log
+
+Inside tmux, arbor switches the client to the session instead of attaching.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pc, err := OpenProjectFromCWD(cmd)
+		if err != nil {
+			return err
+		}
+
+		if !isCommandAvailable("tmux") {
+			return fmt.Errorf("tmux is not installed")
+		}
+
+		target, err := pc.SelectWorktree(args, "Select a worktree")
+		if err != nil {
+			return err
+		}
+
+		if err := startTmuxSession(target.Path, pc.Config.Tmux.Windows); err != nil {
+			return fmt.Errorf("starting tmux session: %w", err)
+		}
+
+		name := tmuxSessionName(target.Path)
+		if mustGetBool(cmd, "detach") || !ui.IsInteractive() {
+			ui.PrintSuccess(fmt.Sprintf("tmux session %s is running", name))
+			return nil
+		}
+
+		action := "attach-session"
+		if os.Getenv("TMUX") != "" {
+			action = "switch-client"
+		}
+
+		c := exec.Command("tmux", action, "-t", "="+name)
+		c.Stdin = os.Stdin
+		c.Stdout = os.Stdout
+		c.Stderr = os.Stderr
+		return c.Run()
+	},
+}
+
+// tmuxSessionName names a worktree's session after its folder. tmux does
+// not allow "." or ":" in session names.
+func tmuxSessionName(worktreePath string) string {
+	return strings.NewReplacer(".", "_", ":", "_").Replace(filepath.Base(worktreePath))
+}
+
+// startTmuxSession starts a detached tmux session in the worktree with the
+// given windows, unless one is already running
+func startTmuxSession(worktreePath string, windows []config.TmuxWindow) error {
+	if !isCommandAvailable("tmux") {
+		return fmt.Errorf("tmux is not installed")
+	}
+
+	name := tmuxSessionName(worktreePath)
+	if exec.Command("tmux", "has-session", "-t", "="+name).Run() == nil {
+		return nil
+	}
+
+	for _, args := range tmuxSessionCommands(name, worktreePath, windows) {
+		if output, err := exec.Command("tmux", args...).CombinedOutput(); err != nil {
+			return fmt.Errorf("tmux %s: %w: %s", args[0], err, strings.TrimSpace(string(output)))
+		}
+	}
+	return nil
+}
+
+// tmuxSessionCommands returns the tmux invocations that create the session
+// and its windows, typing each window's command into it
+func tmuxSessionCommands(name, worktreePath string, windows []config.TmuxWindow) [][]string {
+	if len(windows) == 0 {
+		return [][]string{{"new-session", "-d", "-s", name, "-c", worktreePath}}
+	}
+
+	var commands [][]string
+	for i, window := range windows {
+		windowName := window.Name
+		if windowName == "" {
+			windowName = fmt.Sprintf("window%d", i+1)
+		}
+
+		if i == 0 {
+			commands = append(commands, []string{"new-session", "-d", "-s", name, "-n", windowName, "-c", worktreePath})
+		} else {
+			commands = append(commands, []string{"new-window", "-d", "-t", name + ":", "-n", windowName, "-c", worktreePath})
+		}
+
+		if window.Command != "" {
+			commands = append(commands, []string{"send-keys", "-t", name + ":" + windowName, window.Command, "Enter"})
+		}
+	}
+	return commands
+}
+
+func init() {
+	rootCmd.AddCommand(tmuxCmd)
+
+	tmuxCmd.Flags().BoolP("detach", "d", false, "Start the session without attaching to it")
+}
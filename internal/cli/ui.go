@@ -0,0 +1,156 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/spf13/cobra"
+
+	"github.com/michaeldyrynda/arbor/internal/git"
+	"github.com/michaeldyrynda/arbor/internal/ui"
+	"github.com/michaeldyrynda/arbor/internal/utils"
+)
+
+// dashboardLogLimit is the number of commits shown by the dashboard log view
+const dashboardLogLimit = 100
+
+var uiCmd = &cobra.Command{
+	Use:   "ui",
+	Short: "Open the worktree dashboard",
+	Long: `Opens a full-screen dashboard listing the project's worktrees with
+live status: uncommitted changes, commits ahead of and behind the default
+branch, merge status and processes running inside each worktree.
+
+Keys:
+  n  create a worktree (arbor work)
+  o  open the worktree in $VISUAL or $EDITOR
+  s  open a shell in the worktree
+  d  remove the worktree (arbor remove)
+  p  prune merged worktrees (arbor prune)
+  l  view the worktree's commit log
+  r  refresh
+  q  quit`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !ui.CanPrompt() {
+			return fmt.Errorf("arbor ui needs an interactive terminal")
+		}
+
+		pc, err := OpenProjectFromCWD(cmd)
+		if err != nil {
+			return err
+		}
+
+		executable, err := os.Executable()
+		if err != nil {
+			return fmt.Errorf("finding arbor executable: %w", err)
+		}
+
+		arbor := func(args ...string) *exec.Cmd {
+			return exec.Command(executable, args...)
+		}
+
+		return ui.RunDashboard(filepath.Base(pc.ProjectPath), ui.DashboardActions{
+			Load: func() ([]ui.DashboardRow, error) {
+				return loadDashboardRows(pc)
+			},
+			Log: func(wt git.Worktree) (string, error) {
+				return git.Log(wt.Path, dashboardLogLimit)
+			},
+			Create: func(branch string) *exec.Cmd {
+				return arbor("work", branch)
+			},
+			Open: editorCommand,
+			Shell: func(wt git.Worktree) *exec.Cmd {
+				shell := os.Getenv("SHELL")
+				if shell == "" {
+					shell, _ = utils.ShellArgs(runtime.GOOS, pc.Config.WindowsShell, "")
+				}
+				c := exec.Command(shell)
+				c.Dir = wt.Path
+				return c
+			},
+			Remove: func(wt git.Worktree) *exec.Cmd {
+				return arbor("remove", filepath.Base(wt.Path))
+			},
+			Prune: func() *exec.Cmd {
+				return arbor("prune")
+			},
+		})
+	},
+}
+
+// loadDashboardRows lists the project's worktrees and gathers the status of
+// each one concurrently. A worktree whose status cannot be read keeps its
+// row, with the error in Err.
+func loadDashboardRows(pc *ProjectContext) ([]ui.DashboardRow, error) {
+	worktrees, err := git.ListWorktreesDetailed(pc.BarePath, pc.CWD, pc.DefaultBranch)
+	if err != nil {
+		return nil, fmt.Errorf("listing worktrees: %w", err)
+	}
+
+	var rows []ui.DashboardRow
+	for _, wt := range worktrees {
+		if wt.Branch == "(bare)" {
+			continue
+		}
+		rows = append(rows, ui.DashboardRow{Worktree: wt})
+	}
+
+	var wg sync.WaitGroup
+	for i := range rows {
+		wg.Add(1)
+		go func(row *ui.DashboardRow) {
+			defer wg.Done()
+			row.Err = loadDashboardStatus(row, pc.DefaultBranch)
+		}(&rows[i])
+	}
+	wg.Wait()
+
+	return rows, nil
+}
+
+// loadDashboardStatus fills in the status of a dashboard row. Errors are
+// shown in the row, so they leave out its path.
+func loadDashboardStatus(row *ui.DashboardRow, defaultBranch string) error {
+	dirty, err := git.IsDirty(row.Path)
+	if err != nil {
+		return fmt.Errorf("checking for changes: %w", err)
+	}
+	row.Dirty = dirty
+
+	if !row.IsMain {
+		if row.Ahead, row.Behind, err = git.AheadBehind(row.Path, defaultBranch); err != nil {
+			return fmt.Errorf("comparing with %s: %w", defaultBranch, err)
+		}
+	}
+	row.Processes = utils.ProcessesIn(row.Path)
+	return nil
+}
+
+// editorCommand opens wt in $VISUAL or $EDITOR, which may include arguments
+// such as "code --wait"
+func editorCommand(wt git.Worktree) *exec.Cmd {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+
+	fields := strings.Fields(editor)
+	if len(fields) == 0 {
+		fields = []string{"vi"}
+	}
+
+	c := exec.Command(fields[0], append(fields[1:], wt.Path)...)
+	c.Dir = wt.Path
+	return c
+}
+
+func init() {
+	rootCmd.AddCommand(uiCmd)
+}
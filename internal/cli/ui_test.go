@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/michaeldyrynda/arbor/internal/git"
+	"github.com/michaeldyrynda/arbor/internal/ui"
+)
+
+func TestUICmd_RequiresTerminal(t *testing.T) {
+	ui.SetNoInput(true)
+	t.Cleanup(func() { ui.SetNoInput(false) })
+
+	err := uiCmd.RunE(uiCmd, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "needs an interactive terminal")
+}
+
+func TestLoadDashboardRows(t *testing.T) {
+	barePath, _ := createTestRepo(t)
+	projectPath := filepath.Dir(barePath)
+	mainPath := filepath.Join(projectPath, "main")
+	featurePath := filepath.Join(projectPath, "feature")
+	runGitCmd(t, barePath, "worktree", "add", mainPath, "main")
+	runGitCmd(t, barePath, "worktree", "add", "-b", "feature", featurePath, "main")
+	runGitCmd(t, featurePath, "-c", "user.name=Test", "-c", "user.email=test@example.com", "commit", "--allow-empty", "-m", "Feature")
+	require.NoError(t, os.WriteFile(filepath.Join(featurePath, "notes.txt"), []byte("wip"), 0644))
+
+	rows, err := loadDashboardRows(&ProjectContext{CWD: projectPath, BarePath: barePath, ProjectPath: projectPath, DefaultBranch: "main"})
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+
+	byBranch := map[string]ui.DashboardRow{}
+	for _, row := range rows {
+		byBranch[row.Branch] = row
+	}
+	assert.False(t, byBranch["main"].Dirty)
+	assert.True(t, byBranch["feature"].Dirty)
+	assert.Equal(t, 1, byBranch["feature"].Ahead)
+	assert.Equal(t, 0, byBranch["feature"].Behind)
+}
+
+func TestLoadDashboardRows_MissingWorktree(t *testing.T) {
+	barePath, _ := createTestRepo(t)
+	projectPath := filepath.Dir(barePath)
+	mainPath := filepath.Join(projectPath, "main")
+	featurePath := filepath.Join(projectPath, "feature")
+	runGitCmd(t, barePath, "worktree", "add", mainPath, "main")
+	runGitCmd(t, barePath, "worktree", "add", "-b", "feature", featurePath, "main")
+	require.NoError(t, os.RemoveAll(featurePath))
+
+	rows, err := loadDashboardRows(&ProjectContext{CWD: projectPath, BarePath: barePath, ProjectPath: projectPath, DefaultBranch: "main"})
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+
+	byBranch := map[string]ui.DashboardRow{}
+	for _, row := range rows {
+		byBranch[row.Branch] = row
+	}
+	assert.NoError(t, byBranch["main"].Err)
+	require.Error(t, byBranch["feature"].Err)
+	assert.Contains(t, byBranch["feature"].Err.Error(), "checking for changes")
+}
+
+func TestEditorCommand(t *testing.T) {
+	wt := git.Worktree{Path: t.TempDir()}
+
+	t.Setenv("VISUAL", "code --wait")
+	t.Setenv("EDITOR", "nano")
+	c := editorCommand(wt)
+	assert.Equal(t, []string{"code", "--wait", wt.Path}, c.Args)
+	assert.Equal(t, wt.Path, c.Dir)
+
+	t.Setenv("VISUAL", "")
+	assert.Equal(t, []string{"nano", wt.Path}, editorCommand(wt).Args)
+
+	t.Setenv("EDITOR", "")
+	assert.Equal(t, []string{"vi", wt.Path}, editorCommand(wt).Args)
+}
@@ -0,0 +1,175 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/michaeldyrynda/arbor/internal/config"
+	arborerrors "github.com/michaeldyrynda/arbor/internal/errors"
+	"github.com/michaeldyrynda/arbor/internal/git"
+	"github.com/michaeldyrynda/arbor/internal/scaffold"
+	"github.com/michaeldyrynda/arbor/internal/scaffold/steps"
+	"github.com/michaeldyrynda/arbor/internal/scaffold/types"
+	"github.com/michaeldyrynda/arbor/internal/ui"
+)
+
+var validateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check a project's configuration and tools before use",
+	Long: `Checks that a project is ready to use, for CI or after editing arbor.yaml:
+
+  - arbor.yaml matches the schema, including step and condition names
+  - conditions on configured steps are well formed; those that are false
+    in the main worktree are reported as warnings, as the step would be
+    skipped there
+  - the programs run by steps that would run, such as composer or bash,
+    are installed
+
+Exits non-zero when there are problems. Warnings alone do not fail.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		configPath, err := resolveConfigPath(nil)
+		if err != nil {
+			return err
+		}
+
+		err = config.ValidateProjectFile(configPath, config.ValidateOptions{
+			StepNames:      steps.Names(),
+			ConditionNames: types.ConditionNames(),
+		})
+		var validationErr *config.ValidationError
+		if errors.As(err, &validationErr) {
+			for _, issue := range validationErr.Issues {
+				ui.PrintError(issue.String())
+			}
+			ui.PrintInfo(fmt.Sprintf("See %s for the configuration reference", config.SchemaDocURL))
+			return arborerrors.Wrap(arborerrors.ErrInvalidConfig, fmt.Errorf("%s has %d problem(s)", configPath, len(validationErr.Issues)))
+		}
+		if err != nil {
+			return err
+		}
+		ui.PrintSuccess(fmt.Sprintf("%s matches the schema", configPath))
+
+		pc, err := OpenProjectFromCWD(cmd)
+		if err != nil {
+			return err
+		}
+
+		main, err := mainWorktree(pc)
+		if err != nil {
+			return err
+		}
+
+		report, err := validateProject(pc, main.Path, main.Branch)
+		if err != nil {
+			return err
+		}
+		for _, warning := range report.warnings {
+			ui.PrintWarning(warning)
+		}
+		for _, problem := range report.problems {
+			ui.PrintError(problem)
+		}
+		if len(report.problems) > 0 {
+			return fmt.Errorf("found %d problem(s)", len(report.problems))
+		}
+
+		ui.PrintDone("Project is ready to use")
+		return nil
+	},
+}
+
+// validationReport holds what arbor validate found. Problems fail the
+// command; warnings are only printed.
+type validationReport struct {
+	problems []string
+	warnings []string
+}
+
+func mainWorktree(pc *ProjectContext) (*git.Worktree, error) {
+	worktrees, err := git.ListWorktreesDetailed(pc.BarePath, pc.CWD, pc.DefaultBranch)
+	if err != nil {
+		return nil, fmt.Errorf("listing worktrees: %w", err)
+	}
+	for _, wt := range worktrees {
+		if wt.IsMain {
+			return &wt, nil
+		}
+	}
+	return nil, fmt.Errorf("no main worktree found")
+}
+
+// validateProject checks the configured steps' conditions and the tools
+// the scaffold needs against the main worktree
+func validateProject(pc *ProjectContext, mainPath, mainBranch string) (*validationReport, error) {
+	report := &validationReport{}
+	siteName := filepath.Base(mainPath)
+
+	ctx, err := scaffold.TemplateContext(mainPath, mainBranch, siteName, pc.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	check := func(label string, stepConfig config.StepConfig) {
+		if len(stepConfig.Condition) == 0 {
+			return
+		}
+		ok, err := ctx.EvaluateCondition(stepConfig.Condition)
+		if err != nil {
+			report.problems = append(report.problems, fmt.Sprintf("%s (%s): condition: %v", label, stepConfig.Name, err))
+		} else if !ok {
+			report.warnings = append(report.warnings, fmt.Sprintf("%s (%s) would be skipped in the main worktree: its condition is false", label, stepConfig.Name))
+		}
+	}
+	for i, stepConfig := range pc.Config.Scaffold.Steps {
+		check(fmt.Sprintf("scaffold.steps[%d]", i), stepConfig)
+	}
+	for i, stepConfig := range pc.Config.GlobalSteps {
+		check(fmt.Sprintf("global scaffold.steps[%d]", i), stepConfig)
+	}
+	for i, stepConfig := range pc.Config.Cleanup {
+		check(fmt.Sprintf("cleanup[%d]", i), stepConfig)
+	}
+	for i, stepConfig := range pc.Config.GlobalCleanup {
+		check(fmt.Sprintf("global cleanup[%d]", i), stepConfig)
+	}
+
+	preset := pc.Config.Preset
+	if preset == "" {
+		preset = pc.PresetManager().Detect(mainPath)
+	}
+	plan, err := pc.ScaffoldManager().PlanScaffold(scaffold.PlanOptions{
+		WorktreePath: mainPath,
+		Branch:       mainBranch,
+		RepoName:     filepath.Base(pc.ProjectPath),
+		SiteName:     siteName,
+		Preset:       preset,
+	}, pc.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	missing := make(map[string]bool)
+	for _, step := range plan.Steps {
+		if !step.Run {
+			continue
+		}
+		if step.Error != "" {
+			report.problems = append(report.problems, fmt.Sprintf("%s: %s", step.Name, step.Error))
+		}
+		for _, tool := range step.Tools {
+			if !missing[tool] && !isCommandAvailable(tool) {
+				missing[tool] = true
+				report.problems = append(report.problems, fmt.Sprintf("%s needs %s, which is not installed", step.Name, tool))
+			}
+		}
+	}
+	return report, nil
+}
+
+func init() {
+	rootCmd.AddCommand(validateCmd)
+}
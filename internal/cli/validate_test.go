@@ -0,0 +1,42 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/michaeldyrynda/arbor/internal/config"
+)
+
+func TestValidateProject(t *testing.T) {
+	projectDir := t.TempDir()
+	mainPath := filepath.Join(projectDir, "main")
+	require.NoError(t, os.MkdirAll(mainPath, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(mainPath, "composer.json"), []byte("{}"), 0644))
+
+	cfg := &config.Config{
+		Scaffold: config.ScaffoldConfig{
+			Override: true,
+			Steps: []config.StepConfig{
+				{Name: "bash.run", Command: "echo seeded", Condition: map[string]interface{}{"file_exists": "composer.json"}},
+				{Name: "bash.run", Command: "echo never", Condition: map[string]interface{}{"file_exists": "package.json"}},
+				{Name: "bash.run", Command: "echo broken", Condition: map[string]interface{}{"file_exists": "["}},
+			},
+		},
+	}
+	pc := &ProjectContext{ProjectPath: projectDir, Config: cfg, DefaultBranch: "main"}
+
+	t.Setenv("PATH", t.TempDir())
+	report, err := validateProject(pc, mainPath, "main")
+	require.NoError(t, err)
+
+	require.Len(t, report.warnings, 1)
+	assert.Contains(t, report.warnings[0], "scaffold.steps[1] (bash.run) would be skipped")
+
+	assert.Len(t, report.problems, 2)
+	assert.Contains(t, report.problems[0], "scaffold.steps[2] (bash.run): condition:")
+	assert.Equal(t, "bash.run needs bash, which is not installed", report.problems[1], "each missing tool is reported once")
+}
@@ -2,12 +2,22 @@ package cli
 
 import (
 	"fmt"
+	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
+	"github.com/michaeldyrynda/arbor/internal/audit"
+	"github.com/michaeldyrynda/arbor/internal/config"
+	arborerrors "github.com/michaeldyrynda/arbor/internal/errors"
+	"github.com/michaeldyrynda/arbor/internal/events"
+	"github.com/michaeldyrynda/arbor/internal/forge"
 	"github.com/michaeldyrynda/arbor/internal/git"
+	"github.com/michaeldyrynda/arbor/internal/scaffold"
 	"github.com/michaeldyrynda/arbor/internal/ui"
 	"github.com/michaeldyrynda/arbor/internal/utils"
 )
@@ -22,22 +32,63 @@ Arguments:
   PATH    Optional custom path (defaults to sanitised branch name)
 
 If no branch is provided, interactive mode allows selection from
-available branches or entering a new branch name.`,
+available branches or entering a new branch name.
+
+New branches start from --base, or the base of the first base_branches
+rule in arbor.yaml matching the branch, or the default branch.
+
+Once the worktree is scaffolded, the on_create actions in arbor.yaml run.
+--editor, --browser and --tmux turn those actions on for this run.
+
+--dry-run --plan prints the scaffold steps that would run, in order, with
+their templates resolved, whether their conditions hold and the files and
+databases they would touch. As the worktree does not exist yet, files are
+read from the worktree of the base branch, or the main worktree. Add --json
+for JSON.
+
+--pr checks out a GitHub pull request or GitLab merge request by number,
+fetching its head from origin into a pr-<number> branch, so pull requests
+from forks work too.
+
+--no-scaffold creates the worktree without running any scaffold steps, for
+quick throwaway branches. --preset scaffolds with the named preset instead of
+the configured or detected one, for this run only.`,
 	Args: cobra.RangeArgs(0, 2),
-	RunE: func(cmd *cobra.Command, args []string) error {
-		pc, err := OpenProjectFromCWD()
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		pc, err := OpenProjectFromCWD(cmd)
 		if err != nil {
 			return err
 		}
 
+		start := time.Now()
 		baseBranch := mustGetString(cmd, "base")
 		dryRun := mustGetBool(cmd, "dry-run")
 		verbose := mustGetBool(cmd, "verbose")
 
-		var branch string
-		if len(args) > 0 {
+		prNumber := mustGetInt(cmd, "pr")
+
+		scaffoldOpts := worktreeScaffold{
+			preset:  mustGetString(cmd, "preset"),
+			skip:    mustGetBool(cmd, "no-scaffold"),
+			verbose: verbose,
+		}
+		if scaffoldOpts.skip && wantsPlan(cmd) {
+			return arborerrors.Wrap(arborerrors.ErrInvalidArguments, fmt.Errorf("--no-scaffold cannot be combined with --plan"))
+		}
+
+		var branch, prRef string
+		if prNumber > 0 {
+			if len(args) > 0 {
+				return fmt.Errorf("--pr cannot be combined with a branch argument")
+			}
+			prRef, err = pullRequestRef(pc.BarePath, prNumber)
+			if err != nil {
+				return err
+			}
+			branch = fmt.Sprintf("pr-%d", prNumber)
+		} else if len(args) > 0 {
 			branch = args[0]
-		} else if ui.IsInteractive() {
+		} else if ui.CanPrompt() {
 			localBranches, err := git.ListAllBranches(pc.BarePath)
 			if err != nil {
 				return fmt.Errorf("listing local branches: %w", err)
@@ -56,15 +107,25 @@ available branches or entering a new branch name.`,
 			return fmt.Errorf("branch name required (run interactively or provide branch as argument)")
 		}
 
+		if baseBranch == "" {
+			if baseBranch, err = pc.Config.BaseBranchFor(branch); err != nil {
+				return err
+			}
+		}
 		if baseBranch == "" {
 			baseBranch = pc.DefaultBranch
 		}
+		if scaffoldOpts.preset != "" {
+			if err := scaffoldOpts.validate(pc, planSource(pc, baseBranch)); err != nil {
+				return err
+			}
+		}
 
 		worktreePath := ""
 		if len(args) > 1 {
 			worktreePath = args[1]
 		} else {
-			worktreePath = filepath.Join(pc.ProjectPath, utils.SanitisePath(branch))
+			worktreePath = filepath.Join(git.WorktreeRoot(pc.BarePath), utils.SanitisePath(branch))
 		}
 
 		absWorktreePath, err := filepath.Abs(worktreePath)
@@ -86,39 +147,178 @@ available branches or entering a new branch name.`,
 			}
 		}
 
+		if wantsPlan(cmd) {
+			source := planSource(pc, baseBranch)
+			preset := scaffoldOpts.resolvePreset(pc, source)
+
+			plan, err := pc.ScaffoldManager().PlanScaffold(scaffold.PlanOptions{
+				WorktreePath: absWorktreePath,
+				SourcePath:   source,
+				Branch:       branch,
+				BaseBranch:   baseBranch,
+				RepoName:     filepath.Base(filepath.Dir(absWorktreePath)),
+				SiteName:     filepath.Base(absWorktreePath),
+				Preset:       preset,
+			}, scaffoldOpts.config(pc))
+			if err != nil {
+				return err
+			}
+			return printScaffoldPlan(cmd, os.Stdout, plan)
+		}
+
 		ui.PrintStep(fmt.Sprintf("Creating worktree for branch '%s' from '%s'", branch, baseBranch))
 		ui.PrintInfo(fmt.Sprintf("Path: %s", absWorktreePath))
+		if pc.Config.IsProtectedBranch(branch) {
+			ui.PrintWarning(fmt.Sprintf("'%s' is a protected branch; commit on a feature branch instead", branch))
+		}
 
+		scaffolded := true
 		if !dryRun {
-			if err := git.CreateWorktree(pc.BarePath, absWorktreePath, branch, baseBranch); err != nil {
-				return fmt.Errorf("creating worktree: %w", err)
+			defer func() {
+				recordAudit(pc.BarePath, audit.Entry{Operation: audit.OperationWork, Branch: branch, Path: absWorktreePath}, err)
+			}()
+
+			if prNumber > 0 && !exists {
+				err := ui.RunWithProgress(fmt.Sprintf("Fetching pull request #%d...", prNumber), func() error {
+					return git.FetchRef(pc.BarePath, prRef, branch)
+				})
+				if err != nil {
+					return fmt.Errorf("fetching pull request #%d: %w", prNumber, err)
+				}
+			}
+			scaffolded, err = addWorktree(pc, branch, baseBranch, absWorktreePath, scaffoldOpts)
+			if err != nil {
+				return err
 			}
+			sendEvent(pc, events.WorktreeCreated, branch, absWorktreePath, worktreeDbSuffix(absWorktreePath), start)
 		} else {
 			ui.PrintInfo("[DRY RUN] Would create worktree")
+			if scaffoldOpts.skip {
+				ui.PrintInfo("[DRY RUN] Would skip scaffold steps")
+			} else {
+				ui.PrintInfo("[DRY RUN] Would run scaffold steps")
+			}
 		}
 
+		ui.PrintDone(fmt.Sprintf("Worktree ready at %s", absWorktreePath))
+
+		if !dryRun && !scaffolded {
+			// The worktree is usable, but scripts still need to know the
+			// scaffold did not finish
+			return fmt.Errorf("scaffolding %s: %w", filepath.Base(absWorktreePath), arborerrors.ErrScaffoldStepFailed)
+		}
 		if !dryRun {
-			preset := pc.Config.Preset
-			if preset == "" {
-				preset = pc.PresetManager().Detect(absWorktreePath)
-			}
+			runOnCreate(onCreateActions(cmd, pc.Config.OnCreate), pc.Config.Tmux, pc.Config.WindowsShell, absWorktreePath, branch)
+		}
+		return nil
+	},
+}
 
-			if verbose && preset != "" {
-				ui.PrintInfo(fmt.Sprintf("Running scaffold for preset: %s", preset))
-			}
+// worktreeScaffold is how a new worktree is scaffolded. The zero value runs
+// the configured or detected preset.
+type worktreeScaffold struct {
+	// preset replaces the configured preset for this worktree
+	preset string
+	// skip creates the worktree without running any scaffold steps
+	skip    bool
+	verbose bool
+}
 
-			repoName := filepath.Base(filepath.Dir(absWorktreePath))
-			folderName := filepath.Base(absWorktreePath)
-			if err := pc.ScaffoldManager().RunScaffold(absWorktreePath, branch, repoName, folderName, preset, pc.Config, false, verbose); err != nil {
-				ui.PrintErrorWithHint("Scaffold steps failed", err.Error())
+// validate rejects a preset override naming no preset. Presets committed in
+// .arbor/presets are looked for in source, the worktree the new one starts
+// from.
+func (s worktreeScaffold) validate(pc *ProjectContext, source string) error {
+	if s.preset == "" {
+		return nil
+	}
+	if s.skip {
+		return arborerrors.Wrap(arborerrors.ErrInvalidArguments, fmt.Errorf("--preset cannot be combined with --no-scaffold"))
+	}
+	if _, ok := pc.PresetManager().Get(s.preset); ok {
+		return nil
+	}
+
+	available := pc.PresetManager().Available()
+	if source != "" {
+		repoPresets, err := scaffold.RepoPresets(source)
+		if err != nil {
+			return err
+		}
+		for _, preset := range repoPresets {
+			if preset.Name() == s.preset {
+				return nil
 			}
-		} else {
-			ui.PrintInfo("[DRY RUN] Would run scaffold steps")
+			available = append(available, preset.Name())
 		}
+	}
+	sort.Strings(available)
+	return arborerrors.Wrap(arborerrors.ErrInvalidArguments, fmt.Errorf("unknown preset %q (available: %s)", s.preset, strings.Join(available, ", ")))
+}
 
-		ui.PrintDone(fmt.Sprintf("Worktree ready at %s", absWorktreePath))
-		return nil
-	},
+// resolvePreset returns the override, the configured preset, or the preset
+// detected in path, in that order
+func (s worktreeScaffold) resolvePreset(pc *ProjectContext, path string) string {
+	if s.preset != "" {
+		return s.preset
+	}
+	if pc.Config.Preset != "" || path == "" {
+		return pc.Config.Preset
+	}
+	return pc.PresetManager().Detect(path)
+}
+
+// config returns the project config with the preset override applied, so
+// the scaffold manager picks the override's steps
+func (s worktreeScaffold) config(pc *ProjectContext) *config.Config {
+	if s.preset == "" {
+		return pc.Config
+	}
+	cfg := *pc.Config
+	cfg.Preset = s.preset
+	return &cfg
+}
+
+// addWorktree creates the worktree at path for branch, starting from
+// baseBranch when the branch is new, and scaffolds it. It reports whether the
+// scaffold finished; the worktree is usable either way.
+func addWorktree(pc *ProjectContext, branch, baseBranch, path string, opts worktreeScaffold) (bool, error) {
+	if err := git.CreateWorktree(pc.BarePath, path, branch, baseBranch); err != nil {
+		return false, fmt.Errorf("creating worktree: %w", err)
+	}
+	if err := config.WriteWorktreeConfig(path, map[string]string{"base_branch": baseBranch}); err != nil {
+		ui.PrintWarning(fmt.Sprintf("Could not record base branch: %v", err))
+	}
+
+	scaffolded := true
+	preset := opts.resolvePreset(pc, path)
+	if opts.skip {
+		ui.PrintInfo("Skipping scaffold steps")
+	} else {
+		if opts.verbose && preset != "" {
+			ui.PrintInfo(fmt.Sprintf("Running scaffold for preset: %s", preset))
+		}
+
+		repoName := filepath.Base(filepath.Dir(path))
+		folderName := filepath.Base(path)
+		if err := pc.ScaffoldManager().RunScaffold(path, branch, repoName, folderName, preset, opts.config(pc), false, opts.verbose); err != nil {
+			ui.PrintErrorWithHint("Scaffold steps failed", err.Error())
+			scaffolded = false
+		}
+	}
+
+	recordWorktree(pc, branch, path, preset)
+	syncCodeWorkspace(pc)
+	return scaffolded, nil
+}
+
+// pullRequestRef returns the ref origin publishes the head of pull request
+// number under
+func pullRequestRef(barePath string, number int) (string, error) {
+	provider, err := forge.Detect(barePath)
+	if err != nil {
+		return "", err
+	}
+	return provider.PullRequestRef(number)
 }
 
 func isCommandAvailable(name string) bool {
@@ -130,4 +330,11 @@ func init() {
 	rootCmd.AddCommand(workCmd)
 
 	workCmd.Flags().StringP("base", "b", "", "Base branch for new worktree")
+	workCmd.Flags().Int("pr", 0, "Check out a pull request or merge request, as a pr-<number> branch")
+	workCmd.Flags().Bool("editor", false, "Open the new worktree in $VISUAL or $EDITOR")
+	workCmd.Flags().Bool("browser", false, "Open the new worktree's APP_URL in the browser")
+	workCmd.Flags().Bool("tmux", false, "Start a tmux session for the new worktree")
+	workCmd.Flags().Bool("no-scaffold", false, "Create the worktree without running scaffold steps")
+	workCmd.Flags().String("preset", "", "Scaffold with this preset instead of the configured or detected one")
+	addPlanFlags(workCmd)
 }
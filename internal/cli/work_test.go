@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/michaeldyrynda/arbor/internal/config"
+	arborerrors "github.com/michaeldyrynda/arbor/internal/errors"
+)
+
+func TestWorktreeScaffold(t *testing.T) {
+	t.Run("accepts a known preset", func(t *testing.T) {
+		pc := &ProjectContext{Config: &config.Config{}}
+		assert.NoError(t, worktreeScaffold{preset: "laravel"}.validate(pc, ""))
+	})
+
+	t.Run("rejects an unknown preset", func(t *testing.T) {
+		pc := &ProjectContext{Config: &config.Config{}}
+		err := worktreeScaffold{preset: "rails"}.validate(pc, "")
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, arborerrors.ErrInvalidArguments))
+		assert.Contains(t, err.Error(), "laravel, php")
+	})
+
+	t.Run("accepts a preset committed in .arbor/presets", func(t *testing.T) {
+		source := t.TempDir()
+		require.NoError(t, os.MkdirAll(filepath.Join(source, ".arbor", "presets"), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(source, ".arbor", "presets", "team.yaml"), []byte("steps: []\n"), 0644))
+
+		pc := &ProjectContext{Config: &config.Config{}}
+		assert.NoError(t, worktreeScaffold{preset: "team"}.validate(pc, source))
+
+		err := worktreeScaffold{preset: "rails"}.validate(pc, source)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "laravel, php, team")
+	})
+
+	t.Run("rejects a preset without scaffolding", func(t *testing.T) {
+		pc := &ProjectContext{Config: &config.Config{}}
+		err := worktreeScaffold{preset: "php", skip: true}.validate(pc, "")
+		assert.True(t, errors.Is(err, arborerrors.ErrInvalidArguments))
+	})
+
+	t.Run("override wins over the configured preset", func(t *testing.T) {
+		pc := &ProjectContext{Config: &config.Config{Preset: "laravel"}}
+		assert.Equal(t, "php", worktreeScaffold{preset: "php"}.resolvePreset(pc, t.TempDir()))
+		assert.Equal(t, "laravel", worktreeScaffold{}.resolvePreset(pc, t.TempDir()))
+	})
+
+	t.Run("config carries the override", func(t *testing.T) {
+		pc := &ProjectContext{Config: &config.Config{Preset: "laravel"}}
+		assert.Equal(t, "php", worktreeScaffold{preset: "php"}.config(pc).Preset)
+		assert.Equal(t, "laravel", pc.Config.Preset, "the project config is left alone")
+		assert.Same(t, pc.Config, worktreeScaffold{}.config(pc))
+	})
+
+	t.Run("detects the preset without an override or config", func(t *testing.T) {
+		pc := &ProjectContext{Config: &config.Config{}}
+		assert.Equal(t, "", worktreeScaffold{}.resolvePreset(pc, t.TempDir()))
+		assert.Equal(t, "", worktreeScaffold{}.resolvePreset(pc, ""))
+	})
+}
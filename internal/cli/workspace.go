@@ -0,0 +1,189 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/michaeldyrynda/arbor/internal/audit"
+	"github.com/michaeldyrynda/arbor/internal/config"
+	arborerrors "github.com/michaeldyrynda/arbor/internal/errors"
+	"github.com/michaeldyrynda/arbor/internal/events"
+	"github.com/michaeldyrynda/arbor/internal/git"
+	"github.com/michaeldyrynda/arbor/internal/ui"
+	"github.com/michaeldyrynda/arbor/internal/utils"
+)
+
+var workspaceCmd = &cobra.Command{
+	Use:   "workspace",
+	Short: "Work on a branch across several projects at once",
+	Long: `A workspace is a directory of arbor projects, such as a backend, a
+frontend and a shared package, listed in a workspace.yaml at its root:
+
+  repos:
+    - name: backend
+    - name: frontend
+      base: develop
+    - name: shared
+      path: packages/shared
+
+path is the project directory relative to the workspace root and defaults
+to name. base is the branch new worktrees start from and defaults to the
+project's default branch.`,
+}
+
+var workspaceWorkCmd = &cobra.Command{
+	Use:   "work BRANCH",
+	Short: "Create a worktree for the branch in every project of the workspace",
+	Long: `Creates and scaffolds a worktree for BRANCH in each project listed in
+workspace.yaml, at <project>/<branch>, the path arbor work uses.
+
+Projects that already have a worktree for the branch are left as they are.
+A project that fails does not stop the others; the command fails once all
+have been tried.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("getting current directory: %w", err)
+		}
+		workspace, err := config.FindWorkspace(cwd)
+		if err != nil {
+			return err
+		}
+
+		branch := args[0]
+		base := mustGetString(cmd, "base")
+		dryRun := mustGetBool(cmd, "dry-run")
+		verbose := mustGetBool(cmd, "verbose")
+
+		var failed, unscaffolded []string
+		for _, repo := range workspace.Repos {
+			ui.PrintStep(fmt.Sprintf("%s: creating worktree for '%s'", repo.Name, branch))
+
+			baseBranch := base
+			if baseBranch == "" {
+				baseBranch = repo.Base
+			}
+
+			scaffolded, err := workspaceWork(cmd, workspace.ProjectPath(repo), branch, baseBranch, dryRun, verbose)
+			if err != nil {
+				ui.PrintError(fmt.Sprintf("%s: %v", repo.Name, err))
+				failed = append(failed, repo.Name)
+				continue
+			}
+			if !scaffolded {
+				unscaffolded = append(unscaffolded, repo.Name)
+			}
+		}
+
+		if len(failed) > 0 {
+			return fmt.Errorf("creating worktrees failed in %s", strings.Join(failed, ", "))
+		}
+		ui.PrintDone(fmt.Sprintf("Workspace ready on %s", branch))
+		if len(unscaffolded) > 0 {
+			return fmt.Errorf("scaffolding %s: %w", strings.Join(unscaffolded, ", "), arborerrors.ErrScaffoldStepFailed)
+		}
+		return nil
+	},
+}
+
+// workspaceWork creates the worktree for branch in the project at
+// projectPath, unless it already has one. It reports whether the scaffold
+// finished.
+func workspaceWork(cmd *cobra.Command, projectPath, branch, baseBranch string, dryRun, verbose bool) (scaffolded bool, err error) {
+	if _, err := os.Stat(filepath.Join(projectPath, ".bare")); err != nil {
+		return false, fmt.Errorf("%s is not an arbor project; clone it there with arbor init", projectPath)
+	}
+	pc, err := OpenProject(cmd, projectPath)
+	if err != nil {
+		return false, err
+	}
+	if baseBranch == "" {
+		if baseBranch, err = pc.Config.BaseBranchFor(branch); err != nil {
+			return false, err
+		}
+	}
+	if baseBranch == "" {
+		baseBranch = pc.DefaultBranch
+	}
+
+	worktrees, err := git.ListWorktrees(pc.BarePath)
+	if err != nil {
+		return false, fmt.Errorf("listing worktrees: %w", err)
+	}
+	for _, wt := range worktrees {
+		if wt.Branch == branch {
+			ui.PrintInfo(fmt.Sprintf("Worktree already exists at %s", wt.Path))
+			return true, nil
+		}
+	}
+
+	path := filepath.Join(git.WorktreeRoot(pc.BarePath), utils.SanitisePath(branch))
+	if dryRun {
+		ui.PrintInfo(fmt.Sprintf("[DRY RUN] Would create %s from '%s' and scaffold it", path, baseBranch))
+		return true, nil
+	}
+
+	start := time.Now()
+	defer func() {
+		recordAudit(pc.BarePath, audit.Entry{Operation: audit.OperationWork, Branch: branch, Path: path}, err)
+	}()
+
+	scaffolded, err = addWorktree(pc, branch, baseBranch, path, worktreeScaffold{verbose: verbose})
+	if err != nil {
+		return false, err
+	}
+	sendEvent(pc, events.WorktreeCreated, branch, path, worktreeDbSuffix(path), start)
+	ui.PrintSuccessPath("Worktree ready at", path)
+	return scaffolded, nil
+}
+
+var workspaceListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the projects of the workspace and their worktrees",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("getting current directory: %w", err)
+		}
+		workspace, err := config.FindWorkspace(cwd)
+		if err != nil {
+			return err
+		}
+
+		var rows [][]string
+		for _, repo := range workspace.Repos {
+			path := workspace.ProjectPath(repo)
+			worktrees, branches := "-", "-"
+			if barePath, err := git.FindBarePath(path); err == nil {
+				if list, err := git.ListWorktrees(barePath); err == nil {
+					var names []string
+					for _, wt := range list {
+						names = append(names, wt.Branch)
+					}
+					worktrees = strconv.Itoa(len(list))
+					branches = strings.Join(names, ", ")
+				}
+			}
+			rows = append(rows, []string{repo.Name, path, worktrees, branches})
+		}
+
+		fmt.Fprintln(cmd.OutOrStdout(), ui.RenderTable([]string{"REPO", "PATH", "WORKTREES", "BRANCHES"}, rows))
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(workspaceCmd)
+	workspaceCmd.AddCommand(workspaceWorkCmd)
+	workspaceCmd.AddCommand(workspaceListCmd)
+
+	workspaceWorkCmd.Flags().StringP("base", "b", "", "Base branch for new worktrees in every project")
+}
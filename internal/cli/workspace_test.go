@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/michaeldyrynda/arbor/internal/git"
+)
+
+// createWorkspaceProject creates an arbor project with a main worktree at
+// root/name
+func createWorkspaceProject(t *testing.T, root, name string) string {
+	repoDir := filepath.Join(t.TempDir(), "repo")
+	require.NoError(t, os.MkdirAll(repoDir, 0755))
+	runGitCmd(t, repoDir, "init", "-b", "main")
+	runGitCmd(t, repoDir, "config", "user.email", "test@example.com")
+	runGitCmd(t, repoDir, "config", "user.name", "Test User")
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, "README.md"), []byte(name), 0644))
+	runGitCmd(t, repoDir, "add", ".")
+	runGitCmd(t, repoDir, "commit", "-m", "Initial commit")
+
+	projectPath := filepath.Join(root, name)
+	barePath := filepath.Join(projectPath, ".bare")
+	runGitCmd(t, root, "clone", "--bare", repoDir, barePath)
+	require.NoError(t, git.CreateWorktree(barePath, filepath.Join(projectPath, "main"), "main", ""))
+	require.NoError(t, os.WriteFile(filepath.Join(projectPath, "arbor.yaml"), []byte("default_branch: main\npreset: \"\"\n"), 0644))
+	return projectPath
+}
+
+func TestWorkspaceWorkCmd(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	root := t.TempDir()
+	backend := createWorkspaceProject(t, root, "backend")
+	frontend := createWorkspaceProject(t, root, "frontend")
+	require.NoError(t, os.WriteFile(filepath.Join(root, "workspace.yaml"), []byte("repos:\n  - name: backend\n  - name: frontend\n"), 0644))
+
+	originalDir, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalDir)
+	require.NoError(t, os.Chdir(filepath.Join(backend, "main")))
+
+	newCmd := func() *cobra.Command {
+		cmd := &cobra.Command{}
+		cmd.Flags().String("base", "", "")
+		cmd.Flags().Bool("dry-run", false, "")
+		cmd.Flags().Bool("verbose", false, "")
+		return cmd
+	}
+
+	require.NoError(t, workspaceWorkCmd.RunE(newCmd(), []string{"feature/auth"}))
+
+	for _, project := range []string{backend, frontend} {
+		path := filepath.Join(project, "feature-auth")
+		assert.DirExists(t, path)
+		assert.FileExists(t, filepath.Join(path, "arbor.yaml"), "base branch recorded")
+	}
+
+	t.Run("existing worktrees are left alone", func(t *testing.T) {
+		assert.NoError(t, workspaceWorkCmd.RunE(newCmd(), []string{"feature/auth"}))
+	})
+
+	t.Run("a failing project does not stop the others", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(filepath.Join(root, "workspace.yaml"), []byte("repos:\n  - name: missing\n  - name: frontend\n"), 0644))
+
+		err := workspaceWorkCmd.RunE(newCmd(), []string{"feature/billing"})
+		assert.ErrorContains(t, err, "creating worktrees failed in missing")
+		assert.DirExists(t, filepath.Join(frontend, "feature-billing"))
+	})
+}
@@ -1,11 +1,22 @@
 package config
 
 import (
+	"bytes"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/go-viper/mapstructure/v2"
 	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+
+	arborerrors "github.com/michaeldyrynda/arbor/internal/errors"
+	"github.com/michaeldyrynda/arbor/internal/utils"
 )
 
 const (
@@ -21,22 +32,182 @@ const (
 
 const DefaultBranch = "main"
 
+// EnvPrefix is the prefix for environment variables overriding config values,
+// e.g. ARBOR_DEFAULT_BRANCH overrides default_branch and ARBOR_DB_USERNAME
+// overrides db.username
+const EnvPrefix = "ARBOR"
+
 var DefaultBranchCandidates = []string{"main", "master", "develop"}
 
 // Config represents the project configuration
 type Config struct {
+	Version       int                   `mapstructure:"version"`
 	SiteName      string                `mapstructure:"site_name"`
 	Preset        string                `mapstructure:"preset"`
 	DefaultBranch string                `mapstructure:"default_branch"`
 	Scaffold      ScaffoldConfig        `mapstructure:"scaffold"`
-	Cleanup       []CleanupStep         `mapstructure:"cleanup"`
+	Cleanup       []StepConfig          `mapstructure:"cleanup"`
 	Tools         map[string]ToolConfig `mapstructure:"tools"`
+	DB            DatabaseConfig        `mapstructure:"db"`
+	Vars          map[string]string     `mapstructure:"vars"`
+	OnCreate      OnCreateConfig        `mapstructure:"on_create"`
+	Tmux          TmuxConfig            `mapstructure:"tmux"`
+	CodeWorkspace CodeWorkspaceConfig   `mapstructure:"code_workspace"`
+	PR            PullRequestConfig     `mapstructure:"pr"`
+	Events        EventsConfig          `mapstructure:"events"`
+
+	// ProtectedBranches lists branches, or path.Match patterns such as
+	// release/*, whose worktrees and branches remove and prune never delete
+	ProtectedBranches []string `mapstructure:"protected_branches"`
+
+	// BaseBranches picks the base of a new worktree by its branch name when
+	// arbor work is not given --base. The first matching pattern wins.
+	BaseBranches []BaseBranchRule `mapstructure:"base_branches"`
+
+	// Tasks are named step pipelines run by arbor run, keyed by lowercase
+	// name. Steps may be written as "name arg..." strings.
+	Tasks map[string][]StepConfig `mapstructure:"tasks"`
+
+	// GlobalSteps and GlobalCleanup hold the default steps from the global
+	// config that were not disabled by this project
+	GlobalSteps   []StepConfig `mapstructure:"-"`
+	GlobalCleanup []StepConfig `mapstructure:"-"`
+
+	// WindowsShell is windows_shell from the global config
+	WindowsShell string `mapstructure:"-"`
+
+	// Migrations describes the upgrades applied in memory to an outdated
+	// arbor.yaml. They are not written back until MigrateProjectFile is called.
+	Migrations []string `mapstructure:"-"`
+
+	// Settings holds every loaded setting, with environment overrides and
+	// arbor.local.yaml applied, keyed by lowercase name
+	Settings map[string]interface{} `mapstructure:"-"`
+}
+
+// OnCreateConfig lists the actions arbor work runs once a new worktree has
+// been scaffolded
+type OnCreateConfig struct {
+	Editor  bool   `mapstructure:"editor"`
+	Browser bool   `mapstructure:"browser"`
+	Tmux    bool   `mapstructure:"tmux"`
+	Command string `mapstructure:"command"`
+}
+
+// TmuxConfig describes the tmux session arbor tmux starts for a worktree
+type TmuxConfig struct {
+	Windows []TmuxWindow `mapstructure:"windows"`
+}
+
+// TmuxWindow is a window in a worktree's tmux session, with an optional
+// command typed into it when the session starts
+type TmuxWindow struct {
+	Name    string `mapstructure:"name"`
+	Command string `mapstructure:"command"`
+}
+
+// CodeWorkspaceConfig configures the VS Code workspace written by
+// arbor code-workspace. Its settings are read with CodeWorkspaceSettings.
+type CodeWorkspaceConfig struct {
+	File       string `mapstructure:"file"`
+	AutoUpdate bool   `mapstructure:"auto_update"`
+}
+
+// BaseBranchRule bases new branches matching Pattern, a path.Match pattern
+// such as hotfix/*, on Base
+type BaseBranchRule struct {
+	Pattern string `mapstructure:"pattern"`
+	Base    string `mapstructure:"base"`
+}
+
+// PullRequestConfig holds the defaults for arbor pr create. Title and body
+// are templates rendered against the worktree.
+type PullRequestConfig struct {
+	Title string `mapstructure:"title"`
+	Body  string `mapstructure:"body"`
+	Draft bool   `mapstructure:"draft"`
+}
+
+// EventsConfig holds the webhook URLs posted to when worktrees are created
+// and removed
+type EventsConfig struct {
+	WorktreeCreated string `mapstructure:"worktree_created"`
+	WorktreeRemoved string `mapstructure:"worktree_removed"`
+}
+
+// DatabaseConfig holds project-level database connection defaults
+type DatabaseConfig struct {
+	Host     string `mapstructure:"host"`
+	Port     string `mapstructure:"port"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+	SSLMode  string `mapstructure:"ssl_mode"`
+	SSLCA    string `mapstructure:"ssl_ca"`
+	Socket   string `mapstructure:"socket"`
+
+	// Mode is "database" (the default) to give each worktree its own
+	// database, or "schema" to create a schema per worktree inside Database.
+	// Schemas are only supported for PostgreSQL.
+	Mode     string `mapstructure:"mode"`
+	Database string `mapstructure:"database"`
+
+	// CreateUser gives each worktree its own user, limited to the worktree's
+	// databases, instead of sharing the connection user
+	CreateUser bool `mapstructure:"create_user"`
+
+	// NamingStrategy picks the suffix of each worktree's databases: words
+	// (the default) for a random adjective_noun, branch for the branch name,
+	// ticket for a ticket number such as PROJ-123 taken from the branch with
+	// TicketPattern, or hash for the branch name and a hash of it
+	NamingStrategy string            `mapstructure:"naming_strategy"`
+	TicketPattern  string            `mapstructure:"ticket_pattern"`
+	Words          NamingWordsConfig `mapstructure:"words"`
+
+	// KeepOnRemove leaves a worktree's databases in place when it is removed,
+	// so one re-created for the same branch reuses them. Databases named
+	// with random words are still dropped, since nothing would find them.
+	KeepOnRemove bool `mapstructure:"keep_on_remove"`
+
+	Container DatabaseContainerConfig `mapstructure:"container"`
+}
+
+// NamingWordsConfig replaces the word lists random database suffixes are
+// made from
+type NamingWordsConfig struct {
+	Adjectives []string `mapstructure:"adjectives"`
+	Nouns      []string `mapstructure:"nouns"`
+}
+
+// DatabaseContainerConfig runs project databases in a Docker container
+// managed by arbor instead of a server on the host
+type DatabaseContainerConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Image   string `mapstructure:"image"`
 }
 
 // ScaffoldConfig represents scaffold configuration
 type ScaffoldConfig struct {
-	Steps    []StepConfig `mapstructure:"steps"`
-	Override bool         `mapstructure:"override"`
+	Steps    []StepConfig  `mapstructure:"steps"`
+	Override bool          `mapstructure:"override"`
+	Disable  []string      `mapstructure:"disable"`
+	Inputs   []InputConfig `mapstructure:"inputs"`
+}
+
+// Input types accepted by InputConfig.Type
+const (
+	InputText    = "text"
+	InputSelect  = "select"
+	InputConfirm = "confirm"
+)
+
+// InputConfig declares a question asked before scaffolding. The answer is
+// stored as a var under Name, so steps can use it as {{ .Vars.name }}.
+type InputConfig struct {
+	Name    string      `mapstructure:"name"`
+	Type    string      `mapstructure:"type"`
+	Prompt  string      `mapstructure:"prompt"`
+	Options []string    `mapstructure:"options"`
+	Default interface{} `mapstructure:"default"`
 }
 
 // StepConfig represents a scaffold step configuration
@@ -54,12 +225,41 @@ type StepConfig struct {
 	StoreAs   string                 `mapstructure:"store_as"`
 	File      string                 `mapstructure:"file"`
 	Type      string                 `mapstructure:"type"`
+	Inputs    []InputConfig          `mapstructure:"inputs"`
+	Title     string                 `mapstructure:"title"`
+	Message   string                 `mapstructure:"message"`
+	Keys      []string               `mapstructure:"keys"`
+	Paths     []string               `mapstructure:"paths"`
+	Image     string                 `mapstructure:"image"`
+	Ports     []int                  `mapstructure:"ports"`
+	Mounts    []string               `mapstructure:"mounts"`
+	Settings  map[string]string      `mapstructure:"settings"`
+	Env       map[string]string      `mapstructure:"env"`
+	Dir       string                 `mapstructure:"dir"`
+	CaptureAs string                 `mapstructure:"capture_as"`
 }
 
-// CleanupStep represents a cleanup step configuration
-type CleanupStep struct {
-	Name      string                 `mapstructure:"name"`
-	Condition map[string]interface{} `mapstructure:"condition"`
+// ParseStepShorthand parses a step written as a single string, such as
+// "php.laravel.artisan migrate:fresh --seed", into its name and args.
+// Arguments are split on whitespace.
+func ParseStepShorthand(s string) StepConfig {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return StepConfig{}
+	}
+	step := StepConfig{Name: fields[0]}
+	if len(fields) > 1 {
+		step.Args = fields[1:]
+	}
+	return step
+}
+
+// stepShorthandHook decodes steps written as strings with ParseStepShorthand
+func stepShorthandHook(from, to reflect.Type, data interface{}) (interface{}, error) {
+	if from.Kind() != reflect.String || to != reflect.TypeOf(StepConfig{}) {
+		return data, nil
+	}
+	return ParseStepShorthand(data.(string)), nil
 }
 
 // ToolConfig represents tool-specific configuration
@@ -73,6 +273,44 @@ type GlobalConfig struct {
 	DetectedTools map[string]bool      `mapstructure:"detected_tools"`
 	Tools         map[string]ToolInfo  `mapstructure:"tools"`
 	Scaffold      GlobalScaffoldConfig `mapstructure:"scaffold"`
+	Cleanup       []StepConfig         `mapstructure:"cleanup"`
+	NoInput       bool                 `mapstructure:"no_input"`
+	UI            UIConfig             `mapstructure:"ui"`
+	// Stats records how long scaffold steps take for arbor stats
+	Stats bool `mapstructure:"stats"`
+	// Network configures retries of git clone, fetch and push
+	Network NetworkConfig `mapstructure:"network"`
+	// WindowsShell is sh, the default, or cmd, the shell commands run with
+	// on Windows
+	WindowsShell string `mapstructure:"windows_shell"`
+}
+
+// NetworkConfig configures how git network operations are retried after a
+// transient failure
+type NetworkConfig struct {
+	// Retries is how many times to retry; unset uses the default, 0 disables
+	Retries *int `mapstructure:"retries"`
+	// RetryDelay is the wait before the first retry, doubling after each one
+	RetryDelay time.Duration `mapstructure:"retry_delay"`
+}
+
+// UIConfig configures how arbor's output and prompts look
+type UIConfig struct {
+	// Theme is catppuccin (the default), dracula, plain or custom
+	Theme  string      `mapstructure:"theme"`
+	Colors ThemeColors `mapstructure:"colors"`
+}
+
+// ThemeColors overrides theme colours with hex values such as "#4CAF50" or
+// ANSI colour numbers
+type ThemeColors struct {
+	Primary   string `mapstructure:"primary"`
+	Secondary string `mapstructure:"secondary"`
+	Success   string `mapstructure:"success"`
+	Warning   string `mapstructure:"warning"`
+	Error     string `mapstructure:"error"`
+	Info      string `mapstructure:"info"`
+	Muted     string `mapstructure:"muted"`
 }
 
 // ToolInfo represents detected tool information
@@ -85,31 +323,112 @@ type ToolInfo struct {
 type GlobalScaffoldConfig struct {
 	ParallelDependencies bool `mapstructure:"parallel_dependencies"`
 	Interactive          bool `mapstructure:"interactive"`
+	// Strict makes arbor init fail when scaffolding fails, as --strict does
+	Strict bool         `mapstructure:"strict"`
+	Steps  []StepConfig `mapstructure:"steps"`
 }
 
-// LoadProject loads project configuration from arbor.yaml
+// LoadProject loads project configuration from arbor.yaml, merging
+// arbor.local.yaml over it when present
 func LoadProject(path string) (*Config, error) {
 	v := viper.New()
 
 	v.SetConfigName("arbor")
 	v.SetConfigType("yaml")
 	v.AddConfigPath(path)
+	if err := bindEnv(v, projectSchema.scalarKeys("")); err != nil {
+		return nil, err
+	}
 
 	if err := v.ReadInConfig(); err != nil {
 		if _, ok := err.(viper.ConfigFileNotFoundError); ok {
-			return nil, fmt.Errorf("arbor.yaml not found in %s", path)
+			return nil, arborerrors.Wrap(arborerrors.ErrConfigNotFound, fmt.Errorf("arbor.yaml not found in %s", path))
 		}
+		return nil, arborerrors.Wrap(arborerrors.ErrInvalidConfig, fmt.Errorf("reading config: %w", err))
+	}
+
+	configPath := v.ConfigFileUsed()
+	data, err := os.ReadFile(configPath)
+	if err != nil {
 		return nil, fmt.Errorf("reading config: %w", err)
 	}
 
+	data, migrations, err := MigrateProject(data)
+	if err != nil {
+		return nil, arborerrors.Wrap(arborerrors.ErrInvalidConfig, fmt.Errorf("migrating %s: %w", configPath, err))
+	}
+	if len(migrations) > 0 {
+		if err := v.ReadConfig(bytes.NewReader(data)); err != nil {
+			return nil, fmt.Errorf("reading migrated config: %w", err)
+		}
+	}
+
+	if err := validateProjectData(configPath, data, ValidateOptions{}); err != nil {
+		return nil, err
+	}
+
+	if err := mergeLocalConfig(v, path, data); err != nil {
+		return nil, err
+	}
+
 	var config Config
-	if err := v.Unmarshal(&config); err != nil {
-		return nil, fmt.Errorf("parsing config: %w", err)
+	if err := v.Unmarshal(&config, viper.DecodeHook(mapstructure.ComposeDecodeHookFunc(
+		mapstructure.StringToTimeDurationHookFunc(),
+		mapstructure.StringToSliceHookFunc(","),
+		stepShorthandHook,
+	))); err != nil {
+		return nil, arborerrors.Wrap(arborerrors.ErrInvalidConfig, fmt.Errorf("parsing config: %w", err))
 	}
+	config.Migrations = migrations
+	config.Settings = v.AllSettings()
 
 	return &config, nil
 }
 
+// CodeWorkspaceSettings reads code_workspace.settings from the project's
+// arbor.yaml. VS Code setting names contain dots and capitals, which viper
+// would split and lowercase, so the file is read directly.
+func CodeWorkspaceSettings(path string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(filepath.Join(path, "arbor.yaml"))
+	if err != nil {
+		return nil, err
+	}
+
+	var doc struct {
+		CodeWorkspace struct {
+			Settings map[string]interface{} `yaml:"settings"`
+		} `yaml:"code_workspace"`
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parsing arbor.yaml: %w", err)
+	}
+	return doc.CodeWorkspace.Settings, nil
+}
+
+// ValidateProjectFile validates a project arbor.yaml on disk, returning a
+// *ValidationError describing every issue found
+func ValidateProjectFile(configPath string, opts ValidateOptions) error {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return fmt.Errorf("reading config: %w", err)
+	}
+
+	return validateProjectData(configPath, data, opts)
+}
+
+func validateProjectData(configPath string, data []byte, opts ValidateOptions) error {
+	issues, err := ValidateProject(data, opts)
+	if err != nil {
+		return arborerrors.Wrap(arborerrors.ErrInvalidConfig, fmt.Errorf("parsing config %s: %w", configPath, err))
+	}
+
+	if len(issues) > 0 {
+		return &ValidationError{File: configPath, Issues: issues}
+	}
+
+	return nil
+}
+
 // LoadGlobal loads global configuration from arbor.yaml
 func LoadGlobal() (*GlobalConfig, error) {
 	configDir, err := GetGlobalConfigDir()
@@ -122,10 +441,13 @@ func LoadGlobal() (*GlobalConfig, error) {
 	v.SetConfigName("arbor")
 	v.SetConfigType("yaml")
 	v.AddConfigPath(configDir)
+	if err := bindEnv(v, globalEnvKeys); err != nil {
+		return nil, err
+	}
 
 	if err := v.ReadInConfig(); err != nil {
 		if _, ok := err.(viper.ConfigFileNotFoundError); ok {
-			return nil, fmt.Errorf("global arbor.yaml not found in %s", configDir)
+			return nil, fmt.Errorf("global arbor.yaml not found in %s: %w", configDir, arborerrors.ErrConfigNotFound)
 		}
 		return nil, fmt.Errorf("reading global config: %w", err)
 	}
@@ -134,34 +456,190 @@ func LoadGlobal() (*GlobalConfig, error) {
 	if err := v.Unmarshal(&config); err != nil {
 		return nil, fmt.Errorf("parsing global config: %w", err)
 	}
+	if shell := config.WindowsShell; shell != "" && shell != "sh" && shell != utils.WindowsShellCmd {
+		return nil, fmt.Errorf("invalid global config: windows_shell must be sh or cmd, got %q", shell)
+	}
 
 	return &config, nil
 }
 
-// SaveProject saves project configuration to arbor.yaml
-func SaveProject(path string, config *Config) error {
-	v := viper.New()
+// ApplyGlobalDefaults adds the default scaffold and cleanup steps declared in
+// the global config, skipping any the project disables by name, and the
+// Windows shell setting
+func (c *Config) ApplyGlobalDefaults(global *GlobalConfig) {
+	c.WindowsShell = global.WindowsShell
 
-	v.SetConfigName("arbor")
-	v.SetConfigType("yaml")
-	v.AddConfigPath(path)
+	disabled := make(map[string]bool, len(c.Scaffold.Disable))
+	for _, name := range c.Scaffold.Disable {
+		disabled[name] = true
+	}
 
-	if err := v.MergeConfigMap(map[string]interface{}{
-		"site_name":      config.SiteName,
-		"preset":         config.Preset,
-		"default_branch": config.DefaultBranch,
-	}); err != nil {
-		return fmt.Errorf("merging config: %w", err)
+	c.GlobalSteps = nil
+	for _, step := range global.Scaffold.Steps {
+		if !disabled[step.Name] {
+			c.GlobalSteps = append(c.GlobalSteps, step)
+		}
+	}
+
+	c.GlobalCleanup = nil
+	for _, step := range global.Cleanup {
+		if !disabled[step.Name] {
+			c.GlobalCleanup = append(c.GlobalCleanup, step)
+		}
+	}
+}
+
+// DatabasePrefixes returns the prefixes the project's databases are named
+// with: the site name, and any --prefix set on its db.create steps
+func (c *Config) DatabasePrefixes() []string {
+	var prefixes []string
+	if c.SiteName != "" {
+		prefixes = append(prefixes, c.SiteName)
+	}
+	for _, step := range append(c.Scaffold.Steps, c.GlobalSteps...) {
+		if step.Name != "db.create" {
+			continue
+		}
+		for i, arg := range step.Args {
+			if arg == "--prefix" && i+1 < len(step.Args) && !slices.Contains(prefixes, step.Args[i+1]) {
+				prefixes = append(prefixes, step.Args[i+1])
+			}
+		}
 	}
+	return prefixes
+}
+
+// IsProtectedBranch reports whether branch matches one of ProtectedBranches.
+// A malformed pattern protects every branch, so a typo never leaves a branch
+// unprotected.
+func (c *Config) IsProtectedBranch(branch string) bool {
+	for _, pattern := range c.ProtectedBranches {
+		matched, err := path.Match(pattern, branch)
+		if matched || err != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// BaseBranchFor returns the base of the first BaseBranches rule matching
+// branch, or "" when none does
+func (c *Config) BaseBranchFor(branch string) (string, error) {
+	for _, rule := range c.BaseBranches {
+		matched, err := path.Match(rule.Pattern, branch)
+		if err != nil {
+			return "", fmt.Errorf("base_branches pattern %q: %w", rule.Pattern, err)
+		}
+		if matched {
+			return rule.Base, nil
+		}
+	}
+	return "", nil
+}
+
+// globalEnvKeys lists the global config keys that can be overridden from the environment
+var globalEnvKeys = []string{
+	"default_branch",
+	"scaffold.parallel_dependencies",
+	"scaffold.interactive",
+	"scaffold.strict",
+	"no_input",
+	"ui.theme",
+	"stats",
+	"network.retries",
+	"network.retry_delay",
+	"windows_shell",
+}
 
+// bindEnv enables ARBOR_* environment overrides for the given keys. Keys must
+// be bound explicitly for viper to apply them when unmarshalling.
+func bindEnv(v *viper.Viper, keys []string) error {
+	v.SetEnvPrefix(EnvPrefix)
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+	for _, key := range keys {
+		if err := v.BindEnv(key); err != nil {
+			return fmt.Errorf("binding environment override for %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// EnvVarName returns the environment variable that overrides a config key
+func EnvVarName(key string) string {
+	return EnvPrefix + "_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// SaveProject writes the project's site_name, preset and default_branch to
+// arbor.yaml. An existing file is updated in place, keeping its other keys
+// and comments; empty values leave the key as it is.
+func SaveProject(path string, config *Config) error {
 	configPath := filepath.Join(path, "arbor.yaml")
-	if err := v.WriteConfigAs(configPath); err != nil {
+
+	var doc yaml.Node
+	data, err := os.ReadFile(configPath)
+	switch {
+	case err == nil:
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return fmt.Errorf("%s is not valid YAML, so it was left unchanged: %w", configPath, err)
+		}
+	case !os.IsNotExist(err):
+		return fmt.Errorf("reading config: %w", err)
+	}
+
+	if len(doc.Content) == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("%s is not a mapping of settings, so it was left unchanged", configPath)
+	}
+
+	// An existing version is kept, so older files are still migrated on load
+	if findKey(root, "version") == nil {
+		setVersion(root, CurrentConfigVersion)
+	}
+	setString(root, "site_name", config.SiteName)
+	setString(root, "preset", config.Preset)
+	setString(root, "default_branch", config.DefaultBranch)
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return fmt.Errorf("encoding config: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return fmt.Errorf("encoding config: %w", err)
+	}
+
+	if err := writeFileAtomic(configPath, buf.Bytes(), 0644); err != nil {
 		return fmt.Errorf("writing config: %w", err)
 	}
 
 	return nil
 }
 
+// setString sets key in a mapping node, appending it when missing. An empty
+// value leaves the mapping unchanged.
+func setString(root *yaml.Node, key, value string) {
+	if value == "" {
+		return
+	}
+	if node := findKey(root, key); node != nil {
+		node.Kind = yaml.ScalarNode
+		node.Tag = "!!str"
+		node.Value = value
+		node.Content = nil
+		return
+	}
+
+	root.Content = append(root.Content,
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key},
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value},
+	)
+}
+
 // GetGlobalConfigDir returns the global config directory
 func GetGlobalConfigDir() (string, error) {
 	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
@@ -210,55 +688,155 @@ func CreateGlobalConfig(config *GlobalConfig) error {
 
 // WorktreeConfig represents worktree-local configuration
 type WorktreeConfig struct {
-	DbSuffix string `mapstructure:"db_suffix"`
+	DbSuffix   string `mapstructure:"db_suffix"`
+	BaseBranch string `mapstructure:"base_branch"`
+	// PublicURL and TunnelPID describe the tunnel started by arbor expose
+	PublicURL string `mapstructure:"public_url"`
+	TunnelPID string `mapstructure:"tunnel_pid"`
+	// State holds every other key, such as values recorded by steps
+	State map[string]interface{} `mapstructure:",remain"`
 }
 
 // ReadWorktreeConfig reads worktree-local configuration from arbor.yaml
 func ReadWorktreeConfig(worktreePath string) (*WorktreeConfig, error) {
-	configPath := filepath.Join(worktreePath, "arbor.yaml")
-
-	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		return &WorktreeConfig{}, nil
+	configPath, err := worktreeConfigPath(worktreePath)
+	if err != nil {
+		return nil, err
 	}
-
-	v := viper.New()
-	v.SetConfigName("arbor")
-	v.SetConfigType("yaml")
-	v.AddConfigPath(worktreePath)
-
-	if err := v.ReadInConfig(); err != nil {
-		return nil, fmt.Errorf("reading worktree config: %w", err)
+	values, err := readWorktreeValues(configPath)
+	if err != nil {
+		return nil, err
 	}
 
 	var config WorktreeConfig
-	if err := v.Unmarshal(&config); err != nil {
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		Result:           &config,
+		WeaklyTypedInput: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := decoder.Decode(values); err != nil {
 		return nil, fmt.Errorf("parsing worktree config: %w", err)
 	}
 
 	return &config, nil
 }
 
-// WriteWorktreeConfig writes worktree-local configuration to arbor.yaml
+// WriteWorktreeConfig merges data into the worktree-local arbor.yaml,
+// keeping the keys already written
 func WriteWorktreeConfig(worktreePath string, data map[string]string) error {
-	v := viper.New()
-	v.SetConfigName("arbor")
-	v.SetConfigType("yaml")
-	v.AddConfigPath(worktreePath)
+	return UpdateWorktreeConfig(worktreePath, func(values map[string]interface{}) error {
+		for k, v := range data {
+			values[k] = v
+		}
+		return nil
+	})
+}
 
-	dataMap := make(map[string]interface{})
-	for k, v := range data {
-		dataMap[k] = v
+// UpdateWorktreeConfig changes the worktree-local arbor.yaml with update,
+// which receives every key currently in the file. The file is locked from
+// reading to writing, so concurrent steps and arbor processes updating
+// different keys do not lose each other's changes, and it is replaced
+// atomically so readers never see a partial file.
+func UpdateWorktreeConfig(worktreePath string, update func(values map[string]interface{}) error) error {
+	configPath, err := worktreeConfigPath(worktreePath)
+	if err != nil {
+		return err
 	}
 
-	if err := v.MergeConfigMap(dataMap); err != nil {
-		return fmt.Errorf("merging worktree config: %w", err)
+	unlock, err := lockFile(configPath)
+	if err != nil {
+		return err
 	}
+	defer unlock()
 
-	configPath := filepath.Join(worktreePath, "arbor.yaml")
+	values, err := readWorktreeValues(configPath)
+	if err != nil {
+		return err
+	}
+	if err := update(values); err != nil {
+		return err
+	}
 
-	if err := v.WriteConfigAs(configPath); err != nil {
+	data, err := yaml.Marshal(values)
+	if err != nil {
+		return fmt.Errorf("encoding worktree config: %w", err)
+	}
+	if err := writeFileAtomic(configPath, data, 0644); err != nil {
 		return fmt.Errorf("writing worktree config: %w", err)
 	}
-
 	return nil
 }
+
+// worktreeConfigPath returns the file worktree-local configuration is kept
+// in: the worktree's arbor.yaml, or, in a regular clone, where arbor.yaml is
+// the project's tracked config checked out in every worktree, an arbor.yaml
+// in the worktree's git directory
+func worktreeConfigPath(worktreePath string) (string, error) {
+	gitDir, err := cloneGitDir(worktreePath)
+	if err != nil {
+		return "", err
+	}
+	if gitDir != "" {
+		return filepath.Join(gitDir, "arbor.yaml"), nil
+	}
+	return filepath.Join(worktreePath, "arbor.yaml"), nil
+}
+
+// cloneGitDir returns the git directory of the worktree at worktreePath when
+// it is the checkout of a regular clone or one of its linked worktrees, and
+// "" for the worktrees of a bare repository
+func cloneGitDir(worktreePath string) (string, error) {
+	dotGit := filepath.Join(worktreePath, ".git")
+	info, err := os.Stat(dotGit)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("reading worktree git directory: %w", err)
+	}
+	if info.IsDir() {
+		return dotGit, nil
+	}
+
+	data, err := os.ReadFile(dotGit)
+	if err != nil {
+		return "", fmt.Errorf("reading worktree git directory: %w", err)
+	}
+	gitDir, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir: ")
+	if !ok {
+		return "", nil
+	}
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(worktreePath, gitDir)
+	}
+	// Linked worktrees of a clone keep their git directory in
+	// .git/worktrees/<name>
+	if filepath.Base(filepath.Dir(filepath.Dir(gitDir))) != ".git" {
+		return "", nil
+	}
+	return filepath.Clean(gitDir), nil
+}
+
+// readWorktreeValues reads the worktree-local arbor.yaml as a map, which is
+// empty when the file does not exist
+func readWorktreeValues(configPath string) (map[string]interface{}, error) {
+	values := make(map[string]interface{})
+
+	data, err := os.ReadFile(configPath)
+	if os.IsNotExist(err) {
+		return values, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading worktree config: %w", err)
+	}
+
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("parsing worktree config: %w", err)
+	}
+	if values == nil {
+		values = make(map[string]interface{})
+	}
+	return values, nil
+}
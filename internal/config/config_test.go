@@ -1,13 +1,18 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	arborerrors "github.com/michaeldyrynda/arbor/internal/errors"
 )
 
 func TestLoadProject_ValidConfig(t *testing.T) {
@@ -69,6 +74,48 @@ detected_tools:
 	assert.True(t, cfg.DetectedTools["php"])
 }
 
+func TestLoadGlobal_NoInput(t *testing.T) {
+	tmpDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "arbor.yaml"), []byte("no_input: true\n"), 0644))
+
+	cfg, err := loadGlobalFromTestDir(tmpDir)
+
+	require.NoError(t, err)
+	assert.True(t, cfg.NoInput)
+}
+
+func TestLoadGlobal_Network(t *testing.T) {
+	tmpDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "arbor.yaml"), []byte("network:\n  retries: 0\n  retry_delay: 500ms\n"), 0644))
+
+	cfg, err := loadGlobalFromTestDir(tmpDir)
+
+	require.NoError(t, err)
+	require.NotNil(t, cfg.Network.Retries)
+	assert.Equal(t, 0, *cfg.Network.Retries)
+	assert.Equal(t, 500*time.Millisecond, cfg.Network.RetryDelay)
+}
+
+func TestLoadGlobal_WindowsShell(t *testing.T) {
+	xdg := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+	configPath := filepath.Join(xdg, "arbor", "arbor.yaml")
+	require.NoError(t, os.MkdirAll(filepath.Dir(configPath), 0755))
+	require.NoError(t, os.WriteFile(configPath, []byte("windows_shell: cmd\n"), 0644))
+
+	cfg, err := LoadGlobal()
+	require.NoError(t, err)
+	assert.Equal(t, "cmd", cfg.WindowsShell)
+
+	project := &Config{}
+	project.ApplyGlobalDefaults(cfg)
+	assert.Equal(t, "cmd", project.WindowsShell)
+
+	require.NoError(t, os.WriteFile(configPath, []byte("windows_shell: powershell\n"), 0644))
+	_, err = LoadGlobal()
+	assert.ErrorContains(t, err, "windows_shell must be sh or cmd")
+}
+
 func TestLoadGlobal_MissingConfig(t *testing.T) {
 	tmpDir := t.TempDir()
 
@@ -210,3 +257,432 @@ func loadGlobalFromTestDir(testDir string) (*GlobalConfig, error) {
 
 	return &config, nil
 }
+
+func TestLoadProject_EnvOverrides(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	configContent := `preset: php
+default_branch: main
+db:
+  username: file_user
+`
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "arbor.yaml"), []byte(configContent), 0644))
+
+	t.Setenv("ARBOR_DEFAULT_BRANCH", "develop")
+	t.Setenv("ARBOR_PRESET", "laravel")
+	t.Setenv("ARBOR_DB_USERNAME", "ci_user")
+	t.Setenv("ARBOR_DB_PASSWORD", "secret")
+	t.Setenv("ARBOR_DB_SSL_MODE", "verify-full")
+
+	cfg, err := LoadProject(tmpDir)
+
+	require.NoError(t, err)
+	assert.Equal(t, "develop", cfg.DefaultBranch)
+	assert.Equal(t, "laravel", cfg.Preset)
+	assert.Equal(t, "ci_user", cfg.DB.Username)
+	assert.Equal(t, "secret", cfg.DB.Password)
+	assert.Equal(t, "verify-full", cfg.DB.SSLMode)
+}
+
+func TestLoadProject_FileValuesWithoutEnv(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	configContent := `preset: php
+db:
+  host: db.internal
+  port: 3307
+`
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "arbor.yaml"), []byte(configContent), 0644))
+
+	cfg, err := LoadProject(tmpDir)
+
+	require.NoError(t, err)
+	assert.Equal(t, "php", cfg.Preset)
+	assert.Equal(t, "db.internal", cfg.DB.Host)
+	assert.Equal(t, "3307", cfg.DB.Port)
+}
+
+func TestEnvVarName(t *testing.T) {
+	assert.Equal(t, "ARBOR_DEFAULT_BRANCH", EnvVarName("default_branch"))
+	assert.Equal(t, "ARBOR_DB_USERNAME", EnvVarName("db.username"))
+}
+
+func TestLoadGlobal_MissingConfigIsConfigNotFound(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	cfg, err := LoadGlobal()
+
+	assert.Nil(t, cfg)
+	assert.ErrorIs(t, err, arborerrors.ErrConfigNotFound)
+}
+
+func TestLoadGlobal_DefaultSteps(t *testing.T) {
+	xdg := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+
+	configContent := `scaffold:
+  steps:
+    - name: bash.run
+      command: git config core.hooksPath .githooks
+cleanup:
+  - name: bash.run
+`
+	require.NoError(t, os.MkdirAll(filepath.Join(xdg, "arbor"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(xdg, "arbor", "arbor.yaml"), []byte(configContent), 0644))
+
+	cfg, err := LoadGlobal()
+
+	require.NoError(t, err)
+	require.Len(t, cfg.Scaffold.Steps, 1)
+	assert.Equal(t, "git config core.hooksPath .githooks", cfg.Scaffold.Steps[0].Command)
+	require.Len(t, cfg.Cleanup, 1)
+	assert.Equal(t, "bash.run", cfg.Cleanup[0].Name)
+}
+
+func TestConfig_ApplyGlobalDefaults(t *testing.T) {
+	global := &GlobalConfig{
+		Scaffold: GlobalScaffoldConfig{
+			Steps: []StepConfig{{Name: "git.hooks"}, {Name: "notify"}},
+		},
+		Cleanup: []StepConfig{{Name: "notify"}, {Name: "herd"}},
+	}
+
+	cfg := &Config{Scaffold: ScaffoldConfig{Disable: []string{"notify"}}}
+	cfg.ApplyGlobalDefaults(global)
+
+	assert.Equal(t, []StepConfig{{Name: "git.hooks"}}, cfg.GlobalSteps)
+	assert.Equal(t, []StepConfig{{Name: "herd"}}, cfg.GlobalCleanup)
+}
+
+func TestConfig_DatabasePrefixes(t *testing.T) {
+	cfg := &Config{
+		SiteName: "app",
+		Scaffold: ScaffoldConfig{Steps: []StepConfig{
+			{Name: "db.create", Args: []string{"--prefix", "quotes"}},
+			{Name: "db.create", Args: []string{"--prefix", "app"}},
+			{Name: "db.create"},
+		}},
+		GlobalSteps: []StepConfig{{Name: "db.create", Args: []string{"--type", "mysql", "--prefix", "shared"}}},
+	}
+
+	assert.Equal(t, []string{"app", "quotes", "shared"}, cfg.DatabasePrefixes())
+	assert.Empty(t, (&Config{}).DatabasePrefixes())
+}
+
+func TestConfig_IsProtectedBranch(t *testing.T) {
+	cfg := &Config{ProtectedBranches: []string{"main", "develop", "release/*"}}
+
+	assert.True(t, cfg.IsProtectedBranch("main"))
+	assert.True(t, cfg.IsProtectedBranch("develop"))
+	assert.True(t, cfg.IsProtectedBranch("release/1.2"))
+	assert.False(t, cfg.IsProtectedBranch("release/1.2/hotfix"))
+	assert.False(t, cfg.IsProtectedBranch("feature/release"))
+	assert.False(t, (&Config{}).IsProtectedBranch("main"))
+
+	malformed := &Config{ProtectedBranches: []string{"release/["}}
+	assert.True(t, malformed.IsProtectedBranch("feature/login"), "a malformed pattern protects every branch")
+}
+
+func TestConfig_BaseBranchFor(t *testing.T) {
+	cfg := &Config{BaseBranches: []BaseBranchRule{
+		{Pattern: "hotfix/*", Base: "production"},
+		{Pattern: "feature/*", Base: "develop"},
+		{Pattern: "*", Base: "staging"},
+	}}
+
+	tests := []struct {
+		cfg    *Config
+		branch string
+		want   string
+	}{
+		{cfg, "hotfix/login", "production"},
+		{cfg, "feature/login", "develop"},
+		{cfg, "spike", "staging"},
+		{cfg, "feature/login/part-2", ""},
+		{&Config{}, "hotfix/login", ""},
+	}
+	for _, tt := range tests {
+		got, err := tt.cfg.BaseBranchFor(tt.branch)
+		require.NoError(t, err, tt.branch)
+		assert.Equal(t, tt.want, got, tt.branch)
+	}
+
+	malformed := &Config{BaseBranches: []BaseBranchRule{{Pattern: "hotfix/[", Base: "production"}}}
+	_, err := malformed.BaseBranchFor("hotfix/login")
+	assert.ErrorContains(t, err, `base_branches pattern "hotfix/["`)
+}
+
+func TestLoadProject_BaseBranches(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	configContent := `base_branches:
+  - pattern: hotfix/*
+    base: production
+  - pattern: feature/*
+    base: develop
+`
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "arbor.yaml"), []byte(configContent), 0644))
+
+	cfg, err := LoadProject(tmpDir)
+
+	require.NoError(t, err)
+	assert.Equal(t, []BaseBranchRule{
+		{Pattern: "hotfix/*", Base: "production"},
+		{Pattern: "feature/*", Base: "develop"},
+	}, cfg.BaseBranches)
+
+	base, err := cfg.BaseBranchFor("hotfix/x")
+	require.NoError(t, err)
+	assert.Equal(t, "production", base)
+}
+
+func TestLoadProject_ProtectedBranches(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	configContent := `protected_branches: [main, develop, release/*]
+`
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "arbor.yaml"), []byte(configContent), 0644))
+
+	cfg, err := LoadProject(tmpDir)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"main", "develop", "release/*"}, cfg.ProtectedBranches)
+}
+
+func TestLoadProject_Tasks(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	configContent := `tasks:
+  fresh:
+    - db.create
+    - php.laravel.artisan migrate:fresh --seed
+    - name: bash.run
+      command: npm run build
+`
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "arbor.yaml"), []byte(configContent), 0644))
+
+	cfg, err := LoadProject(tmpDir)
+
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]StepConfig{
+		"fresh": {
+			{Name: "db.create"},
+			{Name: "php.laravel.artisan", Args: []string{"migrate:fresh", "--seed"}},
+			{Name: "bash.run", Command: "npm run build"},
+		},
+	}, cfg.Tasks)
+}
+
+func TestParseStepShorthand(t *testing.T) {
+	assert.Equal(t, StepConfig{Name: "php.composer", Args: []string{"install", "--no-dev"}}, ParseStepShorthand("php.composer  install --no-dev"))
+	assert.Equal(t, StepConfig{Name: "db.create"}, ParseStepShorthand("db.create"))
+	assert.Equal(t, StepConfig{}, ParseStepShorthand("  "))
+}
+
+func TestLoadProject_Vars(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	configContent := `preset: php
+vars:
+  api_base: https://api.test
+  workers: 4
+`
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "arbor.yaml"), []byte(configContent), 0644))
+
+	cfg, err := LoadProject(tmpDir)
+
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"api_base": "https://api.test", "workers": "4"}, cfg.Vars)
+}
+
+func TestLoadProject_Settings(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	configContent := `preset: php
+vars:
+  seed: true
+`
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "arbor.yaml"), []byte(configContent), 0644))
+	t.Setenv("ARBOR_PRESET", "laravel")
+
+	cfg, err := LoadProject(tmpDir)
+
+	require.NoError(t, err)
+	assert.Equal(t, "laravel", cfg.Settings["preset"])
+	assert.Equal(t, map[string]interface{}{"seed": true}, cfg.Settings["vars"])
+}
+
+func TestSaveProject_NewFile(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	require.NoError(t, SaveProject(tmpDir, &Config{SiteName: "myapp", Preset: "laravel", DefaultBranch: "main"}))
+
+	cfg, err := LoadProject(tmpDir)
+	require.NoError(t, err)
+	assert.Equal(t, CurrentConfigVersion, cfg.Version)
+	assert.Equal(t, "myapp", cfg.SiteName)
+	assert.Equal(t, "laravel", cfg.Preset)
+	assert.Equal(t, "main", cfg.DefaultBranch)
+}
+
+func TestSaveProject_KeepsExistingKeysAndComments(t *testing.T) {
+	tmpDir := t.TempDir()
+	existing := `# Shared arbor config
+site_name: shipped
+scaffold:
+  steps:
+    # Install PHP dependencies
+    - name: php.composer
+      args: ["install"]
+cleanup:
+  - name: herd.unlink
+tools:
+  php:
+    version_file: .php-version
+`
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "arbor.yaml"), []byte(existing), 0644))
+
+	require.NoError(t, SaveProject(tmpDir, &Config{SiteName: "myapp", DefaultBranch: "main"}))
+
+	data, err := os.ReadFile(filepath.Join(tmpDir, "arbor.yaml"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "# Shared arbor config")
+	assert.Contains(t, string(data), "# Install PHP dependencies")
+
+	cfg, err := LoadProject(tmpDir)
+	require.NoError(t, err)
+	assert.Equal(t, "myapp", cfg.SiteName)
+	assert.Equal(t, "main", cfg.DefaultBranch)
+	require.Len(t, cfg.Scaffold.Steps, 1)
+	assert.Equal(t, "php.composer", cfg.Scaffold.Steps[0].Name)
+	require.Len(t, cfg.Cleanup, 1)
+	assert.Equal(t, ".php-version", cfg.Tools["php"].VersionFile)
+}
+
+func TestSaveProject_RefusesInvalidYAML(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "arbor.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte("scaffold: [unclosed\n"), 0644))
+
+	err := SaveProject(tmpDir, &Config{SiteName: "myapp"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "left unchanged")
+
+	data, err := os.ReadFile(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, "scaffold: [unclosed\n", string(data))
+}
+
+func TestWriteWorktreeConfig_MergesKeys(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	require.NoError(t, WriteWorktreeConfig(tmpDir, map[string]string{"base_branch": "develop"}))
+	require.NoError(t, WriteWorktreeConfig(tmpDir, map[string]string{"db_suffix": "swift_runner"}))
+
+	cfg, err := ReadWorktreeConfig(tmpDir)
+	require.NoError(t, err)
+	assert.Equal(t, "develop", cfg.BaseBranch)
+	assert.Equal(t, "swift_runner", cfg.DbSuffix)
+}
+
+func TestWriteWorktreeConfig_KeepsUnknownKeys(t *testing.T) {
+	tmpDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "arbor.yaml"), []byte("db_suffix: swift_runner\nmeilisearch_index: app_swift_runner\nports:\n  vite: 5174\n"), 0644))
+
+	require.NoError(t, WriteWorktreeConfig(tmpDir, map[string]string{"base_branch": "develop"}))
+
+	cfg, err := ReadWorktreeConfig(tmpDir)
+	require.NoError(t, err)
+	assert.Equal(t, "swift_runner", cfg.DbSuffix)
+	assert.Equal(t, "develop", cfg.BaseBranch)
+	assert.Equal(t, "app_swift_runner", cfg.State["meilisearch_index"])
+	assert.Equal(t, map[string]interface{}{"vite": 5174}, cfg.State["ports"])
+	assert.NoFileExists(t, filepath.Join(tmpDir, "arbor.yaml.lock"))
+}
+
+func TestWriteWorktreeConfig_KeepsCloneStateInGitDir(t *testing.T) {
+	clonePath := t.TempDir()
+	gitDir := filepath.Join(clonePath, ".git")
+	require.NoError(t, os.MkdirAll(filepath.Join(gitDir, "worktrees", "feature"), 0755))
+	projectConfig := []byte("site_name: myapp\n")
+	require.NoError(t, os.WriteFile(filepath.Join(clonePath, "arbor.yaml"), projectConfig, 0644))
+
+	featurePath := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(featurePath, ".git"), []byte("gitdir: "+filepath.Join(gitDir, "worktrees", "feature")+"\n"), 0644))
+
+	require.NoError(t, WriteWorktreeConfig(clonePath, map[string]string{"db_suffix": "swift_runner"}))
+	require.NoError(t, WriteWorktreeConfig(featurePath, map[string]string{"db_suffix": "calm_meadow"}))
+
+	data, err := os.ReadFile(filepath.Join(clonePath, "arbor.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, projectConfig, data, "the tracked project config is left alone")
+	assert.NoFileExists(t, filepath.Join(featurePath, "arbor.yaml"))
+	assert.FileExists(t, filepath.Join(gitDir, "arbor.yaml"))
+	assert.FileExists(t, filepath.Join(gitDir, "worktrees", "feature", "arbor.yaml"))
+
+	cfg, err := ReadWorktreeConfig(clonePath)
+	require.NoError(t, err)
+	assert.Equal(t, "swift_runner", cfg.DbSuffix)
+
+	cfg, err = ReadWorktreeConfig(featurePath)
+	require.NoError(t, err)
+	assert.Equal(t, "calm_meadow", cfg.DbSuffix)
+}
+
+func TestWriteWorktreeConfig_ConcurrentWriters(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			assert.NoError(t, WriteWorktreeConfig(tmpDir, map[string]string{fmt.Sprintf("key_%d", i): "set"}))
+		}(i)
+	}
+	wg.Wait()
+
+	cfg, err := ReadWorktreeConfig(tmpDir)
+	require.NoError(t, err)
+	assert.Len(t, cfg.State, 20, "no writer's key is lost")
+}
+
+func TestUpdateWorktreeConfig_BreaksStaleLock(t *testing.T) {
+	tmpDir := t.TempDir()
+	lockPath := filepath.Join(tmpDir, "arbor.yaml.lock")
+	require.NoError(t, os.WriteFile(lockPath, []byte("12345\n"), 0644))
+	old := time.Now().Add(-2 * staleLockAge)
+	require.NoError(t, os.Chtimes(lockPath, old, old))
+
+	require.NoError(t, UpdateWorktreeConfig(tmpDir, func(values map[string]interface{}) error {
+		values["db_suffix"] = "swift_runner"
+		return nil
+	}))
+
+	cfg, err := ReadWorktreeConfig(tmpDir)
+	require.NoError(t, err)
+	assert.Equal(t, "swift_runner", cfg.DbSuffix)
+}
+
+func TestCodeWorkspaceSettings_KeepsSettingNames(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	configContent := `code_workspace:
+  auto_update: true
+  settings:
+    php.validate.executablePath: /opt/php/bin/php
+    editor.formatOnSave: true
+`
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "arbor.yaml"), []byte(configContent), 0644))
+
+	settings, err := CodeWorkspaceSettings(tmpDir)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"php.validate.executablePath": "/opt/php/bin/php",
+		"editor.formatOnSave":         true,
+	}, settings)
+
+	cfg, err := LoadProject(tmpDir)
+	require.NoError(t, err)
+	assert.True(t, cfg.CodeWorkspace.AutoUpdate)
+}
@@ -0,0 +1,120 @@
+package config
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// SchemaDocURL points at the configuration reference
+const SchemaDocURL = "https://github.com/michaeldyrynda/arbor#configuration"
+
+const jsonSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// JSONSchema returns a JSON Schema document describing arbor.yaml, suitable
+// for editor integrations such as the VS Code YAML extension. Step names and
+// condition keys are enumerated when provided in opts.
+func JSONSchema(opts ValidateOptions) ([]byte, error) {
+	doc := jsonSchemaFor(projectSchema, opts)
+	doc["$schema"] = jsonSchemaDraft
+	doc["title"] = "arbor.yaml"
+	doc["description"] = "Arbor project configuration. See " + SchemaDocURL
+	doc["$defs"] = map[string]interface{}{
+		"condition": conditionJSONSchema(opts.ConditionNames),
+	}
+
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+func jsonSchemaFor(field *schemaField, opts ValidateOptions) map[string]interface{} {
+	out := make(map[string]interface{})
+	if field.description != "" {
+		out["description"] = field.description
+	}
+	if field.deprecated {
+		out["deprecated"] = true
+	}
+
+	switch field.kind {
+	case kindString:
+		out["type"] = "string"
+	case kindBool:
+		out["type"] = "boolean"
+	case kindInt:
+		out["type"] = "integer"
+	case kindList:
+		out["type"] = "array"
+		if field.elem != nil {
+			out["items"] = jsonSchemaFor(field.elem, opts)
+			if field.shorthand {
+				out["items"] = map[string]interface{}{
+					"anyOf": []interface{}{
+						map[string]interface{}{"type": "string", "description": "Step name followed by its args"},
+						out["items"],
+					},
+				}
+			}
+		}
+	case kindMap:
+		out["type"] = "object"
+		if field.fields != nil {
+			properties := make(map[string]interface{}, len(field.fields))
+			for name, child := range field.fields {
+				properties[name] = jsonSchemaFor(child, opts)
+			}
+			out["properties"] = properties
+			out["additionalProperties"] = false
+		} else if field.elem != nil {
+			out["additionalProperties"] = jsonSchemaFor(field.elem, opts)
+		}
+		if field == stepSchema {
+			out["required"] = []string{"name"}
+			if len(opts.StepNames) > 0 {
+				if props, ok := out["properties"].(map[string]interface{}); ok {
+					names := append([]string(nil), opts.StepNames...)
+					sort.Strings(names)
+					props["name"] = map[string]interface{}{
+						"description": field.fields["name"].description,
+						"type":        "string",
+						"enum":        names,
+					}
+				}
+			}
+		}
+	case kindCondition:
+		return map[string]interface{}{
+			"description": field.description,
+			"$ref":        "#/$defs/condition",
+		}
+	}
+
+	return out
+}
+
+func conditionJSONSchema(conditionNames []string) map[string]interface{} {
+	object := map[string]interface{}{
+		"type": "object",
+	}
+
+	if len(conditionNames) > 0 {
+		properties := make(map[string]interface{}, len(conditionNames))
+		for _, name := range conditionNames {
+			properties[name] = map[string]interface{}{}
+		}
+		for _, operator := range []string{"not", "any_of", "all_of"} {
+			properties[operator] = map[string]interface{}{"$ref": "#/$defs/condition"}
+		}
+		object["properties"] = properties
+		object["additionalProperties"] = false
+	}
+
+	return map[string]interface{}{
+		"description": "Conditions that must all be met for the step to run",
+		"oneOf": []interface{}{
+			object,
+			map[string]interface{}{
+				"type":  "array",
+				"items": map[string]interface{}{"$ref": "#/$defs/condition"},
+			},
+		},
+	}
+}
@@ -0,0 +1,79 @@
+package config
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONSchema(t *testing.T) {
+	data, err := JSONSchema(ValidateOptions{
+		StepNames:      []string{"php.composer", "db.create"},
+		ConditionNames: []string{"file_exists", "not"},
+	})
+	require.NoError(t, err)
+
+	var doc map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &doc))
+
+	assert.Equal(t, jsonSchemaDraft, doc["$schema"])
+	assert.Equal(t, "object", doc["type"])
+	assert.Equal(t, false, doc["additionalProperties"])
+
+	properties := doc["properties"].(map[string]interface{})
+	for _, key := range []string{"site_name", "preset", "default_branch", "scaffold", "cleanup", "tools"} {
+		assert.Contains(t, properties, key)
+	}
+
+	t.Run("steps enumerate registered names", func(t *testing.T) {
+		scaffold := properties["scaffold"].(map[string]interface{})
+		steps := scaffold["properties"].(map[string]interface{})["steps"].(map[string]interface{})
+		item := steps["items"].(map[string]interface{})
+		stepProps := item["properties"].(map[string]interface{})
+
+		assert.Equal(t, []interface{}{"name"}, item["required"])
+		assert.Equal(t, "integer", stepProps["priority"].(map[string]interface{})["type"])
+		assert.Equal(t, []interface{}{"db.create", "php.composer"}, stepProps["name"].(map[string]interface{})["enum"])
+		assert.Equal(t, "#/$defs/condition", stepProps["condition"].(map[string]interface{})["$ref"])
+	})
+
+	t.Run("deprecated keys are marked", func(t *testing.T) {
+		assert.Equal(t, true, properties["bare_path"].(map[string]interface{})["deprecated"])
+		assert.NotContains(t, properties["site_name"], "deprecated")
+	})
+
+	t.Run("task steps may be strings", func(t *testing.T) {
+		tasks := properties["tasks"].(map[string]interface{})
+		task := tasks["additionalProperties"].(map[string]interface{})
+		anyOf := task["items"].(map[string]interface{})["anyOf"].([]interface{})
+
+		require.Len(t, anyOf, 2)
+		assert.Equal(t, "string", anyOf[0].(map[string]interface{})["type"])
+		assert.Equal(t, []interface{}{"name"}, anyOf[1].(map[string]interface{})["required"])
+	})
+
+	t.Run("conditions are defined recursively", func(t *testing.T) {
+		defs := doc["$defs"].(map[string]interface{})
+		condition := defs["condition"].(map[string]interface{})
+		object := condition["oneOf"].([]interface{})[0].(map[string]interface{})
+		conditionProps := object["properties"].(map[string]interface{})
+
+		assert.Contains(t, conditionProps, "file_exists")
+		assert.Equal(t, "#/$defs/condition", conditionProps["not"].(map[string]interface{})["$ref"])
+	})
+}
+
+func TestJSONSchema_WithoutRegistries(t *testing.T) {
+	data, err := JSONSchema(ValidateOptions{})
+	require.NoError(t, err)
+
+	var doc map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &doc))
+
+	defs := doc["$defs"].(map[string]interface{})
+	condition := defs["condition"].(map[string]interface{})
+	object := condition["oneOf"].([]interface{})[0].(map[string]interface{})
+	assert.NotContains(t, object, "additionalProperties")
+}
@@ -0,0 +1,131 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// LocalConfigFile is the personal, gitignored config file whose values are
+// deep-merged over the shared arbor.yaml
+const LocalConfigFile = "arbor.local.yaml"
+
+// mergeLocalConfig merges arbor.local.yaml from dir over the shared config
+// already read into v. Mappings are merged key by key. Step lists are merged
+// by name: a local step whose name matches exactly one shared step is merged
+// into it, any other local step is appended.
+func mergeLocalConfig(v *viper.Viper, dir string, sharedData []byte) error {
+	localPath := filepath.Join(dir, LocalConfigFile)
+	if _, err := os.Stat(localPath); os.IsNotExist(err) {
+		return nil
+	}
+
+	if err := ValidateProjectFile(localPath, ValidateOptions{}); err != nil {
+		return err
+	}
+
+	local, err := readYAMLMap(localPath)
+	if err != nil {
+		return err
+	}
+
+	shared := make(map[string]interface{})
+	if err := yaml.Unmarshal(sharedData, &shared); err != nil {
+		return fmt.Errorf("parsing config: %w", err)
+	}
+
+	merged, _ := mergeValues(shared, local).(map[string]interface{})
+	if err := v.MergeConfigMap(merged); err != nil {
+		return fmt.Errorf("merging %s: %w", LocalConfigFile, err)
+	}
+
+	return nil
+}
+
+func readYAMLMap(path string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading config: %w", err)
+	}
+
+	values := make(map[string]interface{})
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("parsing config %s: %w", path, err)
+	}
+
+	return values, nil
+}
+
+func mergeValues(base, overlay interface{}) interface{} {
+	switch o := overlay.(type) {
+	case map[string]interface{}:
+		b, ok := base.(map[string]interface{})
+		if !ok {
+			return o
+		}
+		result := make(map[string]interface{}, len(b)+len(o))
+		for k, v := range b {
+			result[k] = v
+		}
+		for k, v := range o {
+			result[k] = mergeValues(b[k], v)
+		}
+		return result
+	case []interface{}:
+		b, ok := base.([]interface{})
+		if !ok {
+			return o
+		}
+		return mergeNamedLists(b, o)
+	default:
+		return overlay
+	}
+}
+
+func mergeNamedLists(base, overlay []interface{}) []interface{} {
+	result := make([]interface{}, len(base), len(base)+len(overlay))
+	copy(result, base)
+
+	for _, item := range overlay {
+		if i := uniqueNamedIndex(result, itemName(item)); i >= 0 {
+			result[i] = mergeValues(result[i], item)
+			continue
+		}
+		result = append(result, item)
+	}
+
+	return result
+}
+
+func itemName(item interface{}) string {
+	m, ok := item.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	name, _ := m["name"].(string)
+	return name
+}
+
+// uniqueNamedIndex returns the index of the only item called name, or -1 when
+// there is no such item or the name is ambiguous
+func uniqueNamedIndex(items []interface{}, name string) int {
+	if name == "" {
+		return -1
+	}
+
+	found := -1
+	for i, item := range items {
+		if itemName(item) != name {
+			continue
+		}
+		if found >= 0 {
+			return -1
+		}
+		found = i
+	}
+
+	return found
+}
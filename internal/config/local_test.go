@@ -0,0 +1,118 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadProject_MergesLocalConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	shared := `preset: laravel
+default_branch: main
+db:
+  host: 127.0.0.1
+  username: root
+scaffold:
+  steps:
+    - name: php.composer
+      args: ["install"]
+      priority: 10
+    - name: bash.run
+      command: echo one
+    - name: bash.run
+      command: echo two
+cleanup:
+  - name: herd
+`
+	local := `db:
+  username: me
+  password: secret
+scaffold:
+  steps:
+    - name: php.composer
+      enabled: false
+    - name: node.npm
+      args: ["ci"]
+`
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "arbor.yaml"), []byte(shared), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, LocalConfigFile), []byte(local), 0644))
+
+	cfg, err := LoadProject(tmpDir)
+
+	require.NoError(t, err)
+	assert.Equal(t, "laravel", cfg.Preset)
+	assert.Equal(t, "127.0.0.1", cfg.DB.Host)
+	assert.Equal(t, "me", cfg.DB.Username)
+	assert.Equal(t, "secret", cfg.DB.Password)
+
+	require.Len(t, cfg.Scaffold.Steps, 4)
+	composer := cfg.Scaffold.Steps[0]
+	assert.Equal(t, "php.composer", composer.Name)
+	assert.Equal(t, []string{"install"}, composer.Args)
+	assert.Equal(t, 10, composer.Priority)
+	require.NotNil(t, composer.Enabled)
+	assert.False(t, *composer.Enabled)
+
+	assert.Equal(t, "echo one", cfg.Scaffold.Steps[1].Command)
+	assert.Equal(t, "echo two", cfg.Scaffold.Steps[2].Command)
+	assert.Equal(t, "node.npm", cfg.Scaffold.Steps[3].Name)
+
+	require.Len(t, cfg.Cleanup, 1)
+	assert.Equal(t, "herd", cfg.Cleanup[0].Name)
+}
+
+func TestLoadProject_AmbiguousLocalStepIsAppended(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	shared := `scaffold:
+  steps:
+    - name: bash.run
+      command: echo one
+    - name: bash.run
+      command: echo two
+`
+	local := `scaffold:
+  steps:
+    - name: bash.run
+      command: echo three
+`
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "arbor.yaml"), []byte(shared), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, LocalConfigFile), []byte(local), 0644))
+
+	cfg, err := LoadProject(tmpDir)
+
+	require.NoError(t, err)
+	require.Len(t, cfg.Scaffold.Steps, 3)
+	assert.Equal(t, "echo three", cfg.Scaffold.Steps[2].Command)
+}
+
+func TestLoadProject_InvalidLocalConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "arbor.yaml"), []byte("preset: php\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, LocalConfigFile), []byte("presett: laravel\n"), 0644))
+
+	cfg, err := LoadProject(tmpDir)
+
+	assert.Nil(t, cfg)
+	var validationErr *ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	assert.Contains(t, validationErr.File, LocalConfigFile)
+}
+
+func TestLoadProject_WithoutLocalConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "arbor.yaml"), []byte("preset: php\n"), 0644))
+
+	cfg, err := LoadProject(tmpDir)
+
+	require.NoError(t, err)
+	assert.Equal(t, "php", cfg.Preset)
+}
@@ -0,0 +1,62 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+const (
+	// lockTimeout is how long a writer waits for another to finish
+	lockTimeout = 10 * time.Second
+	// lockRetryInterval is how often a waiting writer tries again
+	lockRetryInterval = 20 * time.Millisecond
+	// staleLockAge is how old a lock must be before it is treated as left
+	// behind by a process that crashed, and removed
+	staleLockAge = time.Minute
+)
+
+// lockFile takes an exclusive lock on path by creating path.lock, waiting
+// while another goroutine or arbor process holds it. Creating the file with
+// O_EXCL works the same on every platform. The returned function releases
+// the lock.
+func lockFile(path string) (func(), error) {
+	lockPath := path + ".lock"
+	deadline := time.Now().Add(lockTimeout)
+
+	for {
+		f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			fmt.Fprintf(f, "%d\n", os.Getpid())
+			f.Close()
+			return func() { os.Remove(lockPath) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("locking %s: %w", path, err)
+		}
+
+		if info, err := os.Stat(lockPath); err == nil && time.Since(info.ModTime()) > staleLockAge {
+			os.Remove(lockPath)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out waiting for %s; remove it if no arbor command is running", lockPath)
+		}
+		time.Sleep(lockRetryInterval)
+	}
+}
+
+// writeFileAtomic writes data to a temporary file and renames it over path,
+// so readers see either the old or the new contents
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmpFile := path + ".tmp"
+	if err := os.WriteFile(tmpFile, data, perm); err != nil {
+		return fmt.Errorf("writing temp file: %w", err)
+	}
+
+	if err := os.Rename(tmpFile, path); err != nil {
+		os.Remove(tmpFile)
+		return fmt.Errorf("renaming temp file: %w", err)
+	}
+	return nil
+}
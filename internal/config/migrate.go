@@ -0,0 +1,161 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CurrentConfigVersion is the arbor.yaml format version understood by this release
+const CurrentConfigVersion = 1
+
+// migration upgrades a config document to version, returning a description
+// of each change it made
+type migration struct {
+	version int
+	apply   func(root *yaml.Node) []string
+}
+
+var migrations = []migration{
+	{version: 1, apply: migrateToV1},
+}
+
+// legacyStepNames maps step names from older releases to their replacements
+var legacyStepNames = map[string]string{
+	"database.create":  "db.create",
+	"database.destroy": "db.destroy",
+}
+
+// MigrateProject upgrades raw arbor.yaml content to CurrentConfigVersion. It
+// returns the migrated content and a description of each change; when nothing
+// needs to change the original data is returned with no changes.
+func MigrateProject(data []byte) ([]byte, []string, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, nil, fmt.Errorf("parsing yaml: %w", err)
+	}
+
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return data, nil, nil
+	}
+	root := doc.Content[0]
+
+	version := 0
+	if node := findKey(root, "version"); node != nil {
+		parsed, err := strconv.Atoi(node.Value)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid config version %q", node.Value)
+		}
+		version = parsed
+	}
+
+	if version > CurrentConfigVersion {
+		return nil, nil, fmt.Errorf("config version %d is newer than this arbor supports (%d); please upgrade arbor", version, CurrentConfigVersion)
+	}
+
+	var changes []string
+	for _, m := range migrations {
+		if m.version > version {
+			changes = append(changes, m.apply(root)...)
+		}
+	}
+
+	if len(changes) == 0 {
+		return data, nil, nil
+	}
+
+	setVersion(root, CurrentConfigVersion)
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, nil, fmt.Errorf("encoding migrated config: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, nil, fmt.Errorf("encoding migrated config: %w", err)
+	}
+
+	return buf.Bytes(), changes, nil
+}
+
+// MigrateProjectFile migrates a project arbor.yaml in place, returning the
+// changes made
+func MigrateProjectFile(configPath string) ([]string, error) {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("reading config: %w", err)
+	}
+
+	migrated, changes, err := MigrateProject(data)
+	if err != nil {
+		return nil, fmt.Errorf("migrating %s: %w", configPath, err)
+	}
+
+	if len(changes) == 0 {
+		return nil, nil
+	}
+
+	if err := os.WriteFile(configPath, migrated, 0644); err != nil {
+		return nil, fmt.Errorf("writing config: %w", err)
+	}
+
+	return changes, nil
+}
+
+func migrateToV1(root *yaml.Node) []string {
+	var changes []string
+
+	if cleanup := findKey(root, "cleanup"); cleanup != nil && cleanup.Kind == yaml.MappingNode {
+		if steps := findKey(cleanup, "steps"); steps != nil && steps.Kind == yaml.SequenceNode {
+			*cleanup = *steps
+			changes = append(changes, "moved cleanup.steps to a list under cleanup")
+		}
+	}
+
+	if scaffold := findKey(root, "scaffold"); scaffold != nil && scaffold.Kind == yaml.MappingNode {
+		changes = append(changes, renameLegacySteps(findKey(scaffold, "steps"), "scaffold.steps")...)
+	}
+	changes = append(changes, renameLegacySteps(findKey(root, "cleanup"), "cleanup")...)
+
+	return changes
+}
+
+func renameLegacySteps(steps *yaml.Node, path string) []string {
+	if steps == nil || steps.Kind != yaml.SequenceNode {
+		return nil
+	}
+
+	var changes []string
+	for i, step := range steps.Content {
+		if step.Kind != yaml.MappingNode {
+			continue
+		}
+		name := findKey(step, "name")
+		if name == nil {
+			continue
+		}
+		if replacement, ok := legacyStepNames[name.Value]; ok {
+			changes = append(changes, fmt.Sprintf("renamed %s[%d] step %q to %q", path, i, name.Value, replacement))
+			name.Value = replacement
+		}
+	}
+
+	return changes
+}
+
+func setVersion(root *yaml.Node, version int) {
+	value := strconv.Itoa(version)
+	if node := findKey(root, "version"); node != nil {
+		node.Value = value
+		node.Tag = "!!int"
+		return
+	}
+
+	key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "version"}
+	val := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: value}
+	root.Content = append([]*yaml.Node{key, val}, root.Content...)
+}
@@ -0,0 +1,106 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const legacyConfig = `# project config
+preset: laravel
+scaffold:
+  steps:
+    - name: database.create
+      type: mysql
+    - name: php.composer
+cleanup:
+  steps:
+    - name: database.destroy
+    - name: herd
+`
+
+func TestMigrateProject_LegacyConfig(t *testing.T) {
+	migrated, changes, err := MigrateProject([]byte(legacyConfig))
+
+	require.NoError(t, err)
+	assert.Len(t, changes, 3)
+	assert.Contains(t, changes[0], "cleanup.steps")
+	assert.Contains(t, changes[1], `"database.create" to "db.create"`)
+	assert.Contains(t, changes[2], `"database.destroy" to "db.destroy"`)
+
+	content := string(migrated)
+	assert.Contains(t, content, "# project config")
+	assert.Contains(t, content, "version: 1")
+	assert.Contains(t, content, "name: db.create")
+	assert.NotContains(t, content, "database.")
+
+	issues, err := ValidateProject(migrated, ValidateOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, issues)
+}
+
+func TestMigrateProject_CurrentConfigUnchanged(t *testing.T) {
+	content := []byte("preset: php\ncleanup:\n  - name: herd\n")
+
+	migrated, changes, err := MigrateProject(content)
+
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+	assert.Equal(t, content, migrated)
+}
+
+func TestMigrateProject_NewerVersion(t *testing.T) {
+	_, _, err := MigrateProject([]byte("version: 99\n"))
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "newer than this arbor supports")
+}
+
+func TestMigrateProject_SkipsAppliedMigrations(t *testing.T) {
+	content := []byte("version: 1\nscaffold:\n  steps:\n    - name: database.create\n")
+
+	_, changes, err := MigrateProject(content)
+
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+}
+
+func TestLoadProject_MigratesInMemory(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "arbor.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte(legacyConfig), 0644))
+
+	cfg, err := LoadProject(tmpDir)
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, cfg.Version)
+	assert.Len(t, cfg.Migrations, 3)
+	assert.Equal(t, "db.create", cfg.Scaffold.Steps[0].Name)
+	require.Len(t, cfg.Cleanup, 2)
+	assert.Equal(t, "db.destroy", cfg.Cleanup[0].Name)
+
+	onDisk, err := os.ReadFile(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, legacyConfig, string(onDisk), "LoadProject should not rewrite the file")
+}
+
+func TestMigrateProjectFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "arbor.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte(legacyConfig), 0644))
+
+	changes, err := MigrateProjectFile(configPath)
+	require.NoError(t, err)
+	assert.Len(t, changes, 3)
+
+	changes, err = MigrateProjectFile(configPath)
+	require.NoError(t, err)
+	assert.Empty(t, changes, "migrating twice should be a no-op")
+
+	cfg, err := LoadProject(tmpDir)
+	require.NoError(t, err)
+	assert.Empty(t, cfg.Migrations)
+}
@@ -0,0 +1,131 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// maxPortSearch is how many ports above the base AllocatePort tries
+const maxPortSearch = 1000
+
+// PortsPath returns where the ports reserved for the project at barePath's
+// worktrees are kept
+func PortsPath(barePath string) string {
+	return filepath.Join(barePath, "arbor", "ports.json")
+}
+
+// AllocatePort returns the port reserved for service, such as vite, in the
+// worktree at worktreePath. A worktree without one is given the first port
+// from base up that no other worktree holds for the service and inUse
+// reports free. Reservations of worktrees whose directory is gone are dropped.
+func AllocatePort(barePath, worktreePath, service string, base int, inUse func(port int) bool) (int, error) {
+	var allocated int
+	err := updatePorts(barePath, func(ports map[string]map[string]int) error {
+		for path := range ports {
+			if _, err := os.Stat(path); os.IsNotExist(err) && path != worktreePath {
+				delete(ports, path)
+			}
+		}
+
+		if port, ok := ports[worktreePath][service]; ok {
+			allocated = port
+			return nil
+		}
+
+		taken := make(map[int]bool)
+		for _, services := range ports {
+			if port, ok := services[service]; ok {
+				taken[port] = true
+			}
+		}
+
+		for port := base; port < base+maxPortSearch && port <= 65535; port++ {
+			if taken[port] || inUse(port) {
+				continue
+			}
+			if ports[worktreePath] == nil {
+				ports[worktreePath] = make(map[string]int)
+			}
+			ports[worktreePath][service] = port
+			allocated = port
+			return nil
+		}
+		return fmt.Errorf("no free %s port between %d and %d", service, base, base+maxPortSearch-1)
+	})
+	return allocated, err
+}
+
+// ReleasePorts drops the ports reserved for the worktree at worktreePath
+func ReleasePorts(barePath, worktreePath string) error {
+	if _, err := os.Stat(PortsPath(barePath)); os.IsNotExist(err) {
+		return nil
+	}
+	return updatePorts(barePath, func(ports map[string]map[string]int) error {
+		delete(ports, worktreePath)
+		return nil
+	})
+}
+
+// ReservedPorts returns the ports reserved for the worktree at worktreePath,
+// keyed by service
+func ReservedPorts(barePath, worktreePath string) (map[string]int, error) {
+	ports, err := readPorts(PortsPath(barePath))
+	if err != nil {
+		return nil, err
+	}
+	return ports[worktreePath], nil
+}
+
+func updatePorts(barePath string, update func(ports map[string]map[string]int) error) error {
+	path := PortsPath(barePath)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("creating %s: %w", filepath.Dir(path), err)
+	}
+
+	unlock, err := lockFile(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	ports, err := readPorts(path)
+	if err != nil {
+		return err
+	}
+	if err := update(ports); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(ports, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding ports: %w", err)
+	}
+	if err := writeFileAtomic(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("writing ports: %w", err)
+	}
+	return nil
+}
+
+// readPorts reads the reserved ports keyed by worktree path and service,
+// which is empty when the file does not exist
+func readPorts(path string) (map[string]map[string]int, error) {
+	ports := make(map[string]map[string]int)
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return ports, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading ports: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &ports); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	if ports == nil {
+		ports = make(map[string]map[string]int)
+	}
+	return ports, nil
+}
@@ -0,0 +1,62 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAllocatePort(t *testing.T) {
+	barePath := t.TempDir()
+	first := t.TempDir()
+	second := t.TempDir()
+	free := func(int) bool { return false }
+
+	port, err := AllocatePort(barePath, first, "vite", 5173, free)
+	require.NoError(t, err)
+	assert.Equal(t, 5173, port)
+
+	port, err = AllocatePort(barePath, first, "vite", 5173, func(int) bool { return true })
+	require.NoError(t, err)
+	assert.Equal(t, 5173, port, "a worktree keeps its port")
+
+	port, err = AllocatePort(barePath, second, "vite", 5173, func(p int) bool { return p == 5174 })
+	require.NoError(t, err)
+	assert.Equal(t, 5175, port, "ports held by other worktrees or in use are skipped")
+
+	reserved, err := ReservedPorts(barePath, second)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"vite": 5175}, reserved)
+
+	require.NoError(t, ReleasePorts(barePath, first))
+	port, err = AllocatePort(barePath, filepath.Join(t.TempDir(), "third"), "vite", 5173, free)
+	require.NoError(t, err)
+	assert.Equal(t, 5173, port, "released ports are reused")
+
+	_, err = AllocatePort(barePath, t.TempDir(), "vite", 5173, func(int) bool { return true })
+	assert.ErrorContains(t, err, "no free vite port")
+}
+
+func TestAllocatePort_DropsRemovedWorktrees(t *testing.T) {
+	barePath := t.TempDir()
+	gone := filepath.Join(t.TempDir(), "gone")
+
+	_, err := AllocatePort(barePath, gone, "vite", 5173, func(int) bool { return false })
+	require.NoError(t, err)
+
+	port, err := AllocatePort(barePath, t.TempDir(), "vite", 5173, func(int) bool { return false })
+	require.NoError(t, err)
+	assert.Equal(t, 5173, port)
+
+	reserved, err := ReservedPorts(barePath, gone)
+	require.NoError(t, err)
+	assert.Empty(t, reserved)
+}
+
+func TestReleasePorts_WithoutFile(t *testing.T) {
+	barePath := t.TempDir()
+	require.NoError(t, ReleasePorts(barePath, "/nowhere"))
+	assert.NoFileExists(t, PortsPath(barePath))
+}
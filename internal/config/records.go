@@ -0,0 +1,107 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// WorktreeRecord is what arbor remembers about a worktree it created. Records
+// are kept in the bare repository, so cleanup can still run for a worktree
+// whose directory was removed without arbor.
+type WorktreeRecord struct {
+	Path       string `json:"path"`
+	Branch     string `json:"branch"`
+	SiteName   string `json:"siteName,omitempty"`
+	Preset     string `json:"preset,omitempty"`
+	DbSuffix   string `json:"dbSuffix,omitempty"`
+	BaseBranch string `json:"baseBranch,omitempty"`
+}
+
+// WorktreeRecordsPath returns where the worktree records for the project at
+// barePath are kept
+func WorktreeRecordsPath(barePath string) string {
+	return filepath.Join(barePath, "arbor", "worktrees.json")
+}
+
+// ReadWorktreeRecords returns the recorded worktrees, sorted by path. A
+// project without records has none.
+func ReadWorktreeRecords(barePath string) ([]WorktreeRecord, error) {
+	records, err := readWorktreeRecords(WorktreeRecordsPath(barePath))
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]WorktreeRecord, 0, len(records))
+	for _, record := range records {
+		list = append(list, record)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Path < list[j].Path })
+	return list, nil
+}
+
+// RecordWorktree saves record, replacing any earlier record for its path
+func RecordWorktree(barePath string, record WorktreeRecord) error {
+	return updateWorktreeRecords(barePath, func(records map[string]WorktreeRecord) {
+		records[record.Path] = record
+	})
+}
+
+// ForgetWorktree removes the record for the worktree at path, if there is one
+func ForgetWorktree(barePath, path string) error {
+	return updateWorktreeRecords(barePath, func(records map[string]WorktreeRecord) {
+		delete(records, path)
+	})
+}
+
+func updateWorktreeRecords(barePath string, update func(records map[string]WorktreeRecord)) error {
+	path := WorktreeRecordsPath(barePath)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("creating %s: %w", filepath.Dir(path), err)
+	}
+
+	unlock, err := lockFile(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	records, err := readWorktreeRecords(path)
+	if err != nil {
+		return err
+	}
+	update(records)
+
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding worktree records: %w", err)
+	}
+	if err := writeFileAtomic(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("writing worktree records: %w", err)
+	}
+	return nil
+}
+
+// readWorktreeRecords reads the records keyed by path, which is empty when
+// the file does not exist
+func readWorktreeRecords(path string) (map[string]WorktreeRecord, error) {
+	records := make(map[string]WorktreeRecord)
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return records, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading worktree records: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	if records == nil {
+		records = make(map[string]WorktreeRecord)
+	}
+	return records, nil
+}
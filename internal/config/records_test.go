@@ -0,0 +1,34 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorktreeRecords(t *testing.T) {
+	barePath := t.TempDir()
+
+	records, err := ReadWorktreeRecords(barePath)
+	require.NoError(t, err)
+	assert.Empty(t, records)
+
+	require.NoError(t, RecordWorktree(barePath, WorktreeRecord{Path: "/code/app/feature-b", Branch: "feature/b"}))
+	require.NoError(t, RecordWorktree(barePath, WorktreeRecord{Path: "/code/app/feature-a", Branch: "feature/a"}))
+	require.NoError(t, RecordWorktree(barePath, WorktreeRecord{Path: "/code/app/feature-b", Branch: "feature/b", DbSuffix: "swift_runner"}))
+
+	records, err = ReadWorktreeRecords(barePath)
+	require.NoError(t, err)
+	assert.Equal(t, []WorktreeRecord{
+		{Path: "/code/app/feature-a", Branch: "feature/a"},
+		{Path: "/code/app/feature-b", Branch: "feature/b", DbSuffix: "swift_runner"},
+	}, records)
+
+	require.NoError(t, ForgetWorktree(barePath, "/code/app/feature-a"))
+	require.NoError(t, ForgetWorktree(barePath, "/code/app/missing"))
+
+	records, err = ReadWorktreeRecords(barePath)
+	require.NoError(t, err)
+	assert.Equal(t, []WorktreeRecord{{Path: "/code/app/feature-b", Branch: "feature/b", DbSuffix: "swift_runner"}}, records)
+}
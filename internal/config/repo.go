@@ -0,0 +1,93 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/go-viper/mapstructure/v2"
+	"gopkg.in/yaml.v3"
+
+	arborerrors "github.com/michaeldyrynda/arbor/internal/errors"
+)
+
+// RepoDir holds the scaffolding assets a repository commits alongside its
+// code: presets, stubs and hook scripts
+const RepoDir = ".arbor"
+
+// Directories of RepoDir arbor picks up
+const (
+	RepoPresetsDir = "presets"
+	RepoStubsDir   = "stubs"
+	RepoHooksDir   = "hooks"
+)
+
+// RepoPresetConfig is a preset defined in .arbor/presets/<name>.yaml
+type RepoPresetConfig struct {
+	// Name is the file name without its extension
+	Name string `mapstructure:"-"`
+	// Detect lists files that must all exist for the preset to be detected.
+	// Without any, the preset is only used when arbor.yaml names it.
+	Detect  []string     `mapstructure:"detect"`
+	Steps   []StepConfig `mapstructure:"steps"`
+	Cleanup []StepConfig `mapstructure:"cleanup"`
+}
+
+// LoadRepoPresets reads the presets in the worktree's .arbor/presets, sorted
+// by name. A worktree without the directory has none.
+func LoadRepoPresets(worktreePath string) ([]RepoPresetConfig, error) {
+	dir := filepath.Join(worktreePath, RepoDir, RepoPresetsDir)
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", dir, err)
+	}
+
+	var presets []RepoPresetConfig
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if entry.IsDir() || (ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+
+		preset, err := readRepoPreset(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		preset.Name = strings.TrimSuffix(entry.Name(), ext)
+		presets = append(presets, preset)
+	}
+
+	sort.Slice(presets, func(i, j int) bool { return presets[i].Name < presets[j].Name })
+	return presets, nil
+}
+
+func readRepoPreset(path string) (RepoPresetConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return RepoPresetConfig{}, fmt.Errorf("reading preset: %w", err)
+	}
+
+	var values map[string]interface{}
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return RepoPresetConfig{}, arborerrors.Wrap(arborerrors.ErrInvalidConfig, fmt.Errorf("parsing %s: %w", path, err))
+	}
+
+	var preset RepoPresetConfig
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		Result:           &preset,
+		WeaklyTypedInput: true,
+		ErrorUnused:      true,
+	})
+	if err != nil {
+		return RepoPresetConfig{}, err
+	}
+	if err := decoder.Decode(values); err != nil {
+		return RepoPresetConfig{}, arborerrors.Wrap(arborerrors.ErrInvalidConfig, fmt.Errorf("parsing %s: %w", path, err))
+	}
+	return preset, nil
+}
@@ -0,0 +1,60 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	arborerrors "github.com/michaeldyrynda/arbor/internal/errors"
+)
+
+func writeRepoPreset(t *testing.T, worktree, file, content string) {
+	t.Helper()
+	dir := filepath.Join(worktree, RepoDir, RepoPresetsDir)
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, file), []byte(content), 0644))
+}
+
+func TestLoadRepoPresets(t *testing.T) {
+	t.Run("none without the directory", func(t *testing.T) {
+		presets, err := LoadRepoPresets(t.TempDir())
+		require.NoError(t, err)
+		assert.Empty(t, presets)
+	})
+
+	t.Run("reads presets sorted by name", func(t *testing.T) {
+		worktree := t.TempDir()
+		writeRepoPreset(t, worktree, "team.yaml", `
+detect: [artisan]
+steps:
+  - name: php.composer
+    args: [install]
+cleanup:
+  - name: herd
+`)
+		writeRepoPreset(t, worktree, "api.yml", "steps: []\n")
+		writeRepoPreset(t, worktree, "README.md", "not a preset")
+
+		presets, err := LoadRepoPresets(worktree)
+		require.NoError(t, err)
+		require.Len(t, presets, 2)
+		assert.Equal(t, "api", presets[0].Name)
+		assert.Equal(t, "team", presets[1].Name)
+		assert.Equal(t, []string{"artisan"}, presets[1].Detect)
+		assert.Equal(t, []StepConfig{{Name: "php.composer", Args: []string{"install"}}}, presets[1].Steps)
+		assert.Equal(t, "herd", presets[1].Cleanup[0].Name)
+	})
+
+	t.Run("rejects unknown keys", func(t *testing.T) {
+		worktree := t.TempDir()
+		writeRepoPreset(t, worktree, "team.yaml", "stpes: []\n")
+
+		_, err := LoadRepoPresets(worktree)
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, arborerrors.ErrInvalidConfig))
+	})
+}
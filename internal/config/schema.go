@@ -0,0 +1,263 @@
+package config
+
+import "sort"
+
+// fieldKind identifies the YAML shape expected for a config value
+type fieldKind int
+
+const (
+	kindString fieldKind = iota
+	kindBool
+	kindInt
+	kindList
+	kindMap
+	kindCondition
+	kindAny
+)
+
+// schemaField describes a single node in the arbor.yaml schema.
+// Maps either declare a fixed set of fields, or accept arbitrary keys
+// whose values all match elem. Lists describe their items with elem; lists
+// of steps marked shorthand also accept "name arg..." strings.
+type schemaField struct {
+	kind        fieldKind
+	description string
+	fields      map[string]*schemaField
+	elem        *schemaField
+	noEnv       bool
+	shorthand   bool
+	// pattern marks strings that are branch patterns, which must be valid
+	// for path.Match
+	pattern bool
+	// deprecated marks keys older configs may still set, which are accepted
+	// and ignored
+	deprecated bool
+}
+
+func (f *schemaField) fieldNames() []string {
+	names := make([]string, 0, len(f.fields))
+	for name := range f.fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// scalarKeys returns the dotted keys of all scalar values reachable through
+// fixed-field mappings, i.e. the settings that can be overridden by a single
+// environment variable
+func (f *schemaField) scalarKeys(prefix string) []string {
+	var keys []string
+	for _, name := range f.fieldNames() {
+		child := f.fields[name]
+		key := joinPath(prefix, name)
+		if child.noEnv {
+			continue
+		}
+		switch child.kind {
+		case kindString, kindBool, kindInt:
+			keys = append(keys, key)
+		case kindMap:
+			if child.fields != nil {
+				keys = append(keys, child.scalarKeys(key)...)
+			}
+		}
+	}
+	return keys
+}
+
+var inputSchema = &schemaField{
+	kind:        kindMap,
+	description: "A question asked before scaffolding, stored as a var",
+	fields: map[string]*schemaField{
+		"name":    {kind: kindString, description: "Var the answer is stored as"},
+		"type":    {kind: kindString, description: "text, select or confirm"},
+		"prompt":  {kind: kindString, description: "Question shown to the user"},
+		"options": {kind: kindList, description: "Choices for select inputs", elem: &schemaField{kind: kindString}},
+		"default": {kind: kindAny, description: "Answer used when arbor cannot prompt"},
+	},
+}
+
+var stepSchema = &schemaField{
+	kind:        kindMap,
+	description: "A scaffold step",
+	fields: map[string]*schemaField{
+		"name":       {kind: kindString, description: "Registered step name, e.g. php.composer"},
+		"enabled":    {kind: kindBool, description: "Enable or disable the step"},
+		"args":       {kind: kindList, description: "Arguments passed to the step", elem: &schemaField{kind: kindString}},
+		"command":    {kind: kindString, description: "Command for bash.run and command.run"},
+		"condition":  {kind: kindCondition, description: "Conditions that must be met for the step to run"},
+		"priority":   {kind: kindInt, description: "Execution order, lower runs first"},
+		"from":       {kind: kindString, description: "Source path for file.copy, or the example file for env.sync"},
+		"to":         {kind: kindString, description: "Destination path for file.copy"},
+		"key":        {kind: kindString, description: "Environment key for env.read and env.write"},
+		"value":      {kind: kindString, description: "Value for env.write, supports templates"},
+		"store_as":   {kind: kindString, description: "Variable name for env.read"},
+		"file":       {kind: kindString, description: "Environment file, defaults to .env"},
+		"type":       {kind: kindString, description: "Database engine for db steps, database_url or app_name for env.write, or expose or ngrok for expose"},
+		"inputs":     {kind: kindList, description: "Questions asked before scaffolding", elem: inputSchema},
+		"title":      {kind: kindString, description: "Notification title for notify, supports templates"},
+		"message":    {kind: kindString, description: "Notification message for notify, supports templates"},
+		"keys":       {kind: kindList, description: ".env keys exported by direnv, or updated with APP_NAME by an app_name env.write", elem: &schemaField{kind: kindString}},
+		"paths":      {kind: kindList, description: "Worktree directories direnv adds to PATH", elem: &schemaField{kind: kindString}},
+		"image":      {kind: kindString, description: "Container image for devcontainer"},
+		"ports":      {kind: kindList, description: "Container ports devcontainer publishes at a per-worktree host port, or the first port node.vite tries", elem: &schemaField{kind: kindInt}},
+		"mounts":     {kind: kindList, description: "Mounts for devcontainer, supports templates", elem: &schemaField{kind: kindString}},
+		"settings":   {kind: kindMap, description: "Git config keys and values set by git.config, supports templates", elem: &schemaField{kind: kindString}},
+		"capture_as": {kind: kindString, description: "Variable name to store the trimmed output of the step's command in"},
+		"dir":        {kind: kindString, description: "Subdirectory of the worktree the step's command runs in, supports templates"},
+		"env":        {kind: kindMap, description: "Environment variables for the step's command, supports templates", elem: &schemaField{kind: kindString}},
+	},
+}
+
+// projectSchema describes the supported keys of a project arbor.yaml
+var projectSchema = &schemaField{
+	kind: kindMap,
+	fields: map[string]*schemaField{
+		"version":        {kind: kindInt, description: "Config format version, managed by arbor", noEnv: true},
+		"bare_path":      {kind: kindString, description: "Deprecated and ignored; arbor finds the .bare repository itself", noEnv: true, deprecated: true},
+		"site_name":      {kind: kindString, description: "Site name used for domains and database names"},
+		"preset":         {kind: kindString, description: "Project preset, e.g. laravel or php"},
+		"default_branch": {kind: kindString, description: "Default branch for new worktrees"},
+		"db_suffix":      {kind: kindString, description: "Worktree database suffix, managed by arbor"},
+		"base_branch":    {kind: kindString, description: "Branch the worktree was created from, managed by arbor"},
+		"public_url":     {kind: kindString, description: "Public URL of the worktree's arbor expose tunnel, managed by arbor"},
+		"tunnel_pid":     {kind: kindString, description: "Process id of the worktree's arbor expose tunnel, managed by arbor"},
+		"base_branches": {
+			kind:        kindList,
+			description: "Base branches for new worktrees by branch pattern, the first match wins",
+			elem: &schemaField{
+				kind: kindMap,
+				fields: map[string]*schemaField{
+					"pattern": {kind: kindString, pattern: true, description: "Branch pattern, e.g. hotfix/*"},
+					"base":    {kind: kindString, description: "Branch new worktrees matching the pattern start from"},
+				},
+			},
+		},
+		"protected_branches": {
+			kind:        kindList,
+			description: "Branches, or patterns such as release/*, whose worktrees remove and prune refuse to delete",
+			elem:        &schemaField{kind: kindString, pattern: true},
+		},
+		"scaffold": {
+			kind:        kindMap,
+			description: "Scaffold configuration",
+			fields: map[string]*schemaField{
+				"steps":    {kind: kindList, description: "Scaffold steps", elem: stepSchema},
+				"override": {kind: kindBool, description: "Replace preset steps instead of appending"},
+				"disable":  {kind: kindList, description: "Names of global default steps to skip", elem: &schemaField{kind: kindString}},
+				"inputs":   {kind: kindList, description: "Questions asked before scaffolding", elem: inputSchema},
+			},
+		},
+		"cleanup": {kind: kindList, description: "Cleanup steps, run when a worktree is removed", elem: stepSchema},
+		"tasks": {
+			kind:        kindMap,
+			description: "Named step pipelines run in a worktree by arbor run",
+			elem: &schemaField{
+				kind:        kindList,
+				description: "Steps run in order, as step mappings or \"name arg...\" strings",
+				elem:        stepSchema,
+				shorthand:   true,
+			},
+		},
+		"db": {
+			kind:        kindMap,
+			description: "Database connection defaults",
+			fields: map[string]*schemaField{
+				"host":            {kind: kindString, description: "Database host"},
+				"port":            {kind: kindString, description: "Database port"},
+				"username":        {kind: kindString, description: "Database username"},
+				"password":        {kind: kindString, description: "Database password"},
+				"ssl_mode":        {kind: kindString, description: "TLS mode: disable, prefer, require, verify-ca or verify-full"},
+				"ssl_ca":          {kind: kindString, description: "CA certificate used to verify the server"},
+				"socket":          {kind: kindString, description: "Unix socket to connect through instead of host and port"},
+				"mode":            {kind: kindString, description: "database (default) or schema, to create a PostgreSQL schema per worktree"},
+				"database":        {kind: kindString, description: "Existing database holding worktree schemas, defaults to DB_DATABASE"},
+				"create_user":     {kind: kindBool, description: "Create a per-worktree user with access only to its databases"},
+				"naming_strategy": {kind: kindString, description: "Database suffix: words (default), branch, ticket or hash"},
+				"ticket_pattern":  {kind: kindString, description: "Regular expression finding the ticket in a branch name for the ticket strategy"},
+				"words": {
+					kind:        kindMap,
+					description: "Word lists random database suffixes are made from",
+					fields: map[string]*schemaField{
+						"adjectives": {kind: kindList, description: "Replaces the built-in adjectives", elem: &schemaField{kind: kindString}},
+						"nouns":      {kind: kindList, description: "Replaces the built-in nouns", elem: &schemaField{kind: kindString}},
+					},
+				},
+				"keep_on_remove": {kind: kindBool, description: "Keep branch-named databases when a worktree is removed, for the next worktree of the branch"},
+				"container": {
+					kind:        kindMap,
+					description: "Run project databases in a Docker container",
+					fields: map[string]*schemaField{
+						"enabled": {kind: kindBool, description: "Start a per-project database container for db steps"},
+						"image":   {kind: kindString, description: "Container image, defaults to mysql:8.4 or postgres:17"},
+					},
+				},
+			},
+		},
+		"on_create": {
+			kind:        kindMap,
+			description: "Actions run by arbor work after scaffolding a new worktree",
+			fields: map[string]*schemaField{
+				"editor":  {kind: kindBool, description: "Open the worktree in $VISUAL or $EDITOR"},
+				"browser": {kind: kindBool, description: "Open the worktree's APP_URL in the browser"},
+				"tmux":    {kind: kindBool, description: "Start a tmux session for the worktree"},
+				"command": {kind: kindString, description: "Shell command run in the worktree, with ARBOR_WORKTREE_PATH and APP_URL set"},
+			},
+		},
+		"tmux": {
+			kind:        kindMap,
+			description: "Session started by arbor tmux",
+			fields: map[string]*schemaField{
+				"windows": {kind: kindList, description: "Windows opened in each worktree's session", elem: &schemaField{
+					kind: kindMap,
+					fields: map[string]*schemaField{
+						"name":    {kind: kindString, description: "Window name, e.g. editor, server or logs"},
+						"command": {kind: kindString, description: "Command run in the window"},
+					},
+				}},
+			},
+		},
+		"code_workspace": {
+			kind:        kindMap,
+			description: "VS Code workspace written by arbor code-workspace",
+			fields: map[string]*schemaField{
+				"file":        {kind: kindString, description: "Workspace file in the project directory, defaults to <site_name>.code-workspace"},
+				"auto_update": {kind: kindBool, description: "Add and remove folders when worktrees are created and removed"},
+				"settings":    {kind: kindMap, description: "Workspace settings, e.g. php.validate.executablePath"},
+			},
+		},
+		"pr": {
+			kind:        kindMap,
+			description: "Defaults for arbor pr create",
+			fields: map[string]*schemaField{
+				"title": {kind: kindString, description: "Pull request title, supports templates"},
+				"body":  {kind: kindString, description: "Pull request body, supports templates"},
+				"draft": {kind: kindBool, description: "Open pull requests as drafts"},
+			},
+		},
+		"events": {
+			kind:        kindMap,
+			description: "Webhooks posted JSON when worktrees are created and removed",
+			fields: map[string]*schemaField{
+				"worktree_created": {kind: kindString, description: "URL posted to after arbor work creates a worktree"},
+				"worktree_removed": {kind: kindString, description: "URL posted to after arbor remove or prune removes a worktree"},
+			},
+		},
+		"vars": {
+			kind:        kindMap,
+			description: "Template variables available to steps as {{ .Vars.name }}",
+			elem:        &schemaField{kind: kindString},
+		},
+		"tools": {
+			kind:        kindMap,
+			description: "Tool-specific configuration",
+			elem: &schemaField{
+				kind: kindMap,
+				fields: map[string]*schemaField{
+					"version_file": {kind: kindString, description: "File declaring the tool version"},
+				},
+			},
+		},
+	},
+}
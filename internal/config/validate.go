@@ -0,0 +1,357 @@
+package config
+
+import (
+	"fmt"
+	pathpkg "path"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	arborerrors "github.com/michaeldyrynda/arbor/internal/errors"
+	"github.com/michaeldyrynda/arbor/internal/utils"
+)
+
+// ValidationIssue describes a single problem found in a config file
+type ValidationIssue struct {
+	Line    int
+	Column  int
+	Path    string
+	Message string
+}
+
+func (i ValidationIssue) String() string {
+	if i.Line > 0 {
+		return fmt.Sprintf("line %d: %s: %s", i.Line, i.Path, i.Message)
+	}
+	return fmt.Sprintf("%s: %s", i.Path, i.Message)
+}
+
+// ValidationError aggregates all issues found in a config file
+type ValidationError struct {
+	File   string
+	Issues []ValidationIssue
+}
+
+func (e *ValidationError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "invalid config %s:", e.File)
+	for _, issue := range e.Issues {
+		b.WriteString("\n  ")
+		b.WriteString(issue.String())
+	}
+	fmt.Fprintf(&b, "\nSee %s for the configuration reference", SchemaDocURL)
+	return b.String()
+}
+
+// Unwrap lets errors.Is match ErrInvalidConfig
+func (e *ValidationError) Unwrap() error {
+	return arborerrors.ErrInvalidConfig
+}
+
+// ValidateOptions enables checks that depend on the scaffold registries.
+// When a list is empty the corresponding check is skipped.
+type ValidateOptions struct {
+	StepNames      []string
+	ConditionNames []string
+}
+
+// ValidateProject validates raw arbor.yaml content against the project schema
+func ValidateProject(data []byte, opts ValidateOptions) ([]ValidationIssue, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("parsing yaml: %w", err)
+	}
+
+	if len(root.Content) == 0 {
+		return nil, nil
+	}
+
+	v := &validator{
+		stepNames:      toSet(opts.StepNames),
+		conditionNames: toSet(opts.ConditionNames),
+	}
+	v.validate(root.Content[0], projectSchema, "")
+
+	return v.issues, nil
+}
+
+type validator struct {
+	stepNames      map[string]bool
+	conditionNames map[string]bool
+	issues         []ValidationIssue
+}
+
+func (v *validator) addIssue(node *yaml.Node, path, format string, args ...interface{}) {
+	if path == "" {
+		path = "(root)"
+	}
+	v.issues = append(v.issues, ValidationIssue{
+		Line:    node.Line,
+		Column:  node.Column,
+		Path:    path,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+func (v *validator) validate(node *yaml.Node, field *schemaField, path string) {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+
+	switch field.kind {
+	case kindAny:
+		return
+	case kindString:
+		if node.Kind != yaml.ScalarNode || node.Tag == "!!null" {
+			v.addIssue(node, path, "expected a string")
+		} else if field.pattern {
+			v.validatePattern(node, path)
+		}
+	case kindBool:
+		if node.Kind != yaml.ScalarNode || node.Tag != "!!bool" {
+			v.addIssue(node, path, "expected true or false, got %s", describeNode(node))
+		}
+	case kindInt:
+		if node.Kind != yaml.ScalarNode || node.Tag != "!!int" {
+			v.addIssue(node, path, "expected an integer, got %s", describeNode(node))
+		}
+	case kindList:
+		if node.Kind != yaml.SequenceNode {
+			v.addIssue(node, path, "expected a list, got %s", describeNode(node))
+			return
+		}
+		for i, item := range node.Content {
+			itemPath := fmt.Sprintf("%s[%d]", path, i)
+			if field.shorthand && item.Kind == yaml.ScalarNode && item.Tag == "!!str" {
+				v.validateStepShorthand(item, itemPath)
+				continue
+			}
+			v.validate(item, field.elem, itemPath)
+		}
+	case kindMap:
+		v.validateMap(node, field, path)
+	case kindCondition:
+		v.validateCondition(node, path)
+	}
+}
+
+func (v *validator) validateMap(node *yaml.Node, field *schemaField, path string) {
+	if node.Kind != yaml.MappingNode {
+		v.addIssue(node, path, "expected a mapping, got %s", describeNode(node))
+		return
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keyNode, valueNode := node.Content[i], node.Content[i+1]
+		key := keyNode.Value
+		childPath := joinPath(path, key)
+
+		if field.fields == nil {
+			if field.elem != nil {
+				v.validate(valueNode, field.elem, childPath)
+			}
+			continue
+		}
+
+		child, ok := field.fields[key]
+		if !ok {
+			msg := fmt.Sprintf("unknown key %q", key)
+			if suggestion := closestMatch(key, field.fieldNames()); suggestion != "" {
+				msg += fmt.Sprintf(" (did you mean %q?)", suggestion)
+			}
+			v.addIssue(keyNode, childPath, "%s", msg)
+			continue
+		}
+
+		v.validate(valueNode, child, childPath)
+
+		if field == stepSchema {
+			switch key {
+			case "name":
+				v.validateStepName(valueNode, childPath)
+			case "dir":
+				v.validateStepDir(valueNode, childPath)
+			}
+		}
+	}
+
+	if field == stepSchema {
+		if findKey(node, "name") == nil {
+			v.addIssue(node, path, "step is missing required key \"name\"")
+		}
+	}
+}
+
+func (v *validator) validateStepName(node *yaml.Node, path string) {
+	if node.Kind != yaml.ScalarNode {
+		return
+	}
+	v.checkStepName(node, node.Value, path)
+}
+
+// validateStepShorthand checks a step written as a "name arg..." string
+func (v *validator) validateStepShorthand(node *yaml.Node, path string) {
+	step := ParseStepShorthand(node.Value)
+	if step.Name == "" {
+		v.addIssue(node, path, "step is missing a name")
+		return
+	}
+	v.checkStepName(node, step.Name, path)
+}
+
+func (v *validator) checkStepName(node *yaml.Node, name, path string) {
+	if len(v.stepNames) == 0 || v.stepNames[name] {
+		return
+	}
+	msg := fmt.Sprintf("unknown step %q", name)
+	if suggestion := closestMatch(name, setKeys(v.stepNames)); suggestion != "" {
+		msg += fmt.Sprintf(" (did you mean %q?)", suggestion)
+	}
+	v.addIssue(node, path, "%s", msg)
+}
+
+// validateStepDir checks that a step's dir stays inside the worktree. Dirs
+// with templates are checked when the step runs.
+func (v *validator) validateStepDir(node *yaml.Node, path string) {
+	if node.Kind != yaml.ScalarNode || strings.Contains(node.Value, "{{") {
+		return
+	}
+	if _, err := utils.ResolveSubdir(".", node.Value); err != nil {
+		v.addIssue(node, path, "%v", err)
+	}
+}
+
+// validatePattern checks a branch pattern, which path.Match would otherwise
+// reject each time a branch is matched against it
+func (v *validator) validatePattern(node *yaml.Node, path string) {
+	if _, err := pathpkg.Match(node.Value, ""); err != nil {
+		v.addIssue(node, path, "invalid pattern %q: %v", node.Value, err)
+	}
+}
+
+func (v *validator) validateCondition(node *yaml.Node, path string) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			keyNode, valueNode := node.Content[i], node.Content[i+1]
+			key := keyNode.Value
+			childPath := joinPath(path, key)
+
+			if len(v.conditionNames) > 0 && !v.conditionNames[key] {
+				msg := fmt.Sprintf("unknown condition %q", key)
+				if suggestion := closestMatch(key, setKeys(v.conditionNames)); suggestion != "" {
+					msg += fmt.Sprintf(" (did you mean %q?)", suggestion)
+				}
+				v.addIssue(keyNode, childPath, "%s", msg)
+				continue
+			}
+
+			if valueNode.Tag == "!!null" {
+				v.addIssue(valueNode, childPath, "condition requires a value")
+				continue
+			}
+
+			switch key {
+			case "not", "any_of", "all_of":
+				v.validateCondition(valueNode, childPath)
+			}
+		}
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			itemPath := fmt.Sprintf("%s[%d]", path, i)
+			if item.Kind != yaml.MappingNode {
+				v.addIssue(item, itemPath, "expected a condition mapping, got %s", describeNode(item))
+				continue
+			}
+			v.validateCondition(item, itemPath)
+		}
+	default:
+		if node.Tag == "!!null" {
+			return
+		}
+		v.addIssue(node, path, "expected a condition mapping, got %s", describeNode(node))
+	}
+}
+
+func findKey(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func describeNode(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return "a mapping"
+	case yaml.SequenceNode:
+		return "a list"
+	case yaml.ScalarNode:
+		if node.Tag == "!!null" {
+			return "an empty value"
+		}
+		return fmt.Sprintf("%q", node.Value)
+	default:
+		return "an unsupported value"
+	}
+}
+
+func joinPath(parent, key string) string {
+	if parent == "" {
+		return key
+	}
+	return parent + "." + key
+}
+
+func toSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[v] = true
+	}
+	return set
+}
+
+func setKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// closestMatch returns the candidate nearest to input by edit distance,
+// or an empty string when nothing is close enough to be a likely typo
+func closestMatch(input string, candidates []string) string {
+	best := ""
+	bestDistance := len(input)/2 + 1
+	for _, candidate := range candidates {
+		d := levenshtein(input, candidate)
+		if d < bestDistance || (d == bestDistance && best != "" && candidate < best) {
+			best = candidate
+			bestDistance = d
+		}
+	}
+	return best
+}
+
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,335 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateProject_ValidConfig(t *testing.T) {
+	content := `site_name: myapp
+preset: laravel
+default_branch: main
+scaffold:
+  override: false
+  steps:
+    - name: php.composer
+      args: ["install"]
+      priority: 10
+      condition:
+        file_exists: composer.lock
+        not:
+          env_exists: CI
+cleanup:
+  - name: db.destroy
+tools:
+  php:
+    version_file: .php-version
+`
+	issues, err := ValidateProject([]byte(content), ValidateOptions{
+		StepNames:      []string{"php.composer", "db.destroy"},
+		ConditionNames: []string{"file_exists", "env_exists", "not"},
+	})
+
+	require.NoError(t, err)
+	assert.Empty(t, issues)
+}
+
+func TestValidateProject_UnknownKeyWithSuggestion(t *testing.T) {
+	content := `preset: php
+scaffold:
+  stpes:
+    - name: php.composer
+`
+	issues, err := ValidateProject([]byte(content), ValidateOptions{})
+
+	require.NoError(t, err)
+	require.Len(t, issues, 1)
+	assert.Equal(t, 3, issues[0].Line)
+	assert.Equal(t, "scaffold.stpes", issues[0].Path)
+	assert.Contains(t, issues[0].Message, `unknown key "stpes"`)
+	assert.Contains(t, issues[0].Message, `did you mean "steps"?`)
+}
+
+func TestValidateProject_BadPriorityType(t *testing.T) {
+	content := `scaffold:
+  steps:
+    - name: php.composer
+      priority: high
+`
+	issues, err := ValidateProject([]byte(content), ValidateOptions{})
+
+	require.NoError(t, err)
+	require.Len(t, issues, 1)
+	assert.Equal(t, 4, issues[0].Line)
+	assert.Equal(t, "scaffold.steps[0].priority", issues[0].Path)
+	assert.Contains(t, issues[0].Message, "expected an integer")
+}
+
+func TestValidateProject_UnknownStepName(t *testing.T) {
+	content := `scaffold:
+  steps:
+    - name: php.composr
+cleanup:
+  - name: db.destroy
+`
+	issues, err := ValidateProject([]byte(content), ValidateOptions{
+		StepNames: []string{"php.composer", "db.destroy"},
+	})
+
+	require.NoError(t, err)
+	require.Len(t, issues, 1)
+	assert.Equal(t, 3, issues[0].Line)
+	assert.Contains(t, issues[0].Message, `unknown step "php.composr"`)
+	assert.Contains(t, issues[0].Message, `did you mean "php.composer"?`)
+}
+
+func TestValidateProject_StepNameNotCheckedWithoutRegistry(t *testing.T) {
+	content := `scaffold:
+  steps:
+    - name: custom.step
+`
+	issues, err := ValidateProject([]byte(content), ValidateOptions{})
+
+	require.NoError(t, err)
+	assert.Empty(t, issues)
+}
+
+func TestValidateProject_MissingStepName(t *testing.T) {
+	content := `scaffold:
+  steps:
+    - args: ["install"]
+`
+	issues, err := ValidateProject([]byte(content), ValidateOptions{})
+
+	require.NoError(t, err)
+	require.Len(t, issues, 1)
+	assert.Contains(t, issues[0].Message, `missing required key "name"`)
+}
+
+func TestValidateProject_StepDirOutsideWorktree(t *testing.T) {
+	content := `scaffold:
+  steps:
+    - name: node.npm
+      dir: frontend
+    - name: node.npm
+      dir: ../other
+    - name: bash.run
+      dir: "{{ .Path }}/.."
+`
+	issues, err := ValidateProject([]byte(content), ValidateOptions{})
+
+	require.NoError(t, err)
+	require.Len(t, issues, 1, "dirs with templates are checked when the step runs")
+	assert.Equal(t, 6, issues[0].Line)
+	assert.Equal(t, "scaffold.steps[1].dir", issues[0].Path)
+	assert.Contains(t, issues[0].Message, "outside the worktree")
+}
+
+func TestValidateProject_AcceptsDeprecatedBarePath(t *testing.T) {
+	issues, err := ValidateProject([]byte("bare_path: .bare\ndefault_branch: main\n"), ValidateOptions{})
+
+	require.NoError(t, err)
+	assert.Empty(t, issues)
+}
+
+func TestValidateProject_MalformedProtectedBranches(t *testing.T) {
+	content := `protected_branches:
+  - main
+  - release/[
+`
+	issues, err := ValidateProject([]byte(content), ValidateOptions{})
+
+	require.NoError(t, err)
+	require.Len(t, issues, 1)
+	assert.Equal(t, 3, issues[0].Line)
+	assert.Equal(t, "protected_branches[1]", issues[0].Path)
+	assert.Contains(t, issues[0].Message, `invalid pattern "release/["`)
+}
+
+func TestValidateProject_MalformedBaseBranchPattern(t *testing.T) {
+	content := `base_branches:
+  - pattern: hotfix/[
+    base: production
+`
+	issues, err := ValidateProject([]byte(content), ValidateOptions{})
+
+	require.NoError(t, err)
+	require.Len(t, issues, 1)
+	assert.Equal(t, "base_branches[0].pattern", issues[0].Path)
+	assert.Contains(t, issues[0].Message, "invalid pattern")
+}
+
+func TestValidateProject_Tasks(t *testing.T) {
+	content := `tasks:
+  fresh:
+    - db.create
+    - php.laravl.artisan migrate:fresh
+    - name: bash.run
+      comand: npm run build
+    - 42
+`
+	issues, err := ValidateProject([]byte(content), ValidateOptions{
+		StepNames: []string{"db.create", "php.laravel.artisan", "bash.run"},
+	})
+
+	require.NoError(t, err)
+	require.Len(t, issues, 3)
+	assert.Equal(t, "tasks.fresh[1]", issues[0].Path)
+	assert.Contains(t, issues[0].Message, `unknown step "php.laravl.artisan"`)
+	assert.Equal(t, "tasks.fresh[2].comand", issues[1].Path)
+	assert.Equal(t, "tasks.fresh[3]", issues[2].Path)
+	assert.Contains(t, issues[2].Message, "expected a mapping")
+}
+
+func TestValidateProject_StepShorthandOnlyInTasks(t *testing.T) {
+	content := `scaffold:
+  steps:
+    - php.composer install
+`
+	issues, err := ValidateProject([]byte(content), ValidateOptions{})
+
+	require.NoError(t, err)
+	require.Len(t, issues, 1)
+	assert.Contains(t, issues[0].Message, "expected a mapping")
+}
+
+func TestValidateProject_MalformedConditions(t *testing.T) {
+	t.Run("condition must be a mapping", func(t *testing.T) {
+		content := `scaffold:
+  steps:
+    - name: php.composer
+      condition: composer.lock
+`
+		issues, err := ValidateProject([]byte(content), ValidateOptions{})
+
+		require.NoError(t, err)
+		require.Len(t, issues, 1)
+		assert.Equal(t, 4, issues[0].Line)
+		assert.Contains(t, issues[0].Message, "expected a condition mapping")
+	})
+
+	t.Run("unknown condition key", func(t *testing.T) {
+		content := `scaffold:
+  steps:
+    - name: php.composer
+      condition:
+        file_exist: composer.lock
+`
+		issues, err := ValidateProject([]byte(content), ValidateOptions{
+			ConditionNames: []string{"file_exists", "not"},
+		})
+
+		require.NoError(t, err)
+		require.Len(t, issues, 1)
+		assert.Equal(t, 5, issues[0].Line)
+		assert.Contains(t, issues[0].Message, `did you mean "file_exists"?`)
+	})
+
+	t.Run("nested not is validated", func(t *testing.T) {
+		content := `cleanup:
+  - name: herd
+    condition:
+      not:
+        bogus: true
+`
+		issues, err := ValidateProject([]byte(content), ValidateOptions{
+			ConditionNames: []string{"file_exists", "not"},
+		})
+
+		require.NoError(t, err)
+		require.Len(t, issues, 1)
+		assert.Equal(t, "cleanup[0].condition.not.bogus", issues[0].Path)
+	})
+
+	t.Run("nested any_of is validated", func(t *testing.T) {
+		content := `cleanup:
+  - name: herd
+    condition:
+      any_of:
+        - file_exists: package-lock.json
+        - bogus: true
+`
+		issues, err := ValidateProject([]byte(content), ValidateOptions{
+			ConditionNames: []string{"file_exists", "any_of"},
+		})
+
+		require.NoError(t, err)
+		require.Len(t, issues, 1)
+		assert.Equal(t, "cleanup[0].condition.any_of[1].bogus", issues[0].Path)
+	})
+
+	t.Run("empty condition value", func(t *testing.T) {
+		content := `scaffold:
+  steps:
+    - name: php.composer
+      condition:
+        file_exists:
+`
+		issues, err := ValidateProject([]byte(content), ValidateOptions{})
+
+		require.NoError(t, err)
+		require.Len(t, issues, 1)
+		assert.Contains(t, issues[0].Message, "condition requires a value")
+	})
+}
+
+func TestValidateProject_WrongShapes(t *testing.T) {
+	content := `scaffold: true
+cleanup:
+  name: herd
+`
+	issues, err := ValidateProject([]byte(content), ValidateOptions{})
+
+	require.NoError(t, err)
+	require.Len(t, issues, 2)
+	assert.Contains(t, issues[0].Message, "expected a mapping")
+	assert.Contains(t, issues[1].Message, "expected a list")
+}
+
+func TestValidateProject_EmptyDocument(t *testing.T) {
+	issues, err := ValidateProject([]byte(""), ValidateOptions{})
+
+	require.NoError(t, err)
+	assert.Empty(t, issues)
+}
+
+func TestLoadProject_RejectsUnknownKeys(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	configContent := `preset: php
+stpes:
+  - name: php.composer
+`
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "arbor.yaml"), []byte(configContent), 0644))
+
+	cfg, err := LoadProject(tmpDir)
+
+	assert.Nil(t, cfg)
+	var validationErr *ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	assert.Len(t, validationErr.Issues, 1)
+	assert.Contains(t, err.Error(), "line 2")
+	assert.Contains(t, err.Error(), `unknown key "stpes"`)
+}
+
+func TestClosestMatch(t *testing.T) {
+	candidates := []string{"steps", "override"}
+
+	assert.Equal(t, "steps", closestMatch("stpes", candidates))
+	assert.Equal(t, "override", closestMatch("overide", candidates))
+	assert.Equal(t, "", closestMatch("completely_different", candidates))
+}
+
+func TestValidationError_IncludesDocURL(t *testing.T) {
+	err := &ValidationError{
+		File:   "arbor.yaml",
+		Issues: []ValidationIssue{{Line: 1, Path: "stpes", Message: "unknown key"}},
+	}
+
+	assert.Contains(t, err.Error(), SchemaDocURL)
+}
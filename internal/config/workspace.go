@@ -0,0 +1,97 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// WorkspaceFile lists the projects of a workspace. It lives in the directory
+// the projects share.
+const WorkspaceFile = "workspace.yaml"
+
+// WorkspaceConfig describes a workspace: several arbor projects, each with
+// its own bare repository, whose worktrees are created together
+type WorkspaceConfig struct {
+	Repos []WorkspaceRepo `yaml:"repos"`
+
+	// Root is the directory holding workspace.yaml
+	Root string `yaml:"-"`
+}
+
+// WorkspaceRepo is one project of a workspace
+type WorkspaceRepo struct {
+	Name string `yaml:"name"`
+	// Path is the project directory, relative to the workspace root. It
+	// defaults to Name.
+	Path string `yaml:"path"`
+	// Base is the branch new worktrees start from, defaulting to the
+	// project's default branch
+	Base string `yaml:"base"`
+}
+
+// ProjectPath returns the absolute path of the repo's project directory
+func (w *WorkspaceConfig) ProjectPath(repo WorkspaceRepo) string {
+	path := repo.Path
+	if path == "" {
+		path = repo.Name
+	}
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(w.Root, path)
+}
+
+// FindWorkspace returns the workspace containing dir, looking in dir and
+// each of its parents for workspace.yaml
+func FindWorkspace(dir string) (*WorkspaceConfig, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("getting absolute path: %w", err)
+	}
+
+	for {
+		if _, err := os.Stat(filepath.Join(dir, WorkspaceFile)); err == nil {
+			return LoadWorkspace(dir)
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, fmt.Errorf("no %s found in this directory or any parent", WorkspaceFile)
+		}
+		dir = parent
+	}
+}
+
+// LoadWorkspace reads workspace.yaml from root
+func LoadWorkspace(root string) (*WorkspaceConfig, error) {
+	path := filepath.Join(root, WorkspaceFile)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading workspace: %w", err)
+	}
+
+	var workspace WorkspaceConfig
+	if err := yaml.Unmarshal(data, &workspace); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	workspace.Root = root
+
+	if len(workspace.Repos) == 0 {
+		return nil, fmt.Errorf("%s lists no repos", path)
+	}
+	seen := make(map[string]bool)
+	for i, repo := range workspace.Repos {
+		if repo.Name == "" {
+			return nil, fmt.Errorf("%s: repos[%d] has no name", path, i)
+		}
+		if seen[repo.Name] {
+			return nil, fmt.Errorf("%s: repo %q is listed more than once", path, repo.Name)
+		}
+		seen[repo.Name] = true
+	}
+
+	return &workspace, nil
+}
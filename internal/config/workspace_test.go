@@ -0,0 +1,59 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindWorkspace(t *testing.T) {
+	root := t.TempDir()
+	content := `repos:
+  - name: backend
+  - name: shared
+    path: packages/shared
+    base: develop
+`
+	require.NoError(t, os.WriteFile(filepath.Join(root, WorkspaceFile), []byte(content), 0644))
+	nested := filepath.Join(root, "backend", "feature-auth")
+	require.NoError(t, os.MkdirAll(nested, 0755))
+
+	workspace, err := FindWorkspace(nested)
+	require.NoError(t, err)
+
+	assert.Equal(t, root, workspace.Root)
+	require.Len(t, workspace.Repos, 2)
+	assert.Equal(t, filepath.Join(root, "backend"), workspace.ProjectPath(workspace.Repos[0]))
+	assert.Equal(t, filepath.Join(root, "packages", "shared"), workspace.ProjectPath(workspace.Repos[1]))
+	assert.Equal(t, "develop", workspace.Repos[1].Base)
+}
+
+func TestFindWorkspace_NotFound(t *testing.T) {
+	_, err := FindWorkspace(t.TempDir())
+	assert.ErrorContains(t, err, "no workspace.yaml found")
+}
+
+func TestLoadWorkspace_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{name: "no repos", content: "repos: []\n", want: "lists no repos"},
+		{name: "missing name", content: "repos:\n  - path: backend\n", want: "repos[0] has no name"},
+		{name: "duplicate", content: "repos:\n  - name: api\n  - name: api\n", want: `repo "api" is listed more than once`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			require.NoError(t, os.WriteFile(filepath.Join(root, WorkspaceFile), []byte(tt.content), 0644))
+
+			_, err := LoadWorkspace(root)
+			assert.ErrorContains(t, err, tt.want)
+		})
+	}
+}
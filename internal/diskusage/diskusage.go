@@ -0,0 +1,293 @@
+// Package diskusage measures how much disk space a worktree takes up and
+// how much of it is build artifacts, such as node_modules and vendor, that
+// can be reinstalled.
+package diskusage
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/michaeldyrynda/arbor/internal/git"
+	"github.com/michaeldyrynda/arbor/internal/logging"
+)
+
+// ArtifactDirs are the directories, relative to the worktree, counted as
+// build artifacts when git ignores them
+var ArtifactDirs = []string{"node_modules", "vendor", "public/build", ".next", ".nuxt", "dist"}
+
+// CacheTTL is how long a measurement is reused before the worktree is walked
+// again
+var CacheTTL = 10 * time.Minute
+
+// Usage is the size of a worktree
+type Usage struct {
+	// Bytes is the apparent size of every file in the worktree
+	Bytes int64 `json:"bytes"`
+	// Artifacts is the size of each artifact directory present, keyed by
+	// its path relative to the worktree
+	Artifacts  map[string]int64 `json:"artifacts,omitempty"`
+	MeasuredAt time.Time        `json:"measuredAt"`
+}
+
+// Artifact is an artifact directory and its size
+type Artifact struct {
+	Path  string `json:"path"`
+	Bytes int64  `json:"bytes"`
+}
+
+// ArtifactBytes returns the combined size of the artifact directories
+func (u Usage) ArtifactBytes() int64 {
+	var total int64
+	for _, size := range u.Artifacts {
+		total += size
+	}
+	return total
+}
+
+// LargestArtifacts returns the artifact directories, biggest first
+func (u Usage) LargestArtifacts() []Artifact {
+	artifacts := make([]Artifact, 0, len(u.Artifacts))
+	for path, size := range u.Artifacts {
+		artifacts = append(artifacts, Artifact{Path: path, Bytes: size})
+	}
+	sort.Slice(artifacts, func(i, j int) bool {
+		if artifacts[i].Bytes != artifacts[j].Bytes {
+			return artifacts[i].Bytes > artifacts[j].Bytes
+		}
+		return artifacts[i].Path < artifacts[j].Path
+	})
+	return artifacts
+}
+
+// Measure walks the worktree at worktreePath, adding up the apparent size of
+// its files like du --apparent-size. Symlinks are not followed and the
+// worktree's .git file is skipped.
+func Measure(worktreePath string) (Usage, error) {
+	usage := Usage{Artifacts: make(map[string]int64), MeasuredAt: time.Now()}
+
+	artifacts := make(map[string]bool, len(ArtifactDirs))
+	for _, dir := range ArtifactDirs {
+		artifacts[filepath.FromSlash(dir)] = true
+	}
+
+	err := filepath.WalkDir(worktreePath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == worktreePath {
+				return err
+			}
+			// Unreadable entries are left out, as du does
+			return nil
+		}
+
+		rel, err := filepath.Rel(worktreePath, path)
+		if err != nil {
+			return err
+		}
+		if rel == ".git" {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if d.IsDir() {
+			if artifacts[rel] && git.IsIgnored(worktreePath, filepath.ToSlash(rel)) {
+				size, err := dirSize(path)
+				if err != nil {
+					return err
+				}
+				usage.Artifacts[filepath.ToSlash(rel)] = size
+				usage.Bytes += size
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		usage.Bytes += fileSize(d)
+		return nil
+	})
+	if err != nil {
+		return Usage{}, fmt.Errorf("measuring %s: %w", worktreePath, err)
+	}
+	return usage, nil
+}
+
+// RemoveArtifacts deletes the artifact directories git ignores in the
+// worktree at worktreePath, returning those removed with their size
+func RemoveArtifacts(worktreePath string, dryRun bool) ([]Artifact, error) {
+	var removed []Artifact
+	for _, dir := range ArtifactDirs {
+		path := filepath.Join(worktreePath, filepath.FromSlash(dir))
+		info, err := os.Lstat(path)
+		if err != nil || !info.IsDir() || !git.IsIgnored(worktreePath, dir) {
+			continue
+		}
+
+		size, err := dirSize(path)
+		if err != nil {
+			return removed, err
+		}
+		if !dryRun {
+			if err := os.RemoveAll(path); err != nil {
+				return removed, fmt.Errorf("removing %s: %w", path, err)
+			}
+		}
+		removed = append(removed, Artifact{Path: dir, Bytes: size})
+	}
+	return removed, nil
+}
+
+func dirSize(root string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == root {
+				return err
+			}
+			return nil
+		}
+		if !d.IsDir() {
+			size += fileSize(d)
+		}
+		return nil
+	})
+	return size, err
+}
+
+func fileSize(d fs.DirEntry) int64 {
+	info, err := d.Info()
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
+
+// Cache holds measurements for a project's worktrees in the bare repository,
+// so listing sizes does not walk every worktree each time. It is safe for
+// concurrent use.
+type Cache struct {
+	path    string
+	mu      sync.Mutex
+	entries map[string]Usage
+	changed bool
+}
+
+// OpenCache reads the cached measurements for the project at barePath. A
+// missing cache starts empty, and so does an unreadable one, which is logged
+// and rebuilt as worktrees are measured.
+func OpenCache(barePath string) *Cache {
+	cache := &Cache{
+		path:    filepath.Join(barePath, "arbor", "disk-usage.json"),
+		entries: make(map[string]Usage),
+	}
+	if err := cache.load(); err != nil {
+		logging.Verbosef("Rebuilding disk usage cache: %v", err)
+		cache.entries = make(map[string]Usage)
+		cache.changed = true
+	}
+	return cache
+}
+
+func (c *Cache) load() error {
+	data, err := os.ReadFile(c.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, &c.entries); err != nil {
+		return fmt.Errorf("parsing %s: %w", c.path, err)
+	}
+	if c.entries == nil {
+		c.entries = make(map[string]Usage)
+	}
+	return nil
+}
+
+// Usage returns the size of the worktree at worktreePath, measuring it when
+// there is no measurement younger than CacheTTL or refresh is set
+func (c *Cache) Usage(worktreePath string, refresh bool) (Usage, error) {
+	c.mu.Lock()
+	usage, ok := c.entries[worktreePath]
+	c.mu.Unlock()
+	if ok && !refresh && time.Since(usage.MeasuredAt) < CacheTTL {
+		return usage, nil
+	}
+
+	usage, err := Measure(worktreePath)
+	if err != nil {
+		return Usage{}, err
+	}
+
+	c.mu.Lock()
+	c.entries[worktreePath] = usage
+	c.changed = true
+	c.mu.Unlock()
+	return usage, nil
+}
+
+// Forget drops the measurement of the worktree at worktreePath
+func (c *Cache) Forget(worktreePath string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[worktreePath]; ok {
+		delete(c.entries, worktreePath)
+		c.changed = true
+	}
+}
+
+// Save writes the measurements back if any changed. Measurements of
+// worktrees that no longer exist are dropped.
+func (c *Cache) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.changed {
+		return nil
+	}
+
+	for path := range c.entries {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			delete(c.entries, path)
+		}
+	}
+
+	data, err := json.Marshal(c.entries)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(c.path, data, 0644); err != nil {
+		return err
+	}
+	c.changed = false
+	return nil
+}
+
+// FormatBytes renders a size in bytes using binary units, e.g. 1.5 GB
+func FormatBytes(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+
+	value := float64(bytes)
+	suffixes := []string{"KB", "MB", "GB", "TB"}
+	i := -1
+	for value >= unit && i < len(suffixes)-1 {
+		value /= unit
+		i++
+	}
+	if value >= 100 {
+		return fmt.Sprintf("%.0f %s", value, suffixes[i])
+	}
+	return fmt.Sprintf("%.1f %s", value, suffixes[i])
+}
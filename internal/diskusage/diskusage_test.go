@@ -0,0 +1,147 @@
+package diskusage
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createWorktree makes a repository with a .gitignore for node_modules, a
+// 100 byte source file, a 1000 byte file in node_modules and a tracked 10
+// byte vendor file
+func createWorktree(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+
+	output, err := exec.Command("git", "-C", dir, "init", "-q").CombinedOutput()
+	require.NoError(t, err, string(output))
+
+	writeFile(t, dir, ".gitignore", "node_modules\n")
+	writeFile(t, dir, "app.php", strings.Repeat("a", 100))
+	writeFile(t, dir, "node_modules/pkg/index.js", strings.Repeat("n", 1000))
+	writeFile(t, dir, "vendor/autoload.php", strings.Repeat("v", 10))
+	return dir
+}
+
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+}
+
+func TestMeasure(t *testing.T) {
+	dir := createWorktree(t)
+
+	usage, err := Measure(dir)
+	require.NoError(t, err)
+
+	gitignore := int64(len("node_modules\n"))
+	assert.Equal(t, gitignore+100+1000+10, usage.Bytes, "the .git directory is not counted")
+	assert.Equal(t, map[string]int64{"node_modules": 1000}, usage.Artifacts, "vendor is not ignored, so it is source")
+	assert.Equal(t, int64(1000), usage.ArtifactBytes())
+	assert.False(t, usage.MeasuredAt.IsZero())
+}
+
+func TestLargestArtifacts(t *testing.T) {
+	usage := Usage{Artifacts: map[string]int64{"vendor": 10, "node_modules": 500, "dist": 10}}
+
+	assert.Equal(t, []Artifact{
+		{Path: "node_modules", Bytes: 500},
+		{Path: "dist", Bytes: 10},
+		{Path: "vendor", Bytes: 10},
+	}, usage.LargestArtifacts())
+}
+
+func TestRemoveArtifacts(t *testing.T) {
+	dir := createWorktree(t)
+
+	removed, err := RemoveArtifacts(dir, true)
+	require.NoError(t, err)
+	assert.Equal(t, []Artifact{{Path: "node_modules", Bytes: 1000}}, removed)
+	assert.DirExists(t, filepath.Join(dir, "node_modules"), "a dry run removes nothing")
+
+	removed, err = RemoveArtifacts(dir, false)
+	require.NoError(t, err)
+	assert.Len(t, removed, 1)
+	assert.NoDirExists(t, filepath.Join(dir, "node_modules"))
+	assert.DirExists(t, filepath.Join(dir, "vendor"))
+}
+
+func TestCache(t *testing.T) {
+	barePath := t.TempDir()
+	dir := createWorktree(t)
+
+	cache := OpenCache(barePath)
+	first, err := cache.Usage(dir, false)
+	require.NoError(t, err)
+	require.NoError(t, cache.Save())
+
+	writeFile(t, dir, "more.txt", "12345")
+
+	cached, err := OpenCache(barePath).Usage(dir, false)
+	require.NoError(t, err)
+	assert.Equal(t, first.Bytes, cached.Bytes, "a recent measurement is reused")
+
+	refreshed, err := OpenCache(barePath).Usage(dir, true)
+	require.NoError(t, err)
+	assert.Equal(t, first.Bytes+5, refreshed.Bytes)
+
+	original := CacheTTL
+	CacheTTL = time.Nanosecond
+	t.Cleanup(func() { CacheTTL = original })
+	expired, err := OpenCache(barePath).Usage(dir, false)
+	require.NoError(t, err)
+	assert.Equal(t, first.Bytes+5, expired.Bytes)
+}
+
+func TestCache_SaveDropsRemovedWorktrees(t *testing.T) {
+	barePath := t.TempDir()
+	dir := createWorktree(t)
+
+	cache := OpenCache(barePath)
+	_, err := cache.Usage(dir, false)
+	require.NoError(t, err)
+	require.NoError(t, cache.Save())
+
+	require.NoError(t, os.RemoveAll(dir))
+	cache = OpenCache(barePath)
+	cache.Forget("/not/cached")
+	_, err = cache.Usage(createWorktree(t), false)
+	require.NoError(t, err)
+	require.NoError(t, cache.Save())
+
+	assert.NotContains(t, OpenCache(barePath).entries, dir)
+}
+
+func TestCache_RebuildsInvalidCache(t *testing.T) {
+	barePath := t.TempDir()
+	writeFile(t, barePath, "arbor/disk-usage.json", "{not json")
+
+	cache := OpenCache(barePath)
+	assert.Empty(t, cache.entries)
+	require.NoError(t, cache.Save())
+
+	data, err := os.ReadFile(filepath.Join(barePath, "arbor", "disk-usage.json"))
+	require.NoError(t, err)
+	assert.Equal(t, "{}", string(data))
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := map[int64]string{
+		0:                      "0 B",
+		512:                    "512 B",
+		1536:                   "1.5 KB",
+		300 * 1024 * 1024:      "300 MB",
+		2 * 1024 * 1024 * 1024: "2.0 GB",
+	}
+	for bytes, want := range tests {
+		assert.Equal(t, want, FormatBytes(bytes), bytes)
+	}
+}
@@ -6,4 +6,29 @@ var (
 	ErrWorktreeNotFound   = errors.New("worktree not found")
 	ErrConfigNotFound     = errors.New("configuration not found")
 	ErrGitOperationFailed = errors.New("git operation failed")
+	ErrScaffoldStepFailed = errors.New("scaffold step failed")
+	ErrInvalidArguments   = errors.New("invalid arguments")
+	ErrInvalidConfig      = errors.New("invalid configuration")
 )
+
+// Wrap marks err as belonging to the class of failure sentinel, so that
+// errors.Is(err, sentinel) holds, without changing its message
+func Wrap(sentinel, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &classified{sentinel: sentinel, err: err}
+}
+
+type classified struct {
+	sentinel error
+	err      error
+}
+
+func (e *classified) Error() string {
+	return e.err.Error()
+}
+
+func (e *classified) Unwrap() []error {
+	return []error{e.err, e.sentinel}
+}
@@ -34,3 +34,16 @@ func TestErrorMessages(t *testing.T) {
 	assert.Equal(t, "configuration not found", ErrConfigNotFound.Error())
 	assert.Equal(t, "git operation failed", ErrGitOperationFailed.Error())
 }
+
+func TestWrap(t *testing.T) {
+	cause := fmt.Errorf("git worktree add failed: %w", errors.New("exit status 128"))
+	wrapped := Wrap(ErrGitOperationFailed, cause)
+
+	assert.Equal(t, cause.Error(), wrapped.Error())
+	assert.True(t, errors.Is(wrapped, ErrGitOperationFailed))
+	assert.True(t, errors.Is(wrapped, cause))
+	assert.False(t, errors.Is(wrapped, ErrWorktreeNotFound))
+
+	assert.True(t, errors.Is(fmt.Errorf("creating worktree: %w", wrapped), ErrGitOperationFailed))
+	assert.Nil(t, Wrap(ErrGitOperationFailed, nil))
+}
@@ -0,0 +1,63 @@
+// Package events posts worktree lifecycle events to webhooks.
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Event names, as used under events: in arbor.yaml
+const (
+	WorktreeCreated = "worktree_created"
+	WorktreeRemoved = "worktree_removed"
+)
+
+// Timeout bounds each webhook request, so a slow endpoint cannot hold up
+// arbor
+var Timeout = 5 * time.Second
+
+// Payload is the JSON body posted for an event
+type Payload struct {
+	Event      string    `json:"event"`
+	Project    string    `json:"project"`
+	Branch     string    `json:"branch"`
+	Path       string    `json:"path"`
+	DbSuffix   string    `json:"dbSuffix,omitempty"`
+	DurationMs int64     `json:"durationMs"`
+	Timestamp  time.Time `json:"timestamp"`
+}
+
+// Send posts payload to url as JSON. Any status other than 2xx is an error.
+func Send(url string, payload Payload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("creating webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "arbor")
+	req.Header.Set("X-Arbor-Event", payload.Event)
+
+	resp, err := (&http.Client{Timeout: Timeout}).Do(req)
+	if err != nil {
+		return fmt.Errorf("posting %s webhook: %w", payload.Event, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("posting %s webhook: %s", payload.Event, resp.Status)
+	}
+	// Drain the body so the connection can be reused
+	if _, err := io.Copy(io.Discard, resp.Body); err != nil {
+		return fmt.Errorf("reading %s webhook response: %w", payload.Event, err)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package events
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSend(t *testing.T) {
+	var received map[string]interface{}
+	var header http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	err := Send(server.URL, Payload{
+		Event:      WorktreeCreated,
+		Project:    "myapp",
+		Branch:     "feature/login",
+		Path:       "/code/myapp/feature-login",
+		DbSuffix:   "swift_fox",
+		DurationMs: 1500,
+		Timestamp:  time.Date(2025, 1, 31, 15, 4, 5, 0, time.UTC),
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "application/json", header.Get("Content-Type"))
+	assert.Equal(t, WorktreeCreated, header.Get("X-Arbor-Event"))
+	assert.Equal(t, map[string]interface{}{
+		"event":      "worktree_created",
+		"project":    "myapp",
+		"branch":     "feature/login",
+		"path":       "/code/myapp/feature-login",
+		"dbSuffix":   "swift_fox",
+		"durationMs": float64(1500),
+		"timestamp":  "2025-01-31T15:04:05Z",
+	}, received)
+}
+
+func TestSend_ErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	err := Send(server.URL, Payload{Event: WorktreeRemoved})
+	assert.ErrorContains(t, err, "500")
+}
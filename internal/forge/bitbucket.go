@@ -0,0 +1,45 @@
+package forge
+
+import (
+	"fmt"
+	"net/url"
+	"os/exec"
+
+	"github.com/michaeldyrynda/arbor/internal/git"
+)
+
+// Bitbucket has no official CLI, so it clones with git and opens pull
+// requests in the browser
+type Bitbucket struct {
+	Host string
+	Path string
+}
+
+func (b *Bitbucket) Name() string { return BitbucketProvider }
+
+func (b *Bitbucket) CLI() string { return "" }
+
+func (b *Bitbucket) Clone(repo, barePath string) error {
+	return git.CloneRepo(cloneURL(b.Host, repo), barePath)
+}
+
+func (b *Bitbucket) PullRequests(dir string) ([]PullRequest, error) {
+	return nil, fmt.Errorf("listing Bitbucket pull requests is %w", ErrUnsupported)
+}
+
+func (b *Bitbucket) PullRequestRef(number int) (string, error) {
+	return "", fmt.Errorf("checking out Bitbucket pull requests is %w, as Bitbucket does not publish their refs", ErrUnsupported)
+}
+
+// CreatePullRequest returns ErrUnsupported; NewPullRequestURL gives the page
+// to open instead
+func (b *Bitbucket) CreatePullRequest(dir string, opts PullRequestOptions) (*exec.Cmd, error) {
+	return nil, fmt.Errorf("opening Bitbucket pull requests from the command line is %w", ErrUnsupported)
+}
+
+// NewPullRequestURL is the web page for opening a pull request from branch
+// into base
+func (b *Bitbucket) NewPullRequestURL(branch, base string) string {
+	return fmt.Sprintf("https://%s/%s/pull-requests/new?source=%s&dest=%s",
+		b.Host, b.Path, url.QueryEscape(branch), url.QueryEscape(base))
+}
@@ -0,0 +1,205 @@
+// Package forge talks to the services hosting a repository, such as GitHub,
+// GitLab and Bitbucket, through their command line tools or plain git.
+package forge
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/michaeldyrynda/arbor/internal/logging"
+	"github.com/michaeldyrynda/arbor/internal/utils"
+)
+
+// ErrUnsupported is returned when a provider cannot do something, such as
+// listing Bitbucket pull requests without a CLI
+var ErrUnsupported = errors.New("not supported")
+
+// Provider names accepted by arbor init --provider
+const (
+	GitHubProvider    = "github"
+	GitLabProvider    = "gitlab"
+	BitbucketProvider = "bitbucket"
+)
+
+// Provider is a service hosting the repository
+type Provider interface {
+	// Name is github, gitlab or bitbucket
+	Name() string
+	// CLI is the provider's command line tool, or "" when only git is used
+	CLI() string
+	// Clone clones repo, a URL or an owner/name shortname, into a bare
+	// repository
+	Clone(repo, barePath string) error
+	// PullRequests lists the open pull requests, which GitLab calls merge
+	// requests, for the repository at dir
+	PullRequests(dir string) ([]PullRequest, error)
+	// PullRequestRef is the ref origin publishes the head of a pull request
+	// under, which includes pull requests from forks
+	PullRequestRef(number int) (string, error)
+	// CreatePullRequest returns the command that opens a pull request for
+	// the branch checked out in dir
+	CreatePullRequest(dir string, opts PullRequestOptions) (*exec.Cmd, error)
+}
+
+// BrowserProvider is implemented by providers that open pull requests in
+// the browser rather than from the command line
+type BrowserProvider interface {
+	NewPullRequestURL(branch, base string) string
+}
+
+// PullRequestOptions describes a pull request to open
+type PullRequestOptions struct {
+	Branch string
+	Base   string
+	Title  string
+	Body   string
+	Draft  bool
+}
+
+// Remote is a parsed remote URL such as git@github.com:acme/app.git
+type Remote struct {
+	Host string
+	// Path is the repository path without .git, e.g. acme/app
+	Path string
+}
+
+// ParseRemote parses HTTPS, SSH and scp-style remote URLs. It returns false
+// for anything else, such as owner/name shortnames and local paths.
+func ParseRemote(url string) (Remote, bool) {
+	var rest string
+	switch {
+	case strings.Contains(url, "://"):
+		rest = url[strings.Index(url, "://")+3:]
+		if at := strings.Index(rest, "@"); at >= 0 && at < strings.Index(rest+"/", "/") {
+			rest = rest[at+1:]
+		}
+		host, path, ok := strings.Cut(rest, "/")
+		if !ok || strings.HasPrefix(url, "file://") {
+			return Remote{}, false
+		}
+		host, _, _ = strings.Cut(host, ":")
+		return Remote{Host: host, Path: strings.TrimSuffix(strings.Trim(path, "/"), ".git")}, true
+	case strings.Contains(url, "@") && strings.Contains(url, ":"):
+		rest = url[strings.Index(url, "@")+1:]
+		host, path, _ := strings.Cut(rest, ":")
+		return Remote{Host: host, Path: strings.TrimSuffix(strings.Trim(path, "/"), ".git")}, true
+	}
+	return Remote{}, false
+}
+
+// ForRemote returns the provider for a remote's host. Self-hosted GitLab
+// and Bitbucket are recognised by their host names; anything else is
+// treated as GitHub.
+func ForRemote(remote Remote) Provider {
+	host := strings.ToLower(remote.Host)
+	switch {
+	case strings.Contains(host, "gitlab"):
+		return &GitLab{Host: remote.Host, Path: remote.Path}
+	case strings.Contains(host, "bitbucket"):
+		return &Bitbucket{Host: remote.Host, Path: remote.Path}
+	}
+	return &GitHub{Host: remote.Host, Path: remote.Path}
+}
+
+// ForName returns the provider called name, using its public host
+func ForName(name string) (Provider, error) {
+	switch strings.ToLower(name) {
+	case "", GitHubProvider:
+		return &GitHub{Host: "github.com"}, nil
+	case GitLabProvider:
+		return &GitLab{Host: "gitlab.com"}, nil
+	case BitbucketProvider:
+		return &Bitbucket{Host: "bitbucket.org"}, nil
+	}
+	return nil, fmt.Errorf("unknown provider %q (use %s, %s or %s)", name, GitHubProvider, GitLabProvider, BitbucketProvider)
+}
+
+// ForRepo returns the provider for a repository being cloned. URLs are
+// matched by host; shortnames use the named provider, GitHub by default.
+func ForRepo(repo, name string) (Provider, error) {
+	if remote, ok := ParseRemote(repo); ok {
+		return ForRemote(remote), nil
+	}
+	return ForName(name)
+}
+
+// Detect returns the provider for the origin remote of the repository at
+// barePath
+func Detect(barePath string) (Provider, error) {
+	output, err := logging.Output(exec.Command("git", "-C", barePath, "remote", "get-url", "origin"))
+	if err != nil {
+		return nil, fmt.Errorf("reading origin remote: %w", err)
+	}
+
+	url := strings.TrimSpace(string(output))
+	remote, ok := ParseRemote(url)
+	if !ok {
+		return nil, fmt.Errorf("origin %s is not a hosted repository", url)
+	}
+	return ForRemote(remote), nil
+}
+
+// isShortname reports whether repo is an owner/name shortname rather than a
+// URL or local path
+func isShortname(repo string) bool {
+	if !utils.IsGitShortFormat(repo) || !strings.Contains(repo, "/") {
+		return false
+	}
+	if strings.HasPrefix(repo, "/") || strings.HasPrefix(repo, ".") {
+		return false
+	}
+	_, err := os.Stat(repo)
+	return err != nil
+}
+
+// cloneURL expands a shortname to an HTTPS URL on host
+func cloneURL(host, repo string) string {
+	if isShortname(repo) {
+		return fmt.Sprintf("https://%s/%s.git", host, strings.TrimSuffix(repo, ".git"))
+	}
+	return repo
+}
+
+func commandAvailable(name string) bool {
+	if name == "" {
+		return false
+	}
+	_, err := exec.LookPath(name)
+	return err == nil
+}
+
+// cloneWithCLI clones with a provider CLI that passes arguments after --
+// through to git clone
+func cloneWithCLI(cli, repo, barePath string) error {
+	if err := os.MkdirAll(barePath, 0755); err != nil {
+		return err
+	}
+
+	output, err := logging.CombinedOutput(exec.Command(cli, "repo", "clone", repo, barePath, "--", "--bare"))
+	if err != nil {
+		return fmt.Errorf("%s repo clone failed: %w\n%s", cli, err, string(output))
+	}
+	return nil
+}
+
+// runCLI runs a provider CLI in dir and returns its output
+func runCLI(dir, cli string, args ...string) ([]byte, error) {
+	if !commandAvailable(cli) {
+		return nil, fmt.Errorf("%s is not installed", cli)
+	}
+
+	cmd := exec.Command(cli, args...)
+	cmd.Dir = dir
+	output, err := logging.Output(cmd)
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return nil, fmt.Errorf("%s %s failed: %s", cli, strings.Join(args[:2], " "), strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, fmt.Errorf("%s %s failed: %w", cli, strings.Join(args[:2], " "), err)
+	}
+	return output, nil
+}
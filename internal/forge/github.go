@@ -0,0 +1,129 @@
+package forge
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/michaeldyrynda/arbor/internal/git"
+)
+
+// GitHub uses the gh CLI, falling back to git for clones
+type GitHub struct {
+	Host string
+	Path string
+}
+
+func (g *GitHub) Name() string { return GitHubProvider }
+
+func (g *GitHub) CLI() string { return "gh" }
+
+func (g *GitHub) Clone(repo, barePath string) error {
+	if commandAvailable("gh") {
+		return cloneWithCLI("gh", repo, barePath)
+	}
+	return git.CloneRepo(cloneURL(g.Host, repo), barePath)
+}
+
+// ghPullRequests lists the repository's open pull requests as gh JSON
+var ghPullRequests = func(dir string) ([]byte, error) {
+	return runCLI(dir, "gh", "pr", "list", "--state", "open", "--limit", "200",
+		"--json", "number,headRefName,url,isDraft,reviewDecision,statusCheckRollup")
+}
+
+func (g *GitHub) PullRequests(dir string) ([]PullRequest, error) {
+	output, err := ghPullRequests(dir)
+	if err != nil {
+		return nil, err
+	}
+	return parseGitHubPullRequests(output)
+}
+
+func (g *GitHub) PullRequestRef(number int) (string, error) {
+	return fmt.Sprintf("refs/pull/%d/head", number), nil
+}
+
+func (g *GitHub) CreatePullRequest(dir string, opts PullRequestOptions) (*exec.Cmd, error) {
+	if !commandAvailable("gh") {
+		return nil, fmt.Errorf("gh is required to open pull requests (https://cli.github.com)")
+	}
+
+	cmd := exec.Command("gh", ghCreateArgs(opts)...)
+	cmd.Dir = dir
+	return cmd, nil
+}
+
+// ghCreateArgs builds the gh pr create arguments. Without a title gh fills
+// in the title and body from the commits.
+func ghCreateArgs(opts PullRequestOptions) []string {
+	args := []string{"pr", "create", "--base", opts.Base}
+	if opts.Title == "" {
+		args = append(args, "--fill")
+	} else {
+		args = append(args, "--title", opts.Title, "--body", opts.Body)
+	}
+	if opts.Draft {
+		args = append(args, "--draft")
+	}
+	return args
+}
+
+type ghCheck struct {
+	Status     string `json:"status"`
+	Conclusion string `json:"conclusion"`
+	State      string `json:"state"`
+}
+
+func parseGitHubPullRequests(data []byte) ([]PullRequest, error) {
+	var raw []struct {
+		Number            int       `json:"number"`
+		HeadRefName       string    `json:"headRefName"`
+		URL               string    `json:"url"`
+		IsDraft           bool      `json:"isDraft"`
+		ReviewDecision    string    `json:"reviewDecision"`
+		StatusCheckRollup []ghCheck `json:"statusCheckRollup"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("parsing gh output: %w", err)
+	}
+
+	prs := make([]PullRequest, len(raw))
+	for i, pr := range raw {
+		prs[i] = PullRequest{
+			Number: pr.Number,
+			Branch: pr.HeadRefName,
+			URL:    pr.URL,
+			Draft:  pr.IsDraft,
+			Review: strings.ToLower(pr.ReviewDecision),
+			Checks: summariseChecks(pr.StatusCheckRollup),
+		}
+	}
+	return prs, nil
+}
+
+// summariseChecks reduces check runs and commit statuses to failing,
+// pending or passing
+func summariseChecks(checks []ghCheck) string {
+	if len(checks) == 0 {
+		return ""
+	}
+
+	pending := false
+	for _, check := range checks {
+		switch strings.ToUpper(check.Conclusion + check.State) {
+		case "FAILURE", "ERROR", "CANCELLED", "TIMED_OUT", "ACTION_REQUIRED", "STARTUP_FAILURE":
+			return "failing"
+		case "", "PENDING", "EXPECTED":
+			pending = true
+		}
+		if check.Status != "" && strings.ToUpper(check.Status) != "COMPLETED" {
+			pending = true
+		}
+	}
+
+	if pending {
+		return "pending"
+	}
+	return "passing"
+}
@@ -0,0 +1,53 @@
+package forge
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const ghPullRequestJSON = `[
+  {"number": 12, "headRefName": "feature/login", "url": "https://github.com/acme/app/pull/12", "isDraft": false,
+   "reviewDecision": "APPROVED",
+   "statusCheckRollup": [{"__typename": "CheckRun", "status": "COMPLETED", "conclusion": "SUCCESS"},
+                         {"__typename": "StatusContext", "state": "SUCCESS"}]},
+  {"number": 15, "headRefName": "feature/search", "url": "https://github.com/acme/app/pull/15", "isDraft": true,
+   "reviewDecision": "", "statusCheckRollup": [{"status": "IN_PROGRESS", "conclusion": ""}]}
+]`
+
+func TestGitHubPullRequests(t *testing.T) {
+	original := ghPullRequests
+	t.Cleanup(func() { ghPullRequests = original })
+	ghPullRequests = func(string) ([]byte, error) {
+		return []byte(ghPullRequestJSON), nil
+	}
+
+	prs, err := (&GitHub{Host: "github.com", Path: "acme/app"}).PullRequests(t.TempDir())
+	require.NoError(t, err)
+	require.Len(t, prs, 2)
+
+	assert.Equal(t, PullRequest{
+		Number: 12,
+		Branch: "feature/login",
+		URL:    "https://github.com/acme/app/pull/12",
+		Review: "approved",
+		Checks: "passing",
+	}, prs[0])
+	assert.True(t, prs[1].Draft)
+	assert.Equal(t, "pending", prs[1].Checks)
+}
+
+func TestSummariseChecks(t *testing.T) {
+	assert.Equal(t, "", summariseChecks(nil))
+	assert.Equal(t, "passing", summariseChecks([]ghCheck{{Status: "COMPLETED", Conclusion: "SKIPPED"}}))
+	assert.Equal(t, "failing", summariseChecks([]ghCheck{{Status: "IN_PROGRESS"}, {Status: "COMPLETED", Conclusion: "FAILURE"}}))
+	assert.Equal(t, "pending", summariseChecks([]ghCheck{{State: "PENDING"}}))
+}
+
+func TestGhCreateArgs(t *testing.T) {
+	assert.Equal(t, []string{"pr", "create", "--base", "main", "--fill"},
+		ghCreateArgs(PullRequestOptions{Base: "main"}))
+	assert.Equal(t, []string{"pr", "create", "--base", "develop", "--title", "Login", "--body", "Adds login", "--draft"},
+		ghCreateArgs(PullRequestOptions{Base: "develop", Title: "Login", Body: "Adds login", Draft: true}))
+}
@@ -0,0 +1,111 @@
+package forge
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+
+	"github.com/michaeldyrynda/arbor/internal/git"
+)
+
+// GitLab uses the glab CLI, falling back to git for clones
+type GitLab struct {
+	Host string
+	Path string
+}
+
+func (g *GitLab) Name() string { return GitLabProvider }
+
+func (g *GitLab) CLI() string { return "glab" }
+
+func (g *GitLab) Clone(repo, barePath string) error {
+	if commandAvailable("glab") {
+		return cloneWithCLI("glab", repo, barePath)
+	}
+	return git.CloneRepo(cloneURL(g.Host, repo), barePath)
+}
+
+// glabMergeRequests lists the repository's open merge requests as glab JSON
+var glabMergeRequests = func(dir string) ([]byte, error) {
+	return runCLI(dir, "glab", "mr", "list", "--per-page", "100", "--output", "json")
+}
+
+func (g *GitLab) PullRequests(dir string) ([]PullRequest, error) {
+	output, err := glabMergeRequests(dir)
+	if err != nil {
+		return nil, err
+	}
+	return parseGitLabMergeRequests(output)
+}
+
+func (g *GitLab) PullRequestRef(number int) (string, error) {
+	return fmt.Sprintf("refs/merge-requests/%d/head", number), nil
+}
+
+func (g *GitLab) CreatePullRequest(dir string, opts PullRequestOptions) (*exec.Cmd, error) {
+	if !commandAvailable("glab") {
+		return nil, fmt.Errorf("glab is required to open merge requests (https://gitlab.com/gitlab-org/cli)")
+	}
+
+	cmd := exec.Command("glab", glabCreateArgs(opts)...)
+	cmd.Dir = dir
+	return cmd, nil
+}
+
+// glabCreateArgs builds the glab mr create arguments. Without a title glab
+// fills in the title and description from the commits.
+func glabCreateArgs(opts PullRequestOptions) []string {
+	args := []string{"mr", "create", "--target-branch", opts.Base, "--yes"}
+	if opts.Title == "" {
+		args = append(args, "--fill")
+	} else {
+		args = append(args, "--title", opts.Title, "--description", opts.Body)
+	}
+	if opts.Draft {
+		args = append(args, "--draft")
+	}
+	return args
+}
+
+func parseGitLabMergeRequests(data []byte) ([]PullRequest, error) {
+	var raw []struct {
+		IID          int    `json:"iid"`
+		SourceBranch string `json:"source_branch"`
+		WebURL       string `json:"web_url"`
+		Draft        bool   `json:"draft"`
+		HeadPipeline *struct {
+			Status string `json:"status"`
+		} `json:"head_pipeline"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("parsing glab output: %w", err)
+	}
+
+	prs := make([]PullRequest, len(raw))
+	for i, mr := range raw {
+		prs[i] = PullRequest{
+			Number: mr.IID,
+			Branch: mr.SourceBranch,
+			URL:    mr.WebURL,
+			Draft:  mr.Draft,
+		}
+		if mr.HeadPipeline != nil {
+			prs[i].Checks = pipelineChecks(mr.HeadPipeline.Status)
+		}
+	}
+	return prs, nil
+}
+
+// pipelineChecks maps a GitLab pipeline status to passing, failing or
+// pending
+func pipelineChecks(status string) string {
+	switch status {
+	case "success":
+		return "passing"
+	case "failed", "canceled":
+		return "failing"
+	case "":
+		return ""
+	}
+	return "pending"
+}
@@ -0,0 +1,42 @@
+package forge
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseGitLabMergeRequests(t *testing.T) {
+	prs, err := parseGitLabMergeRequests([]byte(`[
+  {"iid": 7, "source_branch": "feature/login", "web_url": "https://gitlab.com/acme/app/-/merge_requests/7",
+   "draft": false, "head_pipeline": {"status": "success"}},
+  {"iid": 9, "source_branch": "feature/search", "web_url": "https://gitlab.com/acme/app/-/merge_requests/9",
+   "draft": true, "head_pipeline": null}
+]`))
+	require.NoError(t, err)
+	require.Len(t, prs, 2)
+
+	assert.Equal(t, PullRequest{
+		Number: 7,
+		Branch: "feature/login",
+		URL:    "https://gitlab.com/acme/app/-/merge_requests/7",
+		Checks: "passing",
+	}, prs[0])
+	assert.True(t, prs[1].Draft)
+	assert.Empty(t, prs[1].Checks)
+}
+
+func TestPipelineChecks(t *testing.T) {
+	assert.Equal(t, "passing", pipelineChecks("success"))
+	assert.Equal(t, "failing", pipelineChecks("failed"))
+	assert.Equal(t, "pending", pipelineChecks("running"))
+	assert.Equal(t, "", pipelineChecks(""))
+}
+
+func TestGlabCreateArgs(t *testing.T) {
+	assert.Equal(t, []string{"mr", "create", "--target-branch", "main", "--yes", "--fill"},
+		glabCreateArgs(PullRequestOptions{Base: "main"}))
+	assert.Equal(t, []string{"mr", "create", "--target-branch", "main", "--yes", "--title", "Login", "--description", "Adds login", "--draft"},
+		glabCreateArgs(PullRequestOptions{Base: "main", Title: "Login", Body: "Adds login", Draft: true}))
+}
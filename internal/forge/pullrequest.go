@@ -0,0 +1,74 @@
+package forge
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/michaeldyrynda/arbor/internal/logging"
+)
+
+// PullRequest is an open pull request for a branch
+type PullRequest struct {
+	Number int    `json:"number"`
+	Branch string `json:"branch"`
+	URL    string `json:"url"`
+	Draft  bool   `json:"draft"`
+	// Review is approved, changes_requested, review_required or empty
+	Review string `json:"review,omitempty"`
+	// Checks is passing, failing, pending or empty when there are none
+	Checks string `json:"checks,omitempty"`
+}
+
+// PullRequestCacheTTL is how long pull request details are reused before
+// the provider is asked again, to stay clear of API rate limits
+var PullRequestCacheTTL = 2 * time.Minute
+
+type pullRequestCache struct {
+	FetchedAt    time.Time     `json:"fetchedAt"`
+	PullRequests []PullRequest `json:"pullRequests"`
+}
+
+// OpenPullRequests returns the repository's open pull requests keyed by
+// branch. Results are cached in the bare repository for PullRequestCacheTTL.
+func OpenPullRequests(barePath string, provider Provider) (map[string]PullRequest, error) {
+	cachePath := filepath.Join(barePath, "arbor", "pull-requests.json")
+
+	var cache pullRequestCache
+	if data, err := os.ReadFile(cachePath); err == nil && json.Unmarshal(data, &cache) == nil &&
+		time.Since(cache.FetchedAt) < PullRequestCacheTTL {
+		return pullRequestsByBranch(cache.PullRequests), nil
+	}
+
+	prs, err := provider.PullRequests(barePath)
+	if err != nil {
+		return nil, err
+	}
+
+	// The cache only saves API calls, so failing to write it is logged
+	if err := writePullRequestCache(cachePath, prs); err != nil {
+		logging.Verbosef("Could not cache pull requests: %v", err)
+	}
+
+	return pullRequestsByBranch(prs), nil
+}
+
+func writePullRequestCache(cachePath string, prs []PullRequest) error {
+	data, err := json.Marshal(pullRequestCache{FetchedAt: time.Now(), PullRequests: prs})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(cachePath), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(cachePath, data, 0644)
+}
+
+func pullRequestsByBranch(prs []PullRequest) map[string]PullRequest {
+	byBranch := make(map[string]PullRequest, len(prs))
+	for _, pr := range prs {
+		byBranch[pr.Branch] = pr
+	}
+	return byBranch
+}
@@ -0,0 +1,56 @@
+package forge
+
+import (
+	"errors"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeProvider struct {
+	calls int
+	prs   []PullRequest
+	err   error
+}
+
+func (f *fakeProvider) Name() string                      { return "fake" }
+func (f *fakeProvider) CLI() string                       { return "" }
+func (f *fakeProvider) Clone(repo, barePath string) error { return nil }
+func (f *fakeProvider) PullRequestRef(number int) (string, error) {
+	return "", ErrUnsupported
+}
+func (f *fakeProvider) CreatePullRequest(dir string, opts PullRequestOptions) (*exec.Cmd, error) {
+	return nil, ErrUnsupported
+}
+
+func (f *fakeProvider) PullRequests(dir string) ([]PullRequest, error) {
+	f.calls++
+	return f.prs, f.err
+}
+
+func TestOpenPullRequests_Caches(t *testing.T) {
+	barePath := t.TempDir()
+
+	provider := &fakeProvider{prs: []PullRequest{
+		{Number: 12, Branch: "feature/login"},
+		{Number: 15, Branch: "feature/search"},
+	}}
+
+	prs, err := OpenPullRequests(barePath, provider)
+	require.NoError(t, err)
+	assert.Equal(t, 12, prs["feature/login"].Number)
+
+	provider.prs, provider.err = nil, errors.New("rate limited")
+
+	prs, err = OpenPullRequests(barePath, provider)
+	require.NoError(t, err)
+	assert.Equal(t, 15, prs["feature/search"].Number)
+	assert.Equal(t, 1, provider.calls)
+}
+
+func TestOpenPullRequests_Error(t *testing.T) {
+	_, err := OpenPullRequests(t.TempDir(), &Bitbucket{Host: "bitbucket.org", Path: "acme/app"})
+	assert.ErrorIs(t, err, ErrUnsupported)
+}
@@ -0,0 +1,92 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	arborerrors "github.com/michaeldyrynda/arbor/internal/errors"
+	"github.com/michaeldyrynda/arbor/internal/logging"
+)
+
+// EnableWorktreeConfig turns on extensions.worktreeConfig for the repository
+// of the worktree at worktreePath, so each worktree can have its own config.
+// A bare repository's core.bare is moved to the bare repository's own
+// config.worktree first, as linked worktrees would otherwise read it and
+// treat themselves as bare.
+func EnableWorktreeConfig(worktreePath string) error {
+	enabled, err := configValue(worktreePath, "--bool", "extensions.worktreeConfig")
+	if err != nil {
+		return err
+	}
+	if enabled == "true" {
+		return nil
+	}
+
+	commonDir, err := gitOutput(worktreePath, "rev-parse", "--git-common-dir")
+	if err != nil {
+		return err
+	}
+	if !filepath.IsAbs(commonDir) {
+		commonDir = filepath.Join(worktreePath, commonDir)
+	}
+
+	bare, err := configValue(worktreePath, "--file", filepath.Join(commonDir, "config"), "--bool", "core.bare")
+	if err != nil {
+		return err
+	}
+	if bare == "true" {
+		if _, err := gitOutput(worktreePath, "config", "--file", filepath.Join(commonDir, "config.worktree"), "core.bare", "true"); err != nil {
+			return err
+		}
+	}
+	if _, err := gitOutput(worktreePath, "config", "--file", filepath.Join(commonDir, "config"), "extensions.worktreeConfig", "true"); err != nil {
+		return err
+	}
+	if bare == "true" {
+		if _, err := gitOutput(worktreePath, "config", "--file", filepath.Join(commonDir, "config"), "--unset", "core.bare"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetWorktreeConfig sets key to value in the config of the worktree at
+// worktreePath only. EnableWorktreeConfig must have been called.
+func SetWorktreeConfig(worktreePath, key, value string) error {
+	_, err := gitOutput(worktreePath, "config", "--worktree", key, value)
+	return err
+}
+
+// GetConfig returns the value of key as the worktree at worktreePath sees
+// it, or "" when it is not set
+func GetConfig(worktreePath, key string) (string, error) {
+	return configValue(worktreePath, key)
+}
+
+// configValue runs git config --get with args, returning "" when the key is
+// not set, which git reports with exit status 1
+func configValue(worktreePath string, args ...string) (string, error) {
+	args = append([]string{"config", "--get"}, args...)
+	cmd := exec.Command("git", append([]string{"-C", worktreePath}, args...)...)
+	output, err := logging.CombinedOutput(cmd)
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		return "", nil
+	}
+	if err != nil {
+		return "", arborerrors.Wrap(arborerrors.ErrGitOperationFailed, fmt.Errorf("git %s failed: %w\n%s", strings.Join(args, " "), err, string(output)))
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+func gitOutput(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	output, err := logging.CombinedOutput(cmd)
+	if err != nil {
+		return "", arborerrors.Wrap(arborerrors.ErrGitOperationFailed, fmt.Errorf("git %s failed: %w\n%s", strings.Join(args, " "), err, string(output)))
+	}
+	return strings.TrimSpace(string(output)), nil
+}
@@ -0,0 +1,45 @@
+package git
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorktreeConfig(t *testing.T) {
+	barePath, _ := createTestRepo(t)
+	projectDir := filepath.Dir(barePath)
+
+	mainPath := filepath.Join(projectDir, "main")
+	require.NoError(t, CreateWorktree(barePath, mainPath, "main", ""))
+	featurePath := filepath.Join(projectDir, "feature")
+	require.NoError(t, CreateWorktree(barePath, featurePath, "feature", "main"))
+
+	require.NoError(t, EnableWorktreeConfig(featurePath))
+	require.NoError(t, EnableWorktreeConfig(featurePath), "enabling twice should be a no-op")
+	require.NoError(t, SetWorktreeConfig(featurePath, "user.email", "me@work.test"))
+
+	email, err := GetConfig(featurePath, "user.email")
+	require.NoError(t, err)
+	assert.Equal(t, "me@work.test", email)
+
+	email, err = GetConfig(mainPath, "user.email")
+	require.NoError(t, err)
+	assert.NotEqual(t, "me@work.test", email, "other worktrees keep their config")
+
+	t.Run("worktrees are not treated as bare", func(t *testing.T) {
+		for _, dir := range []string{mainPath, featurePath} {
+			output, err := exec.Command("git", "-C", dir, "rev-parse", "--is-bare-repository").Output()
+			require.NoError(t, err)
+			assert.Equal(t, "false", strings.TrimSpace(string(output)), dir)
+		}
+
+		output, err := exec.Command("git", "-C", barePath, "rev-parse", "--is-bare-repository").Output()
+		require.NoError(t, err)
+		assert.Equal(t, "true", strings.TrimSpace(string(output)))
+	})
+}
@@ -0,0 +1,220 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	arborerrors "github.com/michaeldyrynda/arbor/internal/errors"
+	"github.com/michaeldyrynda/arbor/internal/logging"
+)
+
+// convertDir holds a clone's files while ConvertClone moves them into the
+// new worktree
+const convertDir = ".arbor-convert"
+
+// inProgressStates are files in a .git directory left by an operation that
+// has not finished
+var inProgressStates = []string{"MERGE_HEAD", "CHERRY_PICK_HEAD", "REVERT_HEAD", "BISECT_LOG", "rebase-merge", "rebase-apply"}
+
+// IsWorkingClone reports whether path is the top of a regular, non-bare
+// clone: it has a .git directory rather than the .git file of a worktree or
+// submodule
+func IsWorkingClone(path string) bool {
+	info, err := os.Stat(filepath.Join(path, ".git"))
+	return err == nil && info.IsDir()
+}
+
+// CurrentBranch returns the branch checked out in the worktree at path. A
+// detached HEAD is an error.
+func CurrentBranch(worktreePath string) (string, error) {
+	cmd := exec.Command("git", "-C", worktreePath, "symbolic-ref", "--short", "-q", "HEAD")
+	output, err := logging.Output(cmd)
+	if err != nil {
+		return "", fmt.Errorf("HEAD is detached in %s; check out a branch first", worktreePath)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// CheckConvertible returns why the clone at clonePath cannot be converted
+// by ConvertClone without losing work, or nil when it can. It returns the
+// checked out branch, which becomes the clone's worktree.
+func CheckConvertible(clonePath string) (string, error) {
+	if !IsWorkingClone(clonePath) {
+		return "", fmt.Errorf("%s is not the top of a git clone", clonePath)
+	}
+
+	branch, err := CurrentBranch(clonePath)
+	if err != nil {
+		return "", err
+	}
+
+	for _, state := range inProgressStates {
+		if _, err := os.Stat(filepath.Join(clonePath, ".git", state)); err == nil {
+			return "", fmt.Errorf("%s has a merge, rebase, cherry-pick or bisect in progress; finish or abort it first", clonePath)
+		}
+	}
+
+	dirty, err := IsDirty(clonePath)
+	if err != nil {
+		return "", err
+	}
+	if dirty {
+		return "", fmt.Errorf("%s has uncommitted or untracked changes; commit or stash them first", clonePath)
+	}
+
+	if _, err := os.Stat(filepath.Join(clonePath, ".gitmodules")); err == nil {
+		return "", fmt.Errorf("%s has submodules, which cannot be moved into a worktree; clone it with arbor init instead", clonePath)
+	}
+
+	worktrees, err := ListWorktrees(filepath.Join(clonePath, ".git"))
+	if err != nil {
+		return "", err
+	}
+	if len(worktrees) > 1 {
+		return "", fmt.Errorf("%s already has linked worktrees; remove them first", clonePath)
+	}
+
+	for _, name := range []string{".bare", convertDir} {
+		if _, err := os.Stat(filepath.Join(clonePath, name)); err == nil {
+			return "", fmt.Errorf("%s already contains %s", clonePath, name)
+		}
+	}
+	return branch, nil
+}
+
+// ConvertClone turns the regular clone at clonePath into an arbor project in
+// place. Its .git directory becomes clonePath/.bare, and its files, ignored
+// ones such as .env and installed dependencies included, move into a
+// worktree for branch at clonePath/folder. Check the clone with
+// CheckConvertible first. It returns the bare repository's path. When a step
+// fails, the clone is restored to its original layout.
+func ConvertClone(clonePath, branch, folder string) (_ string, err error) {
+	c := &cloneConversion{
+		clonePath:    clonePath,
+		barePath:     filepath.Join(clonePath, ".bare"),
+		stagingPath:  filepath.Join(clonePath, convertDir),
+		worktreePath: filepath.Join(clonePath, folder),
+	}
+
+	entries, err := os.ReadDir(clonePath)
+	if err != nil {
+		return "", fmt.Errorf("reading %s: %w", clonePath, err)
+	}
+
+	if err := os.Rename(filepath.Join(clonePath, ".git"), c.barePath); err != nil {
+		return "", fmt.Errorf("moving .git to .bare: %w", err)
+	}
+	defer func() {
+		if err == nil {
+			return
+		}
+		if restoreErr := c.restore(); restoreErr != nil {
+			err = fmt.Errorf("%w\nrestoring %s also failed, so it may need fixing by hand: %v", err, clonePath, restoreErr)
+		}
+	}()
+
+	if _, err := gitOutput(c.barePath, "config", "core.bare", "true"); err != nil {
+		return "", err
+	}
+
+	if err := os.Mkdir(c.stagingPath, 0755); err != nil {
+		return "", fmt.Errorf("creating %s: %w", c.stagingPath, err)
+	}
+	c.staged = true
+	for _, entry := range entries {
+		if entry.Name() == ".git" {
+			continue
+		}
+		if err := os.Rename(filepath.Join(clonePath, entry.Name()), filepath.Join(c.stagingPath, entry.Name())); err != nil {
+			return "", fmt.Errorf("moving %s: %w", entry.Name(), err)
+		}
+		c.moved = append(c.moved, entry.Name())
+	}
+
+	// git worktree add needs an empty directory, so the worktree is added
+	// without files and its .git file moved to where the files are
+	cmd := exec.Command("git", "-C", c.barePath, "worktree", "add", "--no-checkout", c.worktreePath, branch)
+	if output, err := logging.CombinedOutput(cmd); err != nil {
+		return "", arborerrors.Wrap(arborerrors.ErrGitOperationFailed, fmt.Errorf("git worktree add failed: %w\n%s", err, string(output)))
+	}
+	c.added = true
+	if err := os.Rename(filepath.Join(c.worktreePath, ".git"), filepath.Join(c.stagingPath, ".git")); err != nil {
+		return "", fmt.Errorf("moving worktree .git file: %w", err)
+	}
+	if err := os.Remove(c.worktreePath); err != nil {
+		return "", fmt.Errorf("removing %s: %w", c.worktreePath, err)
+	}
+	if err := os.Rename(c.stagingPath, c.worktreePath); err != nil {
+		return "", fmt.Errorf("moving files to %s: %w", c.worktreePath, err)
+	}
+	c.finished = true
+
+	// Rebuild the index, which --no-checkout left empty, from HEAD
+	if _, err := gitOutput(c.worktreePath, "reset", "-q"); err != nil {
+		return "", err
+	}
+	return c.barePath, nil
+}
+
+// cloneConversion tracks how far ConvertClone got, so a failed conversion
+// can be undone
+type cloneConversion struct {
+	clonePath    string
+	barePath     string
+	stagingPath  string
+	worktreePath string
+
+	// staged is set once the staging directory exists
+	staged bool
+	// moved lists the clone's entries moved into the staging directory
+	moved []string
+	// added is set once git has added the worktree
+	added bool
+	// finished is set once the staging directory became the worktree
+	finished bool
+}
+
+// restore undoes a partial conversion in reverse: the files move back to the
+// top of the clone, the worktree is dropped and .bare becomes .git again
+func (c *cloneConversion) restore() error {
+	if c.finished {
+		if err := os.Rename(c.worktreePath, c.stagingPath); err != nil {
+			return fmt.Errorf("moving files back from %s: %w", c.worktreePath, err)
+		}
+	}
+	if c.added {
+		if err := os.Remove(filepath.Join(c.stagingPath, ".git")); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("removing worktree .git file: %w", err)
+		}
+		if err := os.RemoveAll(c.worktreePath); err != nil {
+			return fmt.Errorf("removing %s: %w", c.worktreePath, err)
+		}
+	}
+
+	for _, name := range c.moved {
+		if err := os.Rename(filepath.Join(c.stagingPath, name), filepath.Join(c.clonePath, name)); err != nil {
+			return fmt.Errorf("moving %s back: %w", name, err)
+		}
+	}
+	if c.staged {
+		if err := os.Remove(c.stagingPath); err != nil {
+			return fmt.Errorf("removing %s: %w", c.stagingPath, err)
+		}
+	}
+
+	if c.added {
+		if _, err := gitOutput(c.barePath, "worktree", "prune"); err != nil {
+			return err
+		}
+	}
+	if _, err := gitOutput(c.barePath, "config", "core.bare", "false"); err != nil {
+		return err
+	}
+	if err := os.Rename(c.barePath, filepath.Join(c.clonePath, ".git")); err != nil {
+		return fmt.Errorf("moving .bare back to .git: %w", err)
+	}
+	return nil
+}
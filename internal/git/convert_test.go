@@ -0,0 +1,130 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createWorkingClone returns a regular clone with a committed file and an
+// ignored .env
+func createWorkingClone(t *testing.T) string {
+	t.Helper()
+	_, repoDir := createTestRepo(t)
+	// commitFile writes the message as the file's content
+	commitFile(t, repoDir, ".gitignore", ".env")
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, ".env"), []byte("APP_KEY=secret\n"), 0644))
+	return repoDir
+}
+
+func TestCheckConvertible(t *testing.T) {
+	t.Run("clean clone", func(t *testing.T) {
+		clonePath := createWorkingClone(t)
+
+		branch, err := CheckConvertible(clonePath)
+		require.NoError(t, err)
+		assert.Equal(t, "main", branch)
+	})
+
+	t.Run("uncommitted changes", func(t *testing.T) {
+		clonePath := createWorkingClone(t)
+		require.NoError(t, os.WriteFile(filepath.Join(clonePath, "README.md"), []byte("changed"), 0644))
+
+		_, err := CheckConvertible(clonePath)
+		assert.ErrorContains(t, err, "uncommitted or untracked changes")
+	})
+
+	t.Run("untracked files", func(t *testing.T) {
+		clonePath := createWorkingClone(t)
+		require.NoError(t, os.WriteFile(filepath.Join(clonePath, "notes.txt"), []byte("wip"), 0644))
+
+		_, err := CheckConvertible(clonePath)
+		assert.ErrorContains(t, err, "uncommitted or untracked changes")
+	})
+
+	t.Run("detached HEAD", func(t *testing.T) {
+		clonePath := createWorkingClone(t)
+		require.NoError(t, exec.Command("git", "-C", clonePath, "checkout", "-q", "--detach").Run())
+
+		_, err := CheckConvertible(clonePath)
+		assert.ErrorContains(t, err, "HEAD is detached")
+	})
+
+	t.Run("linked worktrees", func(t *testing.T) {
+		clonePath := createWorkingClone(t)
+		linked := filepath.Join(t.TempDir(), "linked")
+		require.NoError(t, exec.Command("git", "-C", clonePath, "worktree", "add", "-q", "-b", "other", linked).Run())
+
+		_, err := CheckConvertible(clonePath)
+		assert.ErrorContains(t, err, "linked worktrees")
+	})
+
+	t.Run("not a clone", func(t *testing.T) {
+		_, err := CheckConvertible(t.TempDir())
+		assert.ErrorContains(t, err, "not the top of a git clone")
+	})
+}
+
+func TestConvertClone(t *testing.T) {
+	clonePath := createWorkingClone(t)
+
+	branch, err := CheckConvertible(clonePath)
+	require.NoError(t, err)
+
+	barePath, err := ConvertClone(clonePath, branch, "main")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(clonePath, ".bare"), barePath)
+
+	worktreePath := filepath.Join(clonePath, "main")
+	assert.FileExists(t, filepath.Join(worktreePath, "README.md"))
+	assert.FileExists(t, filepath.Join(worktreePath, ".env"), "ignored files move with the worktree")
+	assert.NoDirExists(t, filepath.Join(clonePath, ".git"))
+	assert.NoDirExists(t, filepath.Join(clonePath, convertDir))
+
+	dirty, err := IsDirty(worktreePath)
+	require.NoError(t, err)
+	assert.False(t, dirty)
+
+	worktrees, err := ListWorktrees(barePath)
+	require.NoError(t, err)
+	var paths []string
+	for _, wt := range worktrees {
+		paths = append(paths, wt.Path)
+	}
+	assert.Contains(t, paths, worktreePath)
+
+	found, err := FindBarePath(worktreePath)
+	require.NoError(t, err)
+	assert.Equal(t, barePath, found)
+}
+
+func TestConvertClone_RestoresCloneOnFailure(t *testing.T) {
+	clonePath := createWorkingClone(t)
+
+	_, err := ConvertClone(clonePath, "missing-branch", "main")
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "git worktree add failed")
+
+	assert.DirExists(t, filepath.Join(clonePath, ".git"))
+	assert.NoDirExists(t, filepath.Join(clonePath, ".bare"))
+	assert.NoDirExists(t, filepath.Join(clonePath, convertDir))
+	assert.NoDirExists(t, filepath.Join(clonePath, "main"))
+	assert.FileExists(t, filepath.Join(clonePath, "README.md"))
+	assert.FileExists(t, filepath.Join(clonePath, ".env"))
+
+	bare, err := gitOutput(clonePath, "config", "core.bare")
+	require.NoError(t, err)
+	assert.Equal(t, "false", bare)
+
+	dirty, err := IsDirty(clonePath)
+	require.NoError(t, err)
+	assert.False(t, dirty)
+
+	branch, err := CheckConvertible(clonePath)
+	require.NoError(t, err, "the restored clone can be converted again")
+	assert.Equal(t, "main", branch)
+}
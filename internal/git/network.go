@@ -0,0 +1,249 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"time"
+
+	arborerrors "github.com/michaeldyrynda/arbor/internal/errors"
+	"github.com/michaeldyrynda/arbor/internal/logging"
+)
+
+// RetryPolicy controls how git network operations are retried after a
+// transient network failure
+type RetryPolicy struct {
+	// Retries is how many times to retry after the first attempt
+	Retries int
+	// Delay is the wait before the first retry, doubling after each one
+	Delay time.Duration
+}
+
+// DefaultRetryPolicy retries twice, after one and then two seconds
+var DefaultRetryPolicy = RetryPolicy{Retries: 2, Delay: time.Second}
+
+// NetworkRetry is the policy CloneRepo, FetchRef and Push use. The CLI
+// sets it from the network section of the global config.
+var NetworkRetry = DefaultRetryPolicy
+
+// sleep waits between retries, and is replaced in tests
+var sleep = time.Sleep
+
+// FailureKind is the cause of a failed git network operation, as told from
+// git's output
+type FailureKind int
+
+const (
+	FailureUnknown FailureKind = iota
+	// FailureNetwork is a transient failure reaching the remote, which is
+	// retried
+	FailureNetwork
+	// FailureAuth is a rejected or missing credential
+	FailureAuth
+	// FailureNotFound is a repository or ref the remote does not have
+	FailureNotFound
+)
+
+func (k FailureKind) String() string {
+	switch k {
+	case FailureNetwork:
+		return "network error"
+	case FailureAuth:
+		return "authentication failed"
+	case FailureNotFound:
+		return "not found"
+	}
+	return "unknown error"
+}
+
+// failurePatterns maps lowercase fragments of git and ssh error output to the
+// kind of failure they report. Authentication is checked first, as hosts such
+// as GitHub answer a private repository without credentials with "not found".
+var failurePatterns = []struct {
+	kind      FailureKind
+	fragments []string
+}{
+	{FailureAuth, []string{
+		"authentication failed",
+		"permission denied (publickey",
+		"could not read username",
+		"could not read password",
+		"terminal prompts disabled",
+		"invalid username or password",
+		"http basic: access denied",
+		"the requested url returned error: 401",
+		"the requested url returned error: 403",
+		"host key verification failed",
+	}},
+	{FailureNotFound, []string{
+		"repository not found",
+		"does not appear to be a git repository",
+		"does not exist",
+		"couldn't find remote ref",
+		"the requested url returned error: 404",
+		"project you were looking for could not be found",
+	}},
+	{FailureNetwork, []string{
+		"could not resolve host",
+		"could not resolve hostname",
+		"failed to connect",
+		"connection timed out",
+		"connection refused",
+		"connection reset",
+		"operation timed out",
+		"network is unreachable",
+		"remote end hung up unexpectedly",
+		"early eof",
+		"unexpected disconnect",
+		"rpc failed",
+		"gnutls_handshake",
+		"ssl_error",
+		"tls connection",
+		"the requested url returned error: 429",
+		"the requested url returned error: 500",
+		"the requested url returned error: 502",
+		"the requested url returned error: 503",
+		"the requested url returned error: 504",
+	}},
+}
+
+// ClassifyFailure tells from the output of a failed git command whether it
+// failed to reach the remote, was refused credentials or asked for something
+// the remote does not have
+func ClassifyFailure(output string) FailureKind {
+	output = strings.ToLower(output)
+	for _, pattern := range failurePatterns {
+		for _, fragment := range pattern.fragments {
+			if strings.Contains(output, fragment) {
+				return pattern.kind
+			}
+		}
+	}
+	return FailureUnknown
+}
+
+// RemoteError is a failed git clone, fetch or push. Its message ends with a
+// hint on how to fix the failure when its kind is known.
+type RemoteError struct {
+	// Op is the git command, e.g. clone
+	Op string
+	// Remote is the URL of the remote, when known
+	Remote   string
+	Kind     FailureKind
+	Output   string
+	Attempts int
+	Err      error
+}
+
+func (e *RemoteError) Error() string {
+	msg := fmt.Sprintf("git %s failed: %v", e.Op, e.Err)
+	if e.Kind != FailureUnknown {
+		msg = fmt.Sprintf("git %s failed (%s): %v", e.Op, e.Kind, e.Err)
+	}
+	if e.Attempts > 1 {
+		msg += fmt.Sprintf(", after %d attempts", e.Attempts)
+	}
+	if e.Output != "" {
+		msg += "\n" + e.Output
+	}
+	if hint := e.Hint(); hint != "" {
+		msg += "\nhint: " + hint
+	}
+	return msg
+}
+
+func (e *RemoteError) Unwrap() error {
+	return e.Err
+}
+
+// Hint suggests how to fix the failure, or returns "" when its kind is not
+// known
+func (e *RemoteError) Hint() string {
+	switch e.Kind {
+	case FailureAuth:
+		return authHint(e.Remote)
+	case FailureNotFound:
+		if e.Op == "clone" {
+			return "check the repository name, and that your account can access it"
+		}
+		return "check the branch exists on origin, and that your account can access the repository"
+	case FailureNetwork:
+		return "check your network connection; set network.retries in the global arbor.yaml to retry more"
+	}
+	return ""
+}
+
+// authHint suggests how to sign in to the host of remote
+func authHint(remote string) string {
+	switch {
+	case strings.HasPrefix(remote, "git@"), strings.HasPrefix(remote, "ssh://"):
+		return "check your SSH key is added to the host and loaded, e.g. with ssh-add -l"
+	case strings.Contains(remote, "github"):
+		return "run gh auth login, or check your git credentials for GitHub"
+	case strings.Contains(remote, "gitlab"):
+		return "run glab auth login, or check your git credentials for GitLab"
+	}
+	return "check your git credentials for the remote"
+}
+
+// runRemote runs the git command built by newCmd, retrying it under
+// NetworkRetry while it fails with a network error. reset, when set, runs
+// before each retry to undo a partial attempt.
+func runRemote(op, remote string, newCmd func() *exec.Cmd, reset func() error) error {
+	delay := NetworkRetry.Delay
+	for attempt := 1; ; attempt++ {
+		output, err := runWithProgress(newCmd())
+		if err == nil {
+			return nil
+		}
+
+		output = stripProgress(output)
+		remoteErr := &RemoteError{
+			Op:       op,
+			Remote:   remote,
+			Kind:     ClassifyFailure(output),
+			Output:   strings.TrimSpace(output),
+			Attempts: attempt,
+			Err:      err,
+		}
+		if remoteErr.Kind != FailureNetwork || attempt > NetworkRetry.Retries {
+			return arborerrors.Wrap(arborerrors.ErrGitOperationFailed, remoteErr)
+		}
+
+		logging.Infof("git %s failed with a network error, retrying in %s (%d of %d)", op, delay, attempt, NetworkRetry.Retries)
+		sleep(delay)
+		delay *= 2
+		if reset != nil {
+			if err := reset(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// runWithProgress runs cmd and returns its combined output, copying what it
+// writes to stderr, where git reports progress, to ProgressOutput when set
+func runWithProgress(cmd *exec.Cmd) (string, error) {
+	if ProgressOutput == nil {
+		output, err := logging.CombinedOutput(cmd)
+		return string(output), err
+	}
+
+	// Separate buffers, as stdout and stderr are copied concurrently
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = io.MultiWriter(&stderr, ProgressOutput)
+	err := logging.Run(cmd)
+	return stdout.String() + stderr.String(), err
+}
+
+// originURL returns the URL of origin in the repository at dir
+func originURL(dir string) (string, error) {
+	url, err := gitOutput(dir, "remote", "get-url", "origin")
+	if err != nil {
+		return "", fmt.Errorf("reading origin remote: %w", err)
+	}
+	return url, nil
+}
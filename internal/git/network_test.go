@@ -0,0 +1,144 @@
+package git
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	arborerrors "github.com/michaeldyrynda/arbor/internal/errors"
+)
+
+func TestClassifyFailure(t *testing.T) {
+	tests := []struct {
+		output string
+		want   FailureKind
+	}{
+		{"fatal: unable to access 'https://github.com/acme/app.git/': Could not resolve host: github.com", FailureNetwork},
+		{"ssh: connect to host github.com port 22: Connection timed out\nfatal: Could not read from remote repository.", FailureNetwork},
+		{"error: RPC failed; curl 56 GnuTLS recv error (-9)\nfatal: early EOF", FailureNetwork},
+		{"fatal: unable to access 'https://example.com/app.git/': The requested URL returned error: 503", FailureNetwork},
+		{"git@github.com: Permission denied (publickey).\nfatal: Could not read from remote repository.", FailureAuth},
+		{"fatal: could not read Username for 'https://github.com': terminal prompts disabled", FailureAuth},
+		{"remote: HTTP Basic: Access denied\nfatal: Authentication failed for 'https://gitlab.com/acme/app.git/'", FailureAuth},
+		{"remote: Repository not found.\nfatal: repository 'https://github.com/acme/missing.git/' not found", FailureNotFound},
+		{"fatal: couldn't find remote ref refs/heads/missing", FailureNotFound},
+		{"fatal: '/tmp/nowhere' does not appear to be a git repository", FailureNotFound},
+		{"fatal: destination path 'app' already exists and is not an empty directory.", FailureUnknown},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, ClassifyFailure(tt.output), tt.output)
+	}
+}
+
+func TestRemoteError_Hint(t *testing.T) {
+	err := &RemoteError{Op: "clone", Remote: "https://github.com/acme/app.git", Kind: FailureAuth, Err: errors.New("exit status 128")}
+	assert.Contains(t, err.Hint(), "gh auth login")
+	assert.Contains(t, err.Error(), "git clone failed (authentication failed): exit status 128")
+	assert.Contains(t, err.Error(), "\nhint: run gh auth login")
+
+	err.Remote = "git@github.com:acme/app.git"
+	assert.Contains(t, err.Hint(), "ssh-add")
+
+	err.Remote = "https://gitlab.com/acme/app.git"
+	assert.Contains(t, err.Hint(), "glab auth login")
+
+	err = &RemoteError{Op: "fetch", Kind: FailureNotFound, Err: errors.New("exit status 128")}
+	assert.Contains(t, err.Hint(), "branch exists on origin")
+
+	err = &RemoteError{Op: "push", Kind: FailureUnknown, Err: errors.New("exit status 1"), Output: "rejected"}
+	assert.Empty(t, err.Hint())
+	assert.Equal(t, "git push failed: exit status 1\nrejected", err.Error())
+}
+
+// withRetryPolicy sets NetworkRetry for the test and records the delays
+// slept between attempts instead of waiting
+func withRetryPolicy(t *testing.T, policy RetryPolicy) *[]time.Duration {
+	t.Helper()
+	previousPolicy, previousSleep := NetworkRetry, sleep
+	t.Cleanup(func() {
+		NetworkRetry, sleep = previousPolicy, previousSleep
+	})
+
+	var delays []time.Duration
+	NetworkRetry = policy
+	sleep = func(d time.Duration) { delays = append(delays, d) }
+	return &delays
+}
+
+// failingCommand returns a command that prints output to stderr and fails,
+// counting its runs in counter
+func failingCommand(t *testing.T, output string) (func() *exec.Cmd, func() int) {
+	t.Helper()
+	counter := filepath.Join(t.TempDir(), "runs")
+	newCmd := func() *exec.Cmd {
+		return exec.Command("sh", "-c", `echo x >> "$1"; echo "$2" >&2; exit 128`, "sh", counter, output)
+	}
+	runs := func() int {
+		out, err := os.ReadFile(counter)
+		require.NoError(t, err)
+		return strings.Count(string(out), "\n")
+	}
+	return newCmd, runs
+}
+
+func TestRunRemote_RetriesNetworkFailures(t *testing.T) {
+	delays := withRetryPolicy(t, RetryPolicy{Retries: 2, Delay: time.Second})
+	newCmd, runs := failingCommand(t, "fatal: Could not resolve host: github.com")
+
+	resets := 0
+	err := runRemote("clone", "https://github.com/acme/app.git", newCmd, func() error {
+		resets++
+		return nil
+	})
+
+	var remoteErr *RemoteError
+	require.ErrorAs(t, err, &remoteErr)
+	assert.ErrorIs(t, err, arborerrors.ErrGitOperationFailed)
+	assert.Equal(t, FailureNetwork, remoteErr.Kind)
+	assert.Equal(t, 3, remoteErr.Attempts)
+	assert.Equal(t, 3, runs())
+	assert.Equal(t, 2, resets)
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second}, *delays)
+	assert.Contains(t, err.Error(), "after 3 attempts")
+}
+
+func TestRunRemote_DoesNotRetryOtherFailures(t *testing.T) {
+	delays := withRetryPolicy(t, RetryPolicy{Retries: 2, Delay: time.Second})
+	newCmd, runs := failingCommand(t, "remote: Repository not found.")
+
+	err := runRemote("clone", "https://github.com/acme/app.git", newCmd, nil)
+
+	var remoteErr *RemoteError
+	require.ErrorAs(t, err, &remoteErr)
+	assert.Equal(t, FailureNotFound, remoteErr.Kind)
+	assert.Equal(t, 1, runs())
+	assert.Empty(t, *delays)
+}
+
+func TestRunRemote_RetriesDisabled(t *testing.T) {
+	withRetryPolicy(t, RetryPolicy{Retries: 0, Delay: time.Second})
+	newCmd, runs := failingCommand(t, "fatal: Could not resolve host: github.com")
+
+	require.Error(t, runRemote("fetch", "", newCmd, nil))
+	assert.Equal(t, 1, runs())
+}
+
+func TestCloneRepo_NotFound(t *testing.T) {
+	withRetryPolicy(t, DefaultRetryPolicy)
+	dir := t.TempDir()
+
+	err := CloneRepo(filepath.Join(dir, "missing"), filepath.Join(dir, ".bare"))
+
+	var remoteErr *RemoteError
+	require.ErrorAs(t, err, &remoteErr)
+	assert.Equal(t, FailureNotFound, remoteErr.Kind)
+	assert.Equal(t, 1, remoteErr.Attempts)
+}
@@ -0,0 +1,89 @@
+package git
+
+import (
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ProgressOutput, when set, receives git's progress output from clones and
+// fetches, which then run with --progress so git reports it without a
+// terminal. The CLI sets it while showing a progress bar.
+var ProgressOutput io.Writer
+
+// Progress is one update of git's progress output
+type Progress struct {
+	// Phase is what git is doing, e.g. Receiving objects
+	Phase string
+	// Percent is -1 when git only counts, as it does while enumerating
+	Percent int
+	Current int
+	Total   int
+	// Throughput is the transfer rate, e.g. 1.20 MiB/s, when git reports one
+	Throughput string
+	Done       bool
+}
+
+// progressPattern matches lines such as
+// "Receiving objects:  45% (450/1000), 1.20 MiB | 600.00 KiB/s" and
+// "remote: Enumerating objects: 5, done."
+var progressPattern = regexp.MustCompile(`^(?:remote: )?([A-Z][a-z]+(?: [a-z]+)*):\s+(?:(\d+)% \((\d+)/(\d+)\)|(\d+))(.*)$`)
+
+// ParseProgress parses a line of git's progress output, reporting false for
+// any other line
+func ParseProgress(line string) (Progress, bool) {
+	m := progressPattern.FindStringSubmatch(strings.TrimSpace(line))
+	if m == nil {
+		return Progress{}, false
+	}
+
+	p := Progress{Phase: m[1], Percent: -1}
+	var err error
+	if m[2] != "" {
+		if p.Percent, err = strconv.Atoi(m[2]); err != nil {
+			return Progress{}, false
+		}
+		if p.Current, err = strconv.Atoi(m[3]); err != nil {
+			return Progress{}, false
+		}
+		if p.Total, err = strconv.Atoi(m[4]); err != nil {
+			return Progress{}, false
+		}
+	} else if p.Current, err = strconv.Atoi(m[5]); err != nil {
+		return Progress{}, false
+	}
+
+	rest := m[6]
+	if _, rate, ok := strings.Cut(rest, "| "); ok {
+		p.Throughput, _, _ = strings.Cut(rate, ",")
+		p.Throughput = strings.TrimSpace(p.Throughput)
+	}
+	p.Done = strings.HasSuffix(strings.TrimSpace(rest), "done.")
+	return p, true
+}
+
+// withProgress adds --progress after the git subcommand in args when
+// ProgressOutput is set
+func withProgress(subcommand string, args ...string) []string {
+	if ProgressOutput == nil {
+		return append([]string{subcommand}, args...)
+	}
+	return append([]string{subcommand, "--progress"}, args...)
+}
+
+// stripProgress drops git's progress updates from output, leaving the lines
+// that explain a failure
+func stripProgress(output string) string {
+	lines := strings.FieldsFunc(output, func(r rune) bool {
+		return r == '\r' || r == '\n'
+	})
+
+	kept := lines[:0]
+	for _, line := range lines {
+		if _, ok := ParseProgress(line); !ok {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
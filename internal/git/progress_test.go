@@ -0,0 +1,74 @@
+package git
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseProgress(t *testing.T) {
+	tests := []struct {
+		line string
+		want Progress
+	}{
+		{
+			"Receiving objects:  45% (450/1000), 1.20 MiB | 600.00 KiB/s",
+			Progress{Phase: "Receiving objects", Percent: 45, Current: 450, Total: 1000, Throughput: "600.00 KiB/s"},
+		},
+		{
+			"Receiving objects: 100% (1000/1000), 2.40 MiB | 1.10 MiB/s, done.",
+			Progress{Phase: "Receiving objects", Percent: 100, Current: 1000, Total: 1000, Throughput: "1.10 MiB/s", Done: true},
+		},
+		{
+			"remote: Compressing objects:  50% (2/4)        ",
+			Progress{Phase: "Compressing objects", Percent: 50, Current: 2, Total: 4},
+		},
+		{
+			"remote: Enumerating objects: 5, done.",
+			Progress{Phase: "Enumerating objects", Percent: -1, Current: 5, Done: true},
+		},
+		{
+			"Resolving deltas: 100% (10/10), done.",
+			Progress{Phase: "Resolving deltas", Percent: 100, Current: 10, Total: 10, Done: true},
+		},
+	}
+
+	for _, tt := range tests {
+		got, ok := ParseProgress(tt.line)
+		require.True(t, ok, tt.line)
+		assert.Equal(t, tt.want, got, tt.line)
+	}
+
+	for _, line := range []string{
+		"Cloning into bare repository '.bare'...",
+		"remote: Total 5 (delta 0), reused 0 (delta 0), pack-reused 0",
+		"fatal: repository 'https://github.com/acme/missing.git/' not found",
+		"Receiving objects: 45% (450/99999999999999999999)",
+	} {
+		_, ok := ParseProgress(line)
+		assert.False(t, ok, line)
+	}
+}
+
+func TestStripProgress(t *testing.T) {
+	output := "Cloning into bare repository '.bare'...\nReceiving objects:  10% (1/10)\rReceiving objects:  20% (2/10)\r\nerror: RPC failed\nfatal: early EOF\n"
+
+	assert.Equal(t, "Cloning into bare repository '.bare'...\nerror: RPC failed\nfatal: early EOF", stripProgress(output))
+}
+
+func TestCloneRepo_ReportsProgress(t *testing.T) {
+	_, repoDir := createTestRepo(t)
+
+	var progress bytes.Buffer
+	ProgressOutput = &progress
+	t.Cleanup(func() { ProgressOutput = nil })
+
+	// A file:// URL clones over a transport, which reports progress
+	require.NoError(t, CloneRepo("file:///"+strings.TrimPrefix(filepath.ToSlash(repoDir), "/"), filepath.Join(t.TempDir(), ".bare")))
+
+	assert.Contains(t, progress.String(), "Receiving objects: 100%")
+}
@@ -0,0 +1,149 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/michaeldyrynda/arbor/internal/logging"
+)
+
+// IsDirty reports whether the worktree has uncommitted or untracked changes
+func IsDirty(worktreePath string) (bool, error) {
+	cmd := exec.Command("git", "-C", worktreePath, "status", "--porcelain")
+	output, err := logging.Output(cmd)
+	if err != nil {
+		return false, fmt.Errorf("git status failed: %w", err)
+	}
+	return strings.TrimSpace(string(output)) != "", nil
+}
+
+// IsIgnored reports whether path, relative to the worktree, is ignored by
+// git
+func IsIgnored(worktreePath, path string) bool {
+	cmd := exec.Command("git", "-C", worktreePath, "check-ignore", "-q", path)
+	return logging.Run(cmd) == nil
+}
+
+// AheadBehind counts the commits on the worktree's HEAD that are not on
+// base, and the commits on base that are not on HEAD
+func AheadBehind(worktreePath, base string) (ahead, behind int, err error) {
+	cmd := exec.Command("git", "-C", worktreePath, "rev-list", "--left-right", "--count", base+"...HEAD")
+	output, err := logging.Output(cmd)
+	if err != nil {
+		return 0, 0, fmt.Errorf("git rev-list failed: %w", err)
+	}
+
+	fields := strings.Fields(string(output))
+	if len(fields) != 2 {
+		return 0, 0, fmt.Errorf("unexpected git rev-list output: %q", string(output))
+	}
+
+	behind, err = strconv.Atoi(fields[0])
+	if err != nil {
+		return 0, 0, fmt.Errorf("parsing git rev-list output: %w", err)
+	}
+	ahead, err = strconv.Atoi(fields[1])
+	if err != nil {
+		return 0, 0, fmt.Errorf("parsing git rev-list output: %w", err)
+	}
+	return ahead, behind, nil
+}
+
+// Log returns the latest commits of the worktree as one line each
+func Log(worktreePath string, limit int) (string, error) {
+	cmd := exec.Command("git", "-C", worktreePath, "log", "--oneline", "--decorate", "-n", strconv.Itoa(limit))
+	output, err := logging.CombinedOutput(cmd)
+	if err != nil {
+		return "", fmt.Errorf("git log failed: %w\n%s", err, string(output))
+	}
+	return string(output), nil
+}
+
+// LastCommit returns the subject and commit date of the worktree's HEAD
+func LastCommit(worktreePath string) (string, time.Time, error) {
+	cmd := exec.Command("git", "-C", worktreePath, "log", "-1", "--format=%ct%x00%s")
+	output, err := logging.Output(cmd)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("git log failed: %w", err)
+	}
+
+	stamp, subject, ok := strings.Cut(strings.TrimSpace(string(output)), "\x00")
+	if !ok {
+		return "", time.Time{}, fmt.Errorf("unexpected git log output: %q", string(output))
+	}
+	seconds, err := strconv.ParseInt(stamp, 10, 64)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("parsing git log output: %w", err)
+	}
+	return subject, time.Unix(seconds, 0), nil
+}
+
+// LastActivity returns when files in the worktree were last changed: the
+// latest modification time of its uncommitted and untracked files, leaving
+// out those git ignores. It is zero when the worktree has no such files.
+func LastActivity(worktreePath string) (time.Time, error) {
+	cmd := exec.Command("git", "-C", worktreePath, "ls-files", "-z", "--modified", "--others", "--exclude-standard")
+	output, err := logging.Output(cmd)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("git ls-files failed: %w", err)
+	}
+
+	var latest time.Time
+	for _, name := range strings.Split(string(output), "\x00") {
+		if name == "" {
+			continue
+		}
+		// Deleted files are listed as modified but have nothing to stat
+		info, err := os.Stat(filepath.Join(worktreePath, name))
+		if err == nil && info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
+
+// HasUpstream reports whether branch tracks a remote branch. The tracking
+// config is checked rather than @{upstream}, which bare clones without a
+// fetch refspec cannot resolve.
+func HasUpstream(worktreePath, branch string) bool {
+	cmd := exec.Command("git", "-C", worktreePath, "config", "--get", "branch."+branch+".merge")
+	return logging.Run(cmd) == nil
+}
+
+// Push pushes the worktree's branch to origin, setting it as the upstream
+// when the branch does not track one yet. Transient network failures are
+// retried under NetworkRetry.
+func Push(worktreePath, branch string) error {
+	args := []string{"-C", worktreePath, "push"}
+	if !HasUpstream(worktreePath, branch) {
+		args = append(args, "--set-upstream", "origin", branch)
+	}
+
+	remote, err := originURL(worktreePath)
+	if err != nil {
+		return err
+	}
+	return runRemote("push", remote, func() *exec.Cmd {
+		return exec.Command("git", args...)
+	}, nil)
+}
+
+// FetchRef fetches ref from origin into a new local branch, so a worktree can
+// be created for a pull request head that only exists remotely. Transient
+// network failures are retried under NetworkRetry, and progress is reported
+// to ProgressOutput.
+func FetchRef(barePath, ref, branch string) error {
+	refspec := fmt.Sprintf("%s:refs/heads/%s", ref, branch)
+	remote, err := originURL(barePath)
+	if err != nil {
+		return err
+	}
+	return runRemote("fetch", remote, func() *exec.Cmd {
+		return exec.Command("git", append([]string{"-C", barePath}, withProgress("fetch", "origin", refspec)...)...)
+	}, nil)
+}
@@ -0,0 +1,131 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func commitFile(t *testing.T, dir, name, message string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(message), 0644))
+	for _, args := range [][]string{
+		{"add", name},
+		{"-c", "user.email=test@example.com", "-c", "user.name=Test User", "commit", "-m", message},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		output, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(output))
+	}
+}
+
+func TestWorktreeStatus(t *testing.T) {
+	barePath, _ := createTestRepo(t)
+	projectDir := filepath.Dir(barePath)
+
+	mainPath := filepath.Join(projectDir, "main")
+	require.NoError(t, CreateWorktree(barePath, mainPath, "main", ""))
+	featurePath := filepath.Join(projectDir, "feature")
+	require.NoError(t, CreateWorktree(barePath, featurePath, "feature", "main"))
+
+	t.Run("clean worktree", func(t *testing.T) {
+		dirty, err := IsDirty(featurePath)
+		require.NoError(t, err)
+		assert.False(t, dirty)
+	})
+
+	t.Run("untracked file makes the worktree dirty", func(t *testing.T) {
+		untracked := filepath.Join(featurePath, "notes.txt")
+		require.NoError(t, os.WriteFile(untracked, []byte("wip"), 0644))
+		defer os.Remove(untracked)
+
+		dirty, err := IsDirty(featurePath)
+		require.NoError(t, err)
+		assert.True(t, dirty)
+	})
+
+	t.Run("ahead and behind the base branch", func(t *testing.T) {
+		commitFile(t, featurePath, "feature.txt", "Add feature")
+		commitFile(t, featurePath, "more.txt", "Add more")
+		commitFile(t, mainPath, "main.txt", "Update main")
+
+		ahead, behind, err := AheadBehind(featurePath, "main")
+		require.NoError(t, err)
+		assert.Equal(t, 2, ahead)
+		assert.Equal(t, 1, behind)
+	})
+
+	t.Run("log lists recent commits", func(t *testing.T) {
+		log, err := Log(featurePath, 1)
+		require.NoError(t, err)
+		assert.Equal(t, 1, strings.Count(strings.TrimSpace(log), "\n")+1)
+		assert.Contains(t, log, "Add more")
+	})
+
+	t.Run("last commit", func(t *testing.T) {
+		subject, at, err := LastCommit(featurePath)
+		require.NoError(t, err)
+		assert.Equal(t, "Add more", subject)
+		assert.WithinDuration(t, time.Now(), at, time.Hour)
+	})
+
+	t.Run("unknown base", func(t *testing.T) {
+		_, _, err := AheadBehind(featurePath, "missing")
+		assert.Error(t, err)
+	})
+
+	t.Run("last activity", func(t *testing.T) {
+		at, err := LastActivity(featurePath)
+		require.NoError(t, err)
+		assert.True(t, at.IsZero(), "a clean worktree has no file activity")
+
+		changed := time.Now().Add(-72 * time.Hour).Truncate(time.Second)
+		untracked := filepath.Join(featurePath, "draft.txt")
+		require.NoError(t, os.WriteFile(untracked, []byte("wip"), 0644))
+		require.NoError(t, os.Chtimes(untracked, changed, changed))
+		defer os.Remove(untracked)
+
+		at, err = LastActivity(featurePath)
+		require.NoError(t, err)
+		assert.True(t, at.Equal(changed), "got %v, want %v", at, changed)
+	})
+}
+
+func TestPush_SetsUpstream(t *testing.T) {
+	barePath, repoDir := createTestRepo(t)
+	featurePath := filepath.Join(filepath.Dir(barePath), "feature")
+	require.NoError(t, CreateWorktree(barePath, featurePath, "feature", "main"))
+	commitFile(t, featurePath, "feature.txt", "Add feature")
+
+	assert.False(t, HasUpstream(featurePath, "feature"))
+	require.NoError(t, Push(featurePath, "feature"))
+	assert.True(t, HasUpstream(featurePath, "feature"))
+
+	cmd := exec.Command("git", "rev-parse", "--verify", "refs/heads/feature")
+	cmd.Dir = repoDir
+	assert.NoError(t, cmd.Run(), "origin should have the pushed branch")
+
+	commitFile(t, featurePath, "more.txt", "Add more")
+	assert.NoError(t, Push(featurePath, "feature"))
+}
+
+func TestFetchRef(t *testing.T) {
+	barePath, repoDir := createTestRepo(t)
+
+	cmd := exec.Command("git", "update-ref", "refs/pull/42/head", "HEAD")
+	cmd.Dir = repoDir
+	require.NoError(t, cmd.Run())
+
+	assert.False(t, BranchExists(barePath, "pr-42"))
+	require.NoError(t, FetchRef(barePath, "refs/pull/42/head", "pr-42"))
+	assert.True(t, BranchExists(barePath, "pr-42"))
+
+	assert.Error(t, FetchRef(barePath, "refs/pull/7/head", "pr-7"))
+}
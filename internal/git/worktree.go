@@ -7,10 +7,13 @@ import (
 	"os/exec"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/michaeldyrynda/arbor/internal/config"
 	arborerrors "github.com/michaeldyrynda/arbor/internal/errors"
+	"github.com/michaeldyrynda/arbor/internal/logging"
 )
 
 // Worktree represents a git worktree
@@ -20,6 +23,10 @@ type Worktree struct {
 	IsMain    bool
 	IsCurrent bool
 	IsMerged  bool
+
+	// IsCheckout marks the clone's own working tree in a repository that is
+	// not bare, which git cannot remove
+	IsCheckout bool
 }
 
 // CreateWorktree creates a new worktree from a branch
@@ -31,12 +38,12 @@ func CreateWorktree(barePath, worktreePath, branch, baseBranch string) error {
 
 	// Check if branch already exists
 	cmd := exec.Command("git", "-C", barePath, "rev-parse", "--verify", "--quiet", branch)
-	if err := cmd.Run(); err == nil {
+	if err := logging.Run(cmd); err == nil {
 		// Branch exists, just checkout
 		cmd = exec.Command("git", "-C", barePath, "worktree", "add", worktreePath, branch)
-		output, err := cmd.CombinedOutput()
+		output, err := logging.CombinedOutput(cmd)
 		if err != nil {
-			return fmt.Errorf("git worktree add failed: %w\n%s", err, string(output))
+			return arborerrors.Wrap(arborerrors.ErrGitOperationFailed, fmt.Errorf("git worktree add failed: %w\n%s", err, string(output)))
 		}
 		return nil
 	}
@@ -48,9 +55,9 @@ func CreateWorktree(barePath, worktreePath, branch, baseBranch string) error {
 
 	gitArgs := []string{"-C", barePath, "worktree", "add", "-b", branch, worktreePath, baseBranch}
 	cmd = exec.Command("git", gitArgs...)
-	output, err := cmd.CombinedOutput()
+	output, err := logging.CombinedOutput(cmd)
 	if err != nil {
-		return fmt.Errorf("git worktree add failed: %w\n%s", err, string(output))
+		return arborerrors.Wrap(arborerrors.ErrGitOperationFailed, fmt.Errorf("git worktree add failed: %w\n%s", err, string(output)))
 	}
 	return nil
 }
@@ -69,9 +76,9 @@ func RemoveWorktree(worktreePath string, force bool) error {
 	}
 
 	cmd := exec.Command("git", append([]string{"-C", barePath}, args...)...)
-	output, err := cmd.CombinedOutput()
+	output, err := logging.CombinedOutput(cmd)
 	if err != nil {
-		return fmt.Errorf("git worktree remove failed: %w\n%s", err, string(output))
+		return arborerrors.Wrap(arborerrors.ErrGitOperationFailed, fmt.Errorf("git worktree remove failed: %w\n%s", err, string(output)))
 	}
 	return nil
 }
@@ -79,7 +86,7 @@ func RemoveWorktree(worktreePath string, force bool) error {
 // ListWorktrees lists all worktrees in a bare repository
 func ListWorktrees(barePath string) ([]Worktree, error) {
 	cmd := exec.Command("git", "-C", barePath, "worktree", "list", "--porcelain")
-	output, err := cmd.Output()
+	output, err := logging.Output(cmd)
 	if err != nil {
 		return nil, err
 	}
@@ -89,6 +96,9 @@ func ListWorktrees(barePath string) ([]Worktree, error) {
 	var worktrees []Worktree
 	var currentPath string
 	var currentBranch string
+	// git lists the repository's own working tree, or the bare repository,
+	// first
+	entries := 0
 	lines := strings.Split(string(output), "\n")
 	for _, line := range lines {
 		line = strings.TrimSpace(line)
@@ -97,8 +107,11 @@ func ListWorktrees(barePath string) ([]Worktree, error) {
 		}
 
 		if strings.HasPrefix(line, "worktree ") {
+			entries++
 			currentPath = strings.TrimPrefix(line, "worktree ")
-			currentPath = strings.TrimSpace(currentPath)
+			// git for Windows prints C:/code/app, so convert to the
+			// platform's separators before comparing with other paths
+			currentPath = filepath.FromSlash(strings.TrimSpace(currentPath))
 			if !filepath.IsAbs(currentPath) && parentDir != "" {
 				currentPath = filepath.Join(parentDir, currentPath)
 			}
@@ -107,8 +120,9 @@ func ListWorktrees(barePath string) ([]Worktree, error) {
 			currentBranch = strings.TrimSpace(currentBranch)
 			if currentPath != "" && currentBranch != "" {
 				worktrees = append(worktrees, Worktree{
-					Path:   currentPath,
-					Branch: currentBranch,
+					Path:       currentPath,
+					Branch:     currentBranch,
+					IsCheckout: entries == 1,
 				})
 				currentPath = ""
 			}
@@ -200,19 +214,34 @@ func SortWorktrees(worktrees []Worktree, by string, reverse bool) []Worktree {
 	return sorted
 }
 
-// GetDefaultBranch returns the default branch name
+// GetDefaultBranch returns the default branch name: the branch origin/HEAD
+// points to, then, in a bare repository, the branch HEAD points to, as
+// cloning sets it to the remote's default. Repositories with neither fall
+// back to the first of DefaultBranchCandidates that exists.
 func GetDefaultBranch(barePath string) (string, error) {
-	// Try main first, then master, then HEAD
+	if branch := remoteHeadBranch(barePath); branch != "" {
+		return branch, nil
+	}
+	bare, err := gitOutput(barePath, "rev-parse", "--is-bare-repository")
+	if err != nil {
+		return "", err
+	}
+	if bare == "true" {
+		if branch, err := gitOutput(barePath, "symbolic-ref", "--quiet", "--short", "HEAD"); err == nil && BranchExists(barePath, branch) {
+			return branch, nil
+		}
+	}
+
 	for _, branch := range config.DefaultBranchCandidates {
 		cmd := exec.Command("git", "-C", barePath, "rev-parse", "--verify", "--quiet", "refs/heads/"+branch)
-		if err := cmd.Run(); err == nil {
+		if err := logging.Run(cmd); err == nil {
 			return branch, nil
 		}
 	}
 
 	// Fall back to symbolic-ref
 	cmd := exec.Command("git", "-C", barePath, "symbolic-ref", "HEAD", "--short")
-	output, err := cmd.Output()
+	output, err := logging.Output(cmd)
 	if err != nil {
 		return "", err
 	}
@@ -220,38 +249,43 @@ func GetDefaultBranch(barePath string) (string, error) {
 	return strings.TrimSpace(string(output)), nil
 }
 
-// CloneRepo clones a repository to a bare directory
-func CloneRepo(repoURL, barePath string) error {
-	if err := os.MkdirAll(barePath, 0755); err != nil {
-		return err
-	}
-
-	cmd := exec.Command("git", "clone", "--bare", repoURL, barePath)
-	output, err := cmd.CombinedOutput()
+// remoteHeadBranch returns the local branch origin/HEAD points to, or ""
+// when it is not set or there is no such local branch
+func remoteHeadBranch(barePath string) string {
+	ref, err := gitOutput(barePath, "symbolic-ref", "--quiet", "--short", "refs/remotes/origin/HEAD")
 	if err != nil {
-		return fmt.Errorf("git clone failed: %w\n%s", err, string(output))
+		return ""
 	}
-	return nil
+	branch := strings.TrimPrefix(ref, "origin/")
+	if !BranchExists(barePath, branch) {
+		return ""
+	}
+	return branch
 }
 
-// CloneRepoWithGH clones a repository using gh CLI (supports short format)
-func CloneRepoWithGH(repo, barePath string) error {
+// CloneRepo clones a repository to a bare directory, retrying transient
+// network failures under NetworkRetry and reporting progress to
+// ProgressOutput
+func CloneRepo(repoURL, barePath string) error {
 	if err := os.MkdirAll(barePath, 0755); err != nil {
 		return err
 	}
 
-	cmd := exec.Command("gh", "repo", "clone", repo, barePath, "--", "--bare")
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("gh repo clone failed: %w\n%s", err, string(output))
-	}
-	return nil
+	return runRemote("clone", repoURL, func() *exec.Cmd {
+		return exec.Command("git", withProgress("clone", "--bare", repoURL, barePath)...)
+	}, func() error {
+		// Start each retry from an empty directory
+		if err := os.RemoveAll(barePath); err != nil {
+			return err
+		}
+		return os.MkdirAll(barePath, 0755)
+	})
 }
 
 // IsMerged checks if a branch is merged into another branch
 func IsMerged(barePath, branch, targetBranch string) (bool, error) {
 	cmd := exec.Command("git", "-C", barePath, "merge-base", "--is-ancestor", branch, targetBranch)
-	err := cmd.Run()
+	err := logging.Run(cmd)
 	if err == nil {
 		return true, nil
 	}
@@ -270,7 +304,7 @@ func IsMerged(barePath, branch, targetBranch string) (bool, error) {
 // BranchExists checks if a branch exists in the repository
 func BranchExists(barePath, branch string) bool {
 	cmd := exec.Command("git", "-C", barePath, "rev-parse", "--verify", "--quiet", "refs/heads/"+branch)
-	return cmd.Run() == nil
+	return logging.Run(cmd) == nil
 }
 
 // DeleteBranch deletes a branch from the repository
@@ -284,7 +318,7 @@ func DeleteBranch(barePath, branch string, force bool) error {
 	args = append(args, branch)
 
 	cmd := exec.Command("git", append([]string{"-C", barePath}, args...)...)
-	output, err := cmd.CombinedOutput()
+	output, err := logging.CombinedOutput(cmd)
 	if err != nil {
 		return fmt.Errorf("deleting branch: %w\n%s", err, string(output))
 	}
@@ -294,9 +328,9 @@ func DeleteBranch(barePath, branch string, force bool) error {
 // PruneWorktrees prunes stale worktree refs from the repository
 func PruneWorktrees(barePath string) error {
 	cmd := exec.Command("git", "-C", barePath, "worktree", "prune")
-	output, err := cmd.CombinedOutput()
+	output, err := logging.CombinedOutput(cmd)
 	if err != nil {
-		return fmt.Errorf("git worktree prune failed: %w\n%s", err, string(output))
+		return arborerrors.Wrap(arborerrors.ErrGitOperationFailed, fmt.Errorf("git worktree prune failed: %w\n%s", err, string(output)))
 	}
 	return nil
 }
@@ -304,7 +338,7 @@ func PruneWorktrees(barePath string) error {
 // ListBranches lists all local branches in the repository (excluding current branch)
 func ListBranches(barePath string) ([]string, error) {
 	cmd := exec.Command("git", "-C", barePath, "branch", "--list")
-	output, err := cmd.Output()
+	output, err := logging.Output(cmd)
 	if err != nil {
 		return nil, err
 	}
@@ -333,7 +367,7 @@ func ListBranches(barePath string) ([]string, error) {
 // ListAllBranches lists all branches including current branch
 func ListAllBranches(barePath string) ([]string, error) {
 	cmd := exec.Command("git", "-C", barePath, "branch", "--list")
-	output, err := cmd.Output()
+	output, err := logging.Output(cmd)
 	if err != nil {
 		return nil, err
 	}
@@ -359,7 +393,7 @@ func ListAllBranches(barePath string) ([]string, error) {
 // ListRemoteBranches lists all remote branches in the repository
 func ListRemoteBranches(barePath string) ([]string, error) {
 	cmd := exec.Command("git", "-C", barePath, "branch", "-r", "--list")
-	output, err := cmd.Output()
+	output, err := logging.Output(cmd)
 	if err != nil {
 		return nil, err
 	}
@@ -376,30 +410,129 @@ func ListRemoteBranches(barePath string) ([]string, error) {
 }
 
 // FindBarePath finds the bare repository path from a worktree directory
-// by searching for .bare in the current directory or parent directories
+// by searching for .bare in the current directory or parent directories.
+// Without one, the repository shared by a plain git worktree setup, as git
+// rev-parse --git-common-dir reports it, is used instead: a bare repository,
+// or the .git of a clone that already has linked worktrees.
 func FindBarePath(worktreePath string) (string, error) {
 	absPath, err := filepath.Abs(worktreePath)
 	if err != nil {
 		return "", err
 	}
 
-	barePath := filepath.Join(absPath, ".bare")
-	if _, err := os.Stat(barePath); err == nil {
-		return barePath, nil
-	}
-
-	// Search parents
+	// Search the directory and its parents, stopping at the volume root,
+	// which is its own parent on every platform
 	current := absPath
 	for {
-		barePath = filepath.Join(current, ".bare")
-		if _, err := os.Stat(barePath); err == nil {
+		barePath := filepath.Join(current, ".bare")
+		if info, err := os.Stat(barePath); err == nil && info.IsDir() {
 			return barePath, nil
 		}
 
 		parent := filepath.Dir(current)
 		if parent == current {
-			return "", fmt.Errorf(".bare not found in %s or any parent directory: %w", absPath, arborerrors.ErrWorktreeNotFound)
+			break
 		}
 		current = parent
 	}
+
+	commonDir, inRepository := commonGitDir(absPath)
+	if commonDir != "" && (isBareRepository(commonDir) || hasLinkedWorktrees(commonDir)) {
+		return commonDir, nil
+	}
+	return "", &NotAProjectError{Dir: absPath, InRepository: inRepository}
+}
+
+// commonGitDir returns the repository directory shared by all worktrees of
+// the repository containing dir, and whether dir is in a repository at all
+func commonGitDir(dir string) (string, bool) {
+	output, err := logging.Output(exec.Command("git", "-C", dir, "rev-parse", "--git-common-dir"))
+	if err != nil {
+		return "", false
+	}
+	commonDir := filepath.FromSlash(strings.TrimSpace(string(output)))
+	if !filepath.IsAbs(commonDir) {
+		commonDir = filepath.Join(dir, commonDir)
+	}
+	return filepath.Clean(commonDir), true
+}
+
+func isBareRepository(gitDir string) bool {
+	output, err := logging.Output(exec.Command("git", "--git-dir", gitDir, "rev-parse", "--is-bare-repository"))
+	return err == nil && strings.TrimSpace(string(output)) == "true"
+}
+
+// hasLinkedWorktrees reports whether git worktree add has been used in the
+// repository at gitDir
+func hasLinkedWorktrees(gitDir string) bool {
+	entries, err := os.ReadDir(filepath.Join(gitDir, "worktrees"))
+	return err == nil && len(entries) > 0
+}
+
+// WorktreeRoot returns the directory new worktrees are created in: the one
+// holding the bare repository, or, for the .git of a regular clone, a
+// <clone>.worktrees directory beside it, so worktrees are kept together
+// rather than mixed in with the clone's neighbours
+func WorktreeRoot(barePath string) string {
+	root := filepath.Dir(barePath)
+	if !isBareRepository(barePath) {
+		root = filepath.Join(filepath.Dir(root), filepath.Base(root)+".worktrees")
+	}
+	return root
+}
+
+// NotAProjectError is returned by FindBarePath outside an arbor project. Its
+// message describes the layout arbor expects and how to set one up.
+type NotAProjectError struct {
+	Dir string
+	// InRepository is set when Dir is inside a git clone, which arbor init
+	// can convert in place
+	InRepository bool
+}
+
+func (e *NotAProjectError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s is not in an arbor project: no .bare repository found in it or any parent directory\n\n", e.Dir)
+	b.WriteString("An arbor project keeps a bare repository next to its worktrees:\n\n")
+	b.WriteString("  myapp/\n")
+	b.WriteString("    .bare/        the repository\n")
+	b.WriteString("    arbor.yaml    project config\n")
+	b.WriteString("    main/         default branch worktree\n")
+	b.WriteString("    feature-x/    one directory per branch\n\n")
+	if e.InRepository {
+		b.WriteString("This directory is in a git clone; run 'arbor init .' from its root to convert it in place.")
+	} else {
+		b.WriteString("Run 'arbor init <repo>' to clone a repository as an arbor project, or 'arbor init .' inside an existing clone to convert it.")
+	}
+	return b.String()
+}
+
+// Unwrap lets errors.Is match ErrWorktreeNotFound
+func (e *NotAProjectError) Unwrap() error {
+	return arborerrors.ErrWorktreeNotFound
+}
+
+// BranchCommitDates returns the date of the latest commit on each local and
+// remote branch, keyed by the names ListAllBranches and ListRemoteBranches use
+func BranchCommitDates(barePath string) (map[string]time.Time, error) {
+	cmd := exec.Command("git", "-C", barePath, "for-each-ref",
+		"--format=%(refname:short)%09%(committerdate:unix)", "refs/heads", "refs/remotes")
+	output, err := logging.Output(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("git for-each-ref failed: %w", err)
+	}
+
+	dates := make(map[string]time.Time)
+	for _, line := range strings.Split(string(output), "\n") {
+		name, stamp, ok := strings.Cut(strings.TrimSpace(line), "\t")
+		if !ok {
+			continue
+		}
+		seconds, err := strconv.ParseInt(stamp, 10, 64)
+		if err != nil {
+			continue
+		}
+		dates[name] = time.Unix(seconds, 0)
+	}
+	return dates, nil
 }
@@ -5,8 +5,12 @@ import (
 	"os/exec"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	arborerrors "github.com/michaeldyrynda/arbor/internal/errors"
 )
 
 func createTestRepo(t *testing.T) (string, string) {
@@ -149,6 +153,23 @@ func TestListRemoteBranches(t *testing.T) {
 	}
 }
 
+func TestBranchCommitDates(t *testing.T) {
+	barePath, _ := createTestRepo(t)
+
+	dates, err := BranchCommitDates(barePath)
+	if err != nil {
+		t.Fatalf("reading branch commit dates: %v", err)
+	}
+
+	date, ok := dates["main"]
+	if !ok {
+		t.Fatal("main branch should have a commit date")
+	}
+	if time.Since(date) > time.Hour || time.Since(date) < -time.Minute {
+		t.Errorf("expected a recent commit date, got %s", date)
+	}
+}
+
 func TestFindBarePath(t *testing.T) {
 	barePath, _ := createTestRepo(t)
 
@@ -166,6 +187,15 @@ func TestFindBarePath(t *testing.T) {
 		t.Errorf("expected %s, got %s", barePath, found)
 	}
 
+	nested := filepath.Join(mainPath, "app", "Http")
+	if err := os.MkdirAll(nested, 0755); err != nil {
+		t.Fatalf("creating nested directory: %v", err)
+	}
+	found, err = FindBarePath(nested)
+	if err != nil || found != barePath {
+		t.Errorf("expected %s from a nested directory, got %s (%v)", barePath, found, err)
+	}
+
 	_, err = FindBarePath("/nonexistent")
 	if err == nil {
 		t.Error("expected error for nonexistent path")
@@ -280,6 +310,78 @@ func TestFindBarePathParentSearch(t *testing.T) {
 	}
 }
 
+func TestFindBarePath_PlainWorktreeSetup(t *testing.T) {
+	_, repoDir := createTestRepo(t)
+
+	projectDir := t.TempDir()
+	barePath := filepath.Join(projectDir, "myapp.git")
+	output, err := exec.Command("git", "clone", "-q", "--bare", repoDir, barePath).CombinedOutput()
+	require.NoError(t, err, string(output))
+
+	mainPath := filepath.Join(projectDir, "main")
+	require.NoError(t, CreateWorktree(barePath, mainPath, "main", ""))
+
+	found, err := FindBarePath(mainPath)
+	require.NoError(t, err)
+	assert.Equal(t, barePath, found, "the bare repository shared by the worktrees is used")
+
+	found, err = FindBarePath(barePath)
+	require.NoError(t, err)
+	assert.Equal(t, barePath, found)
+	assert.Equal(t, projectDir, WorktreeRoot(barePath))
+}
+
+func TestFindBarePath_CloneWithLinkedWorktrees(t *testing.T) {
+	_, repoDir := createTestRepo(t)
+
+	codeDir := t.TempDir()
+	clonePath := filepath.Join(codeDir, "myapp")
+	output, err := exec.Command("git", "clone", "-q", repoDir, clonePath).CombinedOutput()
+	require.NoError(t, err, string(output))
+
+	featurePath := filepath.Join(codeDir, "feature")
+	output, err = exec.Command("git", "-C", clonePath, "worktree", "add", "-q", "-b", "feature", featurePath).CombinedOutput()
+	require.NoError(t, err, string(output))
+
+	gitDir := filepath.Join(clonePath, ".git")
+	for _, dir := range []string{clonePath, featurePath} {
+		found, err := FindBarePath(dir)
+		require.NoError(t, err, dir)
+		assert.Equal(t, gitDir, found, dir)
+	}
+	assert.Equal(t, filepath.Join(codeDir, "myapp.worktrees"), WorktreeRoot(gitDir), "new worktrees sit in a directory beside the checkout")
+
+	worktrees, err := ListWorktrees(gitDir)
+	require.NoError(t, err)
+	require.Len(t, worktrees, 2)
+	assert.Equal(t, Worktree{Path: clonePath, Branch: "main", IsCheckout: true}, worktrees[0])
+	assert.Equal(t, Worktree{Path: featurePath, Branch: "feature"}, worktrees[1])
+}
+
+func TestFindBarePath_NotAProject(t *testing.T) {
+	_, repoDir := createTestRepo(t)
+
+	// createTestRepo keeps a .bare beside the repo, so clone it elsewhere
+	clonePath := filepath.Join(t.TempDir(), "myapp")
+	output, err := exec.Command("git", "clone", "-q", repoDir, clonePath).CombinedOutput()
+	require.NoError(t, err, string(output))
+
+	_, err = FindBarePath(clonePath)
+	var notProject *NotAProjectError
+	require.ErrorAs(t, err, &notProject)
+	assert.True(t, notProject.InRepository, "a clone without linked worktrees is not used")
+	assert.Contains(t, err.Error(), "arbor init .")
+	assert.ErrorIs(t, err, arborerrors.ErrWorktreeNotFound)
+
+	dir := t.TempDir()
+	_, err = FindBarePath(dir)
+	require.ErrorAs(t, err, &notProject)
+	assert.False(t, notProject.InRepository)
+	assert.Equal(t, dir, notProject.Dir)
+	assert.Contains(t, err.Error(), ".bare/")
+	assert.Contains(t, err.Error(), "arbor init <repo>")
+}
+
 func TestListWorktrees(t *testing.T) {
 	barePath, _ := createTestRepo(t)
 	projectDir := filepath.Dir(barePath)
@@ -937,3 +1039,50 @@ func TestListWorktrees_PorcelainParsing_CurrentBehavior(t *testing.T) {
 	assert.NotNil(t, mainWt, "main worktree should exist")
 	assert.Equal(t, "main", mainWt.Branch)
 }
+
+func TestGetDefaultBranch(t *testing.T) {
+	run := func(t *testing.T, dir string, args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		output, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(output))
+	}
+
+	t.Run("bare clone uses the remote's default over the candidates", func(t *testing.T) {
+		_, repoDir := createTestRepo(t)
+		run(t, repoDir, "branch", "trunk")
+		run(t, repoDir, "checkout", "-q", "trunk")
+
+		barePath := filepath.Join(t.TempDir(), ".bare")
+		run(t, repoDir, "clone", "-q", "--bare", repoDir, barePath)
+
+		branch, err := GetDefaultBranch(barePath)
+		require.NoError(t, err)
+		assert.Equal(t, "trunk", branch)
+	})
+
+	t.Run("clone uses origin/HEAD", func(t *testing.T) {
+		_, repoDir := createTestRepo(t)
+		run(t, repoDir, "branch", "develop")
+		run(t, repoDir, "checkout", "-q", "develop")
+
+		clonePath := filepath.Join(t.TempDir(), "clone")
+		run(t, repoDir, "clone", "-q", repoDir, clonePath)
+		run(t, clonePath, "checkout", "-q", "-b", "feature")
+		run(t, clonePath, "branch", "main", "origin/main")
+
+		branch, err := GetDefaultBranch(clonePath)
+		require.NoError(t, err)
+		assert.Equal(t, "develop", branch)
+	})
+
+	t.Run("falls back to the candidates", func(t *testing.T) {
+		_, repoDir := createTestRepo(t)
+		run(t, repoDir, "checkout", "-q", "-b", "feature")
+
+		branch, err := GetDefaultBranch(repoDir)
+		require.NoError(t, err)
+		assert.Equal(t, "main", branch)
+	})
+}
@@ -0,0 +1,181 @@
+// Package logging is the shared output for step progress and diagnostics.
+// Messages are written at a level, and only those at or below the level set
+// by --quiet, --verbose or --debug are shown.
+package logging
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Level controls which messages are written
+type Level int
+
+const (
+	// LevelQuiet writes nothing, set by --quiet
+	LevelQuiet Level = iota - 1
+	// LevelInfo shows progress that is always useful, such as a created
+	// database or a dry run preview
+	LevelInfo
+	// LevelVerbose adds the details of each step, set by --verbose
+	LevelVerbose
+	// LevelDebug adds every external command run with its arguments and
+	// duration, set by --debug
+	LevelDebug
+)
+
+var (
+	mu         sync.Mutex
+	level      = LevelInfo
+	timestamps bool
+	// out is nil for os.Stderr, looked up on each write so that tests
+	// replacing os.Stderr capture messages too. Messages stay off stdout,
+	// which holds each command's output, such as arbor list --json.
+	out io.Writer
+)
+
+// SetLevel sets the most detailed level that is written
+func SetLevel(l Level) {
+	mu.Lock()
+	defer mu.Unlock()
+	level = l
+}
+
+// Enabled reports whether messages at l are written
+func Enabled(l Level) bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return l <= level
+}
+
+// SetTimestamps prefixes each message with the time it was written
+func SetTimestamps(on bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	timestamps = on
+}
+
+// SetOutput sets where messages are written, returning the previous writer
+func SetOutput(w io.Writer) io.Writer {
+	mu.Lock()
+	defer mu.Unlock()
+	previous := out
+	out = w
+	return previous
+}
+
+// Infof writes a message that is shown unless --quiet is set
+func Infof(format string, args ...interface{}) {
+	write(LevelInfo, format, args...)
+}
+
+// Verbosef writes a message shown with --verbose or --debug
+func Verbosef(format string, args ...interface{}) {
+	write(LevelVerbose, format, args...)
+}
+
+// Debugf writes a message shown with --debug
+func Debugf(format string, args ...interface{}) {
+	write(LevelDebug, format, args...)
+}
+
+func write(l Level, format string, args ...interface{}) {
+	mu.Lock()
+	defer mu.Unlock()
+	if l > level {
+		return
+	}
+
+	msg := Redact(strings.TrimSuffix(fmt.Sprintf(format, args...), "\n"))
+	if timestamps {
+		msg = time.Now().Format("15:04:05.000") + " " + msg
+	}
+	w := out
+	if w == nil {
+		w = os.Stderr
+	}
+	fmt.Fprintln(w, msg)
+}
+
+// secrets match the passwords in SQL, command arguments and URLs, with the
+// replacement keeping the text around them
+var secrets = []struct {
+	pattern     *regexp.Regexp
+	replacement string
+}{
+	{regexp.MustCompile(`(?i)((?:IDENTIFIED BY|PASSWORD)\s+)'(?:[^']|'')*'`), "${1}'********'"},
+	{regexp.MustCompile(`(?i)(\b[A-Z0-9_]*(?:PASSWORD|PWD|SECRET|TOKEN)[A-Z0-9_]*=)[^\s'"]+`), "${1}********"},
+	{regexp.MustCompile(`(://[^/\s:@]+:)[^/\s@]+@`), "${1}********@"},
+}
+
+// Redact replaces the passwords in msg, such as the one in CREATE USER ...
+// IDENTIFIED BY '...', a MYSQL_ROOT_PASSWORD=... argument or a connection
+// URL, with asterisks
+func Redact(msg string) string {
+	for _, secret := range secrets {
+		msg = secret.pattern.ReplaceAllString(msg, secret.replacement)
+	}
+	return msg
+}
+
+// Run runs cmd, logging it at debug level
+func Run(cmd *exec.Cmd) error {
+	defer logCommand(cmd, time.Now())
+	return cmd.Run()
+}
+
+// Output runs cmd and returns its stdout, logging it at debug level
+func Output(cmd *exec.Cmd) ([]byte, error) {
+	defer logCommand(cmd, time.Now())
+	return cmd.Output()
+}
+
+// CombinedOutput runs cmd and returns its stdout and stderr, logging it at
+// debug level
+func CombinedOutput(cmd *exec.Cmd) ([]byte, error) {
+	defer logCommand(cmd, time.Now())
+	return cmd.CombinedOutput()
+}
+
+func logCommand(cmd *exec.Cmd, start time.Time) {
+	if !Enabled(LevelDebug) {
+		return
+	}
+
+	// Arguments are redacted before quoting, which would split a quoted
+	// password apart
+	args := make([]string, len(cmd.Args))
+	for i, arg := range cmd.Args {
+		args[i] = Redact(arg)
+	}
+	line := "$ " + CommandLine(args)
+	if cmd.Dir != "" {
+		line += " (in " + cmd.Dir + ")"
+	}
+	status := "ok"
+	if cmd.ProcessState != nil && !cmd.ProcessState.Success() {
+		status = fmt.Sprintf("exit %d", cmd.ProcessState.ExitCode())
+	} else if cmd.ProcessState == nil {
+		status = "not started"
+	}
+	Debugf("%s [%s, %s]", line, status, time.Since(start).Round(time.Millisecond))
+}
+
+// CommandLine formats args as a shell would show them, quoting arguments
+// containing spaces or quotes
+func CommandLine(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		if arg == "" || strings.ContainsAny(arg, " \t\n'\"") {
+			arg = "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+		}
+		quoted[i] = arg
+	}
+	return strings.Join(quoted, " ")
+}
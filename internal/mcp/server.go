@@ -0,0 +1,195 @@
+// Package mcp serves tools over the Model Context Protocol, using
+// newline-delimited JSON-RPC 2.0 on stdio.
+package mcp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// ProtocolVersion is the newest protocol revision the server speaks
+const ProtocolVersion = "2025-06-18"
+
+// JSON-RPC error codes
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+)
+
+// Tool is a capability clients can call
+type Tool struct {
+	Name        string
+	Description string
+	// InputSchema is the JSON Schema of the tool's arguments
+	InputSchema map[string]interface{}
+	Handler     func(args json.RawMessage) (*Result, error)
+}
+
+// Result is a tool's outcome. Text is shown to the model, Structured is
+// returned as structuredContent, and IsError marks a failed call.
+type Result struct {
+	Text       string
+	Structured interface{}
+	IsError    bool
+}
+
+// Server answers MCP requests with a fixed set of tools
+type Server struct {
+	name    string
+	version string
+	tools   []Tool
+}
+
+func NewServer(name, version string, tools []Tool) *Server {
+	return &Server{name: name, version: version, tools: tools}
+}
+
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Serve reads requests from r until it is closed, writing responses to w.
+// Requests are handled one at a time, in order.
+func (s *Server) Serve(r io.Reader, w io.Writer) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	encoder := json.NewEncoder(w)
+
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		resp := s.handle(line)
+		if resp == nil {
+			continue
+		}
+
+		if err := encoder.Encode(resp); err != nil {
+			return fmt.Errorf("writing response: %w", err)
+		}
+	}
+	return scanner.Err()
+}
+
+// handle answers a single message. Notifications, which have no id, get no
+// response.
+func (s *Server) handle(line []byte) *response {
+	var req request
+	if err := json.Unmarshal(line, &req); err != nil {
+		return &response{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &rpcError{codeParseError, "parse error"}}
+	}
+	if len(req.ID) == 0 {
+		return nil
+	}
+
+	resp := &response{JSONRPC: "2.0", ID: req.ID}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		resp.Error = &rpcError{codeInvalidRequest, "invalid request"}
+		return resp
+	}
+
+	result, rpcErr := s.dispatch(req.Method, req.Params)
+	if rpcErr != nil {
+		resp.Error = rpcErr
+	} else {
+		resp.Result = result
+	}
+	return resp
+}
+
+func (s *Server) dispatch(method string, params json.RawMessage) (interface{}, *rpcError) {
+	switch method {
+	case "initialize":
+		var p struct {
+			ProtocolVersion string `json:"protocolVersion"`
+		}
+		if len(params) > 0 {
+			if err := json.Unmarshal(params, &p); err != nil {
+				return nil, &rpcError{codeInvalidParams, "invalid params"}
+			}
+		}
+		version := p.ProtocolVersion
+		if version == "" || version > ProtocolVersion {
+			version = ProtocolVersion
+		}
+		return map[string]interface{}{
+			"protocolVersion": version,
+			"capabilities":    map[string]interface{}{"tools": map[string]interface{}{}},
+			"serverInfo":      map[string]interface{}{"name": s.name, "version": s.version},
+		}, nil
+	case "ping":
+		return map[string]interface{}{}, nil
+	case "tools/list":
+		tools := make([]map[string]interface{}, len(s.tools))
+		for i, tool := range s.tools {
+			tools[i] = map[string]interface{}{
+				"name":        tool.Name,
+				"description": tool.Description,
+				"inputSchema": tool.InputSchema,
+			}
+		}
+		return map[string]interface{}{"tools": tools}, nil
+	case "tools/call":
+		return s.callTool(params)
+	}
+	return nil, &rpcError{codeMethodNotFound, fmt.Sprintf("method not found: %s", method)}
+}
+
+func (s *Server) callTool(params json.RawMessage) (interface{}, *rpcError) {
+	var p struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, &rpcError{codeInvalidParams, "invalid params"}
+	}
+
+	for _, tool := range s.tools {
+		if tool.Name != p.Name {
+			continue
+		}
+
+		args := p.Arguments
+		if len(args) == 0 {
+			args = json.RawMessage("{}")
+		}
+
+		// Tool failures are results the model can see and react to, not
+		// protocol errors
+		result, err := tool.Handler(args)
+		if err != nil {
+			result = &Result{Text: err.Error(), IsError: true}
+		}
+
+		out := map[string]interface{}{
+			"content": []map[string]interface{}{{"type": "text", "text": result.Text}},
+			"isError": result.IsError,
+		}
+		if result.Structured != nil {
+			out["structuredContent"] = result.Structured
+		}
+		return out, nil
+	}
+	return nil, &rpcError{codeInvalidParams, fmt.Sprintf("unknown tool: %s", p.Name)}
+}
@@ -0,0 +1,107 @@
+package mcp
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func serve(t *testing.T, tools []Tool, messages ...string) []map[string]interface{} {
+	t.Helper()
+
+	var out bytes.Buffer
+	server := NewServer("arbor", "test", tools)
+	require.NoError(t, server.Serve(strings.NewReader(strings.Join(messages, "\n")+"\n"), &out))
+
+	var responses []map[string]interface{}
+	decoder := json.NewDecoder(&out)
+	for decoder.More() {
+		var resp map[string]interface{}
+		require.NoError(t, decoder.Decode(&resp))
+		responses = append(responses, resp)
+	}
+	return responses
+}
+
+func TestServer_Initialize(t *testing.T) {
+	responses := serve(t, nil,
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"agent","version":"1"}}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"ping"}`,
+	)
+	require.Len(t, responses, 2)
+
+	result := responses[0]["result"].(map[string]interface{})
+	assert.Equal(t, "2025-03-26", result["protocolVersion"])
+	assert.Equal(t, "arbor", result["serverInfo"].(map[string]interface{})["name"])
+	assert.Contains(t, result["capabilities"], "tools")
+	assert.Equal(t, float64(2), responses[1]["id"])
+}
+
+func TestServer_Tools(t *testing.T) {
+	tools := []Tool{
+		{
+			Name:        "echo",
+			Description: "Echo the message",
+			InputSchema: map[string]interface{}{"type": "object"},
+			Handler: func(args json.RawMessage) (*Result, error) {
+				var p struct {
+					Message string `json:"message"`
+				}
+				require.NoError(t, json.Unmarshal(args, &p))
+				return &Result{Text: p.Message, Structured: map[string]string{"message": p.Message}}, nil
+			},
+		},
+		{
+			Name:        "fail",
+			InputSchema: map[string]interface{}{"type": "object"},
+			Handler: func(json.RawMessage) (*Result, error) {
+				return nil, errors.New("worktree not found")
+			},
+		},
+	}
+
+	responses := serve(t, tools,
+		`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"echo","arguments":{"message":"hi"}}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"fail"}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"missing"}}`,
+	)
+	require.Len(t, responses, 4)
+
+	listed := responses[0]["result"].(map[string]interface{})["tools"].([]interface{})
+	require.Len(t, listed, 2)
+	assert.Equal(t, "echo", listed[0].(map[string]interface{})["name"])
+
+	echo := responses[1]["result"].(map[string]interface{})
+	assert.Equal(t, false, echo["isError"])
+	assert.Equal(t, "hi", echo["content"].([]interface{})[0].(map[string]interface{})["text"])
+	assert.Equal(t, map[string]interface{}{"message": "hi"}, echo["structuredContent"])
+
+	failed := responses[2]["result"].(map[string]interface{})
+	assert.Equal(t, true, failed["isError"])
+	assert.Equal(t, "worktree not found", failed["content"].([]interface{})[0].(map[string]interface{})["text"])
+
+	assert.Equal(t, float64(codeInvalidParams), responses[3]["error"].(map[string]interface{})["code"])
+}
+
+func TestServer_Errors(t *testing.T) {
+	responses := serve(t, nil,
+		`not json`,
+		`{"jsonrpc":"2.0","id":"a","method":"resources/list"}`,
+		`{"id":5,"method":"ping"}`,
+		`{"jsonrpc":"2.0","id":6,"method":"initialize","params":{"protocolVersion":1}}`,
+	)
+	require.Len(t, responses, 4)
+
+	assert.Equal(t, float64(codeParseError), responses[0]["error"].(map[string]interface{})["code"])
+	assert.Equal(t, "a", responses[1]["id"])
+	assert.Equal(t, float64(codeMethodNotFound), responses[1]["error"].(map[string]interface{})["code"])
+	assert.Equal(t, float64(codeInvalidRequest), responses[2]["error"].(map[string]interface{})["code"])
+	assert.Equal(t, float64(codeInvalidParams), responses[3]["error"].(map[string]interface{})["code"])
+}
@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 
 	"github.com/michaeldyrynda/arbor/internal/config"
+	"github.com/michaeldyrynda/arbor/internal/scaffold/steps"
 	"github.com/michaeldyrynda/arbor/internal/utils"
 )
 
@@ -19,18 +20,18 @@ func NewLaravel() *Laravel {
 			defaultSteps: []config.StepConfig{
 				{Name: "php.composer", Args: []string{"install"}, Condition: map[string]interface{}{"file_exists": "composer.lock"}},
 				{Name: "php.composer", Args: []string{"update"}, Condition: map[string]interface{}{"not": map[string]interface{}{"file_exists": "composer.lock"}}},
-				{Name: "file.copy", From: ".env.example", To: ".env", Priority: 5},
+				{Name: "file.copy", From: ".env.example", To: ".env", Priority: steps.PriorityRuntime},
 				{Name: "db.create", Condition: map[string]interface{}{"env_file_contains": map[string]interface{}{"file": ".env", "key": "DB_CONNECTION"}}},
 				{Name: "node.npm", Args: []string{"ci"}, Condition: map[string]interface{}{"file_exists": "package-lock.json"}},
 				{Name: "php.laravel.artisan", Args: []string{"key:generate", "--no-interaction"}, Condition: map[string]interface{}{"env_file_missing": "APP_KEY"}},
 				{Name: "php.laravel.artisan", Args: []string{"migrate:fresh", "--seed", "--no-interaction"}},
-				{Name: "node.npm", Args: []string{"run", "build"}, Priority: 15, Condition: map[string]interface{}{"file_exists": "package-lock.json"}},
+				{Name: "node.npm", Args: []string{"run", "build"}, Priority: steps.PriorityBuild, Condition: map[string]interface{}{"file_exists": "package-lock.json"}},
 				{Name: "php.laravel.artisan", Args: []string{"storage:link", "--no-interaction"}},
 				{Name: "herd", Args: []string{"link", "--secure", "{{ .SiteName }}"}},
 			},
-			cleanupSteps: []config.CleanupStep{
-				{Name: "herd", Condition: nil},
-				{Name: "db.destroy", Condition: nil},
+			cleanupSteps: []config.StepConfig{
+				{Name: "herd", Args: []string{"unlink"}},
+				{Name: "db.destroy"},
 			},
 		},
 	}
@@ -50,7 +50,18 @@ func RegisterAllWithScaffold(m *scaffold.ScaffoldManager) {
 	}
 }
 
+// Detect returns the preset for path: one committed in its .arbor directory
+// that detects it, or else the first built-in that does. Presets in .arbor
+// that cannot be read are warned about and skipped.
 func (m *Manager) Detect(path string) string {
+	name, err := scaffold.DetectRepoPreset(path)
+	if err != nil {
+		ui.PrintWarning(fmt.Sprintf("Skipping the presets in .arbor: %v", err))
+	}
+	if name != "" {
+		return name
+	}
+
 	// Iterate in priority order (most specific first) using the ordered slice
 	// instead of the map to ensure deterministic detection.
 	// builtInPresets is ordered from most specific (Laravel) to least specific (PHP).
@@ -8,13 +8,13 @@ type Preset interface {
 	Name() string
 	Detect(path string) bool
 	DefaultSteps() []config.StepConfig
-	CleanupSteps() []config.CleanupStep
+	CleanupSteps() []config.StepConfig
 }
 
 type basePreset struct {
 	name         string
 	defaultSteps []config.StepConfig
-	cleanupSteps []config.CleanupStep
+	cleanupSteps []config.StepConfig
 }
 
 func (p *basePreset) Name() string {
@@ -25,6 +25,6 @@ func (p *basePreset) DefaultSteps() []config.StepConfig {
 	return p.defaultSteps
 }
 
-func (p *basePreset) CleanupSteps() []config.CleanupStep {
+func (p *basePreset) CleanupSteps() []config.StepConfig {
 	return p.cleanupSteps
 }
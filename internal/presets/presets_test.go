@@ -97,6 +97,7 @@ func TestLaravelPreset_CleanupSteps(t *testing.T) {
 
 	assert.Len(t, steps, 2)
 	assert.Equal(t, "herd", steps[0].Name)
+	assert.Equal(t, []string{"unlink"}, steps[0].Args)
 	assert.Equal(t, "db.destroy", steps[1].Name)
 }
 
@@ -4,7 +4,10 @@ import (
 	"fmt"
 	"sort"
 	"sync"
+	"time"
 
+	arborerrors "github.com/michaeldyrynda/arbor/internal/errors"
+	"github.com/michaeldyrynda/arbor/internal/logging"
 	"github.com/michaeldyrynda/arbor/internal/scaffold/types"
 )
 
@@ -12,6 +15,8 @@ type ExecutionResult struct {
 	Step    types.ScaffoldStep
 	Error   error
 	Skipped bool
+	// Duration is how long the step ran; it is zero for skipped steps
+	Duration time.Duration
 }
 
 type StepExecutor struct {
@@ -47,11 +52,25 @@ func (e *StepExecutor) Execute() error {
 	return nil
 }
 
+// ExecuteInOrder runs the steps one after another in the order given,
+// ignoring their priorities
+func (e *StepExecutor) ExecuteInOrder() error {
+	e.results = make([]ExecutionResult, 0, len(e.steps))
+
+	for _, step := range e.steps {
+		if err := e.executeStep(step); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (e *StepExecutor) sortByPriority() []types.ScaffoldStep {
 	sorted := make([]types.ScaffoldStep, len(e.steps))
 	copy(sorted, e.steps)
 
-	sort.Slice(sorted, func(i, j int) bool {
+	sort.SliceStable(sorted, func(i, j int) bool {
 		return sorted[i].Priority() < sorted[j].Priority()
 	})
 
@@ -137,21 +156,21 @@ func (e *StepExecutor) executeStep(step types.ScaffoldStep) error {
 			Skipped: true,
 		})
 		e.mu.Unlock()
-		if e.opts.Verbose {
-			fmt.Printf("Skipping step (disabled): %s\n", step.Name())
-		}
+		logging.Verbosef("Skipping step (disabled): %s", step.Name())
 		return nil
 	}
 
 	if step.Condition(e.ctx) {
-		if e.opts.Verbose {
-			fmt.Printf("Executing step: %s\n", step.Name())
+		logging.Verbosef("Executing step: %s", step.Name())
+
+		// Steps that preview their own work still run during a dry run
+		previews := false
+		if dryRunner, ok := step.(interface{ SupportsDryRun() bool }); ok {
+			previews = dryRunner.SupportsDryRun()
 		}
 
-		if e.opts.DryRun {
-			if e.opts.Verbose {
-				fmt.Printf("[DRY-RUN] Would execute: %s\n", step.Name())
-			}
+		if e.opts.DryRun && !previews {
+			logging.Verbosef("[DRY-RUN] Would execute: %s", step.Name())
 			e.mu.Lock()
 			e.results = append(e.results, ExecutionResult{
 				Step: step,
@@ -160,24 +179,28 @@ func (e *StepExecutor) executeStep(step types.ScaffoldStep) error {
 			return nil
 		}
 
-		if err := step.Run(e.ctx, e.opts); err != nil {
+		start := time.Now()
+		err := step.Run(e.ctx, e.opts)
+		duration := time.Since(start)
+		logging.Debugf("Finished step %s in %s", step.Name(), duration.Round(time.Millisecond))
+		if err != nil {
 			e.mu.Lock()
 			e.results = append(e.results, ExecutionResult{
-				Step:  step,
-				Error: err,
+				Step:     step,
+				Error:    err,
+				Duration: duration,
 			})
 			e.mu.Unlock()
-			return fmt.Errorf("step %s failed: %w", step.Name(), err)
+			return arborerrors.Wrap(arborerrors.ErrScaffoldStepFailed, fmt.Errorf("step %s failed: %w", step.Name(), err))
 		}
 		e.mu.Lock()
 		e.results = append(e.results, ExecutionResult{
-			Step: step,
+			Step:     step,
+			Duration: duration,
 		})
 		e.mu.Unlock()
 	} else {
-		if e.opts.Verbose {
-			fmt.Printf("Skipping step (condition not met): %s\n", step.Name())
-		}
+		logging.Verbosef("Skipping step (condition not met): %s", step.Name())
 		e.mu.Lock()
 		e.results = append(e.results, ExecutionResult{
 			Step:    step,
@@ -128,6 +128,27 @@ func TestStepExecutor_Execute_ConditionFalse(t *testing.T) {
 	assert.False(t, step2.runCalled)
 }
 
+func TestStepExecutor_ExecuteInOrder(t *testing.T) {
+	ctx := &types.ScaffoldContext{
+		WorktreePath: "/tmp",
+		Branch:       "test",
+	}
+
+	step1 := &mockStep{name: "step1", priority: 20, conditionResult: true}
+	step2 := &mockStep{name: "step2", priority: 10, conditionResult: true, runError: assert.AnError}
+	step3 := &mockStep{name: "step3", priority: 10, conditionResult: true}
+
+	executor := NewStepExecutor([]types.ScaffoldStep{step1, step2, step3}, ctx, types.StepOptions{})
+
+	err := executor.ExecuteInOrder()
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "step2 failed")
+	assert.True(t, step1.runCalled, "steps run in the order given, not by priority")
+	assert.False(t, step3.runCalled, "steps after a failure do not run")
+	assert.Len(t, executor.Results(), 2)
+}
+
 func TestStepExecutor_Execute_StepFails(t *testing.T) {
 	ctx := &types.ScaffoldContext{
 		WorktreePath: "/tmp",
@@ -167,6 +188,43 @@ func TestStepExecutor_Execute_DryRun(t *testing.T) {
 	assert.False(t, step1.runCalled)
 }
 
+// previewStep runs during dry runs to report what it would do
+type previewStep struct {
+	mockStep
+	dryRun bool
+}
+
+func (s *previewStep) Run(ctx *types.ScaffoldContext, opts types.StepOptions) error {
+	s.runCalled = true
+	s.dryRun = opts.DryRun
+	return s.runError
+}
+
+func (s *previewStep) SupportsDryRun() bool {
+	return true
+}
+
+func TestStepExecutor_Execute_DryRunPreview(t *testing.T) {
+	ctx := &types.ScaffoldContext{
+		WorktreePath: "/tmp",
+		Branch:       "test",
+	}
+
+	skipped := &mockStep{name: "skipped", priority: 10, conditionResult: true}
+	preview := &previewStep{mockStep: mockStep{name: "preview", priority: 20, conditionResult: true}}
+
+	executor := NewStepExecutor([]types.ScaffoldStep{skipped, preview}, ctx, types.StepOptions{
+		DryRun: true,
+	})
+
+	err := executor.Execute()
+
+	assert.NoError(t, err)
+	assert.False(t, skipped.runCalled)
+	assert.True(t, preview.runCalled)
+	assert.True(t, preview.dryRun)
+}
+
 func TestStepExecutor_Results(t *testing.T) {
 	ctx := &types.ScaffoldContext{
 		WorktreePath: "/tmp",
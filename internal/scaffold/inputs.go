@@ -0,0 +1,122 @@
+package scaffold
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/michaeldyrynda/arbor/internal/config"
+	"github.com/michaeldyrynda/arbor/internal/scaffold/types"
+)
+
+// inputsForWorktree returns the inputs declared by the project and by its
+// enabled steps, in order, keeping the first declaration of each name. Step
+// conditions are not evaluated, so a step skipped by its condition still
+// has its inputs asked.
+func (m *ScaffoldManager) inputsForWorktree(cfg *config.Config, worktreePath string) ([]config.InputConfig, error) {
+	var stepConfigs []config.StepConfig
+
+	if !cfg.Scaffold.Override {
+		preset, err := m.presetFor(cfg, worktreePath)
+		if err != nil {
+			return nil, err
+		}
+		if preset != nil {
+			stepConfigs = append(stepConfigs, preset.DefaultSteps()...)
+		}
+	}
+	stepConfigs = append(stepConfigs, cfg.Scaffold.Steps...)
+	stepConfigs = append(stepConfigs, cfg.GlobalSteps...)
+
+	inputs := append([]config.InputConfig{}, cfg.Scaffold.Inputs...)
+	for _, step := range stepConfigs {
+		if step.Enabled != nil && !*step.Enabled {
+			continue
+		}
+		inputs = append(inputs, step.Inputs...)
+	}
+
+	seen := make(map[string]bool, len(inputs))
+	unique := inputs[:0]
+	for _, input := range inputs {
+		if seen[input.Name] {
+			continue
+		}
+		seen[input.Name] = true
+		unique = append(unique, input)
+	}
+	return unique, nil
+}
+
+// collectInputs stores an answer for each input in the context vars, asking
+// prompt when it is set. Every input is validated before the first is asked,
+// and inputs already answered by a project var are not asked again.
+func collectInputs(ctx *types.ScaffoldContext, inputs []config.InputConfig, prompt func(config.InputConfig) (string, error)) error {
+	for _, input := range inputs {
+		if err := validateInput(input); err != nil {
+			return err
+		}
+	}
+
+	for _, input := range inputs {
+		if ctx.GetVar(input.Name) != "" {
+			continue
+		}
+
+		answer := inputDefault(input)
+		if prompt != nil {
+			var err error
+			if answer, err = prompt(input); err != nil {
+				return fmt.Errorf("input %s: %w", input.Name, err)
+			}
+		}
+		ctx.SetVar(input.Name, answer)
+	}
+	return nil
+}
+
+func validateInput(input config.InputConfig) error {
+	if input.Name == "" {
+		return fmt.Errorf("input is missing a name")
+	}
+
+	switch input.Type {
+	case "", config.InputText:
+		return nil
+	case config.InputConfirm:
+		if input.Default == nil {
+			return nil
+		}
+		if _, err := strconv.ParseBool(fmt.Sprint(input.Default)); err != nil {
+			return fmt.Errorf("input %s: default %q is not a boolean", input.Name, fmt.Sprint(input.Default))
+		}
+		return nil
+	case config.InputSelect:
+		if len(input.Options) == 0 {
+			return fmt.Errorf("input %s: select inputs need options", input.Name)
+		}
+		return nil
+	default:
+		return fmt.Errorf("input %s: unsupported type %q", input.Name, input.Type)
+	}
+}
+
+// inputDefault returns the answer used without prompting. Confirm inputs
+// answer "true" or "false" and select inputs fall back to the first option.
+func inputDefault(input config.InputConfig) string {
+	if input.Default != nil {
+		if input.Type == config.InputConfirm {
+			value, _ := strconv.ParseBool(fmt.Sprint(input.Default))
+			return strconv.FormatBool(value)
+		}
+		return fmt.Sprint(input.Default)
+	}
+
+	switch input.Type {
+	case config.InputConfirm:
+		return "false"
+	case config.InputSelect:
+		return input.Options[0]
+	default:
+		return ""
+	}
+}
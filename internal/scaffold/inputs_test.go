@@ -0,0 +1,119 @@
+package scaffold
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/michaeldyrynda/arbor/internal/config"
+	"github.com/michaeldyrynda/arbor/internal/scaffold/types"
+)
+
+func TestScaffoldManager_InputsForWorktree(t *testing.T) {
+	disabled := false
+	cfg := &config.Config{
+		Scaffold: config.ScaffoldConfig{
+			Inputs: []config.InputConfig{{Name: "seeders", Type: config.InputSelect, Options: []string{"all", "none"}}},
+			Steps: []config.StepConfig{
+				{Name: "bash.run", Command: "echo", Inputs: []config.InputConfig{{Name: "seeders"}, {Name: "queue"}}},
+				{Name: "bash.run", Command: "echo", Enabled: &disabled, Inputs: []config.InputConfig{{Name: "skipped"}}},
+			},
+		},
+	}
+
+	inputs, err := NewScaffoldManager().inputsForWorktree(cfg, t.TempDir())
+	require.NoError(t, err)
+
+	var names []string
+	for _, input := range inputs {
+		names = append(names, input.Name)
+	}
+	assert.Equal(t, []string{"seeders", "queue"}, names)
+	assert.Equal(t, config.InputSelect, inputs[0].Type, "the first declaration wins")
+}
+
+func TestCollectInputs(t *testing.T) {
+	inputs := []config.InputConfig{
+		{Name: "seeders", Type: config.InputSelect, Options: []string{"all", "none"}},
+		{Name: "seed", Type: config.InputConfirm},
+		{Name: "region", Default: "au"},
+		{Name: "queue", Type: config.InputConfirm, Default: true},
+	}
+
+	t.Run("uses defaults when not interactive", func(t *testing.T) {
+		ctx := &types.ScaffoldContext{}
+
+		require.NoError(t, collectInputs(ctx, inputs, nil))
+		assert.Equal(t, "all", ctx.GetVar("seeders"))
+		assert.Equal(t, "false", ctx.GetVar("seed"))
+		assert.Equal(t, "au", ctx.GetVar("region"))
+		assert.Equal(t, "true", ctx.GetVar("queue"))
+	})
+
+	t.Run("stores prompted answers", func(t *testing.T) {
+		var asked []string
+		prompt := func(input config.InputConfig) (string, error) {
+			asked = append(asked, input.Name)
+			return "answer-" + input.Name, nil
+		}
+		ctx := &types.ScaffoldContext{Vars: map[string]string{"region": "us"}}
+
+		require.NoError(t, collectInputs(ctx, inputs, prompt))
+		assert.Equal(t, []string{"seeders", "seed", "queue"}, asked, "vars already set are not asked")
+		assert.Equal(t, "answer-seeders", ctx.GetVar("seeders"))
+		assert.Equal(t, "us", ctx.GetVar("region"))
+	})
+
+	t.Run("rejects invalid inputs", func(t *testing.T) {
+		ctx := &types.ScaffoldContext{}
+
+		assert.ErrorContains(t, collectInputs(ctx, []config.InputConfig{{Type: config.InputText}}, nil), "missing a name")
+		assert.ErrorContains(t, collectInputs(ctx, []config.InputConfig{{Name: "x", Type: config.InputSelect}}, nil), "need options")
+		assert.ErrorContains(t, collectInputs(ctx, []config.InputConfig{{Name: "x", Type: "radio"}}, nil), "unsupported type")
+		assert.ErrorContains(t, collectInputs(ctx, []config.InputConfig{{Name: "x", Type: config.InputConfirm, Default: "yes"}}, nil), `default "yes" is not a boolean`)
+	})
+
+	t.Run("validates every input before prompting", func(t *testing.T) {
+		asked := false
+		prompt := func(config.InputConfig) (string, error) {
+			asked = true
+			return "", nil
+		}
+		invalid := []config.InputConfig{{Name: "region"}, {Name: "seed", Type: config.InputConfirm, Default: "maybe"}}
+
+		assert.ErrorContains(t, collectInputs(&types.ScaffoldContext{}, invalid, prompt), `input seed: default "maybe" is not a boolean`)
+		assert.False(t, asked)
+	})
+
+	t.Run("normalizes confirm defaults", func(t *testing.T) {
+		ctx := &types.ScaffoldContext{}
+
+		require.NoError(t, collectInputs(ctx, []config.InputConfig{{Name: "seed", Type: config.InputConfirm, Default: "TRUE"}}, nil))
+		assert.Equal(t, "true", ctx.GetVar("seed"))
+	})
+}
+
+func TestScaffoldManager_RunScaffoldWithInputs(t *testing.T) {
+	worktreePath := t.TempDir()
+	cfg := &config.Config{
+		Scaffold: config.ScaffoldConfig{
+			Steps: []config.StepConfig{
+				{
+					Name:   "env.write",
+					Key:    "SEEDERS",
+					Value:  "{{ .Vars.seeders }}",
+					Inputs: []config.InputConfig{{Name: "seeders", Type: config.InputSelect, Options: []string{"demo", "none"}}},
+				},
+			},
+		},
+	}
+
+	require.NoError(t, NewScaffoldManager().RunScaffold(worktreePath, "feature", "repo", "site", "", cfg, false, false))
+
+	data, err := os.ReadFile(filepath.Join(worktreePath, ".env"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "SEEDERS=demo")
+}
@@ -377,3 +377,30 @@ APP_NAME=myapp
 		assert.True(t, strings.HasPrefix(createCalls[2], "knowledge_"), "Third db should use 'knowledge' prefix")
 	})
 }
+
+func TestIntegration_RunScaffoldProjectVars(t *testing.T) {
+	t.Run("project vars are available to step templates", func(t *testing.T) {
+		tmpDir := t.TempDir()
+
+		envFile := filepath.Join(tmpDir, ".env")
+		require.NoError(t, os.WriteFile(envFile, []byte("APP_NAME=myapp\n"), 0644))
+
+		cfg := &config.Config{
+			Vars: map[string]string{"api_base": "https://api.test"},
+			Scaffold: config.ScaffoldConfig{
+				Steps: []config.StepConfig{
+					{Name: "env.write", Key: "API_URL", Value: "{{ .Vars.api_base }}/v1"},
+				},
+			},
+		}
+		manager := NewScaffoldManager()
+
+		err := manager.RunScaffold(tmpDir, "test", "myrepo", "myapp", "", cfg, false, false)
+		require.NoError(t, err)
+
+		content, err := os.ReadFile(envFile)
+		require.NoError(t, err)
+		assert.Contains(t, string(content), "API_URL=https://api.test/v1")
+		assert.Len(t, cfg.Vars, 1, "project vars should not be mutated")
+	})
+}
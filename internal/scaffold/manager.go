@@ -3,22 +3,30 @@ package scaffold
 import (
 	"fmt"
 	"path/filepath"
+	"time"
 
 	"github.com/michaeldyrynda/arbor/internal/config"
+	"github.com/michaeldyrynda/arbor/internal/git"
 	"github.com/michaeldyrynda/arbor/internal/scaffold/steps"
 	"github.com/michaeldyrynda/arbor/internal/scaffold/types"
-	"github.com/michaeldyrynda/arbor/internal/scaffold/words"
+	"github.com/michaeldyrynda/arbor/internal/secrets"
 )
 
 type ScaffoldManager struct {
 	presets map[string]Preset
+
+	// Interaction is how steps prompt, notify and copy to the clipboard
+	Interaction types.Interaction
+	// StepTimings, when set, receives the results of each scaffold run, with
+	// how long each step took
+	StepTimings func(ctx *types.ScaffoldContext, results []ExecutionResult)
 }
 
 type Preset interface {
 	Name() string
 	Detect(path string) bool
 	DefaultSteps() []config.StepConfig
-	CleanupSteps() []config.CleanupStep
+	CleanupSteps() []config.StepConfig
 }
 
 func NewScaffoldManager() *ScaffoldManager {
@@ -46,122 +54,237 @@ func (m *ScaffoldManager) DetectPreset(path string) string {
 }
 
 func (m *ScaffoldManager) GetStepsForWorktree(cfg *config.Config, worktreePath, branch string) ([]types.ScaffoldStep, error) {
+	return m.stepsForWorktree(cfg, worktreePath, secrets.NewInterpolator(worktreePath))
+}
+
+// stepsForWorktree returns the preset and configured steps, resolving
+// secret references with interpolator
+func (m *ScaffoldManager) stepsForWorktree(cfg *config.Config, worktreePath string, interpolator *secrets.Interpolator) ([]types.ScaffoldStep, error) {
 	var stepsList []types.ScaffoldStep
 
-	presetName := cfg.Preset
-	if presetName == "" {
-		presetName = m.DetectPreset(worktreePath)
+	preset, err := m.presetFor(cfg, worktreePath)
+	if err != nil {
+		return nil, err
 	}
-
-	if preset, ok := m.GetPreset(presetName); ok {
+	if preset != nil {
 		for _, stepConfig := range preset.DefaultSteps() {
-			step := steps.Create(stepConfig.Name, stepConfig)
-			if step != nil {
-				stepsList = append(stepsList, step)
+			step, err := steps.CreateStep(stepConfig.Name, stepConfig)
+			if err != nil {
+				return nil, fmt.Errorf("preset %s: %w", preset.Name(), err)
 			}
+			stepsList = append(stepsList, step)
 		}
 	}
 
+	additionalSteps, err := m.stepsFromConfig(cfg.Scaffold.Steps, interpolator)
+	if err != nil {
+		return nil, err
+	}
+
 	if cfg.Scaffold.Override {
-		stepsList = m.stepsFromConfig(cfg.Scaffold.Steps)
+		stepsList = additionalSteps
 	} else {
-		additionalSteps := m.stepsFromConfig(cfg.Scaffold.Steps)
 		stepsList = append(stepsList, additionalSteps...)
 	}
 
+	globalSteps, err := m.stepsFromConfig(cfg.GlobalSteps, interpolator)
+	if err != nil {
+		return nil, err
+	}
+	stepsList = append(stepsList, globalSteps...)
+
+	repo, err := repoSteps(worktreePath, HookScaffold)
+	if err != nil {
+		return nil, err
+	}
+	stepsList = append(stepsList, repo...)
+
 	return stepsList, nil
 }
 
+// defaultCleanupArgs are the args of cleanup steps that set none, so a bare
+// "- name: herd" under cleanup unlinks the site rather than running herd
+var defaultCleanupArgs = map[string][]string{
+	"herd": {"unlink"},
+}
+
 func (m *ScaffoldManager) GetCleanupSteps(cfg *config.Config, worktreePath, branch string) ([]types.ScaffoldStep, error) {
-	var stepsList []types.ScaffoldStep
+	return m.cleanupSteps(cfg, worktreePath, secrets.NewInterpolator(worktreePath))
+}
 
-	presetName := cfg.Preset
-	if presetName == "" {
-		presetName = m.DetectPreset(worktreePath)
+// cleanupSteps returns the preset and configured cleanup steps, resolving
+// secret references with interpolator
+func (m *ScaffoldManager) cleanupSteps(cfg *config.Config, worktreePath string, interpolator *secrets.Interpolator) ([]types.ScaffoldStep, error) {
+	preset, err := m.presetFor(cfg, worktreePath)
+	if err != nil {
+		return nil, fmt.Errorf("cleanup: %w", err)
 	}
 
-	if preset, ok := m.GetPreset(presetName); ok {
-		for _, cleanupConfig := range preset.CleanupSteps() {
-			stepConfig := config.StepConfig{
-				Name: cleanupConfig.Name,
-				Args: nil,
-			}
-			if cleanupConfig.Name == "herd" {
-				stepConfig.Args = []string{"unlink"}
-			}
-			for k, v := range cleanupConfig.Condition {
-				if k == "command" {
-					if cmd, ok := v.(string); ok {
-						stepConfig.Command = cmd
-					}
-				}
-			}
-			step := steps.Create(cleanupConfig.Name, stepConfig)
-			if step != nil {
-				stepsList = append(stepsList, step)
-			}
-		}
+	var cleanupConfigs []config.StepConfig
+	if preset != nil {
+		cleanupConfigs = append(cleanupConfigs, preset.CleanupSteps()...)
 	}
+	cleanupConfigs = append(cleanupConfigs, cfg.Cleanup...)
+	cleanupConfigs = append(cleanupConfigs, cfg.GlobalCleanup...)
 
-	for _, cleanupConfig := range cfg.Cleanup {
-		stepConfig := config.StepConfig{
-			Name: cleanupConfig.Name,
-			Args: nil,
-		}
-		if cleanupConfig.Name == "herd" {
-			stepConfig.Args = []string{"unlink"}
+	for i, stepConfig := range cleanupConfigs {
+		if len(stepConfig.Args) == 0 {
+			cleanupConfigs[i].Args = defaultCleanupArgs[stepConfig.Name]
 		}
-		for k, v := range cleanupConfig.Condition {
-			if k == "command" {
-				if cmd, ok := v.(string); ok {
-					stepConfig.Command = cmd
-				}
-			}
+	}
+
+	stepsList, err := m.stepsFromConfig(cleanupConfigs, interpolator)
+	if err != nil {
+		return nil, fmt.Errorf("cleanup: %w", err)
+	}
+
+	repo, err := repoSteps(worktreePath, HookCleanup)
+	if err != nil {
+		return nil, fmt.Errorf("cleanup: %w", err)
+	}
+	return append(stepsList, repo...), nil
+}
+
+func (m *ScaffoldManager) stepsFromConfig(stepConfigs []config.StepConfig, interpolator *secrets.Interpolator) ([]types.ScaffoldStep, error) {
+	stepsList := make([]types.ScaffoldStep, 0, len(stepConfigs))
+
+	for _, cfg := range stepConfigs {
+		cfg, err := interpolator.InterpolateStep(cfg)
+		if err != nil {
+			return nil, err
 		}
-		step := steps.Create(cleanupConfig.Name, stepConfig)
-		if step != nil {
-			stepsList = append(stepsList, step)
+		step, err := steps.CreateStep(cfg.Name, cfg)
+		if err != nil {
+			return nil, err
 		}
+		stepsList = append(stepsList, step)
 	}
 
 	return stepsList, nil
 }
 
-func (m *ScaffoldManager) stepsFromConfig(stepConfigs []config.StepConfig) []types.ScaffoldStep {
-	stepsList := make([]types.ScaffoldStep, 0, len(stepConfigs))
+// projectVars copies the configured vars so steps can add to them without
+// mutating the project config
+func projectVars(cfg *config.Config) map[string]string {
+	vars := make(map[string]string, len(cfg.Vars))
+	for k, v := range cfg.Vars {
+		vars[k] = v
+	}
+	return vars
+}
 
-	for _, cfg := range stepConfigs {
-		step := steps.Create(cfg.Name, cfg)
-		if step != nil {
-			stepsList = append(stepsList, step)
+// newContext builds the context steps run with. The project and bare paths
+// are found from the worktree, falling back to its parent directory when it
+// is not inside an arbor project.
+func newContext(worktreePath, branch, repoName, siteName, preset string, cfg *config.Config, worktreeConfig *config.WorktreeConfig, interpolator *secrets.Interpolator) (*types.ScaffoldContext, error) {
+	database, err := interpolator.InterpolateDatabase(cfg.DB)
+	if err != nil {
+		return nil, err
+	}
+
+	projectPath := filepath.Dir(worktreePath)
+	barePath, err := git.FindBarePath(worktreePath)
+	if err == nil {
+		projectPath = filepath.Dir(barePath)
+	} else {
+		barePath = ""
+	}
+
+	defaultBranch := cfg.DefaultBranch
+	if defaultBranch == "" && barePath != "" {
+		if defaultBranch, err = git.GetDefaultBranch(barePath); err != nil {
+			return nil, fmt.Errorf("finding default branch: %w", err)
 		}
 	}
+	if defaultBranch == "" {
+		defaultBranch = config.DefaultBranch
+	}
+
+	baseBranch := worktreeConfig.BaseBranch
+	if baseBranch == "" {
+		baseBranch = defaultBranch
+	}
 
-	return stepsList
+	folder := filepath.Base(worktreePath)
+	return &types.ScaffoldContext{
+		WorktreePath:   worktreePath,
+		Branch:         branch,
+		BaseBranch:     baseBranch,
+		RepoName:       repoName,
+		SiteName:       siteName,
+		Preset:         preset,
+		Env:            make(map[string]string),
+		Path:           folder,
+		RepoPath:       filepath.Base(filepath.Dir(worktreePath)),
+		ProjectPath:    projectPath,
+		BarePath:       barePath,
+		WorktreeFolder: folder,
+		Timestamp:      time.Now().Format(types.TimestampFormat),
+		Vars:           projectVars(cfg),
+		Database:       database,
+		Settings:       cfg.Settings,
+
+		DatabasePrefixes: cfg.DatabasePrefixes(),
+		MainWorktreePath: mainWorktreePath(barePath, defaultBranch),
+		WindowsShell:     cfg.WindowsShell,
+	}, nil
 }
 
-func (m *ScaffoldManager) RunScaffold(worktreePath, branch, repoName, siteName, preset string, cfg *config.Config, dryRun, verbose bool) error {
-	path := filepath.Base(worktreePath)
-	repoPath := filepath.Base(filepath.Dir(worktreePath))
-	ctx := types.ScaffoldContext{
-		WorktreePath: worktreePath,
-		Branch:       branch,
-		RepoName:     repoName,
-		SiteName:     siteName,
-		Preset:       preset,
-		Env:          make(map[string]string),
-		Path:         path,
-		RepoPath:     repoPath,
-		Vars:         make(map[string]string),
+// mainWorktreePath returns the path of the default branch's worktree, or ""
+// when it has none
+func mainWorktreePath(barePath, defaultBranch string) string {
+	if barePath == "" {
+		return ""
+	}
+	worktrees, err := git.ListWorktrees(barePath)
+	if err != nil {
+		return ""
+	}
+	for _, wt := range worktrees {
+		if wt.Branch == defaultBranch {
+			return wt.Path
+		}
+	}
+	return ""
+}
+
+// TemplateContext builds the context used to render templates outside of
+// scaffolding, such as pull request titles, for an existing worktree
+func TemplateContext(worktreePath, branch, siteName string, cfg *config.Config) (*types.ScaffoldContext, error) {
+	worktreeConfig, err := config.ReadWorktreeConfig(worktreePath)
+	if err != nil {
+		return nil, fmt.Errorf("reading worktree config: %w", err)
 	}
 
+	repoName := filepath.Base(filepath.Dir(worktreePath))
+	ctx, err := newContext(worktreePath, branch, repoName, siteName, cfg.Preset, cfg, worktreeConfig, secrets.NewInterpolator(worktreePath))
+	if err != nil {
+		return nil, err
+	}
+	ctx.SetDbSuffix(worktreeConfig.DbSuffix)
+	return ctx, nil
+}
+
+func (m *ScaffoldManager) RunScaffold(worktreePath, branch, repoName, siteName, preset string, cfg *config.Config, dryRun, verbose bool) error {
 	worktreeConfig, err := config.ReadWorktreeConfig(worktreePath)
 	if err != nil {
 		return fmt.Errorf("reading worktree config: %w", err)
 	}
 
+	// One interpolator per run, so each secret is fetched once
+	interpolator := secrets.NewInterpolator(worktreePath)
+	ctx, err := newContext(worktreePath, branch, repoName, siteName, preset, cfg, worktreeConfig, interpolator)
+	if err != nil {
+		return err
+	}
+	ctx.Interaction = m.Interaction
+
 	if worktreeConfig.DbSuffix == "" {
-		newSuffix := words.GenerateSuffix()
+		naming, err := steps.DatabaseNaming(ctx.Database)
+		if err != nil {
+			return err
+		}
+		newSuffix := naming.Suffix(branch)
 		ctx.SetDbSuffix(newSuffix)
 		if !dryRun {
 			if err := config.WriteWorktreeConfig(worktreePath, map[string]string{"db_suffix": newSuffix}); err != nil {
@@ -172,40 +295,48 @@ func (m *ScaffoldManager) RunScaffold(worktreePath, branch, repoName, siteName,
 		ctx.SetDbSuffix(worktreeConfig.DbSuffix)
 	}
 
-	stepsList, err := m.GetStepsForWorktree(cfg, worktreePath, branch)
+	stepsList, err := m.stepsForWorktree(cfg, worktreePath, interpolator)
 	if err != nil {
 		return fmt.Errorf("getting scaffold steps: %w", err)
 	}
 
+	inputs, err := m.inputsForWorktree(cfg, worktreePath)
+	if err != nil {
+		return err
+	}
+	if err := collectInputs(ctx, inputs, m.Interaction.Input); err != nil {
+		return err
+	}
+
 	opts := types.StepOptions{
 		DryRun:  dryRun,
 		Verbose: verbose,
 	}
 
-	executor := NewStepExecutor(stepsList, &ctx, opts)
-	if err := executor.Execute(); err != nil {
-		return err
+	executor := NewStepExecutor(stepsList, ctx, opts)
+	err = executor.Execute()
+	if m.StepTimings != nil && !dryRun {
+		m.StepTimings(ctx, executor.Results())
 	}
-
-	return nil
+	return err
 }
 
 func (m *ScaffoldManager) RunCleanup(worktreePath, branch, repoName, siteName, preset string, cfg *config.Config, dryRun, verbose bool) error {
-	path := filepath.Base(worktreePath)
-	repoPath := filepath.Base(filepath.Dir(worktreePath))
-	ctx := types.ScaffoldContext{
-		WorktreePath: worktreePath,
-		Branch:       branch,
-		RepoName:     repoName,
-		SiteName:     siteName,
-		Preset:       preset,
-		Env:          make(map[string]string),
-		Path:         path,
-		RepoPath:     repoPath,
-		Vars:         make(map[string]string),
-	}
-
-	stepsList, err := m.GetCleanupSteps(cfg, worktreePath, branch)
+	// A damaged worktree config should not stop its resources being cleaned up
+	worktreeConfig, err := config.ReadWorktreeConfig(worktreePath)
+	if err != nil {
+		worktreeConfig = &config.WorktreeConfig{}
+	}
+
+	interpolator := secrets.NewInterpolator(worktreePath)
+	ctx, err := newContext(worktreePath, branch, repoName, siteName, preset, cfg, worktreeConfig, interpolator)
+	if err != nil {
+		return err
+	}
+	ctx.Interaction = m.Interaction
+	ctx.SetDbSuffix(worktreeConfig.DbSuffix)
+
+	stepsList, err := m.cleanupSteps(cfg, worktreePath, interpolator)
 	if err != nil {
 		return fmt.Errorf("getting cleanup steps: %w", err)
 	}
@@ -215,7 +346,7 @@ func (m *ScaffoldManager) RunCleanup(worktreePath, branch, repoName, siteName, p
 		Verbose: verbose,
 	}
 
-	executor := NewStepExecutor(stepsList, &ctx, opts)
+	executor := NewStepExecutor(stepsList, ctx, opts)
 	if err := executor.Execute(); err != nil {
 		return err
 	}
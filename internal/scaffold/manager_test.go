@@ -0,0 +1,309 @@
+package scaffold
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/michaeldyrynda/arbor/internal/config"
+	arborerrors "github.com/michaeldyrynda/arbor/internal/errors"
+	"github.com/michaeldyrynda/arbor/internal/scaffold/template"
+	"github.com/michaeldyrynda/arbor/internal/scaffold/types"
+	"github.com/michaeldyrynda/arbor/internal/secrets"
+)
+
+func stepNames(stepsList []types.ScaffoldStep) []string {
+	names := make([]string, 0, len(stepsList))
+	for _, step := range stepsList {
+		names = append(names, step.Name())
+	}
+	return names
+}
+
+func TestScaffoldManager_GlobalDefaultSteps(t *testing.T) {
+	global := &config.GlobalConfig{
+		Scaffold: config.GlobalScaffoldConfig{
+			Steps: []config.StepConfig{
+				{Name: "bash.run", Command: "echo global"},
+				{Name: "env.write", Key: "GLOBAL", Value: "1"},
+			},
+		},
+		Cleanup: []config.StepConfig{{Name: "bash.run"}},
+	}
+
+	t.Run("global steps are merged with project steps", func(t *testing.T) {
+		cfg := &config.Config{
+			Scaffold: config.ScaffoldConfig{
+				Steps: []config.StepConfig{{Name: "file.copy", From: ".env.example", To: ".env"}},
+			},
+		}
+		cfg.ApplyGlobalDefaults(global)
+
+		stepsList, err := NewScaffoldManager().GetStepsForWorktree(cfg, t.TempDir(), "feature")
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"file.copy", "bash.run", "env.write"}, stepNames(stepsList))
+	})
+
+	t.Run("global steps survive override", func(t *testing.T) {
+		cfg := &config.Config{Scaffold: config.ScaffoldConfig{Override: true}}
+		cfg.ApplyGlobalDefaults(global)
+
+		stepsList, err := NewScaffoldManager().GetStepsForWorktree(cfg, t.TempDir(), "feature")
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"bash.run", "env.write"}, stepNames(stepsList))
+	})
+
+	t.Run("project can disable global steps by name", func(t *testing.T) {
+		cfg := &config.Config{Scaffold: config.ScaffoldConfig{Disable: []string{"bash.run"}}}
+		cfg.ApplyGlobalDefaults(global)
+
+		stepsList, err := NewScaffoldManager().GetStepsForWorktree(cfg, t.TempDir(), "feature")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"env.write"}, stepNames(stepsList))
+
+		cleanupSteps, err := NewScaffoldManager().GetCleanupSteps(cfg, t.TempDir(), "feature")
+		require.NoError(t, err)
+		assert.Empty(t, cleanupSteps)
+	})
+
+	t.Run("global cleanup steps are merged with project cleanup", func(t *testing.T) {
+		cfg := &config.Config{Cleanup: []config.StepConfig{{Name: "db.destroy"}}}
+		cfg.ApplyGlobalDefaults(global)
+
+		cleanupSteps, err := NewScaffoldManager().GetCleanupSteps(cfg, t.TempDir(), "feature")
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"db.destroy", "bash.run"}, stepNames(cleanupSteps))
+	})
+}
+
+func TestScaffoldManager_CleanupSteps(t *testing.T) {
+	planCommand := func(t *testing.T, step types.ScaffoldStep) string {
+		plan, err := step.(types.Planner).Plan(&types.ScaffoldContext{SiteName: "app"}, types.StepOptions{})
+		require.NoError(t, err)
+		return plan.Command
+	}
+
+	t.Run("herd unlinks by default", func(t *testing.T) {
+		cfg := &config.Config{Cleanup: []config.StepConfig{{Name: "herd"}}}
+
+		cleanupSteps, err := NewScaffoldManager().GetCleanupSteps(cfg, t.TempDir(), "feature")
+		require.NoError(t, err)
+		require.Len(t, cleanupSteps, 1)
+		assert.Equal(t, "herd unlink", planCommand(t, cleanupSteps[0]))
+	})
+
+	t.Run("cleanup steps keep args, priority and templates", func(t *testing.T) {
+		cfg := &config.Config{Cleanup: []config.StepConfig{
+			{Name: "herd", Args: []string{"unsecure", "{{ .SiteName }}"}, Priority: 5},
+			{Name: "bash.run", Command: "echo done"},
+		}}
+
+		cleanupSteps, err := NewScaffoldManager().GetCleanupSteps(cfg, t.TempDir(), "feature")
+		require.NoError(t, err)
+		require.Len(t, cleanupSteps, 2)
+		assert.Equal(t, "herd unsecure app", planCommand(t, cleanupSteps[0]))
+		assert.Equal(t, 5, cleanupSteps[0].Priority())
+	})
+
+	t.Run("cleanup steps resolve secrets", func(t *testing.T) {
+		t.Setenv("ARBOR_CLEANUP_SITE", "shop")
+		cfg := &config.Config{Cleanup: []config.StepConfig{{Name: "herd", Args: []string{"unlink", "${env:ARBOR_CLEANUP_SITE}"}}}}
+
+		cleanupSteps, err := NewScaffoldManager().GetCleanupSteps(cfg, t.TempDir(), "feature")
+		require.NoError(t, err)
+		assert.Equal(t, "herd unlink shop", planCommand(t, cleanupSteps[0]))
+	})
+}
+
+type mockPreset struct {
+	name   string
+	steps  []config.StepConfig
+	detect bool
+}
+
+func (p *mockPreset) Name() string                      { return p.name }
+func (p *mockPreset) Detect(path string) bool           { return p.detect }
+func (p *mockPreset) DefaultSteps() []config.StepConfig { return p.steps }
+func (p *mockPreset) CleanupSteps() []config.StepConfig { return nil }
+
+func TestScaffoldManager_RepoDir(t *testing.T) {
+	writeFile := func(t *testing.T, path, content string, mode os.FileMode) {
+		t.Helper()
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), mode))
+	}
+
+	newWorktree := func(t *testing.T) string {
+		worktree := t.TempDir()
+		writeFile(t, filepath.Join(worktree, "composer.json"), "{}", 0644)
+		writeFile(t, filepath.Join(worktree, ".arbor", "presets", "team.yaml"), `
+detect: [composer.json]
+steps:
+  - name: bash.run
+    command: echo team
+cleanup:
+  - name: bash.run
+    command: echo bye
+`, 0644)
+		return worktree
+	}
+
+	manager := NewScaffoldManager()
+	manager.RegisterPreset(&mockPreset{name: "php", steps: []config.StepConfig{{Name: "php.composer", Args: []string{"install"}}}, detect: true})
+
+	t.Run("detected repository presets win over built-ins", func(t *testing.T) {
+		stepsList, err := manager.GetStepsForWorktree(&config.Config{}, newWorktree(t), "feature")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"bash.run"}, stepNames(stepsList))
+	})
+
+	t.Run("configured built-in is still used", func(t *testing.T) {
+		stepsList, err := manager.GetStepsForWorktree(&config.Config{Preset: "php"}, newWorktree(t), "feature")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"php.composer"}, stepNames(stepsList))
+	})
+
+	t.Run("stubs and hooks are picked up", func(t *testing.T) {
+		worktree := newWorktree(t)
+		writeFile(t, filepath.Join(worktree, ".arbor", "stubs", "notes.md"), "stub", 0644)
+		writeFile(t, filepath.Join(worktree, ".arbor", "hooks", "scaffold"), "#!/bin/sh\n", 0755)
+		writeFile(t, filepath.Join(worktree, ".arbor", "hooks", "cleanup"), "#!/bin/sh\n", 0755)
+
+		stepsList, err := manager.GetStepsForWorktree(&config.Config{}, worktree, "feature")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"bash.run", "file.stubs", "command.run"}, stepNames(stepsList))
+
+		cleanupSteps, err := manager.GetCleanupSteps(&config.Config{}, worktree, "feature")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"bash.run", "command.run"}, stepNames(cleanupSteps))
+
+		plan, err := cleanupSteps[1].(types.Planner).Plan(&types.ScaffoldContext{WorktreePath: worktree}, types.StepOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(".arbor", "hooks", "cleanup"), plan.Command)
+	})
+
+	t.Run("invalid preset files fail", func(t *testing.T) {
+		worktree := newWorktree(t)
+		writeFile(t, filepath.Join(worktree, ".arbor", "presets", "broken.yaml"), "steps: {", 0644)
+
+		_, err := manager.GetStepsForWorktree(&config.Config{}, worktree, "feature")
+		assert.True(t, errors.Is(err, arborerrors.ErrInvalidConfig))
+	})
+}
+
+func TestNewContext_MainWorktreePath(t *testing.T) {
+	run := func(args ...string) {
+		t.Helper()
+		output, err := exec.Command("git", args...).CombinedOutput()
+		require.NoError(t, err, string(output))
+	}
+
+	source := t.TempDir()
+	run("-C", source, "init", "-q", "-b", "main")
+	run("-C", source, "-c", "user.name=Test", "-c", "user.email=test@example.com", "commit", "-q", "--allow-empty", "-m", "Initial")
+
+	projectPath := t.TempDir()
+	barePath := filepath.Join(projectPath, ".bare")
+	run("clone", "-q", "--bare", source, barePath)
+	mainPath := filepath.Join(projectPath, "main")
+	featurePath := filepath.Join(projectPath, "feature")
+	run("-C", barePath, "worktree", "add", "-q", mainPath, "main")
+	run("-C", barePath, "worktree", "add", "-q", "-b", "feature", featurePath)
+
+	ctx, err := newContext(featurePath, "feature", "myapp", "myapp", "", &config.Config{}, &config.WorktreeConfig{}, secrets.NewInterpolator(featurePath))
+	require.NoError(t, err)
+	assert.Equal(t, mainPath, ctx.MainWorktreePath)
+
+	data, err := template.ReplaceTemplateVars("{{ .MainWorktreePath }}/.env", ctx)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(mainPath, ".env"), data)
+
+	ctx, err = newContext(featurePath, "feature", "myapp", "myapp", "", &config.Config{DefaultBranch: "trunk"}, &config.WorktreeConfig{}, secrets.NewInterpolator(featurePath))
+	require.NoError(t, err)
+	assert.Empty(t, ctx.MainWorktreePath, "the default branch has no worktree")
+}
+
+func TestScaffoldManager_UnknownStep(t *testing.T) {
+	cfg := &config.Config{Scaffold: config.ScaffoldConfig{Steps: []config.StepConfig{{Name: "php.composr"}}}}
+
+	_, err := NewScaffoldManager().GetStepsForWorktree(cfg, t.TempDir(), "feature")
+	require.Error(t, err)
+	assert.ErrorIs(t, err, arborerrors.ErrInvalidConfig)
+	assert.Contains(t, err.Error(), `unknown step type "php.composr"`)
+	assert.Contains(t, err.Error(), "php.composer")
+}
+
+func TestScaffoldManager_RunScaffoldResolvesSecretsOnce(t *testing.T) {
+	calls := 0
+	secrets.RegisterProvider("scaffoldtest", func(ref, dir string) (string, error) {
+		calls++
+		return "hunter2", nil
+	})
+
+	worktreePath := t.TempDir()
+	cfg := &config.Config{
+		DB: config.DatabaseConfig{Password: "${scaffoldtest:db}"},
+		Scaffold: config.ScaffoldConfig{
+			Steps: []config.StepConfig{
+				{Name: "env.write", Key: "DB_PASSWORD", Value: "${scaffoldtest:db}"},
+				{Name: "env.write", Key: "VITE_APP_NAME", Value: "${APP_NAME}"},
+			},
+		},
+	}
+
+	require.NoError(t, NewScaffoldManager().RunScaffold(worktreePath, "feature", "repo", "site", "", cfg, false, false))
+
+	data, err := os.ReadFile(filepath.Join(worktreePath, ".env"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "DB_PASSWORD=hunter2")
+	assert.Contains(t, string(data), "${APP_NAME}", "references without a provider are written as is")
+	assert.Equal(t, 1, calls, "the database and steps should share one lookup")
+}
+
+func TestNewContext(t *testing.T) {
+	projectPath := t.TempDir()
+	output, err := exec.Command("git", "init", "-q", "--bare", "-b", "main", filepath.Join(projectPath, ".bare")).CombinedOutput()
+	require.NoError(t, err, string(output))
+	worktreePath := filepath.Join(projectPath, "feature-auth")
+	require.NoError(t, os.Mkdir(worktreePath, 0755))
+
+	t.Run("fills project fields", func(t *testing.T) {
+		ctx, err := newContext(worktreePath, "feature/auth", "myapp", "myapp", "", &config.Config{}, &config.WorktreeConfig{BaseBranch: "develop"}, secrets.NewInterpolator(worktreePath))
+		require.NoError(t, err)
+
+		assert.Equal(t, "develop", ctx.BaseBranch)
+		assert.Equal(t, projectPath, ctx.ProjectPath)
+		assert.Equal(t, filepath.Join(projectPath, ".bare"), ctx.BarePath)
+		assert.Equal(t, "feature-auth", ctx.WorktreeFolder)
+		assert.Regexp(t, `^\d{14}$`, ctx.Timestamp)
+	})
+
+	t.Run("base branch falls back to the default branch", func(t *testing.T) {
+		ctx, err := newContext(worktreePath, "feature/auth", "myapp", "myapp", "", &config.Config{DefaultBranch: "trunk"}, &config.WorktreeConfig{}, secrets.NewInterpolator(worktreePath))
+		require.NoError(t, err)
+		assert.Equal(t, "trunk", ctx.BaseBranch)
+	})
+
+	t.Run("main worktree path is empty outside a project", func(t *testing.T) {
+		ctx, err := newContext(worktreePath, "feature/auth", "myapp", "myapp", "", &config.Config{}, &config.WorktreeConfig{}, secrets.NewInterpolator(worktreePath))
+		require.NoError(t, err)
+		assert.Empty(t, ctx.MainWorktreePath)
+	})
+
+	t.Run("template context reads the worktree config", func(t *testing.T) {
+		require.NoError(t, config.WriteWorktreeConfig(worktreePath, map[string]string{"db_suffix": "swift_fox", "base_branch": "develop"}))
+
+		ctx, err := TemplateContext(worktreePath, "feature/auth", "feature-auth", &config.Config{})
+		require.NoError(t, err)
+		assert.Equal(t, "swift_fox", ctx.GetDbSuffix())
+		assert.Equal(t, "develop", ctx.BaseBranch)
+	})
+}
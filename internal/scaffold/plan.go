@@ -0,0 +1,149 @@
+package scaffold
+
+import (
+	"fmt"
+
+	"github.com/michaeldyrynda/arbor/internal/config"
+	"github.com/michaeldyrynda/arbor/internal/scaffold/types"
+	"github.com/michaeldyrynda/arbor/internal/secrets"
+)
+
+// Placeholders used in a plan for values only known when the scaffold runs,
+// so the same worktree and config always give the same plan
+const (
+	PlanDbSuffix  = "<suffix>"
+	PlanTimestamp = "<timestamp>"
+)
+
+// PlanOptions describes the worktree to plan a scaffold for
+type PlanOptions struct {
+	WorktreePath string
+	// SourcePath is where files are read and conditions checked, for a
+	// worktree that does not exist yet. It defaults to WorktreePath.
+	SourcePath string
+	Branch     string
+	// BaseBranch is used when the worktree has not recorded one
+	BaseBranch string
+	RepoName   string
+	SiteName   string
+	Preset     string
+}
+
+// Plan is the ordered list of steps a scaffold would run, as shown by
+// --dry-run --plan
+type Plan struct {
+	Worktree string `json:"worktree"`
+	// Source is set when files were read from another worktree
+	Source   string        `json:"source,omitempty"`
+	Branch   string        `json:"branch"`
+	Preset   string        `json:"preset"`
+	DbSuffix string        `json:"dbSuffix"`
+	Steps    []PlannedStep `json:"steps"`
+}
+
+// PlannedStep is one step of a plan. Steps with the same group run in
+// parallel.
+type PlannedStep struct {
+	Group    int    `json:"group"`
+	Name     string `json:"name"`
+	Priority int    `json:"priority"`
+	Run      bool   `json:"run"`
+	// Reason is why a step would be skipped
+	Reason string `json:"reason,omitempty"`
+	types.StepPlan
+	// Error is set when the step could not be planned, such as a template
+	// referencing an unknown field
+	Error string `json:"error,omitempty"`
+}
+
+// PlanScaffold resolves the steps a scaffold of the worktree would run
+// without running any of them. Secret references are left as written and
+// inputs take their defaults.
+func (m *ScaffoldManager) PlanScaffold(opts PlanOptions, cfg *config.Config) (*Plan, error) {
+	source := opts.SourcePath
+	if source == "" {
+		source = opts.WorktreePath
+	}
+
+	worktreeConfig, err := config.ReadWorktreeConfig(opts.WorktreePath)
+	if err != nil {
+		return nil, fmt.Errorf("reading worktree config: %w", err)
+	}
+	if worktreeConfig.BaseBranch == "" {
+		worktreeConfig.BaseBranch = opts.BaseBranch
+	}
+
+	interpolator := secrets.NewPreviewInterpolator(source)
+	ctx, err := newContext(opts.WorktreePath, opts.Branch, opts.RepoName, opts.SiteName, opts.Preset, cfg, worktreeConfig, interpolator)
+	if err != nil {
+		return nil, err
+	}
+	ctx.WorktreePath = source
+	ctx.Timestamp = PlanTimestamp
+
+	suffix := worktreeConfig.DbSuffix
+	if suffix == "" {
+		suffix = PlanDbSuffix
+	}
+	ctx.SetDbSuffix(suffix)
+
+	stepsList, err := m.stepsForWorktree(cfg, source, interpolator)
+	if err != nil {
+		return nil, fmt.Errorf("getting scaffold steps: %w", err)
+	}
+	inputs, err := m.inputsForWorktree(cfg, source)
+	if err != nil {
+		return nil, err
+	}
+	if err := collectInputs(ctx, inputs, nil); err != nil {
+		return nil, err
+	}
+
+	plan := &Plan{
+		Worktree: opts.WorktreePath,
+		Branch:   opts.Branch,
+		Preset:   opts.Preset,
+		DbSuffix: suffix,
+		Steps:    NewStepExecutor(stepsList, ctx, types.StepOptions{DryRun: true}).Plan(),
+	}
+	if source != opts.WorktreePath {
+		plan.Source = source
+	}
+	return plan, nil
+}
+
+// Plan returns the steps in the order Execute runs them, with whether each
+// would run and what it would do. Steps are planned one at a time, so
+// steps reading the context see what earlier steps stored.
+func (e *StepExecutor) Plan() []PlannedStep {
+	var planned []PlannedStep
+	for group, steps := range e.groupByPriority(e.sortByPriority()) {
+		for _, step := range steps {
+			planned = append(planned, e.planStep(step, group+1))
+		}
+	}
+	return planned
+}
+
+func (e *StepExecutor) planStep(step types.ScaffoldStep, group int) PlannedStep {
+	planned := PlannedStep{Group: group, Name: step.Name(), Priority: step.Priority()}
+
+	if stepConfig, ok := step.(interface{ IsEnabled() bool }); ok && !stepConfig.IsEnabled() {
+		planned.Reason = "disabled"
+		return planned
+	}
+	if !step.Condition(e.ctx) {
+		planned.Reason = "condition not met"
+		return planned
+	}
+
+	planned.Run = true
+	if planner, ok := step.(types.Planner); ok {
+		stepPlan, err := planner.Plan(e.ctx, e.opts)
+		if err != nil {
+			planned.Error = err.Error()
+		}
+		planned.StepPlan = stepPlan
+	}
+	return planned
+}
@@ -0,0 +1,103 @@
+package scaffold
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/michaeldyrynda/arbor/internal/config"
+	"github.com/michaeldyrynda/arbor/internal/scaffold/types"
+)
+
+func TestStepExecutor_Plan(t *testing.T) {
+	steps := []types.ScaffoldStep{
+		&mockStep{name: "late", priority: 20, conditionResult: true},
+		&mockStep{name: "skipped", priority: 10, conditionResult: false},
+		&mockStep{name: "early", priority: 10, conditionResult: true},
+	}
+
+	planned := NewStepExecutor(steps, &types.ScaffoldContext{}, types.StepOptions{DryRun: true}).Plan()
+
+	require.Len(t, planned, 3)
+	assert.Equal(t, PlannedStep{Group: 1, Name: "skipped", Priority: 10, Reason: "condition not met"}, planned[0])
+	assert.Equal(t, PlannedStep{Group: 1, Name: "early", Priority: 10, Run: true}, planned[1])
+	assert.Equal(t, PlannedStep{Group: 2, Name: "late", Priority: 20, Run: true}, planned[2])
+	for _, step := range steps {
+		assert.False(t, step.(*mockStep).runCalled)
+	}
+}
+
+func TestScaffoldManager_PlanScaffold(t *testing.T) {
+	worktreePath := filepath.Join(t.TempDir(), "feature-auth")
+	require.NoError(t, os.MkdirAll(worktreePath, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(worktreePath, ".env"), []byte("APP_URL=http://localhost\n"), 0644))
+
+	cfg := &config.Config{
+		Scaffold: config.ScaffoldConfig{
+			Override: true,
+			Steps: []config.StepConfig{
+				{Name: "env.read", Key: "APP_URL"},
+				{Name: "env.write", Key: "APP_URL", Value: "{{ .Vars.APP_URL }}/{{ .Path }}"},
+				{Name: "file.copy", From: ".env.testing.example", To: ".env.testing"},
+				{Name: "command.run", Command: "echo {{ .DbSuffix }} ${DEPLOY_TOKEN}"},
+				{Name: "env.write", Key: "DB_PASSWORD", Value: "${op://vault/db/password}"},
+			},
+		},
+	}
+
+	opts := PlanOptions{WorktreePath: worktreePath, Branch: "feature/auth", RepoName: "myapp", SiteName: "feature-auth"}
+	plan, err := NewScaffoldManager().PlanScaffold(opts, cfg)
+	require.NoError(t, err)
+
+	assert.Equal(t, PlanDbSuffix, plan.DbSuffix)
+	assert.Empty(t, plan.Source)
+	require.Len(t, plan.Steps, 5)
+
+	byName := map[string][]PlannedStep{}
+	for _, step := range plan.Steps {
+		byName[step.Name] = append(byName[step.Name], step)
+	}
+	assert.Equal(t, "reads APP_URL from .env", byName["env.read"][0].Detail)
+	assert.Equal(t, []string{".env"}, byName["env.write"][0].Files)
+	assert.Equal(t, "APP_URL=http://localhost/feature-auth", byName["env.write"][0].Detail)
+	assert.Equal(t, "DB_PASSWORD=${op://vault/db/password}", byName["env.write"][1].Detail, "secrets are not resolved")
+	assert.Equal(t, "echo <suffix> ${DEPLOY_TOKEN}", byName["command.run"][0].Command)
+	assert.False(t, byName["file.copy"][0].Run)
+	assert.Equal(t, "condition not met", byName["file.copy"][0].Reason)
+
+	data, err := os.ReadFile(filepath.Join(worktreePath, ".env"))
+	require.NoError(t, err)
+	assert.Equal(t, "APP_URL=http://localhost\n", string(data), "planning writes nothing")
+
+	again, err := NewScaffoldManager().PlanScaffold(opts, cfg)
+	require.NoError(t, err)
+	assert.Equal(t, plan, again)
+}
+
+func TestScaffoldManager_PlanScaffold_NewWorktree(t *testing.T) {
+	project := t.TempDir()
+	mainPath := filepath.Join(project, "main")
+	require.NoError(t, os.MkdirAll(mainPath, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(mainPath, ".env.example"), []byte("APP_NAME=app\n"), 0644))
+
+	cfg := &config.Config{
+		Scaffold: config.ScaffoldConfig{
+			Override: true,
+			Steps:    []config.StepConfig{{Name: "file.copy", From: ".env.example", To: ".env"}},
+		},
+	}
+
+	worktreePath := filepath.Join(project, "feature-auth")
+	plan, err := NewScaffoldManager().PlanScaffold(PlanOptions{WorktreePath: worktreePath, SourcePath: mainPath, Branch: "feature/auth", BaseBranch: "main"}, cfg)
+	require.NoError(t, err)
+
+	assert.Equal(t, worktreePath, plan.Worktree)
+	assert.Equal(t, mainPath, plan.Source)
+	require.Len(t, plan.Steps, 1)
+	assert.True(t, plan.Steps[0].Run)
+	assert.Equal(t, []string{".env"}, plan.Steps[0].Files)
+	assert.NoDirExists(t, worktreePath)
+}
@@ -0,0 +1,129 @@
+package scaffold
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/michaeldyrynda/arbor/internal/config"
+	"github.com/michaeldyrynda/arbor/internal/scaffold/steps"
+	"github.com/michaeldyrynda/arbor/internal/scaffold/types"
+)
+
+// Hook scripts in .arbor/hooks, run as command.run steps when present
+const (
+	// HookScaffold runs after the other scaffold steps at its priority
+	HookScaffold = "scaffold"
+	// HookCleanup runs when a worktree is removed
+	HookCleanup = "cleanup"
+)
+
+// repoPreset is a preset committed in the repository's .arbor/presets
+type repoPreset struct {
+	cfg config.RepoPresetConfig
+}
+
+func (p *repoPreset) Name() string {
+	return p.cfg.Name
+}
+
+func (p *repoPreset) Detect(path string) bool {
+	if len(p.cfg.Detect) == 0 {
+		return false
+	}
+	for _, file := range p.cfg.Detect {
+		if _, err := os.Stat(filepath.Join(path, file)); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *repoPreset) DefaultSteps() []config.StepConfig {
+	return p.cfg.Steps
+}
+
+func (p *repoPreset) CleanupSteps() []config.StepConfig {
+	return p.cfg.Cleanup
+}
+
+// RepoPresets returns the presets committed in the worktree's .arbor
+// directory
+func RepoPresets(worktreePath string) ([]Preset, error) {
+	configs, err := config.LoadRepoPresets(worktreePath)
+	if err != nil {
+		return nil, err
+	}
+
+	presets := make([]Preset, 0, len(configs))
+	for _, cfg := range configs {
+		presets = append(presets, &repoPreset{cfg: cfg})
+	}
+	return presets, nil
+}
+
+// DetectRepoPreset returns the first preset committed in the worktree's
+// .arbor directory that detects it, or "" when none does
+func DetectRepoPreset(worktreePath string) (string, error) {
+	presets, err := RepoPresets(worktreePath)
+	if err != nil {
+		return "", err
+	}
+	for _, preset := range presets {
+		if preset.Detect(worktreePath) {
+			return preset.Name(), nil
+		}
+	}
+	return "", nil
+}
+
+// presetFor returns the preset whose steps a worktree runs: the configured
+// one, or the first detected. The repository's presets are tried before the
+// built-ins, so a team can replace a built-in by committing one of its name.
+// It returns nil when there is no preset.
+func (m *ScaffoldManager) presetFor(cfg *config.Config, worktreePath string) (Preset, error) {
+	repoPresets, err := RepoPresets(worktreePath)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, preset := range repoPresets {
+		if preset.Name() == cfg.Preset || (cfg.Preset == "" && preset.Detect(worktreePath)) {
+			return preset, nil
+		}
+	}
+
+	presetName := cfg.Preset
+	if presetName == "" {
+		presetName = m.DetectPreset(worktreePath)
+	}
+	if preset, ok := m.GetPreset(presetName); ok {
+		return preset, nil
+	}
+	return nil, nil
+}
+
+// repoSteps returns the steps for the assets in the worktree's .arbor
+// directory: file.stubs when it has stubs, and the named hook when it exists
+func repoSteps(worktreePath, hook string) ([]types.ScaffoldStep, error) {
+	var configs []config.StepConfig
+	if hook == HookScaffold {
+		if info, err := os.Stat(filepath.Join(worktreePath, steps.DefaultStubsDir)); err == nil && info.IsDir() {
+			configs = append(configs, config.StepConfig{Name: "file.stubs"})
+		}
+	}
+
+	hookPath := filepath.Join(config.RepoDir, config.RepoHooksDir, hook)
+	if _, err := os.Stat(filepath.Join(worktreePath, hookPath)); err == nil {
+		configs = append(configs, config.StepConfig{Name: "command.run", Command: hookPath})
+	}
+
+	stepsList := make([]types.ScaffoldStep, 0, len(configs))
+	for _, cfg := range configs {
+		step, err := steps.CreateStep(cfg.Name, cfg)
+		if err != nil {
+			return nil, err
+		}
+		stepsList = append(stepsList, step)
+	}
+	return stepsList, nil
+}
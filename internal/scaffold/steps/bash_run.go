@@ -4,18 +4,28 @@ import (
 	"fmt"
 	"os/exec"
 
+	"github.com/michaeldyrynda/arbor/internal/config"
 	"github.com/michaeldyrynda/arbor/internal/scaffold/template"
 	"github.com/michaeldyrynda/arbor/internal/scaffold/types"
 )
 
 type BashRunStep struct {
-	command string
+	command   string
+	env       map[string]string
+	dir       string
+	captureAs string
 }
 
 func NewBashRunStep(command string) *BashRunStep {
 	return &BashRunStep{command: command}
 }
 
+// NewBashRunStepWithConfig returns a step for cfg's command, run with cfg's
+// env in cfg's dir, capturing its output when cfg sets capture_as
+func NewBashRunStepWithConfig(cfg config.StepConfig) *BashRunStep {
+	return &BashRunStep{command: cfg.Command, env: cfg.Env, dir: cfg.Dir, captureAs: cfg.CaptureAs}
+}
+
 func (s *BashRunStep) Name() string {
 	return "bash.run"
 }
@@ -27,16 +37,34 @@ func (s *BashRunStep) Run(ctx *types.ScaffoldContext, opts types.StepOptions) er
 	}
 
 	cmd := exec.Command("bash", "-c", command)
-	cmd.Dir = ctx.WorktreePath
-	output, err := cmd.CombinedOutput()
+	if cmd.Dir, err = stepDir("bash.run", s.dir, ctx); err != nil {
+		return err
+	}
+	if cmd.Env, err = stepCommandEnv("bash.run", s.env, ctx); err != nil {
+		return err
+	}
+	output, err := runStepCommand(cmd, s.captureAs, ctx)
 	if err != nil {
 		return fmt.Errorf("bash.run failed: %w\n%s", err, string(output))
 	}
 	return nil
 }
 
+// Plan returns the command the step would run
+func (s *BashRunStep) Plan(ctx *types.ScaffoldContext, opts types.StepOptions) (types.StepPlan, error) {
+	command, err := template.ReplaceTemplateVars(s.command, ctx)
+	if err != nil {
+		return types.StepPlan{}, fmt.Errorf("template replacement failed: %w", err)
+	}
+	env, err := renderStepEnv("bash.run", s.env, ctx)
+	if err != nil {
+		return types.StepPlan{}, err
+	}
+	return types.StepPlan{Command: planCommand(env, command), Tools: []string{"bash"}, Detail: joinDetails(stepDirDetail(s.dir, ctx), planCapture("bash.run", s.captureAs, ctx))}, nil
+}
+
 func (s *BashRunStep) Priority() int {
-	return 100
+	return PriorityCommand
 }
 
 func (s *BashRunStep) Condition(ctx *types.ScaffoldContext) bool {
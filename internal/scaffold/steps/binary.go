@@ -3,11 +3,12 @@ package steps
 import (
 	"fmt"
 	"os/exec"
-	"strings"
 
 	"github.com/michaeldyrynda/arbor/internal/config"
+	"github.com/michaeldyrynda/arbor/internal/logging"
 	"github.com/michaeldyrynda/arbor/internal/scaffold/template"
 	"github.com/michaeldyrynda/arbor/internal/scaffold/types"
+	"github.com/michaeldyrynda/arbor/internal/utils"
 )
 
 type BinaryStep struct {
@@ -16,6 +17,9 @@ type BinaryStep struct {
 	args      []string
 	priority  int
 	condition map[string]interface{}
+	env       map[string]string
+	dir       string
+	captureAs string
 }
 
 func NewBinaryStep(name, binary string, args []string, priority int) *BinaryStep {
@@ -35,6 +39,9 @@ func NewBinaryStepWithCondition(name string, cfg config.StepConfig, binary strin
 		args:      cfg.Args,
 		priority:  priority,
 		condition: cfg.Condition,
+		env:       cfg.Env,
+		dir:       cfg.Dir,
+		captureAs: cfg.CaptureAs,
 	}
 }
 
@@ -55,7 +62,7 @@ func (s *BinaryStep) Condition(ctx *types.ScaffoldContext) bool {
 		return result
 	}
 
-	binaries := strings.Fields(s.binary)
+	binaries := utils.SplitCommand(s.binary)
 	if len(binaries) == 0 {
 		return false
 	}
@@ -64,29 +71,63 @@ func (s *BinaryStep) Condition(ctx *types.ScaffoldContext) bool {
 }
 
 func (s *BinaryStep) Run(ctx *types.ScaffoldContext, opts types.StepOptions) error {
-	allArgs := append(s.args, opts.Args...)
-	allArgs = s.replaceTemplate(allArgs, ctx)
-	if opts.Verbose {
-		binaryParts := strings.Fields(s.binary)
-		fullCmd := append(binaryParts, allArgs...)
-		fmt.Printf("  Running: %s\n", strings.Join(fullCmd, " "))
+	allArgs, err := s.replaceTemplate(append(append([]string{}, s.args...), opts.Args...), ctx)
+	if err != nil {
+		return err
+	}
+	binaryParts := utils.SplitCommand(s.binary)
+	if len(binaryParts) == 0 {
+		return fmt.Errorf("%s: no binary to run", s.name)
+	}
+	logging.Verbosef("  Running: %s", logging.CommandLine(append(append([]string{}, binaryParts...), allArgs...)))
+	// Arguments are passed directly rather than through a shell, so quoting
+	// behaves the same under sh, cmd and PowerShell
+	cmd := exec.Command(binaryParts[0], append(binaryParts[1:], allArgs...)...)
+	if cmd.Dir, err = stepDir(s.name, s.dir, ctx); err != nil {
+		return err
 	}
-	cmd := exec.Command(strings.Fields(s.binary)[0], append(strings.Fields(s.binary)[1:], allArgs...)...)
-	cmd.Dir = ctx.WorktreePath
-	output, err := cmd.CombinedOutput()
+	if cmd.Env, err = stepCommandEnv(s.name, s.env, ctx); err != nil {
+		return err
+	}
+	output, err := runStepCommand(cmd, s.captureAs, ctx)
 	if err != nil {
 		return fmt.Errorf("%s failed: %w\n%s", s.name, err, string(output))
 	}
 	return nil
 }
 
-func (s *BinaryStep) replaceTemplate(args []string, ctx *types.ScaffoldContext) []string {
+// Plan returns the command the step would run
+func (s *BinaryStep) Plan(ctx *types.ScaffoldContext, opts types.StepOptions) (types.StepPlan, error) {
+	allArgs, err := s.replaceTemplate(append(append([]string{}, s.args...), opts.Args...), ctx)
+	if err != nil {
+		return types.StepPlan{}, err
+	}
+	binaryParts := utils.SplitCommand(s.binary)
+	if len(binaryParts) == 0 {
+		return types.StepPlan{}, fmt.Errorf("%s: no binary to run", s.name)
+	}
+	env, err := renderStepEnv(s.name, s.env, ctx)
+	if err != nil {
+		return types.StepPlan{}, err
+	}
+	return types.StepPlan{
+		Command: planCommand(env, logging.CommandLine(append(binaryParts, allArgs...))),
+		Tools:   binaryParts[:1],
+		Detail:  joinDetails(stepDirDetail(s.dir, ctx), planCapture(s.name, s.captureAs, ctx)),
+	}, nil
+}
+
+// replaceTemplate renders each arg against the context, returning a new
+// slice. An arg that does not parse as a template, or references an unknown
+// field, is an error.
+func (s *BinaryStep) replaceTemplate(args []string, ctx *types.ScaffoldContext) ([]string, error) {
+	replaced := make([]string, len(args))
 	for i, arg := range args {
-		replaced, err := template.ReplaceTemplateVars(arg, ctx)
+		value, err := template.ReplaceTemplateVars(arg, ctx)
 		if err != nil {
-			continue
+			return nil, fmt.Errorf("%s: rendering arg %q: %w", s.name, arg, err)
 		}
-		args[i] = replaced
+		replaced[i] = value
 	}
-	return args
+	return replaced, nil
 }
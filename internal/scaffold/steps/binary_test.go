@@ -12,6 +12,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/michaeldyrynda/arbor/internal/config"
+	"github.com/michaeldyrynda/arbor/internal/scaffold/template"
 	"github.com/michaeldyrynda/arbor/internal/scaffold/types"
 )
 
@@ -466,7 +467,8 @@ func TestBinaryStep_TemplateReplacement(t *testing.T) {
 			SiteName:     "myapp",
 		}
 
-		replacedArgs := binaryStep.replaceTemplate(binaryStep.args, ctx)
+		replacedArgs, err := binaryStep.replaceTemplate(binaryStep.args, ctx)
+		require.NoError(t, err)
 		assert.Contains(t, replacedArgs, "--name=myapp")
 	})
 
@@ -482,7 +484,8 @@ func TestBinaryStep_TemplateReplacement(t *testing.T) {
 			RepoName:     "myrepo",
 		}
 
-		replacedArgs := binaryStep.replaceTemplate(binaryStep.args, ctx)
+		replacedArgs, err := binaryStep.replaceTemplate(binaryStep.args, ctx)
+		require.NoError(t, err)
 		assert.Contains(t, replacedArgs, "--repo=myrepo")
 	})
 
@@ -499,7 +502,8 @@ func TestBinaryStep_TemplateReplacement(t *testing.T) {
 			Path:         "feature-auth",
 		}
 
-		replacedArgs := binaryStep.replaceTemplate(binaryStep.args, ctx)
+		replacedArgs, err := binaryStep.replaceTemplate(binaryStep.args, ctx)
+		require.NoError(t, err)
 		assert.Contains(t, replacedArgs, "--domain=feature-auth.test")
 	})
 
@@ -515,7 +519,8 @@ func TestBinaryStep_TemplateReplacement(t *testing.T) {
 		}
 		ctx.SetDbSuffix("swift_runner")
 
-		replacedArgs := binaryStep.replaceTemplate(binaryStep.args, ctx)
+		replacedArgs, err := binaryStep.replaceTemplate(binaryStep.args, ctx)
+		require.NoError(t, err)
 		assert.Contains(t, replacedArgs, "--database=myapp_swift_runner")
 	})
 
@@ -531,7 +536,8 @@ func TestBinaryStep_TemplateReplacement(t *testing.T) {
 		}
 		ctx.SetVar("VarName", "custom_value")
 
-		replacedArgs := binaryStep.replaceTemplate(binaryStep.args, ctx)
+		replacedArgs, err := binaryStep.replaceTemplate(binaryStep.args, ctx)
+		require.NoError(t, err)
 		assert.Contains(t, replacedArgs, "--name=custom_value")
 	})
 
@@ -549,13 +555,14 @@ func TestBinaryStep_TemplateReplacement(t *testing.T) {
 			Path:         "mypath",
 		}
 
-		replacedArgs := binaryStep.replaceTemplate(binaryStep.args, ctx)
+		replacedArgs, err := binaryStep.replaceTemplate(binaryStep.args, ctx)
+		require.NoError(t, err)
 		assert.Contains(t, replacedArgs, "--name=myapp")
 		assert.Contains(t, replacedArgs, "--repo=myrepo")
 		assert.Contains(t, replacedArgs, "--path=mypath")
 	})
 
-	t.Run("ignores template errors for invalid syntax", func(t *testing.T) {
+	t.Run("errors on invalid template syntax", func(t *testing.T) {
 		step := Create("php.composer", config.StepConfig{
 			Args: []string{"--name={{ invalid_syntax }", "--fallback=value"},
 		})
@@ -567,9 +574,44 @@ func TestBinaryStep_TemplateReplacement(t *testing.T) {
 			SiteName:     "myapp",
 		}
 
-		replacedArgs := binaryStep.replaceTemplate(binaryStep.args, ctx)
-		assert.Equal(t, "--name={{ invalid_syntax }", replacedArgs[0])
-		assert.Equal(t, "--fallback=value", replacedArgs[1])
+		_, err := binaryStep.replaceTemplate(binaryStep.args, ctx)
+		assert.ErrorIs(t, err, template.ErrInvalidTemplate)
+		assert.ErrorContains(t, err, `rendering arg "--name={{ invalid_syntax }"`)
+	})
+
+	t.Run("writes a literal {{ with a quoted action", func(t *testing.T) {
+		step := Create("php.composer", config.StepConfig{
+			Args: []string{`--format={{"{{"}} .Name }}`},
+		})
+		binaryStep, ok := step.(*BinaryStep)
+		require.True(t, ok, "Expected BinaryStep type")
+
+		replacedArgs, err := binaryStep.replaceTemplate(binaryStep.args, &types.ScaffoldContext{})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"--format={{ .Name }}"}, replacedArgs)
+	})
+
+	t.Run("errors on unknown fields", func(t *testing.T) {
+		step := Create("php.laravel.artisan", config.StepConfig{
+			Args: []string{"migrate", "--database={{ .SiteName }}_{{ .DbSufix }}"},
+		})
+		binaryStep, ok := step.(*BinaryStep)
+		require.True(t, ok, "Expected BinaryStep type")
+
+		_, err := binaryStep.replaceTemplate(binaryStep.args, &types.ScaffoldContext{SiteName: "myapp"})
+		assert.ErrorContains(t, err, "DbSufix")
+	})
+
+	t.Run("does not modify the configured args", func(t *testing.T) {
+		step := Create("php.composer", config.StepConfig{
+			Args: []string{"--name={{ .SiteName }}"},
+		})
+		binaryStep, ok := step.(*BinaryStep)
+		require.True(t, ok, "Expected BinaryStep type")
+
+		_, err := binaryStep.replaceTemplate(binaryStep.args, &types.ScaffoldContext{SiteName: "myapp"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"--name={{ .SiteName }}"}, binaryStep.args)
 	})
 }
 
@@ -2,35 +2,69 @@ package steps
 
 import (
 	"fmt"
-	"os/exec"
 
+	"github.com/michaeldyrynda/arbor/internal/config"
+	"github.com/michaeldyrynda/arbor/internal/scaffold/template"
 	"github.com/michaeldyrynda/arbor/internal/scaffold/types"
+	"github.com/michaeldyrynda/arbor/internal/utils"
 )
 
 type CommandRunStep struct {
-	command string
+	command   string
+	env       map[string]string
+	dir       string
+	captureAs string
 }
 
 func NewCommandRunStep(command string) *CommandRunStep {
 	return &CommandRunStep{command: command}
 }
 
+// NewCommandRunStepWithConfig returns a step for cfg's command, run with cfg's
+// env in cfg's dir, capturing its output when cfg sets capture_as
+func NewCommandRunStepWithConfig(cfg config.StepConfig) *CommandRunStep {
+	return &CommandRunStep{command: cfg.Command, env: cfg.Env, dir: cfg.Dir, captureAs: cfg.CaptureAs}
+}
+
 func (s *CommandRunStep) Name() string {
 	return "command.run"
 }
 
 func (s *CommandRunStep) Run(ctx *types.ScaffoldContext, opts types.StepOptions) error {
-	cmd := exec.Command("sh", "-c", s.command)
-	cmd.Dir = ctx.WorktreePath
-	output, err := cmd.CombinedOutput()
+	command, err := template.ReplaceTemplateVars(s.command, ctx)
+	if err != nil {
+		return fmt.Errorf("template replacement failed: %w", err)
+	}
+
+	cmd := utils.ShellCommand(ctx.WindowsShell, command)
+	if cmd.Dir, err = stepDir("command.run", s.dir, ctx); err != nil {
+		return err
+	}
+	if cmd.Env, err = stepCommandEnv("command.run", s.env, ctx); err != nil {
+		return err
+	}
+	output, err := runStepCommand(cmd, s.captureAs, ctx)
 	if err != nil {
 		return fmt.Errorf("command.run failed: %w\n%s", err, string(output))
 	}
 	return nil
 }
 
+// Plan returns the command the step would run
+func (s *CommandRunStep) Plan(ctx *types.ScaffoldContext, opts types.StepOptions) (types.StepPlan, error) {
+	command, err := template.ReplaceTemplateVars(s.command, ctx)
+	if err != nil {
+		return types.StepPlan{}, fmt.Errorf("template replacement failed: %w", err)
+	}
+	env, err := renderStepEnv("command.run", s.env, ctx)
+	if err != nil {
+		return types.StepPlan{}, err
+	}
+	return types.StepPlan{Command: planCommand(env, command), Detail: joinDetails(stepDirDetail(s.dir, ctx), planCapture("command.run", s.captureAs, ctx))}, nil
+}
+
 func (s *CommandRunStep) Priority() int {
-	return 100
+	return PriorityCommand
 }
 
 func (s *CommandRunStep) Condition(ctx *types.ScaffoldContext) bool {
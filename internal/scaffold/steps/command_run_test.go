@@ -0,0 +1,33 @@
+package steps
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/michaeldyrynda/arbor/internal/scaffold/types"
+)
+
+func TestCommandRunStep_TemplateReplacement(t *testing.T) {
+	t.Run("renders the command", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		step := NewCommandRunStep("echo {{ .SiteName }}_{{ .DbSuffix }} > out.txt")
+		ctx := &types.ScaffoldContext{WorktreePath: tmpDir, SiteName: "myapp"}
+		ctx.SetDbSuffix("swift_runner")
+
+		require.NoError(t, step.Run(ctx, types.StepOptions{}))
+
+		data, err := os.ReadFile(filepath.Join(tmpDir, "out.txt"))
+		require.NoError(t, err)
+		assert.Equal(t, "myapp_swift_runner\n", string(data))
+	})
+
+	t.Run("errors on unknown fields", func(t *testing.T) {
+		step := NewCommandRunStep("echo {{ .DbSufix }}")
+		err := step.Run(&types.ScaffoldContext{WorktreePath: t.TempDir()}, types.StepOptions{})
+		assert.ErrorContains(t, err, "template replacement failed")
+	})
+}
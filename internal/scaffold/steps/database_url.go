@@ -0,0 +1,138 @@
+package steps
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	arborerrors "github.com/michaeldyrynda/arbor/internal/errors"
+)
+
+// databaseURLKeys are the .env keys checked for a connection URL, in order
+var databaseURLKeys = []string{"DATABASE_URL", "DB_URL"}
+
+// databaseURL holds the connection details parsed from a DATABASE_URL
+type databaseURL struct {
+	Engine   string
+	Host     string
+	Port     string
+	Username string
+	Password string
+	Database string
+	SSLMode  string
+	SSLCA    string
+}
+
+// databaseURLFromEnv parses the first connection URL found in env, or
+// returns nil when there is none. A URL that cannot be parsed is an
+// ErrInvalidConfig error.
+func databaseURLFromEnv(env map[string]string) (*databaseURL, error) {
+	for _, key := range databaseURLKeys {
+		if raw := env[key]; raw != "" {
+			parsed, err := parseDatabaseURL(raw)
+			if err != nil {
+				return nil, arborerrors.Wrap(arborerrors.ErrInvalidConfig, fmt.Errorf("%s in .env: %w", key, err))
+			}
+			return parsed, nil
+		}
+	}
+	return nil, nil
+}
+
+// parseDatabaseURL parses mysql://, postgres://, sqlserver:// and sqlite/file: URLs
+func parseDatabaseURL(raw string) (*databaseURL, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("parsing database url: %w", err)
+	}
+
+	engine := engineFromScheme(u.Scheme)
+	if engine == "" {
+		return nil, fmt.Errorf("unsupported database url scheme: %q", u.Scheme)
+	}
+
+	parsed := &databaseURL{Engine: engine}
+
+	if engine == "sqlite" {
+		switch {
+		case u.Opaque != "":
+			parsed.Database = u.Opaque
+		default:
+			parsed.Database = u.Host + u.Path
+		}
+		return parsed, nil
+	}
+
+	parsed.Host = u.Hostname()
+	parsed.Port = u.Port()
+	if u.User != nil {
+		parsed.Username = u.User.Username()
+		parsed.Password, _ = u.User.Password()
+	}
+	parsed.Database = strings.TrimPrefix(u.Path, "/")
+
+	query := u.Query()
+	parsed.SSLMode = firstNonEmpty(query.Get("sslmode"), query.Get("ssl-mode"))
+	parsed.SSLCA = firstNonEmpty(query.Get("sslrootcert"), query.Get("ssl-ca"))
+	if engine == "sqlsrv" {
+		// sqlserver:// URLs name the database in the query string; the path
+		// is the instance name
+		parsed.Database = query.Get("database")
+	}
+
+	return parsed, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func engineFromScheme(scheme string) string {
+	switch strings.ToLower(scheme) {
+	case "mysql", "mariadb", "mysql2":
+		return "mysql"
+	case "postgres", "postgresql", "pgsql":
+		return "pgsql"
+	case "sqlserver", "mssql", "sqlsrv":
+		return "sqlsrv"
+	case "sqlite", "sqlite3", "file":
+		return "sqlite"
+	default:
+		return ""
+	}
+}
+
+// replaceURLDatabase returns raw with its database name replaced, keeping
+// the scheme, credentials, host and query string intact
+func replaceURLDatabase(raw, database string) (string, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", fmt.Errorf("parsing database url: %w", err)
+	}
+
+	switch engineFromScheme(u.Scheme) {
+	case "":
+		return "", fmt.Errorf("unsupported database url scheme: %q", u.Scheme)
+	case "sqlite":
+		if u.Opaque != "" {
+			u.Opaque = database
+		} else {
+			u.Host = ""
+			u.Path = database
+		}
+	case "sqlsrv":
+		query := u.Query()
+		query.Set("database", database)
+		u.RawQuery = query.Encode()
+	default:
+		u.Path = "/" + database
+		u.RawPath = ""
+	}
+
+	return u.String(), nil
+}
@@ -1,11 +1,19 @@
 package steps
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 
 	"github.com/michaeldyrynda/arbor/internal/config"
+	arborerrors "github.com/michaeldyrynda/arbor/internal/errors"
+	"github.com/michaeldyrynda/arbor/internal/logging"
 	"github.com/michaeldyrynda/arbor/internal/scaffold/types"
 	"github.com/michaeldyrynda/arbor/internal/scaffold/words"
 	"github.com/michaeldyrynda/arbor/internal/utils"
@@ -17,6 +25,7 @@ type DbCreateStep struct {
 	priority      int
 	dbType        string
 	clientFactory DatabaseClientFactory
+	runContainer  commandRunner
 }
 
 func NewDbCreateStep(cfg config.StepConfig, priority int) *DbCreateStep {
@@ -26,6 +35,7 @@ func NewDbCreateStep(cfg config.StepConfig, priority int) *DbCreateStep {
 		priority:      priority,
 		dbType:        cfg.Type,
 		clientFactory: DefaultDatabaseClientFactory,
+		runContainer:  runCommand,
 	}
 }
 
@@ -36,6 +46,7 @@ func NewDbCreateStepWithFactory(cfg config.StepConfig, priority int, factory Dat
 		priority:      priority,
 		dbType:        cfg.Type,
 		clientFactory: factory,
+		runContainer:  runCommand,
 	}
 }
 
@@ -51,71 +62,137 @@ func (s *DbCreateStep) Condition(ctx *types.ScaffoldContext) bool {
 	return true
 }
 
+// SupportsDryRun lets the step run during a dry run to list the databases
+// that would be created
+func (s *DbCreateStep) SupportsDryRun() bool {
+	return true
+}
+
 func (s *DbCreateStep) Run(ctx *types.ScaffoldContext, opts types.StepOptions) error {
 	engine, err := s.detectEngine(ctx)
+	if errors.Is(err, arborerrors.ErrInvalidConfig) {
+		return err
+	}
 	if err != nil {
-		if opts.Verbose {
-			fmt.Printf("  %v\n", err)
-		}
+		logging.Verbosef("  %v", err)
 		return nil
 	}
 
-	if opts.Verbose {
-		fmt.Printf("  Creating database (%s)...\n", engine)
-	}
+	logging.Verbosef("  Creating database (%s)...", engine)
 
 	if engine == "sqlite" {
-		dbName := ""
-		for i, arg := range s.args {
-			if arg == "--database" && i+1 < len(s.args) {
-				dbName = s.args[i+1]
-			}
+		path, err := s.sqlitePath(ctx)
+		if err != nil {
+			return err
 		}
-		if dbName == "" {
-			env := utils.ReadEnvFile(ctx.WorktreePath, ".env")
-			dbName = env["DB_DATABASE"]
+		if err := s.createSqlite(ctx, path, opts); err != nil {
+			return err
+		}
+		if !s.wantsTestDatabase() {
+			return nil
 		}
-		if dbName == "" {
-			dbName = "database/database.sqlite"
+		testPath := testSqlitePath(path)
+		if err := s.createSqlite(ctx, testPath, opts); err != nil {
+			return err
 		}
-		return s.createSqlite(ctx, dbName, opts)
+		if opts.DryRun {
+			return nil
+		}
+		return writeTestingEnv(ctx, testPath, "DB_DATABASE")
 	}
 
 	return s.createWithRetry(ctx, engine, opts)
 }
 
-func (s *DbCreateStep) detectEngine(ctx *types.ScaffoldContext) (string, error) {
-	if s.dbType != "" {
-		switch s.dbType {
-		case "mysql", "pgsql", "sqlite":
-			return s.dbType, nil
-		default:
-			return "", fmt.Errorf("unsupported database type: %s", s.dbType)
+// Plan returns the database the step would create. A name generated here
+// may still be retried on collision during a real run.
+func (s *DbCreateStep) Plan(ctx *types.ScaffoldContext, opts types.StepOptions) (types.StepPlan, error) {
+	engine, err := s.detectEngine(ctx)
+	if err != nil {
+		return types.StepPlan{Detail: err.Error()}, nil
+	}
+
+	if engine == "sqlite" {
+		path, err := s.sqlitePath(ctx)
+		if err != nil {
+			return types.StepPlan{}, err
+		}
+		plan := types.StepPlan{Files: []string{path}, Detail: "sqlite"}
+		if s.wantsTestDatabase() {
+			plan.Files = append(plan.Files, testSqlitePath(path), TestingEnvFile)
 		}
+		return plan, nil
 	}
 
-	env := utils.ReadEnvFile(ctx.WorktreePath, ".env")
-	if conn := env["DB_CONNECTION"]; conn != "" {
-		switch conn {
-		case "mysql", "mariadb":
-			return "mysql", nil
-		case "pgsql", "postgres", "postgresql":
-			return "pgsql", nil
-		case "sqlite":
-			return "sqlite", nil
+	dbName, err := nextDatabaseName(ctx, s.getPrefixOrSiteName(ctx))
+	if err != nil {
+		return types.StepPlan{}, err
+	}
+	plan := types.StepPlan{
+		Databases: []string{dbName},
+		Detail:    engine,
+	}
+	if s.wantsTestDatabase() {
+		plan.Databases = append(plan.Databases, testDatabaseName(ctx, s.getPrefixOrSiteName(ctx)))
+		plan.Files = []string{TestingEnvFile}
+	}
+	if ctx.Database.CreateUser && engine != "sqlsrv" {
+		plan.Detail = fmt.Sprintf("%s, granted to %s", engine, worktreeUserName(ctx.GetDbSuffix()))
+	}
+	return plan, nil
+}
+
+// sqlitePath returns the SQLite database file, from --database, the .env or
+// Laravel's default
+func (s *DbCreateStep) sqlitePath(ctx *types.ScaffoldContext) (string, error) {
+	dbName := ""
+	for i, arg := range s.args {
+		if arg == "--database" && i+1 < len(s.args) {
+			dbName = s.args[i+1]
 		}
 	}
+	if dbName != "" {
+		return dbName, nil
+	}
 
-	return "", fmt.Errorf("database type not specified and DB_CONNECTION not found in .env")
+	env := utils.ReadEnvFile(ctx.WorktreePath, ".env")
+	if dbName := env["DB_DATABASE"]; dbName != "" {
+		return dbName, nil
+	}
+	dbURL, err := databaseURLFromEnv(env)
+	if err != nil {
+		return "", err
+	}
+	if dbURL != nil && dbURL.Engine == "sqlite" && dbURL.Database != "" {
+		return dbURL.Database, nil
+	}
+	return "database/database.sqlite", nil
+}
+
+func (s *DbCreateStep) detectEngine(ctx *types.ScaffoldContext) (string, error) {
+	return detectDatabaseEngine(ctx, s.dbType)
 }
 
 func (s *DbCreateStep) getPrefixOrSiteName(ctx *types.ScaffoldContext) string {
-	for i, arg := range s.args {
-		if arg == "--prefix" && i+1 < len(s.args) {
-			return s.args[i+1]
+	if prefix := prefixArg(s.args); prefix != "" {
+		return prefix
+	}
+	return defaultDatabasePrefix(ctx)
+}
+
+// prefixArg returns the value of --prefix in args, or ""
+func prefixArg(args []string) string {
+	for i, arg := range args {
+		if arg == "--prefix" && i+1 < len(args) {
+			return args[i+1]
 		}
 	}
+	return ""
+}
 
+// defaultDatabasePrefix returns the prefix db.create names databases with
+// when it has no --prefix: the site name, the .env APP_NAME, or app
+func defaultDatabasePrefix(ctx *types.ScaffoldContext) string {
 	siteName := ctx.SiteName
 	if siteName == "" {
 		env := utils.ReadEnvFile(ctx.WorktreePath, ".env")
@@ -127,93 +204,298 @@ func (s *DbCreateStep) getPrefixOrSiteName(ctx *types.ScaffoldContext) string {
 	return siteName
 }
 
-func (s *DbCreateStep) parseConnectionOptions() DatabaseOptions {
-	opts := DatabaseOptions{
-		Host:     "127.0.0.1",
-		Username: "root",
+const maxDbCreateRetries = 5
+
+func (s *DbCreateStep) createWithRetry(ctx *types.ScaffoldContext, engine string, opts types.StepOptions) error {
+	siteName := s.getPrefixOrSiteName(ctx)
+	dbOpts, err := resolveConnectionOptions(ctx, engine, s.args)
+	if err != nil {
+		return err
 	}
 
-	for i, arg := range s.args {
-		if arg == "--username" && i+1 < len(s.args) {
-			opts.Username = s.args[i+1]
-		}
-		if arg == "--password" && i+1 < len(s.args) {
-			opts.Password = s.args[i+1]
-		}
-		if arg == "--host" && i+1 < len(s.args) {
-			opts.Host = s.args[i+1]
-		}
-		if arg == "--port" && i+1 < len(s.args) {
-			opts.Port = s.args[i+1]
-		}
+	if err := validateDatabaseMode(ctx, engine, dbOpts); err != nil {
+		return err
 	}
 
-	return opts
-}
+	if opts.DryRun {
+		return s.previewCreate(ctx, engine, siteName)
+	}
 
-const maxDbCreateRetries = 5
+	var container *databaseContainer
+	started := false
+	if ctx.Database.Container.Enabled {
+		var err error
+		container, err = newDatabaseContainer(ctx, engine, s.runContainer)
+		if err != nil {
+			return err
+		}
+		if started, err = container.start(dbOpts); err != nil {
+			return err
+		}
+		port, err := container.hostPort()
+		if err != nil {
+			return err
+		}
+		dbOpts = container.connectionOptions(dbOpts, port)
 
-func (s *DbCreateStep) createWithRetry(ctx *types.ScaffoldContext, engine string, opts types.StepOptions) error {
-	siteName := s.getPrefixOrSiteName(ctx)
-	dbOpts := s.parseConnectionOptions()
+		logging.Verbosef("  Using database container %s on port %s", container.name, port)
+	}
 
 	client, err := s.clientFactory(engine, dbOpts)
 	if err != nil {
 		return fmt.Errorf("creating database client: %w", err)
 	}
-	defer client.Close()
+	defer func() { client.Close() }()
 
-	if err := client.Ping(); err != nil {
-		if opts.Verbose {
-			fmt.Printf("  Could not connect to %s database: %v\n", engine, err)
+	if started {
+		if err := waitForDatabase(client); err != nil {
+			return fmt.Errorf("waiting for container %s: %w", container.name, err)
+		}
+	} else if client, err = pingDatabase(client, s.clientFactory, engine, dbOpts, ctx.Interaction); err != nil {
+		if container != nil {
+			return fmt.Errorf("connecting to container %s: %w", container.name, err)
 		}
+		logging.Verbosef("  Could not connect to %s database: %v", engine, err)
 		return nil
 	}
 
+	if container != nil {
+		if err := writeContainerEnv(ctx, dbOpts); err != nil {
+			return err
+		}
+	}
+
+	target, err := NewDatabaseTarget(ctx, client)
+	if err != nil {
+		return err
+	}
+
 	var lastErr error
 	for attempt := 0; attempt < maxDbCreateRetries; attempt++ {
-		var dbName string
-		var suffix string
-
 		existingSuffix := ctx.GetDbSuffix()
-		if existingSuffix != "" {
-			suffix = existingSuffix
-			dbName = fmt.Sprintf("%s_%s", words.SanitizeSiteName(siteName), suffix)
-		} else {
-			dbName = words.GenerateDatabaseName(siteName, 0)
-			suffix = words.ExtractSuffix(dbName)
-			ctx.SetDbSuffix(suffix)
+		dbName, err := nextDatabaseName(ctx, siteName)
+		if err != nil {
+			return err
 		}
 
-		if opts.Verbose {
-			fmt.Printf("  Generated database name: %s (attempt %d/%d)\n", dbName, attempt+1, maxDbCreateRetries)
-		}
+		logging.Verbosef("  Generated database name: %s (attempt %d/%d)", dbName, attempt+1, maxDbCreateRetries)
 
-		err := client.CreateDatabase(dbName)
+		err = target.Create(dbName)
 		if err == nil {
-			if opts.Verbose {
-				fmt.Printf("  Database '%s' created successfully.\n", dbName)
+			logging.Verbosef("  Created %s '%s'.", target.Kind, dbName)
+			if err := s.finishCreate(ctx, client, target, dbName, opts); err != nil {
+				return err
 			}
 			if err := s.persistDbSuffix(ctx); err != nil {
-				if opts.Verbose {
-					fmt.Printf("  warning: failed to persist db_suffix: %v\n", err)
-				}
+				logging.Verbosef("  warning: failed to persist db_suffix: %v", err)
 			}
 			return nil
 		}
 
 		if !IsDatabaseExistsError(err) {
-			return fmt.Errorf("failed to create database: %w", err)
+			return fmt.Errorf("failed to create %s: %w", target.Kind, err)
 		}
 
-		if opts.Verbose {
-			fmt.Printf("  Database '%s' already exists, retrying...\n", dbName)
+		// A suffix from an earlier step or run names databases this worktree
+		// already owns, so an existing database is reused rather than replaced
+		if existingSuffix != "" {
+			logging.Verbosef("  %s '%s' already exists, reusing it.", target.Kind, dbName)
+			return s.finishCreate(ctx, client, target, dbName, opts)
 		}
+
+		logging.Verbosef("  %s '%s' already exists, retrying...", target.Kind, dbName)
 		ctx.SetDbSuffix("")
 		lastErr = err
 	}
 
-	return fmt.Errorf("failed to create database after %d attempts: %w", maxDbCreateRetries, lastErr)
+	return fmt.Errorf("failed to create %s after %d attempts: %w", target.Kind, maxDbCreateRetries, lastErr)
+}
+
+// finishCreate points the worktree at a new or reused schema, grants the
+// worktree user access to it and creates the test database with --test
+func (s *DbCreateStep) finishCreate(ctx *types.ScaffoldContext, client DatabaseClient, target *DatabaseTarget, dbName string, opts types.StepOptions) error {
+	if target.Kind == databaseModeSchema {
+		if err := writeSchemaEnv(ctx, dbName); err != nil {
+			return err
+		}
+	}
+	if err := s.grantWorktreeUser(ctx, client, target, dbName, opts); err != nil {
+		return err
+	}
+	if s.wantsTestDatabase() {
+		return s.createTestDatabase(ctx, client, target, opts)
+	}
+	return nil
+}
+
+// DatabaseNaming returns the naming configured for the project's database
+// suffixes
+func DatabaseNaming(db config.DatabaseConfig) (words.Naming, error) {
+	naming, err := words.NewNaming(db.NamingStrategy, db.TicketPattern, db.Words.Adjectives, db.Words.Nouns)
+	if err != nil {
+		return words.Naming{}, fmt.Errorf("db: %w", err)
+	}
+	return naming, nil
+}
+
+// nextDatabaseName names the database for siteName using the worktree
+// suffix, generating and recording a new suffix when there is none yet
+func nextDatabaseName(ctx *types.ScaffoldContext, siteName string) (string, error) {
+	if suffix := ctx.GetDbSuffix(); suffix != "" {
+		return fmt.Sprintf("%s_%s", words.SanitizeSiteName(siteName), suffix), nil
+	}
+
+	naming, err := DatabaseNaming(ctx.Database)
+	if err != nil {
+		return "", err
+	}
+	suffix := naming.Suffix(ctx.Branch)
+	ctx.SetDbSuffix(suffix)
+	return words.JoinDatabaseName(siteName, suffix, 0), nil
+}
+
+// previewCreate prints the database db.create would create and the SQL it
+// would run, without touching the server. A name generated here may still
+// be retried on collision during a real run.
+func (s *DbCreateStep) previewCreate(ctx *types.ScaffoldContext, engine, siteName string) error {
+	if ctx.Database.Container.Enabled {
+		if container, err := newDatabaseContainer(ctx, engine, s.runContainer); err == nil {
+			logging.Infof("  [DRY RUN] Would start database container %s (%s)", container.name, container.image)
+		}
+	}
+
+	target := previewTarget(ctx)
+	dbName, err := nextDatabaseName(ctx, siteName)
+	if err != nil {
+		return err
+	}
+	logging.Infof("  [DRY RUN] Would create %s %s %s", engine, target.Kind, dbName)
+	statements := []string{target.createSQL(engine, dbName)}
+
+	user := worktreeUserName(ctx.GetDbSuffix())
+	grantUser := ctx.Database.CreateUser && engine != "sqlsrv"
+	if grantUser {
+		statements = append(statements,
+			createUserSQL(engine, user, "********"),
+			target.grantSQL(engine, user, dbName))
+	}
+
+	if s.wantsTestDatabase() {
+		testName := testDatabaseName(ctx, siteName)
+		logging.Infof("  [DRY RUN] Would create test %s %s and write it to %s", target.Kind, testName, TestingEnvFile)
+		statements = append(statements, target.createSQL(engine, testName))
+		if grantUser {
+			statements = append(statements, target.grantSQL(engine, user, testName))
+		}
+	}
+
+	for _, statement := range statements {
+		logging.Infof("    %s;", statement)
+	}
+	return nil
+}
+
+// grantWorktreeUser gives the worktree user access to dbName when
+// db.create_user is enabled, creating the user on first use and writing its
+// credentials into the worktree .env
+func (s *DbCreateStep) grantWorktreeUser(ctx *types.ScaffoldContext, client DatabaseClient, target *DatabaseTarget, dbName string, opts types.StepOptions) error {
+	if !ctx.Database.CreateUser {
+		return nil
+	}
+
+	users, ok := client.(DatabaseUserClient)
+	if !ok {
+		return fmt.Errorf("db.create_user is not supported for this database")
+	}
+
+	user := worktreeUserName(ctx.GetDbSuffix())
+	env := utils.ReadEnvFile(ctx.WorktreePath, ".env")
+
+	password := ""
+	if env["DB_USERNAME"] == user {
+		password = env["DB_PASSWORD"]
+	}
+	if password == "" {
+		generated, err := generateDatabasePassword()
+		if err != nil {
+			return err
+		}
+		password = generated
+
+		// The user may remain from an earlier run whose credentials were lost,
+		// so it is recreated to match the new password
+		if err := users.DropUser(user); err != nil {
+			return err
+		}
+	}
+
+	if err := users.CreateUser(user, password); err != nil {
+		return err
+	}
+	if err := target.grant(users, user, dbName); err != nil {
+		return err
+	}
+
+	for _, kv := range [][2]string{{"DB_USERNAME", user}, {"DB_PASSWORD", password}} {
+		if err := utils.WriteEnvValue(ctx.WorktreePath, ".env", kv[0], kv[1]); err != nil {
+			return fmt.Errorf("writing %s to .env: %w", kv[0], err)
+		}
+	}
+
+	logging.Verbosef("  Granted user '%s' access to '%s'.", user, dbName)
+
+	return nil
+}
+
+// maxUserNameLength is MySQL's limit on user names
+const maxUserNameLength = 32
+
+// worktreeUserName derives the per-worktree database user from the database
+// suffix. A suffix too long for MySQL's 32 character limit is shortened and
+// ends in a hash of the whole suffix, so suffixes sharing a prefix still get
+// their own users.
+func worktreeUserName(suffix string) string {
+	name := "arbor_" + suffix
+	if len(name) <= maxUserNameLength {
+		return name
+	}
+
+	sum := sha256.Sum256([]byte(suffix))
+	hash := hex.EncodeToString(sum[:])[:8]
+	return strings.TrimRight(name[:maxUserNameLength-len(hash)-1], "_") + "_" + hash
+}
+
+// ownsWorktreeUser reports whether db.destroy should drop the worktree user:
+// db.create_user is enabled, or the worktree .env still uses the user from
+// when it was
+func ownsWorktreeUser(ctx *types.ScaffoldContext, suffix string) bool {
+	if ctx.Database.CreateUser {
+		return true
+	}
+	return utils.ReadEnvFile(ctx.WorktreePath, ".env")["DB_USERNAME"] == worktreeUserName(suffix)
+}
+
+func generateDatabasePassword() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generating database password: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// writeContainerEnv points the worktree .env at the database container
+func writeContainerEnv(ctx *types.ScaffoldContext, dbOpts DatabaseOptions) error {
+	values := [][2]string{{"DB_HOST", dbOpts.Host}, {"DB_PORT", dbOpts.Port}}
+	if env := utils.ReadEnvFile(ctx.WorktreePath, ".env"); env["DB_USERNAME"] != "" && env["DB_USERNAME"] != dbOpts.Username {
+		values = append(values, [2]string{"DB_USERNAME", dbOpts.Username})
+	}
+
+	for _, kv := range values {
+		if err := utils.WriteEnvValue(ctx.WorktreePath, ".env", kv[0], kv[1]); err != nil {
+			return fmt.Errorf("writing %s to .env: %w", kv[0], err)
+		}
+	}
+	return nil
 }
 
 func (s *DbCreateStep) persistDbSuffix(ctx *types.ScaffoldContext) error {
@@ -234,11 +516,10 @@ func (s *DbCreateStep) persistDbSuffix(ctx *types.ScaffoldContext) error {
 func (s *DbCreateStep) createSqlite(ctx *types.ScaffoldContext, dbName string, opts types.StepOptions) error {
 	dbPath := filepath.Join(ctx.WorktreePath, dbName)
 
-	if opts.Verbose {
-		fmt.Printf("  Creating SQLite database: %s\n", dbPath)
-	}
+	logging.Verbosef("  Creating SQLite database: %s", dbPath)
 
 	if opts.DryRun {
+		logging.Infof("  [DRY RUN] Would create SQLite database %s", dbPath)
 		return nil
 	}
 
@@ -253,9 +534,7 @@ func (s *DbCreateStep) createSqlite(ctx *types.ScaffoldContext, dbName string, o
 	}
 	file.Close()
 
-	if opts.Verbose {
-		fmt.Printf("  SQLite database created at: %s\n", dbPath)
-	}
+	logging.Verbosef("  SQLite database created at: %s", dbPath)
 
 	return nil
 }
@@ -265,6 +544,7 @@ type DbDestroyStep struct {
 	args          []string
 	dbType        string
 	clientFactory DatabaseClientFactory
+	runContainer  commandRunner
 }
 
 func NewDbDestroyStep(cfg config.StepConfig) *DbDestroyStep {
@@ -273,6 +553,7 @@ func NewDbDestroyStep(cfg config.StepConfig) *DbDestroyStep {
 		args:          cfg.Args,
 		dbType:        cfg.Type,
 		clientFactory: DefaultDatabaseClientFactory,
+		runContainer:  runCommand,
 	}
 }
 
@@ -282,6 +563,7 @@ func NewDbDestroyStepWithFactory(cfg config.StepConfig, factory DatabaseClientFa
 		args:          cfg.Args,
 		dbType:        cfg.Type,
 		clientFactory: factory,
+		runContainer:  runCommand,
 	}
 }
 
@@ -290,13 +572,19 @@ func (s *DbDestroyStep) Name() string {
 }
 
 func (s *DbDestroyStep) Priority() int {
-	return 0
+	return PriorityEnv
 }
 
 func (s *DbDestroyStep) Condition(ctx *types.ScaffoldContext) bool {
 	return true
 }
 
+// SupportsDryRun lets the step run during a dry run to list the databases
+// that would be dropped
+func (s *DbDestroyStep) SupportsDryRun() bool {
+	return true
+}
+
 func (s *DbDestroyStep) Run(ctx *types.ScaffoldContext, opts types.StepOptions) error {
 	suffix := ctx.GetDbSuffix()
 	if suffix == "" {
@@ -308,143 +596,396 @@ func (s *DbDestroyStep) Run(ctx *types.ScaffoldContext, opts types.StepOptions)
 	}
 
 	if suffix == "" {
-		if opts.Verbose {
-			fmt.Printf("  No database suffix found, skipping cleanup.\n")
-		}
+		logging.Verbosef("  No database suffix found, skipping cleanup.")
 		return nil
 	}
 
 	ctx.SetDbSuffix(suffix)
 
+	if keep, err := keepDatabases(ctx, suffix); err != nil {
+		return err
+	} else if keep {
+		logging.Infof("  Keeping databases with suffix %s for the next worktree of %s", suffix, ctx.Branch)
+		return nil
+	}
+
 	engine, err := s.detectEngine(ctx)
+	if errors.Is(err, arborerrors.ErrInvalidConfig) {
+		return err
+	}
 	if err != nil {
-		if opts.Verbose {
-			fmt.Printf("  %v\n", err)
-		}
+		logging.Verbosef("  %v", err)
 		return nil
 	}
 
-	if opts.Verbose {
-		fmt.Printf("  Cleaning up databases matching suffix: %s\n", suffix)
-	}
+	logging.Verbosef("  Cleaning up databases matching suffix: %s", suffix)
 
 	if engine == "sqlite" {
 		return nil
 	}
 
-	return s.destroyDatabases(engine, suffix, opts)
+	return s.destroyDatabases(ctx, engine, suffix, opts)
 }
 
-func (s *DbDestroyStep) detectEngine(ctx *types.ScaffoldContext) (string, error) {
-	if s.dbType != "" {
-		switch s.dbType {
-		case "mysql", "pgsql", "sqlite":
-			return s.dbType, nil
-		default:
-			return "", fmt.Errorf("unsupported database type: %s", s.dbType)
-		}
+// keepDatabases reports whether db.keep_on_remove applies to suffix: it is
+// set and suffix is the one the naming strategy will give the branch again
+func keepDatabases(ctx *types.ScaffoldContext, suffix string) (bool, error) {
+	if !ctx.Database.KeepOnRemove {
+		return false, nil
 	}
 
-	env := utils.ReadEnvFile(ctx.WorktreePath, ".env")
-	if conn := env["DB_CONNECTION"]; conn != "" {
-		switch conn {
-		case "mysql", "mariadb":
-			return "mysql", nil
-		case "pgsql", "postgres", "postgresql":
-			return "pgsql", nil
-		case "sqlite":
-			return "sqlite", nil
-		}
+	naming, err := DatabaseNaming(ctx.Database)
+	if err != nil {
+		return false, err
+	}
+	if !naming.Deterministic(ctx.Branch) || naming.Suffix(ctx.Branch) != suffix {
+		logging.Verbosef("  db.keep_on_remove only keeps databases named from the branch, dropping suffix %s", suffix)
+		return false, nil
 	}
+	return true, nil
+}
 
-	return "", fmt.Errorf("database type not specified and DB_CONNECTION not found in .env")
+func (s *DbDestroyStep) detectEngine(ctx *types.ScaffoldContext) (string, error) {
+	return detectDatabaseEngine(ctx, s.dbType)
 }
 
-func (s *DbDestroyStep) parseConnectionOptions(engine string) DatabaseOptions {
-	opts := DatabaseOptions{
-		Host: "127.0.0.1",
+func (s *DbDestroyStep) destroyDatabases(ctx *types.ScaffoldContext, engine, suffix string, opts types.StepOptions) error {
+	dbOpts, err := resolveConnectionOptions(ctx, engine, s.args)
+	if err != nil {
+		return err
+	}
+	if err := validateDatabaseMode(ctx, engine, dbOpts); err != nil {
+		return err
 	}
 
-	if engine == "pgsql" {
-		opts.Username = "postgres"
-		opts.Port = "5432"
-	} else {
-		opts.Username = "root"
-		opts.Port = "3306"
+	if opts.DryRun {
+		s.previewDestroy(ctx, engine, suffix)
+		return nil
 	}
 
-	for i, arg := range s.args {
-		if arg == "--username" && i+1 < len(s.args) {
-			opts.Username = s.args[i+1]
-		}
-		if arg == "--password" && i+1 < len(s.args) {
-			opts.Password = s.args[i+1]
-		}
-		if arg == "--host" && i+1 < len(s.args) {
-			opts.Host = s.args[i+1]
-		}
-		if arg == "--port" && i+1 < len(s.args) {
-			opts.Port = s.args[i+1]
+	var container *databaseContainer
+	if ctx.Database.Container.Enabled {
+		var err error
+		if container, dbOpts, err = s.startContainer(ctx, engine, dbOpts); err != nil || container == nil {
+			if err != nil {
+				logging.Verbosef("  %v", err)
+			}
+			return nil
 		}
 	}
 
-	return opts
-}
-
-func (s *DbDestroyStep) destroyDatabases(engine, suffix string, opts types.StepOptions) error {
-	dbOpts := s.parseConnectionOptions(engine)
-
 	client, err := s.clientFactory(engine, dbOpts)
 	if err != nil {
-		if opts.Verbose {
-			fmt.Printf("  Could not create database client: %v\n", err)
-		}
+		logging.Verbosef("  Could not create database client: %v", err)
 		return nil
 	}
-	defer client.Close()
+	defer func() { client.Close() }()
 
-	if err := client.Ping(); err != nil {
-		if opts.Verbose {
-			fmt.Printf("  Could not connect to %s database: %v\n", engine, err)
-		}
+	if client, err = pingDatabase(client, s.clientFactory, engine, dbOpts, ctx.Interaction); err != nil {
+		logging.Verbosef("  Could not connect to %s database: %v", engine, err)
 		return nil
 	}
 
-	pattern := fmt.Sprintf("%%_%s", suffix)
-	databases, err := client.ListDatabases(pattern)
+	if container != nil {
+		defer s.stopIdleContainer(container, client, dbOpts)
+	}
+	if ownsWorktreeUser(ctx, suffix) {
+		defer s.dropWorktreeUser(client, suffix, opts)
+	}
+
+	target, err := NewDatabaseTarget(ctx, client)
 	if err != nil {
-		if opts.Verbose {
-			fmt.Printf("  Failed to list databases: %v\n", err)
-		}
+		return err
+	}
+
+	// The pattern only narrows the listing, as _ matches any character, so
+	// just the exact names db.create gives the worktree are dropped
+	names := s.databaseNames(ctx, suffix)
+	listed, err := target.List("%_" + suffix)
+	if err != nil {
+		logging.Verbosef("  Failed to list %ss: %v", target.Kind, err)
 		return nil
 	}
+	var databases []string
+	for _, name := range listed {
+		if names[name] {
+			databases = append(databases, name)
+		}
+	}
 
 	if len(databases) == 0 {
-		if opts.Verbose {
-			fmt.Printf("  No databases matching pattern found.\n")
-		}
+		logging.Verbosef("  No %ss with suffix %s found.", target.Kind, suffix)
 		return nil
 	}
 
 	for _, dbName := range databases {
-		if opts.DryRun {
-			if opts.Verbose {
-				fmt.Printf("  Would drop database: %s\n", dbName)
-			}
+		if err := target.Drop(dbName); err != nil {
+			logging.Verbosef("  Failed to drop %s %s: %v", target.Kind, dbName, err)
 			continue
 		}
 
-		if err := client.DropDatabase(dbName); err != nil {
-			if opts.Verbose {
-				fmt.Printf("  Failed to drop database %s: %v\n", dbName, err)
-			}
+		logging.Verbosef("  Dropped %s: %s", target.Kind, dbName)
+	}
+
+	return nil
+}
+
+// previewDestroy prints the databases db.destroy would drop and the SQL it
+// would run, without connecting to the server, so a dry run cannot prompt for
+// a password or start a container. Which of them exist is only known once
+// connected, so each is dropped only if it exists.
+func (s *DbDestroyStep) previewDestroy(ctx *types.ScaffoldContext, engine, suffix string) {
+	target := previewTarget(ctx)
+
+	var names []string
+	for name := range s.databaseNames(ctx, suffix) {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		logging.Infof("  [DRY RUN] Would drop %s %s %s if it exists", engine, target.Kind, name)
+		logging.Infof("    %s;", target.dropSQL(engine, name))
+	}
+	if ownsWorktreeUser(ctx, suffix) && engine != "sqlsrv" {
+		logging.Infof("  [DRY RUN] Would drop user %s\n    %s;", worktreeUserName(suffix), dropUserSQL(engine, worktreeUserName(suffix)))
+	}
+}
+
+// databaseNames returns the names db.create gives the worktree's database and
+// test database, for each prefix the project may have named them with
+func (s *DbDestroyStep) databaseNames(ctx *types.ScaffoldContext, suffix string) map[string]bool {
+	prefixes := append([]string{prefixArg(s.args), defaultDatabasePrefix(ctx)}, ctx.DatabasePrefixes...)
+
+	names := make(map[string]bool)
+	for _, prefix := range prefixes {
+		if prefix == "" {
 			continue
 		}
+		names[words.JoinDatabaseName(prefix, suffix, 0)] = true
+		names[words.JoinDatabaseName(prefix+"_test", suffix, 0)] = true
+	}
+	return names
+}
 
-		if opts.Verbose {
-			fmt.Printf("  Dropped database: %s\n", dbName)
+// startContainer starts the project database container so its databases can
+// be dropped. A nil container is returned when it was never created.
+func (s *DbDestroyStep) startContainer(ctx *types.ScaffoldContext, engine string, dbOpts DatabaseOptions) (*databaseContainer, DatabaseOptions, error) {
+	container, err := newDatabaseContainer(ctx, engine, s.runContainer)
+	if err != nil {
+		return nil, dbOpts, err
+	}
+
+	if exists, _ := container.state(); !exists {
+		return nil, dbOpts, nil
+	}
+
+	started, err := container.start(dbOpts)
+	if err != nil {
+		return nil, dbOpts, err
+	}
+	port, err := container.hostPort()
+	if err != nil {
+		return nil, dbOpts, err
+	}
+	dbOpts = container.connectionOptions(dbOpts, port)
+
+	if started {
+		client, err := s.clientFactory(engine, dbOpts)
+		if err != nil {
+			return nil, dbOpts, err
+		}
+		defer client.Close()
+		if err := waitForDatabase(client); err != nil {
+			return nil, dbOpts, fmt.Errorf("waiting for container %s: %w", container.name, err)
 		}
 	}
 
-	return nil
+	return container, dbOpts, nil
+}
+
+// dropWorktreeUser removes the user created by db.create_user
+func (s *DbDestroyStep) dropWorktreeUser(client DatabaseClient, suffix string, opts types.StepOptions) {
+	users, ok := client.(DatabaseUserClient)
+	if !ok {
+		return
+	}
+
+	user := worktreeUserName(suffix)
+	if err := users.DropUser(user); err != nil {
+		logging.Verbosef("  Failed to drop user %s: %v", user, err)
+		return
+	}
+
+	logging.Verbosef("  Dropped user: %s", user)
+}
+
+// stopIdleContainer stops the project database container once the last
+// worktree database has been dropped
+func (s *DbDestroyStep) stopIdleContainer(container *databaseContainer, client DatabaseClient, dbOpts DatabaseOptions) {
+	inUse, err := hasWorktreeDatabases(client, container.ownDatabase(dbOpts))
+	if err != nil || inUse {
+		return
+	}
+
+	if err := container.stop(); err != nil {
+		logging.Verbosef("  %v", err)
+		return
+	}
+
+	logging.Verbosef("  Stopped database container %s", container.name)
+}
+
+// resolveConnectionOptions builds the connection options for engine. Values
+// are layered from lowest to highest precedence: engine defaults, DB_* keys
+// and MYSQL_ATTR_SSL_CA in the worktree .env, a DATABASE_URL for the same
+// engine, project database config (or ARBOR_DB_* environment variables), then
+// --host/--port/--username/--password step args.
+func resolveConnectionOptions(ctx *types.ScaffoldContext, engine string, args []string) (DatabaseOptions, error) {
+	opts := DatabaseOptions{
+		Host:     "127.0.0.1",
+		Username: "root",
+		Port:     "3306",
+	}
+	switch engine {
+	case "pgsql":
+		opts.Username = "postgres"
+		opts.Port = "5432"
+	case "sqlsrv":
+		opts.Username = "sa"
+		opts.Port = "1433"
+	}
+
+	env := utils.ReadEnvFile(ctx.WorktreePath, ".env")
+	envConfig := config.DatabaseConfig{
+		Host:     env["DB_HOST"],
+		Port:     env["DB_PORT"],
+		Username: env["DB_USERNAME"],
+		Password: env["DB_PASSWORD"],
+		SSLMode:  env["DB_SSLMODE"],
+		SSLCA:    env["MYSQL_ATTR_SSL_CA"],
+		Socket:   env["DB_SOCKET"],
+	}
+	// The worktree user written by db.create_user cannot manage databases
+	if suffix := ctx.GetDbSuffix(); suffix != "" && envConfig.Username == worktreeUserName(suffix) {
+		envConfig.Username, envConfig.Password = "", ""
+	}
+	applyDatabaseConfig(&opts, envConfig)
+
+	dbURL, err := databaseURLFromEnv(env)
+	if err != nil {
+		return DatabaseOptions{}, err
+	}
+	if dbURL != nil && dbURL.Engine == engine {
+		applyDatabaseConfig(&opts, config.DatabaseConfig{
+			Host:     dbURL.Host,
+			Port:     dbURL.Port,
+			Username: dbURL.Username,
+			Password: dbURL.Password,
+			SSLMode:  dbURL.SSLMode,
+			SSLCA:    dbURL.SSLCA,
+		})
+	}
+
+	applyDatabaseConfig(&opts, ctx.Database)
+
+	// Worktree schemas live in the project's existing database
+	if schemaMode(ctx) {
+		opts.Database = firstNonEmpty(ctx.Database.Database, env["DB_DATABASE"])
+		if opts.Database == "" && dbURL != nil {
+			opts.Database = dbURL.Database
+		}
+	}
+
+	for i, arg := range args {
+		if arg == "--username" && i+1 < len(args) {
+			opts.Username = args[i+1]
+		}
+		if arg == "--password" && i+1 < len(args) {
+			opts.Password = args[i+1]
+		}
+		if arg == "--host" && i+1 < len(args) {
+			opts.Host = args[i+1]
+		}
+		if arg == "--port" && i+1 < len(args) {
+			opts.Port = args[i+1]
+		}
+	}
+
+	// Certificate paths in .env or config are relative to the worktree
+	if opts.SSLCA != "" && !filepath.IsAbs(opts.SSLCA) {
+		opts.SSLCA = filepath.Join(ctx.WorktreePath, opts.SSLCA)
+	}
+
+	return opts, nil
+}
+
+// detectDatabaseEngine returns dbType when set, otherwise the engine named by
+// the worktree .env
+func detectDatabaseEngine(ctx *types.ScaffoldContext, dbType string) (string, error) {
+	if dbType != "" {
+		switch dbType {
+		case "mysql", "pgsql", "sqlsrv", "sqlite":
+			return dbType, nil
+		default:
+			return "", fmt.Errorf("unsupported database type: %s", dbType)
+		}
+	}
+
+	env := utils.ReadEnvFile(ctx.WorktreePath, ".env")
+	engine, err := engineFromEnv(env)
+	if err != nil {
+		return "", err
+	}
+	if engine != "" {
+		return engine, nil
+	}
+
+	return "", fmt.Errorf("database type not specified and neither DB_CONNECTION nor DATABASE_URL found in .env")
+}
+
+// engineFromEnv detects the database engine from DB_CONNECTION, falling back
+// to the scheme of DATABASE_URL
+func engineFromEnv(env map[string]string) (string, error) {
+	switch env["DB_CONNECTION"] {
+	case "mysql", "mariadb":
+		return "mysql", nil
+	case "pgsql", "postgres", "postgresql":
+		return "pgsql", nil
+	case "sqlsrv", "mssql", "sqlserver":
+		return "sqlsrv", nil
+	case "sqlite":
+		return "sqlite", nil
+	}
+
+	dbURL, err := databaseURLFromEnv(env)
+	if err != nil || dbURL == nil {
+		return "", err
+	}
+	return dbURL.Engine, nil
+}
+
+// applyDatabaseConfig overlays the non-empty connection values in cfg onto opts
+func applyDatabaseConfig(opts *DatabaseOptions, cfg config.DatabaseConfig) {
+	if cfg.Host != "" {
+		opts.Host = cfg.Host
+	}
+	if cfg.Port != "" {
+		opts.Port = cfg.Port
+	}
+	if cfg.Username != "" {
+		opts.Username = cfg.Username
+	}
+	if cfg.Password != "" {
+		opts.Password = cfg.Password
+	}
+	if cfg.SSLMode != "" {
+		opts.SSLMode = cfg.SSLMode
+	}
+	if cfg.SSLCA != "" {
+		opts.SSLCA = cfg.SSLCA
+	}
+	if cfg.Socket != "" {
+		opts.Socket = cfg.Socket
+	}
 }
@@ -0,0 +1,126 @@
+package steps
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+	mssql "github.com/microsoft/go-mssqldb"
+
+	"github.com/michaeldyrynda/arbor/internal/logging"
+	"github.com/michaeldyrynda/arbor/internal/scaffold/types"
+	"github.com/michaeldyrynda/arbor/internal/secrets"
+)
+
+// keychainGet and keychainSet are replaced in tests
+var (
+	keychainGet = secrets.KeychainGet
+	keychainSet = secrets.KeychainSet
+)
+
+// isAuthError reports whether err means the server rejected the credentials
+func isAuthError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlErr.Number == 1045
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == "28P01" || pgErr.Code == "28000"
+	}
+
+	var mssqlErr mssql.Error
+	if errors.As(err, &mssqlErr) {
+		return mssqlErr.Number == 18456
+	}
+
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "access denied") ||
+		strings.Contains(msg, "password authentication failed") ||
+		strings.Contains(msg, "no password supplied")
+}
+
+// keychainAccount identifies the server credentials in the keychain
+func keychainAccount(dbOpts DatabaseOptions) string {
+	if dbOpts.Socket != "" {
+		return dbOpts.Username + "@" + dbOpts.Socket
+	}
+	return dbOpts.Username + "@" + net.JoinHostPort(dbOpts.Host, dbOpts.Port)
+}
+
+// pingDatabase pings client and returns the client to use from then on. When
+// no password is configured and the server rejects the connection, the
+// keychain is tried first and then the user is prompted through interaction,
+// when it can ask, offering to store a working password in the keychain.
+// Clients receive the password through the driver or the environment, never
+// on a command line. The returned client is always open and must be closed by
+// the caller, even when an error is returned.
+func pingDatabase(client DatabaseClient, factory DatabaseClientFactory, engine string, dbOpts DatabaseOptions, interaction types.Interaction) (DatabaseClient, error) {
+	pingErr := client.Ping()
+	if pingErr == nil || dbOpts.Password != "" || !isAuthError(pingErr) {
+		return client, pingErr
+	}
+
+	account := keychainAccount(dbOpts)
+	if password, err := keychainGet(account); err == nil && password != "" {
+		if retry := reconnect(factory, engine, dbOpts, password); retry != nil {
+			client.Close()
+			return retry, nil
+		}
+	}
+
+	if interaction.Password == nil {
+		return client, pingErr
+	}
+
+	password, err := interaction.Password(fmt.Sprintf("Password for %s", account))
+	if err != nil {
+		return client, err
+	}
+	if password == "" {
+		return client, pingErr
+	}
+
+	retry := reconnect(factory, engine, dbOpts, password)
+	if retry == nil {
+		return client, pingErr
+	}
+	client.Close()
+
+	if interaction.Confirm != nil {
+		save, err := interaction.Confirm("Save this password to your keychain?")
+		if err != nil {
+			return retry, err
+		}
+		if save {
+			if err := keychainSet(account, password); err != nil {
+				logging.Infof("  warning: could not save password to keychain: %v", err)
+			}
+		}
+	}
+
+	return retry, nil
+}
+
+// reconnect returns a connected client using password, or nil when the
+// server still rejects it
+func reconnect(factory DatabaseClientFactory, engine string, dbOpts DatabaseOptions, password string) DatabaseClient {
+	dbOpts.Password = password
+	client, err := factory(engine, dbOpts)
+	if err != nil {
+		return nil
+	}
+	if err := client.Ping(); err != nil {
+		client.Close()
+		return nil
+	}
+	return client
+}
@@ -0,0 +1,148 @@
+package steps
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/michaeldyrynda/arbor/internal/scaffold/types"
+)
+
+// stubPrompts replaces the keychain for a test and returns an interaction
+// answering the password prompt with password, or one that cannot prompt
+// when password is empty
+func stubPrompts(t *testing.T, password string, save bool, stored map[string]string) types.Interaction {
+	t.Helper()
+
+	get, set := keychainGet, keychainSet
+	t.Cleanup(func() {
+		keychainGet, keychainSet = get, set
+	})
+
+	var interaction types.Interaction
+	if password != "" {
+		interaction.Password = func(string) (string, error) { return password, nil }
+		interaction.Confirm = func(string) (bool, error) { return save, nil }
+	}
+	keychainGet = func(account string) (string, error) {
+		if pw, ok := stored[account]; ok {
+			return pw, nil
+		}
+		return "", errors.New("not found")
+	}
+	keychainSet = func(account, password string) error {
+		stored[account] = password
+		return nil
+	}
+	return interaction
+}
+
+// passwordFactory returns clients that only connect with the given password
+func passwordFactory(want string, connected *DatabaseOptions) DatabaseClientFactory {
+	return func(engine string, opts DatabaseOptions) (DatabaseClient, error) {
+		client := NewMockDatabaseClient()
+		if opts.Password != want {
+			client.SetPingError(&mysql.MySQLError{Number: 1045, Message: "Access denied for user 'root'@'localhost'"})
+		} else {
+			*connected = opts
+		}
+		return client, nil
+	}
+}
+
+func TestPingDatabase(t *testing.T) {
+	opts := DatabaseOptions{Host: "127.0.0.1", Port: "3306", Username: "root"}
+
+	t.Run("prompts and saves the password to the keychain", func(t *testing.T) {
+		stored := map[string]string{}
+		interaction := stubPrompts(t, "secret", true, stored)
+
+		var connected DatabaseOptions
+		factory := passwordFactory("secret", &connected)
+		client, _ := factory("mysql", opts)
+
+		client, err := pingDatabase(client, factory, "mysql", opts, interaction)
+		require.NoError(t, err)
+		defer client.Close()
+
+		assert.Equal(t, "secret", connected.Password)
+		assert.Equal(t, map[string]string{"root@127.0.0.1:3306": "secret"}, stored)
+	})
+
+	t.Run("uses a password from the keychain", func(t *testing.T) {
+		stored := map[string]string{"root@127.0.0.1:3306": "cached"}
+		interaction := stubPrompts(t, "", false, stored)
+
+		var connected DatabaseOptions
+		factory := passwordFactory("cached", &connected)
+		client, _ := factory("mysql", opts)
+
+		client, err := pingDatabase(client, factory, "mysql", opts, interaction)
+		require.NoError(t, err)
+		defer client.Close()
+
+		assert.Equal(t, "cached", connected.Password)
+	})
+
+	t.Run("fails without prompting when not interactive", func(t *testing.T) {
+		interaction := stubPrompts(t, "", false, map[string]string{})
+
+		var connected DatabaseOptions
+		factory := passwordFactory("secret", &connected)
+		client, _ := factory("mysql", opts)
+
+		client, err := pingDatabase(client, factory, "mysql", opts, interaction)
+		defer client.Close()
+
+		require.Error(t, err)
+		assert.True(t, isAuthError(err))
+	})
+
+	t.Run("does not prompt when a password is configured", func(t *testing.T) {
+		interaction := stubPrompts(t, "secret", true, map[string]string{})
+		interaction.Password = func(string) (string, error) {
+			t.Fatal("unexpected prompt")
+			return "", nil
+		}
+
+		withPassword := opts
+		withPassword.Password = "wrong"
+		var connected DatabaseOptions
+		factory := passwordFactory("secret", &connected)
+		client, _ := factory("mysql", withPassword)
+
+		client, err := pingDatabase(client, factory, "mysql", withPassword, interaction)
+		defer client.Close()
+
+		require.Error(t, err)
+	})
+
+	t.Run("ignores errors other than authentication", func(t *testing.T) {
+		interaction := stubPrompts(t, "secret", true, map[string]string{})
+
+		client := NewMockDatabaseClient()
+		client.SetPingError(errors.New("connection refused"))
+
+		got, err := pingDatabase(client, MockClientFactory(client), "mysql", opts, interaction)
+
+		require.Error(t, err)
+		assert.Same(t, client, got)
+	})
+}
+
+func TestIsAuthError(t *testing.T) {
+	assert.True(t, isAuthError(&mysql.MySQLError{Number: 1045}))
+	assert.False(t, isAuthError(&mysql.MySQLError{Number: 1007}))
+	assert.True(t, isAuthError(errors.New(`FATAL: password authentication failed for user "postgres"`)))
+	assert.True(t, isAuthError(errors.New("ERROR 1045 (28000): Access denied for user 'root'@'localhost'")))
+	assert.False(t, isAuthError(errors.New("connection refused")))
+	assert.False(t, isAuthError(nil))
+}
+
+func TestKeychainAccount(t *testing.T) {
+	assert.Equal(t, "root@127.0.0.1:3306", keychainAccount(DatabaseOptions{Host: "127.0.0.1", Port: "3306", Username: "root"}))
+	assert.Equal(t, "postgres@/var/run/postgresql", keychainAccount(DatabaseOptions{Socket: "/var/run/postgresql", Username: "postgres"}))
+}
@@ -0,0 +1,209 @@
+package steps
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/michaeldyrynda/arbor/internal/scaffold/types"
+	"github.com/michaeldyrynda/arbor/internal/scaffold/words"
+)
+
+// defaultContainerImages are the images used when db.container.image is unset
+var defaultContainerImages = map[string]string{
+	"mysql": "mysql:8.4",
+	"pgsql": "postgres:17",
+}
+
+// containerPorts are the ports each engine listens on inside its container
+var containerPorts = map[string]string{
+	"mysql": "3306",
+	"pgsql": "5432",
+}
+
+// systemDatabases are created by the server itself, so a container holding
+// only these has no worktree databases left
+var systemDatabases = map[string]bool{
+	"information_schema": true,
+	"mysql":              true,
+	"performance_schema": true,
+	"sys":                true,
+	"postgres":           true,
+}
+
+// containerReadyTimeout bounds how long a freshly started container is given
+// to accept connections
+var (
+	containerReadyTimeout  = 60 * time.Second
+	containerReadyInterval = time.Second
+)
+
+// databaseContainer is the Docker container holding a project's databases.
+// One container is shared by every worktree of the project.
+type databaseContainer struct {
+	name   string
+	engine string
+	image  string
+	run    commandRunner
+}
+
+func newDatabaseContainer(ctx *types.ScaffoldContext, engine string, run commandRunner) (*databaseContainer, error) {
+	if _, ok := containerPorts[engine]; !ok {
+		return nil, fmt.Errorf("db.container does not support %s databases", engine)
+	}
+
+	project := ctx.SiteName
+	if project == "" {
+		project = ctx.RepoPath
+	}
+	if project == "" {
+		project = "app"
+	}
+
+	image := ctx.Database.Container.Image
+	if image == "" {
+		image = defaultContainerImages[engine]
+	}
+
+	return &databaseContainer{
+		name:   fmt.Sprintf("arbor-%s-%s", strings.ReplaceAll(words.SanitizeSiteName(project), "_", "-"), engine),
+		engine: engine,
+		image:  image,
+		run:    run,
+	}, nil
+}
+
+// state reports whether the container exists and whether it is running
+func (c *databaseContainer) state() (exists, running bool) {
+	output, err := c.run("docker", []string{"inspect", "--format", "{{.State.Running}}", c.name}, nil)
+	if err != nil {
+		return false, false
+	}
+	return true, strings.TrimSpace(output) == "true"
+}
+
+// start creates or starts the container, returning true when it was not
+// already running. Credentials are passed through the environment so they
+// do not appear in the process list.
+func (c *databaseContainer) start(opts DatabaseOptions) (bool, error) {
+	exists, running := c.state()
+	if running {
+		return false, nil
+	}
+
+	if exists {
+		if _, err := c.run("docker", []string{"start", c.name}, nil); err != nil {
+			return false, fmt.Errorf("starting container %s: %w", c.name, err)
+		}
+		return true, nil
+	}
+
+	var env []string
+	switch c.engine {
+	case "mysql":
+		if opts.Password != "" {
+			env = append(env, "MYSQL_ROOT_PASSWORD="+opts.Password)
+		} else {
+			env = append(env, "MYSQL_ALLOW_EMPTY_PASSWORD=yes")
+		}
+	case "pgsql":
+		env = append(env, "POSTGRES_USER="+opts.Username)
+		if opts.Password != "" {
+			env = append(env, "POSTGRES_PASSWORD="+opts.Password)
+		} else {
+			env = append(env, "POSTGRES_HOST_AUTH_METHOD=trust")
+		}
+	}
+
+	args := []string{"run", "--detach", "--name", c.name, "--label", "arbor.managed=true"}
+	for _, kv := range env {
+		key, _, _ := strings.Cut(kv, "=")
+		args = append(args, "--env", key)
+	}
+	args = append(args, "--publish", "127.0.0.1::"+containerPorts[c.engine], c.image)
+
+	if _, err := c.run("docker", args, env); err != nil {
+		return false, fmt.Errorf("running container %s: %w", c.name, err)
+	}
+
+	return true, nil
+}
+
+// hostPort returns the host port Docker mapped to the database port
+func (c *databaseContainer) hostPort() (string, error) {
+	output, err := c.run("docker", []string{"port", c.name, containerPorts[c.engine] + "/tcp"}, nil)
+	if err != nil {
+		return "", fmt.Errorf("reading port for container %s: %w", c.name, err)
+	}
+
+	for _, line := range strings.Split(output, "\n") {
+		if _, port, err := net.SplitHostPort(strings.TrimSpace(line)); err == nil {
+			return port, nil
+		}
+	}
+
+	return "", fmt.Errorf("container %s has no published port", c.name)
+}
+
+func (c *databaseContainer) stop() error {
+	if _, err := c.run("docker", []string{"stop", c.name}, nil); err != nil {
+		return fmt.Errorf("stopping container %s: %w", c.name, err)
+	}
+	return nil
+}
+
+// connectionOptions points opts at the container. MySQL containers are
+// managed through root, PostgreSQL ones create the configured superuser.
+// Socket and TLS settings describe the configured server, not the container.
+func (c *databaseContainer) connectionOptions(opts DatabaseOptions, port string) DatabaseOptions {
+	opts.Host = "127.0.0.1"
+	opts.Port = port
+	opts.Socket = ""
+	opts.SSLMode = ""
+	opts.SSLCA = ""
+	if c.engine == "mysql" {
+		opts.Username = "root"
+	}
+	return opts
+}
+
+// waitForDatabase pings client until it accepts connections, giving a newly
+// started server time to initialise
+func waitForDatabase(client DatabaseClient) error {
+	deadline := time.Now().Add(containerReadyTimeout)
+	for {
+		err := client.Ping()
+		if err == nil {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("database not ready after %s: %w", containerReadyTimeout, err)
+		}
+		time.Sleep(containerReadyInterval)
+	}
+}
+
+// ownDatabase returns the database the image creates for the connecting
+// user: the PostgreSQL image gives POSTGRES_USER a database of the same name
+func (c *databaseContainer) ownDatabase(opts DatabaseOptions) string {
+	if c.engine == "pgsql" {
+		return opts.Username
+	}
+	return ""
+}
+
+// hasWorktreeDatabases reports whether any databases remain besides the
+// system ones and own, the container's own database
+func hasWorktreeDatabases(client DatabaseClient, own string) (bool, error) {
+	databases, err := client.ListDatabases("%")
+	if err != nil {
+		return false, err
+	}
+	for _, name := range databases {
+		if !systemDatabases[name] && name != own {
+			return true, nil
+		}
+	}
+	return false, nil
+}
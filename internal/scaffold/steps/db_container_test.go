@@ -0,0 +1,221 @@
+package steps
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/michaeldyrynda/arbor/internal/config"
+	"github.com/michaeldyrynda/arbor/internal/scaffold/types"
+	"github.com/michaeldyrynda/arbor/internal/utils"
+)
+
+// fakeDocker records docker invocations and answers inspect and port calls
+type fakeDocker struct {
+	exists  bool
+	running bool
+	port    string
+	calls   []string
+	env     []string
+}
+
+func (d *fakeDocker) run(name string, args []string, env []string) (string, error) {
+	d.calls = append(d.calls, strings.Join(args, " "))
+	d.env = append(d.env, env...)
+
+	switch args[0] {
+	case "inspect":
+		if !d.exists {
+			return "", errors.New("docker: No such object")
+		}
+		if d.running {
+			return "true\n", nil
+		}
+		return "false\n", nil
+	case "run", "start":
+		d.exists, d.running = true, true
+	case "stop":
+		d.running = false
+	case "port":
+		return "127.0.0.1:" + d.port + "\n", nil
+	}
+	return "", nil
+}
+
+func (d *fakeDocker) called(prefix string) bool {
+	for _, call := range d.calls {
+		if strings.HasPrefix(call, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestDbCreateStep_Container(t *testing.T) {
+	t.Run("starts a container and points .env at it", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, ".env"), []byte("DB_CONNECTION=mysql\nDB_HOST=127.0.0.1\nDB_PORT=3306\nDB_USERNAME=root\nDB_PASSWORD=secret\n"), 0644))
+
+		docker := &fakeDocker{port: "49153"}
+		var connected DatabaseOptions
+		mockClient := NewMockDatabaseClient()
+		factory := func(engine string, opts DatabaseOptions) (DatabaseClient, error) {
+			connected = opts
+			return mockClient, nil
+		}
+
+		step := NewDbCreateStepWithFactory(config.StepConfig{}, 8, factory)
+		step.runContainer = docker.run
+		ctx := &types.ScaffoldContext{
+			WorktreePath: dir,
+			SiteName:     "my_app",
+			Database:     config.DatabaseConfig{Container: config.DatabaseContainerConfig{Enabled: true}},
+		}
+
+		require.NoError(t, step.Run(ctx, types.StepOptions{}))
+
+		assert.True(t, docker.called("run --detach --name arbor-my-app-mysql --label arbor.managed=true --env MYSQL_ROOT_PASSWORD --publish 127.0.0.1::3306 mysql:8.4"))
+		assert.Contains(t, docker.env, "MYSQL_ROOT_PASSWORD=secret")
+		assert.Equal(t, DatabaseOptions{Host: "127.0.0.1", Port: "49153", Username: "root", Password: "secret"}, connected)
+		assert.Len(t, mockClient.GetCreateCalls(), 1)
+
+		env := utils.ReadEnvFile(dir, ".env")
+		assert.Equal(t, "127.0.0.1", env["DB_HOST"])
+		assert.Equal(t, "49153", env["DB_PORT"])
+		assert.Equal(t, "root", env["DB_USERNAME"])
+	})
+
+	t.Run("reuses a running container", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, ".env"), []byte("DB_CONNECTION=pgsql\nDB_USERNAME=sail\n"), 0644))
+
+		docker := &fakeDocker{exists: true, running: true, port: "55432"}
+		step := NewDbCreateStepWithFactory(config.StepConfig{}, 8, MockClientFactory(NewMockDatabaseClient()))
+		step.runContainer = docker.run
+		ctx := &types.ScaffoldContext{
+			WorktreePath: dir,
+			SiteName:     "app",
+			Database:     config.DatabaseConfig{Container: config.DatabaseContainerConfig{Enabled: true}},
+		}
+
+		require.NoError(t, step.Run(ctx, types.StepOptions{}))
+
+		assert.False(t, docker.called("run"))
+		assert.False(t, docker.called("start"))
+		env := utils.ReadEnvFile(dir, ".env")
+		assert.Equal(t, "55432", env["DB_PORT"])
+		assert.Equal(t, "sail", env["DB_USERNAME"], "postgres containers keep the configured user")
+	})
+
+	t.Run("rejects engines without a container image", func(t *testing.T) {
+		step := NewDbCreateStepWithFactory(config.StepConfig{Type: "sqlsrv"}, 8, MockClientFactory(NewMockDatabaseClient()))
+		step.runContainer = (&fakeDocker{}).run
+		ctx := &types.ScaffoldContext{
+			WorktreePath: t.TempDir(),
+			Database:     config.DatabaseConfig{Container: config.DatabaseContainerConfig{Enabled: true}},
+		}
+
+		err := step.Run(ctx, types.StepOptions{})
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "does not support sqlsrv")
+	})
+}
+
+func TestDbDestroyStep_Container(t *testing.T) {
+	newCtx := func(t *testing.T) *types.ScaffoldContext {
+		return &types.ScaffoldContext{
+			WorktreePath: t.TempDir(),
+			SiteName:     "app",
+			DbSuffix:     "cool_engine",
+			Database:     config.DatabaseConfig{Container: config.DatabaseContainerConfig{Enabled: true}},
+		}
+	}
+
+	t.Run("stops the container once the last database is dropped", func(t *testing.T) {
+		docker := &fakeDocker{exists: true, running: true, port: "49153"}
+		mockClient := NewMockDatabaseClient()
+		mockClient.AddDatabase("app_cool_engine")
+
+		step := NewDbDestroyStepWithFactory(config.StepConfig{Type: "mysql"}, MockClientFactory(mockClient))
+		step.runContainer = docker.run
+
+		require.NoError(t, step.Run(newCtx(t), types.StepOptions{}))
+
+		assert.Equal(t, []string{"app_cool_engine"}, mockClient.GetDropCalls())
+		assert.True(t, docker.called("stop arbor-app-mysql"))
+	})
+
+	t.Run("ignores the database the postgres image creates for its user", func(t *testing.T) {
+		docker := &fakeDocker{exists: true, running: true, port: "49153"}
+		mockClient := NewMockDatabaseClient()
+		mockClient.AddDatabase("postgres")
+		mockClient.AddDatabase("sail")
+		mockClient.AddDatabase("app_cool_engine")
+
+		ctx := newCtx(t)
+		ctx.Database.Username = "sail"
+
+		step := NewDbDestroyStepWithFactory(config.StepConfig{Type: "pgsql"}, MockClientFactory(mockClient))
+		step.runContainer = docker.run
+
+		require.NoError(t, step.Run(ctx, types.StepOptions{}))
+
+		assert.Equal(t, []string{"app_cool_engine"}, mockClient.GetDropCalls())
+		assert.True(t, docker.called("stop arbor-app-pgsql"))
+	})
+
+	t.Run("keeps the container running for other worktrees", func(t *testing.T) {
+		docker := &fakeDocker{exists: true, running: true, port: "49153"}
+		mockClient := NewMockDatabaseClient()
+		mockClient.AddDatabase("app_cool_engine")
+		mockClient.SetDropError(errors.New("boom"))
+
+		step := NewDbDestroyStepWithFactory(config.StepConfig{Type: "mysql"}, MockClientFactory(mockClient))
+		step.runContainer = docker.run
+
+		require.NoError(t, step.Run(newCtx(t), types.StepOptions{}))
+
+		assert.False(t, docker.called("stop"))
+	})
+
+	t.Run("does nothing without a container", func(t *testing.T) {
+		docker := &fakeDocker{}
+		mockClient := NewMockDatabaseClient()
+
+		step := NewDbDestroyStepWithFactory(config.StepConfig{Type: "mysql"}, MockClientFactory(mockClient))
+		step.runContainer = docker.run
+
+		require.NoError(t, step.Run(newCtx(t), types.StepOptions{}))
+
+		assert.Equal(t, []string{"inspect --format {{.State.Running}} arbor-app-mysql"}, docker.calls)
+		assert.Empty(t, mockClient.GetDropCalls())
+	})
+}
+
+func TestHasWorktreeDatabases(t *testing.T) {
+	mockClient := NewMockDatabaseClient()
+	mockClient.AddDatabase("mysql")
+	mockClient.AddDatabase("information_schema")
+
+	inUse, err := hasWorktreeDatabases(mockClient, "")
+	require.NoError(t, err)
+	assert.False(t, inUse)
+
+	mockClient.AddDatabase("sail")
+
+	inUse, err = hasWorktreeDatabases(mockClient, "sail")
+	require.NoError(t, err)
+	assert.False(t, inUse, "the container's own database is not a worktree database")
+
+	mockClient.AddDatabase("app_cool_engine")
+
+	inUse, err = hasWorktreeDatabases(mockClient, "sail")
+	require.NoError(t, err)
+	assert.True(t, inUse)
+}
@@ -0,0 +1,95 @@
+package steps
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/michaeldyrynda/arbor/internal/scaffold/types"
+	"github.com/michaeldyrynda/arbor/internal/scaffold/words"
+)
+
+// OpenDatabaseClient connects to the database server used by the worktree in
+// ctx, resolving the engine and credentials the same way db.create does. It
+// returns the connected client and its engine.
+func OpenDatabaseClient(ctx *types.ScaffoldContext, dbType string, factory DatabaseClientFactory) (DatabaseClient, string, error) {
+	engine, err := detectDatabaseEngine(ctx, dbType)
+	if err != nil {
+		return nil, "", err
+	}
+	if engine == "sqlite" {
+		return nil, engine, fmt.Errorf("sqlite databases live in the worktree and are removed with it")
+	}
+
+	dbOpts, err := resolveConnectionOptions(ctx, engine, nil)
+	if err != nil {
+		return nil, engine, err
+	}
+	if err := validateDatabaseMode(ctx, engine, dbOpts); err != nil {
+		return nil, engine, err
+	}
+
+	if ctx.Database.Container.Enabled {
+		container, err := newDatabaseContainer(ctx, engine, runCommand)
+		if err != nil {
+			return nil, engine, err
+		}
+		if _, running := container.state(); !running {
+			return nil, engine, fmt.Errorf("database container %s is not running", container.name)
+		}
+		port, err := container.hostPort()
+		if err != nil {
+			return nil, engine, err
+		}
+		dbOpts = container.connectionOptions(dbOpts, port)
+	}
+
+	client, err := factory(engine, dbOpts)
+	if err != nil {
+		return nil, engine, fmt.Errorf("creating database client: %w", err)
+	}
+
+	if client, err = pingDatabase(client, factory, engine, dbOpts, ctx.Interaction); err != nil {
+		client.Close()
+		return nil, engine, fmt.Errorf("connecting to %s database: %w", engine, err)
+	}
+
+	return client, engine, nil
+}
+
+// OrphanedDatabases returns the databases named {prefix}_{suffix} or
+// {prefix}_test_{suffix} for one of prefixes, where arbor recorded suffix for a
+// worktree and it no longer belongs to an active one. Names are never matched
+// by pattern alone, so a database such as {prefix}_staging is only returned
+// when staging was recorded. A name that splits more than one way is only
+// orphaned when no reading of it is active.
+func OrphanedDatabases(databases []string, recordedSuffixes, activeSuffixes map[string]bool, prefixes []string) []string {
+	var orphaned []string
+	for _, name := range databases {
+		suffixes := databaseSuffixes(name, prefixes)
+		if !slices.ContainsFunc(suffixes, func(suffix string) bool { return recordedSuffixes[suffix] }) ||
+			slices.ContainsFunc(suffixes, func(suffix string) bool { return activeSuffixes[suffix] }) {
+			continue
+		}
+
+		orphaned = append(orphaned, name)
+	}
+
+	sort.Strings(orphaned)
+	return orphaned
+}
+
+// databaseSuffixes returns every suffix name has as a database, or the test
+// database db.create --test gives it, of one of prefixes
+func databaseSuffixes(name string, prefixes []string) []string {
+	var suffixes []string
+	for _, prefix := range prefixes {
+		for _, p := range []string{prefix, prefix + "_test"} {
+			if suffix, ok := strings.CutPrefix(name, words.SanitizeSiteName(p)+"_"); ok && suffix != "" {
+				suffixes = append(suffixes, suffix)
+			}
+		}
+	}
+	return suffixes
+}
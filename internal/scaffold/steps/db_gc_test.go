@@ -0,0 +1,83 @@
+package steps
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/michaeldyrynda/arbor/internal/scaffold/types"
+)
+
+func TestOrphanedDatabases(t *testing.T) {
+	databases := []string{
+		"app_cool_engine",
+		"quotes_cool_engine",
+		"app_swift_runner",
+		"app_test_swift_runner",
+		"feature_login_quick_pilot",
+		"other_project_data",
+		"app",
+		"mysql",
+	}
+	recorded := map[string]bool{"cool_engine": true, "swift_runner": true, "quick_pilot": true}
+	active := map[string]bool{"cool_engine": true}
+
+	t.Run("ignores every database without a prefix", func(t *testing.T) {
+		assert.Empty(t, OrphanedDatabases(databases, recorded, active, nil))
+	})
+
+	t.Run("limits results to the given prefixes", func(t *testing.T) {
+		assert.Equal(t, []string{"feature_login_quick_pilot"}, OrphanedDatabases(databases, recorded, active, []string{"feature-login"}))
+		assert.Equal(t, []string{"app_swift_runner", "app_test_swift_runner"}, OrphanedDatabases(databases, recorded, active, []string{"app"}), "test databases go with their prefix")
+	})
+
+	t.Run("only returns recorded suffixes", func(t *testing.T) {
+		databases := []string{"myapp_staging", "myapp_test", "myapp_backup_2024", "myapp_test_swift_fox", "myapp_proj_123", "myapp_feature_login"}
+		recorded := map[string]bool{"feature_login": true}
+
+		assert.Equal(t, []string{"myapp_feature_login"}, OrphanedDatabases(databases, recorded, nil, []string{"myapp"}))
+	})
+
+	t.Run("keeps names with an active reading", func(t *testing.T) {
+		databases := []string{"app_feature_login", "app_test_feature_login", "app_test_runner", "app_main"}
+		recorded := map[string]bool{"feature_login": true, "runner": true, "main": true, "test_runner": true}
+		active := map[string]bool{"main": true, "test_runner": true}
+
+		assert.Equal(t, []string{"app_feature_login", "app_test_feature_login"}, OrphanedDatabases(databases, recorded, active, []string{"app"}),
+			"app_test_runner may be the database of branch test-runner, which is active")
+	})
+}
+
+func TestOpenDatabaseClient(t *testing.T) {
+	t.Run("connects with resolved options", func(t *testing.T) {
+		var connected DatabaseOptions
+		factory := func(engine string, opts DatabaseOptions) (DatabaseClient, error) {
+			connected = opts
+			return NewMockDatabaseClient(), nil
+		}
+
+		client, engine, err := OpenDatabaseClient(&types.ScaffoldContext{WorktreePath: t.TempDir()}, "pgsql", factory)
+
+		require.NoError(t, err)
+		assert.NotNil(t, client)
+		assert.Equal(t, "pgsql", engine)
+		assert.Equal(t, "postgres", connected.Username)
+	})
+
+	t.Run("reports connection failures", func(t *testing.T) {
+		mockClient := NewMockDatabaseClient()
+		mockClient.SetPingError(errors.New("connection refused"))
+
+		_, _, err := OpenDatabaseClient(&types.ScaffoldContext{WorktreePath: t.TempDir()}, "mysql", MockClientFactory(mockClient))
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "connection refused")
+	})
+
+	t.Run("rejects sqlite", func(t *testing.T) {
+		_, _, err := OpenDatabaseClient(&types.ScaffoldContext{WorktreePath: t.TempDir()}, "sqlite", MockClientFactory(NewMockDatabaseClient()))
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,136 @@
+package steps
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/michaeldyrynda/arbor/internal/scaffold/types"
+	"github.com/michaeldyrynda/arbor/internal/utils"
+)
+
+// Database modes accepted by db.mode
+const (
+	databaseModeDatabase = "database"
+	databaseModeSchema   = "schema"
+)
+
+// schemaMode reports whether worktrees get a schema inside an existing
+// database rather than a database of their own
+func schemaMode(ctx *types.ScaffoldContext) bool {
+	return ctx.Database.Mode == databaseModeSchema
+}
+
+// validateDatabaseMode checks db.mode against the engine and connection.
+// Schemas need PostgreSQL and an existing database to hold them.
+func validateDatabaseMode(ctx *types.ScaffoldContext, engine string, dbOpts DatabaseOptions) error {
+	switch ctx.Database.Mode {
+	case "", databaseModeDatabase:
+		return nil
+	case databaseModeSchema:
+		if engine != "pgsql" {
+			return fmt.Errorf("db.mode schema is only supported for PostgreSQL, not %s", engine)
+		}
+		if dbOpts.Database == "" {
+			return fmt.Errorf("db.mode schema needs db.database or DB_DATABASE to name the database holding worktree schemas")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported db.mode: %s", ctx.Database.Mode)
+	}
+}
+
+// DatabaseTarget creates and drops worktree databases, or worktree schemas
+// when db.mode is schema
+type DatabaseTarget struct {
+	// Kind is "database" or "schema"
+	Kind    string
+	client  DatabaseClient
+	schemas SchemaClient
+}
+
+// NewDatabaseTarget wraps client for the mode configured in ctx
+func NewDatabaseTarget(ctx *types.ScaffoldContext, client DatabaseClient) (*DatabaseTarget, error) {
+	if !schemaMode(ctx) {
+		return &DatabaseTarget{Kind: databaseModeDatabase, client: client}, nil
+	}
+
+	schemas, ok := client.(SchemaClient)
+	if !ok {
+		return nil, fmt.Errorf("db.mode schema is not supported for this database")
+	}
+	return &DatabaseTarget{Kind: databaseModeSchema, client: client, schemas: schemas}, nil
+}
+
+func (t *DatabaseTarget) Create(name string) error {
+	if t.schemas != nil {
+		return t.schemas.CreateSchema(name)
+	}
+	return t.client.CreateDatabase(name)
+}
+
+func (t *DatabaseTarget) Drop(name string) error {
+	if t.schemas != nil {
+		return t.schemas.DropSchema(name)
+	}
+	return t.client.DropDatabase(name)
+}
+
+func (t *DatabaseTarget) List(pattern string) ([]string, error) {
+	if t.schemas != nil {
+		return t.schemas.ListSchemas(pattern)
+	}
+	return t.client.ListDatabases(pattern)
+}
+
+// grant gives user full access to the named database or schema
+func (t *DatabaseTarget) grant(users DatabaseUserClient, user, name string) error {
+	if t.schemas != nil {
+		return t.schemas.GrantSchema(user, name)
+	}
+	return users.GrantDatabase(user, name)
+}
+
+func (t *DatabaseTarget) createSQL(engine, name string) string {
+	if t.Kind == databaseModeSchema {
+		return createSchemaSQL(name)
+	}
+	return createDatabaseSQL(engine, name)
+}
+
+func (t *DatabaseTarget) dropSQL(engine, name string) string {
+	if t.Kind == databaseModeSchema {
+		return dropSchemaSQL(name)
+	}
+	return dropDatabaseSQL(engine, name)
+}
+
+func (t *DatabaseTarget) grantSQL(engine, user, name string) string {
+	if t.Kind == databaseModeSchema {
+		return grantSchemaSQL(user, name)
+	}
+	return grantDatabaseSQL(engine, user, name)
+}
+
+// previewTarget returns a target for dry-run SQL, which needs no client
+func previewTarget(ctx *types.ScaffoldContext) *DatabaseTarget {
+	if schemaMode(ctx) {
+		return &DatabaseTarget{Kind: databaseModeSchema}
+	}
+	return &DatabaseTarget{Kind: databaseModeDatabase}
+}
+
+// writeSchemaEnv points the worktree .env at schema. With several db.create
+// steps the first schema is kept, as the others are reached by name.
+func writeSchemaEnv(ctx *types.ScaffoldContext, schema string) error {
+	env := utils.ReadEnvFile(ctx.WorktreePath, ".env")
+	if current := env["DB_SCHEMA"]; current != schema && strings.HasSuffix(current, "_"+ctx.GetDbSuffix()) {
+		return nil
+	}
+
+	for _, key := range []string{"DB_SCHEMA", "DB_SEARCH_PATH"} {
+		if err := utils.WriteEnvValue(ctx.WorktreePath, ".env", key, schema); err != nil {
+			return fmt.Errorf("writing %s to .env: %w", key, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package steps
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/michaeldyrynda/arbor/internal/config"
+	"github.com/michaeldyrynda/arbor/internal/scaffold/types"
+	"github.com/michaeldyrynda/arbor/internal/utils"
+)
+
+func newSchemaContext(t *testing.T, env string) *types.ScaffoldContext {
+	t.Helper()
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".env"), []byte(env), 0644))
+	ctx := &types.ScaffoldContext{
+		WorktreePath: dir,
+		SiteName:     "app",
+		Database:     config.DatabaseConfig{Mode: "schema"},
+	}
+	ctx.SetDbSuffix("cool_engine")
+	return ctx
+}
+
+func TestDbCreateStep_SchemaMode(t *testing.T) {
+	t.Run("creates a schema in the existing database", func(t *testing.T) {
+		ctx := newSchemaContext(t, "DB_CONNECTION=pgsql\nDB_DATABASE=app\n")
+		mockClient := NewMockDatabaseClient()
+		var connected DatabaseOptions
+		factory := func(engine string, opts DatabaseOptions) (DatabaseClient, error) {
+			connected = opts
+			return mockClient, nil
+		}
+
+		require.NoError(t, NewDbCreateStepWithFactory(config.StepConfig{}, 8, factory).Run(ctx, types.StepOptions{}))
+
+		assert.Equal(t, "app", connected.Database)
+		assert.True(t, mockClient.HasSchema("app_cool_engine"))
+		assert.Empty(t, mockClient.GetCreateCalls(), "no database should be created")
+
+		env := utils.ReadEnvFile(ctx.WorktreePath, ".env")
+		assert.Equal(t, "app_cool_engine", env["DB_SCHEMA"])
+		assert.Equal(t, "app_cool_engine", env["DB_SEARCH_PATH"])
+		assert.Equal(t, "app", env["DB_DATABASE"])
+	})
+
+	t.Run("keeps the first schema in .env", func(t *testing.T) {
+		ctx := newSchemaContext(t, "DB_CONNECTION=pgsql\nDB_DATABASE=app\n")
+		mockClient := NewMockDatabaseClient()
+
+		require.NoError(t, NewDbCreateStepWithFactory(config.StepConfig{}, 8, MockClientFactory(mockClient)).Run(ctx, types.StepOptions{}))
+		require.NoError(t, NewDbCreateStepWithFactory(config.StepConfig{Args: []string{"--prefix", "quotes"}}, 8, MockClientFactory(mockClient)).Run(ctx, types.StepOptions{}))
+
+		assert.True(t, mockClient.HasSchema("quotes_cool_engine"))
+		assert.Equal(t, "app_cool_engine", utils.ReadEnvFile(ctx.WorktreePath, ".env")["DB_SCHEMA"])
+	})
+
+	t.Run("grants the worktree user the schema", func(t *testing.T) {
+		ctx := newSchemaContext(t, "DB_CONNECTION=pgsql\nDB_DATABASE=app\n")
+		ctx.Database.CreateUser = true
+		mockClient := NewMockDatabaseClient()
+
+		require.NoError(t, NewDbCreateStepWithFactory(config.StepConfig{}, 8, MockClientFactory(mockClient)).Run(ctx, types.StepOptions{}))
+
+		assert.Equal(t, []string{"app_cool_engine"}, mockClient.GetGrants("arbor_cool_engine"))
+	})
+
+	t.Run("prefers db.database over .env", func(t *testing.T) {
+		ctx := newSchemaContext(t, "DB_CONNECTION=pgsql\nDB_DATABASE=app\n")
+		ctx.Database.Database = "shared"
+
+		opts, err := resolveConnectionOptions(ctx, "pgsql", nil)
+
+		require.NoError(t, err)
+
+		assert.Equal(t, "shared", opts.Database)
+	})
+
+	t.Run("requires PostgreSQL", func(t *testing.T) {
+		ctx := newSchemaContext(t, "DB_CONNECTION=mysql\nDB_DATABASE=app\n")
+
+		err := NewDbCreateStepWithFactory(config.StepConfig{}, 8, MockClientFactory(NewMockDatabaseClient())).Run(ctx, types.StepOptions{})
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "only supported for PostgreSQL")
+	})
+
+	t.Run("requires a database to hold the schemas", func(t *testing.T) {
+		ctx := newSchemaContext(t, "DB_CONNECTION=pgsql\n")
+
+		err := NewDbCreateStepWithFactory(config.StepConfig{}, 8, MockClientFactory(NewMockDatabaseClient())).Run(ctx, types.StepOptions{})
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "db.database or DB_DATABASE")
+	})
+}
+
+func TestDbDestroyStep_SchemaMode(t *testing.T) {
+	ctx := newSchemaContext(t, "DB_CONNECTION=pgsql\nDB_DATABASE=app\n")
+	mockClient := NewMockDatabaseClient()
+	mockClient.AddDatabase("app")
+	mockClient.AddSchema("app_cool_engine")
+
+	require.NoError(t, NewDbDestroyStepWithFactory(config.StepConfig{}, MockClientFactory(mockClient)).Run(ctx, types.StepOptions{}))
+
+	assert.False(t, mockClient.HasSchema("app_cool_engine"))
+	assert.True(t, mockClient.HasDatabase("app"), "the database holding the schemas is kept")
+	assert.Empty(t, mockClient.GetDropCalls())
+}
+
+func TestValidateDatabaseMode(t *testing.T) {
+	ctx := &types.ScaffoldContext{Database: config.DatabaseConfig{Mode: "tenant"}}
+
+	err := validateDatabaseMode(ctx, "pgsql", DatabaseOptions{})
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported db.mode")
+	assert.NoError(t, validateDatabaseMode(&types.ScaffoldContext{}, "mysql", DatabaseOptions{}))
+}
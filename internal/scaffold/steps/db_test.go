@@ -12,6 +12,7 @@ import (
 
 	"github.com/michaeldyrynda/arbor/internal/config"
 	"github.com/michaeldyrynda/arbor/internal/scaffold/types"
+	"github.com/michaeldyrynda/arbor/internal/utils"
 )
 
 func TestDbCreateStep(t *testing.T) {
@@ -111,6 +112,32 @@ func TestDbCreateStep(t *testing.T) {
 		assert.NotEmpty(t, ctx.GetDbSuffix(), "DbSuffix should be set after db.create")
 	})
 
+	t.Run("auto-detects sqlsrv engine from DB_CONNECTION env", func(t *testing.T) {
+		tmpDir := t.TempDir()
+
+		envFile := filepath.Join(tmpDir, ".env")
+		if err := os.WriteFile(envFile, []byte("DB_CONNECTION=sqlsrv\n"), 0644); err != nil {
+			t.Fatalf("writing env file: %v", err)
+		}
+
+		var engine string
+		mockClient := NewMockDatabaseClient()
+		factory := func(e string, opts DatabaseOptions) (DatabaseClient, error) {
+			engine = e
+			return mockClient, nil
+		}
+		step := NewDbCreateStepWithFactory(config.StepConfig{}, 8, factory)
+		ctx := &types.ScaffoldContext{
+			WorktreePath: tmpDir,
+			SiteName:     "testapp",
+		}
+
+		err := step.Run(ctx, types.StepOptions{Verbose: false})
+		assert.NoError(t, err)
+		assert.Equal(t, "sqlsrv", engine)
+		assert.NotEmpty(t, ctx.GetDbSuffix(), "DbSuffix should be set after db.create")
+	})
+
 	t.Run("uses explicit type config over env detection", func(t *testing.T) {
 		tmpDir := t.TempDir()
 
@@ -409,6 +436,30 @@ func TestDbCreateStep(t *testing.T) {
 		assert.Equal(t, 1, mockClient.DatabaseCount(), "Should have created one database")
 	})
 
+	t.Run("reuses existing database for an established suffix", func(t *testing.T) {
+		tmpDir := t.TempDir()
+
+		envFile := filepath.Join(tmpDir, ".env")
+		if err := os.WriteFile(envFile, []byte("DB_CONNECTION=mysql\n"), 0644); err != nil {
+			t.Fatalf("writing env file: %v", err)
+		}
+
+		mockClient := NewMockDatabaseClient()
+		mockClient.AddDatabase("testapp_shared_suffix")
+
+		step := NewDbCreateStepWithFactory(config.StepConfig{}, 8, MockClientFactory(mockClient))
+		ctx := &types.ScaffoldContext{
+			WorktreePath: tmpDir,
+			SiteName:     "testapp",
+			DbSuffix:     "shared_suffix",
+		}
+
+		err := step.Run(ctx, types.StepOptions{Verbose: false})
+		assert.NoError(t, err)
+		assert.Equal(t, "shared_suffix", ctx.GetDbSuffix(), "Should keep the established suffix")
+		assert.Len(t, mockClient.GetCreateCalls(), 1, "Should not retry with a new suffix")
+	})
+
 	t.Run("fails after max retries", func(t *testing.T) {
 		tmpDir := t.TempDir()
 
@@ -529,7 +580,8 @@ func TestDbDestroyStep(t *testing.T) {
 
 		step := NewDbDestroyStepWithFactory(config.StepConfig{}, MockClientFactory(mockClient))
 		ctx := &types.ScaffoldContext{
-			WorktreePath: tmpDir,
+			WorktreePath:     tmpDir,
+			DatabasePrefixes: []string{"app1", "app2"},
 		}
 		ctx.SetDbSuffix("test_suffix")
 
@@ -541,6 +593,31 @@ func TestDbDestroyStep(t *testing.T) {
 		assert.Equal(t, 0, mockClient.DatabaseCount(), "All databases should be dropped")
 	})
 
+	t.Run("only drops the worktree's exact database names", func(t *testing.T) {
+		tmpDir := t.TempDir()
+
+		envFile := filepath.Join(tmpDir, ".env")
+		if err := os.WriteFile(envFile, []byte("DB_CONNECTION=mysql\n"), 0644); err != nil {
+			t.Fatalf("writing env file: %v", err)
+		}
+
+		mockClient := NewMockDatabaseClient()
+		for _, name := range []string{"app_main", "app_test_main", "other_main", "app_domain", "appxmain"} {
+			mockClient.AddDatabase(name)
+		}
+
+		step := NewDbDestroyStepWithFactory(config.StepConfig{}, MockClientFactory(mockClient))
+		ctx := &types.ScaffoldContext{
+			WorktreePath: tmpDir,
+			SiteName:     "app",
+		}
+		ctx.SetDbSuffix("main")
+
+		require.NoError(t, step.Run(ctx, types.StepOptions{}))
+
+		assert.ElementsMatch(t, []string{"app_main", "app_test_main"}, mockClient.GetDropCalls())
+	})
+
 	t.Run("auto-detects mysql engine from DB_CONNECTION env", func(t *testing.T) {
 		tmpDir := t.TempDir()
 
@@ -727,3 +804,337 @@ func TestIsDatabaseExistsError(t *testing.T) {
 		assert.False(t, IsDatabaseExistsError(err))
 	})
 }
+
+func TestDbStep_DatabaseConfig(t *testing.T) {
+	capturingFactory := func(captured *DatabaseOptions) DatabaseClientFactory {
+		return func(engine string, opts DatabaseOptions) (DatabaseClient, error) {
+			*captured = opts
+			return NewMockDatabaseClient(), nil
+		}
+	}
+
+	t.Run("db.create uses project database config", func(t *testing.T) {
+		var captured DatabaseOptions
+		step := NewDbCreateStepWithFactory(config.StepConfig{Type: "mysql"}, 8, capturingFactory(&captured))
+		ctx := &types.ScaffoldContext{
+			WorktreePath: t.TempDir(),
+			SiteName:     "testapp",
+			Database: config.DatabaseConfig{
+				Host:     "db.internal",
+				Username: "ci_user",
+				Password: "secret",
+			},
+		}
+
+		require.NoError(t, step.Run(ctx, types.StepOptions{}))
+		assert.Equal(t, "db.internal", captured.Host)
+		assert.Equal(t, "ci_user", captured.Username)
+		assert.Equal(t, "secret", captured.Password)
+	})
+
+	t.Run("step args take precedence over database config", func(t *testing.T) {
+		var captured DatabaseOptions
+		step := NewDbCreateStepWithFactory(config.StepConfig{
+			Type: "mysql",
+			Args: []string{"--username", "arg_user"},
+		}, 8, capturingFactory(&captured))
+		ctx := &types.ScaffoldContext{
+			WorktreePath: t.TempDir(),
+			SiteName:     "testapp",
+			Database:     config.DatabaseConfig{Username: "ci_user"},
+		}
+
+		require.NoError(t, step.Run(ctx, types.StepOptions{}))
+		assert.Equal(t, "arg_user", captured.Username)
+	})
+
+	t.Run("db.destroy uses project database config", func(t *testing.T) {
+		var captured DatabaseOptions
+		step := NewDbDestroyStepWithFactory(config.StepConfig{Type: "pgsql"}, capturingFactory(&captured))
+		ctx := &types.ScaffoldContext{
+			WorktreePath: t.TempDir(),
+			SiteName:     "testapp",
+			DbSuffix:     "cool_name",
+			Database:     config.DatabaseConfig{Username: "ci_user", Port: "5433"},
+		}
+
+		require.NoError(t, step.Run(ctx, types.StepOptions{}))
+		assert.Equal(t, "ci_user", captured.Username)
+		assert.Equal(t, "5433", captured.Port)
+	})
+}
+
+func TestResolveConnectionOptions(t *testing.T) {
+	writeEnv := func(t *testing.T, content string) string {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, ".env"), []byte(content), 0644))
+		return dir
+	}
+
+	t.Run("uses engine defaults without .env", func(t *testing.T) {
+		ctx := &types.ScaffoldContext{WorktreePath: t.TempDir()}
+
+		for engine, want := range map[string]DatabaseOptions{
+			"mysql":  {Host: "127.0.0.1", Port: "3306", Username: "root"},
+			"pgsql":  {Host: "127.0.0.1", Port: "5432", Username: "postgres"},
+			"sqlsrv": {Host: "127.0.0.1", Port: "1433", Username: "sa"},
+		} {
+			opts, err := resolveConnectionOptions(ctx, engine, nil)
+			require.NoError(t, err)
+			assert.Equal(t, want, opts, engine)
+		}
+	})
+
+	t.Run("reads connection details from .env", func(t *testing.T) {
+		dir := writeEnv(t, "DB_CONNECTION=mysql\nDB_HOST=mysql.test\nDB_PORT=3307\nDB_USERNAME=sail\nDB_PASSWORD=password\n")
+		ctx := &types.ScaffoldContext{WorktreePath: dir}
+
+		opts, err := resolveConnectionOptions(ctx, "mysql", nil)
+
+		require.NoError(t, err)
+
+		assert.Equal(t, DatabaseOptions{Host: "mysql.test", Port: "3307", Username: "sail", Password: "password"}, opts)
+	})
+
+	t.Run("empty .env values keep defaults", func(t *testing.T) {
+		dir := writeEnv(t, "DB_HOST=\nDB_USERNAME=\nDB_PASSWORD=\n")
+		ctx := &types.ScaffoldContext{WorktreePath: dir}
+
+		opts, err := resolveConnectionOptions(ctx, "mysql", nil)
+
+		require.NoError(t, err)
+
+		assert.Equal(t, "127.0.0.1", opts.Host)
+		assert.Equal(t, "root", opts.Username)
+		assert.Empty(t, opts.Password)
+	})
+
+	t.Run("config and args override .env", func(t *testing.T) {
+		dir := writeEnv(t, "DB_HOST=mysql.test\nDB_USERNAME=sail\nDB_PASSWORD=password\n")
+		ctx := &types.ScaffoldContext{
+			WorktreePath: dir,
+			Database:     config.DatabaseConfig{Username: "ci_user"},
+		}
+
+		opts, err := resolveConnectionOptions(ctx, "mysql", []string{"--password", "from-args"})
+
+		require.NoError(t, err)
+
+		assert.Equal(t, "mysql.test", opts.Host)
+		assert.Equal(t, "ci_user", opts.Username)
+		assert.Equal(t, "from-args", opts.Password)
+	})
+}
+
+func TestDbCreateStep_CreateUser(t *testing.T) {
+	newCtx := func(t *testing.T) *types.ScaffoldContext {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, ".env"), []byte("DB_CONNECTION=mysql\nDB_USERNAME=root\nDB_PASSWORD=rootpw\n"), 0644))
+		ctx := &types.ScaffoldContext{
+			WorktreePath: dir,
+			SiteName:     "app",
+			Database:     config.DatabaseConfig{CreateUser: true},
+		}
+		ctx.SetDbSuffix("cool_engine")
+		return ctx
+	}
+
+	t.Run("creates a user with access to each worktree database", func(t *testing.T) {
+		ctx := newCtx(t)
+		mockClient := NewMockDatabaseClient()
+		var connected []DatabaseOptions
+		factory := func(engine string, opts DatabaseOptions) (DatabaseClient, error) {
+			connected = append(connected, opts)
+			return mockClient, nil
+		}
+
+		require.NoError(t, NewDbCreateStepWithFactory(config.StepConfig{}, 8, factory).Run(ctx, types.StepOptions{}))
+		require.NoError(t, NewDbCreateStepWithFactory(config.StepConfig{Args: []string{"--prefix", "quotes"}}, 8, factory).Run(ctx, types.StepOptions{}))
+
+		env := utils.ReadEnvFile(ctx.WorktreePath, ".env")
+		assert.Equal(t, "arbor_cool_engine", env["DB_USERNAME"])
+		assert.Len(t, env["DB_PASSWORD"], 32)
+
+		password, ok := mockClient.GetUser("arbor_cool_engine")
+		require.True(t, ok)
+		assert.Equal(t, env["DB_PASSWORD"], password)
+		assert.Equal(t, []string{"app_cool_engine", "quotes_cool_engine"}, mockClient.GetGrants("arbor_cool_engine"))
+
+		require.Len(t, connected, 2)
+		assert.Equal(t, "root", connected[1].Username, "the worktree user should not be used to manage databases")
+	})
+
+	t.Run("is skipped unless enabled", func(t *testing.T) {
+		ctx := newCtx(t)
+		ctx.Database.CreateUser = false
+		mockClient := NewMockDatabaseClient()
+
+		require.NoError(t, NewDbCreateStepWithFactory(config.StepConfig{}, 8, MockClientFactory(mockClient)).Run(ctx, types.StepOptions{}))
+
+		_, ok := mockClient.GetUser("arbor_cool_engine")
+		assert.False(t, ok)
+		assert.Equal(t, "root", utils.ReadEnvFile(ctx.WorktreePath, ".env")["DB_USERNAME"])
+	})
+}
+
+func TestDbCreateStep_DryRun(t *testing.T) {
+	t.Run("generates a name without connecting", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, ".env"), []byte("DB_CONNECTION=mysql\n"), 0644))
+
+		factory := func(engine string, opts DatabaseOptions) (DatabaseClient, error) {
+			t.Fatal("dry run should not connect")
+			return nil, nil
+		}
+		ctx := &types.ScaffoldContext{WorktreePath: dir, SiteName: "app"}
+
+		step := NewDbCreateStepWithFactory(config.StepConfig{}, 8, factory)
+		require.True(t, step.SupportsDryRun())
+		require.NoError(t, step.Run(ctx, types.StepOptions{DryRun: true}))
+
+		assert.NotEmpty(t, ctx.GetDbSuffix(), "the generated suffix is shared with later steps")
+	})
+
+	t.Run("does not create a sqlite file", func(t *testing.T) {
+		dir := t.TempDir()
+		step := NewDbCreateStepWithFactory(config.StepConfig{Type: "sqlite"}, 8, MockClientFactory(NewMockDatabaseClient()))
+
+		require.NoError(t, step.Run(&types.ScaffoldContext{WorktreePath: dir}, types.StepOptions{DryRun: true}))
+
+		assert.NoFileExists(t, filepath.Join(dir, "database", "database.sqlite"))
+	})
+}
+
+func TestDbDestroyStep_DryRun(t *testing.T) {
+	t.Run("lists databases without connecting", func(t *testing.T) {
+		factory := func(engine string, opts DatabaseOptions) (DatabaseClient, error) {
+			t.Fatal("dry run should not connect")
+			return nil, nil
+		}
+		ctx := &types.ScaffoldContext{WorktreePath: t.TempDir(), SiteName: "app"}
+		ctx.SetDbSuffix("cool_engine")
+
+		step := NewDbDestroyStepWithFactory(config.StepConfig{Type: "pgsql"}, factory)
+		require.NoError(t, step.Run(ctx, types.StepOptions{DryRun: true}))
+	})
+
+	t.Run("keeps the worktree user", func(t *testing.T) {
+		mockClient := NewMockDatabaseClient()
+		mockClient.AddDatabase("app_cool_engine")
+		require.NoError(t, mockClient.CreateUser("arbor_cool_engine", "pw"))
+
+		ctx := &types.ScaffoldContext{
+			WorktreePath: t.TempDir(),
+			Database:     config.DatabaseConfig{CreateUser: true},
+		}
+		ctx.SetDbSuffix("cool_engine")
+
+		step := NewDbDestroyStepWithFactory(config.StepConfig{Type: "mysql"}, MockClientFactory(mockClient))
+		require.NoError(t, step.Run(ctx, types.StepOptions{DryRun: true}))
+
+		assert.Empty(t, mockClient.GetDropCalls())
+		_, ok := mockClient.GetUser("arbor_cool_engine")
+		assert.True(t, ok)
+	})
+
+	t.Run("does not start a stopped container", func(t *testing.T) {
+		docker := &fakeDocker{exists: true}
+		ctx := &types.ScaffoldContext{
+			WorktreePath: t.TempDir(),
+			SiteName:     "app",
+			Database:     config.DatabaseConfig{Container: config.DatabaseContainerConfig{Enabled: true}},
+		}
+		ctx.SetDbSuffix("cool_engine")
+
+		step := NewDbDestroyStepWithFactory(config.StepConfig{Type: "mysql"}, MockClientFactory(NewMockDatabaseClient()))
+		step.runContainer = docker.run
+		require.NoError(t, step.Run(ctx, types.StepOptions{DryRun: true}))
+
+		assert.False(t, docker.called("start"))
+	})
+}
+
+func TestDbDestroyStep_CreateUser(t *testing.T) {
+	mockClient := NewMockDatabaseClient()
+	mockClient.AddDatabase("app_cool_engine")
+	require.NoError(t, mockClient.CreateUser("arbor_cool_engine", "secret"))
+
+	ctx := &types.ScaffoldContext{
+		WorktreePath: t.TempDir(),
+		Database:     config.DatabaseConfig{CreateUser: true},
+	}
+	ctx.SetDbSuffix("cool_engine")
+
+	step := NewDbDestroyStepWithFactory(config.StepConfig{Type: "mysql"}, MockClientFactory(mockClient))
+	require.NoError(t, step.Run(ctx, types.StepOptions{}))
+
+	_, ok := mockClient.GetUser("arbor_cool_engine")
+	assert.False(t, ok)
+	assert.Equal(t, []string{"app_cool_engine"}, mockClient.GetDropCalls())
+}
+
+func TestDbDestroyStep_KeepOnRemove(t *testing.T) {
+	t.Run("keeps databases named from the branch", func(t *testing.T) {
+		mockClient := NewMockDatabaseClient()
+		mockClient.AddDatabase("app_feature_login")
+
+		ctx := &types.ScaffoldContext{
+			WorktreePath: t.TempDir(),
+			Branch:       "feature/login",
+			Database:     config.DatabaseConfig{NamingStrategy: "branch", KeepOnRemove: true},
+		}
+		ctx.SetDbSuffix("feature_login")
+
+		step := NewDbDestroyStepWithFactory(config.StepConfig{Type: "mysql"}, MockClientFactory(mockClient))
+		require.NoError(t, step.Run(ctx, types.StepOptions{}))
+
+		assert.Empty(t, mockClient.GetDropCalls())
+		assert.True(t, mockClient.HasDatabase("app_feature_login"))
+	})
+
+	t.Run("drops databases named with random words", func(t *testing.T) {
+		mockClient := NewMockDatabaseClient()
+		mockClient.AddDatabase("app_cool_engine")
+
+		ctx := &types.ScaffoldContext{
+			WorktreePath: t.TempDir(),
+			Branch:       "feature/login",
+			Database:     config.DatabaseConfig{NamingStrategy: "hash", KeepOnRemove: true},
+		}
+		ctx.SetDbSuffix("cool_engine")
+
+		step := NewDbDestroyStepWithFactory(config.StepConfig{Type: "mysql"}, MockClientFactory(mockClient))
+		require.NoError(t, step.Run(ctx, types.StepOptions{}))
+
+		assert.Equal(t, []string{"app_cool_engine"}, mockClient.GetDropCalls())
+	})
+}
+
+func TestWorktreeUserName(t *testing.T) {
+	assert.Equal(t, "arbor_cool_engine", worktreeUserName("cool_engine"))
+	assert.Len(t, worktreeUserName("extraordinarily_magnificent_thunderbolt"), 32)
+
+	first := worktreeUserName("feature_payments_refunds_api")
+	second := worktreeUserName("feature_payments_refunds_ui")
+	assert.LessOrEqual(t, len(first), 32)
+	assert.LessOrEqual(t, len(second), 32)
+	assert.NotEqual(t, first, second, "suffixes sharing a long prefix get their own users")
+}
+
+func TestDbDestroyStep_DropsUserAfterCreateUserIsDisabled(t *testing.T) {
+	mockClient := NewMockDatabaseClient()
+	mockClient.AddDatabase("app_cool_engine")
+	require.NoError(t, mockClient.CreateUser("arbor_cool_engine", "secret"))
+
+	tmpDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, ".env"), []byte("DB_USERNAME=arbor_cool_engine\n"), 0644))
+
+	ctx := &types.ScaffoldContext{WorktreePath: tmpDir}
+	ctx.SetDbSuffix("cool_engine")
+
+	step := NewDbDestroyStepWithFactory(config.StepConfig{Type: "mysql"}, MockClientFactory(mockClient))
+	require.NoError(t, step.Run(ctx, types.StepOptions{}))
+
+	_, ok := mockClient.GetUser("arbor_cool_engine")
+	assert.False(t, ok)
+}
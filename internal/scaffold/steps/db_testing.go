@@ -0,0 +1,97 @@
+package steps
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/michaeldyrynda/arbor/internal/logging"
+	"github.com/michaeldyrynda/arbor/internal/scaffold/types"
+	"github.com/michaeldyrynda/arbor/internal/scaffold/words"
+	"github.com/michaeldyrynda/arbor/internal/utils"
+)
+
+// TestingEnvFile is the env file db.create --test points at the test
+// database, which Laravel loads in place of .env when running tests
+const TestingEnvFile = ".env.testing"
+
+// wantsTestDatabase reports whether --test asks for a test database
+// alongside the worktree database
+func (s *DbCreateStep) wantsTestDatabase() bool {
+	for _, arg := range s.args {
+		if arg == "--test" {
+			return true
+		}
+	}
+	return false
+}
+
+// testDatabaseName names the test database for prefix as
+// {prefix}_test_{suffix}, so it ends in the worktree suffix and db.destroy
+// and db gc find it with the worktree database
+func testDatabaseName(ctx *types.ScaffoldContext, prefix string) string {
+	return words.JoinDatabaseName(prefix+"_test", ctx.GetDbSuffix(), 0)
+}
+
+// testSqlitePath returns the test database file beside path, e.g.
+// database/database_test.sqlite
+func testSqlitePath(path string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "_test" + ext
+}
+
+// createTestDatabase creates the worktree's test database, reusing it when it
+// already exists, and points .env.testing at it
+func (s *DbCreateStep) createTestDatabase(ctx *types.ScaffoldContext, client DatabaseClient, target *DatabaseTarget, opts types.StepOptions) error {
+	testName := testDatabaseName(ctx, s.getPrefixOrSiteName(ctx))
+
+	if err := target.Create(testName); err != nil {
+		if !IsDatabaseExistsError(err) {
+			return fmt.Errorf("failed to create test %s: %w", target.Kind, err)
+		}
+		logging.Verbosef("  Test %s '%s' already exists, reusing it.", target.Kind, testName)
+	} else {
+		logging.Verbosef("  Created test %s '%s'.", target.Kind, testName)
+	}
+
+	if ctx.Database.CreateUser {
+		if users, ok := client.(DatabaseUserClient); ok {
+			if err := target.grant(users, worktreeUserName(ctx.GetDbSuffix()), testName); err != nil {
+				return err
+			}
+		}
+	}
+
+	keys := []string{"DB_DATABASE"}
+	if target.Kind == databaseModeSchema {
+		keys = []string{"DB_SCHEMA", "DB_SEARCH_PATH"}
+	}
+	return writeTestingEnv(ctx, testName, keys...)
+}
+
+// writeTestingEnv sets keys to testName in .env.testing, creating it from the
+// worktree .env when missing so tests keep the rest of the app's settings.
+// With several db.create --test steps the first test database is kept.
+func writeTestingEnv(ctx *types.ScaffoldContext, testName string, keys ...string) error {
+	path := filepath.Join(ctx.WorktreePath, TestingEnvFile)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		if data, err := os.ReadFile(filepath.Join(ctx.WorktreePath, ".env")); err == nil {
+			if err := os.WriteFile(path, data, 0600); err != nil {
+				return fmt.Errorf("creating %s: %w", TestingEnvFile, err)
+			}
+		}
+	}
+
+	env := utils.ReadEnvFile(ctx.WorktreePath, TestingEnvFile)
+	if current := utils.UnquoteEnvValue(env[keys[0]]); current != testName && strings.HasSuffix(current, "_test_"+ctx.GetDbSuffix()) {
+		return nil
+	}
+
+	for _, key := range keys {
+		if err := utils.WriteEnvValue(ctx.WorktreePath, TestingEnvFile, key, testName); err != nil {
+			return fmt.Errorf("writing %s to %s: %w", key, TestingEnvFile, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package steps
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/michaeldyrynda/arbor/internal/config"
+	"github.com/michaeldyrynda/arbor/internal/scaffold/types"
+	"github.com/michaeldyrynda/arbor/internal/utils"
+)
+
+func TestDbCreateStep_TestDatabase(t *testing.T) {
+	newCtx := func(t *testing.T, env string) *types.ScaffoldContext {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, ".env"), []byte(env), 0644))
+		ctx := &types.ScaffoldContext{WorktreePath: dir, SiteName: "app"}
+		ctx.SetDbSuffix("cool_engine")
+		return ctx
+	}
+	withTest := config.StepConfig{Args: []string{"--test"}}
+
+	t.Run("creates the test database and writes it to .env.testing", func(t *testing.T) {
+		ctx := newCtx(t, "APP_KEY=base64:key\nDB_CONNECTION=mysql\nDB_DATABASE=app_cool_engine\n")
+		mockClient := NewMockDatabaseClient()
+
+		require.NoError(t, NewDbCreateStepWithFactory(withTest, 8, MockClientFactory(mockClient)).Run(ctx, types.StepOptions{}))
+
+		assert.True(t, mockClient.HasDatabase("app_cool_engine"))
+		assert.True(t, mockClient.HasDatabase("app_test_cool_engine"))
+
+		testingEnv := utils.ReadEnvFile(ctx.WorktreePath, TestingEnvFile)
+		assert.Equal(t, "app_test_cool_engine", testingEnv["DB_DATABASE"])
+		assert.Equal(t, "base64:key", testingEnv["APP_KEY"], ".env.testing starts from the worktree .env")
+		assert.Equal(t, "app_cool_engine", utils.ReadEnvFile(ctx.WorktreePath, ".env")["DB_DATABASE"])
+	})
+
+	t.Run("reuses an existing test database and keeps the first", func(t *testing.T) {
+		ctx := newCtx(t, "DB_CONNECTION=mysql\n")
+		require.NoError(t, os.WriteFile(filepath.Join(ctx.WorktreePath, TestingEnvFile), []byte("DB_DATABASE=testing\n"), 0644))
+		mockClient := NewMockDatabaseClient()
+		mockClient.AddDatabase("app_cool_engine")
+		mockClient.AddDatabase("app_test_cool_engine")
+
+		require.NoError(t, NewDbCreateStepWithFactory(withTest, 8, MockClientFactory(mockClient)).Run(ctx, types.StepOptions{}))
+		quotes := config.StepConfig{Args: []string{"--prefix", "quotes", "--test"}}
+		require.NoError(t, NewDbCreateStepWithFactory(quotes, 8, MockClientFactory(mockClient)).Run(ctx, types.StepOptions{}))
+
+		assert.True(t, mockClient.HasDatabase("quotes_test_cool_engine"))
+		assert.Equal(t, "app_test_cool_engine", utils.ReadEnvFile(ctx.WorktreePath, TestingEnvFile)["DB_DATABASE"])
+	})
+
+	t.Run("grants the worktree user access", func(t *testing.T) {
+		ctx := newCtx(t, "DB_CONNECTION=mysql\n")
+		ctx.Database.CreateUser = true
+		mockClient := NewMockDatabaseClient()
+
+		require.NoError(t, NewDbCreateStepWithFactory(withTest, 8, MockClientFactory(mockClient)).Run(ctx, types.StepOptions{}))
+
+		assert.Equal(t, []string{"app_cool_engine", "app_test_cool_engine"}, mockClient.GetGrants("arbor_cool_engine"))
+	})
+
+	t.Run("writes schemas in schema mode", func(t *testing.T) {
+		ctx := newCtx(t, "DB_CONNECTION=pgsql\nDB_DATABASE=app\n")
+		ctx.Database.Mode = "schema"
+		mockClient := NewMockDatabaseClient()
+
+		require.NoError(t, NewDbCreateStepWithFactory(withTest, 8, MockClientFactory(mockClient)).Run(ctx, types.StepOptions{}))
+
+		assert.True(t, mockClient.HasSchema("app_test_cool_engine"))
+		testingEnv := utils.ReadEnvFile(ctx.WorktreePath, TestingEnvFile)
+		assert.Equal(t, "app_test_cool_engine", testingEnv["DB_SCHEMA"])
+		assert.Equal(t, "app", testingEnv["DB_DATABASE"])
+	})
+
+	t.Run("creates a sqlite file beside the database", func(t *testing.T) {
+		ctx := newCtx(t, "DB_CONNECTION=sqlite\n")
+
+		require.NoError(t, NewDbCreateStep(withTest, 8).Run(ctx, types.StepOptions{}))
+
+		assert.FileExists(t, filepath.Join(ctx.WorktreePath, "database", "database_test.sqlite"))
+		assert.Equal(t, "database/database_test.sqlite", utils.ReadEnvFile(ctx.WorktreePath, TestingEnvFile)["DB_DATABASE"])
+	})
+
+	t.Run("plans the test database", func(t *testing.T) {
+		ctx := newCtx(t, "DB_CONNECTION=mysql\n")
+
+		plan, err := NewDbCreateStep(withTest, 8).Plan(ctx, types.StepOptions{})
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"app_cool_engine", "app_test_cool_engine"}, plan.Databases)
+		assert.Equal(t, []string{TestingEnvFile}, plan.Files)
+	})
+
+	t.Run("is dropped with the worktree databases", func(t *testing.T) {
+		ctx := newCtx(t, "DB_CONNECTION=mysql\n")
+		mockClient := NewMockDatabaseClient()
+		mockClient.AddDatabase("app_cool_engine")
+		mockClient.AddDatabase("app_test_cool_engine")
+
+		require.NoError(t, NewDbDestroyStepWithFactory(config.StepConfig{}, MockClientFactory(mockClient)).Run(ctx, types.StepOptions{}))
+
+		assert.Equal(t, 0, mockClient.DatabaseCount())
+	})
+}
+
+func TestTestSqlitePath(t *testing.T) {
+	assert.Equal(t, "database/database_test.sqlite", testSqlitePath("database/database.sqlite"))
+	assert.Equal(t, "db_test", testSqlitePath("db"))
+}
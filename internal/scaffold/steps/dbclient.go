@@ -2,11 +2,26 @@ package steps
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
+	"os/exec"
 	"strings"
 
-	_ "github.com/go-sql-driver/mysql"
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
 	_ "github.com/jackc/pgx/v5/stdlib"
+	mssql "github.com/microsoft/go-mssqldb"
+)
+
+const (
+	// mysqlErrDatabaseExists is ER_DB_CREATE_EXISTS
+	mysqlErrDatabaseExists = 1007
+	// pgErrDuplicateDatabase is the duplicate_database SQLSTATE
+	pgErrDuplicateDatabase = "42P04"
+	// pgErrDuplicateSchema is the duplicate_schema SQLSTATE
+	pgErrDuplicateSchema = "42P06"
+	// mssqlErrDatabaseExists is the SQL Server "database already exists" error
+	mssqlErrDatabaseExists = 1801
 )
 
 // DatabaseClient abstracts database operations for testability
@@ -18,6 +33,23 @@ type DatabaseClient interface {
 	Close() error
 }
 
+// DatabaseUserClient is implemented by clients that can manage the
+// per-worktree users created when db.create_user is enabled
+type DatabaseUserClient interface {
+	CreateUser(name, password string) error
+	GrantDatabase(user, database string) error
+	DropUser(name string) error
+}
+
+// SchemaClient is implemented by clients that can manage schemas inside the
+// connected database, used when db.mode is schema
+type SchemaClient interface {
+	CreateSchema(name string) error
+	DropSchema(name string) error
+	ListSchemas(pattern string) ([]string, error)
+	GrantSchema(user, schema string) error
+}
+
 // DatabaseClientFactory creates DatabaseClient instances
 type DatabaseClientFactory func(engine string, opts DatabaseOptions) (DatabaseClient, error)
 
@@ -27,20 +59,74 @@ type DatabaseOptions struct {
 	Port     string
 	Username string
 	Password string
+	// SSLMode is one of disable, prefer, require, verify-ca or verify-full
+	SSLMode string
+	// SSLCA is the path to a CA certificate used to verify the server
+	SSLCA string
+	// Socket is a unix socket path used instead of Host and Port
+	Socket string
+	// Database is the database to connect to, defaulting to the server's
+	// maintenance database. Only set when worktrees use schemas.
+	Database string
 }
 
-// DefaultDatabaseClientFactory creates real database clients
+// DefaultDatabaseClientFactory creates native database clients, falling back
+// to the mysql or psql command line tools when the driver cannot connect but
+// the tool can
 func DefaultDatabaseClientFactory(engine string, opts DatabaseOptions) (DatabaseClient, error) {
+	return newDatabaseClientWithFallback(engine, opts, NativeDatabaseClientFactory, cliFallbackFactory)
+}
+
+// NativeDatabaseClientFactory creates database clients backed by Go drivers
+func NativeDatabaseClientFactory(engine string, opts DatabaseOptions) (DatabaseClient, error) {
 	switch engine {
 	case "mysql":
 		return NewMySQLClient(opts)
 	case "pgsql":
 		return NewPostgreSQLClient(opts)
+	case "sqlsrv":
+		return NewMSSQLClient(opts)
 	default:
 		return nil, fmt.Errorf("unsupported database engine: %s", engine)
 	}
 }
 
+func cliFallbackFactory(engine string, opts DatabaseOptions) (DatabaseClient, error) {
+	if engine == "sqlsrv" {
+		return nil, fmt.Errorf("no command line fallback for %s", engine)
+	}
+	if _, err := exec.LookPath(cliBinary(engine)); err != nil {
+		return nil, err
+	}
+	return NewCLIDatabaseClient(engine, opts)
+}
+
+// newDatabaseClientWithFallback returns the native client when it can connect.
+// Otherwise the fallback client is used if it can connect, and the native
+// client is returned as-is so callers see the original connection error.
+func newDatabaseClientWithFallback(engine string, opts DatabaseOptions, native, fallback DatabaseClientFactory) (DatabaseClient, error) {
+	client, err := native(engine, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if client.Ping() == nil {
+		return client, nil
+	}
+
+	alt, err := fallback(engine, opts)
+	if err != nil {
+		return client, nil
+	}
+	if alt.Ping() != nil {
+		alt.Close()
+		return client, nil
+	}
+
+	client.Close()
+	return alt, nil
+}
+
 // MySQLClient implements DatabaseClient for MySQL
 type MySQLClient struct {
 	db   *sql.DB
@@ -59,11 +145,15 @@ func NewMySQLClient(opts DatabaseOptions) (*MySQLClient, error) {
 		opts.Username = "root"
 	}
 
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/", opts.Username, opts.Password, opts.Host, opts.Port)
-	db, err := sql.Open("mysql", dsn)
+	cfg, err := mysqlConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+	connector, err := mysql.NewConnector(cfg)
 	if err != nil {
 		return nil, fmt.Errorf("opening mysql connection: %w", err)
 	}
+	db := sql.OpenDB(connector)
 
 	return &MySQLClient{db: db, opts: opts}, nil
 }
@@ -77,16 +167,20 @@ func (c *MySQLClient) Close() error {
 }
 
 func (c *MySQLClient) CreateDatabase(name string) error {
-	query := fmt.Sprintf("CREATE DATABASE IF NOT EXISTS `%s`", name)
+	query := createDatabaseSQL("mysql", name)
 	_, err := c.db.Exec(query)
 	if err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlErrDatabaseExists {
+			return &DatabaseExistsError{Name: name}
+		}
 		return fmt.Errorf("creating database %s: %w", name, err)
 	}
 	return nil
 }
 
 func (c *MySQLClient) DropDatabase(name string) error {
-	query := fmt.Sprintf("DROP DATABASE IF EXISTS `%s`", name)
+	query := dropDatabaseSQL("mysql", name)
 	_, err := c.db.Exec(query)
 	if err != nil {
 		return fmt.Errorf("dropping database %s: %w", name, err)
@@ -113,6 +207,30 @@ func (c *MySQLClient) ListDatabases(pattern string) ([]string, error) {
 	return databases, rows.Err()
 }
 
+func (c *MySQLClient) CreateUser(name, password string) error {
+	query := createUserSQL("mysql", name, password)
+	if _, err := c.db.Exec(query); err != nil {
+		return fmt.Errorf("creating user %s: %w", name, err)
+	}
+	return nil
+}
+
+func (c *MySQLClient) GrantDatabase(user, database string) error {
+	query := grantDatabaseSQL("mysql", user, database)
+	if _, err := c.db.Exec(query); err != nil {
+		return fmt.Errorf("granting %s to %s: %w", database, user, err)
+	}
+	return nil
+}
+
+func (c *MySQLClient) DropUser(name string) error {
+	query := dropUserSQL("mysql", name)
+	if _, err := c.db.Exec(query); err != nil {
+		return fmt.Errorf("dropping user %s: %w", name, err)
+	}
+	return nil
+}
+
 // PostgreSQLClient implements DatabaseClient for PostgreSQL
 type PostgreSQLClient struct {
 	db   *sql.DB
@@ -131,8 +249,10 @@ func NewPostgreSQLClient(opts DatabaseOptions) (*PostgreSQLClient, error) {
 		opts.Username = "postgres"
 	}
 
-	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=postgres sslmode=disable",
-		opts.Host, opts.Port, opts.Username, opts.Password)
+	dsn, err := postgresDSN(opts)
+	if err != nil {
+		return nil, err
+	}
 	db, err := sql.Open("pgx", dsn)
 	if err != nil {
 		return nil, fmt.Errorf("opening postgres connection: %w", err)
@@ -159,9 +279,13 @@ func (c *PostgreSQLClient) CreateDatabase(name string) error {
 		return &DatabaseExistsError{Name: name}
 	}
 
-	query := fmt.Sprintf("CREATE DATABASE \"%s\"", name)
+	query := createDatabaseSQL("pgsql", name)
 	_, err = c.db.Exec(query)
 	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgErrDuplicateDatabase {
+			return &DatabaseExistsError{Name: name}
+		}
 		if strings.Contains(err.Error(), "already exists") {
 			return &DatabaseExistsError{Name: name}
 		}
@@ -171,7 +295,7 @@ func (c *PostgreSQLClient) CreateDatabase(name string) error {
 }
 
 func (c *PostgreSQLClient) DropDatabase(name string) error {
-	query := fmt.Sprintf("DROP DATABASE IF EXISTS \"%s\"", name)
+	query := dropDatabaseSQL("pgsql", name)
 	_, err := c.db.Exec(query)
 	if err != nil {
 		return fmt.Errorf("dropping database %s: %w", name, err)
@@ -198,6 +322,193 @@ func (c *PostgreSQLClient) ListDatabases(pattern string) ([]string, error) {
 	return databases, rows.Err()
 }
 
+func (c *PostgreSQLClient) CreateUser(name, password string) error {
+	var exists bool
+	err := c.db.QueryRow("SELECT EXISTS(SELECT 1 FROM pg_roles WHERE rolname = $1)", name).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("checking user existence: %w", err)
+	}
+	if exists {
+		return nil
+	}
+
+	query := createUserSQL("pgsql", name, password)
+	if _, err := c.db.Exec(query); err != nil {
+		return fmt.Errorf("creating user %s: %w", name, err)
+	}
+	return nil
+}
+
+func (c *PostgreSQLClient) GrantDatabase(user, database string) error {
+	query := grantDatabaseSQL("pgsql", user, database)
+	if _, err := c.db.Exec(query); err != nil {
+		return fmt.Errorf("granting %s to %s: %w", database, user, err)
+	}
+	return nil
+}
+
+func (c *PostgreSQLClient) DropUser(name string) error {
+	query := dropUserSQL("pgsql", name)
+	if _, err := c.db.Exec(query); err != nil {
+		return fmt.Errorf("dropping user %s: %w", name, err)
+	}
+	return nil
+}
+
+func (c *PostgreSQLClient) CreateSchema(name string) error {
+	var exists bool
+	err := c.db.QueryRow("SELECT EXISTS(SELECT 1 FROM pg_namespace WHERE nspname = $1)", name).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("checking schema existence: %w", err)
+	}
+	if exists {
+		return &DatabaseExistsError{Name: name}
+	}
+
+	if _, err := c.db.Exec(createSchemaSQL(name)); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgErrDuplicateSchema {
+			return &DatabaseExistsError{Name: name}
+		}
+		return fmt.Errorf("creating schema %s: %w", name, err)
+	}
+	return nil
+}
+
+func (c *PostgreSQLClient) DropSchema(name string) error {
+	if _, err := c.db.Exec(dropSchemaSQL(name)); err != nil {
+		return fmt.Errorf("dropping schema %s: %w", name, err)
+	}
+	return nil
+}
+
+func (c *PostgreSQLClient) ListSchemas(pattern string) ([]string, error) {
+	rows, err := c.db.Query("SELECT nspname FROM pg_namespace WHERE nspname LIKE $1", pattern)
+	if err != nil {
+		return nil, fmt.Errorf("listing schemas: %w", err)
+	}
+	defer rows.Close()
+
+	var schemas []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("scanning schema name: %w", err)
+		}
+		schemas = append(schemas, name)
+	}
+	return schemas, rows.Err()
+}
+
+func (c *PostgreSQLClient) GrantSchema(user, schema string) error {
+	if _, err := c.db.Exec(grantSchemaSQL(user, schema)); err != nil {
+		return fmt.Errorf("granting %s to %s: %w", schema, user, err)
+	}
+	return nil
+}
+
+// MSSQLClient implements DatabaseClient for SQL Server
+type MSSQLClient struct {
+	db   *sql.DB
+	opts DatabaseOptions
+}
+
+// NewMSSQLClient creates a new SQL Server client
+func NewMSSQLClient(opts DatabaseOptions) (*MSSQLClient, error) {
+	if opts.Host == "" {
+		opts.Host = "127.0.0.1"
+	}
+	if opts.Port == "" {
+		opts.Port = "1433"
+	}
+	if opts.Username == "" {
+		opts.Username = "sa"
+	}
+
+	dsn, err := mssqlDSN(opts)
+	if err != nil {
+		return nil, err
+	}
+	db, err := sql.Open("sqlserver", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("opening sqlserver connection: %w", err)
+	}
+
+	return &MSSQLClient{db: db, opts: opts}, nil
+}
+
+func (c *MSSQLClient) Ping() error {
+	return c.db.Ping()
+}
+
+func (c *MSSQLClient) Close() error {
+	return c.db.Close()
+}
+
+func (c *MSSQLClient) CreateDatabase(name string) error {
+	query := createDatabaseSQL("sqlsrv", name)
+	_, err := c.db.Exec(query)
+	if err != nil {
+		var mssqlErr mssql.Error
+		if errors.As(err, &mssqlErr) && mssqlErr.Number == mssqlErrDatabaseExists {
+			return &DatabaseExistsError{Name: name}
+		}
+		return fmt.Errorf("creating database %s: %w", name, err)
+	}
+	return nil
+}
+
+func (c *MSSQLClient) DropDatabase(name string) error {
+	query := dropDatabaseSQL("sqlsrv", name)
+	_, err := c.db.Exec(query)
+	if err != nil {
+		return fmt.Errorf("dropping database %s: %w", name, err)
+	}
+	return nil
+}
+
+func (c *MSSQLClient) ListDatabases(pattern string) ([]string, error) {
+	query := "SELECT name FROM sys.databases WHERE name LIKE @p1"
+	rows, err := c.db.Query(query, mssqlLikePattern(pattern))
+	if err != nil {
+		return nil, fmt.Errorf("listing databases: %w", err)
+	}
+	defer rows.Close()
+
+	var databases []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("scanning database name: %w", err)
+		}
+		databases = append(databases, name)
+	}
+	return databases, rows.Err()
+}
+
+// quoteMSSQLIdentifier wraps name in brackets, escaping closing brackets
+func quoteMSSQLIdentifier(name string) string {
+	return "[" + strings.ReplaceAll(name, "]", "]]") + "]"
+}
+
+// mssqlLikePattern escapes the characters SQL Server treats as LIKE wildcards
+// beyond those shared with MySQL and PostgreSQL. Bracket expressions are a
+// SQL Server extension, so a literal [ in a database name must be escaped.
+func mssqlLikePattern(pattern string) string {
+	return strings.ReplaceAll(pattern, "[", "[[]")
+}
+
+// mysqlAccount formats name as a MySQL account reachable from any host, so
+// worktree users also work against databases running in containers
+func mysqlAccount(name string) string {
+	return quoteSQLString(name) + "@'%'"
+}
+
+// quoteSQLString quotes s as a SQL string literal
+func quoteSQLString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
 // DatabaseExistsError indicates a database already exists
 type DatabaseExistsError struct {
 	Name string
@@ -212,7 +523,8 @@ func IsDatabaseExistsError(err error) bool {
 	if err == nil {
 		return false
 	}
-	if _, ok := err.(*DatabaseExistsError); ok {
+	var existsErr *DatabaseExistsError
+	if errors.As(err, &existsErr) {
 		return true
 	}
 	errStr := strings.ToLower(err.Error())
@@ -0,0 +1,261 @@
+package steps
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/michaeldyrynda/arbor/internal/logging"
+)
+
+// commandRunner runs a client binary with extra environment variables,
+// returning its stdout or an error including stderr
+type commandRunner func(name string, args []string, env []string) (string, error)
+
+func runCommand(name string, args []string, env []string) (string, error) {
+	cmd := exec.Command(name, args...)
+	cmd.Env = append(os.Environ(), env...)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := logging.Run(cmd); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%s: %s", name, msg)
+		}
+		return "", fmt.Errorf("%s: %w", name, err)
+	}
+
+	return stdout.String(), nil
+}
+
+// CLIDatabaseClient implements DatabaseClient by shelling out to the mysql or
+// psql binaries. It is used as a fallback when the native drivers cannot
+// connect, e.g. when credentials only live in ~/.my.cnf or ~/.pgpass.
+type CLIDatabaseClient struct {
+	engine string
+	opts   DatabaseOptions
+	run    commandRunner
+}
+
+// NewCLIDatabaseClient creates a client for engine using its command line tool
+func NewCLIDatabaseClient(engine string, opts DatabaseOptions) (*CLIDatabaseClient, error) {
+	if engine != "mysql" && engine != "pgsql" {
+		return nil, fmt.Errorf("unsupported database engine: %s", engine)
+	}
+	return &CLIDatabaseClient{engine: engine, opts: opts, run: runCommand}, nil
+}
+
+// mysqlCLISSLModes maps SSL modes to mysql --ssl-mode values
+var mysqlCLISSLModes = map[string]string{
+	sslModeDisable:    "DISABLED",
+	sslModePrefer:     "PREFERRED",
+	sslModeRequire:    "REQUIRED",
+	sslModeVerifyCA:   "VERIFY_CA",
+	sslModeVerifyFull: "VERIFY_IDENTITY",
+}
+
+// cliBinary returns the client binary for engine
+func cliBinary(engine string) string {
+	if engine == "pgsql" {
+		return "psql"
+	}
+	return "mysql"
+}
+
+func (c *CLIDatabaseClient) exec(query string) (string, error) {
+	var args, env []string
+
+	if c.engine == "pgsql" {
+		database := c.opts.Database
+		if database == "" {
+			database = "postgres"
+		}
+		args = []string{"-d", database, "-tA", "-v", "ON_ERROR_STOP=1", "-c", query}
+		if c.opts.Socket != "" {
+			args = append(args, "-h", postgresSocketDir(c.opts.Socket))
+		} else if c.opts.Host != "" {
+			args = append(args, "-h", c.opts.Host)
+		}
+		if c.opts.Port != "" {
+			args = append(args, "-p", c.opts.Port)
+		}
+		if c.opts.Username != "" {
+			args = append(args, "-U", c.opts.Username)
+		}
+		if c.opts.Password != "" {
+			env = append(env, "PGPASSWORD="+c.opts.Password)
+		}
+		if c.opts.SSLMode != "" || c.opts.SSLCA != "" {
+			if mode, err := normalizeSSLMode(c.opts.SSLMode, c.opts.SSLCA); err == nil {
+				env = append(env, "PGSSLMODE="+mode)
+			}
+		}
+		if c.opts.SSLCA != "" {
+			env = append(env, "PGSSLROOTCERT="+c.opts.SSLCA)
+		}
+	} else {
+		args = []string{"--batch", "--skip-column-names", "-e", query}
+		if c.opts.Socket != "" {
+			args = append(args, "--socket", c.opts.Socket)
+		} else if c.opts.Host != "" {
+			args = append(args, "-h", c.opts.Host)
+		}
+		if c.opts.Port != "" {
+			args = append(args, "-P", c.opts.Port)
+		}
+		if c.opts.SSLMode != "" || c.opts.SSLCA != "" {
+			if mode, err := normalizeSSLMode(c.opts.SSLMode, c.opts.SSLCA); err == nil {
+				args = append(args, "--ssl-mode="+mysqlCLISSLModes[mode])
+			}
+		}
+		if c.opts.SSLCA != "" {
+			args = append(args, "--ssl-ca", c.opts.SSLCA)
+		}
+		if c.opts.Username != "" {
+			args = append(args, "-u", c.opts.Username)
+		}
+		if c.opts.Password != "" {
+			env = append(env, "MYSQL_PWD="+c.opts.Password)
+		}
+	}
+
+	return c.run(cliBinary(c.engine), args, env)
+}
+
+func (c *CLIDatabaseClient) Ping() error {
+	_, err := c.exec("SELECT 1")
+	return err
+}
+
+func (c *CLIDatabaseClient) Close() error {
+	return nil
+}
+
+func (c *CLIDatabaseClient) CreateDatabase(name string) error {
+	if _, err := c.exec(createDatabaseSQL(c.engine, name)); err != nil {
+		if IsDatabaseExistsError(err) {
+			return &DatabaseExistsError{Name: name}
+		}
+		return fmt.Errorf("creating database %s: %w", name, err)
+	}
+	return nil
+}
+
+func (c *CLIDatabaseClient) DropDatabase(name string) error {
+	if _, err := c.exec(dropDatabaseSQL(c.engine, name)); err != nil {
+		return fmt.Errorf("dropping database %s: %w", name, err)
+	}
+	return nil
+}
+
+func (c *CLIDatabaseClient) ListDatabases(pattern string) ([]string, error) {
+	pattern = strings.ReplaceAll(pattern, "'", "''")
+	query := fmt.Sprintf("SHOW DATABASES LIKE '%s'", pattern)
+	if c.engine == "pgsql" {
+		query = fmt.Sprintf("SELECT datname FROM pg_database WHERE datname LIKE '%s' AND datistemplate = false", pattern)
+	}
+
+	output, err := c.exec(query)
+	if err != nil {
+		return nil, fmt.Errorf("listing databases: %w", err)
+	}
+
+	var databases []string
+	for _, line := range strings.Split(output, "\n") {
+		if name := strings.TrimSpace(line); name != "" {
+			databases = append(databases, name)
+		}
+	}
+	return databases, nil
+}
+
+func (c *CLIDatabaseClient) CreateUser(name, password string) error {
+	query := createUserSQL(c.engine, name, password)
+	if c.engine == "pgsql" {
+		query = fmt.Sprintf("DO $$ BEGIN IF NOT EXISTS (SELECT 1 FROM pg_roles WHERE rolname = %s) THEN %s; END IF; END $$",
+			quoteSQLString(name), query)
+	}
+
+	if _, err := c.exec(query); err != nil {
+		return fmt.Errorf("creating user %s: %w", name, err)
+	}
+	return nil
+}
+
+func (c *CLIDatabaseClient) GrantDatabase(user, database string) error {
+	if _, err := c.exec(grantDatabaseSQL(c.engine, user, database)); err != nil {
+		return fmt.Errorf("granting %s to %s: %w", database, user, err)
+	}
+	return nil
+}
+
+func (c *CLIDatabaseClient) DropUser(name string) error {
+	if _, err := c.exec(dropUserSQL(c.engine, name)); err != nil {
+		return fmt.Errorf("dropping user %s: %w", name, err)
+	}
+	return nil
+}
+
+// errSchemasUnsupported is returned by the schema methods for MySQL
+var errSchemasUnsupported = errors.New("schemas are only supported for PostgreSQL")
+
+func (c *CLIDatabaseClient) CreateSchema(name string) error {
+	if c.engine != "pgsql" {
+		return errSchemasUnsupported
+	}
+
+	if _, err := c.exec(createSchemaSQL(name)); err != nil {
+		if IsDatabaseExistsError(err) {
+			return &DatabaseExistsError{Name: name}
+		}
+		return fmt.Errorf("creating schema %s: %w", name, err)
+	}
+	return nil
+}
+
+func (c *CLIDatabaseClient) DropSchema(name string) error {
+	if c.engine != "pgsql" {
+		return errSchemasUnsupported
+	}
+
+	if _, err := c.exec(dropSchemaSQL(name)); err != nil {
+		return fmt.Errorf("dropping schema %s: %w", name, err)
+	}
+	return nil
+}
+
+func (c *CLIDatabaseClient) ListSchemas(pattern string) ([]string, error) {
+	if c.engine != "pgsql" {
+		return nil, errSchemasUnsupported
+	}
+
+	pattern = strings.ReplaceAll(pattern, "'", "''")
+	output, err := c.exec(fmt.Sprintf("SELECT nspname FROM pg_namespace WHERE nspname LIKE '%s'", pattern))
+	if err != nil {
+		return nil, fmt.Errorf("listing schemas: %w", err)
+	}
+
+	var schemas []string
+	for _, line := range strings.Split(output, "\n") {
+		if name := strings.TrimSpace(line); name != "" {
+			schemas = append(schemas, name)
+		}
+	}
+	return schemas, nil
+}
+
+func (c *CLIDatabaseClient) GrantSchema(user, schema string) error {
+	if c.engine != "pgsql" {
+		return errSchemasUnsupported
+	}
+
+	if _, err := c.exec(grantSchemaSQL(user, schema)); err != nil {
+		return fmt.Errorf("granting %s to %s: %w", schema, user, err)
+	}
+	return nil
+}
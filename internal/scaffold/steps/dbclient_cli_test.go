@@ -0,0 +1,159 @@
+package steps
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordedCommand struct {
+	name string
+	args []string
+	env  []string
+}
+
+func newRecordingCLIClient(t *testing.T, engine string, opts DatabaseOptions, output string, err error) (*CLIDatabaseClient, *[]recordedCommand) {
+	t.Helper()
+	client, newErr := NewCLIDatabaseClient(engine, opts)
+	require.NoError(t, newErr)
+
+	var calls []recordedCommand
+	client.run = func(name string, args []string, env []string) (string, error) {
+		calls = append(calls, recordedCommand{name: name, args: args, env: env})
+		return output, err
+	}
+	return client, &calls
+}
+
+func TestCLIDatabaseClient(t *testing.T) {
+	t.Run("mysql passes connection flags and password via env", func(t *testing.T) {
+		client, calls := newRecordingCLIClient(t, "mysql", DatabaseOptions{Host: "db.test", Port: "3307", Username: "app", Password: "secret"}, "", nil)
+
+		require.NoError(t, client.CreateDatabase("app_cool_name"))
+
+		require.Len(t, *calls, 1)
+		call := (*calls)[0]
+		assert.Equal(t, "mysql", call.name)
+		assert.Contains(t, call.args, "CREATE DATABASE `app_cool_name`")
+		assert.Contains(t, strings.Join(call.args, " "), "-h db.test -P 3307 -u app")
+		assert.Equal(t, []string{"MYSQL_PWD=secret"}, call.env)
+	})
+
+	t.Run("psql passes connection flags and password via env", func(t *testing.T) {
+		client, calls := newRecordingCLIClient(t, "pgsql", DatabaseOptions{Host: "pg.test", Port: "5433", Username: "postgres", Password: "secret"}, "", nil)
+
+		require.NoError(t, client.DropDatabase("app_cool_name"))
+
+		call := (*calls)[0]
+		assert.Equal(t, "psql", call.name)
+		assert.Contains(t, call.args, `DROP DATABASE IF EXISTS "app_cool_name"`)
+		assert.Contains(t, strings.Join(call.args, " "), "-h pg.test -p 5433 -U postgres")
+		assert.Equal(t, []string{"PGPASSWORD=secret"}, call.env)
+	})
+
+	t.Run("passes socket and ssl options", func(t *testing.T) {
+		opts := DatabaseOptions{Username: "root", Socket: "/tmp/mysql.sock", SSLMode: "verify-full", SSLCA: "/etc/ssl/ca.pem"}
+		client, calls := newRecordingCLIClient(t, "mysql", opts, "", nil)
+
+		require.NoError(t, client.Ping())
+
+		args := strings.Join((*calls)[0].args, " ")
+		assert.Contains(t, args, "--socket /tmp/mysql.sock")
+		assert.Contains(t, args, "--ssl-mode=VERIFY_IDENTITY --ssl-ca /etc/ssl/ca.pem")
+		assert.NotContains(t, args, "-h ")
+
+		client, calls = newRecordingCLIClient(t, "pgsql", DatabaseOptions{Socket: "/var/run/postgresql/.s.PGSQL.5432", SSLMode: "require"}, "", nil)
+
+		require.NoError(t, client.Ping())
+
+		assert.Contains(t, strings.Join((*calls)[0].args, " "), "-h /var/run/postgresql")
+		assert.Equal(t, []string{"PGSSLMODE=require"}, (*calls)[0].env)
+	})
+
+	t.Run("maps mysql 1007 to DatabaseExistsError", func(t *testing.T) {
+		client, _ := newRecordingCLIClient(t, "mysql", DatabaseOptions{}, "", errors.New("mysql: ERROR 1007 (HY000): Can't create database 'app'; database exists"))
+
+		err := client.CreateDatabase("app")
+
+		var existsErr *DatabaseExistsError
+		assert.ErrorAs(t, err, &existsErr)
+	})
+
+	t.Run("maps postgres already exists to DatabaseExistsError", func(t *testing.T) {
+		client, _ := newRecordingCLIClient(t, "pgsql", DatabaseOptions{}, "", errors.New(`psql: ERROR:  database "app" already exists`))
+
+		err := client.CreateDatabase("app")
+
+		var existsErr *DatabaseExistsError
+		assert.ErrorAs(t, err, &existsErr)
+	})
+
+	t.Run("lists databases from output lines", func(t *testing.T) {
+		client, calls := newRecordingCLIClient(t, "mysql", DatabaseOptions{}, "app_cool_name\nquotes_cool_name\n", nil)
+
+		databases, err := client.ListDatabases("%_cool_name")
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"app_cool_name", "quotes_cool_name"}, databases)
+		assert.Contains(t, (*calls)[0].args, "SHOW DATABASES LIKE '%_cool_name'")
+	})
+
+	t.Run("rejects unsupported engines", func(t *testing.T) {
+		_, err := NewCLIDatabaseClient("sqlite", DatabaseOptions{})
+		assert.Error(t, err)
+	})
+}
+
+func TestNewDatabaseClientWithFallback(t *testing.T) {
+	factoryFor := func(client DatabaseClient, err error) DatabaseClientFactory {
+		return func(engine string, opts DatabaseOptions) (DatabaseClient, error) {
+			return client, err
+		}
+	}
+
+	t.Run("uses native client when it connects", func(t *testing.T) {
+		native := NewMockDatabaseClient()
+		fallback := NewMockDatabaseClient()
+
+		client, err := newDatabaseClientWithFallback("mysql", DatabaseOptions{}, factoryFor(native, nil), factoryFor(fallback, nil))
+
+		require.NoError(t, err)
+		assert.Same(t, native, client)
+	})
+
+	t.Run("falls back when native client cannot connect", func(t *testing.T) {
+		native := NewMockDatabaseClient()
+		native.SetPingError(errors.New("access denied"))
+		fallback := NewMockDatabaseClient()
+
+		client, err := newDatabaseClientWithFallback("mysql", DatabaseOptions{}, factoryFor(native, nil), factoryFor(fallback, nil))
+
+		require.NoError(t, err)
+		assert.Same(t, fallback, client)
+	})
+
+	t.Run("keeps native client when fallback also fails", func(t *testing.T) {
+		native := NewMockDatabaseClient()
+		native.SetPingError(errors.New("access denied"))
+		fallback := NewMockDatabaseClient()
+		fallback.SetPingError(errors.New("connection refused"))
+
+		client, err := newDatabaseClientWithFallback("mysql", DatabaseOptions{}, factoryFor(native, nil), factoryFor(fallback, nil))
+
+		require.NoError(t, err)
+		assert.Same(t, native, client)
+	})
+
+	t.Run("keeps native client when fallback is unavailable", func(t *testing.T) {
+		native := NewMockDatabaseClient()
+		native.SetPingError(errors.New("access denied"))
+
+		client, err := newDatabaseClientWithFallback("mysql", DatabaseOptions{}, factoryFor(native, nil), factoryFor(nil, errors.New("mysql not found")))
+
+		require.NoError(t, err)
+		assert.Same(t, native, client)
+	})
+}
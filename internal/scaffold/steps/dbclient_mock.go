@@ -8,6 +8,9 @@ import (
 type MockDatabaseClient struct {
 	mu           sync.Mutex
 	databases    map[string]bool
+	schemas      map[string]bool
+	users        map[string]string
+	grants       map[string][]string
 	createCalls  []string
 	dropCalls    []string
 	listCalls    []string
@@ -23,6 +26,9 @@ type MockDatabaseClient struct {
 func NewMockDatabaseClient() *MockDatabaseClient {
 	return &MockDatabaseClient{
 		databases:   make(map[string]bool),
+		schemas:     make(map[string]bool),
+		users:       make(map[string]string),
+		grants:      make(map[string][]string),
 		createCalls: make([]string, 0),
 		dropCalls:   make([]string, 0),
 		listCalls:   make([]string, 0),
@@ -91,6 +97,107 @@ func (m *MockDatabaseClient) ListDatabases(pattern string) ([]string, error) {
 	return result, nil
 }
 
+func (m *MockDatabaseClient) CreateUser(name, password string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.users[name]; !ok {
+		m.users[name] = password
+	}
+	return nil
+}
+
+func (m *MockDatabaseClient) GrantDatabase(user, database string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.grants[user] = append(m.grants[user], database)
+	return nil
+}
+
+func (m *MockDatabaseClient) DropUser(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.users, name)
+	delete(m.grants, name)
+	return nil
+}
+
+func (m *MockDatabaseClient) CreateSchema(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.createError != nil {
+		return m.createError
+	}
+	if m.schemas[name] {
+		return &DatabaseExistsError{Name: name}
+	}
+
+	m.schemas[name] = true
+	return nil
+}
+
+func (m *MockDatabaseClient) DropSchema(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.dropError != nil {
+		return m.dropError
+	}
+
+	delete(m.schemas, name)
+	return nil
+}
+
+func (m *MockDatabaseClient) ListSchemas(pattern string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.listError != nil {
+		return nil, m.listError
+	}
+
+	var result []string
+	for name := range m.schemas {
+		result = append(result, name)
+	}
+	return result, nil
+}
+
+func (m *MockDatabaseClient) GrantSchema(user, schema string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.grants[user] = append(m.grants[user], schema)
+	return nil
+}
+
+// HasSchema reports whether the schema exists
+func (m *MockDatabaseClient) HasSchema(name string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.schemas[name]
+}
+
+// GetUser returns the password of a created user and whether it exists
+func (m *MockDatabaseClient) GetUser(name string) (string, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	password, ok := m.users[name]
+	return password, ok
+}
+
+// GetGrants returns the databases or schemas granted to user
+func (m *MockDatabaseClient) GetGrants(user string) []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	result := make([]string, len(m.grants[user]))
+	copy(result, m.grants[user])
+	return result
+}
+
 func (m *MockDatabaseClient) SetPingError(err error) {
 	m.pingError = err
 }
@@ -117,6 +224,12 @@ func (m *MockDatabaseClient) AddDatabase(name string) {
 	m.databases[name] = true
 }
 
+func (m *MockDatabaseClient) AddSchema(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.schemas[name] = true
+}
+
 func (m *MockDatabaseClient) GetCreateCalls() []string {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -0,0 +1,13 @@
+package steps
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMSSQLQuoting(t *testing.T) {
+	assert.Equal(t, "[app_cool_name]", quoteMSSQLIdentifier("app_cool_name"))
+	assert.Equal(t, "[odd]]name]", quoteMSSQLIdentifier("odd]name"))
+	assert.Equal(t, "%_[[]x", mssqlLikePattern("%_[x"))
+}
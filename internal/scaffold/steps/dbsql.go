@@ -0,0 +1,66 @@
+package steps
+
+import "fmt"
+
+// The statements below are shared by the native and CLI clients and by
+// dry-run previews, so a preview shows exactly what would be executed.
+
+func createDatabaseSQL(engine, name string) string {
+	switch engine {
+	case "pgsql":
+		return fmt.Sprintf("CREATE DATABASE \"%s\"", name)
+	case "sqlsrv":
+		return fmt.Sprintf("CREATE DATABASE %s", quoteMSSQLIdentifier(name))
+	default:
+		return fmt.Sprintf("CREATE DATABASE `%s`", name)
+	}
+}
+
+func dropDatabaseSQL(engine, name string) string {
+	switch engine {
+	case "pgsql":
+		return fmt.Sprintf("DROP DATABASE IF EXISTS \"%s\"", name)
+	case "sqlsrv":
+		return fmt.Sprintf("DROP DATABASE IF EXISTS %s", quoteMSSQLIdentifier(name))
+	default:
+		return fmt.Sprintf("DROP DATABASE IF EXISTS `%s`", name)
+	}
+}
+
+// createUserSQL creates a login user. PostgreSQL has no IF NOT EXISTS for
+// roles, so callers check for the role first.
+func createUserSQL(engine, name, password string) string {
+	if engine == "pgsql" {
+		return fmt.Sprintf("CREATE ROLE \"%s\" LOGIN PASSWORD %s", name, quoteSQLString(password))
+	}
+	return fmt.Sprintf("CREATE USER IF NOT EXISTS %s IDENTIFIED BY %s", mysqlAccount(name), quoteSQLString(password))
+}
+
+// grantDatabaseSQL gives user full access to database. On PostgreSQL the
+// user becomes the owner, which also covers the public schema on 15 and later.
+func grantDatabaseSQL(engine, user, database string) string {
+	if engine == "pgsql" {
+		return fmt.Sprintf("ALTER DATABASE \"%s\" OWNER TO \"%s\"", database, user)
+	}
+	return fmt.Sprintf("GRANT ALL PRIVILEGES ON `%s`.* TO %s", database, mysqlAccount(user))
+}
+
+func dropUserSQL(engine, name string) string {
+	if engine == "pgsql" {
+		return fmt.Sprintf("DROP ROLE IF EXISTS \"%s\"", name)
+	}
+	return fmt.Sprintf("DROP USER IF EXISTS %s", mysqlAccount(name))
+}
+
+func createSchemaSQL(name string) string {
+	return fmt.Sprintf("CREATE SCHEMA \"%s\"", name)
+}
+
+// dropSchemaSQL drops the schema along with every table in it
+func dropSchemaSQL(name string) string {
+	return fmt.Sprintf("DROP SCHEMA IF EXISTS \"%s\" CASCADE", name)
+}
+
+func grantSchemaSQL(user, schema string) string {
+	return fmt.Sprintf("ALTER SCHEMA \"%s\" OWNER TO \"%s\"", schema, user)
+}
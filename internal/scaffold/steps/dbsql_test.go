@@ -0,0 +1,42 @@
+package steps
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDatabaseSQL(t *testing.T) {
+	tests := []struct {
+		engine string
+		create string
+		drop   string
+	}{
+		{"mysql", "CREATE DATABASE `app_cool_engine`", "DROP DATABASE IF EXISTS `app_cool_engine`"},
+		{"pgsql", `CREATE DATABASE "app_cool_engine"`, `DROP DATABASE IF EXISTS "app_cool_engine"`},
+		{"sqlsrv", "CREATE DATABASE [app_cool_engine]", "DROP DATABASE IF EXISTS [app_cool_engine]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.engine, func(t *testing.T) {
+			assert.Equal(t, tt.create, createDatabaseSQL(tt.engine, "app_cool_engine"))
+			assert.Equal(t, tt.drop, dropDatabaseSQL(tt.engine, "app_cool_engine"))
+		})
+	}
+}
+
+func TestUserSQL(t *testing.T) {
+	assert.Equal(t, "CREATE USER IF NOT EXISTS 'arbor_x'@'%' IDENTIFIED BY 'pw'", createUserSQL("mysql", "arbor_x", "pw"))
+	assert.Equal(t, "GRANT ALL PRIVILEGES ON `app_x`.* TO 'arbor_x'@'%'", grantDatabaseSQL("mysql", "arbor_x", "app_x"))
+	assert.Equal(t, "DROP USER IF EXISTS 'arbor_x'@'%'", dropUserSQL("mysql", "arbor_x"))
+
+	assert.Equal(t, `CREATE ROLE "arbor_x" LOGIN PASSWORD 'pw'`, createUserSQL("pgsql", "arbor_x", "pw"))
+	assert.Equal(t, `ALTER DATABASE "app_x" OWNER TO "arbor_x"`, grantDatabaseSQL("pgsql", "arbor_x", "app_x"))
+	assert.Equal(t, `DROP ROLE IF EXISTS "arbor_x"`, dropUserSQL("pgsql", "arbor_x"))
+}
+
+func TestSchemaSQL(t *testing.T) {
+	assert.Equal(t, `CREATE SCHEMA "app_x"`, createSchemaSQL("app_x"))
+	assert.Equal(t, `DROP SCHEMA IF EXISTS "app_x" CASCADE`, dropSchemaSQL("app_x"))
+	assert.Equal(t, `ALTER SCHEMA "app_x" OWNER TO "arbor_x"`, grantSchemaSQL("arbor_x", "app_x"))
+}
@@ -0,0 +1,241 @@
+package steps
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// SSL modes accepted in config, .env and connection URLs. MySQL spellings
+// are normalised to their PostgreSQL equivalents.
+const (
+	sslModeDisable    = "disable"
+	sslModePrefer     = "prefer"
+	sslModeRequire    = "require"
+	sslModeVerifyCA   = "verify-ca"
+	sslModeVerifyFull = "verify-full"
+)
+
+// normalizeSSLMode returns the canonical SSL mode for mode. A CA certificate
+// without a mode implies verify-ca, and no mode at all leaves TLS disabled.
+func normalizeSSLMode(mode, ca string) (string, error) {
+	switch strings.ToLower(strings.ReplaceAll(mode, "_", "-")) {
+	case "":
+		if ca != "" {
+			return sslModeVerifyCA, nil
+		}
+		return sslModeDisable, nil
+	case "disable", "disabled", "false":
+		return sslModeDisable, nil
+	case "prefer", "preferred", "allow":
+		return sslModePrefer, nil
+	case "require", "required", "true":
+		return sslModeRequire, nil
+	case "verify-ca":
+		return sslModeVerifyCA, nil
+	case "verify-full", "verify-identity":
+		return sslModeVerifyFull, nil
+	default:
+		return "", fmt.Errorf("unsupported ssl mode: %s", mode)
+	}
+}
+
+// mysqlConfig builds the driver config for opts, connecting over the socket
+// when one is set
+func mysqlConfig(opts DatabaseOptions) (*mysql.Config, error) {
+	cfg := mysql.NewConfig()
+	cfg.User = opts.Username
+	cfg.Passwd = opts.Password
+
+	if opts.Socket != "" {
+		cfg.Net = "unix"
+		cfg.Addr = opts.Socket
+	} else {
+		cfg.Net = "tcp"
+		cfg.Addr = net.JoinHostPort(opts.Host, opts.Port)
+	}
+
+	mode, err := normalizeSSLMode(opts.SSLMode, opts.SSLCA)
+	if err != nil {
+		return nil, err
+	}
+
+	switch mode {
+	case sslModeDisable:
+		cfg.TLSConfig = "false"
+	case sslModePrefer:
+		cfg.TLSConfig = "preferred"
+	case sslModeRequire:
+		cfg.TLSConfig = "skip-verify"
+	case sslModeVerifyCA, sslModeVerifyFull:
+		cfg.TLS, err = verifyingTLSConfig(opts, mode)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return cfg, nil
+}
+
+// verifyingTLSConfig returns a TLS config that validates the server against
+// the CA in opts, or the system roots when none is set. verify-ca checks the
+// certificate chain only, verify-full also checks the host name.
+func verifyingTLSConfig(opts DatabaseOptions, mode string) (*tls.Config, error) {
+	roots, err := loadCertPool(opts.SSLCA)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &tls.Config{RootCAs: roots, ServerName: opts.Host}
+	if mode == sslModeVerifyFull {
+		return cfg, nil
+	}
+
+	// Host name verification is replaced by a chain-only check
+	cfg.InsecureSkipVerify = true
+	cfg.VerifyPeerCertificate = func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			return errors.New("server sent no certificate")
+		}
+
+		certs := make([]*x509.Certificate, len(rawCerts))
+		for i, raw := range rawCerts {
+			cert, err := x509.ParseCertificate(raw)
+			if err != nil {
+				return fmt.Errorf("parsing server certificate: %w", err)
+			}
+			certs[i] = cert
+		}
+
+		intermediates := x509.NewCertPool()
+		for _, cert := range certs[1:] {
+			intermediates.AddCert(cert)
+		}
+
+		_, err := certs[0].Verify(x509.VerifyOptions{Roots: roots, Intermediates: intermediates})
+		return err
+	}
+
+	return cfg, nil
+}
+
+func loadCertPool(caPath string) (*x509.CertPool, error) {
+	if caPath == "" {
+		return nil, nil
+	}
+
+	pem, err := os.ReadFile(caPath)
+	if err != nil {
+		return nil, fmt.Errorf("reading ssl ca: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in %s", caPath)
+	}
+	return pool, nil
+}
+
+// postgresDSN builds a keyword/value connection string for opts. A socket
+// path may name the socket file or its directory.
+func postgresDSN(opts DatabaseOptions) (string, error) {
+	mode, err := normalizeSSLMode(opts.SSLMode, opts.SSLCA)
+	if err != nil {
+		return "", err
+	}
+
+	dbname := opts.Database
+	if dbname == "" {
+		dbname = "postgres"
+	}
+
+	host := opts.Host
+	if opts.Socket != "" {
+		host = postgresSocketDir(opts.Socket)
+	}
+
+	params := [][2]string{
+		{"host", host},
+		{"port", opts.Port},
+		{"user", opts.Username},
+		{"password", opts.Password},
+		{"dbname", dbname},
+		{"sslmode", mode},
+	}
+	if opts.SSLCA != "" {
+		params = append(params, [2]string{"sslrootcert", opts.SSLCA})
+	}
+
+	parts := make([]string, len(params))
+	for i, p := range params {
+		parts[i] = p[0] + "=" + quotePostgresDSNValue(p[1])
+	}
+	return strings.Join(parts, " "), nil
+}
+
+// mssqlDSN builds a sqlserver:// connection URL for opts. TLS settings map to
+// the driver's encrypt, TrustServerCertificate and certificate parameters,
+// and without any the driver's default applies. The driver always checks the
+// host name of a verified certificate, so verify-ca behaves as verify-full.
+func mssqlDSN(opts DatabaseOptions) (string, error) {
+	query := url.Values{"database": {"master"}}
+
+	if opts.SSLMode != "" || opts.SSLCA != "" {
+		mode, err := normalizeSSLMode(opts.SSLMode, opts.SSLCA)
+		if err != nil {
+			return "", err
+		}
+
+		switch mode {
+		case sslModeDisable:
+			query.Set("encrypt", "disable")
+		case sslModePrefer:
+			query.Set("encrypt", "false")
+		case sslModeRequire:
+			query.Set("encrypt", "true")
+			query.Set("TrustServerCertificate", "true")
+		case sslModeVerifyCA, sslModeVerifyFull:
+			query.Set("encrypt", "true")
+			query.Set("TrustServerCertificate", "false")
+			if opts.SSLCA != "" {
+				query.Set("certificate", opts.SSLCA)
+			}
+		}
+	}
+
+	dsn := &url.URL{
+		Scheme:   "sqlserver",
+		User:     url.UserPassword(opts.Username, opts.Password),
+		Host:     net.JoinHostPort(opts.Host, opts.Port),
+		RawQuery: query.Encode(),
+	}
+	return dsn.String(), nil
+}
+
+// postgresSocketDir returns the directory of a PostgreSQL socket, which is
+// what libpq and psql expect as the host
+func postgresSocketDir(socket string) string {
+	if strings.HasPrefix(filepath.Base(socket), ".s.PGSQL.") {
+		return filepath.Dir(socket)
+	}
+	return socket
+}
+
+// quotePostgresDSNValue quotes values that are empty or contain spaces,
+// quotes or backslashes
+func quotePostgresDSNValue(value string) string {
+	if value != "" && !strings.ContainsAny(value, " '\\") {
+		return value
+	}
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `'`, `\'`)
+	return "'" + value + "'"
+}
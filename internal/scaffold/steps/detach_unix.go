@@ -0,0 +1,14 @@
+//go:build !windows
+
+package steps
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// detachProcess starts cmd in its own process group, so it keeps running
+// when arbor exits and is not sent the terminal's Ctrl-C
+func detachProcess(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
@@ -0,0 +1,14 @@
+//go:build windows
+
+package steps
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// detachProcess starts cmd in its own process group, so it keeps running
+// when arbor exits and is not sent the console's Ctrl-C
+func detachProcess(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP}
+}
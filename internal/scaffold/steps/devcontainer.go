@@ -0,0 +1,157 @@
+package steps
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/michaeldyrynda/arbor/internal/config"
+	"github.com/michaeldyrynda/arbor/internal/logging"
+	"github.com/michaeldyrynda/arbor/internal/scaffold/template"
+	"github.com/michaeldyrynda/arbor/internal/scaffold/types"
+)
+
+// devcontainerHeader marks a devcontainer.json written by arbor, which is
+// safe to replace. Dev containers read the file as JSON with comments.
+const devcontainerHeader = "// Generated by arbor. Run `arbor devcontainer` to regenerate it."
+
+// DefaultDevcontainerImage is used when a devcontainer step sets no image
+const DefaultDevcontainerImage = "mcr.microsoft.com/devcontainers/base:ubuntu"
+
+var containerNameInvalid = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+
+type DevcontainerStep struct {
+	image    string
+	ports    []int
+	mounts   []string
+	priority int
+}
+
+func NewDevcontainerStep(cfg config.StepConfig, priority int) *DevcontainerStep {
+	return &DevcontainerStep{image: cfg.Image, ports: cfg.Ports, mounts: cfg.Mounts, priority: priority}
+}
+
+func (s *DevcontainerStep) Name() string {
+	return "devcontainer"
+}
+
+func (s *DevcontainerStep) Run(ctx *types.ScaffoldContext, opts types.StepOptions) error {
+	path, err := WriteDevcontainer(ctx, s.image, s.ports, s.mounts)
+	if err != nil {
+		return err
+	}
+
+	logging.Verbosef("  Wrote %s", path)
+	return nil
+}
+
+func (s *DevcontainerStep) Priority() int {
+	return s.priority
+}
+
+func (s *DevcontainerStep) Condition(ctx *types.ScaffoldContext) bool {
+	return true
+}
+
+type devcontainer struct {
+	Name            string            `json:"name"`
+	Image           string            `json:"image"`
+	RunArgs         []string          `json:"runArgs"`
+	WorkspaceFolder string            `json:"workspaceFolder"`
+	AppPort         []string          `json:"appPort,omitempty"`
+	Mounts          []string          `json:"mounts,omitempty"`
+	ContainerEnv    map[string]string `json:"containerEnv"`
+}
+
+// WriteDevcontainer writes .devcontainer/devcontainer.json for the worktree
+// and returns its path. The container is named after the project and worktree,
+// each port is published on the host at the port plus the worktree's
+// PortOffset, and mounts are templates. A devcontainer.json that arbor did
+// not write is left alone.
+func WriteDevcontainer(ctx *types.ScaffoldContext, image string, ports []int, mounts []string) (string, error) {
+	path := filepath.Join(ctx.WorktreePath, ".devcontainer", "devcontainer.json")
+	if data, err := os.ReadFile(path); err == nil && !strings.HasPrefix(string(data), devcontainerHeader) {
+		return "", fmt.Errorf("%s was not written by arbor; remove it to generate one", path)
+	}
+
+	if image == "" {
+		image = DefaultDevcontainerImage
+	}
+
+	name := DevcontainerName(ctx)
+	folder := filepath.Base(ctx.WorktreePath)
+	dc := devcontainer{
+		Name:            name,
+		Image:           image,
+		RunArgs:         []string{"--name", name},
+		WorkspaceFolder: "/workspaces/" + folder,
+		ContainerEnv:    map[string]string{"ARBOR_BRANCH": ctx.Branch},
+	}
+	if project := ctx.ComposeProjectName(); project != "" {
+		dc.ContainerEnv[composeProjectKey] = project
+	}
+
+	offset := PortOffset(devcontainerSuffix(ctx))
+	for _, port := range ports {
+		hostPort := port + offset
+		if hostPort > 65535 {
+			hostPort = port
+		}
+		dc.AppPort = append(dc.AppPort, fmt.Sprintf("%d:%d", hostPort, port))
+	}
+
+	for _, mount := range mounts {
+		rendered, err := template.ReplaceTemplateVars(mount, ctx)
+		if err != nil {
+			return "", fmt.Errorf("rendering mount %q: %w", mount, err)
+		}
+		dc.Mounts = append(dc.Mounts, rendered)
+	}
+
+	data, err := json.MarshalIndent(dc, "", "\t")
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, []byte(devcontainerHeader+"\n"+string(data)+"\n"), 0644); err != nil {
+		return "", fmt.Errorf("writing %s: %w", path, err)
+	}
+	return path, nil
+}
+
+// DevcontainerName names a worktree's container after its project and
+// folder, e.g. myapp-feature-login
+func DevcontainerName(ctx *types.ScaffoldContext) string {
+	name := filepath.Base(ctx.WorktreePath)
+	if ctx.RepoName != "" {
+		name = ctx.RepoName + "-" + name
+	}
+	return strings.Trim(containerNameInvalid.ReplaceAllString(name, "-"), "-._")
+}
+
+// PortOffset spreads worktrees across host ports: a stable offset from 1 to
+// 999 derived from the suffix, or 0 without one
+func PortOffset(suffix string) int {
+	if suffix == "" {
+		return 0
+	}
+	h := fnv.New32a()
+	h.Write([]byte(suffix))
+	return int(h.Sum32()%999) + 1
+}
+
+// devcontainerSuffix is the database suffix, or the folder name for
+// worktrees that have none
+func devcontainerSuffix(ctx *types.ScaffoldContext) string {
+	if suffix := ctx.GetDbSuffix(); suffix != "" {
+		return suffix
+	}
+	return filepath.Base(ctx.WorktreePath)
+}
@@ -0,0 +1,78 @@
+package steps
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/michaeldyrynda/arbor/internal/config"
+	"github.com/michaeldyrynda/arbor/internal/scaffold/types"
+)
+
+func TestDevcontainerStep(t *testing.T) {
+	t.Run("writes a per-worktree container", func(t *testing.T) {
+		tmpDir := filepath.Join(t.TempDir(), "feature-login")
+		require.NoError(t, os.MkdirAll(tmpDir, 0755))
+
+		ctx := &types.ScaffoldContext{WorktreePath: tmpDir, RepoName: "myapp", Branch: "feature/login", Path: "feature-login"}
+		ctx.SetDbSuffix("swift_fox")
+
+		step := NewDevcontainerStep(config.StepConfig{
+			Ports:  []int{80, 5173},
+			Mounts: []string{"source={{ .RepoName }}-{{ .Path }}-vendor,target=/vendor,type=volume"},
+		}, 107)
+		require.NoError(t, step.Run(ctx, types.StepOptions{}))
+
+		data, err := os.ReadFile(filepath.Join(tmpDir, ".devcontainer", "devcontainer.json"))
+		require.NoError(t, err)
+		require.True(t, strings.HasPrefix(string(data), devcontainerHeader+"\n"))
+
+		var dc devcontainer
+		require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(string(data), devcontainerHeader)), &dc))
+
+		offset := PortOffset("swift_fox")
+		assert.Equal(t, "myapp-feature-login", dc.Name)
+		assert.Equal(t, DefaultDevcontainerImage, dc.Image)
+		assert.Equal(t, []string{"--name", "myapp-feature-login"}, dc.RunArgs)
+		assert.Equal(t, "/workspaces/feature-login", dc.WorkspaceFolder)
+		assert.Equal(t, []string{
+			strings.Join([]string{strconv.Itoa(80 + offset), "80"}, ":"),
+			strings.Join([]string{strconv.Itoa(5173 + offset), "5173"}, ":"),
+		}, dc.AppPort)
+		assert.Equal(t, []string{"source=myapp-feature-login-vendor,target=/vendor,type=volume"}, dc.Mounts)
+		assert.Equal(t, "feature/login", dc.ContainerEnv["ARBOR_BRANCH"])
+	})
+
+	t.Run("leaves a hand-written devcontainer alone", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		path := filepath.Join(tmpDir, ".devcontainer", "devcontainer.json")
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte("{}\n"), 0644))
+
+		_, err := WriteDevcontainer(&types.ScaffoldContext{WorktreePath: tmpDir}, "", nil, nil)
+		assert.ErrorContains(t, err, "was not written by arbor")
+	})
+}
+
+func TestPortOffset(t *testing.T) {
+	assert.Equal(t, 0, PortOffset(""))
+	assert.Equal(t, PortOffset("swift_fox"), PortOffset("swift_fox"))
+	assert.NotEqual(t, PortOffset("swift_fox"), PortOffset("brave_owl"))
+
+	for _, suffix := range []string{"a", "swift_fox", "brave_owl", "calm_lake"} {
+		offset := PortOffset(suffix)
+		assert.GreaterOrEqual(t, offset, 1)
+		assert.LessOrEqual(t, offset, 999)
+	}
+}
+
+func TestDevcontainerName(t *testing.T) {
+	assert.Equal(t, "myapp-feature-a_b", DevcontainerName(&types.ScaffoldContext{WorktreePath: "/p/feature+a_b", RepoName: "myapp"}))
+	assert.Equal(t, "main", DevcontainerName(&types.ScaffoldContext{WorktreePath: "/p/main"}))
+}
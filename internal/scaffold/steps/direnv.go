@@ -0,0 +1,117 @@
+package steps
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/michaeldyrynda/arbor/internal/config"
+	"github.com/michaeldyrynda/arbor/internal/logging"
+	"github.com/michaeldyrynda/arbor/internal/scaffold/types"
+	"github.com/michaeldyrynda/arbor/internal/utils"
+)
+
+// envrcHeader marks an .envrc written by arbor, which is safe to replace
+const envrcHeader = "# Generated by arbor. Run `arbor direnv` to regenerate it."
+
+// DefaultEnvrcKeys are the .env keys exported when a direnv step lists none
+var DefaultEnvrcKeys = []string{"APP_URL", "DB_DATABASE"}
+
+// DefaultEnvrcPaths are the worktree directories added to PATH, when they
+// exist, if a direnv step lists none
+var DefaultEnvrcPaths = []string{"vendor/bin", "node_modules/.bin", "bin"}
+
+type DirenvStep struct {
+	keys     []string
+	paths    []string
+	priority int
+}
+
+func NewDirenvStep(cfg config.StepConfig, priority int) *DirenvStep {
+	keys := cfg.Keys
+	if len(keys) == 0 {
+		keys = DefaultEnvrcKeys
+	}
+	paths := cfg.Paths
+	if len(paths) == 0 {
+		paths = DefaultEnvrcPaths
+	}
+	return &DirenvStep{keys: keys, paths: paths, priority: priority}
+}
+
+func (s *DirenvStep) Name() string {
+	return "direnv"
+}
+
+func (s *DirenvStep) Run(ctx *types.ScaffoldContext, opts types.StepOptions) error {
+	if err := WriteEnvrc(ctx.WorktreePath, s.keys, s.paths); err != nil {
+		return err
+	}
+
+	logging.Verbosef("  Wrote %s", filepath.Join(ctx.WorktreePath, ".envrc"))
+
+	if err := AllowEnvrc(ctx.WorktreePath); err != nil {
+		logging.Verbosef("  %v", err)
+	}
+	return nil
+}
+
+func (s *DirenvStep) Priority() int {
+	return s.priority
+}
+
+func (s *DirenvStep) Condition(ctx *types.ScaffoldContext) bool {
+	return true
+}
+
+// WriteEnvrc writes an .envrc exporting the given keys from the worktree's
+// .env and adding the paths that exist to PATH. An .envrc that arbor did not
+// write is left alone.
+func WriteEnvrc(worktreePath string, keys, paths []string) error {
+	envrcPath := filepath.Join(worktreePath, ".envrc")
+	if data, err := os.ReadFile(envrcPath); err == nil && !strings.HasPrefix(string(data), envrcHeader) {
+		return fmt.Errorf("%s was not written by arbor; remove it to generate one", envrcPath)
+	}
+
+	env := utils.ReadEnvFile(worktreePath, ".env")
+
+	var b strings.Builder
+	b.WriteString(envrcHeader + "\n")
+	for _, key := range keys {
+		value, ok := env[key]
+		if !ok {
+			continue
+		}
+		fmt.Fprintf(&b, "export %s=%s\n", key, shellQuote(utils.UnquoteEnvValue(value)))
+	}
+	for _, dir := range paths {
+		if info, err := os.Stat(filepath.Join(worktreePath, dir)); err == nil && info.IsDir() {
+			fmt.Fprintf(&b, "PATH_add %s\n", shellQuote(dir))
+		}
+	}
+
+	if err := os.WriteFile(envrcPath, []byte(b.String()), 0644); err != nil {
+		return fmt.Errorf("writing %s: %w", envrcPath, err)
+	}
+	return nil
+}
+
+// AllowEnvrc runs direnv allow for the worktree's .envrc
+func AllowEnvrc(worktreePath string) error {
+	if _, err := exec.LookPath("direnv"); err != nil {
+		return fmt.Errorf("direnv is not installed, skipping direnv allow")
+	}
+
+	cmd := exec.Command("direnv", "allow", worktreePath)
+	cmd.Dir = worktreePath
+	if output, err := logging.CombinedOutput(cmd); err != nil {
+		return fmt.Errorf("direnv allow failed: %w\n%s", err, string(output))
+	}
+	return nil
+}
+
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
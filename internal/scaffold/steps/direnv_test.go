@@ -0,0 +1,62 @@
+package steps
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/michaeldyrynda/arbor/internal/config"
+	"github.com/michaeldyrynda/arbor/internal/scaffold/types"
+)
+
+func TestDirenvStep(t *testing.T) {
+	t.Run("exports env keys and existing paths", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(tmpDir, ".env"), []byte("APP_URL=\"https://feature.test\"\nDB_DATABASE=app_swift_fox\nAPP_KEY=secret\n"), 0644))
+		require.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "vendor", "bin"), 0755))
+
+		step := NewDirenvStep(config.StepConfig{}, 105)
+		require.NoError(t, step.Run(&types.ScaffoldContext{WorktreePath: tmpDir}, types.StepOptions{}))
+
+		data, err := os.ReadFile(filepath.Join(tmpDir, ".envrc"))
+		require.NoError(t, err)
+		assert.Equal(t, envrcHeader+"\n"+
+			"export APP_URL='https://feature.test'\n"+
+			"export DB_DATABASE='app_swift_fox'\n"+
+			"PATH_add 'vendor/bin'\n", string(data))
+	})
+
+	t.Run("uses configured keys and paths", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(tmpDir, ".env"), []byte("APP_NAME=It's mine\n"), 0644))
+		require.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "scripts"), 0755))
+
+		step := NewDirenvStep(config.StepConfig{Keys: []string{"APP_NAME"}, Paths: []string{"scripts"}}, 105)
+		require.NoError(t, step.Run(&types.ScaffoldContext{WorktreePath: tmpDir}, types.StepOptions{}))
+
+		data, err := os.ReadFile(filepath.Join(tmpDir, ".envrc"))
+		require.NoError(t, err)
+		assert.Contains(t, string(data), `export APP_NAME='It'\''s mine'`)
+		assert.Contains(t, string(data), "PATH_add 'scripts'")
+	})
+
+	t.Run("leaves a hand-written envrc alone", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(tmpDir, ".envrc"), []byte("use nix\n"), 0644))
+
+		err := WriteEnvrc(tmpDir, DefaultEnvrcKeys, DefaultEnvrcPaths)
+		assert.ErrorContains(t, err, "was not written by arbor")
+
+		data, _ := os.ReadFile(filepath.Join(tmpDir, ".envrc"))
+		assert.Equal(t, "use nix\n", string(data))
+	})
+
+	t.Run("replaces its own envrc", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		require.NoError(t, WriteEnvrc(tmpDir, DefaultEnvrcKeys, nil))
+		assert.NoError(t, WriteEnvrc(tmpDir, DefaultEnvrcKeys, nil))
+	})
+}
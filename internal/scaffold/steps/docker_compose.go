@@ -0,0 +1,76 @@
+package steps
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/michaeldyrynda/arbor/internal/config"
+	"github.com/michaeldyrynda/arbor/internal/logging"
+	"github.com/michaeldyrynda/arbor/internal/scaffold/types"
+	"github.com/michaeldyrynda/arbor/internal/utils"
+)
+
+// composeProjectKey is the variable Docker Compose reads its project name
+// from, in the environment or the project's .env
+const composeProjectKey = "COMPOSE_PROJECT_NAME"
+
+// DockerComposeStep writes the worktree's COMPOSE_PROJECT_NAME to .env, so
+// docker compose run in any worktree gets its own containers, networks and
+// volumes
+type DockerComposeStep struct {
+	file     string
+	priority int
+}
+
+func NewDockerComposeStep(cfg config.StepConfig, priority int) *DockerComposeStep {
+	return &DockerComposeStep{file: cfg.File, priority: priority}
+}
+
+func (s *DockerComposeStep) Name() string {
+	return "docker.compose"
+}
+
+func (s *DockerComposeStep) Run(ctx *types.ScaffoldContext, opts types.StepOptions) error {
+	file := s.file
+	if file == "" {
+		file = ".env"
+	}
+
+	name := ctx.ComposeProjectName()
+	if name == "" {
+		return fmt.Errorf("cannot derive %s without a site name or database suffix", composeProjectKey)
+	}
+
+	if err := utils.WriteEnvValue(ctx.WorktreePath, file, composeProjectKey, name); err != nil {
+		return fmt.Errorf("writing %s to %s: %w", composeProjectKey, file, err)
+	}
+
+	logging.Verbosef("  Set %s=%s in %s", composeProjectKey, name, file)
+	return nil
+}
+
+func (s *DockerComposeStep) Priority() int {
+	return s.priority
+}
+
+func (s *DockerComposeStep) Condition(ctx *types.ScaffoldContext) bool {
+	return true
+}
+
+// commandEnv is the environment for commands run by steps. It sets
+// COMPOSE_PROJECT_NAME, so docker compose commands are isolated per worktree
+// even without a docker.compose step, unless the environment or the
+// worktree's .env already names a project.
+func commandEnv(ctx *types.ScaffoldContext) []string {
+	env := os.Environ()
+	if _, ok := os.LookupEnv(composeProjectKey); ok {
+		return env
+	}
+	if utils.ReadEnvFile(ctx.WorktreePath, ".env")[composeProjectKey] != "" {
+		return env
+	}
+	if name := ctx.ComposeProjectName(); name != "" {
+		env = append(env, composeProjectKey+"="+name)
+	}
+	return env
+}
@@ -0,0 +1,61 @@
+package steps
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/michaeldyrynda/arbor/internal/config"
+	"github.com/michaeldyrynda/arbor/internal/scaffold/types"
+	"github.com/michaeldyrynda/arbor/internal/utils"
+)
+
+func TestDockerComposeStep(t *testing.T) {
+	t.Run("writes the project name to .env", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(tmpDir, ".env"), []byte("APP_NAME=App\n"), 0644))
+
+		ctx := &types.ScaffoldContext{WorktreePath: tmpDir, SiteName: "myapp"}
+		ctx.SetDbSuffix("swift_fox")
+
+		step := NewDockerComposeStep(config.StepConfig{}, 11)
+		require.NoError(t, step.Run(ctx, types.StepOptions{}))
+
+		env := utils.ReadEnvFile(tmpDir, ".env")
+		assert.Equal(t, "myapp_swift_fox", env["COMPOSE_PROJECT_NAME"])
+		assert.Equal(t, "App", env["APP_NAME"])
+	})
+
+	t.Run("fails without a name", func(t *testing.T) {
+		step := NewDockerComposeStep(config.StepConfig{}, 11)
+		err := step.Run(&types.ScaffoldContext{WorktreePath: t.TempDir()}, types.StepOptions{})
+		assert.ErrorContains(t, err, "COMPOSE_PROJECT_NAME")
+	})
+}
+
+func TestCommandEnv(t *testing.T) {
+	t.Setenv("COMPOSE_PROJECT_NAME", "")
+	os.Unsetenv("COMPOSE_PROJECT_NAME")
+
+	tmpDir := t.TempDir()
+	ctx := &types.ScaffoldContext{WorktreePath: tmpDir, SiteName: "myapp"}
+	ctx.SetDbSuffix("swift_fox")
+
+	t.Run("sets the project name", func(t *testing.T) {
+		assert.Contains(t, commandEnv(ctx), "COMPOSE_PROJECT_NAME=myapp_swift_fox")
+	})
+
+	t.Run("keeps a project named in .env", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(filepath.Join(tmpDir, ".env"), []byte("COMPOSE_PROJECT_NAME=shared\n"), 0644))
+		assert.NotContains(t, commandEnv(ctx), "COMPOSE_PROJECT_NAME=myapp_swift_fox")
+	})
+
+	t.Run("is visible to bash.run", func(t *testing.T) {
+		require.NoError(t, os.Remove(filepath.Join(tmpDir, ".env")))
+		step := NewBashRunStep(`test "$COMPOSE_PROJECT_NAME" = myapp_swift_fox`)
+		assert.NoError(t, step.Run(ctx, types.StepOptions{}))
+	})
+}
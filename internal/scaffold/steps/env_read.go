@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/michaeldyrynda/arbor/internal/config"
+	"github.com/michaeldyrynda/arbor/internal/logging"
 	"github.com/michaeldyrynda/arbor/internal/scaffold/types"
 	"github.com/michaeldyrynda/arbor/internal/utils"
 )
@@ -29,13 +30,25 @@ func (s *EnvReadStep) Name() string {
 }
 
 func (s *EnvReadStep) Priority() int {
-	return 0
+	return PriorityEnv
 }
 
 func (s *EnvReadStep) Condition(ctx *types.ScaffoldContext) bool {
 	return true
 }
 
+// Plan reads the value as Run does, since later steps' templates may use it
+func (s *EnvReadStep) Plan(ctx *types.ScaffoldContext, opts types.StepOptions) (types.StepPlan, error) {
+	if err := s.Run(ctx, opts); err != nil {
+		return types.StepPlan{}, err
+	}
+	file := s.file
+	if file == "" {
+		file = ".env"
+	}
+	return types.StepPlan{Detail: fmt.Sprintf("reads %s from %s", s.key, file)}, nil
+}
+
 func (s *EnvReadStep) Run(ctx *types.ScaffoldContext, opts types.StepOptions) error {
 	file := s.file
 	if file == "" {
@@ -49,9 +62,7 @@ func (s *EnvReadStep) Run(ctx *types.ScaffoldContext, opts types.StepOptions) er
 			varName = s.key
 		}
 		ctx.SetVar(varName, value)
-		if opts.Verbose {
-			fmt.Printf("  Read %s=%s from %s as %s\n", s.key, value, file, varName)
-		}
+		logging.Verbosef("  Read %s=%s from %s as %s", s.key, value, file, varName)
 		return nil
 	}
 
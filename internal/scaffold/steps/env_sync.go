@@ -0,0 +1,135 @@
+package steps
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/michaeldyrynda/arbor/internal/config"
+	"github.com/michaeldyrynda/arbor/internal/logging"
+	"github.com/michaeldyrynda/arbor/internal/scaffold/types"
+	"github.com/michaeldyrynda/arbor/internal/utils"
+)
+
+// DefaultEnvExample is the file env.sync takes new keys from when it names
+// none
+const DefaultEnvExample = ".env.example"
+
+// EnvSyncResult is what SyncEnv found. Added keys are appended in the order
+// of the example; removed keys are only reported, since a value may still be
+// needed by another branch.
+type EnvSyncResult struct {
+	Added   []string
+	Removed []string
+}
+
+type EnvSyncStep struct {
+	from     string
+	file     string
+	priority int
+}
+
+func NewEnvSyncStep(cfg config.StepConfig, priority int) *EnvSyncStep {
+	from := cfg.From
+	if from == "" {
+		from = DefaultEnvExample
+	}
+	file := cfg.File
+	if file == "" {
+		file = ".env"
+	}
+	return &EnvSyncStep{from: from, file: file, priority: priority}
+}
+
+func (s *EnvSyncStep) Name() string {
+	return "env.sync"
+}
+
+func (s *EnvSyncStep) Run(ctx *types.ScaffoldContext, opts types.StepOptions) error {
+	result, err := SyncEnv(ctx.WorktreePath, s.file, s.from, ctx.Interaction.EnvValue, opts.DryRun)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range result.Added {
+		logging.Infof("  Added %s to %s from %s", key, s.file, s.from)
+	}
+	for _, key := range result.Removed {
+		logging.Infof("  %s is in %s but no longer in %s", key, s.file, s.from)
+	}
+	return nil
+}
+
+// Plan returns the keys the step would add and those it would report
+func (s *EnvSyncStep) Plan(ctx *types.ScaffoldContext, opts types.StepOptions) (types.StepPlan, error) {
+	result, err := SyncEnv(ctx.WorktreePath, s.file, s.from, nil, true)
+	if err != nil {
+		return types.StepPlan{}, err
+	}
+
+	plan := types.StepPlan{Detail: fmt.Sprintf("%s is up to date with %s", s.file, s.from)}
+	var details []string
+	if len(result.Added) > 0 {
+		plan.Files = []string{s.file}
+		details = append(details, "adds "+strings.Join(result.Added, ", "))
+	}
+	if len(result.Removed) > 0 {
+		details = append(details, "no longer in example: "+strings.Join(result.Removed, ", "))
+	}
+	if len(details) > 0 {
+		plan.Detail = strings.Join(details, "; ")
+	}
+	return plan, nil
+}
+
+func (s *EnvSyncStep) Priority() int {
+	return s.priority
+}
+
+func (s *EnvSyncStep) Condition(ctx *types.ScaffoldContext) bool {
+	_, err := os.Stat(filepath.Join(ctx.WorktreePath, s.from))
+	return err == nil
+}
+
+// SyncEnv appends the keys of example that file lacks, with the example's
+// values or those returned by prompt when it is set, and reports the keys of
+// file that example no longer has. A missing or empty file takes every value
+// from the example without prompting. With dryRun nothing is written.
+func SyncEnv(worktreePath, file, example string, prompt func(key, example string) (string, error), dryRun bool) (EnvSyncResult, error) {
+	if _, err := os.Stat(filepath.Join(worktreePath, example)); err != nil {
+		return EnvSyncResult{}, fmt.Errorf("reading %s: %w", example, err)
+	}
+
+	env := utils.ReadEnvFile(worktreePath, file)
+	exampleEnv := utils.ReadEnvFile(worktreePath, example)
+	if len(env) == 0 {
+		prompt = nil
+	}
+
+	var result EnvSyncResult
+	for _, key := range utils.ReadEnvKeys(worktreePath, example) {
+		if utils.EnvExists(env, key) {
+			continue
+		}
+		result.Added = append(result.Added, key)
+		if dryRun {
+			continue
+		}
+
+		value := exampleEnv[key]
+		if prompt != nil {
+			answer, err := prompt(key, value)
+			if err != nil {
+				return result, fmt.Errorf("value for %s: %w", key, err)
+			}
+			value = answer
+		}
+		if err := utils.WriteEnvValue(worktreePath, file, key, value); err != nil {
+			return result, err
+		}
+	}
+
+	result.Removed = utils.DiffEnv(env, exampleEnv).Extra
+	return result, nil
+}
@@ -0,0 +1,98 @@
+package steps
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/michaeldyrynda/arbor/internal/config"
+	"github.com/michaeldyrynda/arbor/internal/scaffold/types"
+)
+
+func TestEnvSyncStep(t *testing.T) {
+	writeFiles := func(t *testing.T) string {
+		tmpDir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(tmpDir, ".env.example"), []byte("APP_NAME=Laravel\nSTRIPE_KEY=\nREDIS_HOST=127.0.0.1\n"), 0644))
+		require.NoError(t, os.WriteFile(filepath.Join(tmpDir, ".env"), []byte("APP_NAME=\"My App\"\nPUSHER_KEY=abc\n"), 0644))
+		return tmpDir
+	}
+
+	t.Run("appends new keys with example values", func(t *testing.T) {
+		tmpDir := writeFiles(t)
+
+		step := NewEnvSyncStep(config.StepConfig{}, 10)
+		ctx := &types.ScaffoldContext{WorktreePath: tmpDir}
+		assert.True(t, step.Condition(ctx))
+		require.NoError(t, step.Run(ctx, types.StepOptions{}))
+
+		data, err := os.ReadFile(filepath.Join(tmpDir, ".env"))
+		require.NoError(t, err)
+		assert.Equal(t, "APP_NAME=\"My App\"\nPUSHER_KEY=abc\nSTRIPE_KEY=\nREDIS_HOST=127.0.0.1\n", string(data))
+	})
+
+	t.Run("reports removed keys and asks for values", func(t *testing.T) {
+		tmpDir := writeFiles(t)
+
+		var asked []string
+		prompt := func(key, example string) (string, error) {
+			asked = append(asked, key+"="+example)
+			return "answer", nil
+		}
+		result, err := SyncEnv(tmpDir, ".env", ".env.example", prompt, false)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"STRIPE_KEY", "REDIS_HOST"}, result.Added)
+		assert.Equal(t, []string{"PUSHER_KEY"}, result.Removed)
+		assert.Equal(t, []string{"STRIPE_KEY=", "REDIS_HOST=127.0.0.1"}, asked)
+
+		data, _ := os.ReadFile(filepath.Join(tmpDir, ".env"))
+		assert.Contains(t, string(data), "REDIS_HOST=answer\n")
+		assert.Contains(t, string(data), "PUSHER_KEY=abc\n", "removed keys are kept")
+	})
+
+	t.Run("copies defaults into a fresh file without asking", func(t *testing.T) {
+		tmpDir := writeFiles(t)
+		require.NoError(t, os.Remove(filepath.Join(tmpDir, ".env")))
+
+		prompt := func(key, example string) (string, error) {
+			t.Errorf("asked for %s", key)
+			return "", nil
+		}
+		result, err := SyncEnv(tmpDir, ".env", ".env.example", prompt, false)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"APP_NAME", "STRIPE_KEY", "REDIS_HOST"}, result.Added)
+
+		data, err := os.ReadFile(filepath.Join(tmpDir, ".env"))
+		require.NoError(t, err)
+		assert.Equal(t, "APP_NAME=Laravel\nSTRIPE_KEY=\nREDIS_HOST=127.0.0.1\n", string(data))
+	})
+
+	t.Run("runs on its own", func(t *testing.T) {
+		for _, info := range Steps() {
+			if info.Name == "env.sync" {
+				assert.Equal(t, PriorityEnvSync, info.Priority)
+			} else {
+				assert.NotEqual(t, PriorityEnvSync, info.Priority, "%s shares env.sync's priority", info.Name)
+			}
+		}
+	})
+
+	t.Run("plans without writing", func(t *testing.T) {
+		tmpDir := writeFiles(t)
+
+		plan, err := NewEnvSyncStep(config.StepConfig{}, 10).Plan(&types.ScaffoldContext{WorktreePath: tmpDir}, types.StepOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, []string{".env"}, plan.Files)
+		assert.Equal(t, "adds STRIPE_KEY, REDIS_HOST; no longer in example: PUSHER_KEY", plan.Detail)
+
+		data, _ := os.ReadFile(filepath.Join(tmpDir, ".env"))
+		assert.NotContains(t, string(data), "STRIPE_KEY")
+	})
+
+	t.Run("skipped without an example", func(t *testing.T) {
+		step := NewEnvSyncStep(config.StepConfig{From: ".env.dist"}, 10)
+		assert.False(t, step.Condition(&types.ScaffoldContext{WorktreePath: t.TempDir()}))
+	})
+}
@@ -2,28 +2,66 @@ package steps
 
 import (
 	"fmt"
-	"os"
-	"path/filepath"
 	"strings"
 
 	"github.com/michaeldyrynda/arbor/internal/config"
+	"github.com/michaeldyrynda/arbor/internal/logging"
 	"github.com/michaeldyrynda/arbor/internal/scaffold/template"
 	"github.com/michaeldyrynda/arbor/internal/scaffold/types"
+	"github.com/michaeldyrynda/arbor/internal/utils"
 )
 
+// envWriteDatabaseURL is the env.write type that replaces only the database
+// name of an existing connection URL, keeping its host and credentials
+const envWriteDatabaseURL = "database_url"
+
+// envWriteAppName is the env.write type that names the app after the
+// worktree, so mail subjects and dashboards show which branch they belong
+// to. Keys repeating the old APP_NAME are updated along with it.
+const envWriteAppName = "app_name"
+
+// defaultAppName is the value of an app_name env.write without one
+const defaultAppName = "{{ .SiteName }} ({{ .Branch }})"
+
+// DefaultAppNameKeys are the keys an app_name env.write updates along with
+// APP_NAME when it lists none
+var DefaultAppNameKeys = []string{"MAIL_FROM_NAME", "VITE_APP_NAME"}
+
 type EnvWriteStep struct {
-	name  string
-	key   string
-	value string
-	file  string
+	name      string
+	key       string
+	value     string
+	file      string
+	valueType string
+	keys      []string
 }
 
 func NewEnvWriteStep(cfg config.StepConfig) *EnvWriteStep {
+	key := cfg.Key
+	if key == "" && cfg.Type == envWriteDatabaseURL {
+		key = "DATABASE_URL"
+	}
+	value := cfg.Value
+	keys := cfg.Keys
+	if cfg.Type == envWriteAppName {
+		if key == "" {
+			key = "APP_NAME"
+		}
+		if value == "" {
+			value = defaultAppName
+		}
+		if len(keys) == 0 {
+			keys = DefaultAppNameKeys
+		}
+	}
+
 	return &EnvWriteStep{
-		name:  "env.write",
-		key:   cfg.Key,
-		value: cfg.Value,
-		file:  cfg.File,
+		name:      "env.write",
+		key:       key,
+		value:     value,
+		file:      cfg.File,
+		valueType: cfg.Type,
+		keys:      keys,
 	}
 }
 
@@ -32,7 +70,7 @@ func (s *EnvWriteStep) Name() string {
 }
 
 func (s *EnvWriteStep) Priority() int {
-	return 0
+	return PriorityEnv
 }
 
 func (s *EnvWriteStep) Condition(ctx *types.ScaffoldContext) bool {
@@ -40,70 +78,82 @@ func (s *EnvWriteStep) Condition(ctx *types.ScaffoldContext) bool {
 }
 
 func (s *EnvWriteStep) Run(ctx *types.ScaffoldContext, opts types.StepOptions) error {
-	file := s.file
-	if file == "" {
-		file = ".env"
+	file, replacedValue, err := s.resolve(ctx)
+	if err != nil {
+		return err
 	}
 
-	replacedValue, err := template.ReplaceTemplateVars(s.value, ctx)
-	if err != nil {
-		return fmt.Errorf("template replacement failed: %w", err)
+	for _, key := range append([]string{s.key}, s.derivedKeys(ctx, file)...) {
+		if err := utils.WriteEnvValue(ctx.WorktreePath, file, key, replacedValue); err != nil {
+			return err
+		}
+		logging.Verbosef("  Wrote %s=%s to %s", key, replacedValue, file)
 	}
 
-	filePath := filepath.Join(ctx.WorktreePath, file)
+	return nil
+}
 
-	var oldPerms os.FileMode
-	if info, err := os.Stat(filePath); err == nil {
-		oldPerms = info.Mode().Perm()
-	} else {
-		oldPerms = 0644
+// Plan returns the file and value the step would write
+func (s *EnvWriteStep) Plan(ctx *types.ScaffoldContext, opts types.StepOptions) (types.StepPlan, error) {
+	file, replacedValue, err := s.resolve(ctx)
+	if err != nil {
+		return types.StepPlan{}, err
 	}
+	detail := fmt.Sprintf("%s=%s", s.key, replacedValue)
+	if derived := s.derivedKeys(ctx, file); len(derived) > 0 {
+		detail += fmt.Sprintf(" (also %s)", strings.Join(derived, ", "))
+	}
+	return types.StepPlan{Files: []string{file}, Detail: detail}, nil
+}
 
-	var content []byte
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		content = []byte(fmt.Sprintf("%s=%s\n", s.key, replacedValue))
-	} else {
-		content, err = os.ReadFile(filePath)
-		if err != nil {
-			return fmt.Errorf("reading file: %w", err)
-		}
+// derivedKeys returns the keys of an app_name write, from its keys option or
+// DefaultAppNameKeys, whose value is the current APP_NAME written out, such
+// as MAIL_FROM_NAME=Laravel. Keys that reference it, as in "${APP_NAME}",
+// follow it already.
+func (s *EnvWriteStep) derivedKeys(ctx *types.ScaffoldContext, file string) []string {
+	if s.valueType != envWriteAppName {
+		return nil
+	}
 
-		var updated bool
-		lines := strings.Split(string(content), "\n")
-		for i, line := range lines {
-			if strings.HasPrefix(line, s.key+"=") || strings.HasPrefix(line, s.key+" ") {
-				lines[i] = fmt.Sprintf("%s=%s", s.key, replacedValue)
-				updated = true
-				break
-			}
-		}
+	env := utils.ReadEnvFile(ctx.WorktreePath, file)
+	current := utils.UnquoteEnvValue(env[s.key])
+	if current == "" {
+		return nil
+	}
 
-		if !updated {
-			if !strings.HasSuffix(string(content), "\n") {
-				content = append(content, '\n')
-			}
-			content = append(content, []byte(fmt.Sprintf("%s=%s\n", s.key, replacedValue))...)
-		} else {
-			content = []byte(strings.Join(lines, "\n"))
-			if !strings.HasSuffix(string(content), "\n") {
-				content = append(content, '\n')
-			}
+	var keys []string
+	for _, key := range s.keys {
+		if value, ok := env[key]; ok && key != s.key && utils.UnquoteEnvValue(value) == current {
+			keys = append(keys, key)
 		}
 	}
+	return keys
+}
 
-	tmpFile := filePath + ".tmp"
-	if err := os.WriteFile(tmpFile, content, oldPerms); err != nil {
-		return fmt.Errorf("writing temp file: %w", err)
+// resolve returns the env file to write and the rendered value
+func (s *EnvWriteStep) resolve(ctx *types.ScaffoldContext) (string, string, error) {
+	file := s.file
+	if file == "" {
+		file = ".env"
 	}
 
-	if err := os.Rename(tmpFile, filePath); err != nil {
-		os.Remove(tmpFile)
-		return fmt.Errorf("renaming temp file: %w", err)
+	replacedValue, err := template.ReplaceTemplateVars(s.value, ctx)
+	if err != nil {
+		return "", "", fmt.Errorf("template replacement failed: %w", err)
 	}
 
-	if opts.Verbose {
-		fmt.Printf("  Wrote %s=%s to %s\n", s.key, replacedValue, file)
+	if s.valueType == envWriteAppName {
+		replacedValue = `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(replacedValue) + `"`
 	}
-
-	return nil
+	if s.valueType == envWriteDatabaseURL {
+		current := utils.ReadEnvFile(ctx.WorktreePath, file)[s.key]
+		if current == "" {
+			return "", "", fmt.Errorf("%s not found in %s", s.key, file)
+		}
+		replacedValue, err = replaceURLDatabase(current, replacedValue)
+		if err != nil {
+			return "", "", fmt.Errorf("updating %s: %w", s.key, err)
+		}
+	}
+	return file, replacedValue, nil
 }
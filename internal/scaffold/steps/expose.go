@@ -0,0 +1,317 @@
+package steps
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/michaeldyrynda/arbor/internal/config"
+	"github.com/michaeldyrynda/arbor/internal/logging"
+	"github.com/michaeldyrynda/arbor/internal/scaffold/types"
+	"github.com/michaeldyrynda/arbor/internal/utils"
+)
+
+// TunnelTools are the supported tunnel commands, in order of preference
+var TunnelTools = []string{"expose", "ngrok"}
+
+// tunnelStartTimeout bounds how long a tunnel is given to report its URL
+var tunnelStartTimeout = 30 * time.Second
+
+var tunnelURLPattern = regexp.MustCompile(`https://[^\s"'<>\x1b]+`)
+
+type ExposeStep struct {
+	tool     string
+	priority int
+}
+
+func NewExposeStep(cfg config.StepConfig, priority int) *ExposeStep {
+	return &ExposeStep{tool: cfg.Type, priority: priority}
+}
+
+func (s *ExposeStep) Name() string {
+	return "expose"
+}
+
+// Run starts the tunnel in the background and records its public URL. The
+// tunnel keeps running after arbor exits, until arbor expose --stop.
+func (s *ExposeStep) Run(ctx *types.ScaffoldContext, opts types.StepOptions) error {
+	appURL := utils.ReadEnvFile(ctx.WorktreePath, ".env")["APP_URL"]
+	publicURL, err := StartTunnel(ctx.BarePath, ctx.WorktreePath, s.tool, appURL)
+	if err != nil {
+		return err
+	}
+
+	logging.Infof("  Public URL: %s", publicURL)
+	// Without a clipboard the public URL is only printed
+	if copyURL := ctx.Interaction.Clipboard; copyURL != nil {
+		if err := copyURL(publicURL); err != nil {
+			logging.Verbosef("  Could not copy to clipboard: %v", err)
+		} else {
+			logging.Verbosef("  Copied to clipboard")
+		}
+	}
+	return nil
+}
+
+func (s *ExposeStep) Priority() int {
+	return s.priority
+}
+
+func (s *ExposeStep) Condition(ctx *types.ScaffoldContext) bool {
+	if utils.IsCI() {
+		return false
+	}
+	if _, err := TunnelTool(s.tool); err != nil {
+		return false
+	}
+	return utils.ReadEnvFile(ctx.WorktreePath, ".env")["APP_URL"] != ""
+}
+
+// TunnelTool returns the tunnel command to use: preferred when set, or the
+// first of TunnelTools that is installed
+func TunnelTool(preferred string) (string, error) {
+	if preferred != "" {
+		if !isTunnelTool(preferred) {
+			return "", fmt.Errorf("unknown tunnel %q (use %s)", preferred, strings.Join(TunnelTools, " or "))
+		}
+		if _, err := exec.LookPath(preferred); err != nil {
+			return "", fmt.Errorf("%s is not installed", preferred)
+		}
+		return preferred, nil
+	}
+
+	for _, tool := range TunnelTools {
+		if _, err := exec.LookPath(tool); err == nil {
+			return tool, nil
+		}
+	}
+	return "", fmt.Errorf("install expose (https://expose.dev) or ngrok (https://ngrok.com) to share worktrees")
+}
+
+func isTunnelTool(name string) bool {
+	for _, tool := range TunnelTools {
+		if tool == name {
+			return true
+		}
+	}
+	return false
+}
+
+// TunnelCommand returns the command that shares appURL through tool. ngrok
+// logs to stdout as JSON so the public URL can be read from its output.
+func TunnelCommand(tool, appURL string) (*exec.Cmd, error) {
+	local, err := url.Parse(appURL)
+	if err != nil || local.Host == "" {
+		return nil, fmt.Errorf("APP_URL %q is not a URL", appURL)
+	}
+
+	switch tool {
+	case "expose":
+		return exec.Command("expose", "share", appURL), nil
+	case "ngrok":
+		return exec.Command("ngrok", "http", tunnelAddress(local), "--host-header=rewrite", "--log", "stdout", "--log-format", "json"), nil
+	}
+	return nil, fmt.Errorf("unknown tunnel %q (use %s)", tool, strings.Join(TunnelTools, " or "))
+}
+
+// tunnelAddress is the host and port ngrok forwards to, keeping https so
+// local TLS sites such as Herd's keep working
+func tunnelAddress(local *url.URL) string {
+	if local.Scheme == "https" {
+		return "https://" + local.Host
+	}
+	if local.Port() == "" {
+		return local.Host + ":80"
+	}
+	return local.Host
+}
+
+// WaitForTunnelURL reads tunnel output until it reports a public HTTPS URL
+// on a host other than localHost
+func WaitForTunnelURL(r io.Reader, localHost string) (string, error) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		for _, match := range tunnelURLPattern.FindAllString(scanner.Text(), -1) {
+			if u, err := url.Parse(match); err == nil && u.Host != "" && u.Hostname() != localHost {
+				return strings.TrimRight(match, ".,)"), nil
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("tunnel exited without reporting a public URL")
+}
+
+// TunnelLogPath is where a background tunnel for the worktree writes its
+// output
+func TunnelLogPath(barePath, worktreePath string) string {
+	return filepath.Join(barePath, "arbor", "expose-"+filepath.Base(worktreePath)+".log")
+}
+
+// tunnelPIDPath is the pidfile of the worktree's tunnel, holding its process
+// id and tool so StopTunnel only kills the process it started
+func tunnelPIDPath(barePath, worktreePath string) string {
+	return filepath.Join(barePath, "arbor", "expose-"+filepath.Base(worktreePath)+".pid")
+}
+
+// StartTunnel starts a background tunnel for the worktree, logging to
+// TunnelLogPath, and waits for its public URL. The tunnel is recorded with
+// RecordTunnel.
+func StartTunnel(barePath, worktreePath, preferred, appURL string) (string, error) {
+	if appURL == "" {
+		return "", fmt.Errorf("APP_URL is not set in .env")
+	}
+
+	tool, err := TunnelTool(preferred)
+	if err != nil {
+		return "", err
+	}
+	cmd, err := TunnelCommand(tool, appURL)
+	if err != nil {
+		return "", err
+	}
+
+	logPath := TunnelLogPath(barePath, worktreePath)
+	if err := os.MkdirAll(filepath.Dir(logPath), 0755); err != nil {
+		return "", err
+	}
+	logFile, err := os.Create(logPath)
+	if err != nil {
+		return "", fmt.Errorf("creating tunnel log: %w", err)
+	}
+	defer logFile.Close()
+
+	cmd.Dir = worktreePath
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	detachProcess(cmd)
+	if err := cmd.Start(); err != nil {
+		return "", fmt.Errorf("starting %s: %w", tool, err)
+	}
+
+	// The tunnel's exit status is not needed, only that it exited early
+	exited := make(chan error, 1)
+	go func() {
+		exited <- cmd.Wait()
+	}()
+
+	local, err := url.Parse(appURL)
+	if err != nil {
+		return "", fmt.Errorf("APP_URL %q is not a URL: %w", appURL, err)
+	}
+	deadline := time.Now().Add(tunnelStartTimeout)
+	for time.Now().Before(deadline) {
+		if data, err := os.ReadFile(logPath); err == nil {
+			if publicURL, err := WaitForTunnelURL(strings.NewReader(string(data)), local.Hostname()); err == nil {
+				if err := RecordTunnel(barePath, worktreePath, tool, publicURL, cmd.Process.Pid); err != nil {
+					return "", err
+				}
+				return publicURL, nil
+			}
+		}
+
+		select {
+		case <-exited:
+			return "", fmt.Errorf("%s exited before sharing %s; see %s", tool, appURL, logPath)
+		case <-time.After(250 * time.Millisecond):
+		}
+	}
+
+	if err := cmd.Process.Kill(); err != nil {
+		return "", fmt.Errorf("%s did not report a public URL within %s, and could not be stopped: %w", tool, tunnelStartTimeout, err)
+	}
+	return "", fmt.Errorf("%s did not report a public URL within %s; see %s", tool, tunnelStartTimeout, logPath)
+}
+
+// RecordTunnel writes the tunnel's public URL and process id to the
+// worktree's arbor.yaml, and its process id and tool to its pidfile. A pid
+// of 0 records a tunnel arbor is not tracking.
+func RecordTunnel(barePath, worktreePath, tool, publicURL string, pid int) error {
+	values := map[string]string{"public_url": publicURL, "tunnel_pid": ""}
+	if pid > 0 {
+		values["tunnel_pid"] = strconv.Itoa(pid)
+	}
+	if err := config.WriteWorktreeConfig(worktreePath, values); err != nil {
+		return fmt.Errorf("recording public URL: %w", err)
+	}
+
+	pidPath := tunnelPIDPath(barePath, worktreePath)
+	if pid <= 0 {
+		if err := os.Remove(pidPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("removing tunnel pidfile: %w", err)
+		}
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(pidPath), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(pidPath, []byte(fmt.Sprintf("%d %s\n", pid, tool)), 0644); err != nil {
+		return fmt.Errorf("writing tunnel pidfile: %w", err)
+	}
+	return nil
+}
+
+// StopTunnel stops the worktree's background tunnel, if any, and clears its
+// public URL. The process in the pidfile is only killed while it is still
+// the tunnel tool, as its id may since have been reused. It reports whether
+// a tunnel was stopped.
+func StopTunnel(barePath, worktreePath string) (bool, error) {
+	stopped := false
+	if pid, tool, ok := readTunnelPID(tunnelPIDPath(barePath, worktreePath)); ok && isTunnelProcess(pid, tool) {
+		proc, err := os.FindProcess(pid)
+		if err != nil {
+			return false, fmt.Errorf("finding tunnel process %d: %w", pid, err)
+		}
+		if err := proc.Kill(); err != nil {
+			return false, fmt.Errorf("stopping tunnel process %d: %w", pid, err)
+		}
+		stopped = true
+	}
+
+	wtConfig, err := config.ReadWorktreeConfig(worktreePath)
+	if err != nil {
+		return stopped, err
+	}
+	if wtConfig.PublicURL != "" || wtConfig.TunnelPID != "" || stopped {
+		if err := RecordTunnel(barePath, worktreePath, "", "", 0); err != nil {
+			return stopped, err
+		}
+	}
+	return stopped, nil
+}
+
+// readTunnelPID reads the process id and tool from a tunnel pidfile
+func readTunnelPID(path string) (int, string, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, "", false
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) != 2 {
+		return 0, "", false
+	}
+	pid, err := strconv.Atoi(fields[0])
+	if err != nil || pid <= 0 {
+		return 0, "", false
+	}
+	return pid, fields[1], true
+}
+
+// isTunnelProcess reports whether pid is a running process of tool
+func isTunnelProcess(pid int, tool string) bool {
+	name, err := processName(pid)
+	if err != nil {
+		return false
+	}
+	name = strings.TrimSuffix(filepath.Base(name), ".exe")
+	return name == tool
+}
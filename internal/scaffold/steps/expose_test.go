@@ -0,0 +1,116 @@
+package steps
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/michaeldyrynda/arbor/internal/config"
+)
+
+func TestWaitForTunnelURL(t *testing.T) {
+	t.Run("ngrok json logs", func(t *testing.T) {
+		output := `{"lvl":"info","msg":"starting web service","addr":"127.0.0.1:4040"}
+{"addr":"https://feature.test:443","lvl":"info","msg":"started tunnel","name":"command_line","url":"https://ab12-1-2-3-4.ngrok-free.app"}
+`
+		publicURL, err := WaitForTunnelURL(strings.NewReader(output), "feature.test")
+		require.NoError(t, err)
+		assert.Equal(t, "https://ab12-1-2-3-4.ngrok-free.app", publicURL)
+	})
+
+	t.Run("expose output", func(t *testing.T) {
+		output := "Local-URL:     https://feature.test\n" +
+			"Public HTTP:   http://swift-fox.sharedwithexpose.com\n" +
+			"Public HTTPS:  \x1b[32mhttps://swift-fox.sharedwithexpose.com\x1b[0m\n"
+		publicURL, err := WaitForTunnelURL(strings.NewReader(output), "feature.test")
+		require.NoError(t, err)
+		assert.Equal(t, "https://swift-fox.sharedwithexpose.com", publicURL)
+	})
+
+	t.Run("no url", func(t *testing.T) {
+		_, err := WaitForTunnelURL(strings.NewReader("authentication failed\n"), "feature.test")
+		assert.Error(t, err)
+	})
+}
+
+func TestTunnelCommand(t *testing.T) {
+	cmd, err := TunnelCommand("ngrok", "https://feature.test")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ngrok", "http", "https://feature.test", "--host-header=rewrite", "--log", "stdout", "--log-format", "json"}, cmd.Args)
+
+	cmd, err = TunnelCommand("ngrok", "http://localhost:8000")
+	require.NoError(t, err)
+	assert.Equal(t, "localhost:8000", cmd.Args[2])
+
+	cmd, err = TunnelCommand("expose", "http://feature.test")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"expose", "share", "http://feature.test"}, cmd.Args)
+
+	_, err = TunnelCommand("ngrok", "feature")
+	assert.Error(t, err)
+
+	_, err = TunnelTool("localtunnel")
+	assert.ErrorContains(t, err, "unknown tunnel")
+}
+
+func TestStartTunnel(t *testing.T) {
+	binDir := t.TempDir()
+	// The script keeps its own name, which StopTunnel checks before killing it
+	script := "#!/bin/sh\necho '{\"msg\":\"started tunnel\",\"url\":\"https://abc.ngrok-free.app\"}'\nwhile :; do sleep 1; done\n"
+	require.NoError(t, os.WriteFile(filepath.Join(binDir, "ngrok"), []byte(script), 0755))
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	original := tunnelStartTimeout
+	t.Cleanup(func() { tunnelStartTimeout = original })
+	tunnelStartTimeout = 5 * time.Second
+
+	worktreePath := t.TempDir()
+	barePath := t.TempDir()
+
+	publicURL, err := StartTunnel(barePath, worktreePath, "ngrok", "https://feature.test")
+	require.NoError(t, err)
+	assert.Equal(t, "https://abc.ngrok-free.app", publicURL)
+
+	wtConfig, err := config.ReadWorktreeConfig(worktreePath)
+	require.NoError(t, err)
+	assert.Equal(t, publicURL, wtConfig.PublicURL)
+	assert.NotEmpty(t, wtConfig.TunnelPID)
+	assert.FileExists(t, tunnelPIDPath(barePath, worktreePath))
+
+	stopped, err := StopTunnel(barePath, worktreePath)
+	require.NoError(t, err)
+	assert.True(t, stopped)
+
+	wtConfig, err = config.ReadWorktreeConfig(worktreePath)
+	require.NoError(t, err)
+	assert.Empty(t, wtConfig.PublicURL)
+	assert.Empty(t, wtConfig.TunnelPID)
+	assert.NoFileExists(t, tunnelPIDPath(barePath, worktreePath))
+}
+
+func TestStopTunnel_LeavesOtherProcesses(t *testing.T) {
+	sleeper := exec.Command("sleep", "30")
+	require.NoError(t, sleeper.Start())
+	t.Cleanup(func() {
+		require.NoError(t, sleeper.Process.Kill())
+		require.Error(t, sleeper.Wait(), "killed by the cleanup, not by StopTunnel")
+	})
+
+	worktreePath := t.TempDir()
+	barePath := t.TempDir()
+	require.NoError(t, RecordTunnel(barePath, worktreePath, "ngrok", "https://abc.ngrok-free.app", sleeper.Process.Pid))
+
+	stopped, err := StopTunnel(barePath, worktreePath)
+	require.NoError(t, err)
+	assert.False(t, stopped, "the pid no longer belongs to ngrok")
+
+	wtConfig, err := config.ReadWorktreeConfig(worktreePath)
+	require.NoError(t, err)
+	assert.Empty(t, wtConfig.PublicURL)
+}
@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/michaeldyrynda/arbor/internal/logging"
 	"github.com/michaeldyrynda/arbor/internal/scaffold/types"
 )
 
@@ -30,9 +31,7 @@ func (s *FileCopyStep) Run(ctx *types.ScaffoldContext, opts types.StepOptions) e
 	fromPath := filepath.Join(ctx.WorktreePath, s.from)
 	toPath := filepath.Join(ctx.WorktreePath, s.to)
 
-	if opts.Verbose {
-		fmt.Printf("  Copying %s to %s\n", s.from, s.to)
-	}
+	logging.Verbosef("  Copying %s to %s", s.from, s.to)
 
 	data, err := os.ReadFile(fromPath)
 	if err != nil {
@@ -46,6 +45,11 @@ func (s *FileCopyStep) Run(ctx *types.ScaffoldContext, opts types.StepOptions) e
 	return nil
 }
 
+// Plan returns the file the step would write
+func (s *FileCopyStep) Plan(ctx *types.ScaffoldContext, opts types.StepOptions) (types.StepPlan, error) {
+	return types.StepPlan{Files: []string{s.to}, Detail: fmt.Sprintf("copied from %s", s.from)}, nil
+}
+
 func (s *FileCopyStep) Priority() int {
 	return s.priority
 }
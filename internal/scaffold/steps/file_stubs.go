@@ -0,0 +1,141 @@
+package steps
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/michaeldyrynda/arbor/internal/config"
+	"github.com/michaeldyrynda/arbor/internal/logging"
+	"github.com/michaeldyrynda/arbor/internal/scaffold/template"
+	"github.com/michaeldyrynda/arbor/internal/scaffold/types"
+)
+
+// DefaultStubsDir is the directory file.stubs copies from when it names none
+const DefaultStubsDir = config.RepoDir + "/" + config.RepoStubsDir
+
+// stubTemplateExt marks stubs rendered as templates. Other stubs are copied
+// as they are, so files with their own {{ }} syntax are left alone.
+const stubTemplateExt = ".tmpl"
+
+// FileStubsStep copies each file under a stubs directory to the same path in
+// the worktree, unless the worktree already has it
+type FileStubsStep struct {
+	from     string
+	priority int
+}
+
+func NewFileStubsStep(from string, priority int) *FileStubsStep {
+	if from == "" {
+		from = DefaultStubsDir
+	}
+	return &FileStubsStep{from: from, priority: priority}
+}
+
+func (s *FileStubsStep) Name() string {
+	return "file.stubs"
+}
+
+func (s *FileStubsStep) Run(ctx *types.ScaffoldContext, opts types.StepOptions) error {
+	stubs, err := s.pending(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, stub := range stubs {
+		data, err := os.ReadFile(stub.source)
+		if err != nil {
+			return fmt.Errorf("reading stub %s: %w", stub.source, err)
+		}
+		if stub.template {
+			rendered, err := template.ReplaceTemplateVars(string(data), ctx)
+			if err != nil {
+				return fmt.Errorf("rendering stub %s: %w", stub.target, err)
+			}
+			data = []byte(rendered)
+		}
+
+		targetPath := filepath.Join(ctx.WorktreePath, stub.target)
+		if err := os.MkdirAll(filepath.Dir(targetPath), 0755); err != nil {
+			return fmt.Errorf("creating directory for %s: %w", stub.target, err)
+		}
+		if err := os.WriteFile(targetPath, data, stub.mode); err != nil {
+			return fmt.Errorf("writing %s: %w", stub.target, err)
+		}
+		logging.Verbosef("  Created %s from %s", stub.target, s.from)
+	}
+	return nil
+}
+
+// Plan returns the files the step would create
+func (s *FileStubsStep) Plan(ctx *types.ScaffoldContext, opts types.StepOptions) (types.StepPlan, error) {
+	stubs, err := s.pending(ctx)
+	if err != nil {
+		return types.StepPlan{}, err
+	}
+
+	plan := types.StepPlan{Detail: fmt.Sprintf("copied from %s", s.from)}
+	for _, stub := range stubs {
+		plan.Files = append(plan.Files, stub.target)
+	}
+	return plan, nil
+}
+
+func (s *FileStubsStep) Priority() int {
+	return s.priority
+}
+
+func (s *FileStubsStep) Condition(ctx *types.ScaffoldContext) bool {
+	info, err := os.Stat(filepath.Join(ctx.WorktreePath, s.from))
+	return err == nil && info.IsDir()
+}
+
+type stubFile struct {
+	source   string
+	target   string
+	template bool
+	mode     fs.FileMode
+}
+
+// pending lists the stubs whose targets the worktree does not have yet
+func (s *FileStubsStep) pending(ctx *types.ScaffoldContext) ([]stubFile, error) {
+	root := filepath.Join(ctx.WorktreePath, s.from)
+
+	var stubs []stubFile
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		stub := stubFile{source: path, target: rel, template: strings.HasSuffix(rel, stubTemplateExt)}
+		if stub.template {
+			stub.target = strings.TrimSuffix(rel, stubTemplateExt)
+		}
+
+		if _, err := os.Stat(filepath.Join(ctx.WorktreePath, stub.target)); err == nil {
+			logging.Verbosef("  Skipping %s, it already exists", stub.target)
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		stub.mode = info.Mode().Perm()
+		stubs = append(stubs, stub)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reading stubs in %s: %w", s.from, err)
+	}
+	return stubs, nil
+}
@@ -0,0 +1,63 @@
+package steps
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/michaeldyrynda/arbor/internal/scaffold/types"
+)
+
+func writeStub(t *testing.T, worktree, name, content string) {
+	t.Helper()
+	path := filepath.Join(worktree, DefaultStubsDir, name)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+}
+
+func TestFileStubsStep(t *testing.T) {
+	t.Run("copies stubs, rendering templates", func(t *testing.T) {
+		worktree := t.TempDir()
+		writeStub(t, worktree, "config/local.php", "<?php return ['debug' => '{{ raw }}'];\n")
+		writeStub(t, worktree, ".vscode/settings.json.tmpl", `{"site": "{{ .SiteName }}"}`)
+
+		step := NewFileStubsStep("", PriorityFileCopy)
+		ctx := &types.ScaffoldContext{WorktreePath: worktree, SiteName: "shop"}
+		require.True(t, step.Condition(ctx))
+		require.NoError(t, step.Run(ctx, types.StepOptions{}))
+
+		copied, err := os.ReadFile(filepath.Join(worktree, "config", "local.php"))
+		require.NoError(t, err)
+		assert.Contains(t, string(copied), "{{ raw }}", "stubs without .tmpl are copied as they are")
+
+		rendered, err := os.ReadFile(filepath.Join(worktree, ".vscode", "settings.json"))
+		require.NoError(t, err)
+		assert.Equal(t, `{"site": "shop"}`, string(rendered))
+	})
+
+	t.Run("keeps files the worktree already has", func(t *testing.T) {
+		worktree := t.TempDir()
+		writeStub(t, worktree, "notes.md", "stub")
+		require.NoError(t, os.WriteFile(filepath.Join(worktree, "notes.md"), []byte("mine"), 0644))
+
+		step := NewFileStubsStep("", PriorityFileCopy)
+		ctx := &types.ScaffoldContext{WorktreePath: worktree}
+
+		plan, err := step.Plan(ctx, types.StepOptions{})
+		require.NoError(t, err)
+		assert.Empty(t, plan.Files)
+
+		require.NoError(t, step.Run(ctx, types.StepOptions{}))
+		data, err := os.ReadFile(filepath.Join(worktree, "notes.md"))
+		require.NoError(t, err)
+		assert.Equal(t, "mine", string(data))
+	})
+
+	t.Run("skipped without a stubs directory", func(t *testing.T) {
+		step := NewFileStubsStep("", PriorityFileCopy)
+		assert.False(t, step.Condition(&types.ScaffoldContext{WorktreePath: t.TempDir()}))
+	})
+}
@@ -0,0 +1,99 @@
+package steps
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/michaeldyrynda/arbor/internal/config"
+	"github.com/michaeldyrynda/arbor/internal/git"
+	"github.com/michaeldyrynda/arbor/internal/logging"
+	"github.com/michaeldyrynda/arbor/internal/scaffold/template"
+	"github.com/michaeldyrynda/arbor/internal/scaffold/types"
+)
+
+// GitConfigStep sets git config for the worktree alone, such as the
+// user.email to commit with, a signing key or pull.rebase
+type GitConfigStep struct {
+	settings map[string]string
+	priority int
+}
+
+// NewGitConfigStep sets the step's settings, and key to value when a key is
+// given
+func NewGitConfigStep(cfg config.StepConfig, priority int) *GitConfigStep {
+	settings := make(map[string]string, len(cfg.Settings)+1)
+	for key, value := range cfg.Settings {
+		settings[key] = value
+	}
+	if cfg.Key != "" {
+		settings[cfg.Key] = cfg.Value
+	}
+	return &GitConfigStep{settings: settings, priority: priority}
+}
+
+func (s *GitConfigStep) Name() string {
+	return "git.config"
+}
+
+func (s *GitConfigStep) Run(ctx *types.ScaffoldContext, opts types.StepOptions) error {
+	values, err := s.render(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := git.EnableWorktreeConfig(ctx.WorktreePath); err != nil {
+		return fmt.Errorf("enabling worktree config: %w", err)
+	}
+	for _, key := range s.keys() {
+		if err := git.SetWorktreeConfig(ctx.WorktreePath, key, values[key]); err != nil {
+			return err
+		}
+		logging.Verbosef("  Set %s for this worktree", key)
+	}
+	return nil
+}
+
+// Plan returns the settings the step would write
+func (s *GitConfigStep) Plan(ctx *types.ScaffoldContext, opts types.StepOptions) (types.StepPlan, error) {
+	values, err := s.render(ctx)
+	if err != nil {
+		return types.StepPlan{}, err
+	}
+
+	pairs := make([]string, 0, len(values))
+	for _, key := range s.keys() {
+		pairs = append(pairs, key+"="+values[key])
+	}
+	return types.StepPlan{Detail: strings.Join(pairs, ", "), Tools: []string{"git"}}, nil
+}
+
+func (s *GitConfigStep) Priority() int {
+	return s.priority
+}
+
+func (s *GitConfigStep) Condition(ctx *types.ScaffoldContext) bool {
+	return len(s.settings) > 0
+}
+
+func (s *GitConfigStep) keys() []string {
+	keys := make([]string, 0, len(s.settings))
+	for key := range s.settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// render resolves the templates in the settings' values
+func (s *GitConfigStep) render(ctx *types.ScaffoldContext) (map[string]string, error) {
+	values := make(map[string]string, len(s.settings))
+	for key, value := range s.settings {
+		rendered, err := template.ReplaceTemplateVars(value, ctx)
+		if err != nil {
+			return nil, fmt.Errorf("rendering %s: %w", key, err)
+		}
+		values[key] = rendered
+	}
+	return values, nil
+}
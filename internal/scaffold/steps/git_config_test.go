@@ -0,0 +1,63 @@
+package steps
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/michaeldyrynda/arbor/internal/config"
+	"github.com/michaeldyrynda/arbor/internal/scaffold/types"
+)
+
+func TestGitConfigStep(t *testing.T) {
+	t.Run("sets settings for the worktree only", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		barePath := filepath.Join(tmpDir, ".bare")
+		worktreePath := filepath.Join(tmpDir, "feature")
+		repoPath := filepath.Join(tmpDir, "repo")
+		gitCmd(t, tmpDir, "init", "-b", "main", repoPath)
+		gitCmd(t, repoPath, "-c", "user.name=Test", "-c", "user.email=test@example.com", "commit", "--allow-empty", "-m", "Initial commit")
+		gitCmd(t, tmpDir, "clone", "--bare", repoPath, barePath)
+		gitCmd(t, barePath, "worktree", "add", "-b", "feature", worktreePath)
+
+		step := NewGitConfigStep(config.StepConfig{
+			Settings: map[string]string{"user.email": "{{ .Vars.email }}", "pull.rebase": "true"},
+			Key:      "commit.gpgsign",
+			Value:    "true",
+		}, PriorityGitConfig)
+		ctx := &types.ScaffoldContext{WorktreePath: worktreePath, Vars: map[string]string{"email": "me@work.test"}}
+
+		assert.True(t, step.Condition(ctx))
+		require.NoError(t, step.Run(ctx, types.StepOptions{}))
+
+		assert.Equal(t, "me@work.test", gitCmd(t, worktreePath, "config", "--worktree", "--get", "user.email"))
+		assert.Equal(t, "true", gitCmd(t, worktreePath, "config", "--worktree", "--get", "pull.rebase"))
+		assert.Equal(t, "true", gitCmd(t, worktreePath, "config", "--worktree", "--get", "commit.gpgsign"))
+	})
+
+	t.Run("plans the rendered settings", func(t *testing.T) {
+		step := NewGitConfigStep(config.StepConfig{Settings: map[string]string{"user.email": "{{ .Vars.email }}", "pull.rebase": "true"}}, PriorityGitConfig)
+
+		plan, err := step.Plan(&types.ScaffoldContext{Vars: map[string]string{"email": "me@work.test"}}, types.StepOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, "pull.rebase=true, user.email=me@work.test", plan.Detail)
+	})
+
+	t.Run("skipped without settings", func(t *testing.T) {
+		step := NewGitConfigStep(config.StepConfig{}, PriorityGitConfig)
+		assert.False(t, step.Condition(&types.ScaffoldContext{}))
+	})
+}
+
+func gitCmd(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(output))
+	return strings.TrimSpace(string(output))
+}
@@ -0,0 +1,57 @@
+package steps
+
+import (
+	"fmt"
+
+	"github.com/michaeldyrynda/arbor/internal/config"
+	"github.com/michaeldyrynda/arbor/internal/logging"
+	"github.com/michaeldyrynda/arbor/internal/scaffold/template"
+	"github.com/michaeldyrynda/arbor/internal/scaffold/types"
+	"github.com/michaeldyrynda/arbor/internal/utils"
+)
+
+type NotifyStep struct {
+	title    string
+	message  string
+	priority int
+}
+
+func NewNotifyStep(cfg config.StepConfig, priority int) *NotifyStep {
+	title := cfg.Title
+	if title == "" {
+		title = "arbor"
+	}
+	return &NotifyStep{title: title, message: cfg.Message, priority: priority}
+}
+
+func (s *NotifyStep) Name() string {
+	return "notify"
+}
+
+// Run sends the notification through the context's interaction. A
+// notification that cannot be delivered, e.g. because notify-send is not
+// installed, does not fail the scaffold.
+func (s *NotifyStep) Run(ctx *types.ScaffoldContext, opts types.StepOptions) error {
+	title, err := template.ReplaceTemplateVars(s.title, ctx)
+	if err != nil {
+		return fmt.Errorf("template replacement failed: %w", err)
+	}
+
+	message, err := template.ReplaceTemplateVars(s.message, ctx)
+	if err != nil {
+		return fmt.Errorf("template replacement failed: %w", err)
+	}
+
+	if err := ctx.Interaction.Notify(title, message); err != nil {
+		logging.Verbosef("  Notification not sent: %v", err)
+	}
+	return nil
+}
+
+func (s *NotifyStep) Priority() int {
+	return s.priority
+}
+
+func (s *NotifyStep) Condition(ctx *types.ScaffoldContext) bool {
+	return ctx.Interaction.Notify != nil && s.message != "" && !utils.IsCI()
+}
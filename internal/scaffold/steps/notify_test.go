@@ -0,0 +1,58 @@
+package steps
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/michaeldyrynda/arbor/internal/config"
+	"github.com/michaeldyrynda/arbor/internal/scaffold/types"
+)
+
+// stubNotifier returns an interaction that records the notifications sent
+// through it, failing with err
+func stubNotifier(err error) (types.Interaction, *[]string) {
+	var sent []string
+	interaction := types.Interaction{Notify: func(title, message string) error {
+		sent = append(sent, title+": "+message)
+		return err
+	}}
+	return interaction, &sent
+}
+
+func TestNotifyStep(t *testing.T) {
+	t.Setenv("CI", "")
+
+	t.Run("renders the title and message", func(t *testing.T) {
+		interaction, sent := stubNotifier(nil)
+		step := NewNotifyStep(config.StepConfig{Title: "{{ .SiteName }}", Message: "{{ .Branch }} is ready"}, 110)
+		ctx := &types.ScaffoldContext{WorktreePath: t.TempDir(), SiteName: "myapp", Branch: "feature/login", Interaction: interaction}
+
+		assert.True(t, step.Condition(ctx))
+		require.NoError(t, step.Run(ctx, types.StepOptions{}))
+		assert.Equal(t, []string{"myapp: feature/login is ready"}, *sent)
+	})
+
+	t.Run("defaults the title", func(t *testing.T) {
+		interaction, sent := stubNotifier(nil)
+		step := NewNotifyStep(config.StepConfig{Message: "done"}, 110)
+
+		require.NoError(t, step.Run(&types.ScaffoldContext{Interaction: interaction}, types.StepOptions{}))
+		assert.Equal(t, []string{"arbor: done"}, *sent)
+	})
+
+	t.Run("delivery failures do not fail the scaffold", func(t *testing.T) {
+		interaction, _ := stubNotifier(errors.New("notify-send not found"))
+		step := NewNotifyStep(config.StepConfig{Message: "done"}, 110)
+
+		assert.NoError(t, step.Run(&types.ScaffoldContext{Interaction: interaction}, types.StepOptions{}))
+	})
+
+	t.Run("skipped without a notifier or message", func(t *testing.T) {
+		interaction, _ := stubNotifier(nil)
+		assert.False(t, NewNotifyStep(config.StepConfig{}, 110).Condition(&types.ScaffoldContext{Interaction: interaction}))
+		assert.False(t, NewNotifyStep(config.StepConfig{Message: "done"}, 110).Condition(&types.ScaffoldContext{}))
+	})
+}
@@ -0,0 +1,87 @@
+package steps
+
+import (
+	"sort"
+
+	"github.com/michaeldyrynda/arbor/internal/config"
+)
+
+// Default priorities of the built-in steps. Steps run in ascending priority
+// and steps sharing a priority run in parallel, so these decide both order and
+// what may overlap. Steps listing priority among their fields can be moved
+// with the priority key; the others always run at their default.
+const (
+	// PriorityEnv is for env.read, env.write and db.destroy, which run before
+	// anything else so later templates see their values
+	PriorityEnv = 0
+	// PriorityGitConfig is for git.config, set before any step commits
+	PriorityGitConfig = 1
+	// PriorityRuntime is for language runtimes such as php
+	PriorityRuntime = 5
+	// PriorityDatabase is for db.create
+	PriorityDatabase = 8
+	// PriorityFileCopy is for file.copy and file.stubs
+	PriorityFileCopy = 9
+	// PriorityDependencies is for package managers
+	PriorityDependencies = 10
+	// PriorityContainers is for docker.compose, started once dependencies
+	// are installed
+	PriorityContainers = 11
+	// PriorityVite is for node.vite, once .env has been copied into place
+	PriorityVite = 12
+	// PriorityEnvSync is for env.sync, alone so its prompts do not interleave
+	// with other steps' output, and before builds read .env
+	PriorityEnvSync = 13
+	// PriorityBuild is for asset builds that need dependencies installed
+	PriorityBuild = 15
+	// PriorityFramework is for framework commands such as artisan
+	PriorityFramework = 20
+	// PriorityServe is for local web servers such as herd
+	PriorityServe = 60
+	// PriorityCommand is for bash.run and command.run
+	PriorityCommand = 100
+	// PriorityDirenv is for direnv, once .env is final
+	PriorityDirenv = 105
+	// PriorityDevcontainer is for devcontainer
+	PriorityDevcontainer = 107
+	// PriorityExpose is for expose, once the site is served
+	PriorityExpose = 108
+	// PriorityNotify is for notify, which runs last
+	PriorityNotify = 110
+)
+
+// StepInfo describes a registered step for preset authors
+type StepInfo struct {
+	Name     string `json:"name"`
+	Priority int    `json:"priority"`
+	// Fields are the config keys the step reads, besides name, enabled,
+	// condition and inputs, which every step accepts
+	Fields []string `json:"fields"`
+}
+
+// Steps returns every registered step in the order they run by default,
+// then by name
+func Steps() []StepInfo {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	infos := make([]StepInfo, 0, len(registry))
+	for _, r := range registry {
+		infos = append(infos, r.info)
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		if infos[i].Priority != infos[j].Priority {
+			return infos[i].Priority < infos[j].Priority
+		}
+		return infos[i].Name < infos[j].Name
+	})
+	return infos
+}
+
+// priorityOr returns the step's configured priority, or def when it sets none
+func priorityOr(cfg config.StepConfig, def int) int {
+	if cfg.Priority != 0 {
+		return cfg.Priority
+	}
+	return def
+}
@@ -0,0 +1,21 @@
+//go:build !windows
+
+package steps
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/michaeldyrynda/arbor/internal/logging"
+)
+
+// processName returns the command name of the running process pid
+func processName(pid int) (string, error) {
+	output, err := logging.Output(exec.Command("ps", "-o", "comm=", "-p", strconv.Itoa(pid)))
+	if err != nil {
+		return "", fmt.Errorf("process %d is not running", pid)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
@@ -0,0 +1,25 @@
+//go:build windows
+
+package steps
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/michaeldyrynda/arbor/internal/logging"
+)
+
+// processName returns the image name of the running process pid
+func processName(pid int) (string, error) {
+	output, err := logging.Output(exec.Command("tasklist", "/FI", fmt.Sprintf("PID eq %d", pid), "/FO", "CSV", "/NH"))
+	if err != nil {
+		return "", err
+	}
+	record, err := csv.NewReader(strings.NewReader(string(output))).Read()
+	if err != nil || len(record) < 2 {
+		return "", fmt.Errorf("process %d is not running", pid)
+	}
+	return record[0], nil
+}
@@ -1,25 +1,131 @@
 package steps
 
 import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
 	"github.com/michaeldyrynda/arbor/internal/config"
+	arborerrors "github.com/michaeldyrynda/arbor/internal/errors"
+	"github.com/michaeldyrynda/arbor/internal/logging"
 	"github.com/michaeldyrynda/arbor/internal/scaffold/types"
 )
 
 type StepFactory func(cfg config.StepConfig) types.ScaffoldStep
 
-var registry = make(map[string]StepFactory)
+type registration struct {
+	info    StepInfo
+	factory StepFactory
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]registration)
+	// warnedDeprecated records the deprecated names already warned about
+	warnedDeprecated = make(map[string]bool)
+)
 
-func Register(name string, factory StepFactory) {
-	registry[name] = factory
+// deprecatedStepNames maps step names from older releases to the steps that
+// replaced them. They keep working, with a warning, until arbor config
+// migrate renames them.
+var deprecatedStepNames = map[string]string{
+	"database.create":  "db.create",
+	"database.destroy": "db.destroy",
 }
 
-func Create(name string, cfg config.StepConfig) types.ScaffoldStep {
-	if factory, ok := registry[name]; ok {
-		return factory(cfg)
+// Register adds a step under info.Name, with the default priority and config
+// fields listed by arbor steps list. Registering a name twice is an error.
+func Register(info StepInfo, factory StepFactory) error {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if _, ok := registry[info.Name]; ok {
+		return fmt.Errorf("step type %q is already registered", info.Name)
 	}
+	registry[info.Name] = registration{info: info, factory: factory}
 	return nil
 }
 
+// RegisterStepType adds a step type for presets, plugins and tests. Its
+// default priority is that of the step the factory builds from an empty
+// config.
+func RegisterStepType(name string, factory StepFactory) error {
+	return Register(StepInfo{Name: name, Priority: factory(config.StepConfig{}).Priority()}, factory)
+}
+
+// UnregisterStepType removes a step type, so tests can clean up after
+// RegisterStepType
+func UnregisterStepType(name string) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	delete(registry, name)
+}
+
+// mustRegister registers a built-in step, which cannot clash
+func mustRegister(info StepInfo, factory StepFactory) {
+	if err := Register(info, factory); err != nil {
+		panic(err)
+	}
+}
+
+// Create returns the step registered under name, or nil when there is none.
+// A deprecated name creates the step that replaced it.
+func Create(name string, cfg config.StepConfig) types.ScaffoldStep {
+	if replacement, ok := deprecatedStepNames[name]; ok {
+		warnDeprecated(name, replacement)
+		name = replacement
+	}
+
+	registryMu.RLock()
+	r, ok := registry[name]
+	registryMu.RUnlock()
+
+	if !ok {
+		return nil
+	}
+	return r.factory(cfg)
+}
+
+// warnDeprecated warns once per run that a deprecated step name is in use
+func warnDeprecated(name, replacement string) {
+	registryMu.Lock()
+	warned := warnedDeprecated[name]
+	warnedDeprecated[name] = true
+	registryMu.Unlock()
+
+	if !warned {
+		logging.Infof("warning: step %q is deprecated, use %q instead (arbor config migrate renames it)", name, replacement)
+	}
+}
+
+// CreateStep returns the step registered under name, or a configuration
+// error listing the known step types
+func CreateStep(name string, cfg config.StepConfig) (types.ScaffoldStep, error) {
+	if step := Create(name, cfg); step != nil {
+		return step, nil
+	}
+	return nil, arborerrors.Wrap(arborerrors.ErrInvalidConfig,
+		fmt.Errorf("unknown step type %q; known types are %s", name, strings.Join(Names(), ", ")))
+}
+
+// Names returns every registered step name, and the deprecated names that
+// still work, sorted
+func Names() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	names := make([]string, 0, len(registry)+len(deprecatedStepNames))
+	for name := range registry {
+		names = append(names, name)
+	}
+	for name := range deprecatedStepNames {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 type binaryDefinition struct {
 	name     string
 	binary   string
@@ -27,14 +133,14 @@ type binaryDefinition struct {
 }
 
 var binaries = []binaryDefinition{
-	{"php", "php", 5},
-	{"php.composer", "composer", 10},
-	{"php.laravel.artisan", "php artisan", 20},
-	{"node.npm", "npm", 10},
-	{"node.yarn", "yarn", 10},
-	{"node.pnpm", "pnpm", 10},
-	{"node.bun", "bun", 10},
-	{"herd", "herd", 60},
+	{"php", "php", PriorityRuntime},
+	{"php.composer", "composer", PriorityDependencies},
+	{"php.laravel.artisan", "php artisan", PriorityFramework},
+	{"node.npm", "npm", PriorityDependencies},
+	{"node.yarn", "yarn", PriorityDependencies},
+	{"node.pnpm", "pnpm", PriorityDependencies},
+	{"node.bun", "bun", PriorityDependencies},
+	{"herd", "herd", PriorityServe},
 }
 
 func init() {
@@ -42,42 +148,57 @@ func init() {
 		name := b.name
 		binary := b.binary
 		defaultPriority := b.priority
-		Register(name, func(cfg config.StepConfig) types.ScaffoldStep {
-			priority := defaultPriority
-			if cfg.Priority != 0 {
-				priority = cfg.Priority
-			}
-			return NewBinaryStepWithCondition(name, cfg, binary, priority)
+		mustRegister(StepInfo{Name: name, Priority: defaultPriority, Fields: []string{"args", "env", "dir", "capture_as", "priority"}}, func(cfg config.StepConfig) types.ScaffoldStep {
+			return NewBinaryStepWithCondition(name, cfg, binary, priorityOr(cfg, defaultPriority))
 		})
 	}
 
-	Register("file.copy", func(cfg config.StepConfig) types.ScaffoldStep {
-		priority := 9
-		if cfg.Priority != 0 {
-			priority = cfg.Priority
-		}
-		return NewFileCopyStep(cfg.From, cfg.To, priority)
+	mustRegister(StepInfo{Name: "file.copy", Priority: PriorityFileCopy, Fields: []string{"from", "to", "priority"}}, func(cfg config.StepConfig) types.ScaffoldStep {
+		return NewFileCopyStep(cfg.From, cfg.To, priorityOr(cfg, PriorityFileCopy))
+	})
+	mustRegister(StepInfo{Name: "file.stubs", Priority: PriorityFileCopy, Fields: []string{"from", "priority"}}, func(cfg config.StepConfig) types.ScaffoldStep {
+		return NewFileStubsStep(cfg.From, priorityOr(cfg, PriorityFileCopy))
 	})
-	Register("bash.run", func(cfg config.StepConfig) types.ScaffoldStep {
-		return NewBashRunStep(cfg.Command)
+	mustRegister(StepInfo{Name: "bash.run", Priority: PriorityCommand, Fields: []string{"command", "env", "dir", "capture_as"}}, func(cfg config.StepConfig) types.ScaffoldStep {
+		return NewBashRunStepWithConfig(cfg)
 	})
-	Register("command.run", func(cfg config.StepConfig) types.ScaffoldStep {
-		return NewCommandRunStep(cfg.Command)
+	mustRegister(StepInfo{Name: "command.run", Priority: PriorityCommand, Fields: []string{"command", "env", "dir", "capture_as"}}, func(cfg config.StepConfig) types.ScaffoldStep {
+		return NewCommandRunStepWithConfig(cfg)
 	})
-	Register("env.read", func(cfg config.StepConfig) types.ScaffoldStep {
+	mustRegister(StepInfo{Name: "env.read", Priority: PriorityEnv, Fields: []string{"key", "store_as", "file"}}, func(cfg config.StepConfig) types.ScaffoldStep {
 		return NewEnvReadStep(cfg)
 	})
-	Register("env.write", func(cfg config.StepConfig) types.ScaffoldStep {
+	mustRegister(StepInfo{Name: "env.write", Priority: PriorityEnv, Fields: []string{"key", "value", "file", "type"}}, func(cfg config.StepConfig) types.ScaffoldStep {
 		return NewEnvWriteStep(cfg)
 	})
-	Register("db.create", func(cfg config.StepConfig) types.ScaffoldStep {
-		priority := 8
-		if cfg.Priority != 0 {
-			priority = cfg.Priority
-		}
-		return NewDbCreateStep(cfg, priority)
+	mustRegister(StepInfo{Name: "env.sync", Priority: PriorityEnvSync, Fields: []string{"from", "file", "priority"}}, func(cfg config.StepConfig) types.ScaffoldStep {
+		return NewEnvSyncStep(cfg, priorityOr(cfg, PriorityEnvSync))
+	})
+	mustRegister(StepInfo{Name: "db.create", Priority: PriorityDatabase, Fields: []string{"type", "args", "priority"}}, func(cfg config.StepConfig) types.ScaffoldStep {
+		return NewDbCreateStep(cfg, priorityOr(cfg, PriorityDatabase))
+	})
+	mustRegister(StepInfo{Name: "notify", Priority: PriorityNotify, Fields: []string{"title", "message", "priority"}}, func(cfg config.StepConfig) types.ScaffoldStep {
+		return NewNotifyStep(cfg, priorityOr(cfg, PriorityNotify))
+	})
+	mustRegister(StepInfo{Name: "direnv", Priority: PriorityDirenv, Fields: []string{"keys", "paths", "priority"}}, func(cfg config.StepConfig) types.ScaffoldStep {
+		return NewDirenvStep(cfg, priorityOr(cfg, PriorityDirenv))
+	})
+	mustRegister(StepInfo{Name: "docker.compose", Priority: PriorityContainers, Fields: []string{"file", "priority"}}, func(cfg config.StepConfig) types.ScaffoldStep {
+		return NewDockerComposeStep(cfg, priorityOr(cfg, PriorityContainers))
+	})
+	mustRegister(StepInfo{Name: "expose", Priority: PriorityExpose, Fields: []string{"type", "priority"}}, func(cfg config.StepConfig) types.ScaffoldStep {
+		return NewExposeStep(cfg, priorityOr(cfg, PriorityExpose))
+	})
+	mustRegister(StepInfo{Name: "node.vite", Priority: PriorityVite, Fields: []string{"ports", "file", "priority"}}, func(cfg config.StepConfig) types.ScaffoldStep {
+		return NewViteStep(cfg, priorityOr(cfg, PriorityVite))
+	})
+	mustRegister(StepInfo{Name: "devcontainer", Priority: PriorityDevcontainer, Fields: []string{"image", "ports", "mounts", "priority"}}, func(cfg config.StepConfig) types.ScaffoldStep {
+		return NewDevcontainerStep(cfg, priorityOr(cfg, PriorityDevcontainer))
+	})
+	mustRegister(StepInfo{Name: "git.config", Priority: PriorityGitConfig, Fields: []string{"settings", "key", "value", "priority"}}, func(cfg config.StepConfig) types.ScaffoldStep {
+		return NewGitConfigStep(cfg, priorityOr(cfg, PriorityGitConfig))
 	})
-	Register("db.destroy", func(cfg config.StepConfig) types.ScaffoldStep {
+	mustRegister(StepInfo{Name: "db.destroy", Priority: PriorityEnv, Fields: []string{"type", "args"}}, func(cfg config.StepConfig) types.ScaffoldStep {
 		return NewDbDestroyStep(cfg)
 	})
 }
@@ -4,8 +4,11 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/michaeldyrynda/arbor/internal/config"
+	arborerrors "github.com/michaeldyrynda/arbor/internal/errors"
+	"github.com/michaeldyrynda/arbor/internal/scaffold/types"
 )
 
 func TestRegistry_StepRegistration(t *testing.T) {
@@ -100,3 +103,68 @@ func TestRegistry_StepRegistration(t *testing.T) {
 		}
 	})
 }
+
+func TestSteps(t *testing.T) {
+	infos := Steps()
+	assert.Len(t, infos, len(Names())-len(deprecatedStepNames), "deprecated names are not listed")
+
+	for i := 1; i < len(infos); i++ {
+		assert.LessOrEqual(t, infos[i-1].Priority, infos[i].Priority, "steps are listed in run order")
+	}
+
+	for _, info := range infos {
+		step := Create(info.Name, config.StepConfig{})
+		assert.Equal(t, info.Priority, step.Priority(), "%s runs at its listed default priority", info.Name)
+	}
+
+	for _, info := range infos {
+		if info.Name == "env.sync" {
+			assert.Equal(t, []string{"from", "file", "priority"}, info.Fields)
+		}
+	}
+}
+
+func TestRegisterStepType(t *testing.T) {
+	factory := func(cfg config.StepConfig) types.ScaffoldStep {
+		return NewBashRunStep(cfg.Command)
+	}
+
+	require.NoError(t, RegisterStepType("plugin.run", factory))
+	t.Cleanup(func() { UnregisterStepType("plugin.run") })
+
+	step, err := CreateStep("plugin.run", config.StepConfig{Command: "echo hi"})
+	require.NoError(t, err)
+	assert.Equal(t, "bash.run", step.Name())
+	assert.Contains(t, Names(), "plugin.run")
+
+	for _, info := range Steps() {
+		if info.Name == "plugin.run" {
+			assert.Equal(t, PriorityCommand, info.Priority, "default priority comes from the step")
+		}
+	}
+
+	err = RegisterStepType("plugin.run", factory)
+	assert.ErrorContains(t, err, `step type "plugin.run" is already registered`)
+	assert.Error(t, RegisterStepType("bash.run", factory), "built-in steps cannot be replaced")
+}
+
+func TestCreateStep_UnknownType(t *testing.T) {
+	step, err := CreateStep("php.composr", config.StepConfig{})
+	assert.Nil(t, step)
+	assert.ErrorIs(t, err, arborerrors.ErrInvalidConfig)
+	assert.ErrorContains(t, err, `unknown step type "php.composr"; known types are `)
+	assert.ErrorContains(t, err, "php.composer")
+}
+
+func TestCreate_DeprecatedNames(t *testing.T) {
+	step := Create("database.create", config.StepConfig{Args: []string{"--prefix", "app"}})
+	require.NotNil(t, step)
+	assert.Equal(t, "db.create", step.Name())
+	assert.Equal(t, PriorityDatabase, step.Priority())
+
+	step = Create("database.destroy", config.StepConfig{})
+	require.NotNil(t, step)
+	assert.Equal(t, "db.destroy", step.Name())
+
+	assert.Contains(t, Names(), "database.create", "deprecated names still validate")
+}
@@ -0,0 +1,54 @@
+package steps
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/michaeldyrynda/arbor/internal/logging"
+	"github.com/michaeldyrynda/arbor/internal/scaffold/types"
+)
+
+// runStepCommand runs a step's command and returns its output. With
+// captureAs set, the trimmed stdout is stored as that variable for later
+// steps' templates, and only stderr is returned.
+func runStepCommand(cmd *exec.Cmd, captureAs string, ctx *types.ScaffoldContext) ([]byte, error) {
+	if captureAs == "" {
+		return logging.CombinedOutput(cmd)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := logging.Run(cmd); err != nil {
+		return append(stderr.Bytes(), stdout.Bytes()...), err
+	}
+
+	value := strings.TrimSpace(stdout.String())
+	ctx.SetVar(captureAs, value)
+	logging.Verbosef("  Captured output as %s", captureAs)
+	return stderr.Bytes(), nil
+}
+
+// planCapture stands in for the output a step would capture, so later steps'
+// templates still render in a plan without running the command. It returns
+// the plan detail describing the capture.
+func planCapture(stepName, captureAs string, ctx *types.ScaffoldContext) string {
+	if captureAs == "" {
+		return ""
+	}
+	ctx.SetVar(captureAs, fmt.Sprintf("<output of %s>", stepName))
+	return "captures output as " + captureAs
+}
+
+// joinDetails joins the non-empty parts of a plan's detail
+func joinDetails(parts ...string) string {
+	var kept []string
+	for _, part := range parts {
+		if part != "" {
+			kept = append(kept, part)
+		}
+	}
+	return strings.Join(kept, ", ")
+}
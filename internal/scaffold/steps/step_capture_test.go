@@ -0,0 +1,67 @@
+package steps
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/michaeldyrynda/arbor/internal/config"
+	"github.com/michaeldyrynda/arbor/internal/scaffold/template"
+	"github.com/michaeldyrynda/arbor/internal/scaffold/types"
+)
+
+func TestCaptureAs(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+
+	t.Run("bash.run stores trimmed stdout", func(t *testing.T) {
+		ctx := &types.ScaffoldContext{WorktreePath: t.TempDir()}
+		step := Create("bash.run", config.StepConfig{
+			Command:   "echo '  base64:abc123  '; echo 'a warning' >&2",
+			CaptureAs: "AppKey",
+		})
+
+		require.NoError(t, step.Run(ctx, types.StepOptions{}))
+		assert.Equal(t, "base64:abc123", ctx.GetVar("AppKey"))
+
+		rendered, err := template.ReplaceTemplateVars("APP_KEY={{ .AppKey }}", ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "APP_KEY=base64:abc123", rendered)
+	})
+
+	t.Run("binary step stores trimmed stdout", func(t *testing.T) {
+		ctx := &types.ScaffoldContext{WorktreePath: t.TempDir()}
+		step := NewBinaryStepWithCondition("test.sh", config.StepConfig{
+			Args:      []string{"-c", "echo 8042"},
+			CaptureAs: "VitePort",
+		}, "sh", PriorityDependencies)
+
+		require.NoError(t, step.Run(ctx, types.StepOptions{}))
+		assert.Equal(t, "8042", ctx.GetVar("VitePort"))
+	})
+
+	t.Run("failure includes stderr and stores nothing", func(t *testing.T) {
+		ctx := &types.ScaffoldContext{WorktreePath: t.TempDir()}
+		step := Create("bash.run", config.StepConfig{Command: "echo partial; echo broken >&2; exit 1", CaptureAs: "Out"})
+
+		err := step.Run(ctx, types.StepOptions{})
+		assert.ErrorContains(t, err, "broken")
+		assert.Empty(t, ctx.GetVar("Out"))
+	})
+
+	t.Run("plan stands in for the output", func(t *testing.T) {
+		ctx := &types.ScaffoldContext{WorktreePath: t.TempDir()}
+		step := Create("php.laravel.artisan", config.StepConfig{
+			Args:      []string{"key:generate", "--show"},
+			CaptureAs: "AppKey",
+		})
+
+		plan, err := step.(types.Planner).Plan(ctx, types.StepOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, "captures output as AppKey", plan.Detail)
+		assert.Equal(t, "<output of php.laravel.artisan>", ctx.GetVar("AppKey"))
+	})
+}
@@ -0,0 +1,64 @@
+package steps
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/michaeldyrynda/arbor/internal/scaffold/template"
+	"github.com/michaeldyrynda/arbor/internal/scaffold/types"
+	"github.com/michaeldyrynda/arbor/internal/utils"
+)
+
+// stepDir returns the directory a step's command runs in: the worktree, or
+// the subdirectory named by the step's dir config after rendering it. A dir
+// that leads outside the worktree, directly or through a symlink, is an
+// error.
+func stepDir(stepName, dir string, ctx *types.ScaffoldContext) (string, error) {
+	if dir == "" {
+		return ctx.WorktreePath, nil
+	}
+
+	rendered, err := template.ReplaceTemplateVars(dir, ctx)
+	if err != nil {
+		return "", fmt.Errorf("%s: rendering dir: %w", stepName, err)
+	}
+	path, err := utils.ResolveSubdir(ctx.WorktreePath, rendered)
+	if err != nil {
+		return "", fmt.Errorf("%s: dir %w", stepName, err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil || !info.IsDir() {
+		return "", fmt.Errorf("%s: dir %s is not a directory in the worktree", stepName, rendered)
+	}
+
+	realRoot, err := filepath.EvalSymlinks(ctx.WorktreePath)
+	if err != nil {
+		return "", fmt.Errorf("%s: resolving worktree: %w", stepName, err)
+	}
+	realPath, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", fmt.Errorf("%s: resolving dir %s: %w", stepName, rendered, err)
+	}
+	rel, err := filepath.Rel(realRoot, realPath)
+	if err == nil {
+		_, err = utils.ResolveSubdir(realRoot, rel)
+	}
+	if err != nil {
+		return "", fmt.Errorf("%s: dir %s links outside the worktree", stepName, rendered)
+	}
+	return path, nil
+}
+
+// stepDirDetail describes where a step with dir config runs, for its plan.
+// It is empty when the step runs at the top of the worktree.
+func stepDirDetail(dir string, ctx *types.ScaffoldContext) string {
+	if dir == "" {
+		return ""
+	}
+	if rendered, err := template.ReplaceTemplateVars(dir, ctx); err == nil {
+		dir = rendered
+	}
+	return "runs in " + filepath.ToSlash(filepath.Clean(dir))
+}
@@ -0,0 +1,58 @@
+package steps
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/michaeldyrynda/arbor/internal/config"
+	"github.com/michaeldyrynda/arbor/internal/scaffold/types"
+)
+
+func TestStepDir(t *testing.T) {
+	worktree := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(worktree, "apps", "web"), 0755))
+	ctx := &types.ScaffoldContext{WorktreePath: worktree, SiteName: "web"}
+
+	dir, err := stepDir("node.npm", "", ctx)
+	require.NoError(t, err)
+	assert.Equal(t, worktree, dir)
+
+	dir, err = stepDir("node.npm", "apps/{{ .SiteName }}", ctx)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(worktree, "apps", "web"), dir)
+
+	_, err = stepDir("node.npm", "../elsewhere", ctx)
+	assert.ErrorContains(t, err, "outside the worktree")
+
+	_, err = stepDir("node.npm", "missing", ctx)
+	assert.ErrorContains(t, err, "is not a directory")
+
+	if runtime.GOOS != "windows" {
+		outside := t.TempDir()
+		require.NoError(t, os.Symlink(outside, filepath.Join(worktree, "link")))
+		_, err = stepDir("node.npm", "link", ctx)
+		assert.ErrorContains(t, err, "links outside the worktree")
+	}
+}
+
+func TestStepDir_CommandsRunInDir(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses bash")
+	}
+
+	worktree := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(worktree, "frontend"), 0755))
+	step := Create("bash.run", config.StepConfig{Command: "pwd > out.txt", Dir: "frontend"})
+
+	require.NoError(t, step.Run(&types.ScaffoldContext{WorktreePath: worktree}, types.StepOptions{}))
+	assert.FileExists(t, filepath.Join(worktree, "frontend", "out.txt"))
+
+	plan, err := step.(types.Planner).Plan(&types.ScaffoldContext{WorktreePath: worktree}, types.StepOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "runs in frontend", plan.Detail)
+}
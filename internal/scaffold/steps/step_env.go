@@ -0,0 +1,51 @@
+package steps
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/michaeldyrynda/arbor/internal/logging"
+	"github.com/michaeldyrynda/arbor/internal/scaffold/template"
+	"github.com/michaeldyrynda/arbor/internal/scaffold/types"
+)
+
+// renderStepEnv renders the values of a step's env config against the
+// context, returning NAME=value pairs sorted by name. Names are uppercased,
+// as viper lowercases config keys.
+func renderStepEnv(stepName string, env map[string]string, ctx *types.ScaffoldContext) ([]string, error) {
+	names := make([]string, 0, len(env))
+	for name := range env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pairs := make([]string, 0, len(env))
+	for _, name := range names {
+		value, err := template.ReplaceTemplateVars(env[name], ctx)
+		if err != nil {
+			return nil, fmt.Errorf("%s: rendering env %s: %w", stepName, name, err)
+		}
+		pairs = append(pairs, strings.ToUpper(name)+"="+value)
+	}
+	return pairs, nil
+}
+
+// stepCommandEnv returns the environment for a step's command: that of
+// commandEnv with the step's env added, overriding variables of the same name
+func stepCommandEnv(stepName string, env map[string]string, ctx *types.ScaffoldContext) ([]string, error) {
+	pairs, err := renderStepEnv(stepName, env, ctx)
+	if err != nil {
+		return nil, err
+	}
+	return append(commandEnv(ctx), pairs...), nil
+}
+
+// planCommand shows a command with the step's env as leading NAME=value
+// assignments, as it would be typed in a shell
+func planCommand(env []string, command string) string {
+	if len(env) == 0 {
+		return command
+	}
+	return logging.CommandLine(env) + " " + command
+}
@@ -0,0 +1,74 @@
+package steps
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/michaeldyrynda/arbor/internal/config"
+	"github.com/michaeldyrynda/arbor/internal/scaffold/types"
+)
+
+func TestRenderStepEnv(t *testing.T) {
+	ctx := &types.ScaffoldContext{SiteName: "myapp"}
+
+	env, err := renderStepEnv("bash.run", map[string]string{
+		"node_options":          "--max-old-space-size=4096",
+		"composer_memory_limit": "-1",
+		"app_name":              "{{ .SiteName }}",
+	}, ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"APP_NAME=myapp", "COMPOSER_MEMORY_LIMIT=-1", "NODE_OPTIONS=--max-old-space-size=4096"}, env,
+		"names are uppercased, as viper lowercases them, and sorted")
+
+	_, err = renderStepEnv("bash.run", map[string]string{"broken": "{{ .Missing }}"}, ctx)
+	assert.ErrorContains(t, err, "rendering env broken")
+}
+
+func TestStepEnv_PassedToCommands(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+
+	t.Run("bash.run", func(t *testing.T) {
+		dir := t.TempDir()
+		step := Create("bash.run", config.StepConfig{
+			Command: `echo "$COMPOSER_MEMORY_LIMIT $APP_NAME" > out.txt`,
+			Env:     map[string]string{"composer_memory_limit": "-1", "app_name": "{{ .SiteName }}"},
+		})
+
+		require.NoError(t, step.Run(&types.ScaffoldContext{WorktreePath: dir, SiteName: "myapp"}, types.StepOptions{}))
+		out, err := os.ReadFile(filepath.Join(dir, "out.txt"))
+		require.NoError(t, err)
+		assert.Equal(t, "-1 myapp\n", string(out))
+	})
+
+	t.Run("binary step", func(t *testing.T) {
+		dir := t.TempDir()
+		t.Setenv("NODE_OPTIONS", "--inherited")
+		step := NewBinaryStepWithCondition("test.sh", config.StepConfig{
+			Args: []string{"-c", `echo "$NODE_OPTIONS" > out.txt`},
+			Env:  map[string]string{"node_options": "--max-old-space-size=4096"},
+		}, "sh", PriorityDependencies)
+
+		require.NoError(t, step.Run(&types.ScaffoldContext{WorktreePath: dir}, types.StepOptions{}))
+		out, err := os.ReadFile(filepath.Join(dir, "out.txt"))
+		require.NoError(t, err)
+		assert.Equal(t, "--max-old-space-size=4096\n", string(out), "step env overrides the inherited value")
+	})
+}
+
+func TestStepEnv_Plan(t *testing.T) {
+	step := Create("node.npm", config.StepConfig{
+		Args: []string{"run", "build"},
+		Env:  map[string]string{"node_options": "--max-old-space-size=4096"},
+	})
+
+	plan, err := step.(types.Planner).Plan(&types.ScaffoldContext{}, types.StepOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "NODE_OPTIONS=--max-old-space-size=4096 npm run build", plan.Command)
+}
@@ -0,0 +1,118 @@
+package steps
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/michaeldyrynda/arbor/internal/config"
+	"github.com/michaeldyrynda/arbor/internal/logging"
+	"github.com/michaeldyrynda/arbor/internal/scaffold/types"
+	"github.com/michaeldyrynda/arbor/internal/utils"
+)
+
+// DefaultVitePort is the port node.vite allocates from when ports is not set
+const DefaultVitePort = 5173
+
+// vitePortDialTimeout bounds how long node.vite waits to learn whether a
+// port is in use
+var vitePortDialTimeout = 200 * time.Millisecond
+
+type ViteStep struct {
+	base     int
+	file     string
+	priority int
+}
+
+func NewViteStep(cfg config.StepConfig, priority int) *ViteStep {
+	base := DefaultVitePort
+	if len(cfg.Ports) > 0 {
+		base = cfg.Ports[0]
+	}
+	file := cfg.File
+	if file == "" {
+		file = ".env"
+	}
+	return &ViteStep{base: base, file: file, priority: priority}
+}
+
+func (s *ViteStep) Name() string {
+	return "node.vite"
+}
+
+// Run reserves the worktree's Vite port and writes it, with the dev server
+// host taken from APP_URL, to the env file. The port is also available to
+// later templates as {{ .VitePort }}.
+func (s *ViteStep) Run(ctx *types.ScaffoldContext, opts types.StepOptions) error {
+	if ctx.BarePath == "" {
+		return fmt.Errorf("node.vite: no bare repository to reserve ports in")
+	}
+
+	port, err := config.AllocatePort(ctx.BarePath, ctx.WorktreePath, "vite", s.base, portInUse)
+	if err != nil {
+		return fmt.Errorf("node.vite: %w", err)
+	}
+	ctx.SetVar("VitePort", strconv.Itoa(port))
+
+	values := s.envValues(ctx, port)
+	for _, kv := range values {
+		if err := utils.WriteEnvValue(ctx.WorktreePath, s.file, kv[0], kv[1]); err != nil {
+			return fmt.Errorf("node.vite: writing %s: %w", kv[0], err)
+		}
+	}
+
+	logging.Verbosef("  Vite dev server port %d", port)
+	return nil
+}
+
+// Plan shows the port already reserved for the worktree, or where the search
+// for one starts
+func (s *ViteStep) Plan(ctx *types.ScaffoldContext, opts types.StepOptions) (types.StepPlan, error) {
+	port, detail := s.base, fmt.Sprintf("VITE_PORT from %d up", s.base)
+	if ctx.BarePath != "" {
+		if reserved, err := config.ReservedPorts(ctx.BarePath, ctx.WorktreePath); err == nil {
+			if p, ok := reserved["vite"]; ok {
+				port, detail = p, fmt.Sprintf("VITE_PORT=%d", p)
+			}
+		}
+	}
+	ctx.SetVar("VitePort", strconv.Itoa(port))
+	return types.StepPlan{Files: []string{s.file}, Detail: detail}, nil
+}
+
+// envValues are the keys written for port: VITE_PORT, and when APP_URL is
+// set, VITE_APP_URL and the VITE_HMR_HOST browsers reach the dev server on
+func (s *ViteStep) envValues(ctx *types.ScaffoldContext, port int) [][2]string {
+	values := [][2]string{{"VITE_PORT", strconv.Itoa(port)}}
+
+	appURL := utils.UnquoteEnvValue(utils.ReadEnvFile(ctx.WorktreePath, s.file)["APP_URL"])
+	if appURL == "" {
+		return values
+	}
+	values = append(values, [2]string{"VITE_APP_URL", appURL})
+	if parsed, err := url.Parse(appURL); err == nil && parsed.Hostname() != "" {
+		values = append(values, [2]string{"VITE_HMR_HOST", parsed.Hostname()})
+	}
+	return values
+}
+
+func (s *ViteStep) Priority() int {
+	return s.priority
+}
+
+func (s *ViteStep) Condition(ctx *types.ScaffoldContext) bool {
+	return true
+}
+
+// portInUse reports whether something on this machine accepts connections
+// on port
+func portInUse(port int) bool {
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(port)), vitePortDialTimeout)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
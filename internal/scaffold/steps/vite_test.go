@@ -0,0 +1,50 @@
+package steps
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/michaeldyrynda/arbor/internal/config"
+	"github.com/michaeldyrynda/arbor/internal/scaffold/types"
+	"github.com/michaeldyrynda/arbor/internal/utils"
+)
+
+func TestViteStep(t *testing.T) {
+	barePath := t.TempDir()
+	first := t.TempDir()
+	second := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(first, ".env"), []byte("APP_URL=\"https://feature-a.test\"\n"), 0644))
+
+	step := Create("node.vite", config.StepConfig{Ports: []int{41730}})
+	require.NotNil(t, step)
+	assert.Equal(t, PriorityVite, step.Priority())
+
+	firstCtx := &types.ScaffoldContext{BarePath: barePath, WorktreePath: first}
+	require.NoError(t, step.Run(firstCtx, types.StepOptions{}))
+	secondCtx := &types.ScaffoldContext{BarePath: barePath, WorktreePath: second}
+	require.NoError(t, step.Run(secondCtx, types.StepOptions{}))
+
+	env := utils.ReadEnvFile(first, ".env")
+	firstPort := env["VITE_PORT"]
+	assert.NotEmpty(t, firstPort)
+	assert.Equal(t, "https://feature-a.test", env["VITE_APP_URL"])
+	assert.Equal(t, "feature-a.test", env["VITE_HMR_HOST"])
+	assert.Equal(t, firstPort, firstCtx.GetVar("VitePort"))
+
+	secondEnv := utils.ReadEnvFile(second, ".env")
+	assert.NotEqual(t, firstPort, secondEnv["VITE_PORT"], "worktrees get different ports")
+	assert.NotContains(t, secondEnv, "VITE_HMR_HOST", "no APP_URL, no host")
+
+	plan, err := step.(types.Planner).Plan(firstCtx, types.StepOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "VITE_PORT="+firstPort, plan.Detail)
+}
+
+func TestViteStep_NeedsBareRepository(t *testing.T) {
+	step := NewViteStep(config.StepConfig{}, PriorityVite)
+	assert.Error(t, step.Run(&types.ScaffoldContext{WorktreePath: t.TempDir()}, types.StepOptions{}))
+}
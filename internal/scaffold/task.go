@@ -0,0 +1,78 @@
+package scaffold
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/michaeldyrynda/arbor/internal/config"
+	"github.com/michaeldyrynda/arbor/internal/scaffold/types"
+	"github.com/michaeldyrynda/arbor/internal/secrets"
+)
+
+// TaskNames returns the names of the project's tasks, sorted
+func TaskNames(cfg *config.Config) []string {
+	names := make([]string, 0, len(cfg.Tasks))
+	for name := range cfg.Tasks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// taskConfigs returns the steps of the task named name. Task names are
+// matched case-insensitively, as the config lowercases them.
+func taskConfigs(cfg *config.Config, name string) ([]config.StepConfig, bool) {
+	stepConfigs, ok := cfg.Tasks[strings.ToLower(name)]
+	return stepConfigs, ok
+}
+
+// GetTaskSteps returns the steps of the task named name, in the order they
+// are listed
+func (m *ScaffoldManager) GetTaskSteps(cfg *config.Config, name, worktreePath string) ([]types.ScaffoldStep, error) {
+	return m.taskSteps(cfg, name, secrets.NewInterpolator(worktreePath))
+}
+
+func (m *ScaffoldManager) taskSteps(cfg *config.Config, name string, interpolator *secrets.Interpolator) ([]types.ScaffoldStep, error) {
+	stepConfigs, ok := taskConfigs(cfg, name)
+	if !ok {
+		return nil, fmt.Errorf("unknown task %q", name)
+	}
+
+	stepsList, err := m.stepsFromConfig(stepConfigs, interpolator)
+	if err != nil {
+		return nil, fmt.Errorf("task %s: %w", name, err)
+	}
+	return stepsList, nil
+}
+
+// RunTask runs the task named name in an existing worktree, with the same
+// templates, conditions and executor as scaffolding. Unlike scaffold steps,
+// task steps run one at a time in the order they are listed.
+func (m *ScaffoldManager) RunTask(name, worktreePath, branch, siteName string, cfg *config.Config, dryRun, verbose bool) error {
+	interpolator := secrets.NewInterpolator(worktreePath)
+	stepsList, err := m.taskSteps(cfg, name, interpolator)
+	if err != nil {
+		return err
+	}
+
+	worktreeConfig, err := config.ReadWorktreeConfig(worktreePath)
+	if err != nil {
+		return fmt.Errorf("reading worktree config: %w", err)
+	}
+
+	repoName := filepath.Base(filepath.Dir(worktreePath))
+	ctx, err := newContext(worktreePath, branch, repoName, siteName, cfg.Preset, cfg, worktreeConfig, interpolator)
+	if err != nil {
+		return err
+	}
+	ctx.Interaction = m.Interaction
+	ctx.SetDbSuffix(worktreeConfig.DbSuffix)
+
+	opts := types.StepOptions{
+		DryRun:  dryRun,
+		Verbose: verbose,
+	}
+	return NewStepExecutor(stepsList, ctx, opts).ExecuteInOrder()
+}
@@ -0,0 +1,65 @@
+package scaffold
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/michaeldyrynda/arbor/internal/config"
+)
+
+func TestTaskNames(t *testing.T) {
+	cfg := &config.Config{Tasks: map[string][]config.StepConfig{"fresh": nil, "build": nil}}
+
+	assert.Equal(t, []string{"build", "fresh"}, TaskNames(cfg))
+	assert.Empty(t, TaskNames(&config.Config{}))
+}
+
+func TestScaffoldManager_GetTaskSteps(t *testing.T) {
+	cfg := &config.Config{Tasks: map[string][]config.StepConfig{
+		"fresh": {
+			{Name: "php.laravel.artisan", Args: []string{"migrate:fresh"}},
+			{Name: "bash.run", Command: "echo done"},
+		},
+	}}
+	manager := NewScaffoldManager()
+
+	stepsList, err := manager.GetTaskSteps(cfg, "Fresh", t.TempDir())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"php.laravel.artisan", "bash.run"}, stepNames(stepsList))
+
+	_, err = manager.GetTaskSteps(cfg, "missing", t.TempDir())
+	assert.EqualError(t, err, `unknown task "missing"`)
+}
+
+func TestScaffoldManager_RunTask(t *testing.T) {
+	worktree := t.TempDir()
+	require.NoError(t, config.WriteWorktreeConfig(worktree, map[string]string{"db_suffix": "swift_otter"}))
+
+	cfg := &config.Config{Tasks: map[string][]config.StepConfig{
+		"fresh": {
+			{Name: "env.write", Key: "APP_NAME", Value: "app_{{ .DbSuffix }}"},
+			{Name: "bash.run", Command: "grep -o 'swift_otter' .env", CaptureAs: "Suffix"},
+			{Name: "bash.run", Command: "echo {{ .Suffix }} > out.txt"},
+		},
+	}}
+
+	require.NoError(t, NewScaffoldManager().RunTask("fresh", worktree, "feature", "app", cfg, false, false))
+
+	out, err := os.ReadFile(filepath.Join(worktree, "out.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "swift_otter\n", string(out))
+}
+
+func TestScaffoldManager_RunTask_DryRun(t *testing.T) {
+	worktree := t.TempDir()
+	cfg := &config.Config{Tasks: map[string][]config.StepConfig{
+		"build": {{Name: "bash.run", Command: "touch built"}},
+	}}
+
+	require.NoError(t, NewScaffoldManager().RunTask("build", worktree, "feature", "app", cfg, true, false))
+	assert.NoFileExists(t, filepath.Join(worktree, "built"))
+}
@@ -2,19 +2,116 @@ package template
 
 import (
 	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"regexp"
+	"strings"
 	"text/template"
+	"time"
+
+	"github.com/Masterminds/sprig/v3"
 
 	"github.com/michaeldyrynda/arbor/internal/scaffold/types"
 )
 
+// Field describes a value available to step templates
+type Field struct {
+	Name        string
+	Description string
+}
+
+// Fields lists the context fields available to step templates. Project vars
+// and values captured by earlier steps are available by name as well.
+var Fields = []Field{
+	{"Path", "Worktree directory name, e.g. feature-auth"},
+	{"WorktreeFolder", "Same as Path"},
+	{"RepoPath", "Project directory name"},
+	{"ProjectPath", "Absolute path of the project directory"},
+	{"BarePath", "Absolute path of the project's .bare repository"},
+	{"MainWorktreePath", "Absolute path of the default branch's worktree, empty when it has none"},
+	{"RepoName", "Repository name"},
+	{"SiteName", "Site or project name"},
+	{"Branch", "Branch name, e.g. feature/auth"},
+	{"BaseBranch", "Branch the worktree was created from"},
+	{"DbSuffix", "Suffix shared by the worktree's databases"},
+	{"ComposeProjectName", "Docker Compose project name, e.g. myapp_swift_fox"},
+	{"Timestamp", "Time scaffolding started, e.g. 20250131150405"},
+	{"Vars", "Project vars and captured values, e.g. .Vars.api_url"},
+}
+
+// Function describes a function available to step templates
+type Function struct {
+	Name        string
+	Usage       string
+	Description string
+}
+
+// Functions lists arbor's own template functions. The sprig text functions
+// (https://masterminds.github.io/sprig/) are available as well.
+var Functions = []Function{
+	{"slug", `{{ .Branch | slug }}`, "Lowercase, with runs of other characters replaced by -"},
+	{"kebab", `{{ .Branch | kebab }}`, "Same as slug"},
+	{"snake", `{{ .Branch | snake }}`, "Lowercase, with runs of other characters replaced by _"},
+	{"upper", `{{ .SiteName | upper }}`, "Uppercase"},
+	{"lower", `{{ .Branch | lower }}`, "Lowercase"},
+	{"trimPrefix", `{{ .Branch | trimPrefix "feature/" }}`, "Remove a leading prefix"},
+	{"randomHex", `{{ randomHex 16 }}`, "Random hex string of the given length"},
+	{"now", `{{ now.Format "2006-01-02" }}`, "Current time"},
+}
+
+var nonSlugChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// separate lowercases s and joins its runs of letters and digits with sep
+func separate(s, sep string) string {
+	return strings.Trim(nonSlugChars.ReplaceAllString(strings.ToLower(s), sep), sep)
+}
+
+func randomHex(n int) (string, error) {
+	if n <= 0 {
+		return "", nil
+	}
+	buf := make([]byte, (n+1)/2)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf)[:n], nil
+}
+
+// funcMap returns the functions registered on every step template: the
+// sprig text functions, with arbor's own taking precedence
+func funcMap() template.FuncMap {
+	funcs := sprig.TxtFuncMap()
+	for name, fn := range (template.FuncMap{
+		"slug":       func(s string) string { return separate(s, "-") },
+		"kebab":      func(s string) string { return separate(s, "-") },
+		"snake":      func(s string) string { return separate(s, "_") },
+		"upper":      strings.ToUpper,
+		"lower":      strings.ToLower,
+		"trimPrefix": func(prefix, s string) string { return strings.TrimPrefix(s, prefix) },
+		"randomHex":  randomHex,
+		"now":        time.Now,
+	}) {
+		funcs[name] = fn
+	}
+	return funcs
+}
+
+// ErrInvalidTemplate is wrapped by errors for text that does not parse as a
+// template
+var ErrInvalidTemplate = errors.New("invalid template")
+
+// ReplaceTemplateVars renders str against the context. Unknown fields are an
+// error rather than rendering as <no value>.
 func ReplaceTemplateVars(str string, ctx *types.ScaffoldContext) (string, error) {
-	tmpl, err := template.New("").Option("missingkey=error").Parse(str)
+	tmpl, err := template.New("").Funcs(funcMap()).Option("missingkey=error").Parse(str)
 	if err != nil {
-		return "", fmt.Errorf("invalid template: %w", err)
+		return "", fmt.Errorf("%w: %w", ErrInvalidTemplate, err)
 	}
 
 	data := ctx.SnapshotForTemplate()
+
 	var buf bytes.Buffer
 	if err := tmpl.Execute(&buf, data); err != nil {
 		return "", fmt.Errorf("template execution failed: %w", err)
@@ -1,7 +1,10 @@
 package template
 
 import (
+	"regexp"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/michaeldyrynda/arbor/internal/scaffold/types"
 )
@@ -126,6 +129,12 @@ func TestReplaceTemplateVars_SnapshotForTemplate(t *testing.T) {
 		Branch:   "feature/test",
 		DbSuffix: "swift_runner",
 		Vars:     map[string]string{"CustomVar": "custom-value"},
+
+		BaseBranch:     "develop",
+		ProjectPath:    "/code/myapp",
+		BarePath:       "/code/myapp/.bare",
+		WorktreeFolder: "feature-auth",
+		Timestamp:      "20250131150405",
 	}
 
 	tests := []struct {
@@ -133,6 +142,11 @@ func TestReplaceTemplateVars_SnapshotForTemplate(t *testing.T) {
 		input    string
 		expected string
 	}{
+		{
+			name:     "project and worktree fields",
+			input:    "{{ .BaseBranch }} {{ .ProjectPath }} {{ .BarePath }} {{ .WorktreeFolder }} {{ .Timestamp }}",
+			expected: "develop /code/myapp /code/myapp/.bare feature-auth 20250131150405",
+		},
 		{
 			name:     "all built-in variables",
 			input:    "{{ .Path }}-{{ .RepoPath }}-{{ .RepoName }}-{{ .SiteName }}-{{ .Branch }}-{{ .DbSuffix }}",
@@ -143,6 +157,11 @@ func TestReplaceTemplateVars_SnapshotForTemplate(t *testing.T) {
 			input:    "{{ .CustomVar }}",
 			expected: "custom-value",
 		},
+		{
+			name:     "custom variable via Vars",
+			input:    "{{ .Vars.CustomVar }}",
+			expected: "custom-value",
+		},
 		{
 			name:     "built-in and custom variables together",
 			input:    "{{ .SiteName }}_{{ .DbSuffix }}_{{ .CustomVar }}",
@@ -163,3 +182,108 @@ func TestReplaceTemplateVars_SnapshotForTemplate(t *testing.T) {
 		})
 	}
 }
+
+func TestReplaceTemplateVars_Functions(t *testing.T) {
+	ctx := &types.ScaffoldContext{
+		Branch:   "feature/Login_Form",
+		SiteName: "myapp",
+	}
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"slug", "https://{{ .Branch | slug }}.test", "https://feature-login-form.test"},
+		{"kebab", "{{ .Branch | kebab }}", "feature-login-form"},
+		{"snake", "{{ .Branch | snake }}", "feature_login_form"},
+		{"upper", "{{ .SiteName | upper }}", "MYAPP"},
+		{"lower", "{{ .Branch | lower }}", "feature/login_form"},
+		{"trimPrefix", `{{ .Branch | trimPrefix "feature/" }}`, "Login_Form"},
+		{"chained", `{{ .Branch | trimPrefix "feature/" | snake }}`, "login_form"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ReplaceTemplateVars(tt.input, ctx)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, result)
+			}
+		})
+	}
+
+	t.Run("randomHex", func(t *testing.T) {
+		result, err := ReplaceTemplateVars("{{ randomHex 9 }}", ctx)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !regexp.MustCompile(`^[0-9a-f]{9}$`).MatchString(result) {
+			t.Errorf("expected 9 hex characters, got %q", result)
+		}
+	})
+
+	t.Run("now", func(t *testing.T) {
+		result, err := ReplaceTemplateVars(`{{ now.Format "2006" }}`, ctx)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result != time.Now().Format("2006") {
+			t.Errorf("expected the current year, got %q", result)
+		}
+	})
+}
+
+func TestReplaceTemplateVars_Sprig(t *testing.T) {
+	ctx := &types.ScaffoldContext{
+		SiteName: "my app",
+		Branch:   "feature/auth",
+		DbSuffix: "swift_runner",
+		Vars:     map[string]string{"region": "au"},
+	}
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"title", "{{ .SiteName | title }}", "My App"},
+		{"replace", `{{ .Branch | replace "/" "." }}`, "feature.auth"},
+		{"default with index", `{{ index .Vars "missing" | default "us" }}`, "us"},
+		{"sha1sum", `{{ .DbSuffix | sha1sum | trunc 8 }}`, "90a2345f"},
+		{"arbor functions take precedence", "{{ .Branch | snake }}", "feature_auth"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ReplaceTemplateVars(tt.input, ctx)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestReplaceTemplateVars_MissingKeys(t *testing.T) {
+	ctx := &types.ScaffoldContext{
+		DbSuffix: "swift_runner",
+		Vars:     map[string]string{"region": "au"},
+	}
+
+	for _, input := range []string{"{{ .DbSufix }}", "{{ .Vars.regoin }}"} {
+		t.Run(input, func(t *testing.T) {
+			result, err := ReplaceTemplateVars(input, ctx)
+			if err == nil {
+				t.Fatalf("expected error, got %q", result)
+			}
+			if strings.Contains(result, "<no value>") {
+				t.Errorf("expected no <no value> output, got %q", result)
+			}
+		})
+	}
+}
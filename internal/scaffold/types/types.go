@@ -1,32 +1,88 @@
 package types
 
 import (
+	"encoding/json"
+	"fmt"
+	"net"
 	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
+	"regexp"
 	"runtime"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/Masterminds/semver/v3"
 	"github.com/go-viper/mapstructure/v2"
 
+	"github.com/michaeldyrynda/arbor/internal/config"
+	"github.com/michaeldyrynda/arbor/internal/logging"
 	"github.com/michaeldyrynda/arbor/internal/utils"
 )
 
 type ScaffoldContext struct {
-	WorktreePath string
-	Branch       string
-	RepoName     string
-	SiteName     string
-	Preset       string
-	Env          map[string]string
-	Path         string
-	RepoPath     string
-	DbSuffix     string
-	Vars         map[string]string
+	WorktreePath   string
+	Branch         string
+	BaseBranch     string
+	RepoName       string
+	SiteName       string
+	Preset         string
+	Env            map[string]string
+	Path           string
+	RepoPath       string
+	ProjectPath    string
+	BarePath       string
+	WorktreeFolder string
+	Timestamp      string
+	DbSuffix       string
+	Vars           map[string]string
+	Database       config.DatabaseConfig
+	Settings       map[string]interface{}
+
+	// DatabasePrefixes are the prefixes the project names its databases
+	// with, which db.destroy drops the worktree's databases for
+	DatabasePrefixes []string
+
+	// MainWorktreePath is the default branch's worktree, which steps can
+	// copy from, or "" when it has none
+	MainWorktreePath string
+
+	// WindowsShell is the windows_shell setting command.run and shell
+	// conditions run with
+	WindowsShell string
+
+	// Interaction is how steps reach the user during this run
+	Interaction Interaction
+
+	shellResults map[string]bool
 	mu           sync.RWMutex
 }
 
+// Interaction connects steps to the user. The CLI fills it in for each
+// command; a nil prompt means arbor may not ask, and steps fall back to
+// their defaults.
+type Interaction struct {
+	// Interactive is what is_interactive conditions compare with
+	Interactive bool
+	Password    func(title string) (string, error)
+	Confirm     func(message string) (bool, error)
+	// EnvValue asks for the value of a key env.sync adds, starting from the
+	// example's
+	EnvValue func(key, example string) (string, error)
+	Input    func(input config.InputConfig) (string, error)
+	// Notify and Clipboard are nil outside the CLI, and those steps skip them
+	Notify    func(title, message string) error
+	Clipboard func(text string) error
+}
+
+// TimestampFormat formats ScaffoldContext.Timestamp so it sorts and is safe
+// in database and domain names
+const TimestampFormat = "20060102150405"
+
 type StepOptions struct {
 	Args    []string
 	DryRun  bool
@@ -40,19 +96,33 @@ type ScaffoldStep interface {
 	Condition(ctx *ScaffoldContext) bool
 }
 
+// StepPlan is what a step would do, with its templates resolved, as shown
+// by --dry-run --plan
+type StepPlan struct {
+	// Command is the command line the step would run
+	Command string `json:"command,omitempty"`
+	// Files are the files the step would write, relative to the worktree
+	Files []string `json:"files,omitempty"`
+	// Databases are the databases the step would create or drop
+	Databases []string `json:"databases,omitempty"`
+	// Detail is anything else worth knowing, such as an env value written
+	Detail string `json:"detail,omitempty"`
+	// Tools are the programs the step runs, which must be installed
+	Tools []string `json:"tools,omitempty"`
+}
+
+// Planner is implemented by steps that can describe their work without
+// doing it. Plan may read files and update the context, as later steps'
+// templates can depend on it, but must not change anything else.
+type Planner interface {
+	Plan(ctx *ScaffoldContext, opts StepOptions) (StepPlan, error)
+}
+
 func (ctx *ScaffoldContext) EvaluateCondition(conditions map[string]interface{}) (bool, error) {
 	if len(conditions) == 0 {
 		return true, nil
 	}
 
-	if not, ok := conditions["not"]; ok {
-		result, err := ctx.evaluateCondition(not)
-		if err != nil {
-			return false, err
-		}
-		return !result, nil
-	}
-
 	return ctx.evaluateCondition(conditions)
 }
 
@@ -82,7 +152,7 @@ func (ctx *ScaffoldContext) evaluateMapCondition(conditions map[string]interface
 
 func (ctx *ScaffoldContext) evaluateArrayCondition(conditions []interface{}) (bool, error) {
 	for _, item := range conditions {
-		result, err := ctx.evaluateCondition(item.(map[string]interface{}))
+		result, err := ctx.evaluateCondition(item)
 		if err != nil {
 			return false, err
 		}
@@ -93,55 +163,156 @@ func (ctx *ScaffoldContext) evaluateArrayCondition(conditions []interface{}) (bo
 	return true, nil
 }
 
+// evaluateAnyCondition reports whether at least one condition holds. A list
+// holds alternatives, a mapping holds alternative keys.
+func (ctx *ScaffoldContext) evaluateAnyCondition(cond interface{}) (bool, error) {
+	switch c := cond.(type) {
+	case []interface{}:
+		for _, item := range c {
+			result, err := ctx.evaluateCondition(item)
+			if err != nil {
+				return false, err
+			}
+			if result {
+				return true, nil
+			}
+		}
+		return false, nil
+	case map[string]interface{}:
+		for key, value := range c {
+			result, err := ctx.evaluateSingle(key, value)
+			if err != nil {
+				return false, err
+			}
+			if result {
+				return true, nil
+			}
+		}
+		return false, nil
+	default:
+		return false, nil
+	}
+}
+
+// conditionOperators combine other conditions and are handled in
+// evaluateSingle, as they recurse
+var conditionOperators = []string{"all_of", "any_of", "not"}
+
+// conditionHandlers maps condition keys to their evaluators. The operators
+// are handled separately in evaluateSingle as they recurse.
+var conditionHandlers = map[string]func(ctx *ScaffoldContext, value interface{}) (bool, error){
+	"file_exists":          (*ScaffoldContext).fileExists,
+	"dir_exists":           (*ScaffoldContext).dirExists,
+	"file_contains":        (*ScaffoldContext).fileContains,
+	"file_matches":         (*ScaffoldContext).fileMatches,
+	"file_has_script":      (*ScaffoldContext).npmHasScript,
+	"npm_has_script":       (*ScaffoldContext).npmHasScript,
+	"npm_has_dependency":   (*ScaffoldContext).npmHasDependency,
+	"composer_has_package": (*ScaffoldContext).composerHasPackage,
+	"command_exists":       (*ScaffoldContext).commandExists,
+	"command_version":      (*ScaffoldContext).commandVersion,
+	"shell":                (*ScaffoldContext).shellSucceeds,
+	"os":                   (*ScaffoldContext).osMatches,
+	"port_open":            (*ScaffoldContext).portOpen,
+	"service_running":      (*ScaffoldContext).serviceRunning,
+	"branch_matches":       (*ScaffoldContext).branchMatches,
+	"base_branch_is":       (*ScaffoldContext).baseBranchIs,
+	"preset_is":            (*ScaffoldContext).presetIs,
+	"is_ci":                (*ScaffoldContext).isCI,
+	"is_interactive":       (*ScaffoldContext).isInteractive,
+	"env_exists":           (*ScaffoldContext).envExists,
+	"env_not_exists":       (*ScaffoldContext).envNotExists,
+	"env_file_contains":    (*ScaffoldContext).envFileContains,
+	"env_file_missing":     (*ScaffoldContext).envFileMissing,
+	"env_file_equals":      (*ScaffoldContext).envFileEquals,
+	"config_value":         (*ScaffoldContext).configValue,
+}
+
+// ConditionNames returns every supported condition key, sorted
+func ConditionNames() []string {
+	names := make([]string, 0, len(conditionHandlers)+len(conditionOperators))
+	for name := range conditionHandlers {
+		names = append(names, name)
+	}
+	names = append(names, conditionOperators...)
+	sort.Strings(names)
+	return names
+}
+
 func (ctx *ScaffoldContext) evaluateSingle(key string, value interface{}) (bool, error) {
 	switch key {
-	case "file_exists":
-		return ctx.fileExists(value)
-	case "file_contains":
-		return ctx.fileContains(value)
-	case "file_has_script":
-		return ctx.fileHasScript(value)
-	case "command_exists":
-		return ctx.commandExists(value)
-	case "os":
-		return ctx.osMatches(value)
-	case "env_exists":
-		return ctx.envExists(value)
-	case "env_not_exists":
-		return ctx.envNotExists(value)
-	case "env_file_contains":
-		return ctx.envFileContains(value)
-	case "env_file_missing":
-		return ctx.envFileMissing(value)
 	case "not":
 		result, err := ctx.evaluateCondition(value)
 		if err != nil {
 			return false, err
 		}
 		return !result, nil
-	default:
-		return true, nil
+	case "any_of":
+		return ctx.evaluateAnyCondition(value)
+	case "all_of":
+		return ctx.evaluateCondition(value)
+	}
+
+	if handler, ok := conditionHandlers[key]; ok {
+		return handler(ctx, value)
 	}
+
+	return true, nil
 }
 
+// fileExists checks for a path in the worktree, which may be a glob pattern
+// such as database/migrations/*tenant*
 func (ctx *ScaffoldContext) fileExists(value interface{}) (bool, error) {
-	var path string
+	matches, err := ctx.globWorktree("file_exists", pathValue(value, "file"))
+	return len(matches) > 0, err
+}
+
+// dirExists checks for a directory in the worktree, which may be a glob
+// pattern
+func (ctx *ScaffoldContext) dirExists(value interface{}) (bool, error) {
+	matches, err := ctx.globWorktree("dir_exists", pathValue(value, "dir"))
+	if err != nil {
+		return false, err
+	}
+	for _, match := range matches {
+		if info, err := os.Stat(match); err == nil && info.IsDir() {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// pathValue reads a path given directly or under key
+func pathValue(value interface{}, key string) string {
 	switch v := value.(type) {
 	case string:
-		path = v
+		return v
 	case map[string]interface{}:
-		if p, ok := v["file"].(string); ok {
-			path = p
+		if p, ok := v[key].(string); ok {
+			return p
 		}
 	}
+	return ""
+}
 
-	if path == "" {
-		return false, nil
+// globWorktree returns the worktree paths matching pattern. A path that
+// exists as written matches itself, so pages/[id].vue is not read as a
+// character class.
+func (ctx *ScaffoldContext) globWorktree(name, pattern string) ([]string, error) {
+	if pattern == "" {
+		return nil, nil
 	}
 
-	fullPath := filepath.Join(ctx.WorktreePath, path)
-	_, err := os.Stat(fullPath)
-	return err == nil, nil
+	fullPath := filepath.Join(ctx.WorktreePath, pattern)
+	if _, err := os.Stat(fullPath); err == nil {
+		return []string{fullPath}, nil
+	}
+
+	matches, err := filepath.Glob(fullPath)
+	if err != nil {
+		return nil, fmt.Errorf("%s: invalid pattern %q: %w", name, pattern, err)
+	}
+	return matches, nil
 }
 
 func (ctx *ScaffoldContext) fileContains(value interface{}) (bool, error) {
@@ -172,28 +343,120 @@ func (ctx *ScaffoldContext) fileContains(value interface{}) (bool, error) {
 	return strings.Contains(string(data), config.Pattern), nil
 }
 
-func (ctx *ScaffoldContext) fileHasScript(value interface{}) (bool, error) {
-	var scriptName string
+// fileMatches reports whether the file matches a regular expression, e.g. to
+// check a version constraint in composer.json
+func (ctx *ScaffoldContext) fileMatches(value interface{}) (bool, error) {
+	var config struct {
+		File  string `mapstructure:"file"`
+		Regex string `mapstructure:"regex"`
+	}
+
+	v, ok := value.(map[string]interface{})
+	if !ok {
+		return false, nil
+	}
+	if err := mapstructure.Decode(v, &config); err != nil {
+		return false, nil
+	}
+
+	if config.File == "" || config.Regex == "" {
+		return false, nil
+	}
+
+	re, err := regexp.Compile(config.Regex)
+	if err != nil {
+		return false, fmt.Errorf("file_matches: invalid regex %q: %w", config.Regex, err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(ctx.WorktreePath, config.File))
+	if err != nil {
+		return false, nil
+	}
+
+	return re.Match(data), nil
+}
+
+// packageManifest holds the parts of package.json and composer.json read by
+// the package conditions. Values are left raw as their shapes vary, e.g.
+// composer scripts may be a string or a list.
+type packageManifest struct {
+	Scripts              map[string]json.RawMessage `json:"scripts"`
+	Dependencies         map[string]json.RawMessage `json:"dependencies"`
+	DevDependencies      map[string]json.RawMessage `json:"devDependencies"`
+	PeerDependencies     map[string]json.RawMessage `json:"peerDependencies"`
+	OptionalDependencies map[string]json.RawMessage `json:"optionalDependencies"`
+	Require              map[string]json.RawMessage `json:"require"`
+	RequireDev           map[string]json.RawMessage `json:"require-dev"`
+}
+
+// readManifest parses a JSON manifest in the worktree. A missing manifest
+// reads as empty.
+func (ctx *ScaffoldContext) readManifest(name string) (packageManifest, error) {
+	var manifest packageManifest
+	data, err := os.ReadFile(filepath.Join(ctx.WorktreePath, name))
+	if os.IsNotExist(err) {
+		return manifest, nil
+	}
+	if err != nil {
+		return manifest, fmt.Errorf("reading %s: %w", name, err)
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return manifest, fmt.Errorf("parsing %s: %w", name, err)
+	}
+	return manifest, nil
+}
+
+// conditionName reads a name given directly or under the "name" key
+func conditionName(value interface{}) string {
 	switch v := value.(type) {
 	case string:
-		scriptName = v
+		return v
 	case map[string]interface{}:
-		if s, ok := v["name"].(string); ok {
-			scriptName = s
+		if name, ok := v["name"].(string); ok {
+			return name
 		}
 	}
+	return ""
+}
 
-	if scriptName == "" {
-		return false, nil
+// hasKey reports whether name is a key of any of the blocks
+func hasKey(name string, blocks ...map[string]json.RawMessage) bool {
+	if name == "" {
+		return false
 	}
+	for _, block := range blocks {
+		if _, ok := block[name]; ok {
+			return true
+		}
+	}
+	return false
+}
 
-	fullPath := filepath.Join(ctx.WorktreePath, "package.json")
-	data, err := os.ReadFile(fullPath)
+func (ctx *ScaffoldContext) npmHasScript(value interface{}) (bool, error) {
+	manifest, err := ctx.readManifest("package.json")
 	if err != nil {
-		return false, nil
+		return false, err
 	}
+	return hasKey(conditionName(value), manifest.Scripts), nil
+}
 
-	return strings.Contains(string(data), `"`+scriptName+`"`), nil
+// npmHasDependency checks every dependency block of package.json
+func (ctx *ScaffoldContext) npmHasDependency(value interface{}) (bool, error) {
+	manifest, err := ctx.readManifest("package.json")
+	if err != nil {
+		return false, err
+	}
+	return hasKey(conditionName(value), manifest.Dependencies, manifest.DevDependencies,
+		manifest.PeerDependencies, manifest.OptionalDependencies), nil
+}
+
+// composerHasPackage checks require and require-dev in composer.json
+func (ctx *ScaffoldContext) composerHasPackage(value interface{}) (bool, error) {
+	manifest, err := ctx.readManifest("composer.json")
+	if err != nil {
+		return false, err
+	}
+	return hasKey(conditionName(value), manifest.Require, manifest.RequireDev), nil
 }
 
 func (ctx *ScaffoldContext) commandExists(value interface{}) (bool, error) {
@@ -215,6 +478,82 @@ func (ctx *ScaffoldContext) commandExists(value interface{}) (bool, error) {
 	return err == nil, nil
 }
 
+// shellSucceeds runs a shell command in the worktree and holds when it exits
+// zero. Results are cached for the run, so a check shared by several steps
+// only executes once.
+func (ctx *ScaffoldContext) shellSucceeds(value interface{}) (bool, error) {
+	command := pathValue(value, "command")
+	if command == "" {
+		return false, nil
+	}
+
+	ctx.mu.RLock()
+	result, cached := ctx.shellResults[command]
+	ctx.mu.RUnlock()
+	if cached {
+		return result, nil
+	}
+
+	cmd := utils.ShellCommand(ctx.WindowsShell, command)
+	cmd.Dir = ctx.WorktreePath
+	result = logging.Run(cmd) == nil
+
+	ctx.mu.Lock()
+	if ctx.shellResults == nil {
+		ctx.shellResults = make(map[string]bool)
+	}
+	ctx.shellResults[command] = result
+	ctx.mu.Unlock()
+
+	return result, nil
+}
+
+// toolVersion reports a command's version. It is replaced in tests.
+var toolVersion = utils.ToolVersion
+
+// commandVersion checks a command's version against a semver constraint,
+// e.g. {command: php, constraint: ">=8.2"}. It is false when the command is
+// missing or its version cannot be read.
+func (ctx *ScaffoldContext) commandVersion(value interface{}) (bool, error) {
+	var config struct {
+		Command    string `mapstructure:"command"`
+		Constraint string `mapstructure:"constraint"`
+	}
+
+	v, ok := value.(map[string]interface{})
+	if !ok {
+		return false, nil
+	}
+	if err := mapstructure.Decode(v, &config); err != nil {
+		return false, nil
+	}
+	if config.Command == "" || config.Constraint == "" {
+		return false, nil
+	}
+
+	constraint, err := semver.NewConstraint(config.Constraint)
+	if err != nil {
+		return false, fmt.Errorf("command_version: invalid constraint %q: %w", config.Constraint, err)
+	}
+
+	path, err := exec.LookPath(config.Command)
+	if err != nil {
+		return false, nil
+	}
+
+	output, err := toolVersion(filepath.Base(config.Command), path)
+	if err != nil {
+		return false, nil
+	}
+
+	version, err := semver.NewVersion(output)
+	if err != nil {
+		return false, nil
+	}
+
+	return constraint.Check(version), nil
+}
+
 func (ctx *ScaffoldContext) osMatches(value interface{}) (bool, error) {
 	var osList []string
 	switch v := value.(type) {
@@ -236,6 +575,165 @@ func (ctx *ScaffoldContext) osMatches(value interface{}) (bool, error) {
 	return false, nil
 }
 
+// portDialTimeout bounds how long port_open waits for a connection
+var portDialTimeout = 500 * time.Millisecond
+
+// portOpen reports whether something accepts TCP connections on a port,
+// given as 3306, "127.0.0.1:3306" or {host, port}. The host defaults to
+// 127.0.0.1.
+func (ctx *ScaffoldContext) portOpen(value interface{}) (bool, error) {
+	host, port := "127.0.0.1", ""
+	switch v := value.(type) {
+	case int:
+		port = strconv.Itoa(v)
+	case string:
+		if h, p, err := net.SplitHostPort(v); err == nil {
+			host, port = h, p
+		} else {
+			port = v
+		}
+	case map[string]interface{}:
+		if h, ok := v["host"].(string); ok && h != "" {
+			host = h
+		}
+		if p, ok := v["port"]; ok {
+			port = fmt.Sprint(p)
+		}
+	}
+
+	if port == "" {
+		return false, nil
+	}
+
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(host, port), portDialTimeout)
+	if err != nil {
+		return false, nil
+	}
+	conn.Close()
+	return true, nil
+}
+
+// processRunning reports whether a process with the exact name is running.
+// It is replaced in tests.
+var processRunning = func(name string) bool {
+	if runtime.GOOS == "windows" {
+		if !strings.HasSuffix(strings.ToLower(name), ".exe") {
+			name += ".exe"
+		}
+		output, err := logging.Output(exec.Command("tasklist", "/NH", "/FI", "IMAGENAME eq "+name))
+		return err == nil && strings.Contains(strings.ToLower(string(output)), strings.ToLower(name))
+	}
+	return logging.Run(exec.Command("pgrep", "-x", name)) == nil
+}
+
+// serviceRunning reports whether any of the named processes is running,
+// e.g. mysqld or redis-server
+func (ctx *ScaffoldContext) serviceRunning(value interface{}) (bool, error) {
+	for _, name := range stringList(value) {
+		if name != "" && processRunning(name) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// stringList reads a single string or a list of strings
+func stringList(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		var list []string
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				list = append(list, s)
+			}
+		}
+		return list
+	case []string:
+		return v
+	}
+	return nil
+}
+
+// branchMatches matches the branch against glob patterns, e.g. feature/*,
+// or a regular expression given as {regex: ...}
+func (ctx *ScaffoldContext) branchMatches(value interface{}) (bool, error) {
+	if ctx.Branch == "" {
+		return false, nil
+	}
+
+	if v, ok := value.(map[string]interface{}); ok {
+		if pattern, ok := v["regex"].(string); ok && pattern != "" {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return false, fmt.Errorf("branch_matches: invalid regex %q: %w", pattern, err)
+			}
+			return re.MatchString(ctx.Branch), nil
+		}
+		value = v["glob"]
+	}
+
+	for _, pattern := range stringList(value) {
+		matched, err := path.Match(pattern, ctx.Branch)
+		if err != nil {
+			return false, fmt.Errorf("branch_matches: invalid pattern %q: %w", pattern, err)
+		}
+		if matched {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (ctx *ScaffoldContext) presetIs(value interface{}) (bool, error) {
+	for _, preset := range stringList(value) {
+		if ctx.Preset != "" && strings.EqualFold(preset, ctx.Preset) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// baseBranchIs reports whether the worktree was branched from one of the
+// named branches
+func (ctx *ScaffoldContext) baseBranchIs(value interface{}) (bool, error) {
+	for _, branch := range stringList(value) {
+		if ctx.BaseBranch != "" && branch == ctx.BaseBranch {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// expectBool reads the boolean a condition is compared with, so is_ci: false
+// holds outside CI. Quoted YAML falsy values such as "no" and "off" read as
+// false, and a bare key expects true.
+func expectBool(value interface{}) bool {
+	switch v := value.(type) {
+	case bool:
+		return v
+	case int:
+		return v != 0
+	case string:
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "false", "f", "no", "n", "off", "0":
+			return false
+		}
+	}
+	return true
+}
+
+// isCI compares against CI detection, e.g. is_ci: false to skip heavy steps
+// under automation
+func (ctx *ScaffoldContext) isCI(value interface{}) (bool, error) {
+	return utils.IsCI() == expectBool(value), nil
+}
+
+func (ctx *ScaffoldContext) isInteractive(value interface{}) (bool, error) {
+	return ctx.Interaction.Interactive == expectBool(value), nil
+}
+
 func (ctx *ScaffoldContext) envExists(value interface{}) (bool, error) {
 	var envName string
 	switch v := value.(type) {
@@ -296,6 +794,109 @@ func (ctx *ScaffoldContext) envFileMissing(value interface{}) (bool, error) {
 	return !contains, nil
 }
 
+// valueCondition compares a value read by env_file_equals or config_value
+type valueCondition struct {
+	Equals    interface{} `mapstructure:"equals"`
+	NotEquals interface{} `mapstructure:"not_equals"`
+	Matches   string      `mapstructure:"matches"`
+}
+
+// compare reports whether actual passes every comparison that is set.
+// Values are compared as strings, so equals: true matches a "true" value.
+func (c valueCondition) compare(name, actual string) (bool, error) {
+	if c.Equals != nil && actual != fmt.Sprint(c.Equals) {
+		return false, nil
+	}
+	if c.NotEquals != nil && actual == fmt.Sprint(c.NotEquals) {
+		return false, nil
+	}
+	if c.Matches != "" {
+		re, err := regexp.Compile(c.Matches)
+		if err != nil {
+			return false, fmt.Errorf("%s: invalid regex %q: %w", name, c.Matches, err)
+		}
+		return re.MatchString(actual), nil
+	}
+	return true, nil
+}
+
+// envFileEquals compares a key in an env file, e.g.
+// {key: DB_CONNECTION, not_equals: sqlite}. A missing key reads as empty.
+func (ctx *ScaffoldContext) envFileEquals(value interface{}) (bool, error) {
+	var config struct {
+		File           string `mapstructure:"file"`
+		Key            string `mapstructure:"key"`
+		valueCondition `mapstructure:",squash"`
+	}
+
+	v, ok := value.(map[string]interface{})
+	if !ok {
+		return false, nil
+	}
+	if err := mapstructure.Decode(v, &config); err != nil {
+		return false, nil
+	}
+	if config.File == "" {
+		config.File = ".env"
+	}
+	if config.Key == "" {
+		return false, nil
+	}
+
+	env := utils.ReadEnvFile(ctx.WorktreePath, config.File)
+	return config.compare("env_file_equals", env[config.Key])
+}
+
+// configValue compares a setting from arbor.yaml addressed by a dotted key,
+// e.g. {key: vars.seed, equals: true}. Given only a key, it holds when the
+// setting is present and not empty.
+func (ctx *ScaffoldContext) configValue(value interface{}) (bool, error) {
+	var config struct {
+		Key            string `mapstructure:"key"`
+		valueCondition `mapstructure:",squash"`
+	}
+
+	switch v := value.(type) {
+	case string:
+		config.Key = v
+	case map[string]interface{}:
+		if err := mapstructure.Decode(v, &config); err != nil {
+			return false, nil
+		}
+	}
+
+	if config.Key == "" {
+		return false, nil
+	}
+
+	setting, ok := lookupSetting(ctx.Settings, config.Key)
+	if !ok {
+		return false, nil
+	}
+
+	actual := fmt.Sprint(setting)
+	if config.Equals == nil && config.NotEquals == nil && config.Matches == "" {
+		return actual != "", nil
+	}
+	return config.compare("config_value", actual)
+}
+
+// lookupSetting walks settings by a dotted key. Keys are matched without
+// regard to case, as viper lowercases them.
+func lookupSetting(settings map[string]interface{}, key string) (interface{}, bool) {
+	var current interface{} = settings
+	for _, part := range strings.Split(strings.ToLower(key), ".") {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if current, ok = m[part]; !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
 func (ctx *ScaffoldContext) SetVar(key, value string) {
 	ctx.mu.Lock()
 	defer ctx.mu.Unlock()
@@ -323,19 +924,43 @@ func (ctx *ScaffoldContext) GetDbSuffix() string {
 	return ctx.DbSuffix
 }
 
-func (ctx *ScaffoldContext) SnapshotForTemplate() map[string]string {
+// ComposeProjectName is the worktree's Docker Compose project name, derived
+// from the site name and database suffix so each worktree's containers are
+// kept apart
+func (ctx *ScaffoldContext) ComposeProjectName() string {
+	ctx.mu.RLock()
+	defer ctx.mu.RUnlock()
+	return utils.ComposeProjectName(ctx.SiteName, ctx.DbSuffix)
+}
+
+// SnapshotForTemplate returns the template data for the context. Variables
+// are available both at the top level and under .Vars, e.g. {{ .Vars.api_base }}.
+func (ctx *ScaffoldContext) SnapshotForTemplate() map[string]interface{} {
 	ctx.mu.RLock()
 	defer ctx.mu.RUnlock()
-	snapshot := map[string]string{
+	vars := make(map[string]string, len(ctx.Vars))
+	snapshot := map[string]interface{}{
 		"Path":     ctx.Path,
 		"RepoPath": ctx.RepoPath,
 		"RepoName": ctx.RepoName,
 		"SiteName": ctx.SiteName,
 		"Branch":   ctx.Branch,
 		"DbSuffix": ctx.DbSuffix,
+
+		"BaseBranch":     ctx.BaseBranch,
+		"ProjectPath":    ctx.ProjectPath,
+		"BarePath":       ctx.BarePath,
+		"WorktreeFolder": ctx.WorktreeFolder,
+		"Timestamp":      ctx.Timestamp,
+
+		"MainWorktreePath": ctx.MainWorktreePath,
+
+		"ComposeProjectName": utils.ComposeProjectName(ctx.SiteName, ctx.DbSuffix),
 	}
 	for k, v := range ctx.Vars {
 		snapshot[k] = v
+		vars[k] = v
 	}
+	snapshot["Vars"] = vars
 	return snapshot
 }
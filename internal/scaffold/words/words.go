@@ -2,7 +2,9 @@ package words
 
 import (
 	cryptorand "crypto/rand"
+	"crypto/sha256"
 	"encoding/binary"
+	"encoding/hex"
 	"fmt"
 	"os"
 	"regexp"
@@ -37,36 +39,164 @@ const (
 	SuffixMaxLength = 25
 )
 
-func GenerateSuffix() string {
+// Naming strategies for the suffix of each worktree's databases
+const (
+	// StrategyWords suffixes databases with a random adjective_noun
+	StrategyWords = "words"
+	// StrategyBranch suffixes databases with the sanitized branch name
+	StrategyBranch = "branch"
+	// StrategyTicket suffixes databases with a ticket number such as
+	// PROJ-123 found in the branch name, falling back to words
+	StrategyTicket = "ticket"
+	// StrategyHash suffixes databases with a shortened branch name and a
+	// hash of the full name, so long branches sharing a prefix stay apart
+	StrategyHash = "hash"
+)
+
+// hashLength is the number of hex characters of the branch hash
+const hashLength = 8
+
+// DefaultTicketPattern matches ticket numbers such as PROJ-123 or abc-42
+const DefaultTicketPattern = `[A-Za-z][A-Za-z0-9]*-[0-9]+`
+
+// Naming generates database suffixes. The zero value uses random words from
+// Adjectives and Nouns.
+type Naming struct {
+	Strategy   string
+	Adjectives []string
+	Nouns      []string
+
+	ticketPattern *regexp.Regexp
+}
+
+// NewNaming returns the naming for a strategy, using the given word lists
+// in place of Adjectives and Nouns when they are not empty
+func NewNaming(strategy, ticketPattern string, adjectives, nouns []string) (Naming, error) {
+	switch strategy {
+	case "", StrategyWords, StrategyBranch, StrategyTicket, StrategyHash:
+	default:
+		return Naming{}, fmt.Errorf("unknown naming strategy %q (use %s, %s, %s or %s)", strategy, StrategyWords, StrategyBranch, StrategyTicket, StrategyHash)
+	}
+
+	naming := Naming{Strategy: strategy, Adjectives: adjectives, Nouns: nouns}
+	for _, word := range append(append([]string{}, adjectives...), nouns...) {
+		if word == "" || SanitizeSiteName(word) != word || strings.Contains(word, "_") {
+			return Naming{}, fmt.Errorf("invalid naming word %q: use lowercase letters and digits", word)
+		}
+	}
+
+	if strategy == StrategyTicket {
+		if ticketPattern == "" {
+			ticketPattern = DefaultTicketPattern
+		}
+		re, err := regexp.Compile(ticketPattern)
+		if err != nil {
+			return Naming{}, fmt.Errorf("invalid ticket pattern: %w", err)
+		}
+		naming.ticketPattern = re
+	}
+	return naming, nil
+}
+
+func (n Naming) adjectives() []string {
+	if len(n.Adjectives) > 0 {
+		return n.Adjectives
+	}
+	return Adjectives
+}
+
+func (n Naming) nouns() []string {
+	if len(n.Nouns) > 0 {
+		return n.Nouns
+	}
+	return Nouns
+}
+
+// Suffix returns a database suffix for a worktree of branch
+func (n Naming) Suffix(branch string) string {
+	if suffix := n.branchSuffix(branch); suffix != "" {
+		return suffix
+	}
+	return n.randomSuffix()
+}
+
+// Deterministic reports whether the naming always gives branch the same
+// suffix, so a worktree re-created for it finds its databases again
+func (n Naming) Deterministic(branch string) bool {
+	return n.branchSuffix(branch) != ""
+}
+
+// branchSuffix returns the suffix the strategy derives from branch, or ""
+// when random words are used
+func (n Naming) branchSuffix(branch string) string {
+	switch n.Strategy {
+	case StrategyBranch:
+		return truncateSuffix(SanitizeSiteName(branch))
+	case StrategyTicket:
+		pattern := n.ticketPattern
+		if pattern == nil {
+			pattern = regexp.MustCompile(DefaultTicketPattern)
+		}
+		return truncateSuffix(SanitizeSiteName(pattern.FindString(branch)))
+	case StrategyHash:
+		if branch != "" {
+			return hashSuffix(branch)
+		}
+	}
+	return ""
+}
+
+// hashSuffix returns the branch name cut short enough to leave room for
+// a hash of the whole name
+func hashSuffix(branch string) string {
+	sum := sha256.Sum256([]byte(branch))
+	hash := hex.EncodeToString(sum[:])[:hashLength]
+
+	slug := SanitizeSiteName(branch)
+	if maxSlug := SuffixMaxLength - hashLength - 1; len(slug) > maxSlug {
+		slug = strings.TrimRight(slug[:maxSlug], "_")
+	}
+	if slug == "" {
+		return hash
+	}
+	return slug + "_" + hash
+}
+
+func (n Naming) randomSuffix() string {
+	adjectives, nouns := n.adjectives(), n.nouns()
+
 	bytes := make([]byte, 4)
 	if _, err := cryptorand.Read(bytes); err != nil {
 		return fmt.Sprintf("%d_%d", time.Now().UnixNano()%100000, os.Getpid()%1000)
 	}
 
-	adjIndex := int(binary.LittleEndian.Uint16(bytes[0:2])) % len(Adjectives)
-	nounIndex := int(binary.LittleEndian.Uint16(bytes[2:4])) % len(Nouns)
+	adjIndex := int(binary.LittleEndian.Uint16(bytes[0:2])) % len(adjectives)
+	nounIndex := int(binary.LittleEndian.Uint16(bytes[2:4])) % len(nouns)
 
-	return fmt.Sprintf("%s_%s", Adjectives[adjIndex], Nouns[nounIndex])
+	return fmt.Sprintf("%s_%s", adjectives[adjIndex], nouns[nounIndex])
 }
 
-func SanitizeSiteName(name string) string {
-	name = strings.ToLower(name)
-	re := regexp.MustCompile(`[^a-z0-9_]`)
-	name = re.ReplaceAllString(name, "_")
-	re = regexp.MustCompile(`_+`)
-	name = re.ReplaceAllString(name, "_")
-	name = strings.Trim(name, "_")
-	return name
+// truncateSuffix shortens a branch or ticket suffix to SuffixMaxLength
+func truncateSuffix(suffix string) string {
+	if len(suffix) > SuffixMaxLength {
+		suffix = strings.TrimRight(suffix[:SuffixMaxLength], "_")
+	}
+	return suffix
 }
 
-func GenerateDatabaseName(siteName string, maxLength int) string {
+// DatabaseName names a new database for siteName on branch
+func (n Naming) DatabaseName(siteName, branch string, maxLength int) string {
+	return JoinDatabaseName(siteName, n.Suffix(branch), maxLength)
+}
+
+// JoinDatabaseName names a database for siteName with suffix, shortening the
+// site name so the whole fits in maxLength
+func JoinDatabaseName(siteName, suffix string, maxLength int) string {
 	if maxLength == 0 {
 		maxLength = MaxDbNameLength
 	}
 
 	sanitized := SanitizeSiteName(siteName)
-	suffix := GenerateSuffix()
-
 	maxSiteLen := maxLength - len(suffix) - 1
 	if len(sanitized) > maxSiteLen {
 		sanitized = sanitized[:maxSiteLen]
@@ -76,21 +206,22 @@ func GenerateDatabaseName(siteName string, maxLength int) string {
 	return fmt.Sprintf("%s_%s", sanitized, suffix)
 }
 
-func ExtractSuffix(dbName string) string {
+// wordsSuffix returns the adjective_noun suffix ending dbName, or "" when it
+// does not end in one of the naming's words
+func (n Naming) wordsSuffix(dbName string) string {
 	parts := strings.Split(dbName, "_")
 	if len(parts) < 2 {
 		return ""
 	}
+
 	lastPart := parts[len(parts)-1]
 	secondLastPart := parts[len(parts)-2]
 
-	potentialSuffix := fmt.Sprintf("%s_%s", secondLastPart, lastPart)
-
-	for _, noun := range Nouns {
+	for _, noun := range n.nouns() {
 		if noun == lastPart {
-			for _, adj := range Adjectives {
+			for _, adj := range n.adjectives() {
 				if adj == secondLastPart {
-					return potentialSuffix
+					return fmt.Sprintf("%s_%s", secondLastPart, lastPart)
 				}
 			}
 		}
@@ -98,3 +229,25 @@ func ExtractSuffix(dbName string) string {
 
 	return ""
 }
+
+func GenerateSuffix() string {
+	return Naming{}.randomSuffix()
+}
+
+func SanitizeSiteName(name string) string {
+	name = strings.ToLower(name)
+	re := regexp.MustCompile(`[^a-z0-9_]`)
+	name = re.ReplaceAllString(name, "_")
+	re = regexp.MustCompile(`_+`)
+	name = re.ReplaceAllString(name, "_")
+	name = strings.Trim(name, "_")
+	return name
+}
+
+func GenerateDatabaseName(siteName string, maxLength int) string {
+	return Naming{}.DatabaseName(siteName, "", maxLength)
+}
+
+func ExtractSuffix(dbName string) string {
+	return Naming{}.wordsSuffix(dbName)
+}
@@ -308,3 +308,120 @@ func isNoun(word string) bool {
 	}
 	return false
 }
+
+func TestNewNaming(t *testing.T) {
+	tests := []struct {
+		name       string
+		strategy   string
+		pattern    string
+		adjectives []string
+		nouns      []string
+		wantErr    bool
+	}{
+		{name: "default", strategy: ""},
+		{name: "words", strategy: StrategyWords, adjectives: []string{"red"}, nouns: []string{"fox2"}},
+		{name: "branch", strategy: StrategyBranch},
+		{name: "ticket with pattern", strategy: StrategyTicket, pattern: `ABC-\d+`},
+		{name: "unknown strategy", strategy: "uuid", wantErr: true},
+		{name: "uppercase word", strategy: StrategyWords, adjectives: []string{"Red"}, wantErr: true},
+		{name: "word with underscore", strategy: StrategyWords, nouns: []string{"red_fox"}, wantErr: true},
+		{name: "empty word", strategy: StrategyWords, nouns: []string{""}, wantErr: true},
+		{name: "invalid pattern", strategy: StrategyTicket, pattern: `(`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewNaming(tt.strategy, tt.pattern, tt.adjectives, tt.nouns)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewNaming() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNamingSuffix(t *testing.T) {
+	t.Run("custom words", func(t *testing.T) {
+		naming, err := NewNaming(StrategyWords, "", []string{"red"}, []string{"fox"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := naming.Suffix("feature/login"); got != "red_fox" {
+			t.Errorf("Suffix() = %q, want %q", got, "red_fox")
+		}
+	})
+
+	t.Run("branch", func(t *testing.T) {
+		naming, err := NewNaming(StrategyBranch, "", nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := naming.Suffix("feature/Login-Form"); got != "feature_login_form" {
+			t.Errorf("Suffix() = %q, want %q", got, "feature_login_form")
+		}
+		if got := naming.Suffix("feature/a-very-long-branch-name-indeed"); len(got) > SuffixMaxLength || strings.HasSuffix(got, "_") {
+			t.Errorf("Suffix() = %q, want at most %d characters without a trailing underscore", got, SuffixMaxLength)
+		}
+	})
+
+	t.Run("ticket", func(t *testing.T) {
+		naming, err := NewNaming(StrategyTicket, "", nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := naming.Suffix("feature/PROJ-123-login"); got != "proj_123" {
+			t.Errorf("Suffix() = %q, want %q", got, "proj_123")
+		}
+
+		parts := splitSuffix(naming.Suffix("main"))
+		if len(parts) != 2 || !isAdjective(parts[0]) || !isNoun(parts[1]) {
+			t.Errorf("Suffix() without a ticket should fall back to words, got %v", parts)
+		}
+	})
+}
+
+func TestNamingHash(t *testing.T) {
+	naming, err := NewNaming(StrategyHash, "", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	suffix := naming.Suffix("feature/login")
+	if !strings.HasPrefix(suffix, "feature_login_") || len(suffix) != len("feature_login_")+hashLength {
+		t.Errorf("Suffix() = %q, want feature_login_ and a %d character hash", suffix, hashLength)
+	}
+	if again := naming.Suffix("feature/login"); again != suffix {
+		t.Errorf("Suffix() = %q then %q, want the same suffix for a branch", suffix, again)
+	}
+
+	a := naming.Suffix("feature/a-very-long-branch-name-one")
+	b := naming.Suffix("feature/a-very-long-branch-name-two")
+	if a == b || len(a) > SuffixMaxLength {
+		t.Errorf("Suffix() = %q and %q, want distinct suffixes of at most %d characters", a, b, SuffixMaxLength)
+	}
+
+	if !naming.Deterministic("feature/login") || naming.Deterministic("") {
+		t.Error("Deterministic() should hold for named branches only")
+	}
+}
+
+func TestNamingDeterministic(t *testing.T) {
+	ticket, err := NewNaming(StrategyTicket, "", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ticket.Deterministic("feature/PROJ-1") || ticket.Deterministic("main") {
+		t.Error("ticket naming should be deterministic only for branches with a ticket")
+	}
+	if (Naming{}).Deterministic("main") {
+		t.Error("word naming should never be deterministic")
+	}
+}
+
+func TestJoinDatabaseName(t *testing.T) {
+	if got := JoinDatabaseName("My App", "proj_123", 0); got != "my_app_proj_123" {
+		t.Errorf("JoinDatabaseName() = %q, want %q", got, "my_app_proj_123")
+	}
+	if got := JoinDatabaseName("a_long_site_name", "proj_123", 16); len(got) > 16 {
+		t.Errorf("JoinDatabaseName() = %q, want at most 16 characters", got)
+	}
+}
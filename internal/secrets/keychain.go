@@ -0,0 +1,89 @@
+package secrets
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/michaeldyrynda/arbor/internal/logging"
+)
+
+// KeychainService is the service name arbor stores keychain items under
+const KeychainService = "arbor"
+
+// ErrKeychainUnavailable is returned when the platform has no supported
+// keychain tool installed
+var ErrKeychainUnavailable = errors.New("no supported keychain found")
+
+// keychainCommand runs a keychain tool, feeding stdin so secrets never appear
+// in the process list. It is replaced in tests.
+var keychainCommand = func(name string, args []string, stdin string) (string, error) {
+	if _, err := exec.LookPath(name); err != nil {
+		return "", ErrKeychainUnavailable
+	}
+
+	cmd := exec.Command(name, args...)
+	cmd.Stdin = strings.NewReader(stdin)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := logging.Run(cmd); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%s: %s", name, msg)
+		}
+		return "", fmt.Errorf("%s: %w", name, err)
+	}
+
+	return strings.TrimRight(stdout.String(), "\r\n"), nil
+}
+
+// KeychainGet reads the password stored for account, using the macOS
+// keychain or the freedesktop secret service on Linux
+func KeychainGet(account string) (string, error) {
+	switch runtime.GOOS {
+	case "darwin":
+		return keychainCommand("security", []string{"find-generic-password", "-s", KeychainService, "-a", account, "-w"}, "")
+	case "linux":
+		return keychainCommand("secret-tool", []string{"lookup", "service", KeychainService, "account", account}, "")
+	default:
+		return "", ErrKeychainUnavailable
+	}
+}
+
+// KeychainSet stores password for account, replacing any existing item
+func KeychainSet(account, password string) error {
+	var err error
+	switch runtime.GOOS {
+	case "darwin":
+		// security's interactive mode reads the command from stdin
+		command := fmt.Sprintf("add-generic-password -U -s %s -a %s -w %s\n",
+			quoteSecurityArg(KeychainService), quoteSecurityArg(account), quoteSecurityArg(password))
+		_, err = keychainCommand("security", []string{"-i"}, command)
+	case "linux":
+		_, err = keychainCommand("secret-tool", []string{"store", "--label", "arbor: " + account, "service", KeychainService, "account", account}, password)
+	default:
+		err = ErrKeychainUnavailable
+	}
+	return err
+}
+
+// quoteSecurityArg quotes s for a command read by `security -i`
+func quoteSecurityArg(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
+
+// keychainProvider reads a password stored by arbor, e.g. ${keychain:root@127.0.0.1:3306}
+func keychainProvider(ref, dir string) (string, error) {
+	value, err := KeychainGet(ref)
+	if err != nil {
+		return "", fmt.Errorf("keychain %s: %w", ref, err)
+	}
+	return value, nil
+}
@@ -0,0 +1,49 @@
+package secrets
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeychain(t *testing.T) {
+	if runtime.GOOS != "darwin" && runtime.GOOS != "linux" {
+		t.Skip("keychain is only supported on macOS and Linux")
+	}
+
+	type call struct {
+		name  string
+		args  []string
+		stdin string
+	}
+	var calls []call
+
+	original := keychainCommand
+	t.Cleanup(func() { keychainCommand = original })
+	keychainCommand = func(name string, args []string, stdin string) (string, error) {
+		calls = append(calls, call{name, args, stdin})
+		return "secret", nil
+	}
+
+	password, err := KeychainGet("root@127.0.0.1:3306")
+	require.NoError(t, err)
+	assert.Equal(t, "secret", password)
+
+	require.NoError(t, KeychainSet("root@127.0.0.1:3306", `pa"ss`))
+	require.Len(t, calls, 2)
+
+	for _, c := range calls {
+		for _, arg := range c.args {
+			assert.NotContains(t, arg, "pa\"ss", "passwords must not be passed as arguments")
+		}
+	}
+
+	if runtime.GOOS == "darwin" {
+		assert.Equal(t, "add-generic-password -U -s \"arbor\" -a \"root@127.0.0.1:3306\" -w \"pa\\\"ss\"\n", calls[1].stdin)
+	} else {
+		assert.Equal(t, []string{"lookup", "service", "arbor", "account", "root@127.0.0.1:3306"}, calls[0].args)
+		assert.Equal(t, `pa"ss`, calls[1].stdin)
+	}
+}
@@ -0,0 +1,232 @@
+package secrets
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/michaeldyrynda/arbor/internal/config"
+	"github.com/michaeldyrynda/arbor/internal/logging"
+	"github.com/michaeldyrynda/arbor/internal/utils"
+)
+
+// referencePattern matches ${provider:ref} references. Anything else, such as
+// ${APP_NAME} in an env value, is left as written.
+var referencePattern = regexp.MustCompile(`\$\{([a-z][a-z0-9]*):([^}]*)\}`)
+
+// SecretProvider resolves a provider reference to its value. dir is the
+// directory relative paths are resolved against, usually the worktree.
+type SecretProvider func(ref, dir string) (string, error)
+
+var (
+	providersMu sync.RWMutex
+	providers   = map[string]SecretProvider{
+		"env":      envProvider,
+		"file":     fileProvider,
+		"keychain": keychainProvider,
+		"op":       onePasswordProvider,
+		"vault":    vaultProvider,
+	}
+)
+
+// RegisterProvider registers a secret provider for ${scheme:ref} references
+func RegisterProvider(scheme string, provider SecretProvider) {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+	providers[scheme] = provider
+}
+
+func getProvider(scheme string) (SecretProvider, bool) {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+	provider, ok := providers[scheme]
+	return provider, ok
+}
+
+// Interpolator replaces ${...} references in config values. Each reference is
+// resolved at most once, so a secret used by several steps is only fetched once.
+type Interpolator struct {
+	Dir   string
+	cache map[string]string
+	// preview leaves references as written
+	preview bool
+}
+
+func NewInterpolator(dir string) *Interpolator {
+	return &Interpolator{
+		Dir:   dir,
+		cache: make(map[string]string),
+	}
+}
+
+// NewPreviewInterpolator returns an interpolator that leaves every reference
+// as written, so a preview shows which secrets would be used without
+// fetching or printing them
+func NewPreviewInterpolator(dir string) *Interpolator {
+	i := NewInterpolator(dir)
+	i.preview = true
+	return i
+}
+
+// Interpolate replaces every ${provider:ref} reference in s whose provider is
+// registered, e.g. ${env:NAME} or ${op://vault/item/field}. References to
+// unknown providers are left as written.
+func (i *Interpolator) Interpolate(s string) (string, error) {
+	if i.preview {
+		return s, nil
+	}
+
+	var resolveErr error
+	result := referencePattern.ReplaceAllStringFunc(s, func(match string) string {
+		if resolveErr != nil {
+			return match
+		}
+		parts := referencePattern.FindStringSubmatch(match)
+		provider, ok := getProvider(parts[1])
+		if !ok {
+			return match
+		}
+		value, err := i.resolve(provider, parts[1], parts[2])
+		if err != nil {
+			resolveErr = err
+			return match
+		}
+		return value
+	})
+	if resolveErr != nil {
+		return "", resolveErr
+	}
+	return result, nil
+}
+
+// InterpolateStep returns a copy of step with references in its value, args,
+// settings and env resolved. Commands are left alone as the shell expands
+// them itself.
+func (i *Interpolator) InterpolateStep(step config.StepConfig) (config.StepConfig, error) {
+	value, err := i.Interpolate(step.Value)
+	if err != nil {
+		return step, fmt.Errorf("step %s: %w", step.Name, err)
+	}
+	step.Value = value
+
+	if step.Args != nil {
+		args := make([]string, len(step.Args))
+		for n, arg := range step.Args {
+			args[n], err = i.Interpolate(arg)
+			if err != nil {
+				return step, fmt.Errorf("step %s: %w", step.Name, err)
+			}
+		}
+		step.Args = args
+	}
+
+	if step.Settings != nil {
+		settings := make(map[string]string, len(step.Settings))
+		for key, setting := range step.Settings {
+			settings[key], err = i.Interpolate(setting)
+			if err != nil {
+				return step, fmt.Errorf("step %s: %w", step.Name, err)
+			}
+		}
+		step.Settings = settings
+	}
+
+	if step.Env != nil {
+		env := make(map[string]string, len(step.Env))
+		for name, value := range step.Env {
+			env[name], err = i.Interpolate(value)
+			if err != nil {
+				return step, fmt.Errorf("step %s: %w", step.Name, err)
+			}
+		}
+		step.Env = env
+	}
+
+	return step, nil
+}
+
+// InterpolateDatabase returns a copy of db with references resolved, so
+// credentials can be read from the environment or a secret manager
+func (i *Interpolator) InterpolateDatabase(db config.DatabaseConfig) (config.DatabaseConfig, error) {
+	fields := []*string{&db.Host, &db.Port, &db.Username, &db.Password, &db.SSLMode, &db.SSLCA, &db.Socket, &db.Database}
+	for _, field := range fields {
+		value, err := i.Interpolate(*field)
+		if err != nil {
+			return db, fmt.Errorf("db: %w", err)
+		}
+		*field = value
+	}
+	return db, nil
+}
+
+func (i *Interpolator) resolve(provider SecretProvider, scheme, ref string) (string, error) {
+	reference := scheme + ":" + ref
+	if value, ok := i.cache[reference]; ok {
+		return value, nil
+	}
+
+	value, err := provider(ref, i.Dir)
+	if err != nil {
+		return "", fmt.Errorf("resolving ${%s}: %w", reference, err)
+	}
+
+	i.cache[reference] = value
+	return value, nil
+}
+
+func envProvider(ref, dir string) (string, error) {
+	value, ok := os.LookupEnv(ref)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", ref)
+	}
+	return value, nil
+}
+
+// fileProvider reads a key from an env file, e.g. ${file:.env.secrets#DB_PASSWORD}
+func fileProvider(ref, dir string) (string, error) {
+	file, key, ok := strings.Cut(ref, "#")
+	if !ok || file == "" || key == "" {
+		return "", fmt.Errorf("expected file:PATH#KEY")
+	}
+
+	env := utils.ReadEnvFile(dir, file)
+	value, ok := env[key]
+	if !ok {
+		return "", fmt.Errorf("%s not found in %s", key, file)
+	}
+	return value, nil
+}
+
+// onePasswordProvider reads a secret with the 1Password CLI, e.g. ${op://vault/item/field}
+func onePasswordProvider(ref, dir string) (string, error) {
+	return runProvider("op", "read", "op:"+ref)
+}
+
+// vaultProvider reads a field with the Vault CLI, e.g. ${vault:secret/myapp#password}
+func vaultProvider(ref, dir string) (string, error) {
+	path, field, ok := strings.Cut(ref, "#")
+	if !ok || path == "" || field == "" {
+		return "", fmt.Errorf("expected vault:PATH#FIELD")
+	}
+	return runProvider("vault", "kv", "get", "-field="+field, path)
+}
+
+func runProvider(name string, args ...string) (string, error) {
+	if _, err := exec.LookPath(name); err != nil {
+		return "", fmt.Errorf("%s not found in PATH", name)
+	}
+
+	cmd := exec.Command(name, args...)
+	output, err := logging.Output(cmd)
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+			return "", fmt.Errorf("%s: %s", name, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", fmt.Errorf("%s: %w", name, err)
+	}
+
+	return strings.TrimRight(string(output), "\r\n"), nil
+}
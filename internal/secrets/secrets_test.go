@@ -0,0 +1,139 @@
+package secrets
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/michaeldyrynda/arbor/internal/config"
+)
+
+func TestInterpolator_Interpolate(t *testing.T) {
+	t.Run("resolves process environment variables", func(t *testing.T) {
+		t.Setenv("OP_TOKEN", "s3cret")
+
+		result, err := NewInterpolator(t.TempDir()).Interpolate("${env:OP_TOKEN}")
+
+		require.NoError(t, err)
+		assert.Equal(t, "s3cret", result)
+	})
+
+	t.Run("errors on unset environment variable", func(t *testing.T) {
+		_, err := NewInterpolator(t.TempDir()).Interpolate("${env:ARBOR_TEST_UNSET_VARIABLE}")
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "ARBOR_TEST_UNSET_VARIABLE is not set")
+	})
+
+	t.Run("leaves strings without references untouched", func(t *testing.T) {
+		result, err := NewInterpolator(t.TempDir()).Interpolate("{{ .SiteName }}_$HOME")
+
+		require.NoError(t, err)
+		assert.Equal(t, "{{ .SiteName }}_$HOME", result)
+	})
+
+	t.Run("leaves references without a known provider as written", func(t *testing.T) {
+		t.Setenv("APP_NAME", "Laravel")
+
+		result, err := NewInterpolator(t.TempDir()).Interpolate("${APP_NAME} ${bogus:thing} ${DB_HOST:-127.0.0.1}")
+
+		require.NoError(t, err)
+		assert.Equal(t, "${APP_NAME} ${bogus:thing} ${DB_HOST:-127.0.0.1}", result)
+	})
+
+	t.Run("reads keys from env files", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, ".env.secrets"), []byte("DB_PASSWORD=hunter2\n"), 0644))
+
+		result, err := NewInterpolator(dir).Interpolate("${file:.env.secrets#DB_PASSWORD}")
+
+		require.NoError(t, err)
+		assert.Equal(t, "hunter2", result)
+	})
+
+	t.Run("errors on missing env file key", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, ".env.secrets"), []byte("OTHER=1\n"), 0644))
+
+		_, err := NewInterpolator(dir).Interpolate("${file:.env.secrets#DB_PASSWORD}")
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "DB_PASSWORD not found")
+	})
+
+	t.Run("reports missing provider binaries", func(t *testing.T) {
+		t.Setenv("PATH", "")
+
+		_, err := NewInterpolator(t.TempDir()).Interpolate("${op://vault/item/password}")
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "op not found in PATH")
+	})
+
+	t.Run("preview leaves references as written", func(t *testing.T) {
+		t.Setenv("PATH", "")
+
+		result, err := NewPreviewInterpolator(t.TempDir()).Interpolate("--password=${op://vault/item/password}")
+
+		require.NoError(t, err)
+		assert.Equal(t, "--password=${op://vault/item/password}", result)
+	})
+}
+
+func TestRegisterProvider(t *testing.T) {
+	calls := 0
+	RegisterProvider("test", func(ref, dir string) (string, error) {
+		calls++
+		return "resolved-" + ref, nil
+	})
+
+	interpolator := NewInterpolator(t.TempDir())
+
+	first, err := interpolator.Interpolate("${test:key}")
+	require.NoError(t, err)
+	second, err := interpolator.Interpolate("${test:key}-${test:key}")
+	require.NoError(t, err)
+
+	assert.Equal(t, "resolved-key", first)
+	assert.Equal(t, "resolved-key-resolved-key", second)
+	assert.Equal(t, 1, calls, "references should be resolved once per interpolator")
+}
+
+func TestInterpolator_InterpolateStep(t *testing.T) {
+	t.Setenv("API_TOKEN", "abc123")
+
+	step := config.StepConfig{
+		Name:    "env.write",
+		Value:   "${env:API_TOKEN}",
+		Args:    []string{"--token", "${env:API_TOKEN}"},
+		Command: "echo ${env:SHELL_ONLY}",
+		Env:     map[string]string{"npm_token": "${env:API_TOKEN}"},
+	}
+
+	result, err := NewInterpolator(t.TempDir()).InterpolateStep(step)
+
+	require.NoError(t, err)
+	assert.Equal(t, "abc123", result.Value)
+	assert.Equal(t, []string{"--token", "abc123"}, result.Args)
+	assert.Equal(t, "echo ${env:SHELL_ONLY}", result.Command)
+	assert.Equal(t, map[string]string{"npm_token": "abc123"}, result.Env)
+	assert.Equal(t, "${env:API_TOKEN}", step.Args[1], "original step args should not be modified")
+}
+
+func TestInterpolator_InterpolateDatabase(t *testing.T) {
+	t.Setenv("DB_PASS", "hunter2")
+
+	db, err := NewInterpolator(t.TempDir()).InterpolateDatabase(config.DatabaseConfig{
+		Host:     "127.0.0.1",
+		Username: "root",
+		Password: "${env:DB_PASS}",
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "127.0.0.1", db.Host)
+	assert.Equal(t, "root", db.Username)
+	assert.Equal(t, "hunter2", db.Password)
+}
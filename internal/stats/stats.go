@@ -0,0 +1,149 @@
+// Package stats keeps an opt-in, local record of how long scaffold steps
+// take, so users can see which steps are worth caching or parallelising.
+// Nothing is sent anywhere.
+package stats
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// FileName is the stats file kept in the global config directory
+const FileName = "stats.jsonl"
+
+// Record is one run of one scaffold step
+type Record struct {
+	Time       time.Time `json:"time"`
+	Project    string    `json:"project"`
+	Step       string    `json:"step"`
+	DurationMs int64     `json:"durationMs"`
+	Failed     bool      `json:"failed,omitempty"`
+}
+
+// Duration returns how long the step took
+func (r Record) Duration() time.Duration {
+	return time.Duration(r.DurationMs) * time.Millisecond
+}
+
+// Summary is the timing of a step across its recorded runs
+type Summary struct {
+	Step     string        `json:"step"`
+	Runs     int           `json:"runs"`
+	Failures int           `json:"failures"`
+	Mean     time.Duration `json:"mean"`
+	P50      time.Duration `json:"p50"`
+	P90      time.Duration `json:"p90"`
+	Max      time.Duration `json:"max"`
+	Total    time.Duration `json:"total"`
+}
+
+// Append adds records to the stats file at path
+func Append(path string, records []Record) error {
+	if len(records) == 0 {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("opening stats: %w", err)
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	for _, record := range records {
+		data, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(append(data, '\n')); err != nil {
+			return fmt.Errorf("writing stats: %w", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("writing stats: %w", err)
+	}
+	return nil
+}
+
+// Read returns the records in the stats file at path. A missing file has no
+// records, and lines that cannot be parsed are skipped.
+func Read(path string) ([]Record, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("opening stats: %w", err)
+	}
+	defer f.Close()
+
+	var records []Record
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var record Record
+		if json.Unmarshal(scanner.Bytes(), &record) == nil && record.Step != "" {
+			records = append(records, record)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading stats: %w", err)
+	}
+	return records, nil
+}
+
+// Summarise groups records by step, slowest mean first. Failed runs are
+// counted but left out of the timings, since they usually stop early.
+func Summarise(records []Record) []Summary {
+	durations := make(map[string][]time.Duration)
+	failures := make(map[string]int)
+	var order []string
+	for _, record := range records {
+		if _, seen := durations[record.Step]; !seen {
+			durations[record.Step] = nil
+			order = append(order, record.Step)
+		}
+		if record.Failed {
+			failures[record.Step]++
+			continue
+		}
+		durations[record.Step] = append(durations[record.Step], record.Duration())
+	}
+
+	summaries := make([]Summary, 0, len(order))
+	for _, step := range order {
+		times := durations[step]
+		summary := Summary{Step: step, Runs: len(times) + failures[step], Failures: failures[step]}
+		if len(times) > 0 {
+			sort.Slice(times, func(i, j int) bool { return times[i] < times[j] })
+			for _, d := range times {
+				summary.Total += d
+			}
+			summary.Mean = summary.Total / time.Duration(len(times))
+			summary.P50 = percentile(times, 50)
+			summary.P90 = percentile(times, 90)
+			summary.Max = times[len(times)-1]
+		}
+		summaries = append(summaries, summary)
+	}
+
+	sort.SliceStable(summaries, func(i, j int) bool { return summaries[i].Mean > summaries[j].Mean })
+	return summaries
+}
+
+// percentile returns the nearest-rank percentile p of sorted durations
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
@@ -0,0 +1,56 @@
+package stats
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppendAndRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "arbor", FileName)
+
+	records, err := Read(path)
+	require.NoError(t, err)
+	assert.Empty(t, records)
+
+	require.NoError(t, Append(path, []Record{
+		{Project: "/code/app", Step: "php.composer", DurationMs: 95000},
+		{Project: "/code/app", Step: "node.npm", DurationMs: 60000, Failed: true},
+	}))
+	require.NoError(t, Append(path, []Record{{Project: "/code/app", Step: "php.composer", DurationMs: 90000}}))
+
+	records, err = Read(path)
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	assert.Equal(t, 95*time.Second, records[0].Duration())
+	assert.True(t, records[1].Failed)
+}
+
+func TestSummarise(t *testing.T) {
+	var records []Record
+	for i := 1; i <= 10; i++ {
+		records = append(records, Record{Step: "php.composer", DurationMs: int64(i) * 10000})
+	}
+	records = append(records,
+		Record{Step: "env.write", DurationMs: 5},
+		Record{Step: "php.composer", DurationMs: 1000, Failed: true},
+	)
+
+	summaries := Summarise(records)
+	require.Len(t, summaries, 2)
+
+	composer := summaries[0]
+	assert.Equal(t, "php.composer", composer.Step)
+	assert.Equal(t, 11, composer.Runs)
+	assert.Equal(t, 1, composer.Failures)
+	assert.Equal(t, 55*time.Second, composer.Mean)
+	assert.Equal(t, 50*time.Second, composer.P50)
+	assert.Equal(t, 90*time.Second, composer.P90)
+	assert.Equal(t, 100*time.Second, composer.Max)
+
+	assert.Equal(t, "env.write", summaries[1].Step)
+	assert.Equal(t, 5*time.Millisecond, summaries[1].P90)
+}
@@ -0,0 +1,42 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// CopyToClipboard copies text using pbcopy on macOS, wl-copy or xclip on
+// Linux and clip on Windows
+func CopyToClipboard(text string) error {
+	cmd := clipboardCommand(runtime.GOOS, os.Getenv("WAYLAND_DISPLAY") != "")
+	if cmd == nil {
+		return fmt.Errorf("copying to the clipboard is not supported on %s", runtime.GOOS)
+	}
+	if _, err := exec.LookPath(cmd.Args[0]); err != nil {
+		return fmt.Errorf("%s is not installed", cmd.Args[0])
+	}
+
+	cmd.Stdin = strings.NewReader(text)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("copying to the clipboard: %w\n%s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+func clipboardCommand(goos string, wayland bool) *exec.Cmd {
+	switch goos {
+	case "darwin":
+		return exec.Command("pbcopy")
+	case "linux", "freebsd", "openbsd", "netbsd":
+		if wayland {
+			return exec.Command("wl-copy")
+		}
+		return exec.Command("xclip", "-selection", "clipboard")
+	case "windows":
+		return exec.Command("clip")
+	}
+	return nil
+}
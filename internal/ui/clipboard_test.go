@@ -0,0 +1,15 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClipboardCommand(t *testing.T) {
+	assert.Equal(t, []string{"pbcopy"}, clipboardCommand("darwin", false).Args)
+	assert.Equal(t, []string{"wl-copy"}, clipboardCommand("linux", true).Args)
+	assert.Equal(t, []string{"xclip", "-selection", "clipboard"}, clipboardCommand("linux", false).Args)
+	assert.Equal(t, []string{"clip"}, clipboardCommand("windows", false).Args)
+	assert.Nil(t, clipboardCommand("plan9", false))
+}
@@ -0,0 +1,347 @@
+package ui
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/michaeldyrynda/arbor/internal/git"
+)
+
+// DashboardRefresh is how often the dashboard reloads worktree status
+const DashboardRefresh = 5 * time.Second
+
+// DashboardRow is a worktree along with the live status shown by the dashboard
+type DashboardRow struct {
+	git.Worktree
+	Dirty     bool
+	Ahead     int
+	Behind    int
+	Processes []string
+	// Err is why the worktree's status could not be read, which the row
+	// shows in place of its status
+	Err error
+}
+
+// DashboardActions connects the dashboard to arbor. Commands returned by the
+// actions run in the foreground while the dashboard is suspended, so they
+// may prompt as usual. A nil action disables its keybinding.
+type DashboardActions struct {
+	Load   func() ([]DashboardRow, error)
+	Log    func(wt git.Worktree) (string, error)
+	Create func(branch string) *exec.Cmd
+	Open   func(wt git.Worktree) *exec.Cmd
+	Shell  func(wt git.Worktree) *exec.Cmd
+	Remove func(wt git.Worktree) *exec.Cmd
+	Prune  func() *exec.Cmd
+}
+
+// RunDashboard shows the full-screen worktree dashboard until the user quits
+func RunDashboard(title string, actions DashboardActions) error {
+	_, err := tea.NewProgram(newDashboardModel(title, actions), tea.WithAltScreen()).Run()
+	return err
+}
+
+type dashboardMode int
+
+const (
+	dashboardList dashboardMode = iota
+	dashboardCreate
+	dashboardLog
+)
+
+type (
+	dashboardLoadedMsg struct {
+		rows []DashboardRow
+		err  error
+	}
+	dashboardLogMsg struct {
+		branch string
+		log    string
+		err    error
+	}
+	dashboardExecMsg struct{ err error }
+	dashboardTickMsg struct{}
+)
+
+type dashboardModel struct {
+	title   string
+	actions DashboardActions
+	rows    []DashboardRow
+	cursor  int
+	mode    dashboardMode
+	input   textinput.Model
+	log     viewport.Model
+	logName string
+	err     error
+	width   int
+	height  int
+}
+
+func newDashboardModel(title string, actions DashboardActions) dashboardModel {
+	input := textinput.New()
+	input.Placeholder = "feature/my-branch"
+	input.Prompt = "Branch: "
+
+	return dashboardModel{
+		title:   title,
+		actions: actions,
+		input:   input,
+		log:     viewport.New(80, 20),
+	}
+}
+
+func (m dashboardModel) Init() tea.Cmd {
+	return tea.Batch(m.load(), m.tick())
+}
+
+func (m dashboardModel) load() tea.Cmd {
+	return func() tea.Msg {
+		rows, err := m.actions.Load()
+		return dashboardLoadedMsg{rows: rows, err: err}
+	}
+}
+
+func (m dashboardModel) tick() tea.Cmd {
+	return tea.Tick(DashboardRefresh, func(time.Time) tea.Msg { return dashboardTickMsg{} })
+}
+
+// exec suspends the dashboard to run cmd, reloading once it exits
+func (m dashboardModel) exec(cmd *exec.Cmd) tea.Cmd {
+	if cmd == nil {
+		return nil
+	}
+	return tea.ExecProcess(cmd, func(err error) tea.Msg { return dashboardExecMsg{err: err} })
+}
+
+func (m dashboardModel) selected() (git.Worktree, bool) {
+	if m.cursor < 0 || m.cursor >= len(m.rows) {
+		return git.Worktree{}, false
+	}
+	return m.rows[m.cursor].Worktree, true
+}
+
+func (m dashboardModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.log.Width = msg.Width
+		m.log.Height = max(msg.Height-4, 1)
+		return m, nil
+
+	case dashboardLoadedMsg:
+		m.err = msg.err
+		if msg.err == nil {
+			m.rows = msg.rows
+		}
+		m.cursor = min(m.cursor, max(len(m.rows)-1, 0))
+		return m, nil
+
+	case dashboardTickMsg:
+		return m, tea.Batch(m.load(), m.tick())
+
+	case dashboardExecMsg:
+		m.err = msg.err
+		return m, m.load()
+
+	case dashboardLogMsg:
+		if msg.err != nil {
+			m.err = msg.err
+			return m, nil
+		}
+		m.logName = msg.branch
+		m.log.SetContent(msg.log)
+		m.log.GotoTop()
+		m.mode = dashboardLog
+		return m, nil
+
+	case tea.KeyMsg:
+		switch m.mode {
+		case dashboardCreate:
+			return m.updateCreate(msg)
+		case dashboardLog:
+			return m.updateLog(msg)
+		default:
+			return m.updateList(msg)
+		}
+	}
+
+	return m, nil
+}
+
+func (m dashboardModel) updateList(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	wt, ok := m.selected()
+
+	switch msg.String() {
+	case "q", "ctrl+c", "esc":
+		return m, tea.Quit
+	case "up", "k":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case "down", "j":
+		if m.cursor < len(m.rows)-1 {
+			m.cursor++
+		}
+	case "r":
+		return m, m.load()
+	case "n":
+		if m.actions.Create != nil {
+			m.mode = dashboardCreate
+			m.input.SetValue("")
+			return m, m.input.Focus()
+		}
+	case "o", "enter":
+		if ok && m.actions.Open != nil {
+			return m, m.exec(m.actions.Open(wt))
+		}
+	case "s":
+		if ok && m.actions.Shell != nil {
+			return m, m.exec(m.actions.Shell(wt))
+		}
+	case "d":
+		if ok && !wt.IsMain && m.actions.Remove != nil {
+			return m, m.exec(m.actions.Remove(wt))
+		}
+	case "p":
+		if m.actions.Prune != nil {
+			return m, m.exec(m.actions.Prune())
+		}
+	case "l":
+		if ok && m.actions.Log != nil {
+			log := m.actions.Log
+			return m, func() tea.Msg {
+				text, err := log(wt)
+				return dashboardLogMsg{branch: wt.Branch, log: text, err: err}
+			}
+		}
+	}
+
+	return m, nil
+}
+
+func (m dashboardModel) updateCreate(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "ctrl+c":
+		m.mode = dashboardList
+		m.input.Blur()
+		return m, nil
+	case "enter":
+		branch := strings.TrimSpace(m.input.Value())
+		m.mode = dashboardList
+		m.input.Blur()
+		if branch == "" {
+			return m, nil
+		}
+		return m, m.exec(m.actions.Create(branch))
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+func (m dashboardModel) updateLog(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "q", "ctrl+c":
+		m.mode = dashboardList
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.log, cmd = m.log.Update(msg)
+	return m, cmd
+}
+
+func (m dashboardModel) View() string {
+	var b strings.Builder
+
+	b.WriteString(HeaderStyle.Render("🌳 " + m.title))
+	b.WriteString("\n")
+
+	switch m.mode {
+	case dashboardLog:
+		b.WriteString(MutedStyle.Render("Log for " + m.logName))
+		b.WriteString("\n")
+		b.WriteString(m.log.View())
+		b.WriteString("\n")
+		b.WriteString(MutedStyle.Render("↑/↓ scroll • esc back"))
+		return b.String()
+	case dashboardCreate:
+		b.WriteString(m.input.View())
+		b.WriteString("\n\n")
+		b.WriteString(MutedStyle.Render("enter create • esc cancel"))
+		return b.String()
+	}
+
+	if len(m.rows) == 0 {
+		b.WriteString(MutedStyle.Render("No worktrees found"))
+		b.WriteString("\n")
+	}
+
+	folderWidth, branchWidth := 6, 6
+	for _, row := range m.rows {
+		folderWidth = max(folderWidth, lipgloss.Width(filepath.Base(row.Path)))
+		branchWidth = max(branchWidth, lipgloss.Width(row.Branch))
+	}
+
+	for i, row := range m.rows {
+		cursor := "  "
+		if i == m.cursor {
+			cursor = lipgloss.NewStyle().Foreground(Primary).Render("› ")
+		}
+
+		folder := fmt.Sprintf("%-*s", folderWidth, filepath.Base(row.Path))
+		switch {
+		case row.IsCurrent:
+			folder = CurrentWorktreeStyle.Render(folder)
+		case row.IsMain:
+			folder = MainWorktreeStyle.Render(folder)
+		}
+
+		fmt.Fprintf(&b, "%s%s  %-*s  %s\n", cursor, folder, branchWidth, row.Branch, formatDashboardStatus(row))
+	}
+
+	if m.err != nil {
+		b.WriteString("\n")
+		b.WriteString(lipgloss.NewStyle().Foreground(ColorError).Render(m.err.Error()))
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n")
+	b.WriteString(MutedStyle.Render("n new • o open • s shell • d remove • p prune • l log • r refresh • q quit"))
+	return b.String()
+}
+
+func formatDashboardStatus(row DashboardRow) string {
+	if row.Err != nil {
+		return lipgloss.NewStyle().Foreground(ColorError).Render("⚠ " + row.Err.Error())
+	}
+
+	var parts []string
+
+	if row.IsMerged && !row.IsMain {
+		parts = append(parts, lipgloss.NewStyle().Foreground(ColorSuccess).Render("merged"))
+	}
+	if row.Dirty {
+		parts = append(parts, lipgloss.NewStyle().Foreground(ColorWarning).Render("dirty"))
+	}
+	if row.Ahead > 0 || row.Behind > 0 {
+		parts = append(parts, lipgloss.NewStyle().Foreground(ColorInfo).Render(fmt.Sprintf("↑%d ↓%d", row.Ahead, row.Behind)))
+	}
+	if len(row.Processes) > 0 {
+		parts = append(parts, MutedStyle.Render("▶ "+strings.Join(row.Processes, ", ")))
+	}
+
+	if len(parts) == 0 {
+		return MutedStyle.Render("clean")
+	}
+	return strings.Join(parts, "  ")
+}
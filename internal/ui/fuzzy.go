@@ -0,0 +1,86 @@
+package ui
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// FuzzyMatch reports whether every character of pattern appears in s in
+// order, ignoring case. Higher scores mean a better match: consecutive
+// characters and characters starting a word, such as the "l" in
+// "feature/login", score more than scattered ones.
+func FuzzyMatch(pattern, s string) (int, bool) {
+	if pattern == "" {
+		return 0, true
+	}
+
+	target := []rune(strings.ToLower(s))
+	original := []rune(s)
+	score := 0
+	last := -1
+
+	for _, p := range strings.ToLower(pattern) {
+		if unicode.IsSpace(p) {
+			continue
+		}
+
+		found := -1
+		for i := last + 1; i < len(target); i++ {
+			if target[i] == p {
+				found = i
+				break
+			}
+		}
+		if found < 0 {
+			return 0, false
+		}
+
+		score++
+		if found == last+1 {
+			score += 3
+		}
+		if found == 0 || isWordBoundary(original[found-1], original[found]) {
+			score += 2
+		}
+		last = found
+	}
+
+	// Prefer shorter candidates when matches are otherwise equal
+	return score*100 - len(target), true
+}
+
+func isWordBoundary(prev, current rune) bool {
+	if !unicode.IsLetter(prev) && !unicode.IsDigit(prev) {
+		return true
+	}
+	return unicode.IsLower(prev) && unicode.IsUpper(current)
+}
+
+// FuzzyFilter returns the indexes of candidates matching pattern, best match
+// first. Candidates with equal scores keep their original order.
+func FuzzyFilter(pattern string, candidates []string) []int {
+	type match struct {
+		index int
+		score int
+	}
+
+	var matches []match
+	for i, candidate := range candidates {
+		if score, ok := FuzzyMatch(pattern, candidate); ok {
+			matches = append(matches, match{index: i, score: score})
+		}
+	}
+
+	if strings.TrimSpace(pattern) != "" {
+		sort.SliceStable(matches, func(i, j int) bool {
+			return matches[i].score > matches[j].score
+		})
+	}
+
+	indexes := make([]int, len(matches))
+	for i, m := range matches {
+		indexes[i] = m.index
+	}
+	return indexes
+}
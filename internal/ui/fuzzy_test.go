@@ -0,0 +1,46 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFuzzyMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		target  string
+		match   bool
+	}{
+		{"", "main", true},
+		{"flog", "feature/login", true},
+		{"FLOG", "feature/login", true},
+		{"login", "feature/login", true},
+		{"lgn", "feature/login", true},
+		{"nigol", "feature/login", false},
+		{"xyz", "feature/login", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+"/"+tt.target, func(t *testing.T) {
+			_, ok := FuzzyMatch(tt.pattern, tt.target)
+			assert.Equal(t, tt.match, ok)
+		})
+	}
+}
+
+func TestFuzzyFilter(t *testing.T) {
+	candidates := []string{"fix/logging-output", "feature/login", "main", "origin/feature/login-page"}
+
+	t.Run("empty pattern keeps order", func(t *testing.T) {
+		assert.Equal(t, []int{0, 1, 2, 3}, FuzzyFilter("", candidates))
+	})
+
+	t.Run("ranks consecutive and word-start matches first", func(t *testing.T) {
+		assert.Equal(t, []int{1, 3, 0}, FuzzyFilter("login", candidates))
+	})
+
+	t.Run("drops candidates that do not match", func(t *testing.T) {
+		assert.Equal(t, []int{2}, FuzzyFilter("mn", candidates))
+	})
+}
@@ -4,46 +4,88 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/charmbracelet/huh"
 
+	"github.com/michaeldyrynda/arbor/internal/config"
 	"github.com/michaeldyrynda/arbor/internal/git"
 )
 
+// SelectBranchInteractive picks a local or remote branch with a fuzzy search,
+// showing when each branch was last committed to. Branches are listed most
+// recently updated first.
 func SelectBranchInteractive(barePath string, localBranches, remoteBranches []string) (string, error) {
-	var selected string
+	dates, err := git.BranchCommitDates(barePath)
+	if err != nil {
+		return "", err
+	}
 
-	options := []huh.Option[string]{
-		huh.NewOption("Create new branch...", "__new__"),
+	items := []PickerItem{
+		{Label: "Create new branch...", Value: "__new__", Pinned: true},
 	}
+	items = append(items, branchItems(localBranches, dates, "")...)
+	items = append(items, branchItems(remoteBranches, dates, "↓ ")...)
 
-	for _, b := range localBranches {
-		options = append(options, huh.NewOption(b, b))
+	selected, err := FuzzySelect("Select a branch", items)
+	if err != nil {
+		return "", err
 	}
 
-	for _, b := range remoteBranches {
-		options = append(options, huh.NewOption("↓ "+b, b))
+	if selected == "__new__" {
+		return PromptNewBranch()
 	}
 
-	form := huh.NewForm(
-		huh.NewGroup(
-			huh.NewSelect[string]().
-				Title("Select a branch").
-				Description("Choose an existing branch or create a new one").
-				Options(options...).
-				Value(&selected),
-		),
-	).WithTheme(huh.ThemeCatppuccin())
+	return selected, nil
+}
 
-	if err := form.Run(); err != nil {
-		return "", NormalizeAbort(err)
+// branchItems builds picker items for branches, newest commit first
+func branchItems(branches []string, dates map[string]time.Time, prefix string) []PickerItem {
+	var items []PickerItem
+	for _, b := range branches {
+		if strings.Contains(b, " -> ") {
+			continue
+		}
+		items = append(items, PickerItem{Label: prefix + b, Value: b, Detail: RelativeTime(dates[b])})
 	}
 
-	if selected == "__new__" {
-		return PromptNewBranch()
+	sort.SliceStable(items, func(i, j int) bool {
+		return dates[items[i].Value].After(dates[items[j].Value])
+	})
+	return items
+}
+
+// RelativeTime describes how long ago t was, e.g. "3 days ago"
+func RelativeTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
 	}
 
-	return selected, nil
+	plural := func(n int, unit string) string {
+		if n == 1 {
+			return fmt.Sprintf("1 %s ago", unit)
+		}
+		return fmt.Sprintf("%d %ss ago", n, unit)
+	}
+
+	d := time.Since(t)
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return plural(int(d.Minutes()), "minute")
+	case d < 24*time.Hour:
+		return plural(int(d.Hours()), "hour")
+	case d < 30*24*time.Hour:
+		return plural(int(d.Hours()/24), "day")
+	case d < 365*24*time.Hour:
+		return plural(int(d.Hours()/24/30), "month")
+	default:
+		return plural(int(d.Hours()/24/365), "year")
+	}
 }
 
 func PromptNewBranch() (string, error) {
@@ -57,7 +99,7 @@ func PromptNewBranch() (string, error) {
 				Value(&name).
 				Validate(validateBranchName),
 		),
-	).WithTheme(huh.ThemeCatppuccin())
+	).WithTheme(FormTheme())
 
 	if err := form.Run(); err != nil {
 		return "", NormalizeAbort(err)
@@ -76,15 +118,33 @@ func validateBranchName(s string) error {
 	return nil
 }
 
-func SelectWorktreesToPrune(removable []git.Worktree) ([]git.Worktree, error) {
-	if len(removable) == 0 {
+// PruneCandidate is a merged worktree offered for removal by prune
+type PruneCandidate struct {
+	Worktree   git.Worktree
+	LastCommit time.Time
+	Dirty      bool
+}
+
+// SelectWorktreesToPrune lets the user pick which worktrees to remove. Each
+// row shows when the branch was last committed to and whether the worktree
+// has uncommitted changes. Clean worktrees start selected; dirty ones do not.
+func SelectWorktreesToPrune(candidates []PruneCandidate) ([]git.Worktree, error) {
+	if len(candidates) == 0 {
 		return nil, nil
 	}
 
-	options := make([]huh.Option[string], len(removable))
-	for i, wt := range removable {
-		label := fmt.Sprintf("%s (%s)", wt.Branch, filepath.Base(wt.Path))
-		options[i] = huh.NewOption(label, wt.Branch)
+	options := make([]huh.Option[string], len(candidates))
+	for i, c := range candidates {
+		details := []string{"merged"}
+		if age := RelativeTime(c.LastCommit); age != "" {
+			details = append(details, age)
+		}
+		if c.Dirty {
+			details = append(details, "uncommitted changes")
+		}
+
+		label := fmt.Sprintf("%s (%s) • %s", c.Worktree.Branch, filepath.Base(c.Worktree.Path), strings.Join(details, " • "))
+		options[i] = huh.NewOption(label, c.Worktree.Path).Selected(!c.Dirty)
 	}
 
 	var selected []string
@@ -96,21 +156,17 @@ func SelectWorktreesToPrune(removable []git.Worktree) ([]git.Worktree, error) {
 				Options(options...).
 				Value(&selected),
 		),
-	).WithTheme(huh.ThemeCatppuccin())
+	).WithTheme(FormTheme())
 
 	if err := form.Run(); err != nil {
 		return nil, NormalizeAbort(err)
 	}
 
-	if len(selected) == 0 {
-		return nil, nil
-	}
-
 	var result []git.Worktree
-	for _, branch := range selected {
-		for _, wt := range removable {
-			if wt.Branch == branch {
-				result = append(result, wt)
+	for _, path := range selected {
+		for _, c := range candidates {
+			if c.Worktree.Path == path {
+				result = append(result, c.Worktree)
 				break
 			}
 		}
@@ -119,6 +175,35 @@ func SelectWorktreesToPrune(removable []git.Worktree) ([]git.Worktree, error) {
 	return result, nil
 }
 
+// SelectDatabasesToDrop lets the user pick which orphaned databases to drop
+func SelectDatabasesToDrop(databases []string) ([]string, error) {
+	if len(databases) == 0 {
+		return nil, nil
+	}
+
+	options := make([]huh.Option[string], len(databases))
+	for i, name := range databases {
+		options[i] = huh.NewOption(name, name)
+	}
+
+	var selected []string
+	form := huh.NewForm(
+		huh.NewGroup(
+			huh.NewMultiSelect[string]().
+				Title("Select databases to drop").
+				Description("Space to toggle, Enter to confirm").
+				Options(options...).
+				Value(&selected),
+		),
+	).WithTheme(FormTheme())
+
+	if err := form.Run(); err != nil {
+		return nil, NormalizeAbort(err)
+	}
+
+	return selected, nil
+}
+
 func ConfirmRemoval(count int) (bool, error) {
 	var confirmed bool
 
@@ -129,7 +214,7 @@ func ConfirmRemoval(count int) (bool, error) {
 				Description(fmt.Sprintf("Remove %d selected worktree(s)?", count)).
 				Value(&confirmed),
 		),
-	).WithTheme(huh.ThemeCatppuccin())
+	).WithTheme(FormTheme())
 
 	if err := form.Run(); err != nil {
 		return false, NormalizeAbort(err)
@@ -147,7 +232,7 @@ func Confirm(message string) (bool, error) {
 				Title(message).
 				Value(&confirmed),
 		),
-	).WithTheme(huh.ThemeCatppuccin())
+	).WithTheme(FormTheme())
 
 	if err := form.Run(); err != nil {
 		return false, NormalizeAbort(err)
@@ -156,6 +241,74 @@ func Confirm(message string) (bool, error) {
 	return confirmed, nil
 }
 
+// PromptPassword asks for a password without echoing it
+func PromptPassword(title string) (string, error) {
+	var password string
+
+	form := huh.NewForm(
+		huh.NewGroup(
+			huh.NewInput().
+				Title(title).
+				EchoMode(huh.EchoModePassword).
+				Value(&password),
+		),
+	).WithTheme(FormTheme())
+
+	if err := form.Run(); err != nil {
+		return "", NormalizeAbort(err)
+	}
+
+	return password, nil
+}
+
+// PromptInput asks a question declared under inputs:, starting from its
+// default. Confirm answers are returned as "true" or "false".
+func PromptInput(input config.InputConfig) (string, error) {
+	title := input.Prompt
+	if title == "" {
+		title = input.Name
+	}
+
+	var field huh.Field
+	var answer string
+	var confirmed bool
+	if input.Default != nil {
+		answer = fmt.Sprint(input.Default)
+	}
+	if input.Type == config.InputConfirm && answer != "" {
+		var err error
+		if confirmed, err = strconv.ParseBool(answer); err != nil {
+			return "", fmt.Errorf("input %s: default %q is not a boolean", input.Name, answer)
+		}
+	}
+
+	switch input.Type {
+	case config.InputSelect:
+		field = huh.NewSelect[string]().
+			Title(title).
+			Options(huh.NewOptions(input.Options...)...).
+			Value(&answer)
+	case config.InputConfirm:
+		field = huh.NewConfirm().
+			Title(title).
+			Value(&confirmed)
+	default:
+		field = huh.NewInput().
+			Title(title).
+			Value(&answer)
+	}
+
+	form := huh.NewForm(huh.NewGroup(field)).WithTheme(FormTheme())
+	if err := form.Run(); err != nil {
+		return "", NormalizeAbort(err)
+	}
+
+	if input.Type == config.InputConfirm {
+		return strconv.FormatBool(confirmed), nil
+	}
+	return answer, nil
+}
+
 func PromptRepoURL() (string, error) {
 	var repo string
 
@@ -168,7 +321,7 @@ func PromptRepoURL() (string, error) {
 				Value(&repo).
 				Validate(validateRepoURL),
 		),
-	).WithTheme(huh.ThemeCatppuccin())
+	).WithTheme(FormTheme())
 
 	if err := form.Run(); err != nil {
 		return "", NormalizeAbort(err)
@@ -217,7 +370,7 @@ func SelectWorktreeToRemove(worktrees []git.Worktree) (*git.Worktree, error) {
 				Options(options...).
 				Value(&selected),
 		),
-	).WithTheme(huh.ThemeCatppuccin())
+	).WithTheme(FormTheme())
 
 	if err := form.Run(); err != nil {
 		return nil, NormalizeAbort(err)
@@ -273,7 +426,7 @@ func SelectProjectToDestroy(cwd string) (string, error) {
 				Options(options...).
 				Value(&selected),
 		),
-	).WithTheme(huh.ThemeCatppuccin())
+	).WithTheme(FormTheme())
 
 	if err := form.Run(); err != nil {
 		return "", NormalizeAbort(err)
@@ -297,7 +450,7 @@ func ConfirmDestroy(projectName string, worktrees []git.Worktree) (bool, error)
 				Description(fmt.Sprintf("Destroy project %q?\n\nWorktrees to be removed:\n%s\nThis cannot be undone.", projectName, worktreeList)).
 				Value(&confirmed),
 		),
-	).WithTheme(huh.ThemeCatppuccin())
+	).WithTheme(FormTheme())
 
 	if err := form.Run(); err != nil {
 		return false, NormalizeAbort(err)
@@ -306,6 +459,31 @@ func ConfirmDestroy(projectName string, worktrees []git.Worktree) (bool, error)
 	return confirmed, nil
 }
 
+// SelectWorktree lets the user fuzzy-find a worktree by branch or folder
+func SelectWorktree(title string, worktrees []git.Worktree) (*git.Worktree, error) {
+	if len(worktrees) == 0 {
+		return nil, fmt.Errorf("no worktrees found")
+	}
+
+	items := make([]PickerItem, len(worktrees))
+	for i, wt := range worktrees {
+		items[i] = PickerItem{Label: wt.Branch, Detail: filepath.Base(wt.Path), Value: wt.Path}
+	}
+
+	selected, err := FuzzySelect(title, items)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, wt := range worktrees {
+		if wt.Path == selected {
+			return &wt, nil
+		}
+	}
+
+	return nil, fmt.Errorf("worktree not found")
+}
+
 // SelectWorktreeToScaffold allows selecting a worktree to scaffold
 func SelectWorktreeToScaffold(worktrees []git.Worktree) (*git.Worktree, error) {
 	if len(worktrees) == 0 {
@@ -333,7 +511,7 @@ func SelectWorktreeToScaffold(worktrees []git.Worktree) (*git.Worktree, error) {
 				Options(options...).
 				Value(&selected),
 		),
-	).WithTheme(huh.ThemeCatppuccin())
+	).WithTheme(FormTheme())
 
 	if err := form.Run(); err != nil {
 		return nil, NormalizeAbort(err)
@@ -358,7 +536,7 @@ func ConfirmScaffold(branch string) (bool, error) {
 				Description(fmt.Sprintf("Run scaffold steps for worktree %q?", branch)).
 				Value(&confirmed),
 		),
-	).WithTheme(huh.ThemeCatppuccin())
+	).WithTheme(FormTheme())
 
 	if err := form.Run(); err != nil {
 		return false, NormalizeAbort(err)
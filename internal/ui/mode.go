@@ -5,30 +5,43 @@ import (
 
 	"github.com/charmbracelet/x/term"
 	"github.com/spf13/cobra"
+
+	"github.com/michaeldyrynda/arbor/internal/utils"
 )
 
-func ShouldPrompt(cmd *cobra.Command, hasRequiredArgs bool) bool {
-	if cmd == nil {
-		return IsInteractive()
-	}
+// noInput disables every prompt. SetNoInput sets it.
+var noInput bool
 
-	noInteractive, _ := cmd.Flags().GetBool("no-interactive")
-	if noInteractive {
-		return false
-	}
+// SetNoInput turns every prompt off, for --no-input and the global no_input
+// setting
+func SetNoInput(disabled bool) {
+	noInput = disabled
+}
 
-	force, _ := cmd.Flags().GetBool("force")
-	if force {
+// CanPrompt is the single gate for asking the user anything. Prompts are
+// skipped when input is disabled, under CI, or when stdin or stdout is not
+// a terminal, so arbor never waits on input in scripts.
+func CanPrompt() bool {
+	if noInput || utils.IsCI() {
 		return false
 	}
+	return IsInteractive() && term.IsTerminal(os.Stdin.Fd())
+}
 
-	if os.Getenv("CI") != "" {
-		return false
+// ShouldPrompt reports whether a command should prompt. --force skips
+// prompts, and so do arguments that already answer them.
+func ShouldPrompt(cmd *cobra.Command, hasRequiredArgs bool) bool {
+	if cmd != nil {
+		// Not every command has --force
+		if force := cmd.Flags().Lookup("force"); force != nil && force.Value.String() == "true" {
+			return false
+		}
 	}
 
-	return IsInteractive() && !hasRequiredArgs
+	return CanPrompt() && !hasRequiredArgs
 }
 
+// IsInteractive reports whether stdout is a terminal
 func IsInteractive() bool {
 	return term.IsTerminal(os.Stdout.Fd())
 }
@@ -0,0 +1,69 @@
+package ui
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/michaeldyrynda/arbor/internal/logging"
+	"github.com/michaeldyrynda/arbor/internal/utils"
+)
+
+// NotifyAfter is how long a command must run before NotifyIfSlow sends a
+// notification, long enough that the user has likely switched away
+var NotifyAfter = 30 * time.Second
+
+// Notify sends a native desktop notification using osascript on macOS,
+// notify-send on Linux and the BurntToast module on Windows
+func Notify(title, message string) error {
+	cmd := notifyCommand(runtime.GOOS, title, message)
+	if cmd == nil {
+		return fmt.Errorf("desktop notifications are not supported on %s", runtime.GOOS)
+	}
+	if cmd.Err != nil {
+		return fmt.Errorf("sending notification: %w", cmd.Err)
+	}
+
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("sending notification: %w\n%s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// NotifyIfSlow sends a notification when a command started at start has
+// run for at least NotifyAfter. It does nothing under CI or when output is
+// not a terminal, and failures are only logged since the command has already
+// reported its result.
+func NotifyIfSlow(start time.Time, title, message string) {
+	if time.Since(start) < NotifyAfter || utils.IsCI() || !IsInteractive() {
+		return
+	}
+	if err := Notify(title, message); err != nil {
+		logging.Verbosef("Notification not sent: %v", err)
+	}
+}
+
+func notifyCommand(goos, title, message string) *exec.Cmd {
+	switch goos {
+	case "darwin":
+		script := fmt.Sprintf("display notification %s with title %s", appleScriptString(message), appleScriptString(title))
+		return exec.Command("osascript", "-e", script)
+	case "linux", "freebsd", "openbsd", "netbsd":
+		return exec.Command("notify-send", "--app-name=arbor", title, message)
+	case "windows":
+		script := fmt.Sprintf("New-BurntToastNotification -Text %s, %s", powerShellString(title), powerShellString(message))
+		return exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", script)
+	}
+	return nil
+}
+
+func appleScriptString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
+
+func powerShellString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
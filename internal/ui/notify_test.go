@@ -0,0 +1,28 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotifyCommand(t *testing.T) {
+	t.Run("macOS quotes the script strings", func(t *testing.T) {
+		cmd := notifyCommand("darwin", "arbor", `Removed "feature" \ done`)
+		assert.Equal(t, []string{"osascript", "-e", `display notification "Removed \"feature\" \\ done" with title "arbor"`}, cmd.Args)
+	})
+
+	t.Run("linux uses notify-send", func(t *testing.T) {
+		cmd := notifyCommand("linux", "arbor", "Repository ready")
+		assert.Equal(t, []string{"notify-send", "--app-name=arbor", "arbor", "Repository ready"}, cmd.Args)
+	})
+
+	t.Run("windows uses BurntToast", func(t *testing.T) {
+		cmd := notifyCommand("windows", "arbor", "It's ready")
+		assert.Equal(t, "New-BurntToastNotification -Text 'arbor', 'It''s ready'", cmd.Args[len(cmd.Args)-1])
+	})
+
+	t.Run("unsupported platform", func(t *testing.T) {
+		assert.Nil(t, notifyCommand("plan9", "arbor", "hello"))
+	})
+}
@@ -11,52 +11,67 @@ import (
 
 var logger *log.Logger
 
+// Quiet hides progress, leaving warnings, errors and each command's result
+// line printed with PrintDone or PrintSuccessPath. SetQuiet sets it.
+var Quiet bool
+
 func init() {
 	logger = log.New(os.Stderr)
 	logger.SetLevel(log.InfoLevel)
 }
 
+// SetQuiet hides progress messages and spinners, for scripts and makefiles
+// that only want to know how a command finished
+func SetQuiet() {
+	Quiet = true
+	logger.SetLevel(log.WarnLevel)
+}
+
 func PrintSuccess(msg string) {
-	logger.Info("✓ " + msg)
+	logger.Info(symbol("✓") + msg)
 }
 
 func PrintWarning(msg string) {
-	logger.Warn("⚠ " + msg)
+	logger.Warn(symbol("⚠") + msg)
 }
 
 func PrintError(msg string) {
-	logger.Error("✗ " + msg)
+	logger.Error(symbol("✗") + msg)
 }
 
 func PrintInfo(msg string) {
-	logger.Info("ℹ " + msg)
+	logger.Info(symbol("ℹ") + msg)
 }
 
 func PrintStep(msg string) {
-	logger.Info("→ " + msg)
+	logger.Info(symbol("→") + msg)
 }
 
 func PrintDone(msg string) {
 	style := lipgloss.NewStyle().
 		Foreground(ColorSuccess).
 		Bold(true)
-	fmt.Println(style.Render("✓ " + msg))
+	fmt.Println(style.Render(symbol("✓") + msg))
 }
 
 func PrintSuccessPath(msg, path string) {
 	style := lipgloss.NewStyle().
 		Foreground(ColorSuccess)
-	fmt.Println(style.Render("✓ "+msg+": ") + CodeStyle.Render(path))
+	fmt.Println(style.Render(symbol("✓")+msg+": ") + CodeStyle.Render(path))
 }
 
 func PrintErrorWithHint(msg, hint string) {
 	style := lipgloss.NewStyle().
 		Foreground(ColorError)
-	fmt.Println(style.Render("✗ " + msg))
+	fmt.Println(style.Render(symbol("✗") + msg))
 	fmt.Println("  " + MutedStyle.Render(hint))
 }
 
 func RunWithSpinner(title string, action func() error) error {
+	if Quiet {
+		return action()
+	}
+
 	var err error
 	sp := spinner.New().
 		Title(title).
@@ -0,0 +1,181 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// pickerHeight is the number of items the fuzzy picker shows at once
+const pickerHeight = 12
+
+// PickerItem is an option in a fuzzy picker. Detail is shown dimmed beside
+// the label and is not searched. Pinned items are always listed first,
+// whatever has been typed.
+type PickerItem struct {
+	Label  string
+	Detail string
+	Value  string
+	Pinned bool
+}
+
+// FuzzySelect lets the user narrow items by typing and pick one with enter
+func FuzzySelect(title string, items []PickerItem) (string, error) {
+	result, err := tea.NewProgram(newPickerModel(title, items)).Run()
+	if err != nil {
+		return "", err
+	}
+
+	m := result.(pickerModel)
+	if m.aborted {
+		return "", NormalizeAbort(huh.ErrUserAborted)
+	}
+	return m.selected, nil
+}
+
+type pickerModel struct {
+	title    string
+	items    []PickerItem
+	labels   []string
+	visible  []int
+	cursor   int
+	offset   int
+	input    textinput.Model
+	selected string
+	aborted  bool
+}
+
+func newPickerModel(title string, items []PickerItem) pickerModel {
+	input := textinput.New()
+	input.Prompt = "> "
+	input.Placeholder = "type to filter"
+	input.Focus()
+
+	labels := make([]string, len(items))
+	for i, item := range items {
+		labels[i] = item.Label
+	}
+
+	m := pickerModel{title: title, items: items, labels: labels, input: input}
+	m.filter()
+	return m
+}
+
+// filter recomputes the visible items from the current query
+func (m *pickerModel) filter() {
+	m.visible = m.visible[:0]
+	for i, item := range m.items {
+		if item.Pinned {
+			m.visible = append(m.visible, i)
+		}
+	}
+	for _, i := range FuzzyFilter(m.input.Value(), m.labels) {
+		if !m.items[i].Pinned {
+			m.visible = append(m.visible, i)
+		}
+	}
+
+	m.cursor = 0
+	m.offset = 0
+	// Jump past pinned items to the best match once the user starts typing
+	if m.input.Value() != "" {
+		for m.cursor < len(m.visible)-1 && m.items[m.visible[m.cursor]].Pinned {
+			m.cursor++
+		}
+		m.scroll()
+	}
+}
+
+func (m *pickerModel) scroll() {
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if m.cursor >= m.offset+pickerHeight {
+		m.offset = m.cursor - pickerHeight + 1
+	}
+}
+
+func (m pickerModel) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (m pickerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if key, ok := msg.(tea.KeyMsg); ok {
+		switch key.String() {
+		case "ctrl+c", "esc":
+			m.aborted = true
+			return m, tea.Quit
+		case "enter":
+			if len(m.visible) == 0 {
+				return m, nil
+			}
+			m.selected = m.items[m.visible[m.cursor]].Value
+			return m, tea.Quit
+		case "up", "ctrl+p", "ctrl+k":
+			if m.cursor > 0 {
+				m.cursor--
+				m.scroll()
+			}
+			return m, nil
+		case "down", "ctrl+n", "ctrl+j":
+			if m.cursor < len(m.visible)-1 {
+				m.cursor++
+				m.scroll()
+			}
+			return m, nil
+		}
+	}
+
+	previous := m.input.Value()
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	if m.input.Value() != previous {
+		m.filter()
+	}
+	return m, cmd
+}
+
+func (m pickerModel) View() string {
+	if m.selected != "" || m.aborted {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString(lipgloss.NewStyle().Bold(true).Foreground(Primary).Render(m.title))
+	b.WriteString("\n")
+	b.WriteString(m.input.View())
+	b.WriteString("\n")
+
+	if len(m.visible) == 0 {
+		b.WriteString(MutedStyle.Render("  No matches"))
+		b.WriteString("\n")
+	}
+
+	labelWidth := 0
+	for _, i := range m.visible {
+		labelWidth = max(labelWidth, lipgloss.Width(m.items[i].Label))
+	}
+
+	end := min(m.offset+pickerHeight, len(m.visible))
+	for row := m.offset; row < end; row++ {
+		item := m.items[m.visible[row]]
+		label := fmt.Sprintf("%-*s", labelWidth, item.Label)
+		if row == m.cursor {
+			b.WriteString(lipgloss.NewStyle().Foreground(Primary).Render("› " + label))
+		} else {
+			b.WriteString("  " + label)
+		}
+		if item.Detail != "" {
+			b.WriteString("  " + MutedStyle.Render(item.Detail))
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString(MutedStyle.Render(fmt.Sprintf("%d/%d • ↑/↓ move • enter select • esc cancel", len(m.visible), len(m.items))))
+	b.WriteString("\n")
+	return b.String()
+}
@@ -0,0 +1,85 @@
+package ui
+
+import (
+	"os"
+	"strconv"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/term"
+	"github.com/muesli/termenv"
+)
+
+// Plain drops emoji and symbols from output and draws tables with ASCII
+// borders. SetPlain sets it.
+var Plain bool
+
+// NoColorRequested reports whether NO_COLOR is set to a non-empty value,
+// following https://no-color.org
+func NoColorRequested() bool {
+	return os.Getenv("NO_COLOR") != ""
+}
+
+// DisableColor turns off all styling for lipgloss output and the logger
+func DisableColor() {
+	lipgloss.SetColorProfile(termenv.Ascii)
+	logger.SetColorProfile(termenv.Ascii)
+}
+
+// SetPlain disables styling, emoji and symbols, for output read by scripts
+// or captured in logs
+func SetPlain() {
+	Plain = true
+	DisableColor()
+}
+
+// symbol returns s followed by a space, or nothing in plain mode
+func symbol(s string) string {
+	if Plain {
+		return ""
+	}
+	return s + " "
+}
+
+// tableBorder is the border drawn around tables
+func tableBorder() lipgloss.Border {
+	if Plain {
+		return lipgloss.ASCIIBorder()
+	}
+	return lipgloss.NormalBorder()
+}
+
+// TerminalWidth returns the width of the terminal, falling back to COLUMNS
+// when output is redirected. It returns 0 when neither is known.
+func TerminalWidth() int {
+	if width, _, err := term.GetSize(os.Stdout.Fd()); err == nil && width > 0 {
+		return width
+	}
+	if width, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && width > 0 {
+		return width
+	}
+	return 0
+}
+
+// truncateMiddle shortens s to width cells by replacing its middle with an
+// ellipsis, keeping both the prefix and the end of names like
+// "feature/ABC-123-long-description"
+func truncateMiddle(s string, width int) string {
+	if lipgloss.Width(s) <= width {
+		return s
+	}
+
+	ellipsis := "…"
+	if Plain {
+		ellipsis = "..."
+	}
+
+	runes := []rune(s)
+	keep := width - lipgloss.Width(ellipsis)
+	if keep <= 0 {
+		return string(runes[:max(width, 0)])
+	}
+
+	head := (keep + 1) / 2
+	tail := keep - head
+	return string(runes[:head]) + ellipsis + string(runes[len(runes)-tail:])
+}
@@ -0,0 +1,110 @@
+package ui
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/michaeldyrynda/arbor/internal/git"
+)
+
+// progressBarWidth is the number of cells in the progress bar
+const progressBarWidth = 30
+
+// RunWithProgress runs action, a git clone or fetch, showing git's progress
+// as a bar with the phase, object counts and throughput. In plain mode, or
+// when output is not a terminal, git's progress lines are passed through as
+// git prints them instead.
+func RunWithProgress(title string, action func() error) error {
+	if Quiet {
+		return action()
+	}
+
+	var w *progressWriter
+	if Plain || !IsInteractive() {
+		PrintStep(title)
+		w = newProgressPassthrough(os.Stderr)
+	} else {
+		w = newProgressBar(os.Stderr, title)
+	}
+
+	previous := git.ProgressOutput
+	git.ProgressOutput = w
+	defer func() { git.ProgressOutput = previous }()
+
+	err := action()
+	w.finish()
+	return err
+}
+
+// progressWriter splits git's progress output into lines, which git ends
+// with \r while it updates a line in place and \n once it is done
+type progressWriter struct {
+	pending []byte
+	line    func(text string, end byte)
+	finish  func()
+}
+
+func (w *progressWriter) Write(p []byte) (int, error) {
+	w.pending = append(w.pending, p...)
+	for {
+		i := bytes.IndexAny(w.pending, "\r\n")
+		if i < 0 {
+			return len(p), nil
+		}
+		w.line(string(w.pending[:i]), w.pending[i])
+		w.pending = w.pending[i+1:]
+	}
+}
+
+// newProgressPassthrough writes git's progress lines to out unchanged,
+// dropping its other output, which is reported with any error
+func newProgressPassthrough(out io.Writer) *progressWriter {
+	return &progressWriter{
+		line: func(text string, end byte) {
+			if _, ok := git.ParseProgress(text); ok {
+				fmt.Fprintf(out, "%s%c", text, end)
+			}
+		},
+		finish: func() {},
+	}
+}
+
+// newProgressBar redraws a single line on out with the latest progress,
+// clearing it when the operation finishes
+func newProgressBar(out io.Writer, title string) *progressWriter {
+	fmt.Fprint(out, title)
+	return &progressWriter{
+		line: func(text string, _ byte) {
+			if progress, ok := git.ParseProgress(text); ok {
+				fmt.Fprint(out, "\r\x1b[2K"+renderProgress(title, progress))
+			}
+		},
+		finish: func() {
+			fmt.Fprint(out, "\r\x1b[2K")
+		},
+	}
+}
+
+// renderProgress renders a progress update as
+// "title  Receiving objects ███░░░ 45% 450/1000 1.20 MiB/s", or without the
+// bar while git only counts
+func renderProgress(title string, p git.Progress) string {
+	parts := []string{title, p.Phase}
+	if p.Percent < 0 {
+		parts = append(parts, fmt.Sprintf("%d", p.Current))
+	} else {
+		filled := progressBarWidth * min(max(p.Percent, 0), 100) / 100
+		bar := lipgloss.NewStyle().Foreground(Primary).Render(strings.Repeat("█", filled)) +
+			MutedStyle.Render(strings.Repeat("░", progressBarWidth-filled))
+		parts = append(parts, bar, fmt.Sprintf("%3d%%", p.Percent), fmt.Sprintf("%d/%d", p.Current, p.Total))
+	}
+	if p.Throughput != "" {
+		parts = append(parts, MutedStyle.Render(p.Throughput))
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,43 @@
+package ui
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/michaeldyrynda/arbor/internal/git"
+)
+
+func TestProgressPassthrough(t *testing.T) {
+	var out bytes.Buffer
+	w := newProgressPassthrough(&out)
+
+	// git's output arrives in arbitrary chunks
+	for _, chunk := range []string{"Cloning into bare repository '.bare'...\nReceiving obj", "ects:  50% (1/2)\rReceiving objects: 100% (2/2), done.\n", "fatal: early EOF\n"} {
+		_, err := w.Write([]byte(chunk))
+		assert.NoError(t, err)
+	}
+
+	assert.Equal(t, "Receiving objects:  50% (1/2)\rReceiving objects: 100% (2/2), done.\n", out.String())
+}
+
+func TestProgressBar(t *testing.T) {
+	var out bytes.Buffer
+	w := newProgressBar(&out, "Cloning app...")
+
+	_, err := w.Write([]byte("Receiving objects:  50% (5/10), 1.00 MiB | 2.00 MiB/s\r"))
+	assert.NoError(t, err)
+	w.finish()
+
+	assert.Contains(t, out.String(), "Cloning app... Receiving objects")
+	assert.Contains(t, out.String(), " 50% 5/10 2.00 MiB/s")
+	assert.True(t, bytes.HasSuffix(out.Bytes(), []byte("\r\x1b[2K")), "the bar is cleared when done")
+}
+
+func TestRenderProgress(t *testing.T) {
+	assert.Equal(t, "Fetching x... Enumerating objects 12", renderProgress("Fetching x...", git.Progress{Phase: "Enumerating objects", Percent: -1, Current: 12}))
+
+	line := renderProgress("Fetching x...", git.Progress{Phase: "Receiving objects", Percent: 100, Current: 3, Total: 3})
+	assert.Contains(t, line, "██████████████████████████████ 100% 3/3")
+}
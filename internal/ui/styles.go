@@ -3,66 +3,92 @@ package ui
 import "github.com/charmbracelet/lipgloss"
 
 var (
-	Primary   = lipgloss.Color("#4CAF50")
-	Secondary = lipgloss.Color("#A1887F")
+	Primary   lipgloss.Color
+	Secondary lipgloss.Color
 
-	ColorSuccess = lipgloss.Color("#66BB6A")
-	ColorWarning = lipgloss.Color("#FFA726")
-	ColorError   = lipgloss.Color("#EF5350")
-	ColorInfo    = lipgloss.Color("#29B6F6")
-	ColorMuted   = lipgloss.Color("#9E9E9E")
+	ColorSuccess lipgloss.Color
+	ColorWarning lipgloss.Color
+	ColorError   lipgloss.Color
+	ColorInfo    lipgloss.Color
+	ColorMuted   lipgloss.Color
 
 	Text    = lipgloss.Color("#F9FAFB")
 	TextDim = lipgloss.Color("#9CA3AF")
 )
 
 var (
+	HeaderStyle          lipgloss.Style
+	SuccessBadge         lipgloss.Style
+	WarningBadge         lipgloss.Style
+	ErrorBadge           lipgloss.Style
+	BoxStyle             lipgloss.Style
+	MutedStyle           lipgloss.Style
+	CodeStyle            lipgloss.Style
+	InfoBadge            lipgloss.Style
+	MainWorktreeStyle    lipgloss.Style
+	CurrentWorktreeStyle lipgloss.Style
+)
+
+func init() {
+	applyPalette(defaultPalette)
+}
+
+// applyPalette sets the colours and rebuilds the styles derived from them
+func applyPalette(p palette) {
+	Primary = p.Primary
+	Secondary = p.Secondary
+	ColorSuccess = p.Success
+	ColorWarning = p.Warning
+	ColorError = p.Error
+	ColorInfo = p.Info
+	ColorMuted = p.Muted
+
 	HeaderStyle = lipgloss.NewStyle().
-			Bold(true).
-			Foreground(Primary).
-			MarginBottom(1)
+		Bold(true).
+		Foreground(Primary).
+		MarginBottom(1)
 
 	SuccessBadge = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#000")).
-			Background(ColorSuccess).
-			Padding(0, 1).
-			Bold(true)
+		Foreground(lipgloss.Color("#000")).
+		Background(ColorSuccess).
+		Padding(0, 1).
+		Bold(true)
 
 	WarningBadge = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#000")).
-			Background(ColorWarning).
-			Padding(0, 1).
-			Bold(true)
+		Foreground(lipgloss.Color("#000")).
+		Background(ColorWarning).
+		Padding(0, 1).
+		Bold(true)
 
 	ErrorBadge = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#FFF")).
-			Background(ColorError).
-			Padding(0, 1).
-			Bold(true)
+		Foreground(lipgloss.Color("#FFF")).
+		Background(ColorError).
+		Padding(0, 1).
+		Bold(true)
 
 	BoxStyle = lipgloss.NewStyle().
-			Border(lipgloss.RoundedBorder()).
-			BorderForeground(Primary).
-			Padding(1, 2)
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(Primary).
+		Padding(1, 2)
 
 	MutedStyle = lipgloss.NewStyle().
-			Foreground(ColorMuted)
+		Foreground(ColorMuted)
 
 	CodeStyle = lipgloss.NewStyle().
-			Foreground(ColorInfo).
-			Bold(true)
+		Foreground(ColorInfo).
+		Bold(true)
 
 	InfoBadge = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#000")).
-			Background(ColorInfo).
-			Padding(0, 1).
-			Bold(true)
+		Foreground(lipgloss.Color("#000")).
+		Background(ColorInfo).
+		Padding(0, 1).
+		Bold(true)
 
 	MainWorktreeStyle = lipgloss.NewStyle().
-				Foreground(Secondary).
-				Bold(true)
+		Foreground(Secondary).
+		Bold(true)
 
 	CurrentWorktreeStyle = lipgloss.NewStyle().
-				Foreground(ColorSuccess).
-				Bold(true)
-)
+		Foreground(ColorSuccess).
+		Bold(true)
+}
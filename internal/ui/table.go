@@ -13,7 +13,7 @@ import (
 
 func RenderTable(headers []string, rows [][]string) string {
 	t := table.New().
-		Border(lipgloss.NormalBorder()).
+		Border(tableBorder()).
 		BorderStyle(lipgloss.NewStyle().Foreground(Primary)).
 		Headers(headers...).
 		StyleFunc(func(row, col int) lipgloss.Style {
@@ -29,7 +29,7 @@ func RenderTable(headers []string, rows [][]string) string {
 
 func RenderStatusTable(rows [][]string) string {
 	t := table.New().
-		Border(lipgloss.NormalBorder()).
+		Border(tableBorder()).
 		BorderStyle(lipgloss.NewStyle().Foreground(Primary)).
 		Headers("TOOL", "STATUS", "VERSION").
 		StyleFunc(func(row, col int) lipgloss.Style {
@@ -54,37 +54,143 @@ func RenderStatusTable(rows [][]string) string {
 	return fmt.Sprintf("\n%s\n", t.String())
 }
 
+// minColumnWidth is the narrowest a truncated column is made
+const minColumnWidth = 8
+
+// TableColumn describes a column of the worktree table. Truncate allows long
+// values to be shortened in the middle to fit the terminal; it must only be
+// set for columns holding unstyled text.
+type TableColumn struct {
+	Header   string
+	Truncate bool
+}
+
+// DefaultWorktreeColumns are the columns shown by RenderWorktreeTable
+var DefaultWorktreeColumns = []TableColumn{
+	{Header: "WORKTREE", Truncate: true},
+	{Header: "BRANCH", Truncate: true},
+	{Header: "STATUS"},
+}
+
+// RenderWorktreeTable renders worktrees as a table fitting the terminal.
+// Long worktree and branch names are shortened in the middle when the
+// table would otherwise wrap.
 func RenderWorktreeTable(worktrees []git.Worktree) string {
-	title := lipgloss.NewStyle().
+	rows := make([][]string, len(worktrees))
+	for i, wt := range worktrees {
+		rows[i] = []string{filepath.Base(wt.Path), wt.Branch, WorktreeStatus(wt)}
+	}
+	return renderWorktreeTable(worktrees, DefaultWorktreeColumns, rows, TerminalWidth())
+}
+
+// RenderWorktreeColumns renders worktrees as a table of the given columns,
+// with rows holding one value per column for each worktree
+func RenderWorktreeColumns(worktrees []git.Worktree, columns []TableColumn, rows [][]string) string {
+	return renderWorktreeTable(worktrees, columns, rows, TerminalWidth())
+}
+
+func renderWorktreeTable(worktrees []git.Worktree, columns []TableColumn, rows [][]string, width int) string {
+	widths := fitColumns(columns, rows, width)
+	return worktreeTitle() + "\n\n" + renderColumns(worktrees, columns, rows, widths) + "\n" + worktreeSummary(worktrees)
+}
+
+// WorktreeGroup is a titled section of the grouped worktree table
+type WorktreeGroup struct {
+	Title     string
+	Worktrees []git.Worktree
+	Rows      [][]string
+}
+
+// RenderWorktreeGroups renders one table per non-empty group under its
+// title. Columns have the same widths in every group so the sections line up.
+func RenderWorktreeGroups(groups []WorktreeGroup, columns []TableColumn) string {
+	return renderWorktreeGroups(groups, columns, TerminalWidth())
+}
+
+func renderWorktreeGroups(groups []WorktreeGroup, columns []TableColumn, width int) string {
+	var all []git.Worktree
+	var allRows [][]string
+	for _, group := range groups {
+		all = append(all, group.Worktrees...)
+		allRows = append(allRows, group.Rows...)
+	}
+	widths := fitColumns(columns, allRows, width)
+
+	sectionStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(Secondary).
+		Padding(0, 1)
+
+	var b strings.Builder
+	b.WriteString(worktreeTitle())
+	b.WriteString("\n")
+	for _, group := range groups {
+		if len(group.Worktrees) == 0 {
+			continue
+		}
+		b.WriteString("\n")
+		b.WriteString(sectionStyle.Render(fmt.Sprintf("%s (%d)", group.Title, len(group.Worktrees))))
+		b.WriteString("\n")
+		b.WriteString(renderColumns(group.Worktrees, columns, group.Rows, widths))
+		b.WriteString("\n")
+	}
+	b.WriteString(worktreeSummary(all))
+	return b.String()
+}
+
+func worktreeTitle() string {
+	return lipgloss.NewStyle().
 		Foreground(Primary).
 		Bold(true).
 		Padding(0, 1).
-		Render("🌳 Arbor Worktrees")
+		Render(symbol("🌳") + "Arbor Worktrees")
+}
+
+// renderColumns draws the table itself, padding every column to widths so
+// separate tables line up
+func renderColumns(worktrees []git.Worktree, columns []TableColumn, rows [][]string, widths []int) string {
+	headers := make([]string, len(columns))
+	for i, column := range columns {
+		headers[i] = column.Header
+	}
 
 	t := table.New().
-		Border(lipgloss.NormalBorder()).
+		Border(tableBorder()).
 		BorderStyle(lipgloss.NewStyle().Foreground(Primary)).
-		Headers("WORKTREE", "BRANCH", "STATUS").
+		Headers(headers...).
 		StyleFunc(func(row, col int) lipgloss.Style {
+			style := lipgloss.NewStyle().Padding(0, 1)
+			if col < len(widths) {
+				style = style.Width(widths[col] + 2)
+			}
 			if row == 0 {
-				return lipgloss.NewStyle().
+				return style.
 					Bold(true).
-					Foreground(Primary).
-					Padding(0, 1)
+					Foreground(Primary)
 			}
 			if row > 0 && row-1 < len(worktrees) && worktrees[row-1].IsCurrent {
-				return lipgloss.NewStyle().
-					Bold(true).
-					Padding(0, 1)
+				return style.Bold(true)
 			}
-			return lipgloss.NewStyle().Padding(0, 1)
+			return style
 		})
 
+	for _, row := range rows {
+		cells := make([]string, len(row))
+		for i, value := range row {
+			cells[i] = value
+			if i < len(columns) && columns[i].Truncate {
+				cells[i] = truncateMiddle(value, widths[i])
+			}
+		}
+		t.Row(cells...)
+	}
+
+	return t.String()
+}
+
+func worktreeSummary(worktrees []git.Worktree) string {
 	var mergedCount int
 	for _, wt := range worktrees {
-		worktreeName := filepath.Base(wt.Path)
-		status := formatWorktreeStatus(wt)
-		t.Row(worktreeName, wt.Branch, status)
 		if wt.IsMerged && !wt.IsMain {
 			mergedCount++
 		}
@@ -108,21 +214,67 @@ func RenderWorktreeTable(worktrees []git.Worktree) string {
 		Foreground(ColorMuted).
 		Padding(0, 1)
 
-	return title + "\n\n" + t.String() + "\n" + summaryStyle.Render(summary)
+	return summaryStyle.Render(summary)
+}
+
+// fitColumns returns the width each column may use so the table fits width,
+// narrowing the widest truncatable column first. A width of 0 leaves every
+// column at its natural width.
+func fitColumns(columns []TableColumn, rows [][]string, width int) []int {
+	widths := make([]int, len(columns))
+	for i, column := range columns {
+		widths[i] = lipgloss.Width(column.Header)
+	}
+	for _, row := range rows {
+		for i, value := range row {
+			if i < len(widths) {
+				widths[i] = max(widths[i], lipgloss.Width(value))
+			}
+		}
+	}
+
+	if width <= 0 {
+		return widths
+	}
+
+	// Each column has a border to its left and a cell padding of one either side
+	available := width - 1 - 3*len(columns)
+	total := 0
+	for _, w := range widths {
+		total += w
+	}
+
+	for total > available {
+		widest := -1
+		for i, column := range columns {
+			if column.Truncate && widths[i] > minColumnWidth && (widest < 0 || widths[i] > widths[widest]) {
+				widest = i
+			}
+		}
+		if widest < 0 {
+			break
+		}
+		widths[widest]--
+		total--
+	}
+
+	return widths
 }
 
-func formatWorktreeStatus(wt git.Worktree) string {
+// WorktreeStatus describes whether wt is the current or main worktree and
+// whether its branch is merged
+func WorktreeStatus(wt git.Worktree) string {
 	var parts []string
 
 	if wt.IsCurrent {
-		parts = append(parts, CurrentWorktreeStyle.Render("● current"))
+		parts = append(parts, CurrentWorktreeStyle.Render(symbol("●")+"current"))
 	}
 	if wt.IsMain {
-		parts = append(parts, MainWorktreeStyle.Render("★ main"))
+		parts = append(parts, MainWorktreeStyle.Render(symbol("★")+"main"))
 	} else if wt.IsMerged {
-		parts = append(parts, MutedStyle.Render("✓ merged"))
+		parts = append(parts, MutedStyle.Render(symbol("✓")+"merged"))
 	} else {
-		parts = append(parts, MutedStyle.Render("○ active"))
+		parts = append(parts, MutedStyle.Render(symbol("○")+"active"))
 	}
 
 	return strings.Join(parts, " ")
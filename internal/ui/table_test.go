@@ -0,0 +1,71 @@
+package ui
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/michaeldyrynda/arbor/internal/git"
+)
+
+func TestTruncateMiddle(t *testing.T) {
+	assert.Equal(t, "main", truncateMiddle("main", 10))
+	assert.Equal(t, "featu…-123", truncateMiddle("feature/ABC-123", 10))
+	assert.Equal(t, 10, lipgloss.Width(truncateMiddle("feature/a-very-long-branch-name", 10)))
+}
+
+func defaultRows(worktrees []git.Worktree) [][]string {
+	rows := make([][]string, len(worktrees))
+	for i, wt := range worktrees {
+		rows[i] = []string{filepath.Base(wt.Path), wt.Branch, WorktreeStatus(wt)}
+	}
+	return rows
+}
+
+func TestRenderWorktreeTable_FitsWidth(t *testing.T) {
+	worktrees := []git.Worktree{
+		{Path: "/project/main", Branch: "main", IsMain: true},
+		{Path: "/project/feature-abc-123-add-a-very-long-description", Branch: "feature/ABC-123-add-a-very-long-description"},
+	}
+	rows := defaultRows(worktrees)
+
+	t.Run("unlimited width keeps full names", func(t *testing.T) {
+		output := renderWorktreeTable(worktrees, DefaultWorktreeColumns, rows, 0)
+		assert.Contains(t, output, "feature/ABC-123-add-a-very-long-description")
+	})
+
+	t.Run("narrow width shortens names", func(t *testing.T) {
+		output := renderWorktreeTable(worktrees, DefaultWorktreeColumns, rows, 60)
+		assert.NotContains(t, output, "feature/ABC-123-add-a-very-long-description")
+		assert.Contains(t, output, "feature/AB…escription")
+		for _, line := range strings.Split(output, "\n") {
+			assert.LessOrEqual(t, lipgloss.Width(line), 60, line)
+		}
+	})
+}
+
+func TestRenderWorktreeTable_Plain(t *testing.T) {
+	Plain = true
+	t.Cleanup(func() { Plain = false })
+
+	worktrees := []git.Worktree{{Path: "/project/main", Branch: "main", IsMain: true}}
+	output := renderWorktreeTable(worktrees, DefaultWorktreeColumns, defaultRows(worktrees), 0)
+
+	assert.NotContains(t, output, "🌳")
+	assert.NotContains(t, output, "★")
+	assert.Contains(t, output, "+--")
+	assert.Contains(t, output, "main")
+}
+
+func TestFitColumns_KeepsUntruncatedColumns(t *testing.T) {
+	columns := []TableColumn{{Header: "PATH", Truncate: true}, {Header: "AHEAD"}}
+	rows := [][]string{{"/a/very/long/path/to/a/worktree/folder", "12"}}
+
+	widths := fitColumns(columns, rows, 30)
+
+	assert.Equal(t, 5, widths[1])
+	assert.Equal(t, 30-1-3*2-5, widths[0])
+}
@@ -0,0 +1,147 @@
+package ui
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/michaeldyrynda/arbor/internal/config"
+)
+
+// Themes accepted by ui.theme in the global config
+const (
+	ThemeCatppuccin = "catppuccin"
+	ThemeDracula    = "dracula"
+	ThemePlain      = "plain"
+	ThemeCustom     = "custom"
+)
+
+// palette holds the colours the lipgloss styles are built from
+type palette struct {
+	Primary   lipgloss.Color
+	Secondary lipgloss.Color
+	Success   lipgloss.Color
+	Warning   lipgloss.Color
+	Error     lipgloss.Color
+	Info      lipgloss.Color
+	Muted     lipgloss.Color
+}
+
+var defaultPalette = palette{
+	Primary:   lipgloss.Color("#4CAF50"),
+	Secondary: lipgloss.Color("#A1887F"),
+	Success:   lipgloss.Color("#66BB6A"),
+	Warning:   lipgloss.Color("#FFA726"),
+	Error:     lipgloss.Color("#EF5350"),
+	Info:      lipgloss.Color("#29B6F6"),
+	Muted:     lipgloss.Color("#9E9E9E"),
+}
+
+var draculaPalette = palette{
+	Primary:   lipgloss.Color("#BD93F9"),
+	Secondary: lipgloss.Color("#FF79C6"),
+	Success:   lipgloss.Color("#50FA7B"),
+	Warning:   lipgloss.Color("#FFB86C"),
+	Error:     lipgloss.Color("#FF5555"),
+	Info:      lipgloss.Color("#8BE9FD"),
+	Muted:     lipgloss.Color("#6272A4"),
+}
+
+// colorPattern matches hex colours and ANSI colour numbers
+var colorPattern = regexp.MustCompile(`^(#[0-9a-fA-F]{3}|#[0-9a-fA-F]{6}|[0-9]{1,3})$`)
+
+// formTheme builds the huh theme used by every prompt
+var formTheme = huh.ThemeCatppuccin
+
+// FormTheme returns the huh theme for the configured ui.theme
+func FormTheme() *huh.Theme {
+	return formTheme()
+}
+
+// ApplyTheme sets the colours of tables, badges and messages and the theme
+// of prompts from the ui settings in the global config. Colours set under
+// ui.colors override those of the chosen theme; the custom theme starts
+// from the default colours.
+func ApplyTheme(cfg config.UIConfig) error {
+	p := defaultPalette
+	switch cfg.Theme {
+	case "", ThemeCatppuccin:
+		formTheme = huh.ThemeCatppuccin
+	case ThemeDracula:
+		p = draculaPalette
+		formTheme = huh.ThemeDracula
+	case ThemePlain:
+		formTheme = huh.ThemeBase
+		SetPlain()
+	case ThemeCustom:
+	default:
+		return fmt.Errorf("unknown ui.theme %q (use %s, %s, %s or %s)", cfg.Theme, ThemeCatppuccin, ThemeDracula, ThemePlain, ThemeCustom)
+	}
+
+	overrides := []struct {
+		name  string
+		value string
+		color *lipgloss.Color
+	}{
+		{"primary", cfg.Colors.Primary, &p.Primary},
+		{"secondary", cfg.Colors.Secondary, &p.Secondary},
+		{"success", cfg.Colors.Success, &p.Success},
+		{"warning", cfg.Colors.Warning, &p.Warning},
+		{"error", cfg.Colors.Error, &p.Error},
+		{"info", cfg.Colors.Info, &p.Info},
+		{"muted", cfg.Colors.Muted, &p.Muted},
+	}
+
+	customised := false
+	for _, o := range overrides {
+		if o.value == "" {
+			continue
+		}
+		if !colorPattern.MatchString(o.value) {
+			return fmt.Errorf("ui.colors.%s: %q is not a hex colour or ANSI colour number", o.name, o.value)
+		}
+		*o.color = lipgloss.Color(o.value)
+		customised = true
+	}
+
+	applyPalette(p)
+	if cfg.Theme == ThemeCustom || (customised && cfg.Theme != ThemePlain) {
+		formTheme = func() *huh.Theme { return paletteFormTheme(p) }
+	}
+	return nil
+}
+
+// paletteFormTheme colours huh's base theme with p
+func paletteFormTheme(p palette) *huh.Theme {
+	t := huh.ThemeBase()
+
+	t.Focused.Base = t.Focused.Base.BorderForeground(p.Primary)
+	t.Focused.Card = t.Focused.Base
+	t.Focused.Title = t.Focused.Title.Foreground(p.Primary).Bold(true)
+	t.Focused.NoteTitle = t.Focused.NoteTitle.Foreground(p.Primary).Bold(true).MarginBottom(1)
+	t.Focused.Description = t.Focused.Description.Foreground(p.Muted)
+	t.Focused.ErrorIndicator = t.Focused.ErrorIndicator.Foreground(p.Error)
+	t.Focused.ErrorMessage = t.Focused.ErrorMessage.Foreground(p.Error)
+	t.Focused.SelectSelector = t.Focused.SelectSelector.Foreground(p.Secondary)
+	t.Focused.MultiSelectSelector = t.Focused.MultiSelectSelector.Foreground(p.Secondary)
+	t.Focused.SelectedOption = t.Focused.SelectedOption.Foreground(p.Success)
+	t.Focused.SelectedPrefix = lipgloss.NewStyle().Foreground(p.Success).SetString("✓ ")
+	t.Focused.UnselectedPrefix = lipgloss.NewStyle().Foreground(p.Muted).SetString("• ")
+	t.Focused.FocusedButton = t.Focused.FocusedButton.Foreground(lipgloss.Color("#000")).Background(p.Primary)
+	t.Focused.Next = t.Focused.FocusedButton
+	t.Focused.TextInput.Cursor = t.Focused.TextInput.Cursor.Foreground(p.Success)
+	t.Focused.TextInput.Placeholder = t.Focused.TextInput.Placeholder.Foreground(p.Muted)
+	t.Focused.TextInput.Prompt = t.Focused.TextInput.Prompt.Foreground(p.Secondary)
+
+	t.Blurred = t.Focused
+	t.Blurred.Base = t.Focused.Base.BorderStyle(lipgloss.HiddenBorder())
+	t.Blurred.Card = t.Blurred.Base
+	t.Blurred.NextIndicator = lipgloss.NewStyle()
+	t.Blurred.PrevIndicator = lipgloss.NewStyle()
+
+	t.Group.Title = t.Focused.Title
+	t.Group.Description = t.Focused.Description
+	return t
+}
@@ -0,0 +1,63 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/michaeldyrynda/arbor/internal/config"
+)
+
+func resetTheme(t *testing.T) {
+	t.Cleanup(func() {
+		applyPalette(defaultPalette)
+		formTheme = huh.ThemeCatppuccin
+		Plain = false
+	})
+}
+
+func TestApplyTheme(t *testing.T) {
+	t.Run("default theme", func(t *testing.T) {
+		resetTheme(t)
+		require.NoError(t, ApplyTheme(config.UIConfig{}))
+		assert.Equal(t, defaultPalette.Primary, Primary)
+	})
+
+	t.Run("dracula theme", func(t *testing.T) {
+		resetTheme(t)
+		require.NoError(t, ApplyTheme(config.UIConfig{Theme: ThemeDracula}))
+		assert.Equal(t, draculaPalette.Primary, Primary)
+		assert.Equal(t, draculaPalette.Muted, MutedStyle.GetForeground())
+	})
+
+	t.Run("plain theme", func(t *testing.T) {
+		resetTheme(t)
+		require.NoError(t, ApplyTheme(config.UIConfig{Theme: ThemePlain}))
+		assert.True(t, Plain)
+	})
+
+	t.Run("custom colours", func(t *testing.T) {
+		resetTheme(t)
+		require.NoError(t, ApplyTheme(config.UIConfig{
+			Theme:  ThemeCustom,
+			Colors: config.ThemeColors{Primary: "#FF0000", Muted: "244"},
+		}))
+		assert.Equal(t, lipgloss.Color("#FF0000"), Primary)
+		assert.Equal(t, lipgloss.Color("244"), ColorMuted)
+		assert.Equal(t, defaultPalette.Success, ColorSuccess)
+		assert.Equal(t, lipgloss.Color("#FF0000"), FormTheme().Focused.Title.GetForeground())
+	})
+
+	t.Run("unknown theme", func(t *testing.T) {
+		resetTheme(t)
+		assert.ErrorContains(t, ApplyTheme(config.UIConfig{Theme: "neon"}), `unknown ui.theme "neon"`)
+	})
+
+	t.Run("invalid colour", func(t *testing.T) {
+		resetTheme(t)
+		assert.ErrorContains(t, ApplyTheme(config.UIConfig{Colors: config.ThemeColors{Error: "red"}}), "ui.colors.error")
+	})
+}
@@ -0,0 +1,37 @@
+package utils
+
+import (
+	"os"
+	"strings"
+)
+
+// ciEnvVars are set by CI services that do not set CI themselves
+var ciEnvVars = []string{
+	"GITHUB_ACTIONS",
+	"GITLAB_CI",
+	"BUILDKITE",
+	"CIRCLECI",
+	"JENKINS_URL",
+	"TF_BUILD",
+	"TEAMCITY_VERSION",
+}
+
+// IsCI reports whether arbor is running under a CI service. CI=false and
+// CI=0 are honoured so CI can be switched off locally.
+func IsCI() bool {
+	if value := os.Getenv("CI"); value != "" {
+		switch strings.ToLower(value) {
+		case "0", "false":
+			return false
+		default:
+			return true
+		}
+	}
+
+	for _, name := range ciEnvVars {
+		if os.Getenv(name) != "" {
+			return true
+		}
+	}
+	return false
+}
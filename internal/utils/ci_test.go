@@ -0,0 +1,40 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsCI(t *testing.T) {
+	clearCIEnv := func(t *testing.T) {
+		t.Setenv("CI", "")
+		for _, name := range ciEnvVars {
+			t.Setenv(name, "")
+		}
+	}
+
+	t.Run("CI set", func(t *testing.T) {
+		clearCIEnv(t)
+		t.Setenv("CI", "true")
+		assert.True(t, IsCI())
+	})
+
+	t.Run("CI false", func(t *testing.T) {
+		clearCIEnv(t)
+		t.Setenv("CI", "false")
+		t.Setenv("GITHUB_ACTIONS", "true")
+		assert.False(t, IsCI())
+	})
+
+	t.Run("GitHub Actions", func(t *testing.T) {
+		clearCIEnv(t)
+		t.Setenv("GITHUB_ACTIONS", "true")
+		assert.True(t, IsCI())
+	})
+
+	t.Run("not CI", func(t *testing.T) {
+		clearCIEnv(t)
+		assert.False(t, IsCI())
+	})
+}
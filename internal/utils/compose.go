@@ -0,0 +1,22 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+)
+
+var composeNameInvalid = regexp.MustCompile(`[^a-z0-9_-]+`)
+
+// ComposeProjectName derives a Docker Compose project name from a site name
+// and worktree suffix, e.g. myapp_swift_fox. Compose only allows lowercase
+// letters, digits, dashes and underscores, starting with a letter or digit.
+func ComposeProjectName(siteName, suffix string) string {
+	var parts []string
+	for _, part := range []string{siteName, suffix} {
+		part = strings.Trim(composeNameInvalid.ReplaceAllString(strings.ToLower(part), "_"), "_-")
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, "_")
+}
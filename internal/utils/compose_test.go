@@ -0,0 +1,27 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComposeProjectName(t *testing.T) {
+	tests := []struct {
+		siteName string
+		suffix   string
+		expected string
+	}{
+		{"myapp", "swift_fox", "myapp_swift_fox"},
+		{"My App.test", "swift_fox", "my_app_test_swift_fox"},
+		{"-api-", "", "api"},
+		{"", "swift_fox", "swift_fox"},
+		{"", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ComposeProjectName(tt.siteName, tt.suffix))
+		})
+	}
+}
@@ -1,8 +1,10 @@
 package utils
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 )
 
@@ -33,6 +35,33 @@ func ReadEnvFile(worktreePath, filename string) map[string]string {
 	return result
 }
 
+// ReadEnvKeys returns the keys of an env file in the order they appear
+func ReadEnvKeys(worktreePath, filename string) []string {
+	data, err := os.ReadFile(filepath.Join(worktreePath, filename))
+	if err != nil {
+		return nil
+	}
+
+	var keys []string
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) == 2 {
+			key := strings.TrimSpace(parts[0])
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	return keys
+}
+
 func EnvExists(env map[string]string, key string) bool {
 	_, exists := env[key]
 	return exists
@@ -41,3 +70,115 @@ func EnvExists(env map[string]string, key string) bool {
 func EnvNotExists(env map[string]string, key string) bool {
 	return !EnvExists(env, key)
 }
+
+// UnquoteEnvValue strips a matching pair of single or double quotes from an
+// env value
+func UnquoteEnvValue(value string) string {
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
+
+// QuoteEnvValue double quotes an env value that would otherwise be cut short
+// or break the file, such as one containing spaces, a # or a newline.
+// Backslashes, double quotes and newlines are escaped. Values that need no
+// quotes, or are already quoted, are returned as they are.
+func QuoteEnvValue(value string) string {
+	if !strings.ContainsAny(value, " \t\r\n#\"'") || UnquoteEnvValue(value) != value {
+		return value
+	}
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`)
+	return `"` + replacer.Replace(value) + `"`
+}
+
+// EnvDiff holds the keys that differ between two env files, each sorted
+type EnvDiff struct {
+	Missing []string // only in the other file
+	Extra   []string // only in this file
+	Changed []string // in both with different values
+}
+
+// Empty reports whether the two files have the same keys and values
+func (d EnvDiff) Empty() bool {
+	return len(d.Missing) == 0 && len(d.Extra) == 0 && len(d.Changed) == 0
+}
+
+// DiffEnv compares env with other key by key
+func DiffEnv(env, other map[string]string) EnvDiff {
+	var diff EnvDiff
+	for key, value := range env {
+		otherValue, ok := other[key]
+		switch {
+		case !ok:
+			diff.Extra = append(diff.Extra, key)
+		case UnquoteEnvValue(otherValue) != UnquoteEnvValue(value):
+			diff.Changed = append(diff.Changed, key)
+		}
+	}
+	for key := range other {
+		if _, ok := env[key]; !ok {
+			diff.Missing = append(diff.Missing, key)
+		}
+	}
+	sort.Strings(diff.Missing)
+	sort.Strings(diff.Extra)
+	sort.Strings(diff.Changed)
+	return diff
+}
+
+// WriteEnvValue sets key to value in an env file, replacing an existing entry
+// or appending a new one. The file is created if missing and keeps its
+// permissions otherwise.
+func WriteEnvValue(worktreePath, filename, key, value string) error {
+	filePath := filepath.Join(worktreePath, filename)
+
+	perms := os.FileMode(0644)
+	if info, err := os.Stat(filePath); err == nil {
+		perms = info.Mode().Perm()
+	}
+
+	var content []byte
+	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+		content = []byte(fmt.Sprintf("%s=%s\n", key, value))
+	} else {
+		content, err = os.ReadFile(filePath)
+		if err != nil {
+			return fmt.Errorf("reading file: %w", err)
+		}
+
+		var updated bool
+		lines := strings.Split(string(content), "\n")
+		for i, line := range lines {
+			if strings.HasPrefix(line, key+"=") || strings.HasPrefix(line, key+" ") {
+				lines[i] = fmt.Sprintf("%s=%s", key, value)
+				updated = true
+				break
+			}
+		}
+
+		if !updated {
+			if !strings.HasSuffix(string(content), "\n") {
+				content = append(content, '\n')
+			}
+			content = append(content, []byte(fmt.Sprintf("%s=%s\n", key, value))...)
+		} else {
+			content = []byte(strings.Join(lines, "\n"))
+			if !strings.HasSuffix(string(content), "\n") {
+				content = append(content, '\n')
+			}
+		}
+	}
+
+	tmpFile := filePath + ".tmp"
+	if err := os.WriteFile(tmpFile, content, perms); err != nil {
+		return fmt.Errorf("writing temp file: %w", err)
+	}
+
+	if err := os.Rename(tmpFile, filePath); err != nil {
+		os.Remove(tmpFile)
+		return fmt.Errorf("renaming temp file: %w", err)
+	}
+
+	return nil
+}
@@ -125,3 +125,43 @@ func TestEnvNotExists(t *testing.T) {
 	assert.True(t, EnvNotExists(env, "MISSING"))
 	assert.True(t, EnvNotExists(env, "existing"), "keys are case-sensitive")
 }
+
+func TestUnquoteEnvValue(t *testing.T) {
+	assert.Equal(t, "My App", UnquoteEnvValue(`"My App"`))
+	assert.Equal(t, "My App", UnquoteEnvValue(`'My App'`))
+	assert.Equal(t, `"mismatched'`, UnquoteEnvValue(`"mismatched'`))
+	assert.Equal(t, "plain", UnquoteEnvValue("plain"))
+}
+
+func TestQuoteEnvValue(t *testing.T) {
+	assert.Equal(t, "plain", QuoteEnvValue("plain"))
+	assert.Equal(t, "", QuoteEnvValue(""))
+	assert.Equal(t, `C:\path`, QuoteEnvValue(`C:\path`))
+	assert.Equal(t, `"My App"`, QuoteEnvValue("My App"))
+	assert.Equal(t, `"secret#1"`, QuoteEnvValue("secret#1"))
+	assert.Equal(t, `"line one\nline two"`, QuoteEnvValue("line one\nline two"))
+	assert.Equal(t, `"say \"hi\" C:\\dir"`, QuoteEnvValue(`say "hi" C:\dir`))
+	assert.Equal(t, `'My App'`, QuoteEnvValue(`'My App'`), "already quoted")
+}
+
+func TestDiffEnv(t *testing.T) {
+	env := map[string]string{"APP_NAME": `"App"`, "DB_DATABASE": "app_one", "LOCAL_ONLY": "1"}
+	other := map[string]string{"APP_NAME": "App", "DB_DATABASE": "app_two", "STRIPE_KEY": "", "REDIS_HOST": "redis"}
+
+	diff := DiffEnv(env, other)
+	assert.Equal(t, []string{"REDIS_HOST", "STRIPE_KEY"}, diff.Missing)
+	assert.Equal(t, []string{"LOCAL_ONLY"}, diff.Extra)
+	assert.Equal(t, []string{"DB_DATABASE"}, diff.Changed, "quoting alone is not a change")
+	assert.False(t, diff.Empty())
+
+	assert.True(t, DiffEnv(env, env).Empty())
+}
+
+func TestReadEnvKeys(t *testing.T) {
+	tmpDir := t.TempDir()
+	content := "# App\nAPP_NAME=Laravel\nAPP_ENV=local\n\nDB_HOST=127.0.0.1\nAPP_NAME=Again\nmalformed\n"
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, ".env.example"), []byte(content), 0644))
+
+	assert.Equal(t, []string{"APP_NAME", "APP_ENV", "DB_HOST"}, ReadEnvKeys(tmpDir, ".env.example"))
+	assert.Nil(t, ReadEnvKeys(tmpDir, ".env.missing"))
+}
@@ -1,13 +1,41 @@
 package utils
 
 import (
+	"fmt"
+	"path/filepath"
 	"strings"
 )
 
-// SanitisePath converts a branch name to a valid directory path
-// by replacing / with - to prevent nested directories
+// pathReplacer replaces separators, to prevent nested directories, and the
+// characters Windows does not allow in file names
+var pathReplacer = strings.NewReplacer(
+	"/", "-", "\\", "-", ":", "-", "*", "-", "?", "-",
+	"\"", "-", "<", "-", ">", "-", "|", "-",
+)
+
+// SanitisePath converts a branch name to a valid directory path on every
+// platform by replacing / and characters Windows rejects with -. Windows also
+// drops trailing dots and spaces, so they are trimmed.
 func SanitisePath(name string) string {
-	return strings.ReplaceAll(name, "/", "-")
+	sanitised := pathReplacer.Replace(name)
+	if trimmed := strings.TrimRight(sanitised, ". "); trimmed != "" {
+		return trimmed
+	}
+	return sanitised
+}
+
+// ResolveSubdir joins dir to base, returning an error when dir is absolute or
+// leads outside base. The check is on the path alone; symlinks are not
+// followed.
+func ResolveSubdir(base, dir string) (string, error) {
+	if filepath.IsAbs(dir) || filepath.VolumeName(dir) != "" {
+		return "", fmt.Errorf("%s must be relative to the worktree", dir)
+	}
+	clean := filepath.Clean(dir)
+	if clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is outside the worktree", dir)
+	}
+	return filepath.Join(base, clean), nil
 }
 
 // ExtractRepoName extracts the repository name from a git URL
@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -32,6 +33,16 @@ func TestSanitisePath(t *testing.T) {
 			input:    "/",
 			expected: "-",
 		},
+		{
+			name:     "characters Windows rejects",
+			input:    `fix\issue:12|"quoted"`,
+			expected: "fix-issue-12--quoted-",
+		},
+		{
+			name:     "trailing dots",
+			input:    "release/1.0.",
+			expected: "release-1.0",
+		},
 	}
 
 	for _, tt := range tests {
@@ -42,6 +53,27 @@ func TestSanitisePath(t *testing.T) {
 	}
 }
 
+func TestResolveSubdir(t *testing.T) {
+	base := filepath.Join("repo", "main")
+
+	for dir, want := range map[string]string{
+		"frontend":            filepath.Join(base, "frontend"),
+		"packages/web/":       filepath.Join(base, "packages", "web"),
+		"frontend/../backend": filepath.Join(base, "backend"),
+		".":                   base,
+		"..foo":               filepath.Join(base, "..foo"),
+	} {
+		got, err := ResolveSubdir(base, dir)
+		assert.NoError(t, err, dir)
+		assert.Equal(t, want, got, dir)
+	}
+
+	for _, dir := range []string{"..", "../other", "frontend/../../other", string(filepath.Separator) + "tmp"} {
+		_, err := ResolveSubdir(base, dir)
+		assert.Error(t, err, dir)
+	}
+}
+
 func TestExtractRepoName(t *testing.T) {
 	tests := []struct {
 		name     string
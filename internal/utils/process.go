@@ -0,0 +1,110 @@
+package utils
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ProcessesIn returns the names of running processes whose working directory
+// is dir or inside it, such as dev servers started in a worktree. Processes
+// that cannot be inspected are skipped.
+func ProcessesIn(dir string) []string {
+	dir, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return nil
+	}
+
+	var cwds map[int]processInfo
+	switch runtime.GOOS {
+	case "linux":
+		cwds = procCwds()
+	case "darwin", "freebsd":
+		cwds = lsofCwds()
+	default:
+		return nil
+	}
+
+	self := os.Getpid()
+	seen := make(map[string]bool)
+	var names []string
+	for pid, info := range cwds {
+		if pid == self || info.name == "" || seen[info.name] {
+			continue
+		}
+		if info.cwd == dir || strings.HasPrefix(info.cwd, dir+string(filepath.Separator)) {
+			seen[info.name] = true
+			names = append(names, info.name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+type processInfo struct {
+	name string
+	cwd  string
+}
+
+// procCwds reads each process's working directory from /proc
+func procCwds() map[int]processInfo {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil
+	}
+
+	cwds := make(map[int]processInfo)
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		cwd, err := os.Readlink(filepath.Join("/proc", entry.Name(), "cwd"))
+		if err != nil {
+			continue
+		}
+		comm, err := os.ReadFile(filepath.Join("/proc", entry.Name(), "comm"))
+		if err != nil {
+			continue
+		}
+		cwds[pid] = processInfo{name: strings.TrimSpace(string(comm)), cwd: cwd}
+	}
+	return cwds
+}
+
+// lsofCwds reads each process's working directory from lsof's field output
+func lsofCwds() map[int]processInfo {
+	output, err := exec.Command("lsof", "-d", "cwd", "-F", "pcn").Output()
+	if err != nil && len(output) == 0 {
+		return nil
+	}
+
+	cwds := make(map[int]processInfo)
+	pid := 0
+	var info processInfo
+	for _, line := range strings.Split(string(output), "\n") {
+		if line == "" {
+			continue
+		}
+		switch line[0] {
+		case 'p':
+			info = processInfo{}
+			// pid 0 skips the process's fields when its pid does not parse
+			if pid, err = strconv.Atoi(line[1:]); err != nil {
+				pid = 0
+			}
+		case 'c':
+			info.name = line[1:]
+		case 'n':
+			info.cwd = line[1:]
+			if pid != 0 {
+				cwds[pid] = info
+			}
+		}
+	}
+	return cwds
+}
@@ -0,0 +1,69 @@
+package utils
+
+import (
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// WindowsShellCmd is the windows_shell setting that runs commands with
+// cmd.exe rather than sh
+const WindowsShellCmd = "cmd"
+
+// ShellCommand returns a command that runs command through sh -c, or, on
+// Windows with windowsShell set to cmd, through cmd /C
+func ShellCommand(windowsShell, command string) *exec.Cmd {
+	name, args := ShellArgs(runtime.GOOS, windowsShell, command)
+	return exec.Command(name, args...)
+}
+
+// ShellArgs returns the shell and arguments that run command on goos
+func ShellArgs(goos, windowsShell, command string) (string, []string) {
+	if goos == "windows" && windowsShell == WindowsShellCmd {
+		shell := os.Getenv("COMSPEC")
+		if shell == "" {
+			shell = "cmd.exe"
+		}
+		return shell, []string{"/C", command}
+	}
+	return "sh", []string{"-c", command}
+}
+
+// SplitCommand splits a command line such as a step's binary into words.
+// Words are separated by whitespace, and single or double quotes group words
+// containing spaces, such as "C:\Program Files\PHP\php.exe". Backslashes are
+// kept as written so Windows paths survive.
+func SplitCommand(command string) []string {
+	var words []string
+	var word strings.Builder
+	var quote rune
+	inWord := false
+
+	for _, r := range command {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+			inWord = true
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words
+}
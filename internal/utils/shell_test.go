@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShellArgs(t *testing.T) {
+	name, args := ShellArgs("linux", "", "echo hi")
+	assert.Equal(t, "sh", name)
+	assert.Equal(t, []string{"-c", "echo hi"}, args)
+
+	name, args = ShellArgs("linux", WindowsShellCmd, "echo hi")
+	assert.Equal(t, "sh", name, "windows_shell only applies on Windows")
+	assert.Equal(t, []string{"-c", "echo hi"}, args)
+
+	name, args = ShellArgs("windows", "", "echo hi")
+	assert.Equal(t, "sh", name, "Windows keeps using sh unless cmd is asked for")
+	assert.Equal(t, []string{"-c", "echo hi"}, args)
+
+	t.Setenv("COMSPEC", "")
+	name, args = ShellArgs("windows", WindowsShellCmd, "echo hi")
+	assert.Equal(t, "cmd.exe", name)
+	assert.Equal(t, []string{"/C", "echo hi"}, args)
+}
+
+func TestSplitCommand(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"php artisan", []string{"php", "artisan"}},
+		{"  npm   run  build ", []string{"npm", "run", "build"}},
+		{`"C:\Program Files\PHP\php.exe" artisan`, []string{`C:\Program Files\PHP\php.exe`, "artisan"}},
+		{`C:\tools\composer.bat install`, []string{`C:\tools\composer.bat`, "install"}},
+		{`bin/app --name 'two words'`, []string{"bin/app", "--name", "two words"}},
+		{`run ""`, []string{"run", ""}},
+		{"", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			assert.Equal(t, tt.expected, SplitCommand(tt.input))
+		})
+	}
+}
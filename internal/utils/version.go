@@ -0,0 +1,94 @@
+package utils
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// versionPattern finds the first dotted version number in free-form output
+var versionPattern = regexp.MustCompile(`\d+\.\d+(\.\d+)?`)
+
+// versionArgs returns the arguments that make tool print its version
+func versionArgs(tool string) []string {
+	switch tool {
+	case "gh", "herd":
+		return []string{"version"}
+	case "php":
+		return []string{"-v"}
+	default:
+		return []string{"--version"}
+	}
+}
+
+// ToolVersion runs the tool at path and returns the version it reports
+func ToolVersion(tool, path string) (string, error) {
+	output, err := exec.Command(path, versionArgs(tool)...).Output()
+	if err != nil {
+		return "", err
+	}
+
+	version := ExtractVersion(string(output), tool)
+	if version == "" {
+		return "", fmt.Errorf("no version found in %s output", tool)
+	}
+	return version, nil
+}
+
+// ExtractVersion reads the version number from a tool's version output.
+// Known tools are parsed by their output format, others by the first
+// dotted number found.
+func ExtractVersion(output, tool string) string {
+	lines := strings.Split(strings.TrimRight(output, "\n"), "\n")
+	switch tool {
+	case "gh":
+		for _, line := range lines {
+			if strings.Contains(line, "gh version") {
+				parts := strings.Split(line, " ")
+				if len(parts) >= 3 {
+					return strings.TrimPrefix(parts[2], "v")
+				}
+			}
+		}
+	case "php":
+		for _, line := range lines {
+			if strings.Contains(line, "PHP") {
+				parts := strings.Split(line, " ")
+				if len(parts) >= 2 {
+					return strings.TrimPrefix(parts[1], "v")
+				}
+			}
+		}
+	case "composer":
+		for _, line := range lines {
+			if strings.Contains(line, "Composer version") {
+				parts := strings.Split(line, " ")
+				if len(parts) >= 3 {
+					return strings.TrimPrefix(parts[2], "v")
+				}
+			}
+		}
+	case "npm":
+		for _, line := range lines {
+			if strings.Contains(line, ".") {
+				return strings.TrimSpace(line)
+			}
+		}
+	case "herd":
+		for _, line := range lines {
+			if strings.Contains(line, "version") || strings.Contains(line, "Herd") {
+				parts := strings.Fields(line)
+				for _, part := range parts {
+					if strings.HasPrefix(part, "v") && len(part) > 1 {
+						return strings.TrimPrefix(part, "v")
+					}
+				}
+			}
+		}
+	default:
+		return versionPattern.FindString(output)
+	}
+
+	return ""
+}
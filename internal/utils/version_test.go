@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractVersion(t *testing.T) {
+	tests := []struct {
+		name     string
+		tool     string
+		output   string
+		expected string
+	}{
+		{
+			name:     "gh",
+			tool:     "gh",
+			output:   "gh version 2.62.0 (2024-11-14)\nhttps://github.com/cli/cli/releases/tag/v2.62.0\n",
+			expected: "2.62.0",
+		},
+		{
+			name:     "php",
+			tool:     "php",
+			output:   "PHP 8.3.4 (cli) (built: Mar 12 2024 23:42:26) (NTS)\nCopyright (c) The PHP Group\n",
+			expected: "8.3.4",
+		},
+		{
+			name:     "composer",
+			tool:     "composer",
+			output:   "Composer version 2.7.2 2024-03-11 17:12:18\n",
+			expected: "2.7.2",
+		},
+		{
+			name:     "npm",
+			tool:     "npm",
+			output:   "10.5.0\n",
+			expected: "10.5.0",
+		},
+		{
+			name:     "unknown tool uses first version number",
+			tool:     "node",
+			output:   "v20.11.1\n",
+			expected: "20.11.1",
+		},
+		{
+			name:     "no version",
+			tool:     "php",
+			output:   "command failed\n",
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ExtractVersion(tt.output, tt.tool))
+		})
+	}
+}